@@ -0,0 +1,156 @@
+package thema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+)
+
+// anonymizeAttr is the name of the CUE attribute that declares how a schema
+// field is to be anonymized by [Anonymize]. Its first argument names the
+// strategy, e.g.:
+//
+//	email: string @anonymize(hash)
+const anonymizeAttr = "anonymize"
+
+// An AnonymizeStrategy computes a replacement for the value of a single field
+// during [Anonymize]. It is passed the schema's definition of the field, and
+// the field's current value, decoded as by [cue.Value.Decode] into an any.
+type AnonymizeStrategy func(field cue.Value, value any) (any, error)
+
+// An AnonymizeOption customizes the behavior of [Anonymize].
+type AnonymizeOption anonymizeOption
+
+// Internal representation of AnonymizeOption.
+type anonymizeOption func(c *anonymizeConfig)
+
+type anonymizeConfig struct {
+	strategies map[string]AnonymizeStrategy
+}
+
+// AnonymizeWith registers an [AnonymizeStrategy] under the provided name,
+// making it available to @anonymize(<name>) attributes. It replaces any
+// built-in strategy of the same name.
+func AnonymizeWith(name string, s AnonymizeStrategy) AnonymizeOption {
+	return func(c *anonymizeConfig) {
+		c.strategies[name] = s
+	}
+}
+
+// Anonymize produces a scrubbed copy of the provided [Instance], in which the
+// values of fields annotated with the @anonymize attribute in the instance's
+// schema are replaced according to the strategy named in the attribute.
+// The following strategies are built in:
+//
+//   - hash: replaces a string with the hex-encoded SHA-256 hash of its value.
+//     Hashing is deterministic, so equal inputs remain equal after anonymizing.
+//   - null: replaces the value with null.
+//   - fake: replaces the value with the field's default, if it has one, or
+//     else with a placeholder of the field's kind: "anonymized" for strings,
+//     zero for numbers, and false for bools.
+//
+// Further strategies may be provided via [AnonymizeWith].
+//
+// Unlike [Redact], the result is validated against the instance's schema,
+// so that it is suitable for use as test data. An error is returned if any
+// field names an unknown strategy, if a strategy fails, or if the anonymized
+// data is not valid with respect to the schema.
+func Anonymize(inst *Instance, opts ...AnonymizeOption) (*Instance, error) {
+	inst.check()
+	cfg := &anonymizeConfig{
+		strategies: map[string]AnonymizeStrategy{
+			"hash": anonymizeHash,
+			"null": anonymizeNull,
+			"fake": anonymizeFake,
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	type target struct {
+		path  []string
+		field cue.Value
+		strat AnonymizeStrategy
+	}
+	var targets []target
+	var err error
+	sch := inst.Schema()
+	walkFields(sch.Underlying().LookupPath(pathSchDef), nil, func(path []string, field cue.Value) {
+		for _, a := range valueAttrs(field) {
+			if a.Name != anonymizeAttr || err != nil {
+				continue
+			}
+			if len(a.Args) == 0 {
+				err = errors.Newf("@%s attribute on %q must name a strategy", anonymizeAttr, strings.Join(path, "."))
+				return
+			}
+			strat, has := cfg.strategies[a.Args[0].Key]
+			if !has {
+				err = errors.Newf("unknown anonymization strategy %q on %q", a.Args[0].Key, strings.Join(path, "."))
+				return
+			}
+			targets = append(targets, target{path: path, field: field, strat: strat})
+			return
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if err := inst.Underlying().Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "unable to decode instance for anonymization")
+	}
+	for _, t := range targets {
+		data, err = mapPath(data, t.path, func(v any) (any, error) {
+			return t.strat(t.field, v)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to anonymize %q", strings.Join(t.path, "."))
+		}
+	}
+
+	ninst, err := sch.Validate(inst.Underlying().Context().Encode(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "anonymized data is not valid")
+	}
+	ninst.name = inst.name
+	return ninst, nil
+}
+
+func anonymizeHash(_ cue.Value, v any) (any, error) {
+	s, is := v.(string)
+	if !is {
+		return nil, fmt.Errorf("hash strategy requires a string value, got %T", v)
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func anonymizeNull(_ cue.Value, _ any) (any, error) {
+	return nil, nil
+}
+
+func anonymizeFake(field cue.Value, v any) (any, error) {
+	if dv, has := field.Default(); has && dv.IsConcrete() {
+		var d any
+		if err := dv.Decode(&d); err == nil {
+			return d, nil
+		}
+	}
+	switch v.(type) {
+	case string:
+		return "anonymized", nil
+	case json.Number, float64, int, int64:
+		return 0, nil
+	case bool:
+		return false, nil
+	}
+	return nil, fmt.Errorf("fake strategy cannot produce a value of type %T", v)
+}
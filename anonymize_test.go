@@ -0,0 +1,62 @@
+package thema
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymize(t *testing.T) {
+	lin := testLin(`name: "anon"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id:      string
+		email:   string @anonymize(hash)
+		nick?:   null | string @anonymize(null)
+		age:     int @anonymize(fake)
+		country: string | *"nowhere" @anonymize(fake)
+		tags: [...{v: string @anonymize(upper)}]
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+	inst, err := lin.First().Validate(ctx.CompileString(`{
+	id: "u1"
+	email: "a@example.com"
+	nick: "al"
+	age: 42
+	country: "se"
+	tags: [{v: "x"}, {v: "y"}]
+}`))
+	require.NoError(t, err)
+
+	_, err = Anonymize(inst)
+	assert.ErrorContains(t, err, `unknown anonymization strategy "upper"`)
+
+	upper := AnonymizeWith("upper", func(_ cue.Value, v any) (any, error) {
+		return strings.ToUpper(v.(string)), nil
+	})
+	ainst, err := Anonymize(inst, upper)
+	require.NoError(t, err)
+	b, err := ainst.Underlying().MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+	"id": "u1",
+	"email": "08168cd80dfd534ab0f10af10f1303fe00af2d43ab5c1432360d137f8197e17a",
+	"nick": null,
+	"age": 0,
+	"country": "nowhere",
+	"tags": [{"v": "X"}, {"v": "Y"}]
+}`, string(b))
+
+	// A value the schema rejects causes an error
+	rejectAge := AnonymizeWith("fake", func(cue.Value, any) (any, error) {
+		return "nope", nil
+	})
+	_, err = Anonymize(inst, upper, rejectAge)
+	assert.ErrorContains(t, err, "anonymized data is not valid")
+}
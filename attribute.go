@@ -0,0 +1,229 @@
+package thema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// An Attribute is a CUE attribute declared on a field in a schema, such as
+// @grafana(kind=panel, hidden).
+type Attribute struct {
+	// Name is the name of the attribute, e.g. "grafana".
+	Name string
+
+	// Contents is the raw, unparsed contents of the attribute, e.g.
+	// "kind=panel, hidden".
+	Contents string
+
+	// Args are the comma-separated arguments of the attribute, in order. For
+	// key-value arguments, Key and Value are both set. For other arguments, only
+	// Key is set.
+	Args []AttributeArg
+}
+
+// An AttributeArg is a single argument within an [Attribute].
+type AttributeArg struct {
+	Key, Value string
+}
+
+// Lookup returns the value of the first key-value argument with the provided
+// key, and whether it was found.
+func (a Attribute) Lookup(key string) (string, bool) {
+	for _, arg := range a.Args {
+		if arg.Key == key && arg.Value != "" {
+			return arg.Value, true
+		}
+	}
+	return "", false
+}
+
+// Flag reports whether the attribute contains an argument that is exactly the
+// provided key, with no value.
+func (a Attribute) Flag(key string) bool {
+	for _, arg := range a.Args {
+		if arg.Key == key && arg.Value == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func fromCUEAttr(ca cue.Attribute) Attribute {
+	a := Attribute{
+		Name:     ca.Name(),
+		Contents: ca.Contents(),
+	}
+	for i := 0; i < ca.NumArgs(); i++ {
+		k, v := ca.Arg(i)
+		a.Args = append(a.Args, AttributeArg{Key: k, Value: v})
+	}
+	return a
+}
+
+// An AttributeRef is an [Attribute] located at a particular field within a
+// particular schema in a lineage.
+type AttributeRef struct {
+	// Version is the version of the schema containing the field.
+	Version SyntacticVersion
+
+	// Path is the path to the field, in the form accepted by
+	// [Schema.Attributes].
+	Path string
+
+	Attribute Attribute
+}
+
+// Attributes returns the attributes declared on the field at the provided path
+// in the schema, in declaration order.
+//
+// The path is a dot-separated list of field names relative to the root of the
+// schema, e.g. "spec.title". The elements of lists are addressed with "*",
+// e.g. "spec.panels.*.title". An empty path addresses the schema itself.
+//
+// An error wrapping [terrors.ErrValueNotExist] is returned if no field exists
+// at the provided path.
+func (sch *schemaDef) Attributes(path string) ([]Attribute, error) {
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, ".")
+	}
+	v, _, has := lookupField(sch.def, parts)
+	if !has {
+		return nil, errors.Mark(errors.Newf("no field %q in schema %s", path, sch.v), terrors.ErrValueNotExist)
+	}
+	return valueAttrs(v), nil
+}
+
+// lookupField returns the value of the field at the provided path elements
+// within def, whether that field is optional, and whether it exists. Path
+// elements are as described for [Schema.Attributes].
+func lookupField(def cue.Value, parts []string) (cue.Value, bool, bool) {
+	v, optional := def, false
+	for _, part := range parts {
+		optional = false
+		if _, err := strconv.Atoi(part); err == nil || part == "*" {
+			v = v.LookupPath(cue.MakePath(cue.AnyIndex))
+		} else if fv := v.LookupPath(cue.MakePath(cue.Str(part))); fv.Exists() {
+			v = fv
+		} else if fv := v.LookupPath(cue.MakePath(cue.Str(part).Optional())); fv.Exists() {
+			v, optional = fv, true
+		} else if fv, has := hiddenField(v, part); has {
+			v = fv
+		} else {
+			// Fields of maps are constrained by a pattern
+			v, optional = v.LookupPath(cue.MakePath(cue.AnyString)), true
+		}
+		if !v.Exists() {
+			return v, false, false
+		}
+	}
+	return v, optional, true
+}
+
+// hiddenField returns the hidden field with the provided name in v. Hidden
+// labels are qualified by the package in which they are declared, so cannot
+// be looked up by name alone.
+func hiddenField(v cue.Value, name string) (cue.Value, bool) {
+	if !strings.HasPrefix(name, "_") {
+		return cue.Value{}, false
+	}
+	iter, err := v.Fields(cue.Hidden(true), cue.Optional(true))
+	if err != nil {
+		return cue.Value{}, false
+	}
+	for iter.Next() {
+		if iter.Selector().String() == name {
+			return iter.Value(), true
+		}
+	}
+	return cue.Value{}, false
+}
+
+func valueAttrs(v cue.Value) []Attribute {
+	var attrs []Attribute
+	for _, ca := range v.Attributes(cue.ValueAttr) {
+		attrs = append(attrs, fromCUEAttr(ca))
+	}
+	return attrs
+}
+
+// IndexAttributes walks every field in every schema in the lineage, and
+// returns all attributes declared on those fields, ordered by schema version
+// and then by path. If names are provided, only attributes with those names
+// are returned.
+//
+// This is intended for generators and other tooling that drive behavior from
+// field annotations, such as @grafana(...), without re-parsing CUE themselves.
+func IndexAttributes(lin Lineage, names ...string) []AttributeRef {
+	isValidLineage(lin)
+
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+	}
+
+	var refs []AttributeRef
+	for sch := lin.First(); sch != nil; sch = sch.Successor() {
+		var schrefs []AttributeRef
+		walkAttrs(sch.Underlying().LookupPath(pathSchDef), nil, func(path []string, attrs []Attribute) {
+			for _, a := range attrs {
+				if len(want) == 0 || want[a.Name] {
+					schrefs = append(schrefs, AttributeRef{
+						Version:   sch.Version(),
+						Path:      strings.Join(path, "."),
+						Attribute: a,
+					})
+				}
+			}
+		})
+		sort.SliceStable(schrefs, func(i, j int) bool {
+			return schrefs[i].Path < schrefs[j].Path
+		})
+		refs = append(refs, schrefs...)
+	}
+	return refs
+}
+
+// maxAttrDepth bounds the depth to which walkAttrs descends, guarding against
+// infinite expansion of recursive schemas.
+const maxAttrDepth = 32
+
+// walkAttrs recursively visits every regular field in v, calling fn with each
+// field's path and attributes. Fields without attributes are skipped.
+func walkAttrs(v cue.Value, path []string, fn func([]string, []Attribute)) {
+	walkFields(v, path, func(fpath []string, fv cue.Value) {
+		if attrs := valueAttrs(fv); len(attrs) > 0 {
+			fn(fpath, attrs)
+		}
+	})
+}
+
+// walkFields recursively visits every regular field in v, calling fn with each
+// field's path and value. List elements are visited with a path element of "*".
+func walkFields(v cue.Value, path []string, fn func([]string, cue.Value)) {
+	if len(path) > maxAttrDepth {
+		return
+	}
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		iter, err := v.Fields(cue.Optional(true))
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			fpath := append(path[:len(path):len(path)], iter.Selector().Unquoted())
+			fn(fpath, iter.Value())
+			walkFields(iter.Value(), fpath, fn)
+		}
+	case cue.ListKind:
+		if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+			walkFields(elem, append(path[:len(path):len(path)], "*"), fn)
+		}
+	}
+}
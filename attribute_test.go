@@ -0,0 +1,69 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestAttributes(t *testing.T) {
+	lin := testLin(`name: "attrs"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string @grafana(kind=title, searchable)
+		panels: [...{
+			id:      int @grafana(key)
+			legend?: string @other(x)
+		}]
+	}
+}, {
+	version: [0, 1]
+	schema: {
+		title: string @grafana(kind=title, searchable)
+		panels: [...{
+			id:      int @grafana(key)
+			legend?: string @other(x)
+		}]
+		desc?: string @grafana(kind=desc)
+	}
+}]
+`)
+
+	attrs, err := lin.First().Attributes("title")
+	require.NoError(t, err)
+	require.Len(t, attrs, 1)
+	assert.Equal(t, "grafana", attrs[0].Name)
+	assert.Equal(t, "kind=title, searchable", attrs[0].Contents)
+	kind, has := attrs[0].Lookup("kind")
+	assert.True(t, has)
+	assert.Equal(t, "title", kind)
+	assert.True(t, attrs[0].Flag("searchable"))
+	assert.False(t, attrs[0].Flag("kind"))
+
+	attrs, err = lin.First().Attributes("panels.*.legend")
+	require.NoError(t, err)
+	require.Len(t, attrs, 1)
+	assert.Equal(t, "other", attrs[0].Name)
+
+	_, err = lin.First().Attributes("nope")
+	assert.True(t, errors.Is(err, terrors.ErrValueNotExist))
+
+	refs := IndexAttributes(lin, "grafana")
+	var got []string
+	for _, ref := range refs {
+		got = append(got, ref.Version.String()+" "+ref.Path)
+	}
+	assert.Equal(t, []string{
+		"0.0 panels.*.id",
+		"0.0 title",
+		"0.1 desc",
+		"0.1 panels.*.id",
+		"0.1 title",
+	}, got)
+	assert.Len(t, IndexAttributes(lin), 7)
+}
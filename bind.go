@@ -45,6 +45,8 @@ type maybeLineage struct {
 
 	lensmap map[lensID]ImperativeLens
 
+	shortcuts map[lensID]ImperativeLens
+
 	// The raw input value is the root of a package instance
 	// rawIsPackage bool
 }
@@ -62,6 +64,25 @@ func (id lensID) String() string {
 	return fmt.Sprintf("%s -> %s", id.From, id.To)
 }
 
+// A SchemaError reports a problem with a single schema in a lineage, found
+// by [BindLineage].
+type SchemaError struct {
+	// Version is the version of the schema with the problem.
+	Version SyntacticVersion
+
+	// Err is the problem.
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap implements standard Go error unwrapping, relied on by errors.Is.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
 func (ml *maybeLineage) checkGoValidity(cfg *bindConfig) error {
 	schiter, err := ml.uni.LookupPath(cue.MakePath(cue.Str("schemas"))).List()
 	if err != nil {
@@ -89,20 +110,36 @@ func (ml *maybeLineage) checkGoValidity(cfg *bindConfig) error {
 		}
 
 		if err := ml.checkSchemasOrder(previous, sch); err != nil {
-			return err
+			return &SchemaError{Version: sch.v, Err: err}
 		}
 
 		sch.ref = schiter.Value()
 		sch.def = sch.ref.LookupPath(pathSchDef)
+
+		mat, err := sch.ref.LookupPath(pathMaturity).String()
+		if err != nil {
+			return &SchemaError{Version: sch.v, Err: errors.Mark(mkerror(sch.ref.LookupPath(pathMaturity), "schema %s has invalid maturity: %s", sch.v, err), terrors.ErrInvalidLineage)}
+		}
+		sch.maturity = Maturity(mat)
+		if previous != nil && previous.v[0] == sch.v[0] && sch.maturity.less(previous.maturity) {
+			return &SchemaError{Version: sch.v, Err: errors.Mark(mkerror(sch.ref.LookupPath(pathMaturity), "schema %s is %s, but may not be less mature than its predecessor %s, which is %s", sch.v, sch.maturity, previous.v, previous.maturity), terrors.ErrInvalidLineage)}
+		}
+		if notes := sch.ref.LookupPath(pathChanges); notes.Exists() {
+			if err := notes.Decode(&sch.notes); err != nil {
+				return &SchemaError{Version: sch.v, Err: errors.Mark(mkerror(notes, "schema %s has invalid change notes: %s", sch.v, err), terrors.ErrInvalidLineage)}
+			}
+		}
 		if previous != nil && !cfg.skipbuggychecks {
 			compaterr := compat.ThemaCompatible(previous.def, sch.def)
 			if sch.v[1] == 0 && compaterr == nil {
 				// Major version change, should be backwards incompatible
-				return errors.Mark(mkerror(sch.ref.LookupPath(pathSch), "schema %s must be backwards incompatible with schema %s: introduce a breaking change, or redeclare as version %s", sch.v, previous.v, synv(previous.v[0], previous.v[1]+1)), terrors.ErrInvalidLineage)
+				err := mkerror(sch.ref.LookupPath(pathSch), "schema %s must be backwards incompatible with schema %s: introduce a breaking change, or redeclare as version %s", sch.v, previous.v, synv(previous.v[0], previous.v[1]+1))
+				return &SchemaError{Version: sch.v, Err: errors.Mark(errors.Mark(err, terrors.ErrMajorNotBreaking), terrors.ErrInvalidLineage)}
 			}
 			if sch.v[1] != 0 && compaterr != nil {
 				// Minor version change, should be backwards compatible
-				return errors.Mark(mkerror(sch.ref.LookupPath(pathSch), "schema %s is not backwards compatible with schema %s:\n%s", sch.v, previous.v, cerrors.Details(compaterr, nil)), terrors.ErrInvalidLineage)
+				err := mkerror(sch.ref.LookupPath(pathSch), "schema %s is not backwards compatible with schema %s:\n%s", sch.v, previous.v, cerrors.Details(compaterr, nil))
+				return &SchemaError{Version: sch.v, Err: errors.Mark(errors.Mark(err, terrors.ErrMinorNotCompatible), terrors.ErrInvalidLineage)}
 			}
 		}
 
@@ -0,0 +1,90 @@
+package thema
+
+import (
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A Budget bounds the size of the data that [Schema.Validate] and
+// [Schema.ValidateLenient] will evaluate. A zero value for any limit means
+// that it is not enforced.
+type Budget struct {
+	// MaxFields is the maximum total number of struct fields in the data,
+	// counted across all structs at all depths.
+	MaxFields int
+
+	// MaxListLen is the maximum number of elements in any single list.
+	MaxListLen int
+
+	// MaxDepth is the maximum depth to which structs and lists may be nested.
+	// The top-level value has a depth of one.
+	MaxDepth int
+}
+
+// ValidationBudget bounds the size of the data that will be validated against
+// the schemas in the bound [Lineage]. Data exceeding any of the limits in the
+// Budget is rejected before any CUE evaluation against the schema occurs, with
+// an error wrapping [terrors.ErrBudgetExceeded].
+//
+// This protects programs that validate untrusted payloads, such as public
+// endpoints, against enormous or deeply nested documents. As CUE evaluation
+// cannot be interrupted once begun, bounding its input is the means by which
+// the time spent in each evaluation of untrusted data is limited. It is
+// complementary to [EvalTimeout].
+func ValidationBudget(b Budget) BindOption {
+	return func(c *bindConfig) {
+		c.budget = b
+	}
+}
+
+// check returns an error if data exceeds the budget.
+func (b Budget) check(data cue.Value) error {
+	if b == (Budget{}) {
+		return nil
+	}
+	var fields int
+	return b.walk(data, 1, &fields)
+}
+
+func (b Budget) walk(v cue.Value, depth int, fields *int) error {
+	kind := v.IncompleteKind()
+	if kind != cue.StructKind && kind != cue.ListKind {
+		return nil
+	}
+	if b.MaxDepth > 0 && depth > b.MaxDepth {
+		return errors.Mark(errors.Newf("data at %s is nested deeper than maximum depth of %d", v.Path(), b.MaxDepth), terrors.ErrBudgetExceeded)
+	}
+
+	if kind == cue.StructKind {
+		iter, err := v.Fields()
+		if err != nil {
+			return nil
+		}
+		for iter.Next() {
+			*fields++
+			if b.MaxFields > 0 && *fields > b.MaxFields {
+				return errors.Mark(errors.Newf("data contains more than the maximum of %d fields", b.MaxFields), terrors.ErrBudgetExceeded)
+			}
+			if err := b.walk(iter.Value(), depth+1, fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	iter, err := v.List()
+	if err != nil {
+		return nil
+	}
+	for n := 1; iter.Next(); n++ {
+		if b.MaxListLen > 0 && n > b.MaxListLen {
+			return errors.Mark(errors.Newf("list at %s has more than the maximum of %d elements", v.Path(), b.MaxListLen), terrors.ErrBudgetExceeded)
+		}
+		if err := b.walk(iter.Value(), depth+1, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestValidationBudget(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	ctx := rt.Context()
+	lin, err := BindLineage(ctx.CompileString(`name: "budget"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a?: _
+		b?: _
+		c?: _
+		l?: [...]
+	}
+}]
+`), rt, ValidationBudget(Budget{MaxFields: 5, MaxListLen: 3, MaxDepth: 3}))
+	require.NoError(t, err)
+
+	table := map[string]struct {
+		data string
+		ok   bool
+	}{
+		"within":     {data: `{a: 1, b: {x: 1}, l: [1, 2, 3]}`, ok: true},
+		"fields":     {data: `{a: {x: 1, y: 2}, b: {z: 3, w: 4}}`},
+		"list":       {data: `{l: [1, 2, 3, 4]}`},
+		"depth":      {data: `{a: {x: {y: {z: 1}}}}`},
+		"list depth": {data: `{l: [[[1]]]}`},
+	}
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := lin.First().Validate(ctx.CompileString(tt.data))
+			if tt.ok {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, terrors.ErrBudgetExceeded), "expected ErrBudgetExceeded, got %s", err)
+
+			_, _, err = lin.First().ValidateLenient(ctx.CompileString(tt.data))
+			assert.True(t, errors.Is(err, terrors.ErrBudgetExceeded), "expected ErrBudgetExceeded from ValidateLenient, got %s", err)
+		})
+	}
+}
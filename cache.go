@@ -0,0 +1,146 @@
+package thema
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"cuelang.org/go/cue"
+)
+
+// ValidationCache enables an LRU cache of results from [Schema.Validate] on the
+// bound [Lineage], holding at most size entries. Entries are keyed by schema
+// version and a hash of the content of the validated data, such that repeated
+// validation of identical payloads against the same schema returns without
+// re-evaluating CUE.
+//
+// Only the outcome of validation is cached. Each successful call still returns
+// a new [Instance] wrapping the data passed to that call, such that no Instance
+// or cue.Value is shared between callers.
+//
+// Caching is most effective where identical payloads are validated frequently,
+// such as default objects or health checks. Use [ValidationCacheStats] to
+// observe cache effectiveness.
+//
+// A size less than one disables caching, which is the default.
+func ValidationCache(size int) BindOption {
+	return func(c *bindConfig) {
+		c.valcachesize = size
+	}
+}
+
+// CacheStats reports the effectiveness of a lineage's validation cache.
+type CacheStats struct {
+	// Hits is the number of validations answered from the cache.
+	Hits uint64
+	// Misses is the number of validations that required evaluation.
+	Misses uint64
+	// Len is the number of entries currently held in the cache.
+	Len int
+	// Size is the maximum number of entries the cache may hold.
+	Size int
+}
+
+// HitRate returns the fraction of cacheable validations that were answered from
+// the cache, or zero if no validations have occurred.
+func (cs CacheStats) HitRate() float64 {
+	if cs.Hits+cs.Misses == 0 {
+		return 0
+	}
+	return float64(cs.Hits) / float64(cs.Hits+cs.Misses)
+}
+
+// ValidationCacheStats returns statistics about the validation cache of the
+// provided lineage. The boolean return is false if the lineage was not bound
+// with [ValidationCache].
+func ValidationCacheStats(lin Lineage) (CacheStats, bool) {
+	isValidLineage(lin)
+
+	vc := lin.(*baseLineage).vcache
+	if vc == nil {
+		return CacheStats{}, false
+	}
+	return vc.stats(), true
+}
+
+type vcacheKey struct {
+	v    SyntacticVersion
+	hash [sha256.Size]byte
+}
+
+type vcacheEntry struct {
+	key vcacheKey
+	// err is the validation failure, or nil if the data was valid
+	err error
+}
+
+// validationCache is a simple mutex-guarded LRU cache of validation results.
+type validationCache struct {
+	mut    sync.Mutex
+	size   int
+	ll     *list.List
+	items  map[vcacheKey]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+func newValidationCache(size int) *validationCache {
+	return &validationCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[vcacheKey]*list.Element, size),
+	}
+}
+
+// key computes the cache key for the provided data. The boolean return is
+// false if the data cannot be hashed, as is the case for non-concrete values.
+func (vc *validationCache) key(v SyntacticVersion, data cue.Value) (vcacheKey, bool) {
+	b, err := data.MarshalJSON()
+	if err != nil {
+		return vcacheKey{}, false
+	}
+	return vcacheKey{v: v, hash: sha256.Sum256(b)}, true
+}
+
+func (vc *validationCache) get(k vcacheKey) (*vcacheEntry, bool) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if el, has := vc.items[k]; has {
+		vc.hits++
+		vc.ll.MoveToFront(el)
+		return el.Value.(*vcacheEntry), true
+	}
+	vc.misses++
+	return nil, false
+}
+
+func (vc *validationCache) put(k vcacheKey, err error) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if el, has := vc.items[k]; has {
+		vc.ll.MoveToFront(el)
+		el.Value = &vcacheEntry{key: k, err: err}
+		return
+	}
+
+	vc.items[k] = vc.ll.PushFront(&vcacheEntry{key: k, err: err})
+	if vc.ll.Len() > vc.size {
+		last := vc.ll.Back()
+		vc.ll.Remove(last)
+		delete(vc.items, last.Value.(*vcacheEntry).key)
+	}
+}
+
+func (vc *validationCache) stats() CacheStats {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	return CacheStats{
+		Hits:   vc.hits,
+		Misses: vc.misses,
+		Len:    vc.ll.Len(),
+		Size:   vc.size,
+	}
+}
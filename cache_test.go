@@ -0,0 +1,71 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationCache(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(searchlinstr), rt, ValidationCache(2))
+	require.NoError(t, err)
+
+	_, has := ValidationCacheStats(testLin(searchlinstr))
+	assert.False(t, has, "lineage bound without cache should report no stats")
+
+	ctx := rt.Context()
+	sch := lin.Latest()
+	for i := 0; i < 3; i++ {
+		_, err := sch.Validate(ctx.CompileString(`{ name: "foo" }`))
+		require.NoError(t, err)
+		_, err = sch.Validate(ctx.CompileString(`{ title: "foo" }`))
+		require.Error(t, err)
+	}
+
+	stats, has := ValidationCacheStats(lin)
+	require.True(t, has)
+	assert.Equal(t, uint64(4), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 2, stats.Len)
+
+	// Pushes out the least recently used entry, foo
+	_, err = sch.Validate(ctx.CompileString(`{ name: "bar" }`))
+	require.NoError(t, err)
+	_, err = sch.Validate(ctx.CompileString(`{ title: "foo" }`))
+	require.Error(t, err)
+	_, err = sch.Validate(ctx.CompileString(`{ name: "foo" }`))
+	require.NoError(t, err)
+
+	stats, _ = ValidationCacheStats(lin)
+	assert.Equal(t, uint64(5), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+	assert.Equal(t, 2, stats.Len)
+	assert.InDelta(t, 5.0/9.0, stats.HitRate(), 0.001)
+}
+
+func TestValidationCacheFreshInstances(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(searchlinstr), rt, ValidationCache(2))
+	require.NoError(t, err)
+
+	ctx := rt.Context()
+	sch := lin.Latest()
+	d1, d2 := ctx.CompileString(`{ name: "foo" }`), ctx.CompileString(`{ name: "foo" }`)
+	inst1, err := sch.Validate(d1)
+	require.NoError(t, err)
+	inst2, err := sch.Validate(d2)
+	require.NoError(t, err)
+
+	stats, _ := ValidationCacheStats(lin)
+	require.Equal(t, uint64(1), stats.Hits)
+
+	// The cache hit wraps the caller's own value, not the first caller's
+	assert.NotSame(t, inst1, inst2)
+	assert.True(t, d1 == inst1.Underlying())
+	assert.True(t, d2 == inst2.Underlying())
+	assert.False(t, d1 == inst2.Underlying())
+	assert.Equal(t, sch, inst2.Schema())
+}
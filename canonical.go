@@ -0,0 +1,174 @@
+package thema
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+)
+
+// Canonicalize returns a canonical JSON encoding of the data in the provided
+// [Instance], such that any two instances containing equivalent data are
+// encoded to identical bytes. The result is suitable for hashing or comparing
+// objects, as for deduplication and change detection, consistently across
+// programs that may have received the data in different shapes.
+//
+// The encoding is formed as follows:
+//   - fields whose values are equal to their schema default are removed, as by
+//     [Instance.TrimDefaults]
+//   - the fields of each struct are ordered as declared in the schema, followed
+//     by any fields not declared in the schema in lexical order
+//   - integral numbers are written in full, without fraction or exponent, and
+//     all other numbers in the shortest form that round-trips as a float64
+//   - no insignificant whitespace is written
+//
+// If sch is nil, the instance's own schema is used. Otherwise, sch must be in
+// the instance's lineage, and the instance is first translated to it; lacunas
+// emitted by the translation are disregarded. Canonicalizing all instances
+// against the same schema allows programs holding instances of different
+// versions to agree on their encoding.
+func Canonicalize(inst *Instance, sch Schema) ([]byte, error) {
+	inst.check()
+	if sch == nil {
+		sch = inst.Schema()
+	}
+	inst, err := translateTo(inst, sch)
+	if err != nil {
+		return nil, err
+	}
+
+	return guard("canonicalizing instance", func() ([]byte, error) {
+		schv := sch.Underlying().LookupPath(pathSchDef)
+		data, _, err := doDehydrate(schv, inst.Underlying())
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to trim defaults from data")
+		}
+		var buf bytes.Buffer
+		if err := writeCanonical(&buf, schv, data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// writeCanonical writes the canonical JSON encoding of the concrete value v
+// to buf, ordering struct fields by the schema sch, which need not exist.
+func writeCanonical(buf *bytes.Buffer, sch, v cue.Value) error {
+	if op, _ := sch.Expr(); op == cue.OrOp {
+		if branch, err := getBranch(sch, v); err == nil {
+			sch = branch
+		}
+	}
+
+	switch v.Kind() {
+	case cue.StructKind:
+		// LookupPath does not find optional fields, so the schema for each
+		// declared field is kept from iteration
+		var keys []string
+		declared := make(map[string]cue.Value)
+		if sch.Exists() && sch.IncompleteKind() == cue.StructKind {
+			if iter, err := sch.Fields(cue.Optional(true)); err == nil {
+				for iter.Next() {
+					k := iter.Selector().Unquoted()
+					declared[k] = iter.Value()
+					if v.LookupPath(cue.MakePath(cue.Str(k))).Exists() {
+						keys = append(keys, k)
+					}
+				}
+			}
+		}
+		iter, err := v.Fields()
+		if err != nil {
+			return err
+		}
+		var undeclared []string
+		for iter.Next() {
+			k := iter.Selector().Unquoted()
+			if _, has := declared[k]; !has {
+				undeclared = append(undeclared, k)
+			}
+		}
+		sort.Strings(undeclared)
+
+		buf.WriteByte('{')
+		for i, k := range append(keys, undeclared...) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			fsch, has := declared[k]
+			if !has {
+				fsch = sch.LookupPath(cue.MakePath(cue.Str(k)))
+			}
+			if err := writeCanonical(buf, fsch, v.LookupPath(cue.MakePath(cue.Str(k)))); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case cue.ListKind:
+		iter, err := v.List()
+		if err != nil {
+			return err
+		}
+		elem := sch.LookupPath(cue.MakePath(cue.AnyIndex))
+		buf.WriteByte('[')
+		for i := 0; iter.Next(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			esch := elem
+			if ev := sch.LookupPath(cue.MakePath(cue.Index(i))); ev.Exists() {
+				esch = ev
+			}
+			if err := writeCanonical(buf, esch, iter.Value()); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case cue.StringKind:
+		s, err := v.String()
+		if err != nil {
+			return err
+		}
+		writeCanonicalString(buf, s)
+	case cue.IntKind, cue.FloatKind:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		r, ok := new(big.Rat).SetString(string(b))
+		if !ok {
+			return errors.Newf("unable to parse number %s", b)
+		}
+		if r.IsInt() {
+			buf.WriteString(r.Num().String())
+		} else {
+			f, _ := r.Float64()
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case cue.BoolKind, cue.NullKind:
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	default:
+		return errors.Newf("%s: cannot canonicalize non-concrete value", v.Path())
+	}
+	return nil
+}
+
+// writeCanonicalString writes s to buf as a JSON string, escaping only what
+// JSON requires.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) //nolint:errcheck
+	// Encode always terminates its output with a newline
+	buf.Truncate(buf.Len() - 1)
+}
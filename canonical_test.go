@@ -0,0 +1,84 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	lin := testLin(`name: "canonical"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title:   string
+		weight:  number | *1
+		enabled: bool | *true
+		labels?: [string]: string
+		panels?: [...{type: string, span?: int | *12}]
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+	validate := func(v SyntacticVersion, data string) *Instance {
+		inst, err := SchemaP(lin, v).Validate(ctx.CompileString(data))
+		require.NoError(t, err)
+		return inst
+	}
+
+	want := `{"title":"<a>","weight":2.5,"labels":{"a":"x","b":"y"},"panels":[{"type":"graph"},{"type":"text","span":6}]}`
+	for name, data := range map[string]string{
+		"canonical": want,
+		"reordered": `{panels: [{type: "graph"}, {span: 6, type: "text"}], labels: {b: "y", a: "x"}, weight: 2.50, title: "<a>"}`,
+		"defaults":  `{title: "<a>", enabled: true, weight: 25e-1, labels: {a: "x", b: "y"}, panels: [{type: "graph", span: 12}, {type: "text", span: 6}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := Canonicalize(validate(SV(0, 0), data), nil)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(b))
+		})
+	}
+
+	t.Run("integral numbers", func(t *testing.T) {
+		b, err := Canonicalize(validate(SV(0, 0), `{title: "t", weight: 2.0e3}`), nil)
+		require.NoError(t, err)
+		assert.Equal(t, `{"title":"t","weight":2000}`, string(b))
+	})
+
+	t.Run("translated", func(t *testing.T) {
+		lin := testLin(`name: "translated"
+schemas: [{
+	version: [0, 0]
+	schema: {title: string, enabled: bool | *true}
+},
+{
+	version: [0, 1]
+	schema: {title: string, enabled: bool | *true, note?: string}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		title: input.title
+		enabled: input.enabled
+	}
+	lacunas: []
+}]
+`)
+		inst, err := SchemaP(lin, SV(0, 1)).Validate(lin.Runtime().Context().CompileString(`{note: "n", enabled: false, title: "t"}`))
+		require.NoError(t, err)
+		b, err := Canonicalize(inst, SchemaP(lin, SV(0, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, `{"title":"t","enabled":false}`, string(b))
+	})
+
+	t.Run("other lineage", func(t *testing.T) {
+		other := testLin(`name: "other"
+schemas: [{version: [0, 0], schema: title: string}]
+`)
+		_, err := Canonicalize(validate(SV(0, 0), `{title: "t"}`), other.First())
+		assert.Error(t, err)
+	})
+}
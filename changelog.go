@@ -0,0 +1,91 @@
+package thema
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ChangeKind categorizes a [ChangeNote], following the conventions of
+// https://keepachangelog.com.
+type ChangeKind string
+
+const (
+	ChangeAdded      ChangeKind = "added"
+	ChangeChanged    ChangeKind = "changed"
+	ChangeDeprecated ChangeKind = "deprecated"
+	ChangeRemoved    ChangeKind = "removed"
+	ChangeFixed      ChangeKind = "fixed"
+	ChangeSecurity   ChangeKind = "security"
+)
+
+// changeKinds lists all ChangeKinds in the order in which they are rendered.
+var changeKinds = []ChangeKind{ChangeAdded, ChangeChanged, ChangeDeprecated, ChangeRemoved, ChangeFixed, ChangeSecurity}
+
+// A ChangeNote describes a single change made in a schema relative to its
+// predecessor, as declared by the lineage author in the schema's changes
+// field. It corresponds to #ChangeNote in the Thema CUE package.
+type ChangeNote struct {
+	// Kind categorizes the change.
+	Kind ChangeKind `json:"kind"`
+
+	// Message describes the change, for human readers.
+	Message string `json:"message"`
+
+	// Fields are the paths of the fields affected by the change, if declared.
+	Fields []string `json:"fields,omitempty"`
+
+	// Issue references a related issue or pull request, if declared.
+	Issue string `json:"issue,omitempty"`
+}
+
+// WriteChangelog writes a Markdown changelog of the lineage to w, built from
+// the [Schema.ReleaseNotes] of each of its schemas. Schemas are listed newest
+// first, and their notes grouped by kind.
+func WriteChangelog(w io.Writer, lin Lineage) error {
+	isValidLineage(lin)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Changelog: %s\n", lin.Name())
+	all := lin.All()
+	for i := len(all) - 1; i >= 0; i-- {
+		sch := all[i]
+		fmt.Fprintf(bw, "\n## %s", sch.Version())
+		if sch.Maturity() != MaturityStable {
+			fmt.Fprintf(bw, " (%s)", sch.Maturity())
+		}
+		fmt.Fprintln(bw)
+
+		notes := sch.ReleaseNotes()
+		if len(notes) == 0 {
+			if sch.Predecessor() == nil {
+				fmt.Fprintln(bw, "\nInitial schema.")
+			} else {
+				fmt.Fprintln(bw, "\nNo changes recorded.")
+			}
+			continue
+		}
+		for _, kind := range changeKinds {
+			var heading bool
+			for _, note := range notes {
+				if note.Kind != kind {
+					continue
+				}
+				if !heading {
+					fmt.Fprintf(bw, "\n### %s\n\n", strings.ToUpper(string(kind[:1]))+string(kind[1:]))
+					heading = true
+				}
+				fmt.Fprintf(bw, "- %s", note.Message)
+				if len(note.Fields) > 0 {
+					fmt.Fprintf(bw, " (`%s`)", strings.Join(note.Fields, "`, `"))
+				}
+				if note.Issue != "" {
+					fmt.Fprintf(bw, " %s", note.Issue)
+				}
+				fmt.Fprintln(bw)
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,58 @@
+package thema
+
+import (
+	"bytes"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var changeloglinstr = `name: "changelog"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+	maturity: "beta"
+}, {
+	version: [0, 1]
+	schema: {title: string, tags?: [...string]}
+	changes: [{
+		kind: "fixed"
+		message: "Clarified the docs of title."
+	}, {
+		kind: "added"
+		message: "Added tags."
+		fields: ["tags"]
+		issue: "https://example.com/issues/1"
+	}]
+}]
+`
+
+func TestReleaseNotes(t *testing.T) {
+	lin := testLin(changeloglinstr)
+	assert.Empty(t, lin.First().ReleaseNotes())
+	assert.Equal(t, []ChangeNote{
+		{Kind: ChangeFixed, Message: "Clarified the docs of title."},
+		{Kind: ChangeAdded, Message: "Added tags.", Fields: []string{"tags"}, Issue: "https://example.com/issues/1"},
+	}, lin.Latest().ReleaseNotes())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteChangelog(&buf, lin))
+	assert.Equal(t, "# Changelog: changelog\n"+
+		"\n## 0.1\n"+
+		"\n### Added\n\n- Added tags. (`tags`) https://example.com/issues/1\n"+
+		"\n### Fixed\n\n- Clarified the docs of title.\n"+
+		"\n## 0.0 (beta)\n\nInitial schema.\n", buf.String())
+
+	t.Run("invalid notes", func(t *testing.T) {
+		rt := NewRuntime(cuecontext.New())
+		_, err := BindLineage(rt.Context().CompileString(`name: "bad"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+	changes: [{kind: "improved", message: "?"}]
+}]`), rt)
+		assert.Error(t, err)
+	})
+}
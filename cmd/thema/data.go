@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
 
 	"cuelang.org/go/cue"
 	"github.com/spf13/cobra"
@@ -19,8 +23,22 @@ import (
 type dataCommand struct {
 	format  string
 	quiet   bool
+	matrix  bool
 	inbytes []byte
 
+	// oldest and all configure the search made by validate-any.
+	oldest bool
+	all    bool
+
+	// ndjson and lacunas configure streaming translation.
+	ndjson   bool
+	lacunas  string
+	progress bool
+
+	// policy is checked against the lacunas emitted by translation.
+	policyf policyFlag
+	policy  *thema.LacunaPolicy
+
 	datval cue.Value
 
 	lla *lineageLoadArgs
@@ -41,34 +59,50 @@ func (dc *dataCommand) setup(cmd *cobra.Command) {
 
 	dataCmd.AddCommand(validateCmd)
 	validateCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to validate data against. defaults to latest")
-	validateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	validateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format, as a file extension (e.g. \"yaml\", \"toml\", \"cbor\") or media type. Inferred from the input path's extension by default, else autodetected as JSON or YAML.")
 	validateCmd.Flags().BoolVarP(&dc.quiet, "quiet", "q", false, "emit no output, exit status only")
+	validateCmd.Flags().BoolVar(&dc.matrix, "matrix", false, "validate against every schema in the lineage, printing a table of the outcome for each")
 	validateCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional, dc.validateDataInput)
 	validateCmd.RunE = dc.runValidate
 
 	dataCmd.AddCommand(validateAnyCmd)
 	validateAnyCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to validate data against")
-	validateAnyCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	validateAnyCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format, as a file extension (e.g. \"yaml\", \"toml\", \"cbor\") or media type. Inferred from the input path's extension by default, else autodetected as JSON or YAML.")
 	validateAnyCmd.Flags().BoolVarP(&dc.quiet, "quiet", "q", false, "emit no output, exit status only")
+	validateAnyCmd.Flags().BoolVar(&dc.oldest, "oldest", false, "prefer the oldest matching schema, rather than the newest")
+	validateAnyCmd.Flags().BoolVar(&dc.all, "all", false, "output every matching schema version, one per line")
 	validateAnyCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional, dc.validateDataInput)
 	validateAnyCmd.RunE = dc.runValidateAny
 
 	dataCmd.AddCommand(translateCmd)
 	translateCmd.Flags().StringVarP(&dc.lla.verstr, "to", "v", "", "schema version to translate input data to")
 	translateCmd.MarkFlagRequired("to")
-	translateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
-	translateCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInput, dc.validateDataInput)
+	translateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format, as a file extension (e.g. \"yaml\", \"toml\", \"cbor\") or media type. Inferred from the input path's extension by default, else autodetected as JSON or YAML.")
+	translateCmd.Flags().BoolVar(&dc.ndjson, "ndjson", false, "stream newline-delimited JSON input, translating each line to a line of output")
+	translateCmd.Flags().StringVar(&dc.lacunas, "lacunas", "", "with --ndjson, path to a file to which lacunas emitted for each line are written")
+	translateCmd.Flags().BoolVar(&dc.progress, "progress", false, "with --ndjson, periodically report the number of lines translated to stderr")
+	dc.policyf.addFlag(translateCmd)
+	translateCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInput, dc.validateTranslateInput)
 	translateCmd.RunE = dc.runTranslate
 
+	dataCmd.AddCommand(conformanceCmd)
+	conformanceCmd.PersistentPreRunE = dc.lla.validateLineageInput
+	conformanceCmd.RunE = dc.runConformance
+
+	dataCmd.AddCommand(driftCmd)
+	driftCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to check data against. defaults to latest")
+	driftCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional)
+	driftCmd.RunE = dc.runDrift
+
 	dataCmd.AddCommand(hydrateCmd)
 	hydrateCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to validate data against")
-	hydrateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	hydrateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format, as a file extension (e.g. \"yaml\", \"toml\", \"cbor\") or media type. Inferred from the input path's extension by default, else autodetected as JSON or YAML.")
 	hydrateCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional, dc.validateDataInput)
 	hydrateCmd.RunE = dc.runHydrate
 
 	dataCmd.AddCommand(dehydrateCmd)
 	dehydrateCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to validate data against")
-	dehydrateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	dehydrateCmd.Flags().StringVarP(&dc.format, "format", "e", "", "input data format, as a file extension (e.g. \"yaml\", \"toml\", \"cbor\") or media type. Inferred from the input path's extension by default, else autodetected as JSON or YAML.")
 	dehydrateCmd.PersistentPreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional, dc.validateDataInput)
 	dehydrateCmd.RunE = dc.runDehydrate
 }
@@ -86,18 +120,30 @@ absolute. Lineages are necessarily validated prior to validation of the input
 data. All data operations are performed in the context of the provided lineage.
 
 Data may be provided on stdin, or by passing a single path to a file as an
-argument. Stdin is ignored if a path is provided. JSON and YAML inputs are
-supported; the correct format is inferred. Only one object instance may be
-validated per command invocation.
+argument. Stdin is ignored if a path is provided. JSON, YAML, TOML, CBOR,
+MessagePack and binary protobuf Struct inputs are supported. The format is
+inferred from the file extension, or else detected as JSON or YAML, unless
+given with -e. Only one object instance may be validated per command
+invocation.
 `
 
 var validateCmd = &cobra.Command{
-	Use:   "validate -l <lineage-fs-path> -v <synver> [-p <cue-path>] [-q] [-e <format>] [<data-fs-path>]",
+	Use:   "validate -l <lineage-fs-path> [-v <synver> | --matrix] [-p <cue-path>] [-q] [-e <format>] [<data-fs-path>]",
 	Short: "Validate some input data against a particular Thema schema",
 	Long: `Validate some input data against a particular Thema schema.
 ` + dataReuseText + `
 Success outputs nothing and exits 0. Failure outputs the validation problem
 (unless quieted) and exits 1.
+
+With --json, an object is output reporting whether the data is valid, the
+schema version checked, and on failure, each validation issue with the path to
+the field at which it occurred.
+
+With --matrix, the data is validated against every schema in the lineage, and a
+table is output of whether it is valid against each version, along with the
+first issue found for each version it is not valid against. Exits 0 if the data
+is valid against any schema. With --json, the outcome against each schema is
+reported as for validate-any.
 `,
 	Args: cobra.MaximumNArgs(1),
 }
@@ -106,25 +152,122 @@ func (dc *dataCommand) runValidate(cmd *cobra.Command, args []string) error {
 	if !dc.datval.Exists() {
 		panic("datval does not exist")
 	}
+	if dc.matrix {
+		if dc.lla.verstr != "" {
+			return errors.New("--matrix and --version may not be used together")
+		}
+		return dc.runValidateMatrix(cmd)
+	}
 
 	_, err := dc.lla.dl.sch.Validate(dc.datval)
-	if err != nil {
-		return err
+	if jsonOutput && !dc.quiet {
+		if werr := writeJSON(cmd.OutOrStdout(), jsonValidation{
+			Valid:   err == nil,
+			Lineage: dc.lla.dl.lin.Name(),
+			Version: dc.lla.dl.sch.Version().String(),
+			Issues:  toJSONIssues(err),
+		}); werr != nil {
+			return werr
+		}
+		if err != nil {
+			return errReported
+		}
+		return nil
+	}
+	if err != nil && dc.quiet {
+		return errReported
+	}
+	return err
+}
+
+// runValidateMatrix validates the input against every schema in the lineage,
+// in ascending version order, succeeding if any schema accepts it.
+func (dc *dataCommand) runValidateMatrix(cmd *cobra.Command) error {
+	r := jsonValidation{
+		Lineage: dc.lla.dl.lin.Name(),
+	}
+	for _, sch := range dc.lla.dl.lin.All() {
+		_, err := sch.Validate(dc.datval)
+		if err == nil {
+			// As with validate-any, report the newest matching version
+			r.Valid = true
+			r.Version = sch.Version().String()
+		}
+		r.Candidates = append(r.Candidates, jsonCandidate{
+			Version: sch.Version().String(),
+			Valid:   err == nil,
+			Issues:  toJSONIssues(err),
+		})
+	}
+
+	switch {
+	case dc.quiet:
+	case jsonOutput:
+		if err := writeJSON(cmd.OutOrStdout(), r); err != nil {
+			return err
+		}
+	default:
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tVALID\tERROR")
+		for _, c := range r.Candidates {
+			if c.Valid {
+				fmt.Fprintf(tw, "%s\tyes\n", c.Version)
+			} else {
+				fmt.Fprintf(tw, "%s\tno\t%s\n", c.Version, topIssue(c.Issues))
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if !r.Valid {
+		return errReported
 	}
 	return nil
 }
 
+var posLine = regexp.MustCompile(`^\S+:\d+:\d+$`)
+
+// topIssue summarizes the first of issues on a single line.
+func topIssue(issues []jsonIssue) string {
+	if len(issues) == 0 || strings.TrimSpace(issues[0].Message) == "" {
+		return "data is not an instance of the schema"
+	}
+	// Drop the lines giving source positions, which are too noisy for a table
+	var parts []string
+	for _, line := range strings.Split(issues[0].Message, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !posLine.MatchString(line) {
+			parts = append(parts, line)
+		}
+	}
+	msg := strings.Join(parts, " ")
+	if issues[0].Path != "" && !strings.Contains(msg, issues[0].Path+":") {
+		msg = issues[0].Path + ": " + msg
+	}
+	if len(issues) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(issues)-1)
+	}
+	return msg
+}
+
 var validateAnyCmd = &cobra.Command{
 	Use:   "validate-any -l <lineage-fs-path> [-p <cue-path>] [-v <synver>] [-q] [-e <format>] [<data-fs-path>]",
 	Short: "Search a lineage for a schema that validates some input data",
 	Long: `Search a lineage for a schema that validates some input data.
 ` + dataReuseText + `
 Success outputs the schema version that matched and exits 0. Failure exits 1 and
-outputs nothing.
+outputs nothing. The newest matching schema is reported, unless --oldest is
+passed, in which case the earliest schema the data conforms to is. With --all,
+every matching version is output, in the same order of preference.
 
 If --version is passed, that version is checked first. If validation fails
 against all schemas in the lineage, the error against the --version schema will
 be printed.
+
+With --json, an object is output reporting whether the data is valid, the
+matching schema version if any, and the outcome against each schema checked,
+including validation issues with the path to the field at which they occurred.
 `,
 	Args: cobra.MaximumNArgs(1),
 }
@@ -134,25 +277,79 @@ func (dc *dataCommand) runValidateAny(cmd *cobra.Command, args []string) error {
 		panic("datval does not exist")
 	}
 
+	if jsonOutput {
+		return dc.runValidateAnyJSON(cmd)
+	}
+
 	var reterr error
 	if dc.lla.dl.sch != nil {
+		// An explicit --version takes precedence over the search
 		_, reterr = dc.lla.dl.sch.Validate(dc.datval)
-		if reterr == nil {
+		if reterr == nil && dc.lla.verstr != "" && !dc.all {
 			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", dc.lla.dl.sch.Version())
 			return nil
 		}
 	}
-	inst := dc.lla.dl.lin.ValidateAny(dc.datval)
-	if inst != nil {
-		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", inst.Schema().Version())
+	insts, _, err := thema.SearchAndValidateAll(dc.lla.dl.lin, dc.datval, dc.searchOptions()...)
+	if err == nil {
+		if !dc.all {
+			insts = insts[:1]
+		}
+		for _, inst := range insts {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", inst.Schema().Version())
+		}
 		return nil
 	}
 
-	if reterr != nil {
+	if reterr != nil && !dc.quiet {
 		return reterr
 	}
-	// Empty error should cause exit 1, but no output (or maybe just newline)
-	return errors.New("")
+	return errReported
+}
+
+// searchOptions returns the options for the search made by validate-any.
+func (dc *dataCommand) searchOptions() []thema.SearchOption {
+	if dc.oldest {
+		return []thema.SearchOption{thema.Direction(thema.OldestFirst)}
+	}
+	return nil
+}
+
+func (dc *dataCommand) runValidateAnyJSON(cmd *cobra.Command) error {
+	// As without --json, an explicit --version is checked first
+	var cands []thema.Candidate
+	if dc.lla.verstr != "" {
+		_, err := dc.lla.dl.sch.Validate(dc.datval)
+		cands = append(cands, thema.Candidate{Version: dc.lla.dl.sch.Version(), Err: err})
+	}
+	if len(cands) == 0 || !cands[0].Matched() {
+		_, scands, _ := thema.SearchAndValidate(dc.lla.dl.lin, dc.datval, dc.searchOptions()...)
+		cands = append(cands, scands...)
+	}
+
+	r := jsonValidation{
+		Lineage: dc.lla.dl.lin.Name(),
+	}
+	for _, c := range cands {
+		if c.Matched() && !r.Valid {
+			r.Valid = true
+			r.Version = c.Version.String()
+		}
+		r.Candidates = append(r.Candidates, jsonCandidate{
+			Version: c.Version.String(),
+			Valid:   c.Matched(),
+			Issues:  toJSONIssues(c.Err),
+		})
+	}
+	if !dc.quiet {
+		if err := writeJSON(cmd.OutOrStdout(), r); err != nil {
+			return err
+		}
+	}
+	if !r.Valid {
+		return errReported
+	}
+	return nil
 }
 
 var translateCmd = &cobra.Command{
@@ -166,18 +363,43 @@ error.
 
 Note that Thema's invariants (once finalized) guarantee that failures can only
 arise during data input decoding or validation, never during translation.
+
+With --ndjson, input is read as newline-delimited JSON, one object per line,
+and each object's translation is written as a line of output, suitable for use
+in shell pipelines:
+
+  cat in.ndjson | thema data translate -l <lineage> --to 2.0 --ndjson > out.ndjson
+
+Lines that fail to translate are reported on stderr and omitted from the
+output, and processing continues. A summary is written to stderr, and the exit
+status is 1 if any line failed. If --lacunas is given, a JSON array describing
+the lacunas emitted for each line is written to that path. With --progress, the
+number of lines translated so far, and the rate, are reported on stderr every
+second, so that long migrations are not silent.
+
+If --lacuna-policy is given, translations emitting lacunas the policy does not
+permit fail, and lacunas for which it prescribes a warning are reported on
+stderr. A policy is written in CUE or JSON as a thema.#LacunaPolicy:
+
+  rules: [
+    {severity: "critical", action: "fail"},
+    {types: ["DroppedField"], path: "spec.legacy", action: "warn"},
+  ]
 `,
 	Args: cobra.MaximumNArgs(1),
 }
 
 func (dc *dataCommand) runTranslate(cmd *cobra.Command, args []string) error {
+	if dc.ndjson {
+		return dc.runTranslateStream(cmd, args)
+	}
 	if !dc.datval.Exists() {
 		panic("datval does not exist")
 	}
 
-	inst := dc.lla.dl.lin.ValidateAny(dc.datval)
-	if inst == nil {
-		return errors.New("input data is not valid for any schema in lineage")
+	inst, _, err := thema.SearchAndValidate(dc.lla.dl.lin, dc.datval)
+	if err != nil {
+		return err
 	}
 
 	// Prior validations checked that the schema version exists in the lineage
@@ -188,6 +410,13 @@ func (dc *dataCommand) runTranslate(cmd *cobra.Command, args []string) error {
 	if err = dc.validateTranslationResult(tinst, lac); err != nil {
 		return err
 	}
+	warnings, err := dc.policy.Check(lac)
+	if err != nil {
+		return err
+	}
+	for _, l := range warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s lacuna: %s\n", l.Type, l.Message)
+	}
 
 	// TODO support non-JSON output
 	r := translationResult{
@@ -196,6 +425,10 @@ func (dc *dataCommand) runTranslate(cmd *cobra.Command, args []string) error {
 		Result:  tinst.Underlying(),
 		Lacunas: lac,
 	}
+	if jsonOutput && lac != nil {
+		// Normalize to a flat list, regardless of the lens implementation
+		r.Lacunas = thema.Lacunas(lac.AsList())
+	}
 
 	byt, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
@@ -207,6 +440,286 @@ func (dc *dataCommand) runTranslate(cmd *cobra.Command, args []string) error {
 	return err
 }
 
+// validateTranslateInput reads input data for translation, unless it is to be
+// streamed.
+func (dc *dataCommand) validateTranslateInput(cmd *cobra.Command, args []string) error {
+	if dc.lacunas != "" && !dc.ndjson {
+		return errors.New("--lacunas may only be used with --ndjson")
+	}
+	var err error
+	if dc.policy, err = dc.policyf.load(); err != nil {
+		return err
+	}
+	if dc.ndjson {
+		if dc.format != "" && dc.format != "json" {
+			return errors.New("--ndjson input must be JSON")
+		}
+		return nil
+	}
+	return dc.validateDataInput(cmd, args)
+}
+
+// lineLacunas are the lacunas emitted in translating a single line of
+// streamed input.
+type lineLacunas struct {
+	Line    int            `json:"line"`
+	From    string         `json:"from"`
+	Lacunas []thema.Lacuna `json:"lacunas"`
+}
+
+func (dc *dataCommand) runTranslateStream(cmd *cobra.Command, args []string) error {
+	var in io.Reader = cmd.InOrStdin()
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("could not open provided path: %w", err)
+		}
+		defer f.Close() // nolint: errcheck
+		in = f
+	}
+
+	to := dc.lla.dl.sch.Version()
+	out := bufio.NewWriter(cmd.OutOrStdout())
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	prog := newProgress(cmd.ErrOrStderr(), dc.progress)
+	prog.OnStart("translate", -1)
+
+	var line, total, failed int
+	lacs := []lineLacunas{}
+	for sc.Scan() {
+		line++
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		total++
+
+		b, from, lac, err := dc.translateLine(line, sc.Bytes())
+		if err == nil {
+			var warnings thema.Lacunas
+			warnings, err = dc.policy.Check(lac)
+			for _, l := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "line %d: warning: %s lacuna: %s\n", line, l.Type, l.Message)
+			}
+		}
+		prog.OnItem(fmt.Sprintf("line %d", line), err)
+		if err != nil {
+			failed++
+			// Keep to one line of output per failure
+			fmt.Fprintf(cmd.ErrOrStderr(), "line %d: %s\n", line, strings.Join(strings.Fields(err.Error()), " "))
+			continue
+		}
+		if lac != nil && len(lac.AsList()) > 0 {
+			lacs = append(lacs, lineLacunas{Line: line, From: from.String(), Lacunas: lac.AsList()})
+		}
+		out.Write(b)        // nolint: errcheck
+		out.WriteByte('\n') // nolint: errcheck
+	}
+	if err := out.Flush(); err != nil {
+		prog.OnFinish(err)
+		return err
+	}
+	if err := sc.Err(); err != nil {
+		prog.OnFinish(err)
+		return fmt.Errorf("error reading input at line %d: %w", line+1, err)
+	}
+	prog.OnFinish(nil)
+
+	if dc.lacunas != "" {
+		b, err := json.MarshalIndent(lacs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling lacunas to JSON: %w", err)
+		}
+		if err := os.WriteFile(dc.lacunas, append(b, '\n'), 0644); err != nil { // nolint: gosec
+			return err
+		}
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "translated %d of %d objects to %s: %d failed, %d with lacunas\n", total-failed, total, to, failed, len(lacs))
+	if failed > 0 {
+		return errReported
+	}
+	return nil
+}
+
+// translateLine translates a single line of streamed input, returning the
+// translated object as JSON along with the version the input was valid against.
+func (dc *dataCommand) translateLine(line int, b []byte) ([]byte, thema.SyntacticVersion, thema.TranslationLacunas, error) {
+	datval, err := vmux.NewJSONCodec(fmt.Sprintf("line %d", line)).Decode(rt.Underlying().Context(), b)
+	if err != nil {
+		return nil, thema.SyntacticVersion{}, nil, err
+	}
+	inst, _, err := thema.SearchAndValidate(dc.lla.dl.lin, datval)
+	if err != nil {
+		return nil, thema.SyntacticVersion{}, nil, err
+	}
+	tinst, lac, err := inst.Translate(dc.lla.dl.sch.Version())
+	if err != nil {
+		return nil, inst.Schema().Version(), nil, err
+	}
+	if err = dc.validateTranslationResult(tinst, lac); err != nil {
+		return nil, inst.Schema().Version(), nil, err
+	}
+	out, err := json.Marshal(tinst.Underlying())
+	return out, inst.Schema().Version(), lac, err
+}
+
+var conformanceCmd = &cobra.Command{
+	Use:   "conformance -l <lineage-fs-path> [-p <cue-path>] [<data-fs-path>]",
+	Short: "Report how a corpus of data conforms to each schema in a lineage",
+	Long: `Report how a corpus of data conforms to each schema in a lineage.
+
+The corpus is read as newline-delimited JSON, one object per line, from stdin
+or the file at the provided path. Each object is validated against every schema
+in the lineage, and a table is output of how many objects are valid against
+each version, along with the paths at which objects most commonly fail to
+validate and the lines of some objects that fail there.
+
+This is useful for deciding when data produced for an old schema has drained
+enough for that schema to be deprecated.
+
+With --json, the report is output as an object, in which failing objects are
+identified by their zero-based index among the non-empty lines of input.
+`,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func (dc *dataCommand) runConformance(cmd *cobra.Command, args []string) error {
+	corpus, lines, err := readCorpus(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	r := thema.CheckConformance(dc.lla.dl.lin, corpus)
+	if jsonOutput {
+		return writeJSON(cmd.OutOrStdout(), r)
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tVALID\tINVALID\tFAILING PATH\tCOUNT\tEXAMPLE LINES")
+	for _, s := range r.Schemas {
+		fmt.Fprintf(tw, "%s\t%d\t%d", s.Version, s.Valid, s.Invalid)
+		if len(s.FailingPaths) == 0 {
+			fmt.Fprintln(tw)
+		}
+		for i, fp := range s.FailingPaths {
+			if i > 0 {
+				fmt.Fprint(tw, "\t\t")
+			}
+			path := fp.Path
+			if path == "" {
+				path = "(root)"
+			}
+			ex := make([]string, 0, len(fp.Examples))
+			for _, e := range fp.Examples {
+				ex = append(ex, fmt.Sprint(lines[e.Index]))
+			}
+			fmt.Fprintf(tw, "\t%s\t%d\t%s\n", path, fp.Count, strings.Join(ex, ", "))
+		}
+	}
+	return tw.Flush()
+}
+
+// readCorpus reads newline-delimited JSON objects from stdin or the file at
+// the path in args, returning them along with the line on which each began.
+func readCorpus(cmd *cobra.Command, args []string) ([]cue.Value, []int, error) {
+	var in io.Reader = cmd.InOrStdin()
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open provided path: %w", err)
+		}
+		defer f.Close() // nolint: errcheck
+		in = f
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var corpus []cue.Value
+	var lines []int
+	var line int
+	for sc.Scan() {
+		line++
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		v, err := vmux.NewJSONCodec(fmt.Sprintf("line %d", line)).Decode(rt.Underlying().Context(), sc.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		corpus = append(corpus, v)
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading input at line %d: %w", line+1, err)
+	}
+	return corpus, lines, nil
+}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift -l <lineage-fs-path> [-p <cue-path>] [-v <synver>] [<data-fs-path>]",
+	Short: "Report how a corpus of data has drifted from a schema",
+	Long: `Report how a corpus of data has drifted from a schema.
+
+The corpus is read as newline-delimited JSON, one object per line, from stdin
+or the file at the provided path. Each object is validated against the schema
+at --version, defaulting to the latest, and two tables are output.
+
+The first lists the fields present in the data that the schema does not
+declare, with the number of objects containing each, and a declaration
+proposed for adding the field to the schema's next minor version. The second
+lists the paths at which objects failed the schema's constraints, including
+those marked @thema(advisory), with the number of failures and how many of
+those were advisory.
+
+With --json, the report is output as an object.
+`,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func (dc *dataCommand) runDrift(cmd *cobra.Command, args []string) error {
+	corpus, _, err := readCorpus(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	r := thema.AnalyzeDrift(dc.lla.dl.sch, corpus)
+	if jsonOutput {
+		return writeJSON(cmd.OutOrStdout(), r)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "%d objects checked against %s: %d valid, %d with warnings, %d invalid\n", r.Total, r.Version, r.Valid, r.Warned, r.Invalid)
+	if len(r.UnknownFields) > 0 {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "UNKNOWN FIELD\tCOUNT\tCANDIDATE")
+		for _, uf := range r.UnknownFields {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", uf.Path, uf.Count, uf.Candidate)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(r.NearMisses) > 0 {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FAILING PATH\tCODE\tCOUNT\tADVISORY\tEXAMPLE")
+		for _, nm := range r.NearMisses {
+			path := nm.Path
+			if path == "" {
+				path = "(root)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", path, nm.Code, nm.Count, nm.Advisory, topIssue([]jsonIssue{{Message: nm.Examples[0]}}))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type translationResult struct {
 	From    string                   `json:"from"`
 	To      string                   `json:"to,omitempty"`
@@ -347,8 +860,6 @@ func pathOrStdin(args []string) ([]byte, error) {
 }
 
 func (dc *dataCommand) validateDataInput(cmd *cobra.Command, args []string) error {
-	var ext string
-
 	byt, err := pathOrStdin(args)
 	if err != nil {
 		return err
@@ -357,58 +868,58 @@ func (dc *dataCommand) validateDataInput(cmd *cobra.Command, args []string) erro
 		dc.inbytes = byt
 	}
 
+	path, ext := "stdin", ""
 	if len(args) == 1 {
-		switch filepath.Ext(args[0]) {
-		case ".json", ".ldjson":
-			ext = "json"
-		case ".yaml", ".yml":
-			ext = "yaml"
-		}
+		path, ext = args[0], filepath.Ext(args[0])
 	}
+	extmt, hasext := vmux.MediaTypeByExt(ext)
+	ctx := rt.Underlying().Context()
+
+	if dc.format == "" {
+		if hasext {
+			dec, err := vmux.DecoderFor(extmt, path)
+			if err != nil {
+				return err
+			}
+			dc.format = strings.TrimPrefix(ext, ".")
+			dc.datval, err = dec.Decode(ctx, dc.inbytes)
+			return err
+		}
 
-	jd := vmux.NewJSONCodec("stdin")
-	yd := vmux.NewYAMLCodec("stdin")
-
-	switch dc.format {
-	case "":
 		// Figure it out; try JSON first
-		dc.datval, err = jd.Decode(rt.Underlying().Context(), dc.inbytes)
+		dc.datval, err = vmux.NewJSONCodec(path).Decode(ctx, dc.inbytes)
 		if err == nil {
 			dc.format = "json"
-			break
+			return nil
 		}
 		// Nope, try yaml
-		dc.datval, err = yd.Decode(rt.Underlying().Context(), dc.inbytes)
+		dc.datval, err = vmux.NewYAMLCodec(path).Decode(ctx, dc.inbytes)
 		if err == nil {
 			dc.format = "yaml"
-			break
+			return nil
 		}
 		// Double nope
 		return errors.New("unrecognized format of input data")
+	}
 
-	case "json":
-		if ext != "json" {
-			return fmt.Errorf("JSON input format specified, but file extension is %s", ext)
-		}
-
-		dc.datval, err = jd.Decode(rt.Underlying().Context(), dc.inbytes)
-		if err != nil {
-			return err
-		}
-	case "yaml":
-		if ext != "yaml" {
-			return fmt.Errorf("YAML input format specified, but file extension is %s", ext)
-		}
-
-		dc.datval, err = yd.Decode(rt.Underlying().Context(), dc.inbytes)
-		if err != nil {
-			return err
+	// The format may be given as a media type, or as the extension of its
+	// files
+	mt := dc.format
+	if !strings.Contains(mt, "/") {
+		var has bool
+		if mt, has = vmux.MediaTypeByExt(dc.format); !has {
+			return fmt.Errorf("unknown input format %q requested; supported formats are %s", dc.format, strings.Join(vmux.Extensions(), ", "))
 		}
-	default:
-		return fmt.Errorf("unknown input format %q requested", dc.format)
 	}
-
-	return nil
+	if hasext && extmt != mt {
+		return fmt.Errorf("%s input format specified, but file extension is %s", dc.format, ext)
+	}
+	dec, err := vmux.DecoderFor(mt, path)
+	if err != nil {
+		return fmt.Errorf("unknown input format %q requested: %w", dc.format, err)
+	}
+	dc.datval, err = dec.Decode(ctx, dc.inbytes)
+	return err
 }
 
 // Everything here should become unnecessary once Thema's key invariants are in
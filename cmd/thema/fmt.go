@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	cuenc "github.com/grafana/thema/encoding/cue"
+	"github.com/spf13/cobra"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [<path>...]",
+	Short: "Format lineage files, enforcing Thema's layout conventions",
+	Long: `Format lineage files, enforcing Thema's layout conventions.
+
+Each path may be a .cue file, or a directory whose .cue files are formatted.
+Defaults to the current directory. In addition to standard CUE formatting:
+
+  * schemas are ordered ascending by version
+  * lenses are ordered by their 'to' version, then their 'from' version, such
+    that all lenses targeting a schema are adjacent
+  * fields within schemas and lenses appear in a stable order
+
+This keeps diffs of lineage files minimal as they evolve. Files without a
+lineage at --path are formatted only as CUE.
+
+By default, formatted source is written to stdout.
+`,
+}
+
+type fmtCommand struct {
+	lincuepath string
+	write      bool
+	list       bool
+}
+
+func setupFmtCommand(cmd *cobra.Command) {
+	cmd.AddCommand(fmtCmd)
+	fc := new(fmtCommand)
+
+	fmtCmd.Flags().StringVarP(&fc.lincuepath, "path", "p", "", "CUE expression for path to the lineage object within each file, if not root")
+	fmtCmd.Flags().BoolVarP(&fc.write, "write", "w", false, "write results to the source files instead of stdout")
+	fmtCmd.Flags().BoolVarP(&fc.list, "list", "l", false, "list files whose formatting differs, instead of printing them")
+	fmtCmd.RunE = fc.run
+}
+
+func (fc *fmtCommand) run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	var files []string
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.cue"))
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+
+	path := cue.ParsePath(fc.lincuepath)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, err := cuenc.FormatLineage(file, src, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		changed := !bytes.Equal(src, out)
+		if fc.list && changed {
+			fmt.Fprintln(cmd.OutOrStdout(), file)
+		}
+		if fc.write && changed {
+			if err := os.WriteFile(file, out, 0644); err != nil { // nolint: gosec
+				return err
+			}
+		}
+		if !fc.list && !fc.write {
+			if _, err := cmd.OutOrStdout().Write(out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
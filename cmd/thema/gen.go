@@ -0,0 +1,681 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	cueopenapi "cuelang.org/go/encoding/openapi"
+	cueyaml "cuelang.org/go/encoding/yaml"
+	"cuelang.org/go/pkg/encoding/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/bigquery"
+	"github.com/grafana/thema/encoding/codegen"
+	"github.com/grafana/thema/encoding/crd"
+	"github.com/grafana/thema/encoding/gocode"
+	"github.com/grafana/thema/encoding/java"
+	"github.com/grafana/thema/encoding/jsonschema"
+	"github.com/grafana/thema/encoding/openapi"
+	"github.com/grafana/thema/encoding/parquet"
+	"github.com/grafana/thema/encoding/python"
+	"github.com/grafana/thema/encoding/rust"
+	"github.com/grafana/thema/encoding/sqlddl"
+	"github.com/grafana/thema/encoding/typescript"
+)
+
+// codegenConfig is the configuration read by "thema gen", from a codegen block
+// declared next to the lineage in CUE, and from a shared config file. Values in
+// the file take precedence over those in the block, and command line flags
+// over both.
+type codegenConfig struct {
+	// Lineage is the path to the .cue file or package containing the lineage.
+	Lineage string `json:"lineage"`
+	// Path is the CUE path to the lineage within the file, if not root.
+	Path string `json:"path"`
+	// Version is the schema version to generate. Defaults to latest.
+	Version string `json:"version"`
+	// Out is the directory to which generated files are written.
+	Out string `json:"out"`
+	// Targets are generated when none are given as arguments.
+	Targets []string `json:"targets"`
+	// Exclude lists the paths of fields within the schema that are omitted by
+	// all targets, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []string `json:"exclude"`
+
+	Go struct {
+		PkgName  string `json:"pkgname"`
+		DeepCopy bool   `json:"deepCopy"`
+		Getters  bool   `json:"getters"`
+	} `json:"go"`
+	TS struct {
+		RootName   string `json:"rootName"`
+		RootAsType bool   `json:"rootAsType"`
+		Validators bool   `json:"validators"`
+	} `json:"ts"`
+	JSONSchema struct {
+		Format string `json:"format"`
+	} `json:"jsonschema"`
+	OpenAPI struct {
+		Format     string `json:"format"`
+		ExpandRefs bool   `json:"expandRefs"`
+	} `json:"openapi"`
+	Rust struct {
+		RootName string   `json:"rootName"`
+		Derives  []string `json:"derives"`
+	} `json:"rust"`
+	Python struct {
+		RootName string `json:"rootName"`
+	} `json:"python"`
+	Java struct {
+		Package     string `json:"package"`
+		ClassName   string `json:"className"`
+		Nullability string `json:"nullability"`
+	} `json:"java"`
+	CRD struct {
+		Format string `json:"format"`
+		Group  string `json:"group"`
+		Kind   string `json:"kind"`
+		Plural string `json:"plural"`
+		Scope  string `json:"scope"`
+	} `json:"crd"`
+	Parquet struct {
+		MessageName string `json:"messageName"`
+	} `json:"parquet"`
+	SQL struct {
+		Dialect    string `json:"dialect"`
+		Table      string `json:"table"`
+		DataColumn string `json:"dataColumn"`
+		PrimaryKey string `json:"primaryKey"`
+	} `json:"sql"`
+	Templates []struct {
+		// Template is the path to a text/template file.
+		Template string `json:"template"`
+		// Out is the name of the file generated, relative to the output
+		// directory. Defaults to the template's name, less any .tmpl extension.
+		Out string `json:"out"`
+	} `json:"templates"`
+}
+
+type codegenCommand struct {
+	config   string
+	out      string
+	check    bool
+	progress bool
+	cfg      codegenConfig
+
+	lla *lineageLoadArgs
+}
+
+// codegenTargets maps each "thema gen" target to its generator.
+var codegenTargets = map[string]func(cc *codegenCommand) ([]codegen.File, error){
+	"go":         single((*codegenCommand).genGo),
+	"ts":         single((*codegenCommand).genTS),
+	"jsonschema": single((*codegenCommand).genJSONSchema),
+	"openapi":    single((*codegenCommand).genOpenAPI),
+	"crd":        single((*codegenCommand).genCRD),
+	"rust":       single((*codegenCommand).genRust),
+	"python":     single((*codegenCommand).genPython),
+	"java":       single((*codegenCommand).genJava),
+	"bigquery":   single((*codegenCommand).genBigQuery),
+	"parquet":    single((*codegenCommand).genParquet),
+	"arrow":      single((*codegenCommand).genArrow),
+	"sql":        (*codegenCommand).genSQL,
+	"templates":  (*codegenCommand).genTemplates,
+}
+
+// single adapts a generator of a single file to the codegenTargets signature.
+func single(fn func(cc *codegenCommand) (string, []byte, error)) func(cc *codegenCommand) ([]codegen.File, error) {
+	return func(cc *codegenCommand) ([]codegen.File, error) {
+		name, b, err := fn(cc)
+		if err != nil {
+			return nil, err
+		}
+		return []codegen.File{{RelativePath: name, Data: b}}, nil
+	}
+}
+
+func setupCodegenCommand(cmd *cobra.Command) {
+	cmd.AddCommand(codegenCmd)
+	cc := &codegenCommand{lla: new(lineageLoadArgs)}
+
+	for target := range codegenTargets {
+		codegenCmd.ValidArgs = append(codegenCmd.ValidArgs, target)
+	}
+	sort.Strings(codegenCmd.ValidArgs)
+	codegenCmd.Args = cobra.OnlyValidArgs
+
+	codegenCmd.Flags().StringVarP(&cc.config, "config", "c", "", "path to a YAML, JSON or CUE config file shared by all targets")
+	codegenCmd.Flags().StringVarP(&cc.lla.inputLinFilePath, "lineage", "l", "", "path to .cue file or package containing lineage")
+	codegenCmd.Flags().StringVarP(&cc.lla.lincuepath, "path", "p", "", "CUE expression for path to the lineage object within file, if not root")
+	codegenCmd.Flags().StringVarP(&cc.lla.verstr, "version", "v", "", "schema syntactic version to generate. Defaults to latest")
+	codegenCmd.Flags().StringVarP(&cc.out, "out", "o", "", "directory to which generated files are written. Defaults to the current directory")
+	codegenCmd.Flags().BoolVar(&cc.check, "check", false, "write nothing, failing if any generated file is out of date")
+	codegenCmd.Flags().BoolVar(&cc.progress, "progress", false, "report each target as it is generated to stderr")
+	codegenCmd.RunE = cc.run
+}
+
+var codegenCmd = &cobra.Command{
+	Use:   "gen [go|ts|rust|python|java|jsonschema|openapi|crd|bigquery|parquet|arrow|sql|templates]...",
+	Short: "Generate code for one or more targets from a lineage",
+	Long: `Generate code for one or more targets from a lineage.
+
+Each argument names a target for which code is generated from a single schema
+in the lineage. If no targets are given, those listed in the configuration are
+generated. Files are written to --out, named for the lineage:
+
+  go          <name>_types_gen.go     Go types
+  ts          <name>_types.gen.ts     TypeScript types and defaults
+  rust        <name>_types_gen.rs     Rust types, for use with serde
+  python      <name>_models_gen.py    Python pydantic models
+  java        <ClassName>.java        Java classes, for use with Jackson
+  jsonschema  <name>.schema.json      JSON Schema (Draft 4)
+  openapi     <name>.openapi.yaml     OpenAPI 3.0 document
+  crd         <name>.crd.yaml         Kubernetes CustomResourceDefinition
+  bigquery    <name>.bigquery.json    BigQuery table schema
+  parquet     <name>.parquet.schema   Parquet message type
+  arrow       <name>.arrow.json       Arrow schema, in Arrow's JSON format
+  sql         <name>.sql              SQL CREATE TABLE, with ALTER TABLE
+              migrations/<name>_<from>_to_<to>.sql
+                                      migrations from each earlier version
+  templates   (configured)            Output of user-supplied text/templates
+
+Generated files are stamped with a header comment, and recorded in a
+.thema-gen.json manifest in --out. Files recorded in the manifest as produced by
+a target that is run, but which it no longer produces, are deleted. With
+--check, nothing is written; instead, gen fails if any file would be created,
+changed or deleted, so that generated code that has drifted from its lineage
+is caught in CI.
+
+The config file passed with --config allows codegen to be driven identically in
+every build, without flags. Flags take precedence over the file, and paths in
+the file are relative to it. For example:
+
+  lineage: ./kinds/dashboard
+  path: lin
+  version: "1.0"
+  out: ./gen
+  targets: [go, ts, crd]
+  go:
+    pkgname: dashboard
+  crd:
+    group: example.com
+
+The same configuration may be declared in CUE as a codegen block next to the
+lineage, i.e. as a sibling of the field given by --path, so that it is
+versioned with the schemas. Paths in the block are relative to the lineage, and
+its lineage and path settings are ignored. The config file takes precedence
+over the block:
+
+  lin: thema.#Lineage & {...}
+  codegen: {
+    targets: ["go", "ts"]
+    exclude: ["meta.internal", "#Panel.legacy"]
+    go: pkgname: "dashboard"
+  }
+
+The exclude setting lists CUE paths of fields within the schema to omit from
+the output of all targets. Fields of a top-level definition are addressed
+through the definition, and are omitted wherever it is referenced.
+
+Per-target settings are:
+
+  go          pkgname, deepCopy, getters
+  ts          rootName, rootAsType, validators (zod validator schemas)
+  rust        rootName, derives
+  python      rootName
+  java        package, className, nullability (package of @Nullable/@NotNull)
+  jsonschema  format ("json" or "yaml")
+  openapi     format ("json" or "yaml"), expandRefs
+  crd         format ("json" or "yaml"), group (required), kind, plural, scope
+  parquet     messageName
+  sql         dialect ("postgres", "mysql" or "sqlite"), table, dataColumn,
+              primaryKey
+
+The templates target executes each text/template listed in the templates
+setting, writing its output to out, a path relative to --out. Paths to
+templates are relative to the file or block declaring them:
+
+  templates:
+    - template: ./templates/fields.md.tmpl
+      out: docs/fields.md
+
+Templates are executed with the lineage and the selected schema, as described
+by the TemplateData type of the github.com/grafana/thema/encoding/codegen
+package: {{.Lineage.Name}}, {{range .Schema.Fields}}{{.Path}}: {{.Type}}{{end}},
+and so on. The functions lower, upper, title, camel, pascal, snake, kebab,
+trimPrefix, replace, join, quote, json, indent, comment and sortedKeys are
+available.
+`,
+}
+
+func (cc *codegenCommand) run(cmd *cobra.Command, args []string) error {
+	if cc.config != "" {
+		if err := cc.loadConfig(&cc.cfg); err != nil {
+			return err
+		}
+	}
+
+	if cc.lla.inputLinFilePath == "" {
+		cc.lla.inputLinFilePath = cc.cfg.Lineage
+	}
+	if cc.lla.lincuepath == "" {
+		cc.lla.lincuepath = cc.cfg.Path
+	}
+	if cc.lla.verstr == "" {
+		cc.lla.verstr = cc.cfg.Version
+	}
+	if err := cc.lla.validateLineageInput(cmd, args); err != nil {
+		return err
+	}
+	if err := cc.loadLineageConfig(); err != nil {
+		return err
+	}
+	// A version given by the codegen block is only known after loading
+	if cc.lla.verstr == "" && cc.cfg.Version != "" {
+		synv, err := thema.ParseSyntacticVersion(cc.cfg.Version)
+		if err != nil {
+			return err
+		}
+		if cc.lla.dl.sch, err = cc.lla.dl.lin.Schema(synv); err != nil {
+			return fmt.Errorf("schema version %v does not exist in lineage", synv)
+		}
+	}
+
+	targets := args
+	if len(targets) == 0 {
+		targets = cc.cfg.Targets
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets given as arguments, in the lineage's codegen block or in a config file")
+	}
+	for _, target := range targets {
+		if _, has := codegenTargets[target]; !has {
+			return fmt.Errorf("unknown target %q", target)
+		}
+	}
+
+	out := cc.cfg.Out
+	if cc.out != "" {
+		out = cc.out
+	}
+	if out == "" {
+		out = "."
+	}
+
+	prog := newProgress(cmd.ErrOrStderr(), cc.progress)
+	prog.OnStart("gen", len(targets))
+	var all codegen.Files
+	for _, target := range targets {
+		files, err := codegenTargets[target](cc)
+		prog.OnItem(target, err)
+		if err != nil {
+			prog.OnFinish(err)
+			return fmt.Errorf("error generating %s: %w", target, err)
+		}
+		for _, f := range files {
+			f.From = target
+			all = append(all, f)
+		}
+	}
+	prog.OnFinish(nil)
+
+	header := codegen.CommentHeader(fmt.Sprintf(codegenheader, filepath.Base(cc.lla.inputLinFilePath)))
+	changes, err := all.Sync(out, &codegen.SyncConfig{
+		Header: func(f codegen.File) []byte {
+			// Templates are rendered exactly as written
+			if f.From == "templates" {
+				return nil
+			}
+			return header(f)
+		},
+		Check: cc.check,
+	})
+	for _, c := range changes {
+		verb := map[codegen.ChangeKind]string{
+			codegen.ChangeCreate: "generated",
+			codegen.ChangeUpdate: "updated",
+			codegen.ChangeDelete: "deleted stale",
+		}[c.Kind]
+		if cc.check {
+			verb = "would " + string(c.Kind)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", verb, filepath.Join(out, filepath.FromSlash(c.Path)))
+	}
+	return err
+}
+
+// loadConfig decodes the config file onto cfg.
+func (cc *codegenCommand) loadConfig(cfg *codegenConfig) error {
+	b, err := os.ReadFile(cc.config)
+	if err != nil {
+		return err
+	}
+
+	var v cue.Value
+	switch filepath.Ext(cc.config) {
+	case ".yaml", ".yml":
+		f, err := cueyaml.Extract(cc.config, b)
+		if err != nil {
+			return err
+		}
+		v = rt.Context().BuildFile(f)
+	default:
+		v = rt.Context().CompileBytes(b, cue.Filename(cc.config))
+	}
+
+	return decodeConfig(v, cfg, filepath.Dir(cc.config), fmt.Sprintf("config file %s", cc.config))
+}
+
+// loadLineageConfig reads the codegen block declared next to the lineage -
+// that is, the codegen field of the struct containing it - if any. As a
+// lineage at the root of an instance has no siblings, such lineages cannot
+// declare a codegen block.
+//
+// Values from the config file are then reapplied over those in the block.
+func (cc *codegenCommand) loadLineageConfig() error {
+	sels := cue.ParsePath(cc.lla.lincuepath).Selectors()
+	if len(sels) == 0 {
+		return nil
+	}
+	bp := cue.MakePath(append(sels[:len(sels)-1:len(sels)-1], cue.Str("codegen"))...)
+	v := rt.Context().BuildInstance(cc.lla.dl.binst).LookupPath(bp)
+	if !v.Exists() {
+		return nil
+	}
+
+	dir := cc.lla.absInput
+	if !cc.lla.pathIsDir {
+		dir = filepath.Dir(dir)
+	}
+	var cfg codegenConfig
+	if err := decodeConfig(v, &cfg, dir, fmt.Sprintf("codegen block %s", bp)); err != nil {
+		return err
+	}
+	if cc.config != "" {
+		if err := cc.loadConfig(&cfg); err != nil {
+			return err
+		}
+	}
+	cc.cfg = cfg
+	return nil
+}
+
+// decodeConfig decodes v onto cfg, retaining the values of fields absent from
+// v. Relative paths in v are made relative to dir, rather than the working
+// directory.
+func decodeConfig(v cue.Value, cfg *codegenConfig, dir, desc string) error {
+	if err := v.Decode(cfg); err != nil {
+		return fmt.Errorf("invalid %s: %w", desc, err)
+	}
+	for label, p := range map[string]*string{"lineage": &cfg.Lineage, "out": &cfg.Out} {
+		if v.LookupPath(cue.MakePath(cue.Str(label))).Exists() && *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	if v.LookupPath(cue.MakePath(cue.Str("templates"))).Exists() {
+		for i, t := range cfg.Templates {
+			if t.Template != "" && !filepath.IsAbs(t.Template) {
+				cfg.Templates[i].Template = filepath.Join(dir, t.Template)
+			}
+		}
+	}
+	for _, p := range cfg.Exclude {
+		if err := cue.ParsePath(p).Err(); err != nil {
+			return fmt.Errorf("invalid %s: %q is not a valid CUE path: %w", desc, p, err)
+		}
+	}
+	return nil
+}
+
+func (cc *codegenCommand) basename() string {
+	return strings.ToLower(cc.lla.dl.lin.Name())
+}
+
+// exclude returns the paths of the fields excluded from generation.
+func (cc *codegenCommand) exclude() []cue.Path {
+	var paths []cue.Path
+	for _, p := range cc.cfg.Exclude {
+		paths = append(paths, cue.ParsePath(p))
+	}
+	return paths
+}
+
+func (cc *codegenCommand) genGo() (string, []byte, error) {
+	b, err := gocode.GenerateTypesOpenAPI(cc.lla.dl.sch, &gocode.TypeConfigOpenAPI{
+		PackageName: cc.cfg.Go.PkgName,
+		DeepCopy:    cc.cfg.Go.DeepCopy,
+		Getters:     cc.cfg.Go.Getters,
+		Config: &openapi.Config{
+			Exclude: cc.exclude(),
+		},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + "_types_gen.go", b, nil
+}
+
+func (cc *codegenCommand) genTS() (string, []byte, error) {
+	f, err := typescript.GenerateTypes(cc.lla.dl.sch, &typescript.TypeConfig{
+		RootName:   cc.cfg.TS.RootName,
+		RootAsType: cc.cfg.TS.RootAsType,
+		Validators: cc.cfg.TS.Validators,
+		Exclude:    cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + "_types.gen.ts", []byte(f.String()), nil
+}
+
+func (cc *codegenCommand) genRust() (string, []byte, error) {
+	b, err := rust.GenerateTypes(cc.lla.dl.sch, &rust.Config{
+		RootName: cc.cfg.Rust.RootName,
+		Derives:  cc.cfg.Rust.Derives,
+		Exclude:  cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + "_types_gen.rs", b, nil
+}
+
+func (cc *codegenCommand) genPython() (string, []byte, error) {
+	b, err := python.GenerateModels(cc.lla.dl.sch, &python.Config{
+		RootName: cc.cfg.Python.RootName,
+		Exclude:  cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + "_models_gen.py", b, nil
+}
+
+func (cc *codegenCommand) genJava() (string, []byte, error) {
+	b, err := java.GenerateClasses(cc.lla.dl.sch, &java.Config{
+		PackageName:        cc.cfg.Java.Package,
+		ClassName:          cc.cfg.Java.ClassName,
+		NullabilityPackage: cc.cfg.Java.Nullability,
+		Exclude:            cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	name := cc.cfg.Java.ClassName
+	if name == "" {
+		name = java.ClassName(cc.lla.dl.lin)
+	}
+	return name + ".java", b, nil
+}
+
+func (cc *codegenCommand) genJSONSchema() (string, []byte, error) {
+	f, err := jsonschema.GenerateSchemaWithConfig(cc.lla.dl.sch, &jsonschema.Config{
+		Exclude: cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.marshal(f, cc.basename()+".schema", cc.cfg.JSONSchema.Format, "json")
+}
+
+func (cc *codegenCommand) genOpenAPI() (string, []byte, error) {
+	f, err := openapi.GenerateSchema(cc.lla.dl.sch, &openapi.Config{
+		Config: &cueopenapi.Config{
+			ExpandReferences: cc.cfg.OpenAPI.ExpandRefs,
+		},
+		Exclude: cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.marshal(f, cc.basename()+".openapi", cc.cfg.OpenAPI.Format, "yaml")
+}
+
+func (cc *codegenCommand) genCRD() (string, []byte, error) {
+	f, err := crd.GenerateCRD(cc.lla.dl.sch, &crd.Config{
+		Group:   cc.cfg.CRD.Group,
+		Kind:    cc.cfg.CRD.Kind,
+		Plural:  cc.cfg.CRD.Plural,
+		Scope:   cc.cfg.CRD.Scope,
+		Exclude: cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.marshal(f, cc.basename()+".crd", cc.cfg.CRD.Format, "yaml")
+}
+
+func (cc *codegenCommand) genBigQuery() (string, []byte, error) {
+	b, err := bigquery.GenerateSchema(cc.lla.dl.sch, &bigquery.Config{
+		Exclude: cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + ".bigquery.json", b, nil
+}
+
+func (cc *codegenCommand) genParquet() (string, []byte, error) {
+	b, err := parquet.GenerateMessage(cc.lla.dl.sch, &parquet.Config{
+		MessageName: cc.cfg.Parquet.MessageName,
+		Exclude:     cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + ".parquet.schema", b, nil
+}
+
+func (cc *codegenCommand) genArrow() (string, []byte, error) {
+	b, err := parquet.GenerateArrowSchema(cc.lla.dl.sch, &parquet.Config{
+		Exclude: cc.exclude(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return cc.basename() + ".arrow.json", b, nil
+}
+
+// genSQL generates a table for the schema, and migrations to it from each
+// preceding schema in the lineage.
+func (cc *codegenCommand) genSQL() ([]codegen.File, error) {
+	cfg := &sqlddl.Config{
+		Dialect:    sqlddl.Dialect(cc.cfg.SQL.Dialect),
+		Table:      cc.cfg.SQL.Table,
+		DataColumn: cc.cfg.SQL.DataColumn,
+		PrimaryKey: cc.cfg.SQL.PrimaryKey,
+		Exclude:    cc.exclude(),
+	}
+	sch := cc.lla.dl.sch
+	b, err := sqlddl.GenerateTable(sch, cfg)
+	if err != nil {
+		return nil, err
+	}
+	files := []codegen.File{{RelativePath: cc.basename() + ".sql", Data: b}}
+	for to := sch; to.Predecessor() != nil; to = to.Predecessor() {
+		from := to.Predecessor()
+		b, err := sqlddl.GenerateMigration(from, to, cfg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, codegen.File{
+			RelativePath: fmt.Sprintf("migrations/%s_%s_to_%s.sql", cc.basename(), from.Version(), to.Version()),
+			Data:         b,
+		})
+	}
+	return files, nil
+}
+
+// genTemplates executes the configured templates with the data described by
+// [codegen.TemplateData].
+func (cc *codegenCommand) genTemplates() ([]codegen.File, error) {
+	if len(cc.cfg.Templates) == 0 {
+		return nil, fmt.Errorf("no templates configured")
+	}
+	var files []codegen.File
+	for _, t := range cc.cfg.Templates {
+		if t.Template == "" {
+			return nil, fmt.Errorf("template path must be set")
+		}
+		tmpl, err := template.New(filepath.Base(t.Template)).Funcs(codegen.TemplateFuncs()).ParseFiles(t.Template)
+		if err != nil {
+			return nil, err
+		}
+		out := t.Out
+		if out == "" {
+			out = strings.TrimSuffix(filepath.Base(t.Template), ".tmpl")
+		}
+		fs, err := codegen.Template(filepath.Base(t.Template), filepath.ToSlash(out), tmpl, &codegen.TemplateConfig{
+			Exclude: cc.exclude(),
+		}).GenerateSchema(cc.lla.dl.sch)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Template, err)
+		}
+		files = append(files, fs...)
+	}
+	return files, nil
+}
+
+// marshal encodes f in the provided format, or def if empty, returning the
+// filename with the extension for that format.
+func (cc *codegenCommand) marshal(f *ast.File, name, format, def string) (string, []byte, error) {
+	if format == "" {
+		format = def
+	}
+	v := rt.Context().BuildFile(f)
+	switch format {
+	case "json":
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return "", nil, err
+		}
+		nb := new(bytes.Buffer)
+		if err := json.Indent(nb, b, "", "  "); err != nil {
+			return "", nil, err
+		}
+		nb.WriteByte('\n')
+		return name + ".json", nb.Bytes(), nil
+	case "yaml", "yml":
+		str, err := yaml.Marshal(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return name + ".yaml", []byte(str), nil
+	}
+	return "", nil, fmt.Errorf(`unrecognized output format %q - must choose "yaml" or "json"`, format)
+}
+
+// codegenheader is the header stamped on generated files, rendered as a
+// comment in each file's language.
+var codegenheader = `THIS FILE IS GENERATED. EDITING IS FUTILE.
+
+Generated by "thema gen" from lineage defined in %s`
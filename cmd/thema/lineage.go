@@ -33,6 +33,18 @@ func setupLineageCommand(cmd *cobra.Command) {
 
 	fc := new(fixCommand)
 	fc.setup(linCmd)
+
+	dc := new(doctorCommand)
+	dc.setup(linCmd)
+
+	dfc := new(diffCommand)
+	dfc.setup(linCmd)
+
+	rc := new(releaseCommand)
+	rc.setup(linCmd)
+
+	lc := new(logCommand)
+	lc.setup(linCmd)
 }
 
 func toSubpath(subpath string, f *ast.File) (*ast.File, error) {
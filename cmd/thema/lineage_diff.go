@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/thema"
+)
+
+var lineageDiffCmd = &cobra.Command{
+	Use:   "diff -l <path> [-p <cue-path>] [-v <synver>] [--from <synver>]",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Report the differences between two schemas in a lineage",
+	Long: `Report the differences between two schemas in a lineage.
+
+Compare the schema at --from with the schema at --version, and list each field
+that was added, removed, loosened, tightened or retyped.
+
+Removed fields are compared with added fields to suggest likely renames, based
+on the similarity of their names, constraints and doc comments. Renames are
+reported with a score between 0 and 1 and the evidence for them, and should be
+confirmed before relying on them. 'thema lineage gen lens' pre-fills lens stubs
+with the suggested mappings.
+`,
+}
+
+type diffCommand struct {
+	fromstr string
+
+	lla *lineageLoadArgs
+}
+
+func (dc *diffCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageDiffCmd)
+	dc.lla = new(lineageLoadArgs)
+	addLinPathVars(lineageDiffCmd, dc.lla)
+
+	lineageDiffCmd.Flags().StringVarP(&dc.lla.verstr, "version", "v", "", "schema syntactic version to compare to. Defaults to latest")
+	lineageDiffCmd.Flags().StringVar(&dc.fromstr, "from", "", "schema syntactic version to compare from. Defaults to the predecessor of --version")
+	lineageDiffCmd.PreRunE = mergeCobraefuncs(dc.lla.validateLineageInput, dc.lla.validateVersionInputOptional)
+	lineageDiffCmd.RunE = dc.run
+}
+
+func (dc *diffCommand) run(cmd *cobra.Command, args []string) error {
+	to := dc.lla.dl.sch
+	from := to.Predecessor()
+	if dc.fromstr != "" {
+		v, err := thema.ParseSyntacticVersion(dc.fromstr)
+		if err != nil {
+			return err
+		}
+		if from, err = dc.lla.dl.lin.Schema(v); err != nil {
+			return err
+		}
+	}
+	if from == nil {
+		return fmt.Errorf("schema %s has no predecessor; specify --from", to.Version())
+	}
+
+	d := thema.DiffSchemas(from, to)
+	if jsonOutput {
+		return writeJSON(cmd.OutOrStdout(), d)
+	}
+
+	if len(d.Changes) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "no differences between %s and %s\n", d.From, d.To)
+		return nil
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tFIELD\tCONSTRAINT\tNOTE")
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case thema.FieldAdded:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Kind, c.Path, c.To)
+		case thema.FieldRemoved:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Kind, c.Path, c.From)
+		case thema.FieldRenamed:
+			fmt.Fprintf(tw, "%s\t%s -> %s\t%s -> %s\tscore %.2f: %s\n", c.Kind, c.RenamedFrom, c.Path, c.From, c.To, c.Score, strings.Join(c.Reasons, ", "))
+		default:
+			fmt.Fprintf(tw, "%s\t%s\t%s -> %s\n", c.Kind, c.Path, c.From, c.To)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,527 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	cerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/cockroachdb/errors"
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/spf13/cobra"
+)
+
+var lineageDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Diagnose common mistakes in a lineage definition",
+	Long: `Diagnose common mistakes in a lineage definition.
+
+Loads the lineage at --lineage and checks it for common authoring mistakes,
+reporting each with the file and line at which it occurs, and a suggested fix.
+Checks include:
+
+  * imports that do not match the module path in cue.mod/module.cue
+  * a lineage that is not at the root, requiring --path
+  * schema versions that are out of order or skip a version
+  * schemas that are not instances of the lineage's joinSchema
+  * lenses that are missing, or that are not permitted
+  * released schemas that have changed since they were released, if a
+    release manifest is given with --releases
+  * all other failures to bind the lineage
+
+Exits 0 if no problems are found, and 1 otherwise.
+`,
+}
+
+type doctorCommand struct {
+	releases string
+
+	lla *lineageLoadArgs
+}
+
+func (dc *doctorCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageDoctorCmd)
+	dc.lla = new(lineageLoadArgs)
+
+	lineageDoctorCmd.PersistentFlags().StringVarP(&dc.lla.inputLinFilePath, "lineage", "l", ".", "path to .cue file or package containing lineage to diagnose")
+	lineageDoctorCmd.PersistentFlags().StringVarP(&dc.lla.lincuepath, "path", "p", "", "CUE expression for path to the lineage object within file, if not root")
+	lineageDoctorCmd.Flags().StringVar(&dc.releases, "releases", "", "path to a release manifest, written by 'thema lineage release', against which to check released schemas")
+	dc.lla.skipBindLineage = true
+
+	lineageDoctorCmd.RunE = dc.run
+}
+
+// A finding is a single problem diagnosed by the doctor command.
+type finding struct {
+	// Pos is the file:line:col at which the problem occurs, if known.
+	Pos string `json:"pos,omitempty"`
+	// Problem describes what is wrong.
+	Problem string `json:"problem"`
+	// Fix describes how to correct the problem.
+	Fix string `json:"fix"`
+}
+
+type doctor struct {
+	findings []finding
+}
+
+func (d *doctor) add(pos token.Pos, fix, problem string, args ...any) {
+	f := finding{
+		Problem: fmt.Sprintf(problem, args...),
+		Fix:     fix,
+	}
+	if pos.IsValid() {
+		f.Pos = relPos(pos)
+	}
+	d.findings = append(d.findings, f)
+}
+
+// relPos formats pos relative to the working directory, where possible.
+func relPos(pos token.Pos) string {
+	fname := pos.Filename()
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, fname); err == nil && !strings.HasPrefix(rel, "..") {
+			fname = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", fname, pos.Line(), pos.Column())
+}
+
+func (dc *doctorCommand) run(cmd *cobra.Command, args []string) error {
+	d := new(doctor)
+	dc.diagnose(d)
+
+	if jsonOutput {
+		if err := writeJSON(cmd.OutOrStdout(), struct {
+			OK       bool      `json:"ok"`
+			Findings []finding `json:"findings"`
+		}{len(d.findings) == 0, append([]finding{}, d.findings...)}); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range d.findings {
+			if f.Pos != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: ", f.Pos)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n\tfix: %s\n", f.Problem, strings.ReplaceAll(f.Fix, "\n", "\n\t"))
+		}
+		if len(d.findings) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "no problems found")
+		}
+	}
+
+	if len(d.findings) > 0 {
+		return errReported
+	}
+	return nil
+}
+
+func (dc *doctorCommand) diagnose(d *doctor) {
+	dl, err := dc.lla.dynLoad()
+	if err != nil {
+		d.addErr(err, "ensure --lineage points to a .cue file or directory containing a lineage, and that any cue.mod/module.cue is valid")
+		return
+	}
+
+	if dl.binst.Err != nil {
+		d.checkImports(dl)
+		return
+	}
+
+	var m *thema.ReleaseManifest
+	if dc.releases != "" {
+		if m, err = readReleaseManifest(dc.releases); err != nil {
+			d.add(token.NoPos, "pass the path to a release manifest written by 'thema lineage release'", "%s", err)
+			return
+		}
+	}
+	d.findings = append(d.findings, diagnose(rt.Context().BuildInstance(dl.binst), dc.lla.lincuepath, m)...)
+}
+
+// diagnose checks the lineage at path within v, the value of a loaded
+// instance, for common authoring mistakes. Released schemas are checked
+// against m, if it is non-nil.
+func diagnose(v cue.Value, path string, m *thema.ReleaseManifest) []finding {
+	d := new(doctor)
+	d.diagnoseValue(v, path, m)
+	return d.findings
+}
+
+func (d *doctor) diagnoseValue(v cue.Value, path string, m *thema.ReleaseManifest) {
+	if err := v.Err(); err != nil {
+		d.checkEvalErr(err)
+		return
+	}
+
+	if path != "" {
+		p := cue.ParsePath(path)
+		if p.Err() != nil {
+			d.add(token.NoPos, "pass a valid CUE path expression, such as \"lin\" or \"kinds.foo\"", "--path %q is not a valid CUE path", path)
+			return
+		}
+		if v = v.LookupPath(p); !v.Exists() {
+			d.add(token.NoPos, "pass the path to the field containing the lineage", "no value at --path %q", path)
+			return
+		}
+	}
+	if !v.LookupPath(cue.ParsePath("schemas")).Exists() {
+		d.findLineage(v)
+		return
+	}
+
+	vers, poss, ok := d.checkVersions(v)
+	if !ok {
+		return
+	}
+	d.checkJoinSchema(v)
+	d.checkLenses(v, vers, poss)
+	if len(d.findings) > 0 {
+		// Binding would fail for the same reasons, less informatively
+		return
+	}
+
+	var opts []thema.BindOption
+	if m != nil {
+		opts = append(opts, thema.EnforceReleases(m))
+	}
+
+	if _, err := thema.BindLineage(v, rt, opts...); err != nil {
+		var serr *thema.SchemaError
+		if errors.As(err, &serr) {
+			for i, ver := range vers {
+				if ver == serr.Version {
+					d.add(poss[i], bindFix(err), "%s", strings.TrimSpace(err.Error()))
+					return
+				}
+			}
+		}
+		d.addErr(err, bindFix(err))
+	}
+}
+
+// addErr adds a finding for each distinct error within err.
+func (d *doctor) addErr(err error, fix string) {
+	errs := cerrors.Errors(err)
+	if len(errs) == 0 {
+		d.add(token.NoPos, fix, "%s", err)
+		return
+	}
+	seen := make(map[string]bool)
+	for _, e := range errs {
+		msg := errMsg(e)
+		if msg == "" {
+			// Not a CUE error, such as those returned from BindLineage
+			msg = e.Error()
+		}
+		if p := e.Path(); len(p) > 0 {
+			msg = strings.Join(p, ".") + ": " + msg
+		}
+		if !seen[msg] {
+			seen[msg] = true
+			d.add(userPos(e), fix, "%s", msg)
+		}
+	}
+}
+
+// checkEvalErr diagnoses errors from evaluating the instance containing the
+// lineage. Where a lineage is declared as an instance of thema.#Lineage, this
+// is where a schema's conflict with joinSchema surfaces.
+func (d *doctor) checkEvalErr(err error) {
+	seen := make(map[string]bool)
+	for _, e := range cerrors.Errors(err) {
+		path, msg := e.Path(), errMsg(e)
+		for i := 1; i < len(path); i++ {
+			if path[i-1] != "schemas" || i+1 >= len(path) || path[i+1] != "_#schema" {
+				continue
+			}
+			msg = fmt.Sprintf("schema at index %s is not an instance of the lineage's joinSchema: %s: %s", path[i], strings.Join(path[i+2:], "."), msg)
+			if !seen[msg] {
+				seen[msg] = true
+				d.add(userPos(e), "joinSchema must admit every schema in the lineage: change the schema, or widen joinSchema, e.g. by leaving it open with \"...\"", "%s", msg)
+			}
+			break
+		}
+	}
+	if len(d.findings) == 0 {
+		d.addErr(err, "correct the CUE syntax or evaluation error")
+	}
+}
+
+func errMsg(e cerrors.Error) string {
+	format, args := e.Msg()
+	return fmt.Sprintf(format, args...)
+}
+
+// valuePos returns the position at which v is declared in the user's own
+// files, rather than in the thema module, where one is available.
+func valuePos(v cue.Value) token.Pos {
+	if p := v.Pos(); isUserPos(p) {
+		return p
+	}
+	if op, args := v.Expr(); op == cue.AndOp {
+		for _, arg := range args {
+			if p := valuePos(arg); isUserPos(p) {
+				return p
+			}
+		}
+	}
+	return v.Pos()
+}
+
+func isUserPos(p token.Pos) bool {
+	return p.IsValid() && !strings.Contains(filepath.ToSlash(p.Filename()), filepath.ToSlash(themamodpath)+"/")
+}
+
+// userPos returns the position of e in the user's own files, rather than in
+// the thema module, where one is available.
+func userPos(e cerrors.Error) token.Pos {
+	for _, p := range append([]token.Pos{e.Position()}, e.InputPositions()...) {
+		if isUserPos(p) {
+			return p
+		}
+	}
+	return e.Position()
+}
+
+func bindFix(err error) string {
+	switch {
+	case errors.Is(err, terrors.ErrInvalidSchemasOrder):
+		return "order the schemas list ascending by version"
+	case errors.Is(err, terrors.ErrReleasedSchemaChanged):
+		return "revert the changes to released schemas, and make them in a new schema version instead"
+	case errors.Is(err, terrors.ErrInvalidLensesOrder):
+		return "order the lenses list ascending by 'to' version, then by 'from' version"
+	case errors.Is(err, terrors.ErrMajorNotBreaking):
+		return "a new major version must make a breaking change; otherwise, declare it as the next minor version"
+	case errors.Is(err, terrors.ErrMinorNotCompatible):
+		return "a new minor version must be backwards compatible; otherwise, declare it as the next major version and add lenses to and from it"
+	}
+	return "correct the lineage so that it is a valid instance of thema.#Lineage"
+}
+
+// checkImports diagnoses failures to resolve imports, most often arising from
+// a mismatch between import paths and the module path declared in
+// cue.mod/module.cue.
+func (d *doctor) checkImports(dl *dynamicLoader) {
+	for _, e := range cerrors.Errors(dl.binst.Err) {
+		// Import errors wrap the cause, which is omitted from Msg
+		msg := e.Error()
+		if !strings.Contains(msg, "cannot find package") {
+			d.add(e.Position(), "correct the CUE syntax or loading error", "%s", msg)
+			continue
+		}
+
+		ipath := importPathIn(msg)
+		switch {
+		case dl.cm == nil:
+			d.add(e.Position(), "create a cue.mod/module.cue at the root of your CUE module, declaring its module path, e.g. module: \"example.com/mymod\"",
+				"cannot resolve import %q, as no cue.mod directory was found", ipath)
+		case ipath != "" && !strings.HasPrefix(ipath, dl.cm.modname+"/") && ipath != dl.cm.modname:
+			d.add(e.Position(), fmt.Sprintf("change the import to begin with %q, or correct the module field in %s", dl.cm.modname, filepath.Join(dl.cm.cuemodparentdir, "cue.mod", "module.cue")),
+				"import %q is not within module %q declared in cue.mod/module.cue", ipath, dl.cm.modname)
+		default:
+			rel := strings.TrimPrefix(strings.TrimPrefix(ipath, dl.cm.modname), "/")
+			d.add(e.Position(), fmt.Sprintf("create the package at %s, or correct the import path", filepath.Join(dl.cm.cuemodparentdir, rel)),
+				"import %q does not refer to a package in module %q", ipath, dl.cm.modname)
+		}
+	}
+}
+
+// importPathIn extracts the quoted import path from a "cannot find package"
+// error message.
+func importPathIn(msg string) string {
+	if i := strings.Index(msg, "\""); i >= 0 {
+		if j := strings.Index(msg[i+1:], "\""); j >= 0 {
+			return msg[i+1 : i+1+j]
+		}
+	}
+	return ""
+}
+
+// findLineage searches v for fields that look like lineages, suggesting the
+// --path at which to find them.
+func (d *doctor) findLineage(v cue.Value) {
+	var paths []string
+	var walk func(v cue.Value, path []string)
+	walk = func(v cue.Value, path []string) {
+		if len(path) > 3 {
+			return
+		}
+		iter, err := v.Fields()
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			fpath := append(path[:len(path):len(path)], iter.Selector().String())
+			fv := iter.Value()
+			if fv.LookupPath(cue.ParsePath("schemas")).Exists() && fv.LookupPath(cue.ParsePath("name")).Exists() {
+				paths = append(paths, strings.Join(fpath, "."))
+				continue
+			}
+			walk(fv, fpath)
+		}
+	}
+	walk(v, nil)
+
+	switch len(paths) {
+	case 0:
+		d.add(valuePos(v), "declare a lineage with name and schemas fields, e.g. by running \"thema lineage init\"", "no lineage found")
+	case 1:
+		d.add(valuePos(v), fmt.Sprintf("pass --path %s", paths[0]), "lineage is not at the root of the instance; found a lineage at %s", paths[0])
+	default:
+		d.add(valuePos(v), "pass --path with one of the found paths", "lineage is not at the root of the instance; found lineages at %s", strings.Join(paths, ", "))
+	}
+}
+
+// checkVersions checks that schema versions begin at 0.0 and are contiguous,
+// returning the versions in declaration order along with the position at which
+// each is declared. false is returned if the versions could not be checked at
+// all.
+func (d *doctor) checkVersions(v cue.Value) ([]thema.SyntacticVersion, []token.Pos, bool) {
+	iter, err := v.LookupPath(cue.ParsePath("schemas")).List()
+	if err != nil {
+		d.add(valuePos(v.LookupPath(cue.ParsePath("schemas"))), "declare schemas as a list of schema definitions", "schemas is not a list")
+		return nil, nil, false
+	}
+
+	var vers []thema.SyntacticVersion
+	var poss []token.Pos
+	for iter.Next() {
+		vv := iter.Value().LookupPath(cue.ParsePath("version"))
+		var sv thema.SyntacticVersion
+		if !vv.Exists() {
+			d.add(valuePos(iter.Value()), "declare the version of every schema explicitly, e.g. version: [0, 0]", "schema at index %s has no version", iter.Selector())
+			return nil, nil, false
+		}
+		if err := vv.Decode(&sv); err != nil {
+			d.add(valuePos(vv), "declare versions as a list of two concrete, non-negative integers, e.g. version: [0, 0]", "schema at index %s has an invalid version: %s", iter.Selector(), err)
+			return nil, nil, false
+		}
+
+		if len(vers) == 0 {
+			if sv != thema.SV(0, 0) {
+				d.add(valuePos(vv), "renumber the schemas so that the first is 0.0", "the first schema is version %s, but must be 0.0", sv)
+			}
+		} else {
+			prev := vers[len(vers)-1]
+			nextMinor, nextMajor := thema.SV(prev[0], prev[1]+1), thema.SV(prev[0]+1, 0)
+			switch {
+			case !prev.Less(sv):
+				d.add(valuePos(vv), "order the schemas list ascending by version, and ensure no version is declared twice", "schema version %s follows %s, but must be greater than it", sv, prev)
+			case sv != nextMinor && sv != nextMajor:
+				d.add(valuePos(vv), fmt.Sprintf("renumber it as %s if it is backwards compatible with %s, or as %s if it is not", nextMinor, prev, nextMajor),
+					"schema version %s is not contiguous with its predecessor %s", sv, prev)
+			}
+		}
+		vers = append(vers, sv)
+		poss = append(poss, valuePos(vv))
+	}
+	return vers, poss, true
+}
+
+// checkJoinSchema checks that each schema is an instance of the joinSchema,
+// if one is declared.
+func (d *doctor) checkJoinSchema(v cue.Value) {
+	js := v.LookupPath(cue.ParsePath("joinSchema"))
+	if !js.Exists() || js.IncompleteKind() == cue.TopKind {
+		return
+	}
+	if js.IncompleteKind() != cue.StructKind {
+		d.add(valuePos(js), "declare joinSchema as a struct, e.g. joinSchema: { ... }, or remove it", "joinSchema must be a struct, but is %s", js.IncompleteKind())
+		return
+	}
+
+	iter, _ := v.LookupPath(cue.ParsePath("schemas")).List()
+	for iter.Next() {
+		sch := iter.Value().LookupPath(cue.ParsePath("schema"))
+		if err := js.Unify(sch).Validate(); err != nil {
+			var ver thema.SyntacticVersion
+			iter.Value().LookupPath(cue.ParsePath("version")).Decode(&ver) // nolint: errcheck
+			d.add(valuePos(sch), "joinSchema must admit every schema in the lineage: change the schema, or widen joinSchema, e.g. by leaving it open with \"...\"",
+				"schema %s is not an instance of the lineage's joinSchema: %s", ver, cerrors.Details(err, nil))
+		}
+	}
+}
+
+// checkLenses checks that exactly the lenses required by the schema versions
+// are declared. Missing lenses are reported at the position of the version
+// that requires them.
+func (d *doctor) checkLenses(v cue.Value, vers []thema.SyntacticVersion, poss []token.Pos) {
+	type lensID struct{ from, to thema.SyntacticVersion }
+	declared := make(map[lensID]token.Pos)
+	lv := v.LookupPath(cue.ParsePath("lenses"))
+	if iter, err := lv.List(); err == nil {
+		for iter.Next() {
+			var id lensID
+			if iter.Value().LookupPath(cue.ParsePath("from")).Decode(&id.from) != nil || iter.Value().LookupPath(cue.ParsePath("to")).Decode(&id.to) != nil {
+				d.add(valuePos(iter.Value()), "declare the from and to fields of every lens as concrete versions, e.g. from: [0, 1]", "lens at index %s has an invalid from or to version", iter.Selector())
+				continue
+			}
+			declared[id] = valuePos(iter.Value())
+		}
+	}
+
+	required := make(map[lensID]bool)
+	for i := 1; i < len(vers); i++ {
+		prev, curr := vers[i-1], vers[i]
+		ids := []lensID{{from: curr, to: prev}}
+		if curr[0] != prev[0] {
+			ids = append(ids, lensID{from: prev, to: curr})
+		}
+		for _, id := range ids {
+			required[id] = true
+			if _, has := declared[id]; !has {
+				d.add(poss[i], fmt.Sprintf("add a lens to the lenses list:\n\n%s", lensStub(id.from, id.to)),
+					"missing lens from %s to %s", id.from, id.to)
+			}
+		}
+	}
+
+	for i := range vers {
+		for j := range vers {
+			id := lensID{from: vers[i], to: vers[j]}
+			p, has := declared[id]
+			if !has || required[id] {
+				continue
+			}
+			switch {
+			case id.from == id.to:
+				d.add(p, "remove the lens", "lens from %s to itself is not permitted", id.from)
+			case id.from[0] == id.to[0] && id.from.Less(id.to):
+				d.add(p, "remove the lens; translation to a newer minor version is implicit", "lens from %s to %s is not permitted", id.from, id.to)
+			default:
+				d.add(p, "remove the lens; lenses may only connect adjacent versions", "lens from %s to %s does not connect adjacent versions", id.from, id.to)
+			}
+		}
+	}
+	for id, p := range declared {
+		if !containsVersion(vers, id.from) || !containsVersion(vers, id.to) {
+			d.add(p, "remove the lens, or correct its versions", "lens from %s to %s refers to a schema version that does not exist", id.from, id.to)
+		}
+	}
+}
+
+func containsVersion(vers []thema.SyntacticVersion, v thema.SyntacticVersion) bool {
+	for _, ver := range vers {
+		if ver == v {
+			return true
+		}
+	}
+	return false
+}
+
+func lensStub(from, to thema.SyntacticVersion) string {
+	return fmt.Sprintf(`{
+	from: [%d, %d]
+	to: [%d, %d]
+	input: _
+	result: {
+		// map the fields of input to the schema %s
+	}
+}`, from[0], from[1], to[0], to[1], to)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	changed := &thema.ReleaseManifest{Lineages: map[string][]thema.ReleasedSchema{
+		"foo": {{Version: thema.SV(0, 0), Checksum: "sha256:0000"}},
+	}}
+
+	table := []struct {
+		name     string
+		src      string
+		path     string
+		releases *thema.ReleaseManifest
+		// want holds the position and problem of each expected finding, in
+		// order. Positions are given as line:col within the source.
+		want [][2]string
+	}{
+		{
+			name: "valid",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}]`,
+		},
+		{
+			name: "evaluation error",
+			src:  `a: 1 & 2`,
+			want: [][2]string{{"1:8", "a: conflicting values 2 and 1"}},
+		},
+		{
+			name: "invalid path",
+			src:  `lin: {}`,
+			path: "lin.",
+			want: [][2]string{{"", `--path "lin." is not a valid CUE path`}},
+		},
+		{
+			name: "no value at path",
+			src:  `lin: {}`,
+			path: "nope",
+			want: [][2]string{{"", `no value at --path "nope"`}},
+		},
+		{
+			name: "no lineage",
+			src:  `a: 1`,
+			want: [][2]string{{"1:1", "no lineage found"}},
+		},
+		{
+			name: "lineage not at root",
+			src:  `kinds: foo: {name: "foo", schemas: []}`,
+			want: [][2]string{{"1:1", "lineage is not at the root of the instance; found a lineage at kinds.foo"}},
+		},
+		{
+			name: "first version not 0.0",
+			src: `name: "foo"
+schemas: [{version: [0, 1], schema: a: string}]`,
+			want: [][2]string{{"2:12", "the first schema is version 0.1, but must be 0.0"}},
+		},
+		{
+			name: "missing version",
+			src: `name: "foo"
+schemas: [{schema: a: string}]`,
+			want: [][2]string{{"2:11", "schema at index 0 has no version"}},
+		},
+		{
+			name: "versions out of order",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [0, 0], schema: a: string}]`,
+			want: [][2]string{
+				{"2:50", "schema version 0.0 follows 0.0, but must be greater than it"},
+				{"2:50", "missing lens from 0.0 to 0.0"},
+			},
+		},
+		{
+			name: "version skipped",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [0, 2], schema: {a: string, b?: int}}]
+lenses: [{from: [0, 2], to: [0, 0], input: _, result: a: input.a}]`,
+			want: [][2]string{{"2:50", "schema version 0.2 is not contiguous with its predecessor 0.0"}},
+		},
+		{
+			name: "not an instance of joinSchema",
+			src: `name: "foo"
+joinSchema: a: string
+schemas: [{version: [0, 0], schema: a: int}]`,
+			want: [][2]string{{"3:29", "schema 0.0 is not an instance of the lineage's joinSchema: joinSchema.a: conflicting values string and int (mismatched types string and int):\n    lineage.cue:2:16\n    lineage.cue:3:40\n"}},
+		},
+		{
+			name: "missing lenses",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [1, 0], schema: b: string}]`,
+			want: [][2]string{
+				{"2:50", "missing lens from 1.0 to 0.0"},
+				{"2:50", "missing lens from 0.0 to 1.0"},
+			},
+		},
+		{
+			name: "lens to newer minor",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [0, 1], schema: {a: string, b?: int}}]
+lenses: [{from: [0, 1], to: [0, 0], input: _, result: a: input.a}, {from: [0, 0], to: [0, 1], input: _, result: a: input.a}]`,
+			want: [][2]string{{"3:68", "lens from 0.0 to 0.1 is not permitted"}},
+		},
+		{
+			name: "lens to nonexistent version",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}]
+lenses: [{from: [0, 1], to: [0, 0], input: _, result: a: input.a}]`,
+			want: [][2]string{{"3:10", "lens from 0.1 to 0.0 refers to a schema version that does not exist"}},
+		},
+		{
+			name: "major version not breaking",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [1, 0], schema: {a: string, b?: int}}]
+lenses: [{from: [1, 0], to: [0, 0], input: _, result: a: input.a}, {from: [0, 0], to: [1, 0], input: _, result: a: input.a}]`,
+			want: [][2]string{{"2:50", "schema 1.0 must be backwards incompatible with schema 0.0: introduce a breaking change, or redeclare as version 0.1"}},
+		},
+		{
+			name: "minor version not compatible",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [0, 1], schema: b: string}]
+lenses: [{from: [0, 1], to: [0, 0], input: _, result: a: input.b}]`,
+			want: [][2]string{{"2:50", "schema 0.1 is not backwards compatible with schema 0.0:"}},
+		},
+		{
+			name: "released schema changed",
+			src: `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}]`,
+			releases: changed,
+			want:     [][2]string{{"", `lineage "foo": released schema 0.0 has checksum sha256:`}},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			v := rt.Context().CompileString(tt.src, cue.Filename("lineage.cue"))
+			findings := diagnose(v, tt.path, tt.releases)
+			require.Len(t, findings, len(tt.want), "findings: %v", findings)
+			for i, f := range findings {
+				want := tt.want[i]
+				if want[0] != "" {
+					want[0] = "lineage.cue:" + want[0]
+				}
+				assert.Equal(t, want[0], f.Pos)
+				assert.Contains(t, f.Problem, want[1])
+				assert.NotEmpty(t, f.Fix)
+			}
+		})
+	}
+}
+
+func TestBindFix(t *testing.T) {
+	for name, src := range map[string]string{
+		"a new major version must make a breaking change; otherwise, declare it as the next minor version": `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [1, 0], schema: {a: string, b?: int}}]
+lenses: [{from: [1, 0], to: [0, 0], input: _, result: a: input.a}, {from: [0, 0], to: [1, 0], input: _, result: a: input.a}]`,
+		"a new minor version must be backwards compatible; otherwise, declare it as the next major version and add lenses to and from it": `name: "foo"
+schemas: [{version: [0, 0], schema: a: string}, {version: [0, 1], schema: b: string}]
+lenses: [{from: [0, 1], to: [0, 0], input: _, result: a: input.b}]`,
+	} {
+		findings := diagnose(rt.Context().CompileString(src), "", nil)
+		require.Len(t, findings, 1)
+		assert.Equal(t, name, findings[0].Fix)
+	}
+}
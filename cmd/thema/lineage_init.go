@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	upcue "cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
@@ -17,6 +18,7 @@ import (
 	"cuelang.org/go/encoding/yaml"
 	"github.com/grafana/thema/encoding/cue"
 	tastutil "github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/vmux"
 	"github.com/spf13/cobra"
 )
 
@@ -54,6 +56,10 @@ func (ic *initCommand) setup(cmd *cobra.Command) {
 	initLineageJSONSchemaCmd.Flags().StringVar(&ic.srcpath, "src-subpath", "", "Schema path within the JSON Schema document (e.g. #/...) Default: whole document")
 	initLineageJSONSchemaCmd.Run = ic.run
 	initLineageJSONSchemaCmd.PreRunE = ic.processInput
+
+	initLineageCmd.AddCommand(initLineageInferCmd)
+	initLineageInferCmd.Run = ic.run
+	initLineageInferCmd.PreRunE = ic.processPackageArgs
 }
 
 var initLineageCmd = &cobra.Command{
@@ -101,6 +107,26 @@ The generated lineage is printed to stdout.
 `,
 }
 
+var initLineageInferCmd = &cobra.Command{
+	Use:   "infer <path>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Initialize with a schema inferred from example data",
+	Long: `Initialize the lineage with one schema, inferred from a corpus of example data.
+
+Paths to one or more JSON or YAML files must be given as arguments, each
+containing a single object. Files with a .yaml or .yml extension are read as
+YAML, and all others as JSON.
+
+The inferred schema declares each field with the types observed for it, and
+makes optional any field absent from some objects. Comments on each field
+describe how often it was present, and the range of numbers or set of strings
+observed for it. The schema is a starting point, and should be reviewed and
+tightened by hand before the lineage is published.
+
+The generated lineage is printed to stdout.
+`,
+}
+
 func (ic *initCommand) run(cmd *cobra.Command, args []string) {
 	switch cmd.CalledAs() {
 	case "empty":
@@ -109,6 +135,8 @@ func (ic *initCommand) run(cmd *cobra.Command, args []string) {
 		ic.runJSONSchema(cmd, args)
 	case "openapi":
 		ic.runOpenAPI(cmd, args)
+	case "infer":
+		ic.runInfer(cmd, args)
 	default:
 		panic(fmt.Sprint("unrecognized command ", cmd.CalledAs()))
 	}
@@ -360,3 +388,49 @@ func (ic *initCommand) runOpenAPI(cmd *cobra.Command, args []string) {
 
 	fmt.Fprint(cmd.OutOrStdout(), string(b))
 }
+
+func (ic *initCommand) runInfer(cmd *cobra.Command, args []string) {
+	docs := make([]upcue.Value, 0, len(args))
+	for _, path := range args {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			ic.err = err
+			return
+		}
+		codec := vmux.NewJSONCodec(path)
+		if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+			codec = vmux.NewYAMLCodec(path)
+		}
+		v, err := codec.Decode(ctx, b)
+		if err != nil {
+			ic.err = err
+			return
+		}
+		docs = append(docs, v)
+	}
+
+	x, err := cue.InferSchema(docs)
+	if err != nil {
+		ic.err = err
+		return
+	}
+	linf, err := cue.NewLineage(ctx.BuildExpr(x), ic.name, ic.pkgname)
+	if err != nil {
+		ic.err = err
+		return
+	}
+
+	linf, err = toSubpath(ic.cuepath, linf)
+	if err != nil {
+		ic.err = err
+		return
+	}
+
+	b, err := tastutil.FmtNode(linf)
+	if err != nil {
+		ic.err = err
+		return
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), string(b))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/thema"
+)
+
+var lineageLogCmd = &cobra.Command{
+	Use:   "log -l <path> [-p <cue-path>] [--markdown]",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Show the change notes of each schema in a lineage",
+	Long: `Show the change notes of each schema in a lineage.
+
+List the change notes declared in the 'changes' field of each schema, newest
+schema first. With --markdown, the notes are instead rendered as a Markdown
+changelog, suitable for inclusion in generated documentation.
+`,
+}
+
+type logCommand struct {
+	markdown bool
+
+	lla *lineageLoadArgs
+}
+
+func (lc *logCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageLogCmd)
+	lc.lla = new(lineageLoadArgs)
+	addLinPathVars(lineageLogCmd, lc.lla)
+
+	lineageLogCmd.Flags().BoolVar(&lc.markdown, "markdown", false, "render the change notes as a Markdown changelog")
+	lineageLogCmd.PreRunE = lc.lla.validateLineageInput
+	lineageLogCmd.RunE = lc.run
+}
+
+func (lc *logCommand) run(cmd *cobra.Command, args []string) error {
+	lin := lc.lla.dl.lin
+	if lc.markdown {
+		return thema.WriteChangelog(cmd.OutOrStdout(), lin)
+	}
+
+	type entry struct {
+		Version  thema.SyntacticVersion `json:"version"`
+		Maturity thema.Maturity         `json:"maturity"`
+		Notes    []thema.ChangeNote     `json:"notes"`
+	}
+	var entries []entry
+	all := lin.All()
+	for i := len(all) - 1; i >= 0; i-- {
+		entries = append(entries, entry{
+			Version:  all[i].Version(),
+			Maturity: all[i].Maturity(),
+			Notes:    append([]thema.ChangeNote{}, all[i].ReleaseNotes()...),
+		})
+	}
+	if jsonOutput {
+		return writeJSON(cmd.OutOrStdout(), entries)
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s (%s)\n", e.Version, e.Maturity)
+		for _, n := range e.Notes {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s: %s", n.Kind, n.Message)
+			if len(n.Fields) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), " [%s]", strings.Join(n.Fields, ", "))
+			}
+			if n.Issue != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), " (%s)", n.Issue)
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+	}
+	return nil
+}
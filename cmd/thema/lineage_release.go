@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/thema"
+)
+
+var lineageReleaseCmd = &cobra.Command{
+	Use:   "release -l <path> [-p <cue-path>] [-v <synver>] --releases <manifest> [--name <release>]",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Mark schemas in a lineage as released",
+	Long: `Mark schemas in a lineage as released.
+
+Record every schema in the lineage up to and including --version, which
+defaults to the latest, as released in the release manifest at --releases,
+creating it if it does not exist. Each schema is recorded with a checksum, and
+with the release named by --name, if given. Schemas already recorded are left
+untouched.
+
+Once committed, the manifest makes released schemas immutable: 'thema lineage
+doctor --releases' and programs binding the lineage with
+thema.EnforceReleases reject any change to them. Fails without writing the
+manifest if released schemas have already changed.
+`,
+}
+
+type releaseCommand struct {
+	manifest string
+	name     string
+
+	lla *lineageLoadArgs
+}
+
+func (rc *releaseCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageReleaseCmd)
+	rc.lla = new(lineageLoadArgs)
+	addLinPathVars(lineageReleaseCmd, rc.lla)
+
+	lineageReleaseCmd.Flags().StringVarP(&rc.lla.verstr, "version", "v", "", "schema syntactic version through which to mark schemas as released. Defaults to latest")
+	lineageReleaseCmd.Flags().StringVar(&rc.manifest, "releases", "", "path to the release manifest to update")
+	lineageReleaseCmd.MarkFlagRequired("releases") // nolint: errcheck
+	lineageReleaseCmd.Flags().StringVar(&rc.name, "name", "", "name of the release, such as a product version, to record against newly released schemas")
+	lineageReleaseCmd.PreRunE = mergeCobraefuncs(rc.lla.validateLineageInput, rc.lla.validateVersionInputOptional)
+	lineageReleaseCmd.RunE = rc.run
+}
+
+func (rc *releaseCommand) run(cmd *cobra.Command, args []string) error {
+	m, err := readReleaseManifest(rc.manifest)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		m = &thema.ReleaseManifest{}
+	case err != nil:
+		return err
+	}
+
+	lin, sch := rc.lla.dl.lin, rc.lla.dl.sch
+	if err := m.Release(lin, sch.Version(), rc.name); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(rc.manifest, buf.Bytes(), 0644); err != nil { // nolint: gosec
+		return err
+	}
+
+	if jsonOutput {
+		return writeJSON(cmd.OutOrStdout(), m.Lineages[lin.Name()])
+	}
+	for _, rs := range m.Lineages[lin.Name()] {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", rs.Version, rs.Checksum, rs.Release)
+	}
+	return nil
+}
+
+// readReleaseManifest reads the release manifest at the provided path.
+func readReleaseManifest(path string) (*thema.ReleaseManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+	return thema.ReadReleaseManifest(f)
+}
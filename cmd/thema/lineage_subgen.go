@@ -17,9 +17,11 @@ import (
 	"golang.org/x/mod/modfile"
 
 	"github.com/grafana/thema"
+	cuenc "github.com/grafana/thema/encoding/cue"
 	"github.com/grafana/thema/encoding/gocode"
 	"github.com/grafana/thema/encoding/jsonschema"
 	"github.com/grafana/thema/encoding/openapi"
+	tastutil "github.com/grafana/thema/internal/astutil"
 )
 
 type genCommand struct {
@@ -47,6 +49,14 @@ type genCommand struct {
 	bindtype string
 	// go package name to target
 	pkgname string
+	// generate DeepCopy and getter methods for go types
+	deepcopy, getters bool
+	// format string naming the go type for each schema version
+	typefmt string
+	// schema version to generate a lens from
+	fromstr string
+	// synthesize a complete identity lens instead of a stub
+	identity bool
 	// path for embedding
 	epath string
 
@@ -73,9 +83,11 @@ func (gc *genCommand) setup(cmd *cobra.Command) {
 
 	ggt := genGoTypesLineageCmd
 	genLineageCmd.AddCommand(ggt)
-	ggt.Use = "gotypes -l <path> [-p <cue-path>] [-v <synver>] [--pkgname <name>] [--stdout]"
+	ggt.Use = "gotypes -l <path> [-p <cue-path>] [-v <synver>] [--pkgname <name>] [--deepcopy] [--getters] [--stdout]"
 	ggt.Flags().StringVarP(&gc.lla.verstr, "version", "v", "", "schema syntactic version to generate. Defaults to latest")
 	ggt.Flags().StringVar(&gc.pkgname, "pkgname", "", "Name for generated Go package. Defaults to lowercase lineage name")
+	ggt.Flags().BoolVar(&gc.deepcopy, "deepcopy", false, "Generate DeepCopy and DeepCopyInto methods for each type")
+	ggt.Flags().BoolVar(&gc.getters, "getters", false, "Generate a Get<Field> accessor method for each struct field")
 	ggt.Flags().BoolVar(&gc.noembed, "stdout", false, "Write to stdout instead of '<lineage.name>_types_gen.go'")
 	ggt.Flags().BoolVarP(&gc.quiet, "quiet", "q", false, "Do not print generated filename")
 	ggt.Run = gc.run
@@ -94,6 +106,21 @@ func (gc *genCommand) setup(cmd *cobra.Command) {
 	ggb.Flags().BoolVarP(&gc.quiet, "quiet", "q", false, "Do not print generated filename")
 	ggb.Run = gc.run
 
+	ggtr := genGoTranslatorsLineageCmd
+	genLineageCmd.AddCommand(ggtr)
+	ggtr.Flags().StringVar(&gc.pkgname, "pkgname", "", "Name for generated Go package. Defaults to lowercase lineage name")
+	ggtr.Flags().StringVar(&gc.typefmt, "type-format", "", "Format string for the name of the Go type of each schema, given its major and minor version, e.g. \"FooV%d_%d\"")
+	ggtr.Flags().BoolVar(&gc.stdout, "stdout", false, "Write to stdout instead of '<lineage.name>_translate_gen.go'")
+	ggtr.Flags().BoolVarP(&gc.quiet, "quiet", "q", false, "Do not print generated filename")
+	ggtr.Run = gc.run
+
+	gl := genLensLineageCmd
+	genLineageCmd.AddCommand(gl)
+	gl.Flags().StringVarP(&gc.lla.verstr, "version", "v", "", "schema syntactic version the lens translates to. Defaults to latest")
+	gl.Flags().StringVar(&gc.fromstr, "from", "", "schema syntactic version the lens translates from. Defaults to the predecessor of --version")
+	gl.Flags().BoolVar(&gc.identity, "identity", false, "Synthesize a complete identity lens, failing if the schemas differ by more than compatible changes to constraints")
+	gl.Run = gc.run
+
 	// TODO
 	// genLineageCmd.AddCommand(genTSTypesLineageCmd)
 	// genTSTypesLineageCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to generate. Defaults to latest")
@@ -125,8 +152,12 @@ func (gc *genCommand) run(cmd *cobra.Command, args []string) {
 		err = gc.runGoTypes(cmd, args)
 	case "gobindings":
 		err = gc.runGoBindings(cmd, args)
+	case "gotranslators":
+		err = gc.runGoTranslators(cmd, args)
 	case "tstypes":
 		err = gc.runTSTypes(cmd, args)
+	case "lens":
+		err = gc.runLens(cmd, args)
 	default:
 		panic(fmt.Sprint("unrecognized command ", cmd.CalledAs()))
 	}
@@ -191,6 +222,60 @@ func (gc *genCommand) runOpenAPI(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var genLensLineageCmd = &cobra.Command{
+	Use:   "lens -l <path> [-p <cue-path>] [-v <synver>] [--from <synver>] [--identity]",
+	Short: "Generate a lens stub between two schemas in a lineage",
+	Long: `Generate a lens stub between two schemas in a lineage.
+
+Compare the schema at --from with the schema at --version, and print a CUE lens
+translating from the former to the latter, suitable for adding to the
+lineage's lenses list.
+
+Fields that are unchanged, or only loosened, are mapped directly from the
+input. Fields that were added, removed or retyped are marked with TODO comments.
+Where the target schema requires a value that could not be generated, the field
+is declared as bottom (_|_), such that the lineage will not bind until the lens
+is completed.
+
+With --identity, a complete lens is synthesized for schemas that differ only by
+tightened or loosened constraints, as is common for major version bumps made as
+a matter of policy. Each field is mapped directly from the input, and the lens is
+verified by translating the examples of the --from schema. The command fails,
+listing the changes that prevent it, if such a lens cannot be synthesized.
+`,
+}
+
+func (gc *genCommand) runLens(cmd *cobra.Command, args []string) error {
+	from := gc.sch.Predecessor()
+	if gc.fromstr != "" {
+		v, err := thema.ParseSyntacticVersion(gc.fromstr)
+		if err != nil {
+			return err
+		}
+		if from, err = gc.lin.Schema(v); err != nil {
+			return err
+		}
+	}
+	if from == nil {
+		return fmt.Errorf("schema %s has no predecessor; specify --from", gc.sch.Version())
+	}
+
+	gen := cuenc.GenerateLensStub
+	if gc.identity {
+		gen = cuenc.SynthesizeIdentityLens
+	}
+	x, err := gen(from, gc.sch)
+	if err != nil {
+		return err
+	}
+	b, err := tastutil.FmtNode(x)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), string(b))
+	return nil
+}
+
 var genJschLineageCmd = &cobra.Command{
 	Use:   "jsonschema",
 	Short: "Generate JSON Schema from a lineage",
@@ -254,6 +339,8 @@ func (gc *genCommand) runGoTypes(cmd *cobra.Command, args []string) error {
 	}
 	b, err := gocode.GenerateTypesOpenAPI(gc.sch, &gocode.TypeConfigOpenAPI{
 		PackageName: gc.pkgname,
+		DeepCopy:    gc.deepcopy,
+		Getters:     gc.getters,
 	})
 	if err != nil {
 		return err
@@ -272,6 +359,71 @@ func (gc *genCommand) runGoTypes(cmd *cobra.Command, args []string) error {
 	return os.WriteFile(path, buf.Bytes(), 0644)
 }
 
+var genGoTranslatorsLineageCmd = &cobra.Command{
+	Use:   "gotranslators -l <path> [-p <cue-path>] [--pkgname <name>] [--type-format <format>] [--stdout]",
+	Short: "Generate typed Go translation funcs for a lineage",
+	Long: `Generate typed Go translation funcs for a lineage.
+
+Generate a Go func for translating between each pair of adjacent schemas in a
+lineage, in both directions, e.g.:
+
+  func TranslateV0_0ToV1_0(lin thema.Lineage, in FooV0_0) (FooV1_0, []thema.Lacuna, error)
+
+The funcs wrap the lineage's lenses, giving migration code compile-time checks
+on the types it translates between. The types themselves are not generated by
+this command, and must exist in the target package. By default, each is named
+after the title-cased lineage name and the schema version, as above. Pass
+--type-format to choose a different name, e.g. --type-format "FooMajor%dMinor%d".
+
+Output is written to the same directory that contains the lineage, in a file
+named $NAME_translate_gen.go, where $NAME is the lowercase string value of the
+lineage's name. Pass --stdout to send generated code to stdout instead.
+`,
+}
+
+func (gc *genCommand) runGoTranslators(cmd *cobra.Command, args []string) error {
+	cfg := &gocode.TranslatorConfig{
+		PackageName: gc.pkgname,
+	}
+	if gc.typefmt != "" {
+		cfg.TypeName = func(sch thema.Schema) string {
+			return fmt.Sprintf(gc.typefmt, sch.Version()[0], sch.Version()[1])
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if gc.stdout {
+		fmt.Fprint(buf, goheader)
+	} else {
+		fmt.Fprintf(buf, fmt.Sprintf(goheaderp, gc.epath))
+	}
+	b, err := gocode.GenerateTranslators(gc.lin, cfg)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	if gc.stdout {
+		fmt.Fprint(cmd.OutOrStdout(), buf.String())
+		return nil
+	}
+
+	path := gc.lla.absInput
+	if !gc.lla.pathIsDir {
+		path = filepath.Dir(path)
+	}
+	path = filepath.Join(path, fmt.Sprintf("%s_translate_gen.go", strings.ToLower(gc.lin.Name())))
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	if gc.quiet {
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "generated go translators for %s at %s\n", gc.lin.Name(), path)
+	return nil
+}
+
 var genGoBindingsLineageCmd = &cobra.Command{
 	Short: "Generate Go bindings for a lineage",
 	Long: `Generate Go bindings for a lineage.
@@ -14,20 +14,27 @@ var rt = thema.NewRuntime(ctx)
 func main() {
 	setupDataCommand(rootCmd)
 	setupLineageCommand(rootCmd)
+	setupServeCommand(rootCmd)
+	setupCodegenCommand(rootCmd)
+	setupReplCommand(rootCmd)
+	setupFmtCommand(rootCmd)
+
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "emit results and errors as JSON on stdout, for consumption by other programs")
 
 	// Stop cobra from being so "helpful"
 	for _, cmd := range allCmds {
 		cmd.DisableFlagsInUseLine = true
 		cmd.SilenceUsage = true
+		// Errors are reported by reportError, in the form selected by --json
+		cmd.SilenceErrors = true
 		cmd.CompletionOptions = cobra.CompletionOptions{
 			HiddenDefaultCmd: true,
 		}
 	}
 
-	// srv commands
-	// TODO
 	err := rootCmd.Execute()
 	if err != nil {
+		reportError(rootCmd.OutOrStdout(), rootCmd.ErrOrStderr(), err)
 		os.Exit(1)
 	}
 }
@@ -41,25 +48,36 @@ func addLinPathVars(cmd *cobra.Command, lla *lineageLoadArgs) {
 // List of all commands, for batching stuff
 var allCmds = []*cobra.Command{
 	rootCmd,
-	srvCmd,
-	httpCmd,
+	serveCmd,
+	codegenCmd,
+	replCmd,
+	fmtCmd,
 	dataCmd,
 	translateCmd,
 	validateCmd,
 	validateAnyCmd,
+	conformanceCmd,
+	driftCmd,
 	linCmd,
 	initLineageCmd,
 	initLineageEmptyCmd,
 	initLineageOpenAPICmd,
+	initLineageInferCmd,
 	initLineageJSONSchemaCmd,
 	lineageBumpCmd,
 	lineageFixCmd,
+	lineageDoctorCmd,
+	lineageDiffCmd,
+	lineageReleaseCmd,
+	lineageLogCmd,
 	genLineageCmd,
 	genTSTypesLineageCmd,
 	genGoBindingsLineageCmd,
 	genGoTypesLineageCmd,
+	genGoTranslatorsLineageCmd,
 	genOapiLineageCmd,
 	genJschLineageCmd,
+	genLensLineageCmd,
 }
 
 var rootCmd = &cobra.Command{
@@ -72,7 +90,7 @@ This program offers several kinds of behavior for working with Thema:
 * Validating and inspecting of written lineages.
 * Given a valid lineage, provides basic Thema operations (validate, translate,
   [de]hydrate) on some input data.
-* Run an HTTP server that exposes basic Thema operations to the network. (TODO)
+* Run an HTTP server that exposes basic Thema operations to the network.
 * Provides scaffolding for writing lineages, lenses, and schema. (TODO)
 `,
 }
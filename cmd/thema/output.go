@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// jsonOutput is set by the global --json flag, and causes commands to emit
+// their results and errors as JSON on stdout.
+var jsonOutput bool
+
+// errReported is returned from commands that have already written their own
+// failure output, so that only a nonzero exit status remains.
+var errReported = errors.New("")
+
+// progressInterval is the interval at which commands passed --progress report
+// on long-running operations.
+const progressInterval = time.Second
+
+// newProgress returns a [thema.Progress] reporting to w if enabled, by the
+// --progress flag, or else discarding all reports.
+func newProgress(w io.Writer, enabled bool) thema.Progress {
+	if !enabled {
+		return nopProgress{}
+	}
+	return thema.ConsoleProgress(w, progressInterval)
+}
+
+type nopProgress struct{}
+
+func (nopProgress) OnStart(string, int)  {}
+func (nopProgress) OnItem(string, error) {}
+func (nopProgress) OnFinish(error)       {}
+
+// jsonIssue is the JSON form of a [thema.ValidationIssue].
+type jsonIssue struct {
+	// Path is the dot-separated path to the field at which the issue occurred.
+	// Empty for issues not associated with a field.
+	Path    string `json:"path"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// jsonError is the JSON form of an error returned from any command.
+type jsonError struct {
+	Message string `json:"message"`
+	// Issues are set if the error arose from data failing validation.
+	Issues []jsonIssue `json:"issues,omitempty"`
+}
+
+// jsonValidation is the JSON result of the validate and validate-any commands.
+type jsonValidation struct {
+	Valid   bool   `json:"valid"`
+	Lineage string `json:"lineage"`
+	// Version is the version of the schema the data was valid against, or for
+	// validate, the version it was checked against.
+	Version string      `json:"version,omitempty"`
+	Issues  []jsonIssue `json:"issues,omitempty"`
+	// Candidates are set by validate-any, describing the outcome against each
+	// schema checked.
+	Candidates []jsonCandidate `json:"candidates,omitempty"`
+}
+
+type jsonCandidate struct {
+	Version string      `json:"version"`
+	Valid   bool        `json:"valid"`
+	Issues  []jsonIssue `json:"issues,omitempty"`
+}
+
+func toJSONIssues(err error) []jsonIssue {
+	var issues []jsonIssue
+	for _, vi := range thema.ValidationIssues(err) {
+		issues = append(issues, jsonIssue{
+			Path:    strings.Join(vi.Path, "."),
+			Code:    vi.Code.String(),
+			Message: vi.Message,
+		})
+	}
+	return issues
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error marshaling output to JSON: %w", err)
+	}
+	return nil
+}
+
+// reportError writes err to the appropriate stream in the form selected by
+// --json.
+func reportError(stdout, stderr io.Writer, err error) {
+	if errors.Is(err, errReported) {
+		return
+	}
+	if !jsonOutput {
+		fmt.Fprintln(stderr, "Error:", err)
+		return
+	}
+
+	je := jsonError{Message: err.Error()}
+	if errors.Is(err, terrors.ErrInvalidData) {
+		je.Issues = toJSONIssues(err)
+	}
+	writeJSON(stdout, struct { // nolint: errcheck
+		Error jsonError `json:"error"`
+	}{je})
+}
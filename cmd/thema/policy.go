@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/spf13/cobra"
+)
+
+// policyFlag is the --lacuna-policy flag shared by the commands that
+// translate data.
+type policyFlag struct {
+	path string
+}
+
+func (pf *policyFlag) addFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&pf.path, "lacuna-policy", "", "path to a CUE or JSON file containing a thema.#LacunaPolicy, checked against the lacunas emitted by each translation")
+}
+
+// load reads the lacuna policy named by the flag, returning nil if the flag
+// was not given.
+func (pf *policyFlag) load() (*thema.LacunaPolicy, error) {
+	if pf.path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(pf.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read lacuna policy: %w", err)
+	}
+
+	v := rt.Underlying().LookupPath(cue.MakePath(cue.Def("#LacunaPolicy"))).
+		Unify(rt.Context().CompileBytes(b, cue.Filename(pf.path)))
+	if err = v.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("invalid lacuna policy in %s: %w", pf.path, err)
+	}
+	return thema.DecodeLacunaPolicy(v)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/vmux"
+	"github.com/spf13/cobra"
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Interactively explore a lineage",
+	Long: `Interactively explore a lineage.
+
+Loads the lineage at --lineage and reads commands from stdin. A resource may be
+loaded and then stepped forward and backward through the lineage's lenses,
+showing the intermediate state at each version, which is useful when authoring
+lenses.
+
+Commands taking JSON read it from the rest of the line. If the JSON is not
+complete, subsequent lines are read until it is, or until an empty line.
+Type "help" within the REPL for a list of commands.
+`,
+}
+
+type replCommand struct {
+	lla *lineageLoadArgs
+}
+
+func setupReplCommand(cmd *cobra.Command) {
+	cmd.AddCommand(replCmd)
+	rc := &replCommand{lla: new(lineageLoadArgs)}
+
+	replCmd.Flags().StringVarP(&rc.lla.inputLinFilePath, "lineage", "l", ".", "path to .cue file or package containing lineage")
+	replCmd.Flags().StringVarP(&rc.lla.lincuepath, "path", "p", "", "CUE expression for path to the lineage object within file, if not root")
+	replCmd.PreRunE = rc.lla.validateLineageInput
+	replCmd.RunE = rc.run
+}
+
+func (rc *replCommand) run(cmd *cobra.Command, args []string) error {
+	r := &repl{
+		lin: rc.lla.dl.lin,
+		sch: rc.lla.dl.lin.Latest(),
+		in:  bufio.NewReader(cmd.InOrStdin()),
+		out: cmd.OutOrStdout(),
+	}
+	fmt.Fprintf(r.out, "lineage %q, versions %s through %s. type \"help\" for commands\n", r.lin.Name(), r.lin.First().Version(), r.lin.Latest().Version())
+	return r.loop()
+}
+
+// replCommands describes each command accepted by the REPL, in the order they
+// are listed by help.
+var replCommands = []struct {
+	name, args, help string
+	fn               func(r *repl, args string) error
+}{
+	{"versions", "", "list the versions of all schemas in the lineage", (*repl).versions},
+	{"use", "<version>", "select the current schema", (*repl).use},
+	{"show", "[<version>]", "show the fields of the current schema, or the given one", (*repl).show},
+	{"validate", "[<version>] <json>", "validate JSON against the current schema, or the given one", (*repl).validate},
+	{"load", "[<version>] <json>", "load JSON as the current resource, as an instance of the given schema, or the newest it is valid against", (*repl).load},
+	{"state", "", "print the current resource and its version", (*repl).state},
+	{"next", "", "translate the current resource to the next version", (*repl).next},
+	{"prev", "", "translate the current resource to the previous version", (*repl).prev},
+	{"goto", "<version>", "translate the current resource to a version, one step at a time", (*repl).goTo},
+	{"help", "", "print this help", nil},
+	{"quit", "", "exit the REPL", nil},
+}
+
+// errQuit is returned from readLine when input is exhausted.
+var errQuit = errors.New("quit")
+
+type repl struct {
+	lin thema.Lineage
+	// sch is the current schema, against which commands operate by default.
+	sch thema.Schema
+	// inst is the current resource, if one has been loaded.
+	inst *thema.Instance
+
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *repl) loop() error {
+	for {
+		fmt.Fprintf(r.out, "%s> ", r.sch.Version())
+		line, err := r.readLine()
+		if err != nil {
+			if errors.Is(err, errQuit) {
+				fmt.Fprintln(r.out)
+				return nil
+			}
+			return err
+		}
+
+		name, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if name == "" {
+			continue
+		}
+		switch name {
+		case "quit", "exit":
+			return nil
+		case "help":
+			r.help()
+			continue
+		}
+
+		found := false
+		for _, c := range replCommands {
+			if c.name == name {
+				found = true
+				if err := c.fn(r, strings.TrimSpace(args)); err != nil {
+					fmt.Fprintln(r.out, "error:", err)
+				}
+			}
+		}
+		if !found {
+			fmt.Fprintf(r.out, "unknown command %q; type \"help\" for commands\n", name)
+		}
+	}
+}
+
+func (r *repl) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			if line == "" {
+				return "", errQuit
+			}
+			return line, nil
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// readJSON returns s if it is complete JSON, otherwise reading further lines
+// until it is, or until an empty line.
+func (r *repl) readJSON(s string) (cue.Value, error) {
+	for !json.Valid([]byte(s)) {
+		line, err := r.readLine()
+		if err != nil && !errors.Is(err, errQuit) {
+			return cue.Value{}, err
+		}
+		if strings.TrimSpace(line) == "" {
+			if strings.TrimSpace(s) == "" {
+				return cue.Value{}, errors.New("no JSON given")
+			}
+			break
+		}
+		s += "\n" + line
+	}
+	return vmux.NewJSONCodec("repl").Decode(rt.Underlying().Context(), []byte(s))
+}
+
+// schemaArg returns the schema named by a leading version in args, or the
+// current schema if there is none, along with the remainder of args.
+func (r *repl) schemaArg(args string) (thema.Schema, string, error) {
+	first, rest, _ := strings.Cut(args, " ")
+	if first == "" || strings.ContainsAny(first[:1], "{[\"") {
+		return r.sch, args, nil
+	}
+	v, err := thema.ParseSyntacticVersion(first)
+	if err != nil {
+		return nil, "", err
+	}
+	sch, err := r.lin.Schema(v)
+	if err != nil {
+		return nil, "", err
+	}
+	return sch, strings.TrimSpace(rest), nil
+}
+
+func (r *repl) versions(_ string) error {
+	for _, sch := range r.lin.All() {
+		var marks []string
+		if sch.Version() == r.sch.Version() {
+			marks = append(marks, "current")
+		}
+		if r.inst != nil && sch.Version() == r.inst.Schema().Version() {
+			marks = append(marks, "resource")
+		}
+		if len(marks) > 0 {
+			fmt.Fprintf(r.out, "%s\t(%s)\n", sch.Version(), strings.Join(marks, ", "))
+		} else {
+			fmt.Fprintln(r.out, sch.Version())
+		}
+	}
+	return nil
+}
+
+func (r *repl) use(args string) error {
+	if args == "" {
+		return errors.New("a version is required")
+	}
+	sch, _, err := r.schemaArg(args)
+	if err != nil {
+		return err
+	}
+	r.sch = sch
+	return nil
+}
+
+func (r *repl) show(args string) error {
+	sch, _, err := r.schemaArg(args)
+	if err != nil {
+		return err
+	}
+	def := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	return r.showFields(def, "")
+}
+
+func (r *repl) showFields(v cue.Value, prefix string) error {
+	iter, err := v.Fields(cue.Optional(true), cue.Definitions(true))
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		name := prefix + iter.Selector().String()
+		if iter.IsOptional() {
+			name += "?"
+		}
+		fv := iter.Value()
+		if hasFields(fv) {
+			fmt.Fprintf(r.out, "%s\tstruct\n", name)
+			if err := r.showFields(fv, strings.TrimSuffix(name, "?")+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(r.out, "%s\t%v\n", name, fv)
+	}
+	return nil
+}
+
+// hasFields reports whether v is a struct with any fields of its own, which are
+// shown individually.
+func hasFields(v cue.Value) bool {
+	if v.IncompleteKind() != cue.StructKind {
+		return false
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	return err == nil && iter.Next()
+}
+
+func (r *repl) validate(args string) error {
+	sch, rest, err := r.schemaArg(args)
+	if err != nil {
+		return err
+	}
+	data, err := r.readJSON(rest)
+	if err != nil {
+		return err
+	}
+	if _, err := sch.Validate(data); err != nil {
+		fmt.Fprintf(r.out, "invalid against %s:\n", sch.Version())
+		for _, vi := range thema.ValidationIssues(err) {
+			fmt.Fprintf(r.out, "  %s: %s\n", strings.Join(vi.Path, "."), vi.Message)
+		}
+		return nil
+	}
+	fmt.Fprintf(r.out, "valid against %s\n", sch.Version())
+	return nil
+}
+
+func (r *repl) load(args string) error {
+	sch, rest, err := r.schemaArg(args)
+	if err != nil {
+		return err
+	}
+	data, err := r.readJSON(rest)
+	if err != nil {
+		return err
+	}
+
+	var inst *thema.Instance
+	if rest == args {
+		inst, _, err = thema.SearchAndValidate(r.lin, data)
+	} else {
+		inst, err = sch.Validate(data)
+	}
+	if err != nil {
+		return err
+	}
+	r.inst, r.sch = inst, inst.Schema()
+	fmt.Fprintf(r.out, "loaded resource as an instance of %s\n", inst.Schema().Version())
+	return nil
+}
+
+func (r *repl) state(_ string) error {
+	if r.inst == nil {
+		return errors.New(`no resource loaded; use "load"`)
+	}
+	byt, err := json.MarshalIndent(r.inst.Underlying(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "%s:\n%s\n", r.inst.Schema().Version(), byt)
+	return nil
+}
+
+func (r *repl) next(_ string) error {
+	if r.inst == nil {
+		return errors.New(`no resource loaded; use "load"`)
+	}
+	if r.inst.Schema().Successor() == nil {
+		return fmt.Errorf("resource is already at the latest version, %s", r.inst.Schema().Version())
+	}
+	return r.step(r.inst.AsSuccessor)
+}
+
+func (r *repl) prev(_ string) error {
+	if r.inst == nil {
+		return errors.New(`no resource loaded; use "load"`)
+	}
+	if r.inst.Schema().Predecessor() == nil {
+		return fmt.Errorf("resource is already at the first version, %s", r.inst.Schema().Version())
+	}
+	return r.step(r.inst.AsPredecessor)
+}
+
+func (r *repl) goTo(args string) error {
+	if r.inst == nil {
+		return errors.New(`no resource loaded; use "load"`)
+	}
+	if args == "" {
+		return errors.New("a version is required")
+	}
+	sch, _, err := r.schemaArg(args)
+	if err != nil {
+		return err
+	}
+	for r.inst.Schema().Version() != sch.Version() {
+		step := r.next
+		if sch.Version().Less(r.inst.Schema().Version()) {
+			step = r.prev
+		}
+		if err := step(""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step translates the current resource with fn, printing the resulting state
+// and any lacunas.
+func (r *repl) step(fn func() (*thema.Instance, thema.TranslationLacunas, error)) error {
+	from := r.inst.Schema().Version()
+	ninst, lac, err := fn()
+	if err != nil {
+		return err
+	}
+	r.inst, r.sch = ninst, ninst.Schema()
+
+	fmt.Fprintf(r.out, "%s -> ", from)
+	if err := r.state(""); err != nil {
+		return err
+	}
+	if lac != nil {
+		for _, l := range lac.AsList() {
+			fmt.Fprintf(r.out, "lacuna: %s\n", l.Message)
+		}
+	}
+	return nil
+}
+
+func (r *repl) help() {
+	for _, c := range replCommands {
+		fmt.Fprintf(r.out, "  %-30s %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grafana/thema"
+	tload "github.com/grafana/thema/load"
+	"github.com/grafana/thema/server"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func setupServeCommand(cmd *cobra.Command) {
+	cmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringArrayVarP(&sc.lineages, "lineage", "l", nil, "path to .cue file or package containing a lineage to serve; may be repeated")
+	serveCmd.Flags().StringVarP(&sc.dir, "dir", "d", "", "CUE module root from which to load and serve all lineages, instead of --lineage")
+	serveCmd.Flags().StringVarP(&sc.lincuepath, "path", "p", "", "CUE expression for path to the lineage object within each file, if not root")
+	serveCmd.Flags().StringVar(&sc.addr, "http", ":8080", "address on which to serve HTTP")
+	serveCmd.Flags().StringVar(&sc.grpcAddr, "grpc", "", "address on which to also serve gRPC, if set")
+	serveCmd.Flags().StringArrayVar(&sc.targets, "target-version", nil, "default version to translate to, as <version> for all lineages or <name>=<version> for one; may be repeated. defaults to latest")
+	serveCmd.Flags().StringVar(&sc.defaults, "defaults", "keep", "treatment of schema defaults in translated data: keep, apply, or trim")
+	serveCmd.Flags().StringVar(&sc.tlsCert, "tls-cert", "", "path to a PEM-encoded TLS certificate; serves HTTPS if set")
+	serveCmd.Flags().StringVar(&sc.tlsKey, "tls-key", "", "path to the PEM-encoded private key for --tls-cert")
+	sc.limits.addFlags(serveCmd)
+	sc.policy.addFlag(serveCmd)
+	serveCmd.RunE = sc.run
+}
+
+// limitFlags holds the request limits of the server.Handler run by serve.
+type limitFlags struct {
+	maxBody int64
+	rps     float64
+	burst   int
+}
+
+func (lf *limitFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&lf.maxBody, "max-body", server.DefaultMaxBodyBytes, "maximum request body size in bytes for validate and translate; 0 for no limit")
+	cmd.Flags().Float64Var(&lf.rps, "rate-limit", 0, "maximum average validate and translate requests per second per client; 0 for no limit")
+	cmd.Flags().IntVar(&lf.burst, "rate-burst", 10, "maximum burst of requests per client permitted by --rate-limit")
+}
+
+func (lf *limitFlags) options() []server.Option {
+	return []server.Option{
+		server.MaxBodyBytes(lf.maxBody),
+		server.RateLimit(lf.rps, lf.burst),
+	}
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve validation and translation for lineages over HTTP and gRPC",
+	Long: `Serve validation and translation for lineages over HTTP and gRPC.
+
+The lineages given with --lineage, or all lineages in the CUE module at --dir,
+are loaded and served, suitable for deployment as a validation sidecar. The
+HTTP server offers the following endpoints:
+
+  /healthz   liveness check
+  /readyz    readiness check, succeeding once all lineages are loaded and warm
+  /lineages  JSON metadata about each lineage: name, versions, and checksum
+  /validate  POST: validate JSON data against ?lineage=<name>[&version=<v>]
+  /translate POST: validate, then translate JSON data ?to=<v>, or to latest
+
+If --grpc is given, the thema.server.v1.Thema gRPC service, declared in
+server/thema.proto, is also served on that address. Its Validate, Translate and
+Lineages methods behave as the HTTP endpoints of the same name.
+
+Unless a request specifies otherwise, data is translated to the version given by
+--target-version, or to the latest schema in its lineage. The defaults in
+translated data are kept, applied, or trimmed according to --defaults.
+Translations emitting lacunas not permitted by --lacuna-policy are rejected
+with 422.
+
+Request bodies larger than --max-body are rejected with 413. If --rate-limit is
+set, clients exceeding it are rejected with 429.
+
+HTTPS, and gRPC over TLS, are served if both --tls-cert and --tls-key are given.
+`,
+}
+
+type serveCommand struct {
+	lineages   []string
+	dir        string
+	lincuepath string
+	addr       string
+	grpcAddr   string
+	targets    []string
+	defaults   string
+	tlsCert    string
+	tlsKey     string
+	limits     limitFlags
+	policy     policyFlag
+}
+
+var sc = &serveCommand{}
+
+func (sc *serveCommand) run(cmd *cobra.Command, args []string) error {
+	if (sc.tlsCert == "") != (sc.tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+
+	set, err := sc.load()
+	if err != nil {
+		return err
+	}
+
+	opts := sc.limits.options()
+	mode, err := server.ParseDefaultsMode(sc.defaults)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, server.Defaults(mode))
+	policy, err := sc.policy.load()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, server.LacunaPolicy(policy))
+	for _, t := range sc.targets {
+		topts, err := targetOptions(set, t)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, topts...)
+	}
+
+	h := server.NewHandler(set, opts...)
+	go func() {
+		if err := h.Warm(context.Background()); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "failed to warm lineages: %s\n", err)
+		}
+	}()
+
+	errc := make(chan error, 2)
+	if sc.grpcAddr != "" {
+		gs, err := sc.grpcServer()
+		if err != nil {
+			return err
+		}
+		h.RegisterGRPC(gs)
+		lis, err := net.Listen("tcp", sc.grpcAddr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "serving %d lineages over gRPC on %s\n", set.Len(), sc.grpcAddr)
+		go func() { errc <- gs.Serve(lis) }()
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "serving %d lineages on %s\n", set.Len(), sc.addr)
+	go func() {
+		if sc.tlsCert != "" {
+			errc <- http.ListenAndServeTLS(sc.addr, sc.tlsCert, sc.tlsKey, h) // nolint: gosec
+			return
+		}
+		errc <- http.ListenAndServe(sc.addr, h) // nolint: gosec
+	}()
+	return <-errc
+}
+
+// load loads the lineages given by --lineage or --dir.
+func (sc *serveCommand) load() (*thema.LineageSet, error) {
+	if (len(sc.lineages) == 0) == (sc.dir == "") {
+		return nil, fmt.Errorf("exactly one of --lineage or --dir must be given")
+	}
+	if sc.dir != "" {
+		return tload.LoadAll(os.DirFS(sc.dir), rt)
+	}
+
+	var lins []thema.Lineage
+	for _, path := range sc.lineages {
+		lla := &lineageLoadArgs{inputLinFilePath: path, lincuepath: sc.lincuepath}
+		dl, err := lla.dynLoad()
+		if err != nil {
+			return nil, fmt.Errorf("error loading lineage from %s: %w", path, err)
+		}
+		lins = append(lins, dl.lin)
+	}
+	return thema.NewLineageSet(lins...)
+}
+
+// grpcServer creates the server for --grpc, using the TLS certificate given by
+// --tls-cert and --tls-key, if any.
+func (sc *serveCommand) grpcServer() (*grpc.Server, error) {
+	if sc.tlsCert == "" {
+		return grpc.NewServer(), nil
+	}
+	creds, err := credentials.NewServerTLSFromFile(sc.tlsCert, sc.tlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("error loading TLS certificate: %w", err)
+	}
+	return grpc.NewServer(grpc.Creds(creds)), nil
+}
+
+// targetOptions parses a --target-version value, checking that the version it
+// names exists in each lineage to which it applies.
+func targetOptions(set *thema.LineageSet, t string) ([]server.Option, error) {
+	names := set.Names()
+	vstr := t
+	if name, v, has := strings.Cut(t, "="); has {
+		if _, has := set.Get(name); !has {
+			return nil, fmt.Errorf("--target-version %s: no lineage named %q is being served", t, name)
+		}
+		names, vstr = []string{name}, v
+	}
+	v, err := thema.ParseSyntacticVersion(vstr)
+	if err != nil {
+		return nil, fmt.Errorf("--target-version %s: %w", t, err)
+	}
+
+	var opts []server.Option
+	for _, name := range names {
+		lin, _ := set.Get(name)
+		if _, err := lin.Schema(v); err != nil {
+			return nil, fmt.Errorf("--target-version %s: %w", t, err)
+		}
+		opts = append(opts, server.TargetVersion(name, v))
+	}
+	return opts, nil
+}
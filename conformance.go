@@ -0,0 +1,131 @@
+package thema
+
+import (
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+)
+
+const (
+	// maxConformancePaths is the number of failing paths reported per schema
+	// by [CheckConformance].
+	maxConformancePaths = 10
+
+	// maxConformanceExamples is the number of examples reported per failing
+	// path by [CheckConformance].
+	maxConformanceExamples = 3
+)
+
+// A ConformanceReport describes how a corpus of data conforms to each schema
+// in a lineage, as returned from [CheckConformance].
+type ConformanceReport struct {
+	// Total is the number of values in the corpus.
+	Total int `json:"total"`
+
+	// Schemas holds the conformance of the corpus to each schema in the
+	// lineage, in version order.
+	Schemas []SchemaConformance `json:"schemas"`
+}
+
+// SchemaConformance describes how a corpus of data conforms to a single
+// schema.
+type SchemaConformance struct {
+	Version SyntacticVersion `json:"version"`
+
+	// Valid is the number of values in the corpus that are valid instances of
+	// the schema.
+	Valid int `json:"valid"`
+
+	// Invalid is the number of values in the corpus that are not.
+	Invalid int `json:"invalid"`
+
+	// FailingPaths lists the paths at which values most commonly failed to
+	// validate against the schema, most common first.
+	FailingPaths []FailingPath `json:"failingPaths,omitempty"`
+}
+
+// A FailingPath is a path at which values in a corpus failed to validate
+// against a schema.
+type FailingPath struct {
+	// Path is the dot-separated path to the failing field, or empty for
+	// failures not attributed to a field.
+	Path string `json:"path"`
+
+	// Count is the number of values that failed at the path.
+	Count int `json:"count"`
+
+	// Examples holds a sample of the failures, in corpus order.
+	Examples []ConformanceExample `json:"examples"`
+}
+
+// A ConformanceExample identifies a single value in a corpus that failed to
+// validate at a [FailingPath].
+type ConformanceExample struct {
+	// Index is the position of the value in the corpus.
+	Index int `json:"index"`
+
+	// Message describes the failure.
+	Message string `json:"message"`
+}
+
+// CheckConformance validates each value in the corpus against every schema in
+// the lineage, reporting per schema how many values validate, the paths at
+// which the remainder most commonly fail, and examples of those failures.
+//
+// It is intended for deciding when the data being produced for an old schema
+// has diminished enough for that schema to be deprecated. Each value counts
+// at most once towards each failing path of a schema, however many issues it
+// has at that path. Ties in the order of failing paths are broken by path.
+func CheckConformance(lin Lineage, corpus []cue.Value) *ConformanceReport {
+	isValidLineage(lin)
+
+	r := &ConformanceReport{Total: len(corpus)}
+	for _, sch := range lin.All() {
+		sc := SchemaConformance{Version: sch.Version()}
+		byPath := make(map[string]*FailingPath)
+		for idx, v := range corpus {
+			_, err := sch.Validate(v)
+			if err == nil {
+				sc.Valid++
+				continue
+			}
+			sc.Invalid++
+
+			seen := make(map[string]bool)
+			for _, issue := range ValidationIssues(err) {
+				p := strings.Join(issue.Path, ".")
+				if seen[p] {
+					continue
+				}
+				seen[p] = true
+
+				fp, has := byPath[p]
+				if !has {
+					fp = &FailingPath{Path: p}
+					byPath[p] = fp
+				}
+				fp.Count++
+				if len(fp.Examples) < maxConformanceExamples {
+					fp.Examples = append(fp.Examples, ConformanceExample{Index: idx, Message: issue.Message})
+				}
+			}
+		}
+
+		for _, fp := range byPath {
+			sc.FailingPaths = append(sc.FailingPaths, *fp)
+		}
+		sort.Slice(sc.FailingPaths, func(i, j int) bool {
+			fi, fj := sc.FailingPaths[i], sc.FailingPaths[j]
+			if fi.Count != fj.Count {
+				return fi.Count > fj.Count
+			}
+			return fi.Path < fj.Path
+		})
+		if len(sc.FailingPaths) > maxConformancePaths {
+			sc.FailingPaths = sc.FailingPaths[:maxConformancePaths]
+		}
+		r.Schemas = append(r.Schemas, sc)
+	}
+	return r
+}
@@ -0,0 +1,47 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConformance(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(routelin), rt)
+	require.NoError(t, err)
+
+	var corpus []cue.Value
+	for _, s := range []string{
+		`{title: "a"}`,
+		`{title: "b", via: "x"}`,
+		`{name: "c"}`,
+		`{name: 1}`,
+		`{label: "d"}`,
+	} {
+		corpus = append(corpus, rt.Context().CompileString(s))
+	}
+
+	r := CheckConformance(lin, corpus)
+	assert.Equal(t, 5, r.Total)
+	require.Len(t, r.Schemas, 4)
+
+	valid := make(map[string]int)
+	for _, sc := range r.Schemas {
+		valid[sc.Version.String()] = sc.Valid
+		assert.Equal(t, 5, sc.Valid+sc.Invalid, sc.Version.String())
+	}
+	assert.Equal(t, map[string]int{"0.0": 1, "0.1": 2, "1.0": 1, "2.0": 1}, valid)
+
+	// Both values with a name field fail 0.1, the most common failing path
+	sc := r.Schemas[1]
+	require.NotEmpty(t, sc.FailingPaths)
+	assert.Equal(t, "name", sc.FailingPaths[0].Path)
+	assert.Equal(t, 2, sc.FailingPaths[0].Count)
+	require.Len(t, sc.FailingPaths[0].Examples, 2)
+	assert.Equal(t, 2, sc.FailingPaths[0].Examples[0].Index)
+	assert.Equal(t, 3, sc.FailingPaths[0].Examples[1].Index)
+}
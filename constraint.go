@@ -0,0 +1,144 @@
+package thema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A VersionConstraint is a parsed version constraint expression, as returned
+// from [ParseVersionConstraint]. It reports whether a [SyntacticVersion]
+// satisfies the expression.
+//
+// The zero VersionConstraint has no alternatives, and so matches no version.
+type VersionConstraint struct {
+	raw string
+	// disjunction of conjunctions
+	alts [][]versionComparison
+}
+
+type versionComparison struct {
+	op string
+	v  SyntacticVersion
+}
+
+func (vc versionComparison) matches(v SyntacticVersion) bool {
+	switch vc.op {
+	case "=":
+		return v == vc.v
+	case "!=":
+		return v != vc.v
+	case ">":
+		return vc.v.Less(v)
+	case ">=":
+		return !v.Less(vc.v)
+	case "<":
+		return v.Less(vc.v)
+	case "<=":
+		return !vc.v.Less(v)
+	default:
+		panic(fmt.Sprintf("unreachable - unknown comparison operator %q", vc.op))
+	}
+}
+
+// versionOperators are the comparison operators permitted in a version
+// constraint expression, ordered such that no operator precedes another of
+// which it is a prefix.
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseVersionConstraint parses a human-readable version constraint
+// expression, such as those that might appear in service configuration.
+//
+// An expression is a whitespace-separated list of comparisons, all of which
+// must be satisfied. Each comparison is an operator - one of =, !=, >, >=, <,
+// or <= - followed by a syntactic version, optionally separated from it by
+// whitespace. An operator may be omitted, in which case = is assumed.
+// Alternative lists may be separated by ||, in which case satisfying any one
+// of the lists is sufficient. For example:
+//
+//	>=1.2 <3.0
+//	>= 1.2 < 3.0
+//	0.0 || >=2.0
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	vc := VersionConstraint{raw: s}
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return VersionConstraint{}, errors.Mark(errors.Newf("%q contains an empty alternative", s), terrors.ErrMalformedVersionConstraint)
+		}
+
+		var conj []versionComparison
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			var op string
+			for _, candidate := range versionOperators {
+				if strings.HasPrefix(f, candidate) {
+					op = candidate
+					break
+				}
+			}
+
+			vstr := strings.TrimPrefix(f, op)
+			if op == "" {
+				op = "="
+			} else if vstr == "" && i+1 < len(fields) {
+				// The operator is separated from its version by whitespace,
+				// as in ">= 1.2".
+				i++
+				vstr = fields[i]
+				f += " " + vstr
+			}
+			v, err := ParseSyntacticVersion(vstr)
+			if err != nil {
+				return VersionConstraint{}, errors.Mark(errors.Newf("%q has invalid version in comparison %q", s, f), terrors.ErrMalformedVersionConstraint)
+			}
+			conj = append(conj, versionComparison{op: op, v: v})
+		}
+		vc.alts = append(vc.alts, conj)
+	}
+
+	return vc, nil
+}
+
+// MustParseVersionConstraint is the same as [ParseVersionConstraint], but panics
+// on error.
+func MustParseVersionConstraint(s string) VersionConstraint {
+	vc, err := ParseVersionConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return vc
+}
+
+// Matches reports whether the provided version satisfies the constraint.
+func (vc VersionConstraint) Matches(v SyntacticVersion) bool {
+	for _, conj := range vc.alts {
+		all := true
+		for _, cmp := range conj {
+			if !cmp.matches(v) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (vc VersionConstraint) String() string {
+	return vc.raw
+}
+
+// Satisfies restricts a search to schemas with versions that satisfy the
+// provided [VersionConstraint].
+func Satisfies(vc VersionConstraint) SearchOption {
+	return func(c *searchConfig) {
+		c.filters = append(c.filters, func(sch Schema) bool {
+			return vc.Matches(sch.Version())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	table := []struct {
+		expr    string
+		matches []SyntacticVersion
+		misses  []SyntacticVersion
+	}{
+		{
+			expr:    ">=1.2 <3.0",
+			matches: []SyntacticVersion{SV(1, 2), SV(1, 9), SV(2, 0), SV(2, 14)},
+			misses:  []SyntacticVersion{SV(0, 0), SV(1, 1), SV(3, 0), SV(3, 1)},
+		},
+		{
+			expr:    "1.0",
+			matches: []SyntacticVersion{SV(1, 0)},
+			misses:  []SyntacticVersion{SV(0, 0), SV(1, 1)},
+		},
+		{
+			expr:    "0.0 || >2.0 !=2.3",
+			matches: []SyntacticVersion{SV(0, 0), SV(2, 1), SV(4, 0)},
+			misses:  []SyntacticVersion{SV(0, 1), SV(2, 0), SV(2, 3)},
+		},
+		{
+			expr:    ">= 1.2 < 3.0",
+			matches: []SyntacticVersion{SV(1, 2), SV(1, 9), SV(2, 0), SV(2, 14)},
+			misses:  []SyntacticVersion{SV(0, 0), SV(1, 1), SV(3, 0), SV(3, 1)},
+		},
+		{
+			expr:    ">= 1.2 <3.0",
+			matches: []SyntacticVersion{SV(1, 2), SV(2, 14)},
+			misses:  []SyntacticVersion{SV(1, 1), SV(3, 0)},
+		},
+		{
+			expr:    "= 0.0 || > 2.0 != 2.3",
+			matches: []SyntacticVersion{SV(0, 0), SV(2, 1), SV(4, 0)},
+			misses:  []SyntacticVersion{SV(0, 1), SV(2, 0), SV(2, 3)},
+		},
+		{
+			expr:    "  <=   0.1  ",
+			matches: []SyntacticVersion{SV(0, 0), SV(0, 1)},
+			misses:  []SyntacticVersion{SV(0, 2), SV(1, 0)},
+		},
+		{
+			expr:    "<=0.1",
+			matches: []SyntacticVersion{SV(0, 0), SV(0, 1)},
+			misses:  []SyntacticVersion{SV(0, 2), SV(1, 0)},
+		},
+	}
+
+	for _, item := range table {
+		t.Run(item.expr, func(t *testing.T) {
+			vc, err := ParseVersionConstraint(item.expr)
+			require.NoError(t, err)
+			for _, v := range item.matches {
+				assert.True(t, vc.Matches(v), "expected %s to match", v)
+			}
+			for _, v := range item.misses {
+				assert.False(t, vc.Matches(v), "expected %s not to match", v)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", ">=", "1", ">=1.x", "1.0 ||", "~1.0", ">= ", "1.0 <", ">= <1.0", "> = 1.0", "~ 1.0"} {
+		_, err := ParseVersionConstraint(bad)
+		assert.True(t, errors.Is(err, terrors.ErrMalformedVersionConstraint), "expected error for %q", bad)
+	}
+
+	assert.False(t, VersionConstraint{}.Matches(SV(0, 0)), "expected zero constraint to match nothing")
+}
+
+func TestSatisfies(t *testing.T) {
+	lin := testLin(searchlinstr)
+
+	sch, err := Find(lin, Satisfies(MustParseVersionConstraint("<1.0")))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), sch.Version())
+
+	inst, _, err := SearchAndValidate(lin, lin.Runtime().Context().CompileString(`{ title: "foo" }`), Satisfies(MustParseVersionConstraint("=0.0")))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 0), inst.Schema().Version())
+}
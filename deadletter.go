@@ -0,0 +1,31 @@
+package thema
+
+// A DeadLetter describes data that a bulk or streaming operation could not
+// validate or translate, as passed to a [DeadLetterSink].
+type DeadLetter struct {
+	// ID identifies the data within the operation's input, such as the ID of
+	// a migrated item, or the position of a job within a stream.
+	ID string
+
+	// Data is the original encoded data.
+	Data []byte
+
+	// Attempted lists the versions of the schemas against which the data was
+	// validated, or to which it was translated, in the order attempted. It is
+	// empty if the data was rejected before validation, such as because it
+	// could not be decoded.
+	Attempted []SyntacticVersion
+
+	// Err is the error that caused the data to be rejected.
+	Err error
+
+	// Lacunas are any lacunas emitted by translation before the failure.
+	Lacunas TranslationLacunas
+}
+
+// A DeadLetterSink receives the data rejected by a bulk or streaming
+// operation, such as [TranslationExecutor.Run], so that it may be quarantined
+// for later inspection rather than halting the operation. A sink is called
+// from a single goroutine per operation, in the order of the operation's
+// input.
+type DeadLetterSink func(DeadLetter)
@@ -0,0 +1,341 @@
+package thema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// ApplyDefaultsOpts controls which schema defaults [Instance.ApplyDefaults]
+// fills into an instance.
+//
+// The zero value fills defaults only for missing required fields, including
+// those nested within structs.
+type ApplyDefaultsOpts struct {
+	// Optional additionally fills defaults for missing optional fields. Missing
+	// optional fields with no default are left absent.
+	Optional bool
+
+	// Lists additionally recurses into the elements of lists, filling defaults
+	// for missing fields within each element.
+	Lists bool
+
+	// PreserveOrder keeps the fields of each struct in the order in which they
+	// appear in the input, with any filled fields following them. Otherwise,
+	// fields are ordered lexically.
+	PreserveOrder bool
+}
+
+// ApplyDefaults returns a copy of the Instance in which missing fields are
+// filled with the default values declared for them in the schema, according
+// to the provided ApplyDefaultsOpts. Values already present in the instance
+// are never changed.
+func (i *Instance) ApplyDefaults(opts ApplyDefaultsOpts) (*Instance, error) {
+	i.check()
+
+	raw, err := ApplyDefaults(i.sch, i.raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{
+		valid: true,
+		raw:   raw,
+		name:  i.name,
+		sch:   i.sch,
+	}, nil
+}
+
+// ApplyDefaults fills missing fields in the provided data with the default
+// values declared for them in the provided schema, as [Instance.ApplyDefaults].
+//
+// The data is neither validated against the schema nor wrapped in an
+// [Instance], avoiding that cost for callers that already hold data known to
+// be valid. Results are undefined for data that is not valid.
+func ApplyDefaults(sch Schema, data cue.Value, opts ApplyDefaultsOpts) (cue.Value, error) {
+	return guard("applying defaults", func() (cue.Value, error) {
+		return applyDefaultsValue(sch, data, opts)
+	})
+}
+
+func applyDefaultsValue(sch Schema, data cue.Value, opts ApplyDefaultsOpts) (cue.Value, error) {
+	var x any
+	if err := data.Decode(&x); err != nil {
+		return cue.Value{}, errors.Wrap(err, "unable to decode data to apply defaults")
+	}
+	x = applyDefaults(sch.Underlying().LookupPath(pathSchDef), x, opts, 0)
+
+	out := data.Context().Encode(x)
+	if opts.PreserveOrder {
+		out = orderLike(out, data)
+	}
+	return out, nil
+}
+
+// TrimDefaultsOpts controls the behavior of [Instance.TrimDefaults].
+type TrimDefaultsOpts struct {
+	// PreserveOrder keeps the remaining fields of each struct in the order in
+	// which they appear in the input. Otherwise, fields declared in the schema
+	// are ordered as in the schema, followed by any others.
+	PreserveOrder bool
+}
+
+// TrimDefaults returns a copy of the Instance from which all fields whose
+// values are equal to the default declared for them in the schema are removed.
+// It is the inverse of [Instance.ApplyDefaults].
+func (i *Instance) TrimDefaults(opts TrimDefaultsOpts) (*Instance, error) {
+	i.check()
+
+	raw, err := TrimDefaults(i.sch, i.raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{
+		valid: true,
+		raw:   raw,
+		name:  i.name,
+		sch:   i.sch,
+	}, nil
+}
+
+// TrimDefaults removes all fields from the provided data whose values are
+// equal to the default declared for them in the provided schema, as
+// [Instance.TrimDefaults].
+//
+// As with [ApplyDefaults], the data is not validated against the schema.
+func TrimDefaults(sch Schema, data cue.Value, opts TrimDefaultsOpts) (cue.Value, error) {
+	return guard("trimming defaults", func() (cue.Value, error) {
+		return trimDefaultsValue(sch, data, opts)
+	})
+}
+
+func trimDefaultsValue(sch Schema, data cue.Value, opts TrimDefaultsOpts) (cue.Value, error) {
+	out, _, err := doDehydrate(sch.Underlying().LookupPath(pathSchDef), data)
+	if err != nil {
+		return cue.Value{}, errors.Wrap(err, "unable to trim defaults from data")
+	}
+	if opts.PreserveOrder {
+		out = orderLike(out, data)
+	}
+	return out, nil
+}
+
+// orderLike returns a copy of v in which the fields of every struct are
+// ordered as the corresponding fields in ref. Fields of v that are absent from
+// ref follow, in their original order. List elements are matched by index
+// when both lists have the same length.
+func orderLike(v, ref cue.Value) cue.Value {
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		if !ref.Exists() || ref.IncompleteKind() != cue.StructKind {
+			return v
+		}
+		rv := emptyStruct(v.Context())
+		seen := make(map[string]bool)
+		iter, err := ref.Fields()
+		if err != nil {
+			return v
+		}
+		for iter.Next() {
+			p := cue.MakePath(iter.Selector())
+			if fv := v.LookupPath(p); fv.Exists() {
+				seen[iter.Selector().String()] = true
+				rv = rv.FillPath(p, orderLike(fv, iter.Value()))
+			}
+		}
+		iter, err = v.Fields()
+		if err != nil {
+			return v
+		}
+		for iter.Next() {
+			if !seen[iter.Selector().String()] {
+				rv = rv.FillPath(cue.MakePath(iter.Selector()), iter.Value())
+			}
+		}
+		return rv
+	case cue.ListKind:
+		vl, err := v.List()
+		if err != nil {
+			return v
+		}
+		var refs []cue.Value
+		if !ref.Exists() || ref.IncompleteKind() != cue.ListKind {
+			return v
+		}
+		if rl, err := ref.List(); err == nil {
+			for rl.Next() {
+				refs = append(refs, rl.Value())
+			}
+		}
+		var elems []cue.Value
+		for vl.Next() {
+			elems = append(elems, vl.Value())
+		}
+		if len(elems) != len(refs) {
+			return v
+		}
+		for idx := range elems {
+			elems[idx] = orderLike(elems[idx], refs[idx])
+		}
+		return v.Context().NewList(elems...)
+	}
+	return v
+}
+
+func applyDefaults(sch cue.Value, data any, opts ApplyDefaultsOpts, depth int) any {
+	if depth > maxAttrDepth {
+		return data
+	}
+
+	switch x := data.(type) {
+	case map[string]any:
+		if sch.IncompleteKind() != cue.StructKind {
+			return data
+		}
+		iter, err := sch.Fields(cue.Optional(true))
+		if err != nil {
+			return data
+		}
+		for iter.Next() {
+			label, field := iter.Selector().Unquoted(), iter.Value()
+			if v, has := x[label]; has {
+				x[label] = applyDefaults(field, v, opts, depth+1)
+				continue
+			}
+			if iter.IsOptional() && !opts.Optional {
+				continue
+			}
+			if d, has := concreteDefault(field); has {
+				x[label] = applyDefaults(field, d, opts, depth+1)
+			} else if !iter.IsOptional() && field.IncompleteKind() == cue.StructKind {
+				// A required struct with no default of its own is only absent if
+				// all of its own fields are defaulted.
+				if m := applyDefaults(field, map[string]any{}, opts, depth+1).(map[string]any); len(m) > 0 {
+					x[label] = m
+				}
+			}
+		}
+	case []any:
+		if !opts.Lists {
+			return data
+		}
+		elem := sch.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() {
+			return data
+		}
+		for idx, v := range x {
+			x[idx] = applyDefaults(elem, v, opts, depth+1)
+		}
+	}
+	return data
+}
+
+// concreteDefault returns the decoded default value of v, if v has a default
+// that is entirely concrete.
+func concreteDefault(v cue.Value) (any, bool) {
+	d, has := v.Default()
+	if !has || d.Validate(cue.Concrete(true)) != nil {
+		return nil, false
+	}
+	var x any
+	if err := d.Decode(&x); err != nil {
+		return nil, false
+	}
+	return x, true
+}
+
+// VerifyDefaultsRoundTrip checks that [Instance.ApplyDefaults] and
+// [Instance.TrimDefaults] behave as inverses for the provided schema. For
+// each instance x, it checks that:
+//
+//	Trim(Apply(x)) == Trim(x)
+//	Apply(Trim(x)) == Apply(x)
+//
+// where Apply fills all defaults, including optional fields and within lists.
+// The checks are run against each of the schema's examples, and also against
+// the instance formed from the schema's defaults alone, if that is concrete.
+//
+// This is intended to be run in the CI for a lineage, catching cases such as
+// disjunctions and lists where the default helpers disagree. An error wrapping
+// [terrors.ErrDefaultsRoundTrip] is returned, describing every failure.
+func VerifyDefaultsRoundTrip(sch Schema) error {
+	insts := sch.Examples()
+	if d, has := concreteDefault(sch.Underlying().LookupPath(pathSchDef)); has {
+		if inst, err := sch.Validate(sch.Underlying().Context().Encode(d)); err == nil {
+			insts["(defaults)"] = inst
+		}
+	}
+
+	names := make([]string, 0, len(insts))
+	for name := range insts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		if err := verifyDefaultsRoundTrip(insts[name]); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Mark(errors.Newf("schema %s: %s", sch.Version(), strings.Join(failures, "; ")), terrors.ErrDefaultsRoundTrip)
+	}
+	return nil
+}
+
+func verifyDefaultsRoundTrip(x *Instance) error {
+	apply := func(i *Instance) (*Instance, error) {
+		return i.ApplyDefaults(ApplyDefaultsOpts{Optional: true, Lists: true})
+	}
+	trim := func(i *Instance) (*Instance, error) {
+		return i.TrimDefaults(TrimDefaultsOpts{})
+	}
+
+	ax, err := apply(x)
+	if err != nil {
+		return err
+	}
+	tx, err := trim(x)
+	if err != nil {
+		return err
+	}
+	tax, err := trim(ax)
+	if err != nil {
+		return err
+	}
+	atx, err := apply(tx)
+	if err != nil {
+		return err
+	}
+
+	if eq, err := instanceDataEqual(tax, tx); err != nil {
+		return err
+	} else if !eq {
+		return errors.Newf("Trim(Apply(x)) != Trim(x)")
+	}
+	if eq, err := instanceDataEqual(atx, ax); err != nil {
+		return err
+	} else if !eq {
+		return errors.Newf("Apply(Trim(x)) != Apply(x)")
+	}
+	return nil
+}
+
+// instanceDataEqual reports whether the data in two instances is equal,
+// irrespective of field order.
+func instanceDataEqual(a, b *Instance) (bool, error) {
+	var ad, bd any
+	if err := a.raw.Decode(&ad); err != nil {
+		return false, err
+	}
+	if err := b.raw.Decode(&bd); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(ad, bd), nil
+}
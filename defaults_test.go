@@ -0,0 +1,152 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	lin := testLin(`name: "defaults"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a:  string | *"x"
+		b?: int | *3
+		c?: string
+		n: m: int | *1
+		l: [...{e: bool | *true, f?: string | *"q"}]
+	}
+}]
+`)
+	inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(`{l: [{}, {e: false}]}`))
+	require.NoError(t, err)
+
+	table := map[string]struct {
+		opts ApplyDefaultsOpts
+		want string
+	}{
+		"required": {
+			want: `{"a": "x", "n": {"m": 1}, "l": [{}, {"e": false}]}`,
+		},
+		"optional": {
+			opts: ApplyDefaultsOpts{Optional: true},
+			want: `{"a": "x", "b": 3, "n": {"m": 1}, "l": [{}, {"e": false}]}`,
+		},
+		"lists": {
+			opts: ApplyDefaultsOpts{Lists: true},
+			want: `{"a": "x", "n": {"m": 1}, "l": [{"e": true}, {"e": false}]}`,
+		},
+		"all": {
+			opts: ApplyDefaultsOpts{Optional: true, Lists: true},
+			want: `{"a": "x", "b": 3, "n": {"m": 1}, "l": [{"e": true, "f": "q"}, {"e": false, "f": "q"}]}`,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ni, err := inst.ApplyDefaults(tt.opts)
+			require.NoError(t, err)
+			b, err := ni.Underlying().MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestDefaultsPreserveOrder(t *testing.T) {
+	lin := testLin(`name: "order"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a: string | *"x"
+		b: int
+		c: string | *"y"
+		l: [...{d: int, e: bool | *true}]
+	}
+}]
+`)
+	inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(`{l: [{e: true, d: 1}], c: "y", b: 2}`))
+	require.NoError(t, err)
+
+	marshal := func(inst *Instance) string {
+		b, err := inst.Underlying().MarshalJSON()
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	trimmed, err := inst.TrimDefaults(TrimDefaultsOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2,"l":[{"d":1}]}`, marshal(trimmed))
+	trimmed, err = inst.TrimDefaults(TrimDefaultsOpts{PreserveOrder: true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"l":[{"d":1}],"b":2}`, marshal(trimmed))
+
+	applied, err := trimmed.ApplyDefaults(ApplyDefaultsOpts{Lists: true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"x","b":2,"c":"y","l":[{"d":1,"e":true}]}`, marshal(applied))
+	applied, err = trimmed.ApplyDefaults(ApplyDefaultsOpts{Lists: true, PreserveOrder: true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"l":[{"d":1,"e":true}],"b":2,"a":"x","c":"y"}`, marshal(applied))
+}
+
+func TestVerifyDefaultsRoundTrip(t *testing.T) {
+	lin := testLin(`name: "roundtrip"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a:  string | *"x"
+		b?: int | *3
+		l: [...{e: bool | *true}]
+	}
+	examples: {
+		empty: {}
+		full: {a: "z", b: 4, l: [{e: false}, {}]}
+	}
+}]
+`)
+	require.NoError(t, VerifyDefaultsRoundTrip(lin.First()))
+}
+
+func TestVerifyDefaultsRoundTripFailure(t *testing.T) {
+	lin := testLin(`name: "roundtrip"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		l: [...({a: int | *1} | {b: string})]
+	}
+	examples: disj: {l: [{b: "x"}]}
+}]
+`)
+	err := VerifyDefaultsRoundTrip(lin.First())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, terrors.ErrDefaultsRoundTrip), "error should be ErrDefaultsRoundTrip, got %s", err)
+	assert.Contains(t, err.Error(), "disj")
+}
+
+func BenchmarkTrimDefaults(b *testing.B) {
+	lin := testLin(`name: "bench"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a: string | *"x"
+		n: m: int | *1
+		l: [...{e: bool | *true, f: int}]
+	}
+}]
+`)
+	data := lin.Runtime().Context().CompileString(`{a: "x", n: m: 2, l: [{e: true, f: 1}, {e: false, f: 2}, {f: 3}]}`)
+	sch := lin.First()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := TrimDefaults(sch, data, TrimDefaultsOpts{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package thema
+
+import (
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+)
+
+// A FieldChangeKind classifies how a field differs between two schemas.
+type FieldChangeKind string
+
+const (
+	// FieldAdded indicates a field present only in the later schema.
+	FieldAdded FieldChangeKind = "added"
+	// FieldRemoved indicates a field present only in the earlier schema.
+	FieldRemoved FieldChangeKind = "removed"
+	// FieldRenamed indicates a field removed from the earlier schema that is
+	// likely to have been renamed to a field added in the later schema. Renames
+	// are inferred heuristically, and should be confirmed by a human.
+	FieldRenamed FieldChangeKind = "renamed"
+	// FieldLoosened indicates a field whose constraints in the later schema
+	// accept all values accepted by the earlier schema, and more.
+	FieldLoosened FieldChangeKind = "loosened"
+	// FieldTightened indicates a field whose constraints in the later schema
+	// accept only a subset of the values accepted by the earlier schema.
+	FieldTightened FieldChangeKind = "tightened"
+	// FieldRetyped indicates a field whose constraints in either schema accept
+	// values the other does not.
+	FieldRetyped FieldChangeKind = "retyped"
+)
+
+// A FieldChange describes a difference in a single field between two schemas.
+type FieldChange struct {
+	Kind FieldChangeKind `json:"kind"`
+
+	// Path is the path to the field, in the form accepted by
+	// [Schema.Attributes]. For removed fields, this is the path in the earlier
+	// schema; otherwise, it is the path in the later schema.
+	Path string `json:"path"`
+
+	// RenamedFrom is the path to the field in the earlier schema, for renamed
+	// fields.
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+
+	// From and To are the CUE expressions constraining the field in the
+	// earlier and later schemas, respectively, where the field exists. They
+	// are prefixed with "?" where the field is optional.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	// Score is the confidence of a rename suggestion, between 0 and 1.
+	Score float64 `json:"score,omitempty"`
+
+	// Reasons lists the evidence for a rename suggestion.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// A SchemaDiff reports the differences between the fields of two schemas.
+type SchemaDiff struct {
+	From    SyntacticVersion `json:"from"`
+	To      SyntacticVersion `json:"to"`
+	Changes []FieldChange    `json:"changes"`
+}
+
+// Renames returns the changes in the diff that are suggested renames.
+func (d *SchemaDiff) Renames() []FieldChange {
+	var renames []FieldChange
+	for _, c := range d.Changes {
+		if c.Kind == FieldRenamed {
+			renames = append(renames, c)
+		}
+	}
+	return renames
+}
+
+// minRenameScore is the score below which a removed and added field pair is
+// not suggested as a rename. It is reached by a pair with identical
+// constraints and no other evidence.
+const minRenameScore = 0.5
+
+// DiffSchemas reports how the fields of schema to differ from those of schema
+// from. Changes are ordered by path. Where a struct field's fields differ,
+// changes are reported for those fields, rather than for the struct.
+//
+// Fields removed from a struct are heuristically compared with those added to
+// the same struct, and proposed as renames where the similarity of their names,
+// constraints and doc comments suggests so. Each field is proposed in at most
+// one rename, preferring the most likely.
+func DiffSchemas(from, to Schema) *SchemaDiff {
+	d := &SchemaDiff{From: from.Version(), To: to.Version()}
+	diffStruct(d, from.Underlying().LookupPath(pathSchDef), to.Underlying().LookupPath(pathSchDef), nil)
+	sort.SliceStable(d.Changes, func(i, j int) bool {
+		pi, pj := strings.Split(d.Changes[i].Path, "."), strings.Split(d.Changes[j].Path, ".")
+		for k := 0; k < len(pi) && k < len(pj); k++ {
+			if pi[k] != pj[k] {
+				return pi[k] < pj[k]
+			}
+		}
+		return len(pi) < len(pj)
+	})
+	return d
+}
+
+type diffField struct {
+	name     string
+	v        cue.Value
+	optional bool
+}
+
+func structFields(v cue.Value) []diffField {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil
+	}
+	var fields []diffField
+	for iter.Next() {
+		fields = append(fields, diffField{name: iter.Selector().Unquoted(), v: iter.Value(), optional: iter.IsOptional()})
+	}
+	return fields
+}
+
+func diffStruct(d *SchemaDiff, fromv, tov cue.Value, path []string) {
+	fromFields, toFields := structFields(fromv), structFields(tov)
+	byName := make(map[string]diffField, len(fromFields))
+	for _, f := range fromFields {
+		byName[f.name] = f
+	}
+
+	var added, removed []diffField
+	var nested [][2]diffField
+	declared := make(map[string]bool, len(toFields))
+	for _, tf := range toFields {
+		declared[tf.name] = true
+		ff, has := byName[tf.name]
+		if !has {
+			added = append(added, tf)
+			continue
+		}
+		fpath := joinPath(path, tf.name)
+		kind := compareFields(ff, tf)
+		switch {
+		case kind == "":
+		case isPlainStruct(ff.v) && isPlainStruct(tf.v):
+			// Describe changes to the struct's fields, rather than the struct
+			if ff.optional != tf.optional {
+				d.Changes = append(d.Changes, FieldChange{Kind: optionalityChange(tf), Path: fpath, From: fieldConstraint(ff), To: fieldConstraint(tf)})
+			}
+			nested = append(nested, [2]diffField{ff, tf})
+		default:
+			d.Changes = append(d.Changes, FieldChange{Kind: kind, Path: fpath, From: fieldConstraint(ff), To: fieldConstraint(tf)})
+		}
+	}
+	for _, ff := range fromFields {
+		if !declared[ff.name] {
+			removed = append(removed, ff)
+		}
+	}
+
+	renamed := make(map[string]bool)
+	for _, r := range suggestRenames(removed, added) {
+		renamed[r.from.name], renamed[r.to.name] = true, true
+		d.Changes = append(d.Changes, FieldChange{
+			Kind:        FieldRenamed,
+			Path:        joinPath(path, r.to.name),
+			RenamedFrom: joinPath(path, r.from.name),
+			From:        fieldConstraint(r.from),
+			To:          fieldConstraint(r.to),
+			Score:       r.score,
+			Reasons:     r.reasons,
+		})
+	}
+	for _, f := range added {
+		if !renamed[f.name] {
+			d.Changes = append(d.Changes, FieldChange{Kind: FieldAdded, Path: joinPath(path, f.name), To: fieldConstraint(f)})
+		}
+	}
+	for _, f := range removed {
+		if !renamed[f.name] {
+			d.Changes = append(d.Changes, FieldChange{Kind: FieldRemoved, Path: joinPath(path, f.name), From: fieldConstraint(f)})
+		}
+	}
+
+	for _, pair := range nested {
+		diffStruct(d, pair[0].v, pair[1].v, append(path[:len(path):len(path)], pair[1].name))
+	}
+}
+
+// compareFields classifies the change from ff to tf, returning the empty
+// string if they are equivalent.
+func compareFields(ff, tf diffField) FieldChangeKind {
+	looser := tf.v.Subsume(ff.v, cue.Raw(), cue.All()) == nil && (!ff.optional || tf.optional)
+	tighter := ff.v.Subsume(tf.v, cue.Raw(), cue.All()) == nil && (!tf.optional || ff.optional)
+	switch {
+	case looser && tighter:
+		return ""
+	case looser:
+		return FieldLoosened
+	case tighter:
+		return FieldTightened
+	default:
+		return FieldRetyped
+	}
+}
+
+func optionalityChange(tf diffField) FieldChangeKind {
+	if tf.optional {
+		return FieldLoosened
+	}
+	return FieldTightened
+}
+
+// isPlainStruct reports whether v is a struct whose fields are not constrained
+// by a pattern.
+func isPlainStruct(v cue.Value) bool {
+	return v.IncompleteKind() == cue.StructKind && !v.LookupPath(cue.MakePath(cue.AnyString)).Exists()
+}
+
+func fieldConstraint(f diffField) string {
+	s := "struct"
+	if f.v.IncompleteKind() != cue.StructKind {
+		if b, err := format.Node(f.v.Syntax(cue.Raw())); err == nil {
+			s = strings.Join(strings.Fields(string(b)), " ")
+		} else {
+			s = f.v.IncompleteKind().String()
+		}
+	}
+	if f.optional {
+		return "?" + s
+	}
+	return s
+}
+
+func joinPath(path []string, name string) string {
+	return strings.Join(append(path[:len(path):len(path)], name), ".")
+}
+
+type renameSuggestion struct {
+	from, to diffField
+	score    float64
+	reasons  []string
+}
+
+// suggestRenames pairs removed fields with added fields that are likely to be
+// their new names.
+func suggestRenames(removed, added []diffField) []renameSuggestion {
+	var all []renameSuggestion
+	for _, ff := range removed {
+		for _, tf := range added {
+			if s, ok := scoreRename(ff, tf); ok {
+				all = append(all, s)
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].score > all[j].score
+	})
+
+	var picked []renameSuggestion
+	used := make(map[string]bool)
+	for _, s := range all {
+		if used["-"+s.from.name] || used["+"+s.to.name] {
+			continue
+		}
+		used["-"+s.from.name], used["+"+s.to.name] = true, true
+		picked = append(picked, s)
+	}
+	sort.SliceStable(picked, func(i, j int) bool {
+		return picked[i].to.name < picked[j].to.name
+	})
+	return picked
+}
+
+// scoreRename scores the likelihood that ff was renamed to tf, from the
+// similarity of their names, constraints and doc comments. Fields of different
+// kinds are never considered renames.
+func scoreRename(ff, tf diffField) (renameSuggestion, bool) {
+	if ff.v.IncompleteKind() != tf.v.IncompleteKind() {
+		return renameSuggestion{}, false
+	}
+	s := renameSuggestion{from: ff, to: tf}
+
+	var typeScore float64
+	if compareFields(ff, tf) == "" {
+		typeScore = 1
+		s.reasons = append(s.reasons, "identical constraints")
+	} else {
+		typeScore = 0.5
+		s.reasons = append(s.reasons, "same kind")
+	}
+
+	nameScore := nameSimilarity(ff.name, tf.name)
+	if nameScore >= 0.5 {
+		s.reasons = append(s.reasons, "similar names")
+	}
+
+	fromDoc, toDoc := docText(ff.v), docText(tf.v)
+	if fromDoc == "" || toDoc == "" {
+		s.score = 0.5*typeScore + 0.5*nameScore
+	} else {
+		docScore := wordSimilarity(fromDoc, toDoc)
+		if docScore >= 0.5 {
+			s.reasons = append(s.reasons, "similar doc comments")
+		}
+		s.score = 0.4*typeScore + 0.3*nameScore + 0.3*docScore
+	}
+	return s, s.score >= minRenameScore
+}
+
+func docText(v cue.Value) string {
+	var docs []string
+	for _, cg := range v.Doc() {
+		docs = append(docs, strings.TrimSpace(cg.Text()))
+	}
+	return strings.Join(docs, "\n")
+}
+
+// nameSimilarity returns the similarity of two field names between 0 and 1,
+// disregarding case and separators. Names where one contains the other, such
+// as "name" and "displayName", are considered highly similar.
+func nameSimilarity(a, b string) float64 {
+	norm := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(s))
+	}
+	a, b = norm(a), norm(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	long := len(a)
+	if len(b) > long {
+		long = len(b)
+	}
+	sim := 1 - float64(levenshtein(a, b))/float64(long)
+	if (strings.Contains(a, b) || strings.Contains(b, a)) && sim < 0.75 {
+		sim = 0.75
+	}
+	return sim
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// wordSimilarity returns the Jaccard similarity of the sets of words in a and b.
+func wordSimilarity(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		m := make(map[string]bool)
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+		}) {
+			m[w] = true
+		}
+		return m
+	}
+	wa, wb := words(a), words(b)
+	var inter int
+	for w := range wa {
+		if wb[w] {
+			inter++
+		}
+	}
+	union := len(wa) + len(wb) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
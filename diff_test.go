@@ -0,0 +1,79 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	lin := testLin(`name: "diff"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The title of the dashboard.
+		title: string
+		kind:  string
+		count: int
+		gone:  bool
+		spec: {
+			userName: string
+			level:    int
+		}
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		// The title of the dashboard, as shown.
+		name:  string
+		kind:  "a" | "b"
+		count: string
+		spec: {
+			displayUserName: string
+			level?:          int
+		}
+		added: [...string]
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: {title: input.name, kind: input.kind, count: 0, gone: true, spec: userName: input.spec.displayUserName, spec: level: 0}
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {name: input.title, kind: "a", count: "0", spec: displayUserName: input.spec.userName, added: []}
+}]
+`)
+
+	d := DiffSchemas(lin.First(), lin.Latest())
+	assert.Equal(t, SyntacticVersion{0, 0}, d.From)
+	assert.Equal(t, SyntacticVersion{1, 0}, d.To)
+
+	type change struct {
+		kind       FieldChangeKind
+		path, from string
+	}
+	var got []change
+	for _, c := range d.Changes {
+		got = append(got, change{c.Kind, c.Path, c.RenamedFrom})
+	}
+	assert.Equal(t, []change{
+		{FieldAdded, "added", ""},
+		{FieldRetyped, "count", ""},
+		{FieldRemoved, "gone", ""},
+		{FieldTightened, "kind", ""},
+		{FieldRenamed, "name", "title"},
+		{FieldRenamed, "spec.displayUserName", "spec.userName"},
+		{FieldLoosened, "spec.level", ""},
+	}, got)
+
+	renames := d.Renames()
+	assert.Len(t, renames, 2)
+	assert.Equal(t, []string{"identical constraints", "similar doc comments"}, renames[0].Reasons)
+	assert.Equal(t, []string{"identical constraints", "similar names"}, renames[1].Reasons)
+	assert.Greater(t, renames[1].Score, renames[0].Score)
+	assert.Equal(t, "?int", d.Changes[6].To)
+}
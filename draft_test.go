@@ -0,0 +1,72 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestDraftGate(t *testing.T) {
+	lin := testLin(maturitylinstr)
+	ctx := lin.Runtime().Context()
+	assert.False(t, IsDraft(lin.First()))
+	assert.True(t, IsDraft(SchemaP(lin, SV(1, 0))))
+	assert.True(t, IsDraft(lin.Latest()))
+
+	for name, tc := range map[string]struct {
+		gate   DraftGate
+		newest SyntacticVersion
+	}{
+		"zero value":    {DraftGate{}, SV(0, 0)},
+		"one draft":     {EnableDrafts(SV(1, 0)), SV(1, 0)},
+		"newest draft":  {EnableDrafts(SV(1, 0), SV(1, 1)), SV(1, 1)},
+		"unknown draft": {EnableDrafts(SV(3, 0)), SV(0, 0)},
+		"all drafts":    {EnableAllDrafts(), SV(1, 1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sch, err := Find(lin, WithDraftGate(tc.gate))
+			require.NoError(t, err)
+			assert.Equal(t, tc.newest, sch.Version())
+			assert.NoError(t, tc.gate.Check(sch))
+		})
+	}
+
+	gate := EnableDrafts(SV(1, 0))
+	inst, _, err := SearchAndValidate(lin, ctx.CompileString(`{ name: "foo", count: 1 }`), WithDraftGate(gate))
+	assert.True(t, errors.Is(err, terrors.ErrInvalidData), "1.1 data must not match while 1.1 is gated")
+	assert.Nil(t, inst)
+
+	inst, _, err = SearchAndValidate(lin, ctx.CompileString(`{ title: "foo" }`), WithDraftGate(gate))
+	require.NoError(t, err)
+	tinst, _, err := gate.Translate(inst, SV(1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, SV(1, 0), tinst.Schema().Version())
+
+	_, _, err = gate.Translate(inst, SV(1, 1))
+	assert.True(t, errors.Is(err, terrors.ErrDraftNotEnabled))
+	_, _, err = gate.Translate(inst, SV(4, 0))
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+}
+
+func TestParseDraftGate(t *testing.T) {
+	lin := testLin(maturitylinstr)
+	for s, want := range map[string]SyntacticVersion{
+		"":         SV(0, 0),
+		"*":        SV(1, 1),
+		" 1.0 ":    SV(1, 0),
+		"1.1, 1.0": SV(1, 1),
+	} {
+		g, err := ParseDraftGate(s)
+		require.NoError(t, err, s)
+		sch, err := Find(lin, WithDraftGate(g))
+		require.NoError(t, err, s)
+		assert.Equal(t, want, sch.Version(), s)
+	}
+
+	_, err := ParseDraftGate("beta")
+	assert.True(t, errors.Is(err, terrors.ErrMalformedSyntacticVersion))
+}
@@ -0,0 +1,268 @@
+package thema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// maxDriftExamples is the number of example messages reported per near-miss by
+// [AnalyzeDrift].
+const maxDriftExamples = 3
+
+// A DriftReport describes how a corpus of data has drifted from a schema, as
+// returned from [AnalyzeDrift].
+type DriftReport struct {
+	Version SyntacticVersion `json:"version"`
+
+	// Total is the number of values in the corpus.
+	Total int `json:"total"`
+
+	// Valid is the number of values that passed lenient validation without
+	// warnings.
+	Valid int `json:"valid"`
+
+	// Warned is the number of values that passed lenient validation with
+	// warnings.
+	Warned int `json:"warned"`
+
+	// Invalid is the number of values that failed lenient validation.
+	Invalid int `json:"invalid"`
+
+	// UnknownFields lists the fields present in the data that the schema does
+	// not declare, most common first.
+	UnknownFields []UnknownField `json:"unknownFields,omitempty"`
+
+	// NearMisses lists the paths at which the data failed the schema's
+	// constraints, whether as warnings or errors, most common first.
+	NearMisses []NearMiss `json:"nearMisses,omitempty"`
+}
+
+// An UnknownField is a field present in data that its schema does not declare.
+type UnknownField struct {
+	// Path is the dot-separated path to the field. Elements of lists are
+	// denoted by "*".
+	Path string `json:"path"`
+
+	// Count is the number of values in the corpus containing the field.
+	Count int `json:"count"`
+
+	// Kinds lists the kinds of value observed for the field.
+	Kinds []string `json:"kinds"`
+
+	// Candidate is a CUE field declaration proposed for addition to the
+	// schema's next minor version, such as `color?: string`. Empty where the
+	// field's parent is itself unknown, as the parent's candidate covers it.
+	Candidate string `json:"candidate,omitempty"`
+}
+
+// A NearMiss is a path at which values in a corpus failed the constraints of a
+// schema.
+type NearMiss struct {
+	// Path is the dot-separated path to the field, or empty for failures not
+	// attributed to a field.
+	Path string `json:"path"`
+
+	// Code classifies the failure.
+	Code terrors.ValidationCode `json:"code"`
+
+	// Count is the number of values that failed at the path.
+	Count int `json:"count"`
+
+	// Advisory is the number of those failures that were of constraints marked
+	// with @thema(advisory), and so were warnings rather than errors.
+	Advisory int `json:"advisory"`
+
+	// Examples holds a sample of the failure messages, in corpus order.
+	Examples []string `json:"examples"`
+}
+
+// AnalyzeDrift validates each value in the corpus against the schema, and
+// aggregates the fields the schema does not declare and the failures of its
+// constraints across the corpus. Values are classified as by
+// [Schema.ValidateLenient], with failures of advisory constraints counting as
+// warnings, though such failures are reported even for values that are
+// otherwise invalid.
+//
+// It is intended for finding where data in production has drifted from its
+// schema. Unknown fields commonly present in the data are candidates for
+// addition to the next minor version of the schema, and constraints that data
+// frequently narrowly fails are candidates for loosening. As adding a field is
+// only backwards compatible if it is optional, candidates are always declared
+// optional.
+func AnalyzeDrift(sch Schema, corpus []cue.Value) *DriftReport {
+	sd := sch.(*schemaDef)
+	r := &DriftReport{Version: sch.Version(), Total: len(corpus)}
+	def := sch.Underlying().LookupPath(pathSchDef)
+
+	unknown := make(map[string]*driftField)
+	misses := make(map[string]*NearMiss)
+	for _, v := range corpus {
+		// Validate strictly, rather than leniently, so that failures of advisory
+		// constraints are reported alongside any others
+		_, err := sd.Validate(v)
+		issues := ValidationIssues(err)
+		advisory := make([]bool, len(issues))
+		hard := false
+		sd.rt().rl()
+		for i, issue := range issues {
+			advisory[i] = issue.Code != 0 && sd.isAdvisory(issue.Path)
+			hard = hard || !advisory[i]
+		}
+		sd.rt().ru()
+		switch {
+		case hard:
+			r.Invalid++
+		case len(issues) > 0:
+			r.Warned++
+		default:
+			r.Valid++
+		}
+
+		seen := make(map[string]bool)
+		for i, issue := range issues {
+			if issue.Code == terrors.ExcessField {
+				// Reported as unknown fields
+				continue
+			}
+			p := strings.Join(issue.Path, ".")
+			key := p + "\x00" + issue.Code.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			nm, has := misses[key]
+			if !has {
+				nm = &NearMiss{Path: p, Code: issue.Code}
+				misses[key] = nm
+			}
+			nm.Count++
+			if advisory[i] {
+				nm.Advisory++
+			}
+			if len(nm.Examples) < maxDriftExamples {
+				nm.Examples = append(nm.Examples, issue.Message)
+			}
+		}
+
+		found := make(map[string]cue.Value)
+		findUnknown(def, v, nil, found)
+		for p, fv := range found {
+			df, has := unknown[p]
+			if !has {
+				df = &driftField{kinds: make(map[cue.Kind]bool)}
+				unknown[p] = df
+			}
+			df.count++
+			df.kinds[fv.Kind()] = true
+		}
+	}
+
+	for p, df := range unknown {
+		uf := UnknownField{Path: p, Count: df.count, Kinds: df.kindNames()}
+		parts := strings.Split(p, ".")
+		if _, has := unknown[strings.Join(parts[:len(parts)-1], ".")]; len(parts) == 1 || !has {
+			uf.Candidate = cueLabel(parts[len(parts)-1]) + "?: " + df.constraint()
+		}
+		r.UnknownFields = append(r.UnknownFields, uf)
+	}
+	sort.Slice(r.UnknownFields, func(i, j int) bool {
+		fi, fj := r.UnknownFields[i], r.UnknownFields[j]
+		if fi.Count != fj.Count {
+			return fi.Count > fj.Count
+		}
+		return fi.Path < fj.Path
+	})
+
+	for _, nm := range misses {
+		r.NearMisses = append(r.NearMisses, *nm)
+	}
+	sort.Slice(r.NearMisses, func(i, j int) bool {
+		mi, mj := r.NearMisses[i], r.NearMisses[j]
+		if mi.Count != mj.Count {
+			return mi.Count > mj.Count
+		}
+		if mi.Path != mj.Path {
+			return mi.Path < mj.Path
+		}
+		return mi.Code < mj.Code
+	})
+	return r
+}
+
+// findUnknown records in found each field within the data v, at path, that
+// the schema def does not declare, along with those fields' descendants.
+func findUnknown(def, v cue.Value, path []string, found map[string]cue.Value) {
+	switch v.Kind() {
+	case cue.StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			fpath := append(path[:len(path):len(path)], iter.Selector().Unquoted())
+			if _, _, has := lookupField(def, fpath); !has {
+				found[strings.Join(fpath, ".")] = iter.Value()
+			}
+			findUnknown(def, iter.Value(), fpath, found)
+		}
+	case cue.ListKind:
+		iter, err := v.List()
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			findUnknown(def, iter.Value(), append(path[:len(path):len(path)], "*"), found)
+		}
+	}
+}
+
+type driftField struct {
+	count int
+	kinds map[cue.Kind]bool
+}
+
+func (df *driftField) kindNames() []string {
+	var names []string
+	for _, k := range []cue.Kind{cue.NullKind, cue.BoolKind, cue.IntKind, cue.FloatKind, cue.StringKind, cue.BytesKind, cue.StructKind, cue.ListKind} {
+		if df.kinds[k] {
+			names = append(names, k.String())
+		}
+	}
+	return names
+}
+
+// constraint returns the loosest CUE constraint accepting the observed kinds.
+func (df *driftField) constraint() string {
+	var parts []string
+	for _, name := range df.kindNames() {
+		switch name {
+		case "int":
+			if df.kinds[cue.FloatKind] {
+				continue
+			}
+		case "float":
+			name = "number"
+		case "struct":
+			name = "{...}"
+		case "list":
+			name = "[..._]"
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// cueLabel returns name as a CUE field label, quoted if necessary.
+func cueLabel(name string) string {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") {
+		return name
+	}
+	return strconv.Quote(name)
+}
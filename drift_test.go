@@ -0,0 +1,54 @@
+package thema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestAnalyzeDrift(t *testing.T) {
+	lin := testLin(lenientlinstr)
+	ctx := lin.Runtime().Context()
+
+	var corpus []cue.Value
+	for _, s := range []string{
+		`{name: "ok", count: 3, nested: {inner: 1}}`,
+		`{name: "UPPER", count: 3, nested: {inner: 1}}`,
+		`{name: "Mixed", count: 12, nested: {inner: 1}}`,
+		`{name: "ok", count: 3, nested: {inner: 1}, color: "red"}`,
+		`{name: "ok", count: 3, nested: {inner: 1, extra: 1.5}, color: "blue", meta: {owner: "me"}}`,
+	} {
+		corpus = append(corpus, ctx.CompileString(s))
+	}
+
+	r := AnalyzeDrift(lin.First(), corpus)
+	assert.Equal(t, 5, r.Total)
+	assert.Equal(t, 1, r.Valid)
+	assert.Equal(t, 1, r.Warned)
+	assert.Equal(t, 3, r.Invalid)
+
+	assert.Equal(t, []UnknownField{
+		{Path: "color", Count: 2, Kinds: []string{"string"}, Candidate: "color?: string"},
+		{Path: "meta", Count: 1, Kinds: []string{"struct"}, Candidate: "meta?: {...}"},
+		{Path: "meta.owner", Count: 1, Kinds: []string{"string"}},
+		{Path: "nested.extra", Count: 1, Kinds: []string{"float"}, Candidate: "extra?: number"},
+	}, r.UnknownFields)
+
+	require.Len(t, r.NearMisses, 2)
+	assert.Equal(t, "name", r.NearMisses[0].Path)
+	assert.Equal(t, terrors.OutOfBounds, r.NearMisses[0].Code)
+	assert.Equal(t, 2, r.NearMisses[0].Count)
+	assert.Equal(t, 2, r.NearMisses[0].Advisory)
+	assert.Len(t, r.NearMisses[0].Examples, 2)
+	assert.Equal(t, "count", r.NearMisses[1].Path)
+	assert.Equal(t, 0, r.NearMisses[1].Advisory)
+
+	b, err := json.Marshal(r.NearMisses[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"code":"OutOfBounds"`)
+}
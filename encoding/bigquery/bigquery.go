@@ -0,0 +1,109 @@
+// Package bigquery generates BigQuery table schemas from Thema schemas.
+package bigquery
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/columnar"
+)
+
+// Config controls BigQuery table schema generation from a Thema schema.
+type Config struct {
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated table schema, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []cue.Path
+}
+
+// Field is a field of a BigQuery table schema, as accepted by the bq command
+// line tool and the BigQuery API.
+type Field struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Mode        string   `json:"mode"`
+	Description string   `json:"description,omitempty"`
+	Fields      []*Field `json:"fields,omitempty"`
+}
+
+// GenerateSchema generates the JSON representation of a BigQuery table
+// schema, in which instances of the provided schema may be stored.
+//
+// Fields are REQUIRED unless they are optional or may be null. Structs are
+// represented as RECORDs, and lists as REPEATED fields. Values that cannot be
+// represented by BigQuery's types - maps, lists of lists, disjunctions of
+// several kinds, and unconstrained values - are represented as JSON. Field
+// names are sanitized to contain only letters, digits and underscores.
+func GenerateSchema(sch thema.Schema, cfg *Config) ([]byte, error) {
+	fields, err := Fields(sch, cfg)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Fields returns the fields of a BigQuery table schema, as generated by
+// [GenerateSchema].
+func Fields(sch thema.Schema, cfg *Config) ([]*Field, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cols, err := columnar.Fields(sch, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return convertFields(cols), nil
+}
+
+func convertFields(cols []*columnar.Field) []*Field {
+	fields := make([]*Field, 0, len(cols))
+	for _, c := range cols {
+		f := convert(c)
+		f.Name = columnar.Ident(c.Name)
+		f.Description = c.Doc
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func convert(c *columnar.Field) *Field {
+	f := &Field{Mode: "REQUIRED"}
+	if c.Nullable {
+		f.Mode = "NULLABLE"
+	}
+
+	switch c.Type {
+	case columnar.String:
+		f.Type = "STRING"
+	case columnar.Int:
+		f.Type = "INT64"
+	case columnar.Float:
+		f.Type = "FLOAT64"
+	case columnar.Bool:
+		f.Type = "BOOL"
+	case columnar.Bytes:
+		f.Type = "BYTES"
+	case columnar.Record:
+		f.Type = "RECORD"
+		f.Fields = convertFields(c.Fields)
+	case columnar.List:
+		// BigQuery has no arrays of arrays, and arrays may not contain nulls
+		if c.Elem.Type == columnar.List {
+			f.Type = "JSON"
+			break
+		}
+		elem := convert(c.Elem)
+		f.Type, f.Fields = elem.Type, elem.Fields
+		f.Mode = "REPEATED"
+	case columnar.Map, columnar.JSON:
+		f.Type = "JSON"
+	default:
+		panic(fmt.Sprintf("unhandled columnar type %d", c.Type))
+	}
+	return f
+}
@@ -0,0 +1,69 @@
+package bigquery
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLineage = `
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The title of the dashboard.
+		title: string
+		"1st-rank"?: int
+		ratio: number | *1.5
+		hidden: bool | null
+		style: "light" | "dark" | *"dark"
+		thumbnail?: bytes
+		tags: [...string] | *[]
+		grid: [...[...int]]
+		panels: [...#Panel]
+		labels: [string]: string
+		any: _
+		meta: {
+			internal: bool
+			owner: string
+		}
+		#Panel: {
+			type: string
+			legacy?: bool
+		}
+	}
+}]
+`
+
+func TestGenerateSchema(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(testLineage), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateSchema(lin.Latest(), &Config{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+  {"name": "title", "type": "STRING", "mode": "REQUIRED", "description": "The title of the dashboard."},
+  {"name": "_1st_rank", "type": "INT64", "mode": "NULLABLE"},
+  {"name": "ratio", "type": "FLOAT64", "mode": "REQUIRED"},
+  {"name": "hidden", "type": "BOOL", "mode": "NULLABLE"},
+  {"name": "style", "type": "STRING", "mode": "REQUIRED"},
+  {"name": "thumbnail", "type": "BYTES", "mode": "NULLABLE"},
+  {"name": "tags", "type": "STRING", "mode": "REPEATED"},
+  {"name": "grid", "type": "JSON", "mode": "REQUIRED"},
+  {"name": "panels", "type": "RECORD", "mode": "REPEATED", "fields": [
+    {"name": "type", "type": "STRING", "mode": "REQUIRED"}
+  ]},
+  {"name": "labels", "type": "JSON", "mode": "REQUIRED"},
+  {"name": "any", "type": "JSON", "mode": "REQUIRED"},
+  {"name": "meta", "type": "RECORD", "mode": "REQUIRED", "fields": [
+    {"name": "owner", "type": "STRING", "mode": "REQUIRED"}
+  ]}
+]`, string(b))
+}
@@ -0,0 +1,108 @@
+// Package cbor provides a CBOR (RFC 8949) codec for Thema instances.
+//
+// Only the subset of CBOR that corresponds to the CUE data model is supported:
+// integers, floats, text and byte strings, arrays, maps keyed by text strings,
+// booleans and null. Tags are accepted on input and ignored, except for
+// bignums, which are decoded as integers, and date/times, which have no CUE
+// equivalent and are decoded as strings in their RFC 3339 form, in UTC.
+package cbor
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/grafana/thema"
+)
+
+// maxDepth is the maximum nesting depth of arrays and maps that will be
+// decoded.
+const maxDepth = 512
+
+var (
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+)
+
+func init() {
+	var err error
+	if encMode, err = (cbor.EncOptions{Sort: cbor.SortCoreDeterministic}).EncMode(); err != nil {
+		panic(err)
+	}
+	if decMode, err = (cbor.DecOptions{
+		MaxNestedLevels: maxDepth,
+		DefaultMapType:  reflect.TypeOf(map[string]any(nil)),
+	}).DecMode(); err != nil {
+		panic(err)
+	}
+}
+
+// Marshal encodes the data in the provided instance as CBOR. Map keys are
+// sorted as in the core deterministic encoding of RFC 8949, so the output is
+// deterministic.
+func Marshal(inst *thema.Instance) ([]byte, error) {
+	var x any
+	if err := inst.Underlying().Decode(&x); err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(x)
+}
+
+// Unmarshal decodes CBOR-encoded data and validates it against the provided
+// schema, exactly as if the equivalent JSON had been passed to
+// [thema.Schema.Validate].
+func Unmarshal(b []byte, sch thema.Schema) (*thema.Instance, error) {
+	v, err := Decode(sch.Underlying().Context(), b)
+	if err != nil {
+		return nil, err
+	}
+	return sch.Validate(v)
+}
+
+// Decode decodes CBOR-encoded data into a [cue.Value] built by the provided
+// context, readying it for a call to [thema.Schema.Validate].
+func Decode(ctx *cue.Context, b []byte) (cue.Value, error) {
+	var x any
+	if err := decMode.Unmarshal(b, &x); err != nil {
+		return cue.Value{}, err
+	}
+	x, err := export(x)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	return ctx.Encode(x), nil
+}
+
+// export converts the values in x that have no direct CUE equivalent.
+func export(x any) (any, error) {
+	switch v := x.(type) {
+	case cbor.Tag:
+		return export(v.Content)
+	case big.Int:
+		return &v, nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case cbor.SimpleValue:
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", v)
+	case map[string]any:
+		for k, e := range v {
+			ex, err := export(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = ex
+		}
+	case []any:
+		for i, e := range v {
+			ex, err := export(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ex
+		}
+	}
+	return x, nil
+}
@@ -0,0 +1,159 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rt = thema.NewRuntime(cuecontext.New())
+
+const roundTripLineage = `
+name: "roundtrip"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		small:  int
+		neg:    int
+		big:    uint64
+		min:    int64
+		f:      float
+		s:      string
+		long:   string
+		by:     bytes
+		n:      null
+		t:      bool
+		l: [...{k: string, v?: [...int]}]
+		m: [string]: number
+	}
+}]
+`
+
+var roundTripData = `{
+	small: 7
+	neg:   -100000
+	big:   18446744073709551615
+	min:   -9223372036854775808
+	f:     -2.5e-3
+	s:     "héllo"
+	long:  "` + strings.Repeat("x", 70000) + `"
+	by:    'bytes'
+	n:     null
+	t:     true
+	l: [{k: "a", v: [1, 2, 3]}, {k: "b"}]
+	m: {one: 1, two: 2.5}
+}`
+
+func TestRoundTrip(t *testing.T) {
+	lin, err := thema.BindLineage(rt.Context().CompileString(roundTripLineage), rt)
+	require.NoError(t, err)
+	inst, err := lin.First().Validate(rt.Context().CompileString(roundTripData))
+	require.NoError(t, err)
+
+	b, err := Marshal(inst)
+	require.NoError(t, err)
+	rinst, err := Unmarshal(b, lin.First())
+	require.NoError(t, err)
+
+	want, err := inst.Underlying().MarshalJSON()
+	require.NoError(t, err)
+	got, err := rinst.Underlying().MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+func TestUnmarshal(t *testing.T) {
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "cbortest"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a: int
+		b: [...number]
+		c?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	sch := lin.First()
+
+	table := map[string]struct {
+		hex  string
+		json string
+		err  bool
+	}{
+		"definite": {
+			// {"a": 1, "b": [2, 3.5]}
+			hex:  "a2616101616282 02 fb400c000000000000",
+			json: `{"a": 1, "b": [2, 3.5]}`,
+		},
+		"indefinite": {
+			// {_ "a": -500, "b": [_ 1.5 (half)], "c": (_ "x", "y")}
+			hex:  "bf 6161 3901f3 6162 9f f93e00 ff 6163 7f 6178 6179 ff ff",
+			json: `{"a": -500, "b": [1.5], "c": "xy"}`,
+		},
+		"tagged": {
+			// {"a": 55799(1000), "b": []}
+			hex:  "a2 6161 d9d9f7 1903e8 6162 80",
+			json: `{"a": 1000, "b": []}`,
+		},
+		"date/time": {
+			// {"a": 1, "b": [], "c": 1(1000)}
+			hex:  "a3 6161 01 6162 80 6163 c1 1903e8",
+			json: `{"a": 1, "b": [], "c": "1970-01-01T00:16:40Z"}`,
+		},
+		"bignum": {
+			// {"a": 2(h'01'), "b": []}
+			hex:  "a2 6161 c2 4101 6162 80",
+			json: `{"a": 1, "b": []}`,
+		},
+		"bytes for string": {
+			// {"a": 1, "b": [], "c": h'78'}
+			hex: "a3 6161 01 6162 80 6163 4178",
+			err: true,
+		},
+		"invalid for schema": {
+			// {"a": "1", "b": []}
+			hex: "a2 6161 6131 6162 80",
+			err: true,
+		},
+		"truncated": {
+			hex: "a2 6161 01 6162 82 02",
+			err: true,
+		},
+		"trailing": {
+			hex: "a2 6161 01 6162 80 00",
+			err: true,
+		},
+		"non-string key": {
+			hex: "a1 01 01",
+			err: true,
+		},
+		"too deep": {
+			hex: strings.Repeat("81", 1000) + "01",
+			err: true,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(strings.ReplaceAll(tt.hex, " ", ""))
+			require.NoError(t, err)
+			inst, err := Unmarshal(b, sch)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got, err := inst.Underlying().MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.json, string(got))
+		})
+	}
+}
@@ -0,0 +1,304 @@
+// Package codegen composes code generators ("jennies") into a pipeline that
+// runs them against a lineage and writes their output.
+//
+// The generators in the other encoding packages each produce the contents of a
+// single file. Wrapping them, along with any custom generators, in a
+// [Pipeline] allows the whole set of generated files for a lineage to be
+// produced, checked for conflicts and written in one step, without forking
+// the packages that implement them:
+//
+//	p := codegen.NewPipeline()
+//	p.Register(codegen.SchemaFunc("rust", func(sch thema.Schema) ([]codegen.File, error) {
+//		b, err := rust.GenerateTypes(sch, nil)
+//		return []codegen.File{{RelativePath: "types.rs", Data: b}}, err
+//	}), codegen.WithPrefix("rust"))
+//	files, err := p.Run(lin)
+//	...
+//	err = files.Write("gen")
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/thema"
+)
+
+// File is a file produced by a generator.
+type File struct {
+	// RelativePath is the slash-separated path of the file, relative to the
+	// directory to which output is written. It must not be absolute or refer
+	// to a parent directory.
+	RelativePath string
+
+	// Data is the contents of the file.
+	Data []byte
+
+	// From is the name of the generator that produced the file. It is set by
+	// the [Pipeline].
+	From string
+}
+
+// Files is the output of a [Pipeline].
+type Files []File
+
+// Write writes the files into dir, creating any directories necessary.
+func (fs Files) Write(dir string) error {
+	for _, f := range fs {
+		p := filepath.Join(dir, filepath.FromSlash(f.RelativePath))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, f.Data, 0644); err != nil { // nolint: gosec
+			return err
+		}
+	}
+	return nil
+}
+
+// Generator is a named code generator. A Generator must also implement at
+// least one of [SchemaGenerator] and [LineageGenerator] to be registered with
+// a [Pipeline].
+type Generator interface {
+	// Name identifies the generator in errors, and in the From field of the
+	// files it produces.
+	Name() string
+}
+
+// SchemaGenerator is a [Generator] that produces files from a single schema.
+type SchemaGenerator interface {
+	Generator
+
+	// GenerateSchema returns the files generated for the provided schema.
+	GenerateSchema(sch thema.Schema) ([]File, error)
+}
+
+// LineageGenerator is a [Generator] that produces files from a whole lineage,
+// such as bindings or translation code spanning its schemas.
+type LineageGenerator interface {
+	Generator
+
+	// GenerateLineage returns the files generated for the provided lineage.
+	GenerateLineage(lin thema.Lineage) ([]File, error)
+}
+
+// SchemaFunc returns a [SchemaGenerator] with the provided name that calls fn.
+func SchemaFunc(name string, fn func(sch thema.Schema) ([]File, error)) SchemaGenerator {
+	return schemaFunc{name: name, fn: fn}
+}
+
+type schemaFunc struct {
+	name string
+	fn   func(sch thema.Schema) ([]File, error)
+}
+
+func (f schemaFunc) Name() string { return f.name }
+
+func (f schemaFunc) GenerateSchema(sch thema.Schema) ([]File, error) { return f.fn(sch) }
+
+// LineageFunc returns a [LineageGenerator] with the provided name that calls
+// fn.
+func LineageFunc(name string, fn func(lin thema.Lineage) ([]File, error)) LineageGenerator {
+	return lineageFunc{name: name, fn: fn}
+}
+
+type lineageFunc struct {
+	name string
+	fn   func(lin thema.Lineage) ([]File, error)
+}
+
+func (f lineageFunc) Name() string { return f.name }
+
+func (f lineageFunc) GenerateLineage(lin thema.Lineage) ([]File, error) { return f.fn(lin) }
+
+// A RegisterOption customizes how a generator registered with a [Pipeline] is
+// run.
+type RegisterOption registerOption
+
+// Internal representation of RegisterOption.
+type registerOption func(c *registration)
+
+// WithPrefix prefixes the paths of all files produced by the generator with
+// the provided slash-separated directory.
+func WithPrefix(dir string) RegisterOption {
+	return func(c *registration) {
+		c.prefix = path.Join(c.prefix, dir)
+	}
+}
+
+// AllSchemas causes a [SchemaGenerator] to be run against every schema in the
+// lineage, rather than only the latest. Its output for each schema is placed
+// in a directory named for the schema's version, e.g. v1.2.
+func AllSchemas() RegisterOption {
+	return func(c *registration) {
+		c.all = true
+	}
+}
+
+// Versioned causes the files a [SchemaGenerator] produces for each schema to
+// be placed in a directory named for the schema's version, e.g. v1.2. It is
+// implied by [AllSchemas].
+func Versioned() RegisterOption {
+	return func(c *registration) {
+		c.versioned = true
+	}
+}
+
+type registration struct {
+	gen            Generator
+	prefix         string
+	all, versioned bool
+}
+
+// Pipeline composes registered generators, running each in turn against a
+// lineage. The zero value is an empty pipeline, ready to use.
+type Pipeline struct {
+	// Progress, if non-nil, is notified as each generator completes for the
+	// lineage, or for each schema.
+	Progress thema.Progress
+
+	regs []registration
+}
+
+// NewPipeline returns a Pipeline running the provided generators with default
+// options. It panics if any generator implements neither [SchemaGenerator] nor
+// [LineageGenerator].
+func NewPipeline(gens ...Generator) *Pipeline {
+	p := &Pipeline{}
+	for _, g := range gens {
+		if err := p.Register(g); err != nil {
+			panic(err)
+		}
+	}
+	return p
+}
+
+// Register adds a generator to the pipeline. Generators are run in the order
+// in which they are registered.
+//
+// An error is returned if the generator implements neither [SchemaGenerator]
+// nor [LineageGenerator], or if its name is already registered.
+func (p *Pipeline) Register(g Generator, opts ...RegisterOption) error {
+	_, issch := g.(SchemaGenerator)
+	_, islin := g.(LineageGenerator)
+	if !issch && !islin {
+		return fmt.Errorf("generator %q implements neither SchemaGenerator nor LineageGenerator", g.Name())
+	}
+	for _, r := range p.regs {
+		if r.gen.Name() == g.Name() {
+			return fmt.Errorf("a generator named %q is already registered", g.Name())
+		}
+	}
+
+	r := registration{gen: g}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	r.versioned = r.versioned || r.all
+	p.regs = append(p.regs, r)
+	return nil
+}
+
+// Run runs all registered generators against the provided lineage, returning
+// the files they produce.
+//
+// An error is returned if any generator fails, produces a file with an
+// invalid path, or produces a file at the same path as another.
+func (p *Pipeline) Run(lin thema.Lineage) (Files, error) {
+	var files Files
+	from := make(map[string]string)
+	add := func(r registration, prefix string, fs []File) error {
+		for _, f := range fs {
+			if !isLocal(f.RelativePath) {
+				return fmt.Errorf("generator %q produced a file with invalid path %q; paths must be relative, and within the output directory", r.gen.Name(), f.RelativePath)
+			}
+			f.RelativePath = path.Join(r.prefix, prefix, f.RelativePath)
+			f.From = r.gen.Name()
+			if other, has := from[f.RelativePath]; has {
+				return fmt.Errorf("generators %q and %q both produced %s", other, f.From, f.RelativePath)
+			}
+			from[f.RelativePath] = f.From
+			files = append(files, f)
+		}
+		return nil
+	}
+
+	// Every generator's schemas are known in advance, so that progress can be
+	// reported against the total
+	schemas := make([][]thema.Schema, len(p.regs))
+	var total int
+	for i, r := range p.regs {
+		if _, is := r.gen.(LineageGenerator); is {
+			total++
+		}
+		if _, is := r.gen.(SchemaGenerator); !is {
+			continue
+		}
+		schemas[i] = []thema.Schema{lin.Latest()}
+		if r.all {
+			schemas[i] = lin.All()
+		}
+		total += len(schemas[i])
+	}
+
+	prog := p.Progress
+	if prog == nil {
+		prog = nopProgress{}
+	}
+	prog.OnStart("codegen", total)
+	fail := func(item string, err error) (Files, error) {
+		prog.OnItem(item, err)
+		prog.OnFinish(err)
+		return nil, err
+	}
+
+	for i, r := range p.regs {
+		if g, is := r.gen.(LineageGenerator); is {
+			fs, err := g.GenerateLineage(lin)
+			if err != nil {
+				return fail(r.gen.Name(), fmt.Errorf("generator %q failed: %w", r.gen.Name(), err))
+			}
+			if err := add(r, "", fs); err != nil {
+				return fail(r.gen.Name(), err)
+			}
+			prog.OnItem(r.gen.Name(), nil)
+		}
+
+		for _, sch := range schemas[i] {
+			item := fmt.Sprintf("%s v%s", r.gen.Name(), sch.Version())
+			fs, err := r.gen.(SchemaGenerator).GenerateSchema(sch)
+			if err != nil {
+				return fail(item, fmt.Errorf("generator %q failed for schema %s: %w", r.gen.Name(), sch.Version(), err))
+			}
+			var prefix string
+			if r.versioned {
+				prefix = "v" + sch.Version().String()
+			}
+			if err := add(r, prefix, fs); err != nil {
+				return fail(item, err)
+			}
+			prog.OnItem(item, nil)
+		}
+	}
+	prog.OnFinish(nil)
+	return files, nil
+}
+
+type nopProgress struct{}
+
+func (nopProgress) OnStart(string, int)  {}
+func (nopProgress) OnItem(string, error) {}
+func (nopProgress) OnFinish(error)       {}
+
+// isLocal reports whether the slash-separated path p is a non-empty relative
+// path that does not refer to a parent directory.
+func isLocal(p string) bool {
+	if p == "" || path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean != "." && clean != ".." && !strings.HasPrefix(clean, "../")
+}
@@ -0,0 +1,130 @@
+package codegen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLineage(t *testing.T) thema.Lineage {
+	t.Helper()
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "foo"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [0, 1]
+	schema: {
+		a: string
+		b?: int
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	return lin
+}
+
+func versionFile(sch thema.Schema) ([]File, error) {
+	return []File{{RelativePath: "version.txt", Data: []byte(sch.Version().String())}}, nil
+}
+
+type nameOnly string
+
+func (n nameOnly) Name() string { return string(n) }
+
+func TestPipeline(t *testing.T) {
+	lin := testLineage(t)
+
+	p := NewPipeline(SchemaFunc("latest", versionFile))
+	require.NoError(t, p.Register(SchemaFunc("all", versionFile), AllSchemas(), WithPrefix("all")))
+	require.NoError(t, p.Register(LineageFunc("name", func(lin thema.Lineage) ([]File, error) {
+		return []File{{RelativePath: "name.txt", Data: []byte(lin.Name())}}, nil
+	}), WithPrefix("meta")))
+
+	files, err := p.Run(lin)
+	require.NoError(t, err)
+	require.Equal(t, Files{
+		{RelativePath: "version.txt", Data: []byte("0.1"), From: "latest"},
+		{RelativePath: "all/v0.0/version.txt", Data: []byte("0.0"), From: "all"},
+		{RelativePath: "all/v0.1/version.txt", Data: []byte("0.1"), From: "all"},
+		{RelativePath: "meta/name.txt", Data: []byte("foo"), From: "name"},
+	}, files)
+
+	dir := t.TempDir()
+	require.NoError(t, files.Write(dir))
+	b, err := os.ReadFile(filepath.Join(dir, "all", "v0.0", "version.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.0", string(b))
+}
+
+type recordProgress struct {
+	items []string
+	total int
+	err   error
+}
+
+func (p *recordProgress) OnStart(op string, total int)  { p.total = total }
+func (p *recordProgress) OnItem(item string, err error) { p.items = append(p.items, item) }
+func (p *recordProgress) OnFinish(err error)            { p.err = err }
+
+func TestPipelineProgress(t *testing.T) {
+	lin := testLineage(t)
+
+	prog := &recordProgress{}
+	p := NewPipeline(SchemaFunc("all", versionFile), LineageFunc("name", func(lin thema.Lineage) ([]File, error) {
+		return []File{{RelativePath: "name.txt", Data: []byte(lin.Name())}}, nil
+	}))
+	p.Progress = prog
+	_, err := p.Run(lin)
+	require.NoError(t, err)
+	assert.Equal(t, 2, prog.total)
+	assert.Equal(t, []string{"all v0.1", "name"}, prog.items)
+	assert.NoError(t, prog.err)
+
+	errFail := errors.New("fail")
+	prog = &recordProgress{}
+	p = NewPipeline(SchemaFunc("failing", func(sch thema.Schema) ([]File, error) {
+		return nil, errFail
+	}))
+	p.Progress = prog
+	_, err = p.Run(lin)
+	assert.ErrorIs(t, err, errFail)
+	assert.ErrorIs(t, prog.err, errFail)
+}
+
+func TestPipelineErrors(t *testing.T) {
+	lin := testLineage(t)
+
+	var p Pipeline
+	require.NoError(t, p.Register(SchemaFunc("a", versionFile)))
+	assert.Error(t, p.Register(SchemaFunc("a", versionFile)), "duplicate names should be rejected")
+	assert.Error(t, p.Register(nameOnly("b")), "generators must implement SchemaGenerator or LineageGenerator")
+
+	// Conflicting output paths
+	require.NoError(t, p.Register(SchemaFunc("c", versionFile)))
+	_, err := p.Run(lin)
+	assert.ErrorContains(t, err, `generators "a" and "c" both produced version.txt`)
+
+	for _, bad := range []string{"", "/abs", "../up", "a/../../up"} {
+		p := NewPipeline(SchemaFunc("bad", func(sch thema.Schema) ([]File, error) {
+			return []File{{RelativePath: bad}}, nil
+		}))
+		_, err := p.Run(lin)
+		assert.ErrorContains(t, err, "invalid path", bad)
+	}
+
+	errFail := errors.New("fail")
+	p2 := NewPipeline(SchemaFunc("failing", func(sch thema.Schema) ([]File, error) {
+		return nil, errFail
+	}))
+	_, err = p2.Run(lin)
+	assert.ErrorIs(t, err, errFail)
+}
@@ -0,0 +1,281 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrOutOfDate indicates that the generated files in a directory differ from
+// those that would be written by [Files.Sync].
+var ErrOutOfDate = errors.New("generated files are out of date")
+
+// DefaultManifest is the name of the file within an output directory in which
+// [Files.Sync] records the files it has written, if not otherwise configured.
+const DefaultManifest = ".thema-gen.json"
+
+// ChangeKind categorizes a [Change].
+type ChangeKind string
+
+const (
+	// ChangeCreate indicates a file that does not exist, and is created.
+	ChangeCreate ChangeKind = "create"
+	// ChangeUpdate indicates a file that exists with different contents, and
+	// is overwritten.
+	ChangeUpdate ChangeKind = "update"
+	// ChangeDelete indicates a file that was previously generated but is no
+	// longer, and is deleted.
+	ChangeDelete ChangeKind = "delete"
+)
+
+// A Change is a change to a file made, or required, by [Files.Sync].
+type Change struct {
+	// Path is the slash-separated path of the file, relative to the output
+	// directory.
+	Path string
+	// Kind is the kind of change.
+	Kind ChangeKind
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s", c.Kind, c.Path)
+}
+
+// SyncConfig controls how [Files.Sync] writes files.
+type SyncConfig struct {
+	// Header, if set, returns a header to prepend to each file, e.g. one
+	// returned by [CommentHeader]. A nil header is not prepended.
+	Header func(f File) []byte
+
+	// Manifest is the name of the file within the output directory in which
+	// the paths of generated files are recorded. Defaults to DefaultManifest.
+	Manifest string
+
+	// Check causes no changes to be made. Instead, an error wrapping
+	// ErrOutOfDate is returned if any change would be made.
+	Check bool
+}
+
+// manifest is the serialized form of the manifest of generated files.
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Path string `json:"path"`
+	From string `json:"from"`
+}
+
+// Sync writes the files into dir, as [Files.Write] does, while managing the
+// lifecycle of previously generated files. It:
+//
+//   - prepends the configured header to each file
+//   - skips files whose contents are unchanged
+//   - deletes files recorded in the manifest as produced by one of the
+//     generators that produced fs, but which are not in fs
+//   - records the files in the manifest
+//
+// Files recorded in the manifest as produced by other generators are retained,
+// so that different sets of generators may be run against the same directory.
+//
+// The changes made, or in check mode required, are returned in path order.
+func (fs Files) Sync(dir string, cfg *SyncConfig) ([]Change, error) {
+	if cfg == nil {
+		cfg = new(SyncConfig)
+	}
+	mname := cfg.Manifest
+	if mname == "" {
+		mname = DefaultManifest
+	}
+	mpath := filepath.Join(dir, mname)
+
+	prev, err := readManifest(mpath)
+	if err != nil {
+		return nil, err
+	}
+
+	type write struct {
+		p    string
+		data []byte
+	}
+	var changes []Change
+	var writes []write
+	gens := make(map[string]bool)
+	next := manifest{Files: []manifestEntry{}}
+	current := make(map[string]bool)
+	for _, f := range fs {
+		if !isLocal(f.RelativePath) {
+			return nil, fmt.Errorf("invalid path %q; paths must be relative, and within the output directory", f.RelativePath)
+		}
+		data := f.Data
+		if cfg.Header != nil {
+			if h := cfg.Header(f); h != nil {
+				data = append(append([]byte{}, h...), data...)
+			}
+		}
+		gens[f.From] = true
+		current[path.Clean(f.RelativePath)] = true
+		next.Files = append(next.Files, manifestEntry{Path: path.Clean(f.RelativePath), From: f.From})
+
+		p := filepath.Join(dir, filepath.FromSlash(f.RelativePath))
+		c, err := diff(p, f.RelativePath, data)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			changes = append(changes, *c)
+			writes = append(writes, write{p: p, data: data})
+		}
+	}
+
+	var deletes []string
+	for _, e := range prev.Files {
+		if current[e.Path] {
+			continue
+		}
+		if !gens[e.From] {
+			next.Files = append(next.Files, e)
+			continue
+		}
+		// Guard against manifests edited to point outside the directory
+		if !isLocal(e.Path) {
+			continue
+		}
+		p := filepath.Join(dir, filepath.FromSlash(e.Path))
+		if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		changes = append(changes, Change{Path: e.Path, Kind: ChangeDelete})
+		deletes = append(deletes, p)
+	}
+
+	sort.Slice(next.Files, func(i, j int) bool {
+		return next.Files[i].Path < next.Files[j].Path
+	})
+	mb, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	mb = append(mb, '\n')
+	if c, err := diff(mpath, filepath.ToSlash(mname), mb); err != nil {
+		return nil, err
+	} else if c != nil {
+		changes = append(changes, *c)
+		writes = append(writes, write{p: mpath, data: mb})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	if cfg.Check {
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		strs := make([]string, len(changes))
+		for i, c := range changes {
+			strs[i] = c.String()
+		}
+		return changes, fmt.Errorf("%w: %s", ErrOutOfDate, strings.Join(strs, ", "))
+	}
+
+	for _, w := range writes {
+		if err := os.MkdirAll(filepath.Dir(w.p), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(w.p, w.data, 0644); err != nil { // nolint: gosec
+			return nil, err
+		}
+	}
+	for _, p := range deletes {
+		if err := os.Remove(p); err != nil {
+			return nil, err
+		}
+		removeEmptyDirs(filepath.Dir(p), dir)
+	}
+	return changes, nil
+}
+
+// readManifest reads the manifest at p, returning an empty manifest if none
+// exists.
+func readManifest(p string) (manifest, error) {
+	var m manifest
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", p, err)
+	}
+	return m, nil
+}
+
+// diff returns the change required for the file at p, displayed as relpath,
+// to contain data, or nil if it already does.
+func diff(p, relpath string, data []byte) (*Change, error) {
+	b, err := os.ReadFile(p)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return &Change{Path: relpath, Kind: ChangeCreate}, nil
+	case err != nil:
+		return nil, err
+	case !bytes.Equal(b, data):
+		return &Change{Path: relpath, Kind: ChangeUpdate}, nil
+	}
+	return nil, nil
+}
+
+// removeEmptyDirs removes dir, and then each of its parents, until reaching a
+// directory that is not empty, or root.
+func removeEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// CommentHeader returns a header function for [SyncConfig], which renders
+// text as a comment in the syntax of each file's language, as determined by
+// its extension. Files in languages without comments, such as JSON, and those
+// with unrecognized extensions, are given no header.
+func CommentHeader(text string) func(f File) []byte {
+	return func(f File) []byte {
+		var prefix, open, close string
+		switch path.Ext(f.RelativePath) {
+		case ".go", ".ts", ".tsx", ".js", ".rs", ".java", ".kt", ".cs", ".swift", ".proto", ".cue":
+			prefix = "// "
+		case ".py", ".yaml", ".yml", ".toml", ".sh", ".rb":
+			prefix = "# "
+		case ".sql":
+			prefix = "-- "
+		case ".md", ".html", ".xml":
+			open, close = "<!--\n", "-->\n"
+		default:
+			return nil
+		}
+
+		var b strings.Builder
+		b.WriteString(open)
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			if line == "" {
+				b.WriteString(strings.TrimSpace(prefix))
+			} else {
+				b.WriteString(prefix + line)
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(close)
+		b.WriteByte('\n')
+		return []byte(b.String())
+	}
+}
@@ -0,0 +1,83 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &SyncConfig{Header: CommentHeader("GENERATED")}
+	read := func(p string) string {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	files := Files{
+		{RelativePath: "a.go", Data: []byte("package a\n"), From: "go"},
+		{RelativePath: "v0.0/b.json", Data: []byte("{}\n"), From: "jsonschema"},
+		{RelativePath: "c.ts", Data: []byte("export {};\n"), From: "ts"},
+	}
+	changes, err := files.Sync(dir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: DefaultManifest, Kind: ChangeCreate},
+		{Path: "a.go", Kind: ChangeCreate},
+		{Path: "c.ts", Kind: ChangeCreate},
+		{Path: "v0.0/b.json", Kind: ChangeCreate},
+	}, changes)
+	assert.Equal(t, "// GENERATED\n\npackage a\n", read("a.go"))
+	assert.Equal(t, "{}\n", read("v0.0/b.json"))
+
+	// Nothing to do, and so nothing to check
+	changes, err = files.Sync(dir, cfg)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	cfg.Check = true
+	_, err = files.Sync(dir, cfg)
+	require.NoError(t, err)
+
+	// jsonschema no longer produces b.json, and go produces a different a.go.
+	// ts is not run, so c.ts is retained.
+	files = Files{
+		{RelativePath: "a.go", Data: []byte("package aa\n"), From: "go"},
+		{RelativePath: "v0.1/b.json", Data: []byte("{}\n"), From: "jsonschema"},
+	}
+	changes, err = files.Sync(dir, cfg)
+	require.ErrorIs(t, err, ErrOutOfDate)
+	assert.EqualError(t, err, "generated files are out of date: update .thema-gen.json, update a.go, delete v0.0/b.json, create v0.1/b.json")
+	assert.Len(t, changes, 4)
+	assert.Equal(t, "// GENERATED\n\npackage a\n", read("a.go"), "check mode should not write")
+
+	cfg.Check = false
+	_, err = files.Sync(dir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "// GENERATED\n\npackage aa\n", read("a.go"))
+	assert.NoDirExists(t, filepath.Join(dir, "v0.0"))
+	assert.FileExists(t, filepath.Join(dir, "c.ts"))
+	assert.JSONEq(t, `{"files": [
+		{"path": "a.go", "from": "go"},
+		{"path": "c.ts", "from": "ts"},
+		{"path": "v0.1/b.json", "from": "jsonschema"}
+	]}`, read(DefaultManifest))
+
+	// Files edited by hand are out of date
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package edited\n"), 0644))
+	cfg.Check = true
+	_, err = files.Sync(dir, cfg)
+	assert.ErrorIs(t, err, ErrOutOfDate)
+}
+
+func TestCommentHeader(t *testing.T) {
+	h := CommentHeader("THIS FILE IS GENERATED.\n\nFrom foo.cue")
+	assert.Equal(t, "// THIS FILE IS GENERATED.\n//\n// From foo.cue\n\n", string(h(File{RelativePath: "x/y.rs"})))
+	assert.Equal(t, "# THIS FILE IS GENERATED.\n#\n# From foo.cue\n\n", string(h(File{RelativePath: "y.py"})))
+	assert.Equal(t, "<!--\nTHIS FILE IS GENERATED.\n\nFrom foo.cue\n-->\n\n", string(h(File{RelativePath: "README.md"})))
+	assert.Equal(t, "-- THIS FILE IS GENERATED.\n--\n-- From foo.cue\n\n", string(h(File{RelativePath: "y.sql"})))
+	assert.Nil(t, h(File{RelativePath: "y.json"}))
+}
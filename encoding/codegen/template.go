@@ -0,0 +1,521 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// TemplateConfig controls the data passed to templates by a [Template]
+// generator.
+type TemplateConfig struct {
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the template data, e.g. "meta.internal" or "#Panel.legacy". Fields of a
+	// top-level definition are addressed through the definition.
+	Exclude []cue.Path
+}
+
+// TemplateData is the data with which templates are executed.
+type TemplateData struct {
+	// Lineage is the lineage containing the schema.
+	Lineage *LineageData
+	// Schema is the schema for which the template is executed. It is one of the
+	// schemas of Lineage.
+	Schema *SchemaData
+}
+
+// LineageData describes a lineage to templates.
+type LineageData struct {
+	// Name is the name of the lineage.
+	Name string
+	// Schemas are the schemas in the lineage, in version order.
+	Schemas []*SchemaData
+}
+
+// SchemaData describes a schema to templates.
+type SchemaData struct {
+	// Version is the version of the schema, e.g. "1.2".
+	Version string
+	// Major and Minor are the components of Version.
+	Major, Minor uint
+	// Latest is true for the latest schema in the lineage.
+	Latest bool
+	// Maturity is the declared maturity of the schema, e.g. "stable".
+	Maturity string
+	// ReleaseNotes are the change notes declared for the schema.
+	ReleaseNotes []thema.ChangeNote
+	// Fields are the fields of the schema, in declaration order.
+	Fields []*FieldData
+	// Definitions are the top-level definitions of the schema, in declaration
+	// order.
+	Definitions []*FieldData
+}
+
+// FieldData describes a field, definition, or list or map element to
+// templates.
+type FieldData struct {
+	// Name is the label of the field, e.g. "title" or "#Panel". It is empty for
+	// list and map elements.
+	Name string
+	// Path is the CUE path of the field relative to the schema, e.g.
+	// "meta.title" or "#Panel.type". Fields of a definition are addressed
+	// through the definition. It is empty for values within lists and maps.
+	Path string
+	// Doc is the text of the doc comments of the field.
+	Doc string
+	// Kind is the kind of the value: one of "null", "bool", "int", "number",
+	// "string", "bytes", "list", "map" or "struct", or a kind expression such
+	// as "int|string" for values of several kinds.
+	Kind string
+	// Type is the CUE expression declaring the type of the value, e.g.
+	// "int & >=0", `"a" | "b"` or "#Panel". It is "{...}" for struct
+	// literals, whose fields are described by Fields.
+	Type string
+	// Ref is the name of the top-level definition to which the value refers,
+	// e.g. "#Panel", if any. The fields of the definition are not repeated.
+	Ref string
+	// Optional is true for optional fields.
+	Optional bool
+	// HasDefault is true if the field has a default value.
+	HasDefault bool
+	// Default is the default value of the field, as decoded from CUE.
+	Default any
+	// Enum lists the values of a disjunction of concrete values, e.g. the
+	// strings of a string enum, as decoded from CUE.
+	Enum []any
+	// Attributes are the attributes of the field, keyed by name.
+	Attributes map[string]*AttributeData
+	// Fields are the fields of a struct value, in declaration order.
+	Fields []*FieldData
+	// Elem describes the elements of a list or map value.
+	Elem *FieldData
+}
+
+// AttributeData describes a field attribute, e.g. @go(name=Foo), to templates.
+type AttributeData struct {
+	// Name is the name of the attribute, e.g. "go".
+	Name string
+	// Contents is the text between the attribute's parentheses, e.g.
+	// "name=Foo".
+	Contents string
+	// Args are the comma-separated arguments of the attribute, e.g.
+	// ["name=Foo"].
+	Args []string
+	// Values maps the keys of key=value arguments to their values.
+	Values map[string]string
+}
+
+// Has reports whether the attribute has an argument arg, either alone or as
+// the key of a key=value argument.
+func (a *AttributeData) Has(arg string) bool {
+	if _, has := a.Values[arg]; has {
+		return true
+	}
+	for _, s := range a.Args {
+		if s == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// Template returns a [SchemaGenerator] named name, which executes tmpl with
+// the [TemplateData] of each schema it is run against, producing a file at
+// outPath.
+//
+// Templates should be created with [TemplateFuncs] so they may use the helper
+// functions it provides.
+func Template(name, outPath string, tmpl *template.Template, cfg *TemplateConfig) SchemaGenerator {
+	if cfg == nil {
+		cfg = new(TemplateConfig)
+	}
+	return SchemaFunc(name, func(sch thema.Schema) ([]File, error) {
+		data, err := NewTemplateData(sch, cfg)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return []File{{RelativePath: outPath, Data: buf.Bytes()}}, nil
+	})
+}
+
+// NewTemplateData returns the data with which a template is executed for the
+// provided schema.
+func NewTemplateData(sch thema.Schema, cfg *TemplateConfig) (*TemplateData, error) {
+	if cfg == nil {
+		cfg = new(TemplateConfig)
+	}
+	lin := sch.Lineage()
+	data := &TemplateData{
+		Lineage: &LineageData{Name: lin.Name()},
+	}
+	for _, s := range lin.All() {
+		sd, err := newSchemaData(s, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", s.Version(), err)
+		}
+		data.Lineage.Schemas = append(data.Lineage.Schemas, sd)
+		if s.Version() == sch.Version() {
+			data.Schema = sd
+		}
+	}
+	return data, nil
+}
+
+func newSchemaData(sch thema.Schema, cfg *TemplateConfig) (*SchemaData, error) {
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	b := &dataBuilder{schdef: schdef, exclude: cfg.Exclude}
+
+	v := sch.Version()
+	sd := &SchemaData{
+		Version:      v.String(),
+		Major:        v[0],
+		Minor:        v[1],
+		Latest:       sch.Successor() == nil,
+		Maturity:     string(sch.Maturity()),
+		ReleaseNotes: sch.ReleaseNotes(),
+	}
+
+	var err error
+	if sd.Fields, err = b.fields(schdef, nil, true); err != nil {
+		return nil, err
+	}
+	iter, err := schdef.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		if !sel.IsDefinition() {
+			continue
+		}
+		fd, err := b.field(iter.Value(), []cue.Selector{sel}, true)
+		if err != nil {
+			return nil, err
+		}
+		fd.Name = sel.String()
+		sd.Definitions = append(sd.Definitions, fd)
+	}
+	return sd, nil
+}
+
+// dataBuilder builds the template data for the values of a schema.
+type dataBuilder struct {
+	// schdef is the schema, in which references to definitions are resolved.
+	schdef  cue.Value
+	exclude []cue.Path
+}
+
+// fields returns the data for the regular fields of the struct v, whose path
+// within the schema is path, if addressable is true.
+func (b *dataBuilder) fields(v cue.Value, path []cue.Selector, addressable bool) ([]*FieldData, error) {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+	var fds []*FieldData
+	for iter.Next() {
+		sel := iter.Selector()
+		fpath := append(append([]cue.Selector{}, path...), sel)
+		if addressable && cuetil.ContainsPath(b.exclude, cue.MakePath(fpath...)) {
+			continue
+		}
+		fd, err := b.field(iter.Value(), fpath, addressable)
+		if err != nil {
+			return nil, err
+		}
+		fd.Name = sel.Unquoted()
+		fd.Optional = iter.IsOptional()
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// field returns the data for the value v, whose path within the schema is
+// path, if addressable is true.
+func (b *dataBuilder) field(v cue.Value, path []cue.Selector, addressable bool) (*FieldData, error) {
+	fd := &FieldData{
+		Doc:        doc(v),
+		Kind:       kind(v),
+		Type:       typeExpr(v),
+		Attributes: attributes(v),
+	}
+	if addressable {
+		fd.Path = cue.MakePath(path...).String()
+	}
+
+	if d, has := v.Default(); has && d.IsConcrete() {
+		fd.HasDefault = true
+		if err := d.Decode(&fd.Default); err != nil {
+			return nil, err
+		}
+		// Empty lists decode as nil
+		if l, is := fd.Default.([]any); is && l == nil {
+			fd.Default = []any{}
+		}
+	}
+	if op, args := v.Expr(); op == cue.OrOp {
+		for _, arg := range args {
+			if !arg.IsConcrete() || arg.IncompleteKind() == cue.StructKind || arg.IncompleteKind() == cue.ListKind {
+				fd.Enum = nil
+				break
+			}
+			var x any
+			if err := arg.Decode(&x); err != nil {
+				return nil, err
+			}
+			fd.Enum = append(fd.Enum, x)
+		}
+	}
+
+	// Definitions are described once, rather than wherever they are referenced
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		last := sels[len(sels)-1]
+		if last.IsDefinition() && b.schdef.LookupPath(cue.MakePath(last)).Exists() && !(len(path) == 1 && path[0] == last) {
+			fd.Ref = last.String()
+			return fd, nil
+		}
+	}
+
+	var err error
+	switch fd.Kind {
+	case "struct":
+		fd.Fields, err = b.fields(v, path, addressable)
+	case "map":
+		fd.Elem, err = b.field(v.LookupPath(cue.MakePath(cue.AnyString)), nil, false)
+	case "list":
+		fd.Elem, err = b.field(listElem(v), nil, false)
+	}
+	return fd, err
+}
+
+// kind returns the kind of v, as documented on [FieldData].
+func kind(v cue.Value) string {
+	k := v.IncompleteKind()
+	switch k {
+	case cue.StructKind:
+		if isMap(v) {
+			return "map"
+		}
+		return "struct"
+	case cue.NumberKind | cue.IntKind:
+		return "number"
+	}
+	return strings.ReplaceAll(k.String(), " ", "")
+}
+
+// isMap reports whether v is a struct with no fields, only a pattern
+// constraint, e.g. [string]: int.
+func isMap(v cue.Value) bool {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil || iter.Next() {
+		return false
+	}
+	return v.LookupPath(cue.MakePath(cue.AnyString)).Exists()
+}
+
+// listElem returns the value of the elements of the list v.
+func listElem(v cue.Value) cue.Value {
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+		return elem
+	}
+	// Lists with a default, e.g. [...string] | *[], wrap the list type
+	if op, args := v.Expr(); op == cue.NoOp && len(args) == 1 {
+		return args[0].LookupPath(cue.MakePath(cue.AnyIndex))
+	}
+	return v
+}
+
+// typeExpr returns the CUE expression declaring the type of v, as documented
+// on [FieldData].
+func typeExpr(v cue.Value) string {
+	var expr ast.Expr
+	if f, is := v.Source().(*ast.Field); is {
+		expr = f.Value
+	} else if e, is := v.Syntax(cue.Raw()).(ast.Expr); is {
+		expr = e
+	}
+	if expr == nil {
+		return ""
+	}
+	if _, is := expr.(*ast.StructLit); is {
+		return "{...}"
+	}
+	b, err := format.Node(expr)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// doc returns the text of the doc comments of v.
+func doc(v cue.Value) string {
+	var lines []string
+	for _, cg := range v.Doc() {
+		lines = append(lines, strings.TrimSpace(cg.Text()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// attributes returns the field attributes of v, keyed by name.
+func attributes(v cue.Value) map[string]*AttributeData {
+	attrs := make(map[string]*AttributeData)
+	for _, a := range v.Attributes(cue.FieldAttr) {
+		ad := &AttributeData{
+			Name:     a.Name(),
+			Contents: a.Contents(),
+			Values:   make(map[string]string),
+		}
+		for i := 0; i < a.NumArgs(); i++ {
+			ad.Args = append(ad.Args, a.RawArg(i))
+			if k, val := a.Arg(i); val != "" || strings.Contains(a.RawArg(i), "=") {
+				ad.Values[k] = val
+			}
+		}
+		attrs[ad.Name] = ad
+	}
+	return attrs
+}
+
+// TemplateFuncs returns the helper functions available to templates executed
+// by a [Template] generator. They are:
+//
+//	lower, upper      change the case of a string
+//	title             upper-case the first letter of a string
+//	camel, pascal     convert a label to camelCase or PascalCase
+//	snake, kebab      convert a label to snake_case or kebab-case
+//	trimPrefix        remove a prefix, e.g. {{trimPrefix "#" .Name}}
+//	replace           replace all instances of a string
+//	join              join a list of strings with a separator
+//	quote             quote a string as a Go/JSON string literal
+//	json              encode a value as JSON
+//	indent            indent each non-empty line of a string
+//	comment           prefix each line of a string, e.g. {{comment "// " .Doc}}
+//	sortedKeys        return the sorted keys of a map of strings
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"title": func(s string) string {
+			rs := []rune(s)
+			if len(rs) > 0 {
+				rs[0] = unicode.ToUpper(rs[0])
+			}
+			return string(rs)
+		},
+		"camel": func(s string) string {
+			ws := words(s)
+			for i := range ws {
+				if i == 0 {
+					ws[i] = strings.ToLower(ws[i])
+				} else {
+					ws[i] = upperFirst(ws[i])
+				}
+			}
+			return strings.Join(ws, "")
+		},
+		"pascal": func(s string) string {
+			ws := words(s)
+			for i := range ws {
+				ws[i] = upperFirst(ws[i])
+			}
+			return strings.Join(ws, "")
+		},
+		"snake": func(s string) string {
+			return strings.ToLower(strings.Join(words(s), "_"))
+		},
+		"kebab": func(s string) string {
+			return strings.ToLower(strings.Join(words(s), "-"))
+		},
+		"trimPrefix": func(prefix, s string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+		"quote": strconv.Quote,
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"indent": func(n int, s string) string {
+			return prefixLines(strings.Repeat(" ", n), s, false)
+		},
+		"comment": func(prefix, s string) string {
+			return prefixLines(prefix, s, true)
+		},
+		"sortedKeys": func(m map[string]string) []string {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+	}
+}
+
+// words splits s into words at non-alphanumeric characters and changes of
+// case, e.g. "#HTTPPanel_type" into ["HTTP", "Panel", "type"].
+func words(s string) []string {
+	rs := []rune(s)
+	var ws []string
+	var cur []rune
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				ws, cur = append(ws, string(cur)), nil
+			}
+			continue
+		}
+		// Start a new word at lowerUpper, or at the last of UPPERLower
+		if unicode.IsUpper(r) && len(cur) > 0 &&
+			(!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+			ws, cur = append(ws, string(cur)), nil
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		ws = append(ws, string(cur))
+	}
+	return ws
+}
+
+func upperFirst(s string) string {
+	rs := []rune(strings.ToLower(s))
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+// prefixLines prefixes each line of s with prefix. Empty lines are prefixed
+// only if all is true, in which case trailing whitespace is trimmed from them.
+func prefixLines(prefix, s string, all bool) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case line != "":
+			lines[i] = prefix + line
+		case all:
+			lines[i] = strings.TrimRightFunc(prefix, unicode.IsSpace)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
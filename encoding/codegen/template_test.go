@@ -0,0 +1,116 @@
+package codegen
+
+import (
+	"testing"
+	"text/template"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templateLineage = `
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The title of the dashboard.
+		title: string @go(name=Name) @grafana(required)
+		style?: "light" | "dark" | *"dark"
+		refresh: int & >=0 | *30
+		tags: [...string] | *[]
+		panels: [...#Panel]
+		links: [string]: #Link
+		meta: {
+			internal: bool
+			version: int
+		}
+		#Panel: {
+			type: string
+			// Deprecated.
+			legacy?: bool
+			link: #Link
+		}
+		#Link: url: string
+	}
+}]
+`
+
+func TestTemplate(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(templateLineage), rt)
+	require.NoError(t, err)
+
+	tmpl := template.Must(template.New("fields").Funcs(TemplateFuncs()).Parse(`# {{pascal .Lineage.Name}} v{{.Schema.Version}}
+{{range .Lineage.Schemas}}- {{.Version}}{{if .Latest}} (latest){{end}}
+{{end -}}
+{{define "field"}}{{.Path}}{{if .Optional}}?{{end}} {{.Kind}} {{.Type}}
+{{- with .Ref}} ref={{.}}{{end}}
+{{- if .HasDefault}} default={{json .Default}}{{end}}
+{{- with .Enum}} enum={{json .}}{{end}}
+{{- with .Elem}} elem={{.Kind}}{{with .Ref}}:{{.}}{{end}}{{end}}
+{{- with index .Attributes "go"}} goname={{index .Values "name"}}{{end}}
+{{- with index .Attributes "grafana"}}{{if .Has "required"}} required{{end}}{{end}}
+{{with .Doc}}{{comment "  // " .}}
+{{end}}{{range .Fields}}{{template "field" .}}{{end}}{{end -}}
+{{range .Schema.Fields}}{{template "field" .}}{{end -}}
+{{range .Schema.Definitions}}{{template "field" .}}{{end -}}
+`))
+
+	p := NewPipeline()
+	require.NoError(t, p.Register(Template("doc", "fields.md", tmpl, &TemplateConfig{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})))
+	files, err := p.Run(lin)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "fields.md", files[0].RelativePath)
+	assert.Equal(t, `# Dashboard v0.0
+- 0.0 (latest)
+title string string goname=Name required
+  // The title of the dashboard.
+style? string "light" | "dark" | *"dark" default="dark" enum=["light","dark"]
+refresh int int & >=0 | *30 default=30
+tags list [...string] | *[] default=[] elem=string
+panels list [...#Panel] default=[] elem=struct:#Panel
+links map {...} elem=struct:#Link
+meta struct {...}
+meta.version int int
+#Panel struct {...}
+#Panel.type string string
+#Panel.link struct #Link ref=#Link
+#Link struct {...}
+#Link.url string string
+`, string(files[0].Data))
+}
+
+func TestNewTemplateData(t *testing.T) {
+	lin := testLineage(t)
+	data, err := NewTemplateData(lin.First(), nil)
+	require.NoError(t, err)
+	require.Len(t, data.Lineage.Schemas, 2)
+	assert.Same(t, data.Lineage.Schemas[0], data.Schema)
+	assert.Equal(t, "0.0", data.Schema.Version)
+	assert.False(t, data.Schema.Latest)
+	assert.True(t, data.Lineage.Schemas[1].Latest)
+	assert.Equal(t, "stable", data.Schema.Maturity)
+	require.Len(t, data.Lineage.Schemas[1].Fields, 2)
+	assert.True(t, data.Lineage.Schemas[1].Fields[1].Optional)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	funcs := TemplateFuncs()
+	for in, want := range map[string][4]string{
+		"#HTTPPanel_type": {"httpPanelType", "HttpPanelType", "http_panel_type", "http-panel-type"},
+		"fooBar":          {"fooBar", "FooBar", "foo_bar", "foo-bar"},
+		"x-y z":           {"xYZ", "XYZ", "x_y_z", "x-y-z"},
+	} {
+		for i, fn := range []string{"camel", "pascal", "snake", "kebab"} {
+			assert.Equal(t, want[i], funcs[fn].(func(string) string)(in), "%s(%q)", fn, in)
+		}
+	}
+	assert.Equal(t, "// a\n//\n// b", funcs["comment"].(func(string, string) string)("// ", "a\n\nb"))
+	assert.Equal(t, "  a\n\n  b", funcs["indent"].(func(int, string) string)(2, "a\n\nb"))
+}
@@ -0,0 +1,126 @@
+// Package crd generates Kubernetes CustomResourceDefinitions from Thema
+// schemas.
+package crd
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	cueopenapi "cuelang.org/go/encoding/openapi"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/openapi"
+)
+
+// Config controls CustomResourceDefinition generation from a Thema schema.
+type Config struct {
+	// Group is the API group of the resource, e.g. "example.com". Required.
+	Group string
+
+	// Kind is the kind of the resource, e.g. "Dashboard". If empty, this
+	// defaults to titlecasing of the lineage name.
+	Kind string
+
+	// Plural is the plural name of the resource, used in its API path. If
+	// empty, this defaults to the lowercase Kind with an "s" appended.
+	Plural string
+
+	// Scope is the scope of the resource, either "Namespaced" or "Cluster". If
+	// empty, this defaults to "Namespaced".
+	Scope string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the structural schema, e.g. "meta.internal". As references are expanded,
+	// fields of definitions cannot be excluded. See [openapi.Config.Exclude].
+	Exclude []cue.Path
+}
+
+// GenerateCRD creates a CustomResourceDefinition for a resource whose spec is
+// described by the provided Thema schema.
+//
+// The CRD contains a single version, named for the schema's version as
+// "v<major>-<minor>", which is both served and stored. The structural schema
+// of that version is derived from the schema's OpenAPI representation, with
+// all references expanded, as Kubernetes requires.
+//
+// Returns the result as a CUE AST, which is suitable for direct manipulation and
+// marshaling to either JSON or YAML.
+func GenerateCRD(sch thema.Schema, cfg *Config) (*ast.File, error) {
+	if cfg == nil || cfg.Group == "" {
+		return nil, fmt.Errorf("a group is required to generate a CRD")
+	}
+	c := *cfg
+	if c.Kind == "" {
+		c.Kind = strings.Title(sch.Lineage().Name())
+	}
+	if c.Plural == "" {
+		c.Plural = strings.ToLower(c.Kind) + "s"
+	}
+	if c.Scope == "" {
+		c.Scope = "Namespaced"
+	}
+
+	f, err := openapi.GenerateSchema(sch, &openapi.Config{
+		Config: &cueopenapi.Config{
+			ExpandReferences: true,
+		},
+		RootName: c.Kind,
+		Exclude:  c.Exclude,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx := sch.Underlying().Context()
+	spec := ctx.BuildFile(f).LookupPath(cue.MakePath(cue.Str("components"), cue.Str("schemas"), cue.Str(c.Kind)))
+	if !spec.Exists() {
+		return nil, fmt.Errorf("no OpenAPI schema generated for %s", c.Kind)
+	}
+
+	specExpr, is := spec.Syntax(cue.Final()).(ast.Expr)
+	if !is {
+		return nil, fmt.Errorf("unable to convert OpenAPI schema for %s to CUE syntax", c.Kind)
+	}
+
+	return &ast.File{
+		Decls: []ast.Decl{
+			ast.NewStruct(
+				"apiVersion", ast.NewString("apiextensions.k8s.io/v1"),
+				"kind", ast.NewString("CustomResourceDefinition"),
+				"metadata", ast.NewStruct(
+					"name", ast.NewString(c.Plural+"."+c.Group),
+				),
+				"spec", ast.NewStruct(
+					"group", ast.NewString(c.Group),
+					"names", ast.NewStruct(
+						"kind", ast.NewString(c.Kind),
+						"listKind", ast.NewString(c.Kind+"List"),
+						"plural", ast.NewString(c.Plural),
+						"singular", ast.NewString(strings.ToLower(c.Kind)),
+					),
+					"scope", ast.NewString(c.Scope),
+					"versions", ast.NewList(ast.NewStruct(
+						"name", ast.NewString(VersionName(sch.Version())),
+						"served", ast.NewBool(true),
+						"storage", ast.NewBool(true),
+						"schema", ast.NewStruct(
+							"openAPIV3Schema", ast.NewStruct(
+								"type", ast.NewString("object"),
+								"properties", ast.NewStruct(
+									"spec", specExpr,
+								),
+							),
+						),
+					)),
+				),
+			),
+		},
+	}, nil
+}
+
+// VersionName returns the name of the CRD version corresponding to a Thema
+// schema version, e.g. "v1-0" for 1.0. Dots are not permitted in CRD version
+// names.
+func VersionName(v thema.SyntacticVersion) string {
+	return fmt.Sprintf("v%d-%d", v[0], v[1])
+}
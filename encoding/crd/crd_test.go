@@ -0,0 +1,72 @@
+package crd
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCRD(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	schema: {
+		title: string
+		#Panel: id: int
+		panels?: [...#Panel]
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.title
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: title: input.title
+}]
+`), rt)
+	require.NoError(t, err)
+
+	_, err = GenerateCRD(lin.Latest(), nil)
+	assert.Error(t, err)
+
+	f, err := GenerateCRD(lin.Latest(), &Config{Group: "example.com"})
+	require.NoError(t, err)
+	v := rt.Context().BuildFile(f)
+	require.NoError(t, v.Err())
+
+	str := func(path string) string {
+		s, err := v.LookupPath(cue.ParsePath(path)).String()
+		require.NoError(t, err, path)
+		return s
+	}
+	assert.Equal(t, "CustomResourceDefinition", str("kind"))
+	assert.Equal(t, "dashboards.example.com", str("metadata.name"))
+	assert.Equal(t, "Dashboard", str("spec.names.kind"))
+	assert.Equal(t, "Namespaced", str("spec.scope"))
+	assert.Equal(t, "v1-0", str("spec.versions[0].name"))
+
+	spec := "spec.versions[0].schema.openAPIV3Schema.properties.spec"
+	assert.Equal(t, "string", str(spec+".properties.title.type"))
+	// References must be expanded, as CRDs do not permit them
+	assert.Equal(t, "integer", str(spec+".properties.panels.items.properties.id.type"))
+
+	f, err = GenerateCRD(lin.Latest(), &Config{Group: "example.com", Kind: "Board", Plural: "boardz", Scope: "Cluster"})
+	require.NoError(t, err)
+	v = rt.Context().BuildFile(f)
+	assert.Equal(t, "boardz.example.com", str("metadata.name"))
+	assert.Equal(t, "board", str("spec.names.singular"))
+	assert.Equal(t, "Cluster", str("spec.scope"))
+}
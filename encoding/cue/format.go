@@ -0,0 +1,286 @@
+package cue
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+	"github.com/grafana/thema"
+	tastutil "github.com/grafana/thema/internal/astutil"
+)
+
+var (
+	// schemaFieldOrder is the canonical order of fields within an element of a
+	// lineage's schemas list.
+	schemaFieldOrder = []string{"version", "maturity", "schema", "examples"}
+	// lensFieldOrder is the canonical order of fields within an element of a
+	// lineage's lenses list.
+	lensFieldOrder = []string{"to", "from", "input", "result", "lacunas"}
+)
+
+// FormatLineage formats CUE source containing a lineage declaration at the
+// provided path, enforcing Thema's conventions for the layout of lineages such
+// that diffs between successive versions of a lineage file remain minimal:
+//
+//   - schemas are ordered ascending by version
+//   - lenses are ordered ascending by their 'to' version, then by their 'from'
+//     version, such that all lenses targeting a schema are adjacent
+//   - fields within schemas and lenses appear in a stable order
+//
+// Sorting is stable, and fields not known to Thema retain their positions. Doc
+// comments move with the schema, lens or field they precede. The result is
+// otherwise formatted as by the standard CUE formatter.
+//
+// As this operates only on syntax, versions must be declared as list literals.
+// Lineage fields declared in multiple parts, e.g. 'lin: schemas: [...]', are
+// supported, but lists composed through references or comprehensions are left
+// as they are. Source in which no lineage is declared at the path is formatted
+// only as CUE.
+func FormatLineage(filename string, src []byte, path cue.Path) ([]byte, error) {
+	if err := path.Err(); err != nil {
+		return nil, err
+	}
+	var base []string
+	for _, sel := range path.Selectors() {
+		base = append(base, sel.String())
+	}
+	lists := []struct {
+		field string
+		order []string
+		key   func(*ast.StructLit) (thema.SyntacticVersion, thema.SyntacticVersion, error)
+	}{
+		{"schemas", schemaFieldOrder, func(s *ast.StructLit) (thema.SyntacticVersion, thema.SyntacticVersion, error) {
+			v, err := versionField(s, "version")
+			return v, thema.SyntacticVersion{}, err
+		}},
+		{"lenses", lensFieldOrder, func(s *ast.StructLit) (thema.SyntacticVersion, thema.SyntacticVersion, error) {
+			to, err := versionField(s, "to")
+			if err != nil {
+				return to, to, err
+			}
+			from, err := versionField(s, "from")
+			return to, from, err
+		}},
+	}
+
+	// Fields within elements are ordered in a first pass, and the elements
+	// themselves in a second, as each pass moves spans of the source that
+	// contain those moved by the prior pass.
+	for pass := 0; pass < 2; pass++ {
+		f, err := parser.ParseFile(filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		var edits []spanEdit
+		var errs errors.Error
+		for _, l := range lists {
+			walkListsAt(f, nil, append(base[:len(base):len(base)], l.field), func(list *ast.ListLit) {
+				elems, ok := structElements(list)
+				if !ok {
+					return
+				}
+				if pass == 0 {
+					for _, s := range elems {
+						edits = append(edits, fieldOrderEdit(s, l.order))
+					}
+					return
+				}
+				edit, err := elementOrderEdit(elems, l.key)
+				if err != nil {
+					errs = errors.Append(errs, err)
+				}
+				edits = append(edits, edit)
+			})
+		}
+		if errs != nil {
+			return nil, errs
+		}
+		src = applyEdits(src, edits)
+	}
+
+	f, err := parser.ParseFile(filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return tastutil.FmtNode(f)
+}
+
+// walkListsAt calls fn with the list literal value of each field in n at the
+// target path, descending through struct literals, embeddings and unifications.
+func walkListsAt(n ast.Node, path, target []string, fn func(*ast.ListLit)) {
+	switch x := n.(type) {
+	case *ast.File:
+		for _, d := range x.Decls {
+			walkListsAt(d, path, target, fn)
+		}
+	case *ast.StructLit:
+		for _, d := range x.Elts {
+			walkListsAt(d, path, target, fn)
+		}
+	case *ast.EmbedDecl:
+		walkListsAt(x.Expr, path, target, fn)
+	case *ast.ParenExpr:
+		walkListsAt(x.X, path, target, fn)
+	case *ast.BinaryExpr:
+		if x.Op == token.AND {
+			walkListsAt(x.X, path, target, fn)
+			walkListsAt(x.Y, path, target, fn)
+		}
+	case *ast.Field:
+		name, _, err := ast.LabelName(x.Label)
+		if err != nil || len(path) >= len(target) || name != target[len(path)] {
+			return
+		}
+		path = append(path[:len(path):len(path)], name)
+		if len(path) < len(target) {
+			walkListsAt(x.Value, path, target, fn)
+		} else if list, is := x.Value.(*ast.ListLit); is {
+			fn(list)
+		}
+	}
+}
+
+// structElements returns the elements of list, if all are struct literals.
+func structElements(list *ast.ListLit) ([]*ast.StructLit, bool) {
+	elems := make([]*ast.StructLit, 0, len(list.Elts))
+	for _, e := range list.Elts {
+		s, is := e.(*ast.StructLit)
+		if !is {
+			return nil, false
+		}
+		elems = append(elems, s)
+	}
+	return elems, true
+}
+
+// A spanEdit permutes a set of non-overlapping spans of source, in ascending
+// order. The span at slots[i] is replaced by the source at slots[from[i]], with
+// the source between spans left in place.
+type spanEdit struct {
+	slots [][2]int
+	from  []int
+}
+
+func applyEdits(src []byte, edits []spanEdit) []byte {
+	type repl struct{ slot, with [2]int }
+	var repls []repl
+	for _, e := range edits {
+		for i, j := range e.from {
+			repls = append(repls, repl{slot: e.slots[i], with: e.slots[j]})
+		}
+	}
+	sort.Slice(repls, func(i, j int) bool {
+		return repls[i].slot[0] < repls[j].slot[0]
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, r := range repls {
+		buf.Write(src[last:r.slot[0]])
+		buf.Write(src[r.with[0]:r.with[1]])
+		last = r.slot[1]
+	}
+	buf.Write(src[last:])
+	return buf.Bytes()
+}
+
+// span returns the source span of n, including any doc comments preceding it.
+func span(n ast.Node) [2]int {
+	start := n.Pos().Offset()
+	for _, cg := range ast.Comments(n) {
+		if cg.Doc && cg.Pos().IsValid() && cg.Pos().Offset() < start {
+			start = cg.Pos().Offset()
+		}
+	}
+	return [2]int{start, n.End().Offset()}
+}
+
+// elementOrderEdit sorts elems stably by the pair of versions returned from key.
+func elementOrderEdit(elems []*ast.StructLit, key func(*ast.StructLit) (thema.SyntacticVersion, thema.SyntacticVersion, error)) (spanEdit, errors.Error) {
+	type elem struct {
+		idx           int
+		first, second thema.SyntacticVersion
+	}
+	sorted := make([]elem, 0, len(elems))
+	edit := spanEdit{}
+	for i, s := range elems {
+		first, second, err := key(s)
+		if err != nil {
+			return spanEdit{}, errors.Newf(s.Pos(), "%s", err)
+		}
+		sorted = append(sorted, elem{idx: i, first: first, second: second})
+		edit.slots = append(edit.slots, span(s))
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].first != sorted[j].first {
+			return sorted[i].first.Less(sorted[j].first)
+		}
+		return sorted[i].second.Less(sorted[j].second)
+	})
+	for _, e := range sorted {
+		edit.from = append(edit.from, e.idx)
+	}
+	return edit, nil
+}
+
+// fieldOrderEdit orders the fields of s named in order to appear in that order,
+// occupying the same slots among the struct's declarations as before.
+func fieldOrderEdit(s *ast.StructLit, order []string) spanEdit {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+
+	var edit spanEdit
+	var ranks []int
+	for _, d := range s.Elts {
+		f, is := d.(*ast.Field)
+		if !is {
+			continue
+		}
+		name, _, err := ast.LabelName(f.Label)
+		if r, has := rank[name]; err == nil && has {
+			edit.slots = append(edit.slots, span(f))
+			edit.from = append(edit.from, len(ranks))
+			ranks = append(ranks, r)
+		}
+	}
+	sort.SliceStable(edit.from, func(i, j int) bool {
+		return ranks[edit.from[i]] < ranks[edit.from[j]]
+	})
+	return edit
+}
+
+// versionField returns the syntactic version declared as a list literal in
+// the named field of s.
+func versionField(s *ast.StructLit, name string) (thema.SyntacticVersion, error) {
+	var v thema.SyntacticVersion
+	f, err := tastutil.GetFieldByLabel(s, name)
+	if err != nil {
+		return v, fmt.Errorf("no %s field", name)
+	}
+	list, is := f.Value.(*ast.ListLit)
+	if !is || len(list.Elts) != 2 {
+		return v, fmt.Errorf("%s must be declared as a list literal of two integers to be formatted", name)
+	}
+	for i, e := range list.Elts {
+		lit, is := e.(*ast.BasicLit)
+		if !is || lit.Kind != token.INT {
+			return v, fmt.Errorf("%s must be declared as a list literal of two integers to be formatted", name)
+		}
+		n, err := strconv.ParseUint(lit.Value, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		v[i] = uint(n)
+	}
+	return v, nil
+}
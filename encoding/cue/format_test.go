@@ -0,0 +1,122 @@
+package cue
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLineage(t *testing.T) {
+	table := []struct {
+		name, path, in, out string
+		err                 bool
+	}{
+		{
+			name: "canonical",
+			in: `name: "x"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}]
+lenses: []
+`,
+			out: `name: "x"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}]
+lenses: []
+`,
+		},
+		{
+			name: "unordered",
+			in: `name: "x"
+schemas: [{
+	// second
+	schema: {a: string, b?: int}
+	version: [0, 1]
+}, {
+	version: [1, 0]
+	schema: c: string
+}, {
+	version: [0, 0]
+	schema: a: string
+}]
+lenses: [{
+	from: [0, 1]
+	to: [1, 0]
+	input: _
+	result: c: input.a
+}, {
+	// back
+	input: _
+	to: [0, 1]
+	from: [1, 0]
+	result: a: input.c
+}]
+`,
+			out: `name: "x"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [0, 1]
+	// second
+	schema: {a: string, b?: int}
+}, {
+	version: [1, 0]
+	schema: c: string
+}]
+lenses: [{
+	to: [0, 1]
+	from: [1, 0]
+	// back
+	input: _
+	result: a: input.c
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: c: input.a
+}]
+`,
+		},
+		{
+			name: "subpath",
+			path: "lin",
+			in: `other: schemas: [{version: [0, 1]}, {version: [0, 0]}]
+lin: name: "x"
+lin: schemas: [{version: [0, 1], schema: {}}, {version: [0, 0], schema: {}}]
+`,
+			out: `other: schemas: [{version: [0, 1]}, {version: [0, 0]}]
+lin: name: "x"
+lin: schemas: [{version: [0, 0], schema: {}}, {version: [0, 1], schema: {}}]
+`,
+		},
+		{
+			name: "nonliteral",
+			in: `v: [0, 0]
+schemas: [{version: v, schema: {}}]
+`,
+			err: true,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := FormatLineage(tt.name+".cue", []byte(tt.in), cue.ParsePath(tt.path))
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, string(out))
+
+			again, err := FormatLineage(tt.name+".cue", out, cue.ParsePath(tt.path))
+			require.NoError(t, err)
+			assert.Equal(t, string(out), string(again), "formatting is not idempotent")
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package cue
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+)
+
+// maxInferredValues is the largest number of distinct strings observed for a
+// field for which InferSchema reports the values.
+const maxInferredValues = 5
+
+// InferSchema returns a CUE struct literal proposing a schema to which each of
+// the provided documents conforms, suitable as a starting point for the first
+// schema of a new lineage, or for a new schema in an existing one.
+//
+// Each field is declared with the types observed for it across the documents,
+// and is optional if it is absent from any of them. Fields are annotated with
+// comments describing how often they were present, the mix of types observed,
+// the range of numbers observed, and, where few distinct strings were observed,
+// what those strings were. These observations are not encoded as constraints,
+// as a corpus rarely includes every valid value, and are intended to inform
+// the schema author's judgment.
+//
+// Documents must be concrete structs, such as decoded JSON or YAML objects.
+func InferSchema(docs []cue.Value) (ast.Expr, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no documents to infer a schema from")
+	}
+	root := newObservation()
+	for i, doc := range docs {
+		if err := doc.Validate(cue.Concrete(true)); err != nil {
+			return nil, fmt.Errorf("document %d is not concrete: %w", i, err)
+		}
+		if doc.Kind() != cue.StructKind {
+			return nil, fmt.Errorf("document %d is a %s, not a struct", i, doc.Kind())
+		}
+		root.observe(doc)
+	}
+
+	var b strings.Builder
+	root.writeStruct(&b, 0, "documents")
+	x, err := parser.ParseExpr("inferred.cue", b.String(), parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("%s\nerror while parsing inferred schema: %w", b.String(), err)
+	}
+	return x, nil
+}
+
+// An observation accumulates the values observed at a single position across
+// a corpus of documents.
+type observation struct {
+	count int
+	kinds map[cue.Kind]int
+
+	// min and max are the bounds of observed numbers.
+	min, max float64
+
+	// strs holds the distinct observed strings, up to one more than
+	// maxInferredValues.
+	strs map[string]bool
+
+	// structs is the number of observed structs, which are merged into fields.
+	structs int
+	fields  map[string]*observation
+	order   []string
+
+	// elems merges the elements of observed lists.
+	elems *observation
+}
+
+func newObservation() *observation {
+	return &observation{
+		kinds:  make(map[cue.Kind]int),
+		strs:   make(map[string]bool),
+		fields: make(map[string]*observation),
+	}
+}
+
+func (o *observation) observe(v cue.Value) {
+	o.count++
+	k := v.Kind()
+	o.kinds[k]++
+
+	switch k {
+	case cue.IntKind, cue.FloatKind:
+		f, _ := v.Float64()
+		if o.kinds[cue.IntKind]+o.kinds[cue.FloatKind] == 1 || f < o.min {
+			o.min = f
+		}
+		if o.kinds[cue.IntKind]+o.kinds[cue.FloatKind] == 1 || f > o.max {
+			o.max = f
+		}
+	case cue.StringKind:
+		if len(o.strs) <= maxInferredValues {
+			s, _ := v.String()
+			o.strs[s] = true
+		}
+	case cue.StructKind:
+		o.structs++
+		iter, _ := v.Fields()
+		for iter.Next() {
+			name := iter.Selector().Unquoted()
+			child, has := o.fields[name]
+			if !has {
+				child = newObservation()
+				o.fields[name] = child
+				o.order = append(o.order, name)
+			}
+			child.observe(iter.Value())
+		}
+	case cue.ListKind:
+		if o.elems == nil {
+			o.elems = newObservation()
+		}
+		iter, _ := v.List()
+		for iter.Next() {
+			o.elems.observe(iter.Value())
+		}
+	}
+}
+
+// writeStruct writes the struct merged from the observed structs. Field
+// presence is described relative to noun, the plural of what the structs are.
+func (o *observation) writeStruct(b *strings.Builder, depth int, noun string) {
+	b.WriteString("{\n")
+	for _, name := range o.order {
+		f := o.fields[name]
+		indent := strings.Repeat("\t", depth+1)
+		var notes []string
+		if f.count < o.structs {
+			notes = append(notes, fmt.Sprintf("Present in %d of %d %s.", f.count, o.structs, noun))
+		}
+		notes = append(notes, f.notes("")...)
+		if f.elems != nil {
+			notes = append(notes, f.elems.notes("Elements: ")...)
+		}
+		for _, n := range notes {
+			fmt.Fprintf(b, "%s// %s\n", indent, n)
+		}
+
+		b.WriteString(indent + cueLabel(name))
+		if f.count < o.structs {
+			b.WriteString("?")
+		}
+		b.WriteString(": ")
+		f.writeType(b, depth+1)
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Repeat("\t", depth) + "}")
+}
+
+// writeType writes a disjunction of the types observed, in a fixed order.
+func (o *observation) writeType(b *strings.Builder, depth int) {
+	var types []string
+	for _, k := range []cue.Kind{cue.NullKind, cue.BoolKind, cue.IntKind, cue.StringKind, cue.BytesKind} {
+		if o.kinds[k] == 0 || k == cue.IntKind && o.kinds[cue.FloatKind] > 0 {
+			continue
+		}
+		types = append(types, k.String())
+	}
+	if o.kinds[cue.FloatKind] > 0 {
+		types = append(types, "number")
+	}
+	if len(types) == 0 && o.kinds[cue.StructKind] == 0 && o.kinds[cue.ListKind] == 0 {
+		types = append(types, "_")
+	}
+	b.WriteString(strings.Join(types, " | "))
+
+	if o.kinds[cue.StructKind] > 0 {
+		if len(types) > 0 {
+			b.WriteString(" | ")
+		}
+		o.writeStruct(b, depth, "objects")
+	}
+	if o.kinds[cue.ListKind] > 0 {
+		if len(types) > 0 || o.kinds[cue.StructKind] > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString("[...")
+		if o.elems.count == 0 {
+			b.WriteString("_")
+		} else {
+			o.elems.writeType(b, depth)
+		}
+		b.WriteString("]")
+	}
+}
+
+// notes describes the observed values, each note starting with prefix.
+func (o *observation) notes(prefix string) []string {
+	var notes []string
+	if len(o.kinds) > 1 {
+		kinds := make([]cue.Kind, 0, len(o.kinds))
+		for k := range o.kinds {
+			kinds = append(kinds, k)
+		}
+		sort.Slice(kinds, func(i, j int) bool {
+			if o.kinds[kinds[i]] != o.kinds[kinds[j]] {
+				return o.kinds[kinds[i]] > o.kinds[kinds[j]]
+			}
+			return kinds[i] < kinds[j]
+		})
+		parts := make([]string, 0, len(kinds))
+		for _, k := range kinds {
+			parts = append(parts, fmt.Sprintf("%s (%d)", k, o.kinds[k]))
+		}
+		notes = append(notes, prefix+"Observed types: "+strings.Join(parts, ", ")+".")
+	}
+
+	if n := o.kinds[cue.IntKind] + o.kinds[cue.FloatKind]; n > 0 {
+		fmtf := func(f float64) string {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		if o.min == o.max {
+			notes = append(notes, fmt.Sprintf("%sObserved value: %s.", prefix, fmtf(o.min)))
+		} else {
+			notes = append(notes, fmt.Sprintf("%sObserved range: %s to %s.", prefix, fmtf(o.min), fmtf(o.max)))
+		}
+	}
+
+	// Only report strings repeated across documents, as unique strings, such
+	// as identifiers, are unlikely to be drawn from a fixed set
+	if n := o.kinds[cue.StringKind]; n > len(o.strs) && len(o.strs) <= maxInferredValues {
+		strs := make([]string, 0, len(o.strs))
+		for s := range o.strs {
+			strs = append(strs, strconv.Quote(s))
+		}
+		sort.Strings(strs)
+		notes = append(notes, prefix+"Observed values: "+strings.Join(strs, ", ")+".")
+	}
+	return notes
+}
@@ -0,0 +1,58 @@
+package cue
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema/internal/astutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	var docs []cue.Value
+	for _, s := range []string{
+		`{"title": "a", "kind": "panel", "count": 1, "tags": ["x"], "meta": {"owner": "me"}}`,
+		`{"title": "b", "kind": "row", "count": 42, "tags": [], "meta": {"owner": "you", "team": "t"}}`,
+		`{"title": "c", "kind": "panel", "count": 2.5, "tags": ["y", "z"], "id": null}`,
+		`{"title": "d", "kind": "row", "count": 7, "tags": [], "id": "abc"}`,
+	} {
+		docs = append(docs, ctx.CompileString(s))
+	}
+
+	x, err := InferSchema(docs)
+	require.NoError(t, err)
+	b, err := astutil.FmtNode(x)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+	title: string
+	// Observed values: "panel", "row".
+	kind: string
+	// Observed types: int (3), float (1).
+	// Observed range: 1 to 42.
+	count: number
+	tags: [...string]
+	// Present in 2 of 4 documents.
+	meta?: {
+		owner: string
+		// Present in 1 of 2 objects.
+		team?: string
+	}
+	// Present in 2 of 4 documents.
+	// Observed types: null (1), string (1).
+	id?: null | string
+}
+`, string(b))
+
+	// The proposed schema accepts every document
+	sch := ctx.BuildExpr(x)
+	require.NoError(t, sch.Err())
+	for _, doc := range docs {
+		assert.NoError(t, sch.Unify(doc).Validate(cue.Concrete(true)))
+	}
+
+	_, err = InferSchema([]cue.Value{ctx.CompileString(`[1]`)})
+	assert.Error(t, err)
+	_, err = InferSchema(nil)
+	assert.Error(t, err)
+}
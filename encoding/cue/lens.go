@@ -0,0 +1,314 @@
+package cue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"github.com/cockroachdb/errors"
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+)
+
+var pathSchDef = cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema"))
+
+// GenerateLensStub returns a CUE struct literal declaring a stub lens
+// translating instances of the schema from into instances of the schema to,
+// suitable for appending to a lineage's lenses list.
+//
+// The lens result maps each field of to that is unchanged, or only loosened,
+// relative to the same field of from directly from the input. Each field that
+// was added, removed or retyped is marked with a TODO comment describing the
+// change. Fields likely to have been renamed, as reported by
+// [thema.DiffSchemas], are mapped from their former names, marked with a TODO
+// comment to verify the mapping. Where to requires a value the generator cannot provide, the field is
+// declared as bottom (_|_), such that the lineage fails to bind until the lens
+// author has replaced it.
+func GenerateLensStub(from, to thema.Schema) (ast.Expr, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("both from and to schemas must be provided")
+	}
+	x, _, err := generateLens(from, to, false)
+	return x, err
+}
+
+// SynthesizeIdentityLens returns a CUE struct literal declaring a complete lens
+// translating instances of the schema from into instances of the schema to, for
+// the common case in which each field of to can be populated directly from the
+// same field of from. This is typical of major version bumps made for reasons
+// of policy, where constraints are only tightened or loosened, and spares
+// lens authors from writing such boilerplate lenses by hand.
+//
+// An error wrapping [terrors.ErrLensNotIdentity] describing each obstacle is
+// returned if fields were added without a default, removed, or retyped such
+// that neither schema's constraints subsume the other's. Use
+// [GenerateLensStub] to begin writing a lens by hand in such cases.
+//
+// As tightening a field's constraints may cause translation of some instances
+// to fail, the synthesized lens is verified by translating each example of
+// from. An error wrapping [terrors.ErrLensIncomplete] or
+// [terrors.ErrLensResultIsInvalidData] is returned if verification fails.
+func SynthesizeIdentityLens(from, to thema.Schema) (ast.Expr, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("both from and to schemas must be provided")
+	}
+	x, unresolved, err := generateLens(from, to, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(unresolved) > 0 {
+		return nil, errors.Mark(errors.Newf("cannot translate %s to %s by identity:\n\t%s", from.Version(), to.Version(), strings.Join(unresolved, "\n\t")), terrors.ErrLensNotIdentity)
+	}
+
+	lens := from.Underlying().Context().BuildExpr(x)
+	if err := lens.Err(); err != nil {
+		return nil, err
+	}
+	examples := from.Examples()
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := lens.FillPath(cue.MakePath(cue.Str("input")), examples[name].Underlying()).LookupPath(cue.MakePath(cue.Str("result")))
+		if _, err := json.Marshal(result); err != nil {
+			return nil, errors.Mark(errors.Wrapf(err, "translating example %s of %s to %s produced a non-concrete result", name, from.Version(), to.Version()), terrors.ErrLensIncomplete)
+		}
+		if _, err := to.Validate(result); err != nil {
+			return nil, errors.Mark(errors.Wrapf(err, "translating example %s of %s to %s produced invalid data", name, from.Version(), to.Version()), terrors.ErrLensResultIsInvalidData)
+		}
+	}
+	return x, nil
+}
+
+// generateLens generates a lens translating from into to, returning the lens
+// and descriptions of any changes it does not translate.
+func generateLens(from, to thema.Schema, identity bool) (ast.Expr, []string, error) {
+	g := &lensGen{from: from.Version(), to: to.Version(), identity: identity, buf: new(strings.Builder)}
+	if !identity {
+		g.renames = make(map[string]thema.FieldChange)
+		for _, r := range thema.DiffSchemas(from, to).Renames() {
+			g.renames[r.Path] = r
+		}
+	}
+	g.result(from.Underlying().LookupPath(pathSchDef), to.Underlying().LookupPath(pathSchDef), nil, nil)
+
+	src := fmt.Sprintf("{\n\tto: [%d, %d]\n\tfrom: [%d, %d]\n\tinput: _\n\tresult: {\n%s\t}\n\tlacunas: []\n}",
+		g.to[0], g.to[1], g.from[0], g.from[1], g.buf.String())
+	x, err := parser.ParseExpr("lens.cue", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s\nerror while parsing generated lens: %w", src, err)
+	}
+	return x, g.unresolved, nil
+}
+
+type lensGen struct {
+	from, to thema.SyntacticVersion
+	// identity maps fields whose constraints were tightened directly from the
+	// input, rather than marking them as retyped.
+	identity bool
+	// renames are the suggested renames of fields, keyed by their path in the
+	// target schema, used to pre-fill their mappings.
+	renames map[string]thema.FieldChange
+	buf     *strings.Builder
+	// notes are TODO comments not attached to any declaration. They are
+	// written ahead of the declarations of the struct they belong to, as the
+	// CUE formatter misplaces comments trailing a comprehension.
+	notes []string
+	// unresolved describes each change to the schema not translated by the
+	// generated lens.
+	unresolved []string
+}
+
+func (g *lensGen) line(depth int, format string, args ...interface{}) {
+	g.buf.WriteString(strings.Repeat("\t", depth+2))
+	fmt.Fprintf(g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// todo writes a TODO comment describing a change the lens does not translate.
+func (g *lensGen) todo(depth int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	g.unresolved = append(g.unresolved, msg)
+	g.line(depth, "// TODO %s", msg)
+}
+
+// note is as todo, but for comments not attached to a declaration. Notes for
+// changes the lens need not translate, such as the addition of an optional
+// field, are not unresolved, and are omitted from identity lenses.
+func (g *lensGen) note(depth int, unresolved bool, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if unresolved {
+		g.unresolved = append(g.unresolved, msg)
+	} else if g.identity {
+		return
+	}
+	g.notes = append(g.notes, strings.Repeat("\t", depth+2)+"// TODO "+msg+"\n")
+}
+
+// result writes the declarations of the lens result for the struct tov at
+// path, given the corresponding struct fromv at inpath in the input.
+func (g *lensGen) result(fromv, tov cue.Value, inpath, path []string) {
+	outer, notes := g.buf, g.notes
+	g.buf, g.notes = new(strings.Builder), nil
+	defer func() {
+		for _, n := range g.notes {
+			outer.WriteString(n)
+		}
+		outer.WriteString(g.buf.String())
+		g.buf, g.notes = outer, notes
+	}()
+
+	depth := len(path)
+	iter, err := tov.Fields(cue.Optional(true))
+	if err != nil {
+		g.note(depth, true, "could not read fields of %s: %s", g.to, oneLine(err.Error()))
+		return
+	}
+
+	mapped := make(map[string]bool)
+	for iter.Next() {
+		name := iter.Selector().Unquoted()
+		fpath := append(path[:len(path):len(path)], name)
+		mapped[g.field(fromv, iter.Value(), inpath, fpath, iter.IsOptional())] = true
+	}
+
+	iter, err = fromv.Fields(cue.Optional(true))
+	if err != nil {
+		return
+	}
+	for iter.Next() {
+		name := iter.Selector().Unquoted()
+		if !mapped[name] {
+			fpath := append(inpath[:len(inpath):len(inpath)], name)
+			g.note(depth, true, "%s was removed in %s; map it to another field or record a lacuna", strings.Join(fpath, "."), g.to)
+		}
+	}
+}
+
+// field writes the declaration of the field at path in the lens result, with
+// value tov in the target schema, given the struct fromstruct at inpath in the
+// input. It returns the name of the input field the field is mapped from, if
+// any.
+func (g *lensGen) field(fromstruct, tov cue.Value, inpath, path []string, optional bool) string {
+	depth := len(path) - 1
+	name := path[len(path)-1]
+	label, dotted := cueLabel(name), strings.Join(path, ".")
+	_, hasDefault := tov.Default()
+	required := !optional && !hasDefault
+
+	inname := name
+	fromv, fromOptional, has := fromField(fromstruct, name)
+	if r, is := g.renames[dotted]; is && !has {
+		inname = r.RenamedFrom[strings.LastIndex(r.RenamedFrom, ".")+1:]
+		fromv, fromOptional, has = fromField(fromstruct, inname)
+		g.line(depth, "// TODO %s may have been renamed from %s (%s); verify this mapping", dotted, r.RenamedFrom, strings.Join(r.Reasons, ", "))
+	}
+	fpath := append(inpath[:len(inpath):len(inpath)], inname)
+	in := inputRef(fpath)
+
+	switch {
+	case !has:
+		if required {
+			g.todo(depth, "%s was added in %s and must be populated", dotted, g.to)
+			g.line(depth, "%s: _|_", label)
+		} else {
+			g.note(depth, false, "%s was added in %s; populate it from input if possible", dotted, g.to)
+		}
+		return ""
+	case tov.Subsume(fromv, cue.Raw(), cue.All()) == nil,
+		g.identity && fromv.Subsume(tov, cue.Raw(), cue.All()) == nil:
+		g.guard(depth, in, fromOptional, required, dotted, func(depth int) {
+			g.line(depth, "%s: %s", label, in)
+		})
+	case tov.IncompleteKind() == cue.StructKind && fromv.IncompleteKind() == cue.StructKind &&
+		!isMap(tov) && !isMap(fromv):
+		g.guard(depth, in, fromOptional, required, dotted, func(depth int) {
+			g.line(depth, "%s: {", label)
+			g.result(fromv, tov, fpath, path)
+			g.line(depth, "}")
+		})
+	default:
+		g.todo(depth, "%s was retyped in %s from %s to %s", dotted, g.to, constraint(fromv), constraint(tov))
+		g.line(depth, "%s: _|_", label)
+	}
+	return inname
+}
+
+// guard writes the declaration made by decl, conditional on the presence of
+// the field in the input if it is optional there.
+func (g *lensGen) guard(depth int, in string, fromOptional, required bool, dotted string, decl func(depth int)) {
+	if !fromOptional {
+		decl(depth)
+		return
+	}
+	if required {
+		g.todo(depth, "%s is optional in %s but required in %s; populate it when absent from input", dotted, g.from, g.to)
+	}
+	g.line(depth, "if %s != _|_ {", in)
+	decl(depth + 1)
+	g.line(depth, "}")
+}
+
+// fromField returns the value of the named field in the struct v, whether it
+// is optional, and whether it exists.
+func fromField(v cue.Value, name string) (cue.Value, bool, bool) {
+	if fv := v.LookupPath(cue.MakePath(cue.Str(name))); fv.Exists() {
+		return fv, false, true
+	}
+	if fv := v.LookupPath(cue.MakePath(cue.Str(name).Optional())); fv.Exists() {
+		return fv, true, true
+	}
+	return cue.Value{}, false, false
+}
+
+// isMap reports whether the struct v constrains its fields by a pattern.
+func isMap(v cue.Value) bool {
+	return v.LookupPath(cue.MakePath(cue.AnyString)).Exists()
+}
+
+// constraint returns a single-line description of the constraints on v.
+func constraint(v cue.Value) string {
+	if v.IncompleteKind() == cue.StructKind {
+		return "struct"
+	}
+	b, err := format.Node(v.Syntax(cue.Raw()))
+	if err != nil {
+		return v.IncompleteKind().String()
+	}
+	return oneLine(string(b))
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cueLabel returns name as a CUE field label, quoted if it is not a valid
+// regular identifier.
+func cueLabel(name string) string {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// inputRef returns a CUE reference to the field at path within the lens input.
+func inputRef(path []string) string {
+	var b strings.Builder
+	b.WriteString("input")
+	for _, name := range path {
+		if label := cueLabel(name); label == name {
+			b.WriteString("." + name)
+		} else {
+			b.WriteString("[" + label + "]")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,283 @@
+package gocode
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/dave/dst"
+	"github.com/dave/dst/dstutil"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/openapi"
+	"github.com/grafana/thema/internal/deepmap/oapi-codegen/pkg/codegen"
+	"github.com/grafana/thema/internal/util"
+)
+
+// goAttr is the name of the attribute that customizes the Go code generated
+// for a schema field or definition, e.g. @go(name=ID,omitempty).
+const goAttr = "go"
+
+// goFieldAttr holds the arguments of a @go attribute.
+type goFieldAttr struct {
+	// name replaces the generated identifier of the field or definition.
+	name string
+	// omitempty forces the omitempty option on the field's json tag.
+	omitempty bool
+}
+
+// parseGoAttr returns the arguments of the @go attribute declared on v, if
+// any.
+func parseGoAttr(v cue.Value) (*goFieldAttr, error) {
+	a := v.Attribute(goAttr)
+	if a.Err() != nil {
+		// No @go attribute
+		return nil, nil
+	}
+
+	ga := &goFieldAttr{}
+	for i := 0; i < a.NumArgs(); i++ {
+		k, val := a.Arg(i)
+		switch {
+		case k == "name" && val != "":
+			if !token.IsIdentifier(val) || !token.IsExported(val) {
+				return nil, fmt.Errorf("%s: @go(name=%s) is not an exported Go identifier", v.Pos(), val)
+			}
+			ga.name = val
+		case k == "omitempty" && val == "":
+			ga.omitempty = true
+		default:
+			return nil, fmt.Errorf("%s: unknown @go attribute argument %q; expected name=<ident> or omitempty", v.Pos(), a.Contents())
+		}
+	}
+	return ga, nil
+}
+
+// goAttrTarget pairs a CUE value with the name of the Go type generated from
+// it.
+type goAttrTarget struct {
+	typeName string
+	v        cue.Value
+}
+
+// goAttributes returns an AST manipulator that applies the @go attributes
+// declared in the schema to the types generated from it. Fields are renamed,
+// or made to omit empty values, and top-level definitions are renamed, along
+// with every reference to them.
+//
+// Attributes are validated eagerly, so that errors can be returned before
+// generation begins.
+func goAttributes(sch thema.Schema, cfg *openapi.Config) (dstutil.ApplyFunc, error) {
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+
+	var targets []goAttrTarget
+	addDefs := func(v cue.Value, group bool) {
+		iter, err := v.Fields(cue.Definitions(true), cue.Optional(true))
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			if group || iter.Selector().IsDefinition() {
+				targets = append(targets, goAttrTarget{
+					typeName: goTypeName(strings.Trim(iter.Selector().String(), "?#")),
+					v:        iter.Value(),
+				})
+			}
+		}
+	}
+
+	switch {
+	case cfg != nil && cfg.Group:
+		addDefs(schdef, true)
+	default:
+		name := util.SanitizeLabelString(sch.Lineage().Name())
+		root := schdef
+		if cfg != nil && len(cfg.Subpath.Selectors()) > 0 {
+			sels := cfg.Subpath.Selectors()
+			name = sels[len(sels)-1].String()
+			root = schdef.LookupPath(cfg.Subpath)
+		}
+		if cfg != nil && cfg.RootName != "" {
+			name = cfg.RootName
+		}
+		targets = append(targets, goAttrTarget{typeName: goTypeName(name), v: root})
+		addDefs(schdef, false)
+	}
+
+	renames := make(map[string]string)
+	for _, t := range targets {
+		ga, err := parseGoAttr(t.v)
+		if err != nil {
+			return nil, err
+		}
+		if ga != nil && ga.name != "" {
+			renames[t.typeName] = ga.name
+		}
+	}
+	for _, t := range targets {
+		if err := validateGoAttrs(t.v, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(c *dstutil.Cursor) bool {
+		f, is := c.Node().(*dst.File)
+		if !is {
+			return false
+		}
+
+		types := make(map[string]*dst.TypeSpec)
+		for _, decl := range f.Decls {
+			if gd, is := decl.(*dst.GenDecl); is {
+				for _, spec := range gd.Specs {
+					if ts, is := spec.(*dst.TypeSpec); is {
+						types[ts.Name.Name] = ts
+					}
+				}
+			}
+		}
+
+		visited := make(map[string]bool)
+		for _, t := range targets {
+			if ts, has := types[t.typeName]; has && !visited[t.typeName] {
+				visited[t.typeName] = true
+				applyGoAttrs(t.v, ts.Type, types, visited)
+			}
+		}
+		renameTypes(f, renames)
+		return false
+	}, nil
+}
+
+// goTypeName returns the name of the Go type generated for the OpenAPI
+// schema component with the provided name.
+func goTypeName(name string) string {
+	return strings.ReplaceAll(codegen.SchemaNameToTypeName(name), "_", "")
+}
+
+// validateGoAttrs recursively validates the @go attributes on all fields in v.
+func validateGoAttrs(v cue.Value, depth int) error {
+	if depth > maxAttrDepth {
+		return nil
+	}
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		iter, err := v.Fields(cue.Optional(true))
+		if err != nil {
+			return nil
+		}
+		for iter.Next() {
+			if _, err := parseGoAttr(iter.Value()); err != nil {
+				return err
+			}
+			if err := validateGoAttrs(iter.Value(), depth+1); err != nil {
+				return err
+			}
+		}
+	case cue.ListKind:
+		return validateGoAttrs(v.LookupPath(cue.MakePath(cue.AnyIndex)), depth+1)
+	}
+	return nil
+}
+
+// maxAttrDepth bounds the depth to which schemas are walked, guarding against
+// infinite expansion of recursive schemas.
+const maxAttrDepth = 32
+
+// applyGoAttrs applies the @go attributes on the fields of v to the Go type
+// generated from it, descending into nested structs, slices and maps, and
+// into named types not yet visited.
+func applyGoAttrs(v cue.Value, typ dst.Expr, types map[string]*dst.TypeSpec, visited map[string]bool) {
+	switch x := depoint(typ).(type) {
+	case *dst.Ident:
+		if ts, has := types[x.Name]; has && !visited[x.Name] {
+			visited[x.Name] = true
+			applyGoAttrs(v, ts.Type, types, visited)
+		}
+	case *dst.ArrayType:
+		applyGoAttrs(v.LookupPath(cue.MakePath(cue.AnyIndex)), x.Elt, types, visited)
+	case *dst.MapType:
+		applyGoAttrs(v.LookupPath(cue.MakePath(cue.AnyString)), x.Value, types, visited)
+	case *dst.StructType:
+		iter, err := v.Fields(cue.Optional(true))
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			field := fieldByJSONName(x, iter.Selector().Unquoted())
+			if field == nil {
+				continue
+			}
+			// Already validated by goAttributes
+			ga, _ := parseGoAttr(iter.Value()) // nolint: errcheck
+			if ga != nil {
+				if ga.name != "" && len(field.Names) == 1 {
+					renameComment(field.Decorations(), field.Names[0].Name, ga.name)
+					field.Names[0].Name = ga.name
+				}
+				if ga.omitempty && field.Tag != nil && !strings.Contains(field.Tag.Value, ",omitempty\"") {
+					field.Tag.Value = strings.Replace(field.Tag.Value, fmt.Sprintf("json:%q", iter.Selector().Unquoted()), fmt.Sprintf("json:\"%s,omitempty\"", iter.Selector().Unquoted()), 1)
+				}
+			}
+			applyGoAttrs(iter.Value(), field.Type, types, visited)
+		}
+	}
+}
+
+// fieldByJSONName returns the field of st whose json tag has the provided
+// name, if any.
+func fieldByJSONName(st *dst.StructType, name string) *dst.Field {
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag := strings.Trim(field.Tag.Value, "`")
+		i := strings.Index(tag, `json:"`)
+		if i < 0 {
+			continue
+		}
+		jname := tag[i+len(`json:"`):]
+		jname = jname[:strings.IndexAny(jname, `,"`)]
+		if jname == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// renameTypes renames the named types in f, and all references to them.
+func renameTypes(f *dst.File, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+	dstutil.Apply(f, func(c *dstutil.Cursor) bool {
+		switch x := c.Node().(type) {
+		case *dst.TypeSpec:
+			if to, has := renames[x.Name.Name]; has {
+				if gd, is := c.Parent().(*dst.GenDecl); is {
+					renameComment(gd.Decorations(), x.Name.Name, to)
+				}
+			}
+		case *dst.Ident:
+			// Leave field names alone, even where they match a type name
+			if _, is := c.Parent().(*dst.Field); is && c.Name() == "Names" {
+				return true
+			}
+			if to, has := renames[x.Name]; has {
+				x.Name = to
+			}
+		}
+		return true
+	}, nil)
+}
+
+// renameComment replaces the identifier at the start of a doc comment, so that
+// it continues to name the renamed declaration.
+func renameComment(decs *dst.NodeDecs, from, to string) {
+	for i, line := range decs.Start {
+		if strings.HasPrefix(line, "// "+from+" ") {
+			decs.Start[i] = "// " + to + line[len("// "+from):]
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package gocode
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// deepCopyAnyFunc is the name of the helper generated to deep copy values of
+// unconstrained fields, which hold the results of decoding arbitrary JSON.
+const deepCopyAnyFunc = "deepCopyJSONValue"
+
+// methodGen generates methods for the types declared in a generated Go file.
+type methodGen struct {
+	buf  bytes.Buffer
+	fset *token.FileSet
+
+	// types maps the name of each type declared in the file to its
+	// definition. Aliases are resolved to their targets.
+	types map[string]ast.Expr
+	// names lists the declared types in order of declaration, excluding
+	// aliases, which cannot be given methods.
+	names    []string
+	declared map[string]bool
+
+	// needsAny records that the deep copy helper for unconstrained values
+	// must be generated.
+	needsAny bool
+	// nvar is used to generate unique variable names.
+	nvar int
+}
+
+// addMethods appends the methods requested by cfg to the generated Go file
+// src, returning the formatted result.
+func addMethods(src []byte, cfg *TypeConfigOpenAPI) ([]byte, error) {
+	if !cfg.DeepCopy && !cfg.Getters {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing generated file: %w", err)
+	}
+
+	g := &methodGen{fset: fset, types: make(map[string]ast.Expr), declared: make(map[string]bool)}
+	for _, decl := range f.Decls {
+		gd, is := decl.(*ast.GenDecl)
+		if !is || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			g.types[ts.Name.Name] = ts.Type
+			if !ts.Assign.IsValid() {
+				g.names = append(g.names, ts.Name.Name)
+				g.declared[ts.Name.Name] = true
+			}
+		}
+	}
+
+	for _, name := range g.names {
+		if cfg.DeepCopy {
+			g.genDeepCopy(name)
+		}
+		if cfg.Getters {
+			g.genGetters(name)
+		}
+	}
+	if g.needsAny {
+		g.genDeepCopyAny()
+	}
+
+	out, err := format.Source(append(append(src, '\n'), g.buf.Bytes()...))
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated methods: %w", err)
+	}
+	return out, nil
+}
+
+func (g *methodGen) p(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format+"\n", args...)
+}
+
+// resolve returns the underlying definition of typ, following references to
+// types declared in the file.
+func (g *methodGen) resolve(typ ast.Expr) ast.Expr {
+	for i := 0; i < len(g.types); i++ {
+		id, is := typ.(*ast.Ident)
+		if !is {
+			return typ
+		}
+		def, has := g.types[id.Name]
+		if !has {
+			return typ
+		}
+		typ = def
+	}
+	return typ
+}
+
+// isShallow reports whether values of typ are fully copied by assignment.
+func (g *methodGen) isShallow(typ ast.Expr) bool {
+	switch x := g.resolve(typ).(type) {
+	case *ast.Ident:
+		return x.Name != "any"
+	case *ast.SelectorExpr:
+		// e.g. time.Time, but not json.RawMessage, which is a byte slice
+		return g.expr(x) != "json.RawMessage"
+	case *ast.StructType:
+		for _, field := range x.Fields.List {
+			if !g.isShallow(field.Type) {
+				return false
+			}
+		}
+		return true
+	case *ast.ArrayType:
+		return x.Len != nil && g.isShallow(x.Elt)
+	}
+	return false
+}
+
+func (g *methodGen) genDeepCopy(name string) {
+	g.p("// DeepCopyInto copies the receiver into out. in must be non-nil.")
+	g.p("func (in *%s) DeepCopyInto(out *%s) {", name, name)
+	g.p("*out = *in")
+	if st, is := g.types[name].(*ast.StructType); is {
+		g.copyFields("out", "in", st)
+	} else if !g.isShallow(g.types[name]) {
+		g.copyValue("(*out)", "(*in)", g.types[name])
+	}
+	g.p("}")
+	g.p("")
+	g.p("// DeepCopy copies the receiver, creating a new %s.", name)
+	g.p("func (in *%s) DeepCopy() *%s {", name, name)
+	g.p("if in == nil {")
+	g.p("return nil")
+	g.p("}")
+	g.p("out := new(%s)", name)
+	g.p("in.DeepCopyInto(out)")
+	g.p("return out")
+	g.p("}")
+	g.p("")
+}
+
+// copyFields generates statements deep copying the fields of the struct src
+// into dst, where dst is already a shallow copy of src.
+func (g *methodGen) copyFields(dst, src string, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		if g.isShallow(field.Type) {
+			continue
+		}
+		for _, n := range fieldNames(field) {
+			g.copyValue(dst+"."+n, src+"."+n, field.Type)
+		}
+	}
+}
+
+// copyValue generates statements assigning a deep copy of src, of type typ,
+// to dst.
+func (g *methodGen) copyValue(dst, src string, typ ast.Expr) {
+	if g.isShallow(typ) {
+		g.p("%s = %s", dst, src)
+		return
+	}
+	if id, is := typ.(*ast.Ident); is {
+		if g.declared[id.Name] {
+			g.p("%s.DeepCopyInto(&%s)", src, dst)
+			return
+		}
+	}
+
+	switch x := g.resolve(typ).(type) {
+	case *ast.Ident, *ast.InterfaceType:
+		// any
+		g.needsAny = true
+		g.p("%s = %s(%s)", dst, deepCopyAnyFunc, src)
+	case *ast.SelectorExpr:
+		// json.RawMessage
+		g.p("if %s != nil {", src)
+		g.p("%s = make(%s, len(%s))", dst, g.expr(typ), src)
+		g.p("copy(%s, %s)", dst, src)
+		g.p("}")
+	case *ast.StructType:
+		g.p("%s = %s", dst, src)
+		g.copyFields(dst, src, x)
+	case *ast.StarExpr:
+		g.p("if %s != nil {", src)
+		g.p("%s = new(%s)", dst, g.expr(x.X))
+		g.copyValue("(*"+dst+")", "(*"+src+")", x.X)
+		g.p("}")
+	case *ast.ArrayType:
+		i := g.v("i")
+		if x.Len == nil {
+			g.p("if %s != nil {", src)
+			g.p("%s = make(%s, len(%s))", dst, g.expr(typ), src)
+		} else {
+			g.p("{")
+		}
+		if g.isShallow(x.Elt) && x.Len == nil {
+			g.p("copy(%s, %s)", dst, src)
+		} else if g.isShallow(x.Elt) {
+			g.p("copy(%s[:], %s[:])", dst, src)
+		} else {
+			g.p("for %s := range %s {", i, src)
+			g.copyValue(dst+"["+i+"]", src+"["+i+"]", x.Elt)
+			g.p("}")
+		}
+		g.p("}")
+	case *ast.MapType:
+		k, v, c := g.v("k"), g.v("v"), g.v("c")
+		g.p("if %s != nil {", src)
+		g.p("%s = make(%s, len(%s))", dst, g.expr(typ), src)
+		g.p("for %s, %s := range %s {", k, v, src)
+		if g.isShallow(x.Value) {
+			g.p("%s[%s] = %s", dst, k, v)
+		} else {
+			g.p("var %s %s", c, g.expr(x.Value))
+			g.copyValue(c, v, x.Value)
+			g.p("%s[%s] = %s", dst, k, c)
+		}
+		g.p("}")
+		g.p("}")
+	default:
+		g.p("%s = %s", dst, src)
+	}
+}
+
+// expr formats the type expression x as it appears in the source, which,
+// unlike [types.ExprString], retains the tags of anonymous structs.
+func (g *methodGen) expr(x ast.Expr) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, g.fset, x); err != nil {
+		return types.ExprString(x)
+	}
+	return b.String()
+}
+
+// v returns a new variable name with the provided prefix.
+func (g *methodGen) v(prefix string) string {
+	g.nvar++
+	return fmt.Sprintf("%s%d", prefix, g.nvar)
+}
+
+func (g *methodGen) genGetters(name string) {
+	st, is := g.types[name].(*ast.StructType)
+	if !is {
+		return
+	}
+
+	taken := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		for _, n := range fieldNames(field) {
+			taken[n] = true
+		}
+	}
+	for _, field := range st.Fields.List {
+		for _, n := range fieldNames(field) {
+			getter := "Get" + n
+			if taken[getter] {
+				continue
+			}
+
+			// Pointers to scalars represent optional fields, and are dereferenced
+			if star, is := field.Type.(*ast.StarExpr); is && g.isShallow(star.X) {
+				if _, isStruct := g.resolve(star.X).(*ast.StructType); !isStruct {
+					g.p("// %s returns the value of %s, or its zero value if unset.", getter, n)
+					g.p("func (in *%s) %s() %s {", name, getter, g.expr(star.X))
+					g.p("if in == nil || in.%s == nil {", n)
+					g.p("var zero %s", g.expr(star.X))
+					g.p("return zero")
+					g.p("}")
+					g.p("return *in.%s", n)
+					g.p("}")
+					g.p("")
+					continue
+				}
+			}
+
+			g.p("// %s returns the value of %s, or its zero value if the receiver is nil.", getter, n)
+			g.p("func (in *%s) %s() %s {", name, getter, g.expr(field.Type))
+			g.p("if in == nil {")
+			g.p("var zero %s", g.expr(field.Type))
+			g.p("return zero")
+			g.p("}")
+			g.p("return in.%s", n)
+			g.p("}")
+			g.p("")
+		}
+	}
+}
+
+func (g *methodGen) genDeepCopyAny() {
+	g.p("// %s deep copies a value decoded from arbitrary JSON.", deepCopyAnyFunc)
+	g.p("func %s(v any) any {", deepCopyAnyFunc)
+	g.p("switch x := v.(type) {")
+	g.p("case map[string]any:")
+	g.p("out := make(map[string]any, len(x))")
+	g.p("for k, v := range x {")
+	g.p("out[k] = %s(v)", deepCopyAnyFunc)
+	g.p("}")
+	g.p("return out")
+	g.p("case []any:")
+	g.p("out := make([]any, len(x))")
+	g.p("for i, v := range x {")
+	g.p("out[i] = %s(v)", deepCopyAnyFunc)
+	g.p("}")
+	g.p("return out")
+	g.p("}")
+	g.p("return v")
+	g.p("}")
+}
+
+// fieldNames returns the names of a struct field, which for an embedded field
+// is the name of its type.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		name := strings.TrimPrefix(types.ExprString(field.Type), "*")
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		return []string{name}
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, n := range field.Names {
+		names = append(names, n.Name)
+	}
+	return names
+}
@@ -58,11 +58,30 @@ type TypeConfigOpenAPI struct {
 	// UseGoDeclInComments sets the name of the fields and structs at the beginning of each comment.
 	UseGoDeclInComments bool
 
+	// DeepCopy causes DeepCopyInto and DeepCopy methods to be generated for
+	// each generated type, in the style expected of Kubernetes API types by
+	// controller-runtime and similar libraries.
+	DeepCopy bool
+
+	// Getters causes a Get<Field> accessor method to be generated for each
+	// field of each generated struct type. Getters may be called on a nil
+	// receiver, and return the zero value of the field in that case. Optional
+	// scalar fields, represented as pointers, are dereferenced by their
+	// getters, returning the zero value if unset.
+	Getters bool
+
 	// Config is passed through to the Thema OpenAPI encoder, [openapi.GenerateSchema].
 	Config *openapi.Config
 }
 
 // GenerateTypesOpenAPI generates native Go code corresponding to the provided Schema.
+//
+// Generated identifiers may be customized with @go attributes on schema fields
+// and top-level definitions. @go(name=ID) replaces the name of the generated
+// struct field or type, and @go(omitempty) adds the omitempty option to a
+// field's json tag, e.g.:
+//
+//	id: string @go(name=ID,omitempty)
 func GenerateTypesOpenAPI(sch thema.Schema, cfg *TypeConfigOpenAPI) ([]byte, error) {
 	if cfg == nil {
 		cfg = new(TypeConfigOpenAPI)
@@ -73,7 +92,12 @@ func GenerateTypesOpenAPI(sch thema.Schema, cfg *TypeConfigOpenAPI) ([]byte, err
 		depointer = depointerizer(true)
 	}
 
-	applyFuncs := []dstutil.ApplyFunc{depointer, fixRawData(), fixUnderscoreInTypeName()}
+	attrs, err := goAttributes(sch, cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	applyFuncs := []dstutil.ApplyFunc{depointer, fixRawData(), fixUnderscoreInTypeName(), attrs}
 	if !cfg.UseGoDeclInComments {
 		applyFuncs = append(applyFuncs, fixTODOComments())
 	}
@@ -122,12 +146,16 @@ func GenerateTypesOpenAPI(sch thema.Schema, cfg *TypeConfigOpenAPI) ([]byte, err
 		return nil, fmt.Errorf("openapi generation failed: %w", err)
 	}
 
-	return PostprocessGoFile(GenGoFile{
+	b, err := PostprocessGoFile(GenGoFile{
 		Path:                    fmt.Sprintf("%s_type_gen.go", sch.Lineage().Name()),
 		Appliers:                applyFuncs,
 		In:                      []byte(gostr),
 		IgnoreDiscoveredImports: cfg.IgnoreDiscoveredImports,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return addMethods(b, cfg)
 }
 
 // Almost all of the below imports are eliminated by dst transformers and calls
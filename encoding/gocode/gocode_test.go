@@ -2,6 +2,12 @@ package gocode
 
 import (
 	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue/cuecontext"
@@ -102,3 +108,169 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateAttributes(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "attrs"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string @go(name=ID)
+		count: int @go(omitempty)
+		nested: url: string @go(name=URL)
+		items: [...#Item]
+		#Item: {
+			val?: string @go(name=Value)
+		} @go(name=Entry)
+	}
+}]`), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateTypesOpenAPI(lin.First(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type Entry struct {",
+		"Value *string `json:\"val,omitempty\"`",
+		"ID     string",
+		"Count  int     `json:\"count,omitempty\"`",
+		"URL string `json:\"url\"`",
+		"Items  []Entry",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, b)
+		}
+	}
+
+	lin, err = thema.BindLineage(rt.Context().CompileString(`name: "attrs"
+schemas: [{
+	version: [0, 0]
+	schema: id: string @go(name=id)
+}]`), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateTypesOpenAPI(lin.First(), nil); err == nil {
+		t.Error("expected error for unexported @go name")
+	}
+}
+
+func TestGenerateMethods(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "methods"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		count?: int
+		tags: [...string]
+		labels?: [string]: [...int]
+		nested: deep?: items: [...#Item]
+		byName: [string]: #Item
+		anything?: _
+		kind: #Kind
+		#Item: {
+			key: string
+			more?: [...{a: int}]
+		}
+		#Kind: "a" | "b"
+	}
+}]`), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateTypesOpenAPI(lin.First(), &TypeConfigOpenAPI{DeepCopy: true, Getters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "methods_type_gen.go", b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := cfg.Check("methods", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not compile: %s\n%s", err, b)
+	}
+
+	for _, want := range []string{
+		"func (in *Methods) DeepCopyInto(out *Methods) {",
+		"func (in *Item) DeepCopy() *Item {",
+		"func (in *Methods) GetCount() int {",
+		"func (in *Methods) GetByName() map[string]Item {",
+		"in.Nested.Deep).Items[",
+		"deepCopyJSONValue(",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, b)
+		}
+	}
+}
+
+func TestGenerateTranslators(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "translated"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [0, 1]
+	schema: {a: string, b?: string}
+}, {
+	version: [1, 0]
+	schema: {a: string, b: string}
+}]
+lenses: [{
+	to: [0, 1]
+	from: [1, 0]
+	input: _
+	result: {a: input.a, b: input.b}
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: {a: input.a, b: *input.b | ""}
+	lacunas: []
+}]`), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateTranslators(lin, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "translated_translate_gen.go", b, 0); err != nil {
+		t.Fatalf("generated code does not parse: %s\n%s", err, b)
+	}
+	for _, want := range []string{
+		"package translated",
+		"func TranslateV0_0ToV0_1(lin thema.Lineage, in TranslatedV0_0) (TranslatedV0_1, []thema.Lacuna, error) {",
+		"func TranslateV0_1ToV0_0(lin thema.Lineage, in TranslatedV0_1) (TranslatedV0_0, []thema.Lacuna, error) {",
+		"func TranslateV0_1ToV1_0(lin thema.Lineage, in TranslatedV0_1) (TranslatedV1_0, []thema.Lacuna, error) {",
+		"func TranslateV1_0ToV0_1(lin thema.Lineage, in TranslatedV1_0) (TranslatedV0_1, []thema.Lacuna, error) {",
+		"translateTranslated(lin, thema.SV(0, 1), thema.SV(1, 0), in, &out)",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, b)
+		}
+	}
+
+	b, err = GenerateTranslators(lin, &TranslatorConfig{
+		PackageName: "other",
+		TypeName: func(sch thema.Schema) string {
+			return fmt.Sprintf("Translated%d", sch.Version()[0])
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "func TranslateV0_1ToV1_0(lin thema.Lineage, in Translated0) (Translated1, []thema.Lacuna, error) {"; !strings.Contains(string(b), want) {
+		t.Errorf("generated code does not contain %q:\n%s", want, b)
+	}
+}
@@ -0,0 +1,129 @@
+package gocode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/dave/dst/dstutil"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/util"
+)
+
+// TranslatorConfig governs the behavior of [GenerateTranslators].
+type TranslatorConfig struct {
+	// PackageName determines the name of the generated Go package. If empty, the
+	// lowercase version of the Lineage.Name() is used.
+	PackageName string
+
+	// TypeName returns the name of the Go type corresponding to the provided
+	// schema, which must already exist in the target package - for example, as
+	// generated by [GenerateTypesOpenAPI] with an [openapi.Config.RootName].
+	//
+	// If nil, types are named after the title-cased lineage name and the
+	// schema's version, e.g. FooV0_1 for the 0.1 schema of the "foo" lineage.
+	TypeName func(sch thema.Schema) string
+
+	// ApplyFuncs is a slice of AST manipulation funcs that will be executed against
+	// the generated Go file prior to running it through goimports. For each slice
+	// element, [dstutil.Apply] is called with the element as the "pre" parameter.
+	ApplyFuncs []dstutil.ApplyFunc
+
+	// IgnoreDiscoveredImports causes the generator not to fail with an error in the
+	// event that goimports adds additional import statements. (The default behavior
+	// is to fail because adding imports entails a search, which can slow down
+	// codegen by multiple orders of magnitude. Succeeding silently but slowly is a bad
+	// default behavior when the fix is usually quite easy.)
+	IgnoreDiscoveredImports bool
+}
+
+// GenerateTranslators generates Go functions that translate between the Go
+// types corresponding to adjacent schemas in the provided lineage, in both
+// directions. For the "foo" lineage, translation from 0.0 to 1.0 is generated
+// as:
+//
+//	func TranslateV0_0ToV1_0(lin thema.Lineage, in FooV0_0) (FooV1_0, []thema.Lacuna, error)
+//
+// The generated functions wrap [thema.Instance.Translate], and so rely on the
+// lineage's lenses, but give migration code the compile-time safety of the
+// generated types. Translation between non-adjacent schemas may be achieved by
+// chaining the generated functions.
+func GenerateTranslators(lin thema.Lineage, cfg *TranslatorConfig) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(TranslatorConfig)
+	}
+
+	typeName := cfg.TypeName
+	if typeName == nil {
+		base := goTypeName(util.SanitizeLabelString(lin.Name()))
+		typeName = func(sch thema.Schema) string {
+			return fmt.Sprintf("%s%s", base, versionIdent(sch.Version()))
+		}
+	}
+
+	vars := translatorVars{
+		Name:        lin.Name(),
+		PackageName: cfg.PackageName,
+		HelperName:  "translate" + goTypeName(util.SanitizeLabelString(lin.Name())),
+	}
+	if vars.PackageName == "" {
+		vars.PackageName = strings.ToLower(lin.Name())
+	}
+
+	for sch := lin.First(); sch.Successor() != nil; sch = sch.Successor() {
+		next := sch.Successor()
+		vars.Translators = append(vars.Translators,
+			translatorFunc{
+				FuncName: fmt.Sprintf("Translate%sTo%s", versionIdent(sch.Version()), versionIdent(next.Version())),
+				From:     sch.Version(),
+				To:       next.Version(),
+				FromType: typeName(sch),
+				ToType:   typeName(next),
+			},
+			translatorFunc{
+				FuncName: fmt.Sprintf("Translate%sTo%s", versionIdent(next.Version()), versionIdent(sch.Version())),
+				From:     next.Version(),
+				To:       sch.Version(),
+				FromType: typeName(next),
+				ToType:   typeName(sch),
+			},
+		)
+	}
+
+	buf := new(bytes.Buffer)
+	err := tmpls.Lookup("translate.tmpl").Execute(buf, vars)
+	if err != nil {
+		return nil, fmt.Errorf("error executing translate template: %w", err)
+	}
+
+	return PostprocessGoFile(GenGoFile{
+		Path:                    fmt.Sprintf("%s_translate_gen.go", strings.ToLower(lin.Name())),
+		Appliers:                cfg.ApplyFuncs,
+		In:                      buf.Bytes(),
+		IgnoreDiscoveredImports: cfg.IgnoreDiscoveredImports,
+	})
+}
+
+// versionIdent returns a representation of v suitable for use in Go
+// identifiers, e.g. V1_2.
+func versionIdent(v thema.SyntacticVersion) string {
+	return fmt.Sprintf("V%d_%d", v[0], v[1])
+}
+
+type translatorVars struct {
+	// Name of the lineage
+	Name string
+	// name to be used for the generated package
+	PackageName string
+	// name of the generated unexported func that performs translation
+	HelperName string
+
+	Translators []translatorFunc
+}
+
+type translatorFunc struct {
+	FuncName         string
+	From, To         thema.SyntacticVersion
+	FromType, ToType string
+}
@@ -0,0 +1,649 @@
+// Package java generates Java classes from Thema schemas.
+package java
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+	"github.com/grafana/thema/internal/typegen"
+	"github.com/grafana/thema/internal/util"
+)
+
+// Config controls Java class generation from a Thema schema.
+type Config struct {
+	// PackageName determines the name of the generated Java package. If empty,
+	// the lowercase version of the Lineage.Name() is used.
+	PackageName string
+
+	// ClassName specifies the name to use for the class representing the root
+	// of the schema. If empty, this defaults to [ClassName].
+	ClassName string
+
+	// NullabilityPackage is the package providing the @Nullable and @NotNull
+	// annotations with which fields are annotated. If empty, this defaults to
+	// "org.jetbrains.annotations", which Kotlin recognizes.
+	NullabilityPackage string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated classes, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []cue.Path
+}
+
+// ClassName returns the default name of the root class generated for schemas
+// in the provided lineage: the title-cased lineage name. As it is public, the
+// root class must be placed in a file of the same name, e.g. Dashboard.java.
+func ClassName(lin thema.Lineage) string {
+	return typeIdent(util.SanitizeLabelString(lin.Name()))
+}
+
+// GenerateClasses generates a Java source file, annotated for serialization
+// with Jackson, corresponding to the provided Schema.
+//
+// The file contains a class for the schema root. Each of the schema's
+// top-level definitions and nested structs is generated as a static class
+// nested within it, and each disjunction of strings as an enum. Every class
+// has a no-argument constructor that applies the schema's defaults, getters
+// and setters for each field, and a builder.
+//
+// Getters of required fields are annotated @NotNull, and those of optional or
+// nullable fields @Nullable, such that Kotlin code sees types of matching
+// nullability. Null values are omitted when serializing.
+func GenerateClasses(sch thema.Schema, cfg *Config) ([]byte, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.PackageName == "" {
+		c.PackageName = strings.ToLower(util.SanitizeLabelString(sch.Lineage().Name()))
+	}
+	if c.ClassName == "" {
+		c.ClassName = ClassName(sch.Lineage())
+	}
+	if c.NullabilityPackage == "" {
+		c.NullabilityPackage = "org.jetbrains.annotations"
+	}
+
+	g := &javaGen{
+		defs:    make(map[string]string),
+		enums:   make(map[string]map[string]string),
+		taken:   make(typegen.Names),
+		imports: make(map[string]bool),
+		exclude: c.Exclude,
+	}
+	root := typeIdent(c.ClassName)
+	g.taken[root] = true
+	g.taken["Builder"] = true
+
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	g.schdef = schdef
+	type def struct {
+		sel, name string
+		v         cue.Value
+	}
+	var defs []def
+	iter, err := schdef.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		if iter.Selector().IsDefinition() {
+			name := g.taken.Reserve(typeIdent(strings.TrimPrefix(iter.Selector().String(), "#")))
+			g.defs[iter.Selector().String()] = name
+			defs = append(defs, def{sel: iter.Selector().String(), name: name, v: iter.Value()})
+		}
+	}
+	for _, d := range defs {
+		if err := g.genNamed(d.sel, d.name, d.v); err != nil {
+			return nil, fmt.Errorf("generating class for #%s failed: %w", d.name, err)
+		}
+	}
+
+	doc := fmt.Sprintf("Version %s of the %s lineage.", sch.Version(), sch.Lineage().Name())
+	for _, cg := range schdef.Doc() {
+		doc += "\n\n" + strings.TrimSpace(cg.Text())
+	}
+	body, err := g.class(root, schdef, doc, false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s;\n\n", c.PackageName)
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	imports = append(imports, c.NullabilityPackage+".NotNull", c.NullabilityPackage+".Nullable")
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&b, "import %s;\n", imp)
+	}
+	b.WriteString("\n")
+
+	// Insert the nested types before the closing brace of the root class
+	b.WriteString(strings.TrimSuffix(body, "}\n"))
+	for _, n := range g.nested {
+		b.WriteString("\n")
+		b.WriteString(indent(n, "    "))
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// javaGen accumulates the Java declarations generated for a schema.
+type javaGen struct {
+	exclude []cue.Path
+	// schdef is the schema, relative to which excluded paths are resolved.
+	schdef cue.Value
+
+	// defs maps the selector of each top-level definition in the schema to the
+	// name of its generated class.
+	defs map[string]string
+	// enums maps the name of each generated enum to the names of its
+	// constants, keyed by value.
+	enums map[string]map[string]string
+	// taken records the class names already in use.
+	taken typegen.Names
+	// imports records the imported classes.
+	imports map[string]bool
+
+	// nested holds the declarations of the classes nested in the root class.
+	nested []string
+}
+
+func (g *javaGen) use(imports ...string) {
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+// genNamed generates a nested class named name for the top-level definition
+// v, selected by sel. Definitions that are neither structs nor disjunctions of strings are
+// not represented by a class, and references to them use the corresponding
+// Java type directly.
+func (g *javaGen) genNamed(sel, name string, v cue.Value) error {
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		if strs, nullable := typegen.StringEnum(args); strs != nil && !nullable {
+			g.genEnum(name, v, strs)
+			return nil
+		}
+	} else if v.IncompleteKind() == cue.StructKind && !typegen.IsMap(v) {
+		body, err := g.class(name, v, docText(v), true, 0)
+		if err != nil {
+			return err
+		}
+		g.nested = append(g.nested, body)
+		return nil
+	}
+
+	typ, _, err := g.typeFor(v, name+"Value", 0)
+	if err != nil {
+		return err
+	}
+	g.defs[sel] = typ
+	return nil
+}
+
+// field describes a field of a generated class.
+type field struct {
+	label, ident, typ string
+	nullable          bool
+	def, doc          string
+}
+
+// class returns the declaration of a class named name with the fields of v.
+func (g *javaGen) class(name string, v cue.Value, doc string, static bool, depth int) (string, error) {
+	var fields []field
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return "", err
+	}
+	for iter.Next() {
+		label := iter.Selector().Unquoted()
+		fv := iter.Value()
+		if cuetil.ContainsPath(g.exclude, cuetil.RelPath(g.schdef, fv)) {
+			continue
+		}
+		typ, nullable, err := g.typeFor(fv, name+typeIdent(label), depth+1)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", label, err)
+		}
+		f := field{
+			label:    label,
+			ident:    fieldIdent(label),
+			typ:      typ,
+			nullable: nullable || iter.IsOptional(),
+			doc:      docText(fv),
+		}
+		if d, has := fv.Default(); has && !iter.IsOptional() {
+			f.def = g.literal(d, typ)
+		}
+		fields = append(fields, f)
+	}
+
+	g.use("com.fasterxml.jackson.annotation.JsonInclude", "com.fasterxml.jackson.annotation.JsonProperty", "java.util.Objects")
+	var b strings.Builder
+	b.WriteString(javadoc(doc, ""))
+	b.WriteString("@JsonInclude(JsonInclude.Include.NON_NULL)\n")
+	if v.Allows(cue.Str("_thema_undeclared_field")) {
+		g.use("com.fasterxml.jackson.annotation.JsonIgnoreProperties")
+		b.WriteString("@JsonIgnoreProperties(ignoreUnknown = true)\n")
+	}
+	mod := "public"
+	if static {
+		mod = "public static"
+	}
+	fmt.Fprintf(&b, "%s class %s {\n", mod, name)
+
+	for _, f := range fields {
+		b.WriteString(javadoc(f.doc, "    "))
+		fmt.Fprintf(&b, "    @JsonProperty(%s)\n", javaString(f.label))
+		if f.def != "" {
+			fmt.Fprintf(&b, "    private %s %s = %s;\n", f.typ, f.ident, f.def)
+		} else {
+			fmt.Fprintf(&b, "    private %s %s;\n", f.typ, f.ident)
+		}
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "    public %s() {\n    }\n", name)
+	for _, f := range fields {
+		ann := "@NotNull"
+		if f.nullable {
+			ann = "@Nullable"
+		}
+		acc := accessorName(f.ident)
+		fmt.Fprintf(&b, "\n    %s\n    public %s get%s() {\n        return %s;\n    }\n", ann, f.typ, acc, f.ident)
+		fmt.Fprintf(&b, "\n    public void set%s(%s %s %s) {\n        this.%s = %s;\n    }\n", acc, ann, f.typ, f.ident, f.ident, f.ident)
+	}
+
+	// equals and hashCode
+	fmt.Fprintf(&b, "\n    @Override\n    public boolean equals(Object o) {\n")
+	fmt.Fprintf(&b, "        if (this == o) {\n            return true;\n        }\n")
+	fmt.Fprintf(&b, "        if (o == null || getClass() != o.getClass()) {\n            return false;\n        }\n")
+	if len(fields) == 0 {
+		b.WriteString("        return true;\n    }\n")
+	} else {
+		fmt.Fprintf(&b, "        %s that = (%s) o;\n", name, name)
+		var eqs, ids []string
+		for _, f := range fields {
+			eqs = append(eqs, fmt.Sprintf("Objects.equals(%s, that.%s)", f.ident, f.ident))
+			ids = append(ids, f.ident)
+		}
+		fmt.Fprintf(&b, "        return %s;\n    }\n", strings.Join(eqs, "\n            && "))
+		fmt.Fprintf(&b, "\n    @Override\n    public int hashCode() {\n        return Objects.hash(%s);\n    }\n", strings.Join(ids, ", "))
+	}
+	if len(fields) == 0 {
+		fmt.Fprintf(&b, "\n    @Override\n    public int hashCode() {\n        return 0;\n    }\n")
+	}
+
+	// Builder
+	fmt.Fprintf(&b, "\n    public static Builder builder() {\n        return new Builder();\n    }\n")
+	fmt.Fprintf(&b, "\n    /**\n     * Builds instances of {@link %s}, starting from its defaults.\n     */\n", name)
+	fmt.Fprintf(&b, "    public static final class Builder {\n        private final %s instance = new %s();\n\n        private Builder() {\n        }\n", name, name)
+	for _, f := range fields {
+		ann := "@NotNull"
+		if f.nullable {
+			ann = "@Nullable"
+		}
+		fmt.Fprintf(&b, "\n        public Builder %s(%s %s %s) {\n            instance.%s = %s;\n            return this;\n        }\n", f.ident, ann, f.typ, f.ident, f.ident, f.ident)
+	}
+	fmt.Fprintf(&b, "\n        public %s build() {\n            return instance;\n        }\n    }\n", name)
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// literal returns the default value d of a field of the provided type as a
+// Java expression, or the empty string if it cannot be represented.
+func (g *javaGen) literal(d cue.Value, typ string) string {
+	if d.Validate(cue.Concrete(true)) != nil {
+		return ""
+	}
+	switch d.Kind() {
+	case cue.StringKind:
+		s, _ := d.String() // nolint: errcheck
+		if members, is := g.enums[typ]; is {
+			return typ + "." + members[s]
+		}
+		if typ == "String" {
+			return javaString(s)
+		}
+	case cue.BoolKind:
+		if typ == "Boolean" {
+			bv, _ := d.Bool() // nolint: errcheck
+			return fmt.Sprint(bv)
+		}
+	case cue.IntKind, cue.FloatKind:
+		b, err := d.MarshalJSON()
+		if err != nil {
+			return ""
+		}
+		switch typ {
+		case "Integer":
+			return string(b)
+		case "Long":
+			return string(b) + "L"
+		case "Double":
+			if !strings.ContainsAny(string(b), ".eE") {
+				return string(b) + ".0"
+			}
+			return string(b)
+		}
+	case cue.ListKind:
+		if n, err := d.Len().Int64(); err == nil && n == 0 && strings.HasPrefix(typ, "List<") {
+			g.use("java.util.ArrayList")
+			return "new ArrayList<>()"
+		}
+	}
+	return ""
+}
+
+// genEnum generates an enum named name, with a constant for each of the
+// provided strings.
+func (g *javaGen) genEnum(name string, v cue.Value, strs []string) {
+	g.use("com.fasterxml.jackson.annotation.JsonProperty")
+	members := make(map[string]string)
+	g.enums[name] = members
+
+	var b strings.Builder
+	b.WriteString(javadoc(docText(v), ""))
+	fmt.Fprintf(&b, "public enum %s {\n", name)
+	used := make(map[string]bool)
+	for i, s := range strs {
+		constant := constantIdent(s)
+		for j := 2; used[constant]; j++ {
+			constant = fmt.Sprintf("%s_%d", constantIdent(s), j)
+		}
+		used[constant] = true
+		members[s] = constant
+
+		sep := ","
+		if i == len(strs)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&b, "    @JsonProperty(%s)\n    %s%s\n", javaString(s), constant, sep)
+	}
+	b.WriteString("}\n")
+	g.nested = append(g.nested, b.String())
+}
+
+// typeFor returns the Java type of values of v, and whether v may be null.
+// Where a class must be generated for v, it is given the provided name.
+func (g *javaGen) typeFor(v cue.Value, name string, depth int) (string, bool, error) {
+	if depth > typegen.MaxDepth {
+		return "Object", false, nil
+	}
+
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		if typ, has := g.defs[sels[len(sels)-1].String()]; has {
+			return typ, false, nil
+		}
+	}
+
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		return g.union(v, args, name, depth)
+	}
+
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		if typegen.IsMap(v) {
+			typ, _, err := g.typeFor(v.LookupPath(cue.MakePath(cue.AnyString)), name+"Value", depth+1)
+			if err != nil {
+				return "", false, err
+			}
+			g.use("java.util.Map")
+			return "Map<String, " + typ + ">", false, nil
+		}
+		name = g.taken.Reserve(name)
+		body, err := g.class(name, v, docText(v), true, depth)
+		if err != nil {
+			return "", false, err
+		}
+		g.nested = append(g.nested, body)
+		return name, false, nil
+	case cue.ListKind:
+		g.use("java.util.List")
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() && op == cue.NoOp && len(args) == 1 {
+			// Lists with a default, e.g. [...string] | *[], wrap the list type
+			elem = args[0].LookupPath(cue.MakePath(cue.AnyIndex))
+		}
+		if elem.Exists() {
+			typ, _, err := g.typeFor(elem, name+"Item", depth+1)
+			if err != nil {
+				return "", false, err
+			}
+			return "List<" + typ + ">", false, nil
+		}
+		// Java has no tuple types
+		return "List<Object>", false, nil
+	case cue.StringKind, cue.BytesKind:
+		return "String", false, nil
+	case cue.BoolKind:
+		return "Boolean", false, nil
+	case cue.IntKind:
+		return intType(v), false, nil
+	case cue.FloatKind, cue.NumberKind:
+		return "Double", false, nil
+	case cue.NullKind:
+		return "Object", true, nil
+	}
+	return "Object", false, nil
+}
+
+// union returns the Java type of values of v, a disjunction of the provided
+// disjuncts. Disjunctions of strings are represented as enums, and
+// disjunctions with null as nullable. Other disjunctions have no Java
+// equivalent, and are represented as Object.
+func (g *javaGen) union(v cue.Value, disjuncts []cue.Value, name string, depth int) (string, bool, error) {
+	strs, nullable := typegen.StringEnum(disjuncts)
+	if strs != nil {
+		name = g.taken.Reserve(name)
+		g.genEnum(name, v, strs)
+		return name, nullable, nil
+	}
+
+	var rest []cue.Value
+	for i, d := range disjuncts {
+		if d.IncompleteKind() == cue.NullKind {
+			nullable = true
+			continue
+		}
+		if !typegen.Subsumed(disjuncts, i) {
+			rest = append(rest, d)
+		}
+	}
+	if len(rest) == 1 {
+		typ, _, err := g.typeFor(rest[0], name, depth+1)
+		return typ, nullable, err
+	}
+	return "Object", nullable, nil
+}
+
+// intType returns Integer if the bounds on the integer v fit in 32 bits, and
+// Long otherwise.
+func intType(v cue.Value) string {
+	var lo, hi bool
+	typegen.Constraints(v, func(op cue.Op, arg cue.Value) {
+		n, err := arg.Int64()
+		if err != nil {
+			return
+		}
+		switch op {
+		case cue.GreaterThanEqualOp, cue.GreaterThanOp:
+			lo = lo || n >= -1<<31
+		case cue.LessThanEqualOp, cue.LessThanOp:
+			hi = hi || n <= 1<<31-1
+		}
+	})
+	if lo && hi {
+		return "Integer"
+	}
+	return "Long"
+}
+
+// docText returns the text of the doc comments of v.
+func docText(v cue.Value) string {
+	var docs []string
+	for _, cg := range v.Doc() {
+		docs = append(docs, strings.TrimSpace(cg.Text()))
+	}
+	return strings.Join(docs, "\n\n")
+}
+
+// javadoc returns text as a Javadoc comment, with each line prefixed by
+// indent.
+func javadoc(text, indent string) string {
+	if text == "" {
+		return ""
+	}
+	text = strings.ReplaceAll(text, "*/", "*&#47;")
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/**\n", indent)
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(&b, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+	fmt.Fprintf(&b, "%s */\n", indent)
+	return b.String()
+}
+
+// indent prefixes each non-empty line of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// javaString returns s as a Java string literal.
+func javaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// typeIdent converts s to an UpperCamelCase Java class name.
+func typeIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+		case upper:
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				b.WriteByte('V')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// javaKeywords lists the reserved words of Java, which cannot be used as
+// identifiers.
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true,
+	"case": true, "catch": true, "char": true, "class": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extends": true, "final": true, "finally": true, "float": true,
+	"for": true, "goto": true, "if": true, "implements": true, "import": true,
+	"instanceof": true, "int": true, "interface": true, "long": true, "native": true,
+	"new": true, "package": true, "private": true, "protected": true, "public": true,
+	"return": true, "short": true, "static": true, "strictfp": true, "super": true,
+	"switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "try": true, "void": true, "volatile": true, "while": true,
+	"true": true, "false": true, "null": true, "var": true, "record": true,
+	"yield": true, "instance": true,
+}
+
+// fieldIdent converts s to a lowerCamelCase Java field name.
+func fieldIdent(s string) string {
+	id := typeIdent(s)
+	if id == "" {
+		return "field"
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }); strings.IndexFunc(s[i:], unicode.IsDigit) == 0 {
+		// typeIdent prefixes leading digits with V
+		return "f" + id[1:]
+	}
+	rs := []rune(id)
+	rs[0] = unicode.ToLower(rs[0])
+	id = string(rs)
+	if javaKeywords[id] {
+		return id + "_"
+	}
+	return id
+}
+
+// accessorName returns the suffix of the getter and setter of the field
+// with the provided identifier.
+func accessorName(ident string) string {
+	rs := []rune(strings.TrimSuffix(ident, "_"))
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+// constantIdent converts s to an UPPER_SNAKE_CASE Java enum constant.
+func constantIdent(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") && !unicode.IsUpper(rs[i-1]) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	id := strings.TrimSuffix(b.String(), "_")
+	switch {
+	case id == "":
+		return "EMPTY"
+	case unicode.IsDigit([]rune(id)[0]):
+		return "V" + id
+	}
+	return id
+}
@@ -0,0 +1,147 @@
+package java
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateClasses(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The dashboard's unique identifier.
+		id: string
+		count: uint8 | *1
+		ratio?: float
+		big: int
+		tags: [...string] | *[]
+		labels?: [string]: int
+		panels: [...#Panel]
+		kind: #Kind
+		n: null | string
+		public: bool | *true
+		mixed: string | int
+		#Panel: {
+			id: int
+			gridPos?: {x: int, y: int}
+			...
+		}
+		#Kind: "a" | *"b-c"
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateClasses(lin.First(), nil)
+	require.NoError(t, err)
+	out := string(b)
+
+	assert.Contains(t, out, "package dashboard;\n\n")
+	assert.Contains(t, out, "import com.fasterxml.jackson.annotation.JsonIgnoreProperties;\n")
+	assert.Contains(t, out, "import org.jetbrains.annotations.Nullable;\n")
+	assert.Contains(t, out, `/**
+ * Version 0.0 of the dashboard lineage.
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+public class Dashboard {
+    /**
+     * The dashboard's unique identifier.
+     */
+    @JsonProperty("id")
+    private String id;
+    @JsonProperty("count")
+    private Integer count = 1;
+    @JsonProperty("ratio")
+    private Double ratio;
+    @JsonProperty("big")
+    private Long big;
+    @JsonProperty("tags")
+    private List<String> tags = new ArrayList<>();
+    @JsonProperty("labels")
+    private Map<String, Long> labels;
+    @JsonProperty("panels")
+    private List<Panel> panels = new ArrayList<>();
+    @JsonProperty("kind")
+    private Kind kind = Kind.B_C;
+    @JsonProperty("n")
+    private String n;
+    @JsonProperty("public")
+    private Boolean public_ = true;
+    @JsonProperty("mixed")
+    private Object mixed;
+`)
+
+	// Nullability follows optionality
+	assert.Contains(t, out, "    @NotNull\n    public String getId() {\n")
+	assert.Contains(t, out, "    @Nullable\n    public Double getRatio() {\n")
+	assert.Contains(t, out, "    @Nullable\n    public String getN() {\n")
+	assert.Contains(t, out, "    public void setPublic(@NotNull Boolean public_) {\n")
+
+	assert.Contains(t, out, `            public Builder gridPos(@Nullable PanelGridPos gridPos) {
+                instance.gridPos = gridPos;
+                return this;
+            }
+`)
+	assert.Contains(t, out, `    @JsonInclude(JsonInclude.Include.NON_NULL)
+    @JsonIgnoreProperties(ignoreUnknown = true)
+    public static class Panel {
+`)
+	assert.Contains(t, out, `    public enum Kind {
+        @JsonProperty("a")
+        A,
+        @JsonProperty("b-c")
+        B_C
+    }
+}
+`)
+}
+
+func TestIdents(t *testing.T) {
+	assert.Equal(t, "gridPos", fieldIdent("gridPos"))
+	assert.Equal(t, "class_", fieldIdent("class"))
+	assert.Equal(t, "f1x", fieldIdent("1x"))
+	assert.Equal(t, "v1", fieldIdent("v1"))
+	assert.Equal(t, "GridPos", typeIdent("grid_pos"))
+	assert.Equal(t, "SOME_VALUE", constantIdent("someValue"))
+	assert.Equal(t, "V1_2", constantIdent("1.2"))
+	assert.Equal(t, `"a\"b\n"`, javaString("a\"b\n"))
+}
+
+func TestExclude(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dash"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		meta: {
+			internal: string
+			owner?: string
+		}
+		panels: [...#Panel]
+		#Panel: {
+			x: int
+			legacy?: bool
+		}
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateClasses(lin.First(), &Config{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "internal")
+	assert.NotContains(t, string(b), "legacy")
+	assert.Contains(t, string(b), "owner")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/ast/astutil"
 	"cuelang.org/go/cue/token"
@@ -12,10 +13,30 @@ import (
 	"github.com/grafana/thema/encoding/openapi"
 )
 
+// Config controls JSON Schema derivation from a Thema schema.
+type Config struct {
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated schema, e.g. "meta.internal" or "#Panel.legacy". See
+	// [openapi.Config.Exclude].
+	Exclude []cue.Path
+}
+
 // GenerateSchema generates a JSON Schema (Draft 4) schema representation of the
 // provided Thema schema.
 func GenerateSchema(sch thema.Schema) (*ast.File, error) {
-	f, err := openapi.GenerateSchema(sch, nil)
+	return GenerateSchemaWithConfig(sch, nil)
+}
+
+// GenerateSchemaWithConfig is the same as [GenerateSchema], but controls the
+// generated schema with the provided Config. A nil Config is equivalent to
+// [GenerateSchema].
+func GenerateSchemaWithConfig(sch thema.Schema, cfg *Config) (*ast.File, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	f, err := openapi.GenerateSchema(sch, &openapi.Config{
+		Exclude: cfg.Exclude,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,159 @@
+// Package msgpack provides a MessagePack codec for Thema instances.
+//
+// Only the subset of MessagePack that corresponds to the CUE data model is
+// supported: integers, floats, strings, binary, arrays, maps keyed by strings,
+// booleans and nil. Extension types are rejected.
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// maxDepth is the maximum nesting depth of arrays and maps that will be
+// decoded.
+const maxDepth = 512
+
+// Marshal encodes the data in the provided instance as MessagePack, using the
+// smallest representation of each integer. Map keys are sorted, so the output
+// is deterministic.
+func Marshal(inst *thema.Instance) ([]byte, error) {
+	var x any
+	if err := inst.Underlying().Decode(&x); err != nil {
+		return nil, err
+	}
+	x, err := unbig(x)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(x); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unbig converts all *big.Int values in x, which MessagePack cannot
+// represent, to int64 or uint64.
+func unbig(x any) (any, error) {
+	switch v := x.(type) {
+	case *big.Int:
+		switch {
+		case v.IsInt64():
+			return v.Int64(), nil
+		case v.IsUint64():
+			return v.Uint64(), nil
+		}
+		return nil, fmt.Errorf("msgpack: integer %s out of range", v)
+	case map[string]any:
+		for k, e := range v {
+			ex, err := unbig(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = ex
+		}
+	case []any:
+		for i, e := range v {
+			ex, err := unbig(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ex
+		}
+	}
+	return x, nil
+}
+
+// Unmarshal decodes MessagePack-encoded data and validates it against the
+// provided schema, exactly as if the equivalent JSON had been passed to
+// [thema.Schema.Validate].
+func Unmarshal(b []byte, sch thema.Schema) (*thema.Instance, error) {
+	v, err := Decode(sch.Underlying().Context(), b)
+	if err != nil {
+		return nil, err
+	}
+	return sch.Validate(v)
+}
+
+// Decode decodes MessagePack-encoded data into a [cue.Value] built by the provided
+// context, readying it for a call to [thema.Schema.Validate].
+func Decode(ctx *cue.Context, b []byte) (cue.Value, error) {
+	r := bytes.NewReader(b)
+	x, err := decode(msgpack.NewDecoder(r), 0)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	if r.Len() != 0 {
+		return cue.Value{}, fmt.Errorf("msgpack: %d trailing bytes after value", r.Len())
+	}
+	return ctx.Encode(x), nil
+}
+
+// decode decodes the next value from d. Arrays and maps are walked here,
+// rather than by d, to bound their nesting depth and to require string keys.
+func decode(d *msgpack.Decoder, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("msgpack: maximum nesting depth exceeded")
+	}
+	c, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		n, err := d.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		a := []any{}
+		for i := 0; i < n; i++ {
+			e, err := decode(d, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, e)
+		}
+		return a, nil
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		n, err := d.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any)
+		for i := 0; i < n; i++ {
+			kc, err := d.PeekCode()
+			if err != nil {
+				return nil, err
+			}
+			if !msgpcode.IsString(kc) {
+				return nil, fmt.Errorf("msgpack: map key must be a string, got code %#x", kc)
+			}
+			k, err := d.DecodeString()
+			if err != nil {
+				return nil, err
+			}
+			if m[k], err = decode(d, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case msgpcode.IsBin(c):
+		// Decoded as strings by DecodeInterfaceLoose
+		return d.DecodeBytes()
+	case msgpcode.IsExt(c):
+		return nil, fmt.Errorf("msgpack: unsupported extension type")
+	}
+	return d.DecodeInterfaceLoose()
+}
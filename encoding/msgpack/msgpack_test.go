@@ -0,0 +1,154 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rt = thema.NewRuntime(cuecontext.New())
+
+const roundTripLineage = `
+name: "roundtrip"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		small:  int
+		neg:    int
+		big:    uint64
+		min:    int64
+		f:      float
+		s:      string
+		long:   string
+		by:     bytes
+		n:      null
+		t:      bool
+		l: [...{k: string, v?: [...int]}]
+		m: [string]: number
+	}
+}]
+`
+
+var roundTripData = `{
+	small: 7
+	neg:   -100000
+	big:   18446744073709551615
+	min:   -9223372036854775808
+	f:     -2.5e-3
+	s:     "héllo"
+	long:  "` + strings.Repeat("x", 70000) + `"
+	by:    'bytes'
+	n:     null
+	t:     true
+	l: [{k: "a", v: [1, 2, 3]}, {k: "b"}]
+	m: {one: 1, two: 2.5}
+}`
+
+func TestRoundTrip(t *testing.T) {
+	lin, err := thema.BindLineage(rt.Context().CompileString(roundTripLineage), rt)
+	require.NoError(t, err)
+	inst, err := lin.First().Validate(rt.Context().CompileString(roundTripData))
+	require.NoError(t, err)
+
+	b, err := Marshal(inst)
+	require.NoError(t, err)
+	rinst, err := Unmarshal(b, lin.First())
+	require.NoError(t, err)
+
+	want, err := inst.Underlying().MarshalJSON()
+	require.NoError(t, err)
+	got, err := rinst.Underlying().MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+func TestUnmarshal(t *testing.T) {
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "msgpacktest"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		a: int
+		b: [...number]
+		c?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	sch := lin.First()
+
+	table := map[string]struct {
+		hex  string
+		json string
+		err  bool
+	}{
+		"fixed": {
+			// {"a": 1, "b": [2, 3.5]}
+			hex:  "82 a161 01 a162 92 02 cb400c000000000000",
+			json: `{"a": 1, "b": [2, 3.5]}`,
+		},
+		"sized": {
+			// map16 {str8 "a": int16 -500, "b": array16 [float32 1.5], "c": str16 "xy"}
+			hex:  "de0003 d901 61 d1fe0c a162 dc0001 ca3fc00000 a163 da0002 7879",
+			json: `{"a": -500, "b": [1.5], "c": "xy"}`,
+		},
+		"unsigned": {
+			// {"a": uint32 1000, "b": []}
+			hex:  "82 a161 ce000003e8 a162 90",
+			json: `{"a": 1000, "b": []}`,
+		},
+		"bin for string": {
+			// {"a": 1, "b": [], "c": bin8 "x"}
+			hex: "83 a161 01 a162 90 a163 c40178",
+			err: true,
+		},
+		"invalid for schema": {
+			// {"a": "1", "b": []}
+			hex: "82 a161 a131 a162 90",
+			err: true,
+		},
+		"extension": {
+			// {"a": fixext1, "b": []}
+			hex: "82 a161 d40100 a162 90",
+			err: true,
+		},
+		"truncated": {
+			hex: "82 a161 01 a162 92 02",
+			err: true,
+		},
+		"trailing": {
+			hex: "82 a161 01 a162 90 00",
+			err: true,
+		},
+		"non-string key": {
+			hex: "81 01 01",
+			err: true,
+		},
+		"too deep": {
+			hex: strings.Repeat("91", 1000) + "01",
+			err: true,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(strings.ReplaceAll(tt.hex, " ", ""))
+			require.NoError(t, err)
+			inst, err := Unmarshal(b, sch)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got, err := inst.Underlying().MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.json, string(got))
+		})
+	}
+}
@@ -44,6 +44,14 @@ type Config struct {
 	// to generate the file. It's useful when their merged _#schema version doesn't show
 	// the desired results.
 	SplitSchema bool
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated schemas, e.g. "meta.internal". Fields of top-level
+	// definitions are addressed through the definition, e.g. "#Panel.legacy",
+	// and are omitted wherever the definition is referenced. As such paths
+	// address the schema component generated for the definition, they have no
+	// effect if references are expanded.
+	Exclude []cue.Path
 }
 
 // GenerateSchema creates an OpenAPI document that represents the provided Thema
@@ -83,6 +91,9 @@ func GenerateSchema(sch thema.Schema, cfg *Config) (*ast.File, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, p := range cfg.Exclude {
+		excludeField(decls, gen.name, cfg.Group, p)
+	}
 
 	// TODO recursively sort output to improve stability of output
 	return &ast.File{
@@ -239,6 +250,73 @@ func (gen *oapiGen) nfSingle(val cue.Value, path, defpath cue.Path, name string)
 	return strings.Trim(tpath.String(), "?#")
 }
 
+// excludeField removes the field at path p from the generated schema
+// components. The path is resolved from the component for the schema root,
+// named root, unless its first selector names a definition or, for grouped
+// lineages, a top-level field, in which case it is resolved from the component
+// generated for that.
+func excludeField(decls []ast.Decl, root string, group bool, p cue.Path) {
+	sels := p.Selectors()
+	if len(sels) > 0 && (group || sels[0].IsDefinition()) {
+		root = strings.Trim(sels[0].String(), "?#")
+		sels = sels[1:]
+	}
+	if len(sels) == 0 {
+		return
+	}
+
+	f, err := astutil.GetFieldByLabel(&ast.StructLit{Elts: decls}, root)
+	if err != nil {
+		return
+	}
+	for i, sel := range sels {
+		label := strings.TrimSuffix(sel.String(), "?")
+		props, err := astutil.GetFieldByLabel(f.Value, "properties")
+		if err != nil {
+			return
+		}
+		if i < len(sels)-1 {
+			if f, err = astutil.GetFieldByLabel(props.Value, label); err != nil {
+				return
+			}
+			continue
+		}
+
+		pl := props.Value.(*ast.StructLit)
+		for j, elt := range pl.Elts {
+			if pf, is := elt.(*ast.Field); is && labelIs(pf, label) {
+				pl.Elts = append(pl.Elts[:j], pl.Elts[j+1:]...)
+				break
+			}
+		}
+		if req, err := astutil.GetFieldByLabel(f.Value, "required"); err == nil {
+			if rl, is := req.Value.(*ast.ListLit); is {
+				for j, elt := range rl.Elts {
+					if lit, is := elt.(*ast.BasicLit); is && strings.Trim(lit.Value, `"`) == label {
+						rl.Elts = append(rl.Elts[:j], rl.Elts[j+1:]...)
+						break
+					}
+				}
+				// OpenAPI does not permit an empty list of required fields
+				if len(rl.Elts) == 0 {
+					sl := f.Value.(*ast.StructLit)
+					for j, elt := range sl.Elts {
+						if elt == req {
+							sl.Elts = append(sl.Elts[:j], sl.Elts[j+1:]...)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func labelIs(f *ast.Field, label string) bool {
+	name, _, err := ast.LabelName(f.Label)
+	return err == nil && name == label
+}
+
 func getSchemas(f *ast.File) []ast.Decl {
 	compos := orp(astutil.GetFieldByLabel(f, "components"))
 	schemas := orp(astutil.GetFieldByLabel(compos.Value, "schemas"))
@@ -107,3 +107,43 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestExclude(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dash"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		meta: {
+			internal: string
+			owner?: string
+		}
+		panels: [...#Panel]
+		#Panel: {
+			legacy?: bool
+		}
+	}
+}]
+`), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := GenerateSchema(lin.First(), &Config{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy"), cue.ParsePath("id")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := rt.Context().BuildFile(f).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"Panel":{"type":"object","properties":{}},"dash":{"type":"object","required":["meta","panels"],"properties":{"meta":{"type":"object","properties":{"owner":{"type":"string"}}},"panels":{"type":"array","items":{"$ref":"#/components/schemas/Panel"}}}}}`
+	if got := string(b); !strings.Contains(got, want) {
+		t.Errorf("expected excluded fields to be omitted, got:\n%s", got)
+	}
+}
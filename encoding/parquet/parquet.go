@@ -0,0 +1,177 @@
+// Package parquet generates Parquet and Arrow schemas from Thema schemas.
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/columnar"
+)
+
+// Config controls Parquet and Arrow schema generation from a Thema schema.
+type Config struct {
+	// MessageName is the name of the Parquet message. If empty, this defaults
+	// to the lineage name.
+	MessageName string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated schema, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []cue.Path
+}
+
+// GenerateMessage generates a Parquet message type, in the text format
+// accepted by parquet-mr's MessageTypeParser and printed by parquet-tools, in
+// which instances of the provided schema may be stored.
+//
+// Fields are required unless they are optional or may be null. Structs are
+// represented as groups, lists and maps with the LIST and MAP logical types,
+// and strings with the STRING logical type. Values that cannot be represented
+// by Parquet's types - disjunctions of several kinds, tuples and unconstrained
+// values - are represented as binary with the JSON logical type. Field names
+// are sanitized to contain only letters, digits and underscores.
+func GenerateMessage(sch thema.Schema, cfg *Config) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cols, err := columnar.Fields(sch, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.MessageName
+	if name == "" {
+		name = sch.Lineage().Name()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "message %s {\n", columnar.Ident(name))
+	for _, c := range cols {
+		writeField(&b, c, columnar.Ident(c.Name), "  ")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String()), nil
+}
+
+func writeField(b *strings.Builder, c *columnar.Field, name, indent string) {
+	rep := "required"
+	if c.Nullable {
+		rep = "optional"
+	}
+
+	switch c.Type {
+	case columnar.String:
+		fmt.Fprintf(b, "%s%s binary %s (STRING);\n", indent, rep, name)
+	case columnar.Int:
+		fmt.Fprintf(b, "%s%s int64 %s;\n", indent, rep, name)
+	case columnar.Float:
+		fmt.Fprintf(b, "%s%s double %s;\n", indent, rep, name)
+	case columnar.Bool:
+		fmt.Fprintf(b, "%s%s boolean %s;\n", indent, rep, name)
+	case columnar.Bytes:
+		fmt.Fprintf(b, "%s%s binary %s;\n", indent, rep, name)
+	case columnar.JSON:
+		fmt.Fprintf(b, "%s%s binary %s (JSON);\n", indent, rep, name)
+	case columnar.Record:
+		fmt.Fprintf(b, "%s%s group %s {\n", indent, rep, name)
+		for _, f := range c.Fields {
+			writeField(b, f, columnar.Ident(f.Name), indent+"  ")
+		}
+		fmt.Fprintf(b, "%s}\n", indent)
+	case columnar.List:
+		fmt.Fprintf(b, "%s%s group %s (LIST) {\n", indent, rep, name)
+		fmt.Fprintf(b, "%s  repeated group list {\n", indent)
+		writeField(b, c.Elem, "element", indent+"    ")
+		fmt.Fprintf(b, "%s  }\n%s}\n", indent, indent)
+	case columnar.Map:
+		fmt.Fprintf(b, "%s%s group %s (MAP) {\n", indent, rep, name)
+		fmt.Fprintf(b, "%s  repeated group key_value {\n", indent)
+		fmt.Fprintf(b, "%s    required binary key (STRING);\n", indent)
+		writeField(b, c.Elem, "value", indent+"    ")
+		fmt.Fprintf(b, "%s  }\n%s}\n", indent, indent)
+	default:
+		panic(fmt.Sprintf("unhandled columnar type %d", c.Type))
+	}
+}
+
+// ArrowField is a field of an Arrow schema, in the JSON representation used
+// by Arrow's integration tests.
+type ArrowField struct {
+	Name     string           `json:"name"`
+	Nullable bool             `json:"nullable"`
+	Type     map[string]any   `json:"type"`
+	Children []*ArrowField    `json:"children"`
+	Metadata []ArrowMetadatum `json:"metadata,omitempty"`
+}
+
+// ArrowMetadatum is a key-value pair of Arrow field metadata.
+type ArrowMetadatum struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// GenerateArrowSchema generates an Arrow schema, in the JSON representation
+// used by Arrow's integration tests, corresponding to the Parquet message
+// generated by [GenerateMessage]. Values represented as JSON in Parquet are
+// utf8 fields of the arrow.json canonical extension type.
+func GenerateArrowSchema(sch thema.Schema, cfg *Config) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	cols, err := columnar.Fields(sch, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	schema := struct {
+		Fields []*ArrowField `json:"fields"`
+	}{Fields: []*ArrowField{}}
+	for _, c := range cols {
+		schema.Fields = append(schema.Fields, arrowField(c, columnar.Ident(c.Name)))
+	}
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func arrowField(c *columnar.Field, name string) *ArrowField {
+	f := &ArrowField{Name: name, Nullable: c.Nullable, Children: []*ArrowField{}}
+	switch c.Type {
+	case columnar.String:
+		f.Type = map[string]any{"name": "utf8"}
+	case columnar.Int:
+		f.Type = map[string]any{"name": "int", "bitWidth": 64, "isSigned": true}
+	case columnar.Float:
+		f.Type = map[string]any{"name": "floatingpoint", "precision": "DOUBLE"}
+	case columnar.Bool:
+		f.Type = map[string]any{"name": "bool"}
+	case columnar.Bytes:
+		f.Type = map[string]any{"name": "binary"}
+	case columnar.JSON:
+		f.Type = map[string]any{"name": "utf8"}
+		f.Metadata = []ArrowMetadatum{{Key: "ARROW:extension:name", Value: "arrow.json"}}
+	case columnar.Record:
+		f.Type = map[string]any{"name": "struct"}
+		for _, cf := range c.Fields {
+			f.Children = append(f.Children, arrowField(cf, columnar.Ident(cf.Name)))
+		}
+	case columnar.List:
+		f.Type = map[string]any{"name": "list"}
+		f.Children = append(f.Children, arrowField(c.Elem, "item"))
+	case columnar.Map:
+		f.Type = map[string]any{"name": "map", "keysSorted": false}
+		f.Children = append(f.Children, &ArrowField{
+			Name: "entries",
+			Type: map[string]any{"name": "struct"},
+			Children: []*ArrowField{
+				{Name: "key", Type: map[string]any{"name": "utf8"}, Children: []*ArrowField{}},
+				arrowField(c.Elem, "value"),
+			},
+		})
+	default:
+		panic(fmt.Sprintf("unhandled columnar type %d", c.Type))
+	}
+	return f
+}
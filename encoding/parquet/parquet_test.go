@@ -0,0 +1,115 @@
+package parquet
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLineage = `
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		"1st-rank"?: int
+		ratio: number | *1.5
+		hidden: bool | null
+		thumbnail?: bytes
+		tags: [...string] | *[]
+		panels: [...#Panel]
+		labels: [string]: int
+		any: _
+		meta: {
+			internal: bool
+			owner: string
+		}
+		#Panel: {
+			type: string
+			legacy?: bool
+		}
+	}
+}]
+`
+
+func testSchema(t *testing.T) thema.Schema {
+	t.Helper()
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(testLineage), rt)
+	require.NoError(t, err)
+	return lin.Latest()
+}
+
+var testConfig = &Config{
+	Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+}
+
+func TestGenerateMessage(t *testing.T) {
+	b, err := GenerateMessage(testSchema(t), testConfig)
+	require.NoError(t, err)
+	assert.Equal(t, `message dashboard {
+  required binary title (STRING);
+  optional int64 _1st_rank;
+  required double ratio;
+  optional boolean hidden;
+  optional binary thumbnail;
+  required group tags (LIST) {
+    repeated group list {
+      required binary element (STRING);
+    }
+  }
+  required group panels (LIST) {
+    repeated group list {
+      required group element {
+        required binary type (STRING);
+      }
+    }
+  }
+  required group labels (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      required int64 value;
+    }
+  }
+  required binary any (JSON);
+  required group meta {
+    required binary owner (STRING);
+  }
+}
+`, string(b))
+}
+
+func TestGenerateArrowSchema(t *testing.T) {
+	b, err := GenerateArrowSchema(testSchema(t), testConfig)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fields": [
+  {"name": "title", "nullable": false, "type": {"name": "utf8"}, "children": []},
+  {"name": "_1st_rank", "nullable": true, "type": {"name": "int", "bitWidth": 64, "isSigned": true}, "children": []},
+  {"name": "ratio", "nullable": false, "type": {"name": "floatingpoint", "precision": "DOUBLE"}, "children": []},
+  {"name": "hidden", "nullable": true, "type": {"name": "bool"}, "children": []},
+  {"name": "thumbnail", "nullable": true, "type": {"name": "binary"}, "children": []},
+  {"name": "tags", "nullable": false, "type": {"name": "list"}, "children": [
+    {"name": "item", "nullable": false, "type": {"name": "utf8"}, "children": []}
+  ]},
+  {"name": "panels", "nullable": false, "type": {"name": "list"}, "children": [
+    {"name": "item", "nullable": false, "type": {"name": "struct"}, "children": [
+      {"name": "type", "nullable": false, "type": {"name": "utf8"}, "children": []}
+    ]}
+  ]},
+  {"name": "labels", "nullable": false, "type": {"name": "map", "keysSorted": false}, "children": [
+    {"name": "entries", "nullable": false, "type": {"name": "struct"}, "children": [
+      {"name": "key", "nullable": false, "type": {"name": "utf8"}, "children": []},
+      {"name": "value", "nullable": false, "type": {"name": "int", "bitWidth": 64, "isSigned": true}, "children": []}
+    ]}
+  ]},
+  {"name": "any", "nullable": false, "type": {"name": "utf8"}, "children": [],
+   "metadata": [{"key": "ARROW:extension:name", "value": "arrow.json"}]},
+  {"name": "meta", "nullable": false, "type": {"name": "struct"}, "children": [
+    {"name": "owner", "nullable": false, "type": {"name": "utf8"}, "children": []}
+  ]}
+]}`, string(b))
+}
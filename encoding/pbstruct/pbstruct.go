@@ -0,0 +1,136 @@
+// Package pbstruct converts between Thema instances and the dynamic payloads
+// carried by the google.protobuf.Struct and google.protobuf.Value well-known
+// types, as implemented by structpb.
+//
+//	inst, err := pbstruct.FromStruct(req.GetSpec(), sch)
+//	...
+//	m, err := pbstruct.ToMap(inst)
+//	spec, err := structpb.NewStruct(m)
+//
+// Structs received as bytes, such as HTTP request bodies, can be decoded
+// directly from the protobuf binary wire format with [Unmarshal].
+package pbstruct
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FromStruct validates the contents of the provided Struct against the
+// provided schema.
+//
+// google.protobuf.Struct represents all numbers as doubles. To give the same
+// result as round-tripping the payload through JSON text, numbers with no
+// fractional part are treated as integers.
+func FromStruct(s *structpb.Struct, sch thema.Schema) (*thema.Instance, error) {
+	return validate(s.AsMap(), sch)
+}
+
+// FromValue validates the contents of the provided Value against the provided
+// schema. Numbers are treated as in [FromStruct].
+func FromValue(v *structpb.Value, sch thema.Schema) (*thema.Instance, error) {
+	return validate(v.AsInterface(), sch)
+}
+
+// Unmarshal decodes a google.protobuf.Struct in the protobuf binary wire
+// format, as produced by proto.Marshal, and validates its contents against the
+// provided schema. Numbers are treated as in [FromStruct].
+func Unmarshal(b []byte, sch thema.Schema) (*thema.Instance, error) {
+	v, err := Decode(sch.Underlying().Context(), b)
+	if err != nil {
+		return nil, err
+	}
+	return sch.Validate(v)
+}
+
+// Decode decodes a google.protobuf.Struct in the protobuf binary wire format
+// into a [cue.Value] built by the provided context, readying it for a call to
+// [thema.Schema.Validate]. Numbers are treated as in [FromStruct].
+func Decode(ctx *cue.Context, b []byte) (cue.Value, error) {
+	s := new(structpb.Struct)
+	if err := proto.Unmarshal(b, s); err != nil {
+		return cue.Value{}, err
+	}
+	return ctx.Encode(intify(s.AsMap())), nil
+}
+
+func validate(x any, sch thema.Schema) (*thema.Instance, error) {
+	return sch.Validate(sch.Underlying().Context().Encode(intify(x)))
+}
+
+// intify converts all integral float64 values in x to int64.
+func intify(x any) any {
+	switch v := x.(type) {
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = intify(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = intify(e)
+		}
+	}
+	return x
+}
+
+// ToMap returns the data in the provided instance as a map suitable for
+// passing to structpb.NewStruct. An error is returned if the instance is not
+// a struct.
+func ToMap(inst *thema.Instance) (map[string]any, error) {
+	x, err := ToInterface(inst)
+	if err != nil {
+		return nil, err
+	}
+	m, is := x.(map[string]any)
+	if !is {
+		return nil, fmt.Errorf("instance is not a struct, got %T", x)
+	}
+	return m, nil
+}
+
+// ToInterface returns the data in the provided instance as a value suitable
+// for passing to structpb.NewValue. Integers too large to be represented by
+// structpb are converted to float64. Bytes are left as []byte, which
+// structpb.NewValue encodes as a base64 string.
+func ToInterface(inst *thema.Instance) (any, error) {
+	var x any
+	if err := inst.Underlying().Decode(&x); err != nil {
+		return nil, err
+	}
+	return unbig(x), nil
+}
+
+// unbig converts all *big.Int values in x, which structpb does not accept, to
+// the nearest representable type.
+func unbig(x any) any {
+	switch v := x.(type) {
+	case *big.Int:
+		if v.IsInt64() {
+			return v.Int64()
+		}
+		if v.IsUint64() {
+			return v.Uint64()
+		}
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = unbig(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = unbig(e)
+		}
+	}
+	return x
+}
@@ -0,0 +1,131 @@
+package pbstruct
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestPBStruct(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "pbstruct"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		count: int
+		ratio: float
+		tags: [...string]
+		nested: id: int
+		huge?: int
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	sch := lin.First()
+
+	inst, err := FromStruct(mustStruct(t, map[string]any{
+		"count":  3,
+		"ratio":  0.5,
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"id": -7},
+	}), sch)
+	require.NoError(t, err)
+
+	m, err := ToMap(inst)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"count":  3,
+		"ratio":  0.5,
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"id": -7},
+	}, m)
+
+	_, err = FromValue(structpb.NewStructValue(mustStruct(t, map[string]any{
+		"count":  1.5,
+		"ratio":  0.5,
+		"tags":   []any{},
+		"nested": map[string]any{"id": 1},
+	})), sch)
+	assert.Error(t, err, "fractional number should not validate as int")
+
+	_, err = FromValue(structpb.NewStringValue("nope"), sch)
+	assert.Error(t, err)
+
+	huge := new(big.Int).Lsh(big.NewInt(1), 70)
+	inst, err = sch.Validate(rt.Context().CompileString(`{count: 1, ratio: 1.0, tags: [], nested: id: 1, huge: ` + huge.String() + `}`))
+	require.NoError(t, err)
+	m, err = ToMap(inst)
+	require.NoError(t, err)
+	assert.IsType(t, float64(0), m["huge"])
+}
+
+func TestUnmarshal(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "pbwire"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		count: int
+		ratio: float
+		ok:    bool
+		tags: [...string]
+		nested: id: int
+		empty?: null
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	sch := lin.First()
+
+	marshal := func(m map[string]any) []byte {
+		b, err := proto.Marshal(mustStruct(t, m))
+		require.NoError(t, err)
+		return b
+	}
+
+	b := marshal(map[string]any{
+		"count":  3,
+		"ratio":  0.5,
+		"ok":     true,
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"id": -7},
+		"empty":  nil,
+	})
+	inst, err := Unmarshal(b, sch)
+	require.NoError(t, err)
+	m, err := ToMap(inst)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"count":  3,
+		"ratio":  0.5,
+		"ok":     true,
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"id": -7},
+		"empty":  nil,
+	}, m)
+
+	_, err = Unmarshal(marshal(map[string]any{"count": 1.5}), sch)
+	assert.Error(t, err, "fractional number should not validate as int")
+
+	_, err = Unmarshal(b[:len(b)-3], sch)
+	assert.Error(t, err, "truncated input should not decode")
+
+	_, err = Unmarshal(marshal(map[string]any{"count": math.NaN()}), sch)
+	assert.Error(t, err, "NaN has no CUE representation")
+}
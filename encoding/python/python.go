@@ -0,0 +1,544 @@
+// Package python generates Python models from Thema schemas.
+package python
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+	"github.com/grafana/thema/internal/typegen"
+	"github.com/grafana/thema/internal/util"
+)
+
+// Config controls Python model generation from a Thema schema.
+type Config struct {
+	// RootName specifies the name to use for the model representing the root of
+	// the schema. If empty, this defaults to titlecasing of the lineage name.
+	RootName string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated models, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []cue.Path
+}
+
+// GenerateModels generates a Python module containing pydantic (v2) models
+// corresponding to the provided Schema.
+//
+// A model is generated for the schema root, and for each of its top-level
+// definitions and nested structs. Top-level definitions of disjunctions of
+// strings are generated as enums. Constraints with a pydantic equivalent, such
+// as bounds and regular expressions, are declared on fields, along with their
+// defaults. Schema comments become docstrings.
+//
+// Models forbid fields not declared by the schema, unless the schema is open.
+// Fields whose names are not valid Python identifiers are renamed, with an
+// alias to their name in the schema.
+func GenerateModels(sch thema.Schema, cfg *Config) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	g := &pyGen{
+		defs:    make(map[string]string),
+		enums:   make(map[string]map[string]string),
+		taken:   make(typegen.Names),
+		exclude: cfg.Exclude,
+	}
+
+	rootName := cfg.RootName
+	if rootName == "" {
+		rootName = strings.Title(util.SanitizeLabelString(sch.Lineage().Name()))
+	}
+	rootName = className(rootName)
+	g.taken[rootName] = true
+
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	g.schdef = schdef
+	type def struct {
+		name string
+		v    cue.Value
+	}
+	var defs []def
+	iter, err := schdef.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		if iter.Selector().IsDefinition() {
+			name := g.taken.Reserve(className(strings.TrimPrefix(iter.Selector().String(), "#")))
+			g.defs[iter.Selector().String()] = name
+			defs = append(defs, def{name: name, v: iter.Value()})
+		}
+	}
+
+	for _, d := range defs {
+		if err := g.genNamed(d.name, d.v); err != nil {
+			return nil, fmt.Errorf("generating model for #%s failed: %w", d.name, err)
+		}
+	}
+	if err := g.genModel(rootName, schdef, 0); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "\"\"\"Models for version %s of the %s lineage.\"\"\"\n\n", sch.Version(), sch.Lineage().Name())
+	fmt.Fprintln(&b, "from __future__ import annotations")
+	fmt.Fprintln(&b)
+	if g.usesEnum {
+		fmt.Fprintln(&b, "import enum")
+	}
+	fmt.Fprintln(&b, "import typing")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "import pydantic")
+	for _, d := range g.decls {
+		fmt.Fprintf(&b, "\n\n%s", d)
+	}
+	return b.Bytes(), nil
+}
+
+// pyGen accumulates the Python declarations generated for a schema.
+type pyGen struct {
+	exclude []cue.Path
+	// schdef is the schema, relative to which excluded paths are resolved.
+	schdef cue.Value
+
+	// defs maps the selector of each top-level definition in the schema to the
+	// name of its generated class.
+	defs map[string]string
+	// enums maps the name of each generated enum to the names of its members,
+	// keyed by value.
+	enums map[string]map[string]string
+	// taken records the class names already in use.
+	taken typegen.Names
+
+	// decls holds the generated class declarations, in order.
+	decls []string
+
+	usesEnum bool
+}
+
+// genNamed generates a class named name for the top-level definition v. Where
+// v is neither a struct nor a disjunction of strings, a type alias is
+// generated instead.
+func (g *pyGen) genNamed(name string, v cue.Value) error {
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		if strs, nullable := typegen.StringEnum(args); strs != nil && !nullable {
+			g.genEnum(name, v, strs)
+			return nil
+		}
+	} else if v.IncompleteKind() == cue.StructKind && !typegen.IsMap(v) {
+		return g.genModel(name, v, 0)
+	}
+
+	typ, err := g.typeFor(v, name+"Value", 0)
+	if err != nil {
+		return err
+	}
+	g.decls = append(g.decls, fmt.Sprintf("%s = %s\n%s", name, typ, docstring(v, "")))
+	return nil
+}
+
+// genModel generates a pydantic model named name with the fields of v. The
+// models of nested structs are generated first, such that they are declared
+// before use.
+func (g *pyGen) genModel(name string, v cue.Value, depth int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "class %s(pydantic.BaseModel):\n", name)
+	if doc := docstring(v, "    "); doc != "" {
+		fmt.Fprintf(&b, "%s\n", doc)
+	}
+
+	extra := "forbid"
+	if v.Allows(cue.Str("_thema_undeclared_field")) {
+		extra = "allow"
+	}
+	fmt.Fprintf(&b, "    model_config = pydantic.ConfigDict(extra=%q, populate_by_name=True)\n\n", extra)
+
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		label := iter.Selector().Unquoted()
+		fv := iter.Value()
+		if cuetil.ContainsPath(g.exclude, cuetil.RelPath(g.schdef, fv)) {
+			continue
+		}
+		typ, err := g.typeFor(fv, name+className(label), depth+1)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		var args []string
+		field := fieldIdent(label)
+		if field != label {
+			args = append(args, "alias="+pyString(label))
+		}
+		args = append(args, constraints(fv)...)
+
+		var def string
+		if iter.IsOptional() {
+			typ = optional(typ)
+			def = "None"
+		} else if d, has := fv.Default(); has {
+			def = g.literal(d, typ)
+		}
+
+		switch {
+		case def == "[]":
+			args = append([]string{"default_factory=list"}, args...)
+			fmt.Fprintf(&b, "    %s: %s = pydantic.Field(%s)\n", field, typ, strings.Join(args, ", "))
+		case len(args) > 0 && def != "":
+			args = append([]string{"default=" + def}, args...)
+			fallthrough
+		case len(args) > 0:
+			fmt.Fprintf(&b, "    %s: %s = pydantic.Field(%s)\n", field, typ, strings.Join(args, ", "))
+		case def != "":
+			fmt.Fprintf(&b, "    %s: %s = %s\n", field, typ, def)
+		default:
+			fmt.Fprintf(&b, "    %s: %s\n", field, typ)
+		}
+		if doc := docstring(fv, "    "); doc != "" {
+			// Separate documented fields from the next
+			b.WriteString(doc + "\n")
+		}
+	}
+	g.decls = append(g.decls, strings.TrimRight(b.String(), "\n")+"\n")
+	return nil
+}
+
+// literal returns the default value d of a field of the provided type as a
+// Python literal, or the empty string if it cannot be represented.
+func (g *pyGen) literal(d cue.Value, typ string) string {
+	if d.Validate(cue.Concrete(true)) != nil {
+		return ""
+	}
+	switch d.Kind() {
+	case cue.StringKind:
+		s, _ := d.String() // nolint: errcheck
+		enum := strings.TrimSuffix(strings.TrimPrefix(typ, "typing.Optional["), "]")
+		if members, is := g.enums[enum]; is {
+			return enum + "." + members[s]
+		}
+		return pyString(s)
+	case cue.BoolKind:
+		if bv, _ := d.Bool(); bv { // nolint: errcheck
+			return "True"
+		}
+		return "False"
+	case cue.NullKind:
+		return "None"
+	case cue.IntKind, cue.FloatKind:
+		b, err := d.MarshalJSON()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	case cue.ListKind:
+		if n, err := d.Len().Int64(); err == nil && n == 0 {
+			return "[]"
+		}
+	}
+	// Mutable defaults, such as non-empty lists, would be shared between
+	// instances
+	return ""
+}
+
+// genEnum generates an enum named name, with a member for each of the
+// provided strings.
+func (g *pyGen) genEnum(name string, v cue.Value, strs []string) {
+	g.usesEnum = true
+	members := make(map[string]string)
+	g.enums[name] = members
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "class %s(str, enum.Enum):\n", name)
+	if doc := docstring(v, "    "); doc != "" {
+		fmt.Fprintf(&b, "%s\n", doc)
+	}
+	used := make(map[string]bool)
+	for _, s := range strs {
+		member := strings.ToUpper(fieldIdent(s))
+		if member == "" || member == "FIELD" && s == "" {
+			member = "EMPTY"
+		}
+		for i := 2; used[member]; i++ {
+			member = fmt.Sprintf("%s_%d", strings.ToUpper(fieldIdent(s)), i)
+		}
+		used[member] = true
+		members[s] = member
+		fmt.Fprintf(&b, "    %s = %s\n", member, pyString(s))
+	}
+	g.decls = append(g.decls, b.String())
+}
+
+// typeFor returns the Python type annotation for values of v. Where a model
+// must be generated for v, it is given the provided name.
+func (g *pyGen) typeFor(v cue.Value, name string, depth int) (string, error) {
+	if depth > typegen.MaxDepth {
+		return "typing.Any", nil
+	}
+
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		if typ, has := g.defs[sels[len(sels)-1].String()]; has {
+			return typ, nil
+		}
+	}
+
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		return g.union(args, name, depth)
+	}
+
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		if typegen.IsMap(v) {
+			typ, err := g.typeFor(v.LookupPath(cue.MakePath(cue.AnyString)), name+"Value", depth+1)
+			if err != nil {
+				return "", err
+			}
+			return "typing.Dict[str, " + typ + "]", nil
+		}
+		name = g.taken.Reserve(name)
+		if err := g.genModel(name, v, depth); err != nil {
+			return "", err
+		}
+		return name, nil
+	case cue.ListKind:
+		if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+			typ, err := g.typeFor(elem, name+"Item", depth+1)
+			if err != nil {
+				return "", err
+			}
+			return "typing.List[" + typ + "]", nil
+		}
+		var elems []string
+		iter, err := v.List()
+		if err != nil {
+			return "", err
+		}
+		for i := 0; iter.Next(); i++ {
+			typ, err := g.typeFor(iter.Value(), fmt.Sprintf("%s%d", name, i), depth+1)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, typ)
+		}
+		if len(elems) == 0 {
+			return "typing.Tuple[()]", nil
+		}
+		return "typing.Tuple[" + strings.Join(elems, ", ") + "]", nil
+	case cue.StringKind:
+		if v.IsConcrete() {
+			s, _ := v.String() // nolint: errcheck
+			return "typing.Literal[" + pyString(s) + "]", nil
+		}
+		return "str", nil
+	case cue.BytesKind:
+		return "str", nil
+	case cue.BoolKind:
+		return "bool", nil
+	case cue.IntKind:
+		return "int", nil
+	case cue.FloatKind, cue.NumberKind:
+		return "float", nil
+	case cue.NullKind:
+		return "None", nil
+	}
+	return "typing.Any", nil
+}
+
+// union returns the Python type annotation for values of a disjunction of the
+// provided disjuncts.
+func (g *pyGen) union(disjuncts []cue.Value, name string, depth int) (string, error) {
+	strs, nullable := typegen.StringEnum(disjuncts)
+	if strs != nil {
+		quoted := make([]string, 0, len(strs))
+		for _, s := range strs {
+			quoted = append(quoted, pyString(s))
+		}
+		typ := "typing.Literal[" + strings.Join(quoted, ", ") + "]"
+		if nullable {
+			return optional(typ), nil
+		}
+		return typ, nil
+	}
+
+	var types []string
+	for i, d := range disjuncts {
+		if d.IncompleteKind() == cue.NullKind {
+			nullable = true
+			continue
+		}
+		if typegen.Subsumed(disjuncts, i) {
+			continue
+		}
+		typ, err := g.typeFor(d, name, depth+1)
+		if err != nil {
+			return "", err
+		}
+		types = append(types, typ)
+	}
+
+	var typ string
+	switch len(types) {
+	case 0:
+		return "None", nil
+	case 1:
+		typ = types[0]
+	default:
+		typ = "typing.Union[" + strings.Join(types, ", ") + "]"
+	}
+	if nullable {
+		return optional(typ), nil
+	}
+	return typ, nil
+}
+
+func optional(typ string) string {
+	if strings.HasPrefix(typ, "typing.Optional[") || typ == "None" || typ == "typing.Any" {
+		return typ
+	}
+	return "typing.Optional[" + typ + "]"
+}
+
+// constraints returns the arguments to pydantic.Field corresponding to the
+// constraints on the scalar v.
+func constraints(v cue.Value) []string {
+	switch v.IncompleteKind() {
+	case cue.StringKind, cue.IntKind, cue.FloatKind, cue.NumberKind:
+	default:
+		return nil
+	}
+	var args []string
+	typegen.Constraints(v, func(op cue.Op, arg cue.Value) {
+		if !arg.IsConcrete() {
+			return
+		}
+		var lit string
+		if s, err := arg.String(); err == nil {
+			lit = pyString(s)
+		} else if b, err := arg.MarshalJSON(); err == nil {
+			lit = string(b)
+		} else {
+			return
+		}
+
+		switch op {
+		case cue.LessThanOp:
+			args = append(args, "lt="+lit)
+		case cue.LessThanEqualOp:
+			args = append(args, "le="+lit)
+		case cue.GreaterThanOp:
+			args = append(args, "gt="+lit)
+		case cue.GreaterThanEqualOp:
+			args = append(args, "ge="+lit)
+		case cue.RegexMatchOp:
+			args = append(args, "pattern="+lit)
+		}
+	})
+	return args
+}
+
+// docstring returns the doc comments of v as a Python docstring, with each
+// line prefixed by indent.
+func docstring(v cue.Value, indent string) string {
+	var lines []string
+	for _, cg := range v.Doc() {
+		lines = append(lines, strings.Split(strings.TrimSpace(cg.Text()), "\n")...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	text := strings.ReplaceAll(strings.Join(lines, "\n"), `"""`, `\"\"\"`)
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s\"\"\"%s\"\"\"\n", indent, text)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\"\"\"", indent)
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 && line != "" {
+			b.WriteString(indent)
+		}
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+	fmt.Fprintf(&b, "%s\"\"\"\n", indent)
+	return b.String()
+}
+
+// pyString returns s as a Python string literal.
+func pyString(s string) string {
+	// JSON string literals are valid Python, save for escaped forward slashes,
+	// which Go does not produce
+	b, _ := json.Marshal(s) // nolint: errcheck
+	return string(b)
+}
+
+// className converts s to a CamelCase Python class name.
+func className(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+		case upper:
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				b.WriteByte('V')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pyKeywords lists the reserved words of Python, along with the names that
+// would shadow the attributes of pydantic.BaseModel.
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true,
+	"if": true, "import": true, "in": true, "is": true, "lambda": true,
+	"nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+	"model_config": true, "model_fields": true, "copy": true, "dict": true,
+	"json": true, "schema": true, "validate": true,
+}
+
+// fieldIdent converts s to a valid Python attribute name, leaving it
+// unchanged where possible.
+func fieldIdent(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteByte('_')
+		}
+	}
+
+	id := b.String()
+	switch {
+	case id == "":
+		return "field"
+	case unicode.IsDigit([]rune(id)[0]):
+		return "f_" + id
+	case strings.HasPrefix(id, "_"):
+		// pydantic treats underscored attributes as private
+		return "f" + id
+	case pyKeywords[id]:
+		return id + "_"
+	}
+	return id
+}
@@ -0,0 +1,123 @@
+package python
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateModels(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The dashboard's unique identifier.
+		//
+		// Must be lowercase.
+		id: string & =~"^[a-z]+$"
+		count: uint8 | *1
+		ratio?: float & >0 & <1
+		tags: [...string]
+		labels?: [string]: int
+		panels: [...#Panel]
+		kind: #Kind
+		mode: "x" | *"y"
+		n: null | string
+		from: string
+		mixed: string | int
+		#Panel: {
+			id: int
+			gridPos?: {x: int, y: int}
+		}
+		#Kind: "a" | *"b"
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateModels(lin.First(), nil)
+	require.NoError(t, err)
+	out := string(b)
+
+	assert.Contains(t, out, "import enum\nimport typing\n\nimport pydantic\n")
+	assert.Contains(t, out, `class PanelGridPos(pydantic.BaseModel):
+    model_config = pydantic.ConfigDict(extra="forbid", populate_by_name=True)
+
+    x: int
+    y: int
+
+
+class Panel(pydantic.BaseModel):
+    model_config = pydantic.ConfigDict(extra="forbid", populate_by_name=True)
+
+    id: int
+    gridPos: typing.Optional[PanelGridPos] = None
+
+
+class Kind(str, enum.Enum):
+    A = "a"
+    B = "b"
+
+
+class Dashboard(pydantic.BaseModel):
+    model_config = pydantic.ConfigDict(extra="forbid", populate_by_name=True)
+
+    id: str = pydantic.Field(pattern="^[a-z]+$")
+    """The dashboard's unique identifier.
+
+    Must be lowercase.
+    """
+
+    count: int = pydantic.Field(default=1, ge=0, le=255)
+    ratio: typing.Optional[float] = pydantic.Field(default=None, gt=0, lt=1)
+    tags: typing.List[str] = pydantic.Field(default_factory=list)
+    labels: typing.Optional[typing.Dict[str, int]] = None
+    panels: typing.List[Panel] = pydantic.Field(default_factory=list)
+    kind: Kind = Kind.B
+    mode: typing.Literal["x", "y"] = "y"
+    n: typing.Optional[str]
+    from_: str = pydantic.Field(alias="from")
+    mixed: typing.Union[str, int]
+`)
+
+	b, err = GenerateModels(lin.First(), &Config{RootName: "dash"})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "class Dash(pydantic.BaseModel):")
+}
+
+func TestExclude(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dash"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		meta: {
+			internal: string
+			owner?: string
+		}
+		panels: [...#Panel]
+		#Panel: {
+			x: int
+			legacy?: bool
+		}
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateModels(lin.First(), &Config{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "internal")
+	assert.NotContains(t, string(b), "legacy")
+	assert.Contains(t, string(b), "owner")
+}
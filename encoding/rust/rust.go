@@ -0,0 +1,651 @@
+// Package rust generates Rust types from Thema schemas.
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+	"github.com/grafana/thema/internal/typegen"
+	"github.com/grafana/thema/internal/util"
+)
+
+// Config controls Rust type generation from a Thema schema.
+type Config struct {
+	// RootName specifies the name to use for the type representing the root of
+	// the schema. If empty, this defaults to titlecasing of the lineage name.
+	RootName string
+
+	// Derives lists the traits derived by each generated type, in addition to
+	// serde's Serialize and Deserialize. If nil, this defaults to Debug, Clone
+	// and PartialEq.
+	Derives []string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated types, e.g. "meta.internal" or "#Panel.legacy".
+	Exclude []cue.Path
+}
+
+// jsonValue is the Rust type used for values that are unconstrained, or whose
+// constraints have no Rust equivalent.
+const jsonValue = "serde_json::Value"
+
+// GenerateTypes generates Rust types, annotated for serialization with serde,
+// corresponding to the provided Schema.
+//
+// A struct is generated for the schema root, and for each of its top-level
+// definitions and nested structs. Optional fields are represented as Option,
+// disjunctions of strings as enums, and other disjunctions as untagged enums.
+// Field names are converted to snake case, and renamed to their schema name
+// for serialization. Where a field has a default, it is applied when the
+// field is absent during deserialization.
+//
+// Generated code depends on the serde crate, with its derive feature, and on
+// serde_json where a schema contains unconstrained values.
+func GenerateTypes(sch thema.Schema, cfg *Config) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	g := &rustGen{
+		derives: cfg.Derives,
+		exclude: cfg.Exclude,
+		defs:    make(map[string]string),
+		taken:   make(typegen.Names),
+	}
+	if g.derives == nil {
+		g.derives = []string{"Debug", "Clone", "PartialEq"}
+	}
+
+	rootName := cfg.RootName
+	if rootName == "" {
+		rootName = strings.Title(util.SanitizeLabelString(sch.Lineage().Name()))
+	}
+	rootName = typeIdent(rootName)
+	g.taken[rootName] = true
+
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	g.schdef = schdef
+	type def struct {
+		name string
+		v    cue.Value
+	}
+	var defs []def
+	iter, err := schdef.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		if iter.Selector().IsDefinition() {
+			name := g.taken.Reserve(typeIdent(strings.TrimPrefix(iter.Selector().String(), "#")))
+			g.defs[iter.Selector().String()] = name
+			defs = append(defs, def{name: name, v: iter.Value()})
+		}
+	}
+
+	if err := g.genStruct(rootName, schdef, 0); err != nil {
+		return nil, err
+	}
+	for _, d := range defs {
+		if err := g.genNamed(d.name, d.v); err != nil {
+			return nil, fmt.Errorf("generating type for #%s failed: %w", d.name, err)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "//! Types for version %s of the %s lineage.\n\n", sch.Version(), sch.Lineage().Name())
+	fmt.Fprintln(&b, "use serde::{Deserialize, Serialize};")
+	if g.usesMap {
+		fmt.Fprintln(&b, "use std::collections::BTreeMap;")
+	}
+	for _, d := range g.decls {
+		fmt.Fprintf(&b, "\n%s", d)
+	}
+	for _, f := range g.fns {
+		fmt.Fprintf(&b, "\n%s", f)
+	}
+	return b.Bytes(), nil
+}
+
+// rustGen accumulates the Rust declarations generated for a schema.
+type rustGen struct {
+	derives []string
+	exclude []cue.Path
+	// schdef is the schema, relative to which excluded paths are resolved.
+	schdef cue.Value
+
+	// defs maps the selector of each top-level definition in the schema to the
+	// name of its generated type.
+	defs map[string]string
+	// taken records the type names already in use.
+	taken typegen.Names
+
+	// decls holds the generated type declarations, in order.
+	decls []string
+	// fns holds the generated functions providing field defaults.
+	fns []string
+
+	usesMap bool
+}
+
+// genNamed generates a type named name for the top-level definition v.
+func (g *rustGen) genNamed(name string, v cue.Value) error {
+	if op, args := v.Expr(); op != cue.OrOp && v.IncompleteKind() == cue.StructKind {
+		if _, is := g.mapType(v, name, 0); !is {
+			return g.genStruct(name, v, 0)
+		}
+	} else if strs, nullable := typegen.StringEnum(args); op == cue.OrOp && strs != nil && !nullable {
+		g.genEnum(name, v, strs)
+		return nil
+	}
+
+	typ, err := g.typeFor(v, name+"Value", 0)
+	if err != nil {
+		return err
+	}
+	g.decls = append(g.decls, fmt.Sprintf("%spub type %s = %s;\n", docComment(v, ""), name, typ))
+	return nil
+}
+
+func (g *rustGen) derive(extra ...string) string {
+	return fmt.Sprintf("#[derive(%s)]\n", strings.Join(append(append(append([]string{}, g.derives...), extra...), "Serialize", "Deserialize"), ", "))
+}
+
+// genStruct generates a struct named name with the fields of v.
+func (g *rustGen) genStruct(name string, v cue.Value, depth int) error {
+	// Reserve a position, so that types are declared before those they contain
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	b.WriteString(docComment(v, ""))
+	b.WriteString(g.derive())
+	fmt.Fprintf(&b, "pub struct %s {\n", name)
+
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		label := iter.Selector().Unquoted()
+		fv := iter.Value()
+		if cuetil.ContainsPath(g.exclude, cuetil.RelPath(g.schdef, fv)) {
+			continue
+		}
+		typ, err := g.typeFor(fv, name+typeIdent(label), depth+1)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		var serde []string
+		field := fieldIdent(label)
+		if strings.TrimPrefix(field, "r#") != label {
+			serde = append(serde, "rename = "+rustString(label))
+		}
+		switch {
+		case iter.IsOptional():
+			if !strings.HasPrefix(typ, "Option<") {
+				typ = "Option<" + typ + ">"
+			}
+			serde = append(serde, "default", `skip_serializing_if = "Option::is_none"`)
+		default:
+			if d, has := fv.Default(); has {
+				if attr := g.defaultAttr(name, field, typ, d); attr != "" {
+					serde = append(serde, attr)
+				}
+			}
+		}
+
+		b.WriteString(docComment(fv, "    "))
+		if len(serde) > 0 {
+			fmt.Fprintf(&b, "    #[serde(%s)]\n", strings.Join(serde, ", "))
+		}
+		fmt.Fprintf(&b, "    pub %s: %s,\n", field, typ)
+	}
+	b.WriteString("}\n")
+	g.decls[idx] = b.String()
+	return nil
+}
+
+// defaultAttr returns the serde attribute applying the default value d to the
+// field of the provided type, if it can be represented in Rust.
+func (g *rustGen) defaultAttr(structName, field, typ string, d cue.Value) string {
+	var lit string
+	switch d.Kind() {
+	case cue.StringKind:
+		s, _ := d.String() // nolint: errcheck
+		switch {
+		case typ != "String" && g.taken[typ]:
+			// Enums derive Default from the schema's default
+			return "default"
+		case typ != "String":
+			return ""
+		case s == "":
+			return "default"
+		}
+		lit = rustString(s) + ".to_string()"
+	case cue.BoolKind:
+		if typ != "bool" {
+			return ""
+		}
+		bv, _ := d.Bool() // nolint: errcheck
+		if !bv {
+			return "default"
+		}
+		lit = "true"
+	case cue.IntKind, cue.FloatKind:
+		if !numericTypes[typ] {
+			return ""
+		}
+		b, err := d.MarshalJSON()
+		if err != nil {
+			return ""
+		}
+		lit = string(b)
+		if lit == "0" {
+			return "default"
+		}
+		if typ == "f64" && !strings.ContainsAny(lit, ".eE") {
+			lit += ".0"
+		}
+	case cue.ListKind:
+		if n, err := d.Len().Int64(); err == nil && n == 0 && strings.HasPrefix(typ, "Vec<") {
+			return "default"
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	fn := fmt.Sprintf("default_%s_%s", fieldIdent(structName), strings.TrimPrefix(field, "r#"))
+	g.fns = append(g.fns, fmt.Sprintf("fn %s() -> %s {\n    %s\n}\n", fn, typ, lit))
+	return "default = " + rustString(fn)
+}
+
+var numericTypes = map[string]bool{
+	"u8": true, "u16": true, "u32": true, "u64": true,
+	"i8": true, "i16": true, "i32": true, "i64": true, "f64": true,
+}
+
+// genEnum generates an enum named name, with a variant for each of the
+// provided strings. If v has a default, it is the enum's Default.
+func (g *rustGen) genEnum(name string, v cue.Value, strs []string) {
+	var def string
+	if d, has := v.Default(); has {
+		def, _ = d.String() // nolint: errcheck
+	}
+
+	var b strings.Builder
+	b.WriteString(docComment(v, ""))
+	if def != "" {
+		b.WriteString(g.derive("Default"))
+	} else {
+		b.WriteString(g.derive())
+	}
+	fmt.Fprintf(&b, "pub enum %s {\n", name)
+	used := make(map[string]bool)
+	for _, s := range strs {
+		variant := typeIdent(s)
+		if variant == "" {
+			variant = "Empty"
+		}
+		for i := 2; used[variant]; i++ {
+			variant = fmt.Sprintf("%s%d", typeIdent(s), i)
+		}
+		used[variant] = true
+
+		if s == def {
+			b.WriteString("    #[default]\n")
+		}
+		fmt.Fprintf(&b, "    #[serde(rename = %s)]\n", rustString(s))
+		fmt.Fprintf(&b, "    %s,\n", variant)
+	}
+	b.WriteString("}\n")
+	g.decls = append(g.decls, b.String())
+}
+
+// typeFor returns the Rust type of values of v. Where a named type must be
+// generated for v, it is given the provided name.
+func (g *rustGen) typeFor(v cue.Value, name string, depth int) (string, error) {
+	if depth > typegen.MaxDepth {
+		return jsonValue, nil
+	}
+
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		if typ, has := g.defs[sels[len(sels)-1].String()]; has {
+			return typ, nil
+		}
+	}
+
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		return g.union(v, args, name, depth)
+	}
+
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		if typ, is := g.mapType(v, name, depth); is {
+			return typ, nil
+		}
+		name = g.taken.Reserve(name)
+		if err := g.genStruct(name, v, depth); err != nil {
+			return "", err
+		}
+		return name, nil
+	case cue.ListKind:
+		if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+			typ, err := g.typeFor(elem, name+"Item", depth+1)
+			if err != nil {
+				return "", err
+			}
+			return "Vec<" + typ + ">", nil
+		}
+		var elems []string
+		iter, err := v.List()
+		if err != nil {
+			return "", err
+		}
+		for i := 0; iter.Next(); i++ {
+			typ, err := g.typeFor(iter.Value(), fmt.Sprintf("%s%d", name, i), depth+1)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, typ)
+		}
+		if len(elems) == 1 {
+			// A trailing comma distinguishes a one-element tuple
+			return "(" + elems[0] + ",)", nil
+		}
+		return "(" + strings.Join(elems, ", ") + ")", nil
+	case cue.StringKind, cue.BytesKind:
+		return "String", nil
+	case cue.BoolKind:
+		return "bool", nil
+	case cue.IntKind:
+		return intType(v), nil
+	case cue.FloatKind, cue.NumberKind:
+		return "f64", nil
+	case cue.NullKind:
+		return "()", nil
+	}
+	return jsonValue, nil
+}
+
+// mapType returns the BTreeMap type of values of v, if v is a struct
+// constrained only by a pattern, e.g. [string]: int.
+func (g *rustGen) mapType(v cue.Value, name string, depth int) (string, bool) {
+	if !typegen.IsMap(v) {
+		return "", false
+	}
+	typ, err := g.typeFor(v.LookupPath(cue.MakePath(cue.AnyString)), name+"Value", depth+1)
+	if err != nil {
+		typ = jsonValue
+	}
+	g.usesMap = true
+	return "BTreeMap<String, " + typ + ">", true
+}
+
+// union returns the Rust type of values of v, a disjunction of the provided
+// disjuncts. Disjunctions of strings are represented as enums, other
+// disjunctions as untagged enums, and disjunctions with null as Options.
+func (g *rustGen) union(v cue.Value, disjuncts []cue.Value, name string, depth int) (string, error) {
+	strs, nullable := typegen.StringEnum(disjuncts)
+	if strs != nil {
+		name = g.taken.Reserve(name)
+		g.genEnum(name, v, strs)
+		return option(name, nullable), nil
+	}
+
+	var rest []cue.Value
+	for i, d := range disjuncts {
+		if d.IncompleteKind() == cue.NullKind {
+			nullable = true
+			continue
+		}
+		if !typegen.Subsumed(disjuncts, i) {
+			rest = append(rest, d)
+		}
+	}
+	switch len(rest) {
+	case 0:
+		return "()", nil
+	case 1:
+		typ, err := g.typeFor(rest[0], name, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return option(typ, nullable), nil
+	}
+
+	name = g.taken.Reserve(name)
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	b.WriteString(docComment(v, ""))
+	b.WriteString(g.derive())
+	b.WriteString("#[serde(untagged)]\n")
+	fmt.Fprintf(&b, "pub enum %s {\n", name)
+	used := make(map[string]bool)
+	for _, d := range rest {
+		typ, err := g.typeFor(d, name+kindName(d), depth+1)
+		if err != nil {
+			return "", err
+		}
+		variant := kindName(d)
+		if _, p := d.ReferencePath(); len(p.Selectors()) > 0 && g.taken[typ] {
+			variant = typ
+		}
+		for i := 2; used[variant]; i++ {
+			variant = fmt.Sprintf("%s%d", kindName(d), i)
+		}
+		used[variant] = true
+		fmt.Fprintf(&b, "    %s(%s),\n", variant, typ)
+	}
+	b.WriteString("}\n")
+	g.decls[idx] = b.String()
+	return option(name, nullable), nil
+}
+
+func option(typ string, nullable bool) string {
+	if nullable && !strings.HasPrefix(typ, "Option<") {
+		return "Option<" + typ + ">"
+	}
+	return typ
+}
+
+// kindName returns a name for the kind of values of v, for use as the name
+// of an untagged enum variant.
+func kindName(v cue.Value) string {
+	switch v.IncompleteKind() {
+	case cue.StringKind, cue.BytesKind:
+		return "String"
+	case cue.BoolKind:
+		return "Bool"
+	case cue.IntKind:
+		return "Int"
+	case cue.FloatKind, cue.NumberKind:
+		return "Float"
+	case cue.ListKind:
+		return "List"
+	case cue.StructKind:
+		return "Object"
+	}
+	return "Value"
+}
+
+// intType returns the smallest Rust integer type containing the bounds on the
+// integer v, or i64 if it is unbounded.
+func intType(v cue.Value) string {
+	lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+	typegen.Constraints(v, func(op cue.Op, arg cue.Value) {
+		n, err := arg.Int64()
+		if err != nil {
+			return
+		}
+		switch op {
+		case cue.GreaterThanEqualOp:
+			lo = maxInt64(lo, n)
+		case cue.GreaterThanOp:
+			if n < math.MaxInt64 {
+				lo = maxInt64(lo, n+1)
+			}
+		case cue.LessThanEqualOp:
+			hi = minInt64(hi, n)
+		case cue.LessThanOp:
+			if n > math.MinInt64 {
+				hi = minInt64(hi, n-1)
+			}
+		}
+	})
+
+	if lo >= 0 {
+		switch {
+		case hi <= math.MaxUint8:
+			return "u8"
+		case hi <= math.MaxUint16:
+			return "u16"
+		case hi <= math.MaxUint32:
+			return "u32"
+		}
+		return "u64"
+	}
+	switch {
+	case lo >= math.MinInt8 && hi <= math.MaxInt8:
+		return "i8"
+	case lo >= math.MinInt16 && hi <= math.MaxInt16:
+		return "i16"
+	case lo >= math.MinInt32 && hi <= math.MaxInt32:
+		return "i32"
+	}
+	return "i64"
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// docComment returns the doc comments of v as a Rust doc comment, with each
+// line prefixed by indent.
+func docComment(v cue.Value, indent string) string {
+	var b strings.Builder
+	for _, cg := range v.Doc() {
+		for _, line := range strings.Split(strings.TrimSpace(cg.Text()), "\n") {
+			fmt.Fprintf(&b, "%s///%s\n", indent, strings.TrimRight(" "+line, " "))
+		}
+	}
+	return b.String()
+}
+
+// rustString returns s as a Rust string literal.
+func rustString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&b, `\u{%x}`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// typeIdent converts s to an UpperCamelCase Rust type or variant identifier.
+func typeIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+		case upper:
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				b.WriteByte('V')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rustKeywords lists the reserved words of Rust, which cannot be used as
+// field names without the r# prefix.
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true,
+	"continue": true, "dyn": true, "else": true, "enum": true, "extern": true,
+	"false": true, "fn": true, "for": true, "if": true, "impl": true, "in": true,
+	"let": true, "loop": true, "match": true, "mod": true, "move": true,
+	"mut": true, "pub": true, "ref": true, "return": true, "static": true,
+	"struct": true, "trait": true, "true": true, "type": true, "unsafe": true,
+	"use": true, "where": true, "while": true, "abstract": true, "become": true,
+	"box": true, "do": true, "final": true, "macro": true, "override": true,
+	"priv": true, "try": true, "typeof": true, "unsized": true, "virtual": true,
+	"yield": true,
+}
+
+// fieldIdent converts s to a snake_case Rust field identifier.
+func fieldIdent(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			// Start a new word at lowerUpper, or at the last of UPPERLower
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") &&
+				(!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	id := strings.TrimSuffix(b.String(), "_")
+	switch {
+	case id == "":
+		return "field"
+	case unicode.IsDigit([]rune(id)[0]):
+		return "f_" + id
+	case id == "self" || id == "super" || id == "crate":
+		// Cannot be raw identifiers
+		return id + "_"
+	case rustKeywords[id]:
+		return "r#" + id
+	}
+	return id
+}
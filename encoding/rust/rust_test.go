@@ -0,0 +1,160 @@
+package rust
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTypes(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// The dashboard's unique identifier.
+		id: string
+		count: uint8 | *1
+		ratio?: float & >0 & <1
+		labels?: [string]: int
+		panels: [...#Panel]
+		kind: #Kind
+		mode: "x" | *"y"
+		n: null | string
+		time?: {from: string | *"now-6h", to: string}
+		type: string
+		schemaVersion: int & >=-5 & <100
+		mixed: string | int
+		#Panel: {
+			id: int
+			gridPos?: {x: int, y: int}
+		}
+		#Kind: "a" | *"b"
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateTypes(lin.First(), nil)
+	require.NoError(t, err)
+	out := string(b)
+
+	assert.Contains(t, out, "use std::collections::BTreeMap;")
+	assert.Contains(t, out, `#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Dashboard {
+    /// The dashboard's unique identifier.
+    pub id: String,
+    #[serde(default = "default_dashboard_count")]
+    pub count: u8,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub ratio: Option<f64>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub labels: Option<BTreeMap<String, i64>>,
+    #[serde(default)]
+    pub panels: Vec<Panel>,
+    #[serde(default)]
+    pub kind: Kind,
+    #[serde(default)]
+    pub mode: DashboardMode,
+    pub n: Option<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub time: Option<DashboardTime>,
+    pub r#type: String,
+    #[serde(rename = "schemaVersion")]
+    pub schema_version: i8,
+    pub mixed: DashboardMixed,
+}
+`)
+	assert.Contains(t, out, `pub struct DashboardTime {
+    #[serde(default = "default_dashboard_time_from")]
+    pub from: String,
+    pub to: String,
+}
+`)
+	assert.Contains(t, out, `#[serde(untagged)]
+pub enum DashboardMixed {
+    String(String),
+    Int(i64),
+}
+`)
+	assert.Contains(t, out, `pub struct Panel {
+    pub id: i64,
+    #[serde(rename = "gridPos", default, skip_serializing_if = "Option::is_none")]
+    pub grid_pos: Option<PanelGridPos>,
+}
+`)
+	assert.Contains(t, out, `#[derive(Debug, Clone, PartialEq, Default, Serialize, Deserialize)]
+pub enum Kind {
+    #[serde(rename = "a")]
+    A,
+    #[default]
+    #[serde(rename = "b")]
+    B,
+}
+`)
+	assert.Contains(t, out, `fn default_dashboard_time_from() -> String {
+    "now-6h".to_string()
+}
+`)
+
+	b, err = GenerateTypes(lin.First(), &Config{RootName: "Dash", Derives: []string{"Debug"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "#[derive(Debug, Serialize, Deserialize)]\npub struct Dash {")
+}
+
+func TestIdents(t *testing.T) {
+	for in, want := range map[string]string{
+		"panelId":    "panel_id",
+		"HTTPServer": "http_server",
+		"some-field": "some_field",
+		"type":       "r#type",
+		"self":       "self_",
+		"1st":        "f_1st",
+	} {
+		assert.Equal(t, want, fieldIdent(in), in)
+	}
+	for in, want := range map[string]string{
+		"panel":    "Panel",
+		"grid_pos": "GridPos",
+		"now-6h":   "Now6h",
+		"1d":       "V1d",
+	} {
+		assert.Equal(t, want, typeIdent(in), in)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "dash"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		meta: {
+			internal: string
+			owner?: string
+		}
+		panels: [...#Panel]
+		#Panel: {
+			x: int
+			legacy?: bool
+		}
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+
+	b, err := GenerateTypes(lin.First(), &Config{
+		Exclude: []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "internal")
+	assert.NotContains(t, string(b), "legacy")
+	assert.Contains(t, string(b), "owner")
+}
@@ -0,0 +1,489 @@
+// Package sqlddl generates SQL DDL for storing instances of Thema schemas in
+// relational tables.
+package sqlddl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// Dialect is a dialect of SQL for which DDL is generated.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+)
+
+// Config controls SQL DDL generation from Thema schemas.
+type Config struct {
+	// Dialect is the dialect of SQL generated. Defaults to Postgres.
+	Dialect Dialect
+
+	// Table is the name of the table. If empty, this defaults to the lineage
+	// name, in snake case.
+	Table string
+
+	// DataColumn is the name of the JSON column holding the fields of an
+	// instance not mapped to their own columns. Defaults to "data".
+	DataColumn string
+
+	// PrimaryKey is the name of a top-level field of the schema whose column
+	// is the table's primary key, if any. The field must be mapped to a
+	// column.
+	PrimaryKey string
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the table, e.g. "meta". Only top-level fields are mapped to columns, so
+	// other paths have no effect.
+	Exclude []cue.Path
+}
+
+// column is a column mapped from a top-level schema field.
+type column struct {
+	// field is the name of the schema field.
+	field string
+	name  string
+	typ   string
+	// notNull is true for required fields that may not be null.
+	notNull bool
+	// def is the SQL literal of the field's default, if any.
+	def string
+	// enum lists the SQL literals of the values of a string enum.
+	enum []string
+	// check is the name of the CHECK constraint on the values of an enum.
+	check string
+	doc   string
+}
+
+// table is the layout of a table mapped from a schema.
+type table struct {
+	// cols are the columns mapped from fields, in declaration order.
+	cols []column
+	// byField indexes cols by field name.
+	byField map[string]int
+}
+
+// GenerateTable generates a CREATE TABLE statement for a table in which
+// instances of the provided schema may be stored.
+//
+// Each top-level field of the schema with a scalar type - a string, number,
+// boolean or bytes, optionally null - is mapped to a column of the
+// corresponding SQL type, named for the field in snake case. Required fields
+// that may not be null are NOT NULL, fields with a default have a DEFAULT,
+// and string enums are constrained with a CHECK. All other fields, including
+// structs and lists, and any fields not declared by an open schema, are stored
+// together in a JSON column, named by [Config.DataColumn].
+func GenerateTable(sch thema.Schema, cfg *Config) ([]byte, error) {
+	cfg, err := normalize(sch, cfg)
+	if err != nil {
+		return nil, err
+	}
+	t, err := layout(sch, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []string
+	for _, c := range t.cols {
+		def := cfg.columnDef(c)
+		if c.doc != "" {
+			def = comment(c.doc, "  ") + "  " + def
+		} else {
+			def = "  " + def
+		}
+		defs = append(defs, def)
+	}
+	defs = append(defs, "  "+cfg.dataColumnDef())
+	if cfg.PrimaryKey != "" {
+		i, has := t.byField[cfg.PrimaryKey]
+		if !has {
+			return nil, fmt.Errorf("primary key %q is not a top-level field mapped to a column", cfg.PrimaryKey)
+		}
+		defs = append(defs, fmt.Sprintf("  PRIMARY KEY (%s)", cfg.quote(t.cols[i].name)))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "-- Table for %s schema version %s\n", sch.Lineage().Name(), sch.Version())
+	fmt.Fprintf(&buf, "CREATE TABLE %s (\n%s\n);\n", cfg.quote(cfg.Table), strings.Join(defs, ",\n"))
+	return buf.Bytes(), nil
+}
+
+// GenerateMigration generates ALTER TABLE statements that migrate a table
+// generated by [GenerateTable] for schema from to that for schema to.
+//
+// Columns are added, dropped, retyped and changed in nullability and default
+// as needed. Renames of top-level fields suggested by [thema.DiffSchemas] are
+// applied as column renames, and marked with a comment, as they should be
+// confirmed by a human. Where the dialect cannot alter a column in place, as
+// for SQLite, a comment describes the change to be made by rebuilding the
+// table.
+//
+// Only the table's structure is migrated. Migrating the data stored in it,
+// such as moving values between a column and the JSON data column, is left to
+// the lineage's lenses.
+func GenerateMigration(from, to thema.Schema, cfg *Config) ([]byte, error) {
+	cfg, err := normalize(to, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := layout(from, cfg)
+	if err != nil {
+		return nil, err
+	}
+	tt, err := layout(to, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "-- Migrate %s table from schema version %s to %s\n", from.Lineage().Name(), from.Version(), to.Version())
+	alter := fmt.Sprintf("ALTER TABLE %s", cfg.quote(cfg.Table))
+	var n int
+	stmt := func(format string, args ...any) {
+		fmt.Fprintf(&buf, "%s %s;\n", alter, fmt.Sprintf(format, args...))
+		n++
+	}
+	note := func(format string, args ...any) {
+		fmt.Fprintf(&buf, "-- %s\n", fmt.Sprintf(format, args...))
+	}
+
+	// fromCol maps the field names of columns in to, to their column in from
+	fromCol := make(map[string]column)
+	for _, c := range ft.cols {
+		if _, has := tt.byField[c.field]; has {
+			fromCol[c.field] = c
+		}
+	}
+	for _, ch := range thema.DiffSchemas(from, to).Renames() {
+		if strings.Contains(ch.Path, ".") {
+			continue
+		}
+		fi, fhas := ft.byField[ch.RenamedFrom]
+		_, thas := tt.byField[ch.Path]
+		if !fhas || !thas {
+			continue
+		}
+		fc := ft.cols[fi]
+		note("Field %s appears to have been renamed to %s; confirm before applying.", ch.RenamedFrom, ch.Path)
+		if tc := tt.cols[tt.byField[ch.Path]]; fc.name != tc.name {
+			stmt("RENAME COLUMN %s TO %s", cfg.quote(fc.name), cfg.quote(tc.name))
+		}
+		fc.name = tt.cols[tt.byField[ch.Path]].name
+		fromCol[ch.Path] = fc
+		delete(ft.byField, ch.RenamedFrom)
+	}
+
+	for _, c := range ft.cols {
+		if _, has := ft.byField[c.field]; !has {
+			continue // renamed
+		}
+		if _, has := tt.byField[c.field]; !has {
+			stmt("DROP COLUMN %s", cfg.quote(c.name))
+		}
+	}
+	for _, c := range tt.cols {
+		fc, has := fromCol[c.field]
+		if !has {
+			if c.notNull && c.def == "" {
+				note("%s is required, but has no default with which to fill existing rows. It is added", c.field)
+				note("as nullable; after populating it, make it NOT NULL.")
+				c.notNull = false
+			}
+			stmt("ADD COLUMN %s", cfg.columnDef(c))
+			continue
+		}
+		cfg.alterColumn(fc, c, stmt, note)
+	}
+
+	if n == 0 {
+		note("No changes to the table are required.")
+	}
+	return buf.Bytes(), nil
+}
+
+// alterColumn emits the statements altering column fc to c.
+func (cfg *Config) alterColumn(fc, c column, stmt, note func(format string, args ...any)) {
+	checkChanged := strings.Join(fc.enum, ",") != strings.Join(c.enum, ",")
+	if fc.typ == c.typ && fc.notNull == c.notNull && fc.def == c.def && !checkChanged {
+		return
+	}
+	col := cfg.quote(c.name)
+	switch {
+	case cfg.Dialect == SQLite:
+		note("SQLite cannot alter columns. Rebuild the table to change %s to:", col)
+		note("  %s", cfg.columnDef(c))
+		return
+	case cfg.Dialect == MySQL && (fc.typ != c.typ || fc.notNull != c.notNull || fc.def != c.def):
+		// MODIFY COLUMN restates the column in full, save for constraints
+		nc := c
+		nc.enum = nil
+		stmt("MODIFY COLUMN %s", cfg.columnDef(nc))
+	case fc.typ != c.typ:
+		stmt("ALTER COLUMN %s TYPE %s USING %s::%s", col, c.typ, col, c.typ)
+	}
+	if cfg.Dialect == Postgres && fc.notNull != c.notNull {
+		if c.notNull {
+			stmt("ALTER COLUMN %s SET NOT NULL", col)
+		} else {
+			stmt("ALTER COLUMN %s DROP NOT NULL", col)
+		}
+	}
+	if cfg.Dialect == Postgres && fc.def != c.def {
+		if c.def != "" {
+			stmt("ALTER COLUMN %s SET DEFAULT %s", col, cfg.defaultExpr(c))
+		} else {
+			stmt("ALTER COLUMN %s DROP DEFAULT", col)
+		}
+	}
+	if checkChanged {
+		if len(fc.enum) > 0 {
+			stmt("DROP CONSTRAINT %s", cfg.quote(fc.check))
+		}
+		if len(c.enum) > 0 {
+			stmt("ADD CONSTRAINT %s CHECK (%s IN (%s))", cfg.quote(c.check), col, strings.Join(c.enum, ", "))
+		}
+	}
+}
+
+// normalize returns a copy of cfg with defaults applied for sch.
+func normalize(sch thema.Schema, cfg *Config) (*Config, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	switch c.Dialect {
+	case "":
+		c.Dialect = Postgres
+	case Postgres, MySQL, SQLite:
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q; must be one of %q, %q or %q", c.Dialect, Postgres, MySQL, SQLite)
+	}
+	if c.Table == "" {
+		c.Table = snake(sch.Lineage().Name())
+	}
+	if c.DataColumn == "" {
+		c.DataColumn = "data"
+	}
+	return &c, nil
+}
+
+// layout maps the top-level fields of sch to columns.
+func layout(sch thema.Schema, cfg *Config) (*table, error) {
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	iter, err := schdef.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{byField: make(map[string]int)}
+	names := make(map[string]string)
+	for iter.Next() {
+		sel := iter.Selector()
+		field := sel.Unquoted()
+		if cuetil.ContainsPath(cfg.Exclude, cue.MakePath(sel)) {
+			continue
+		}
+		c, ok, err := cfg.mapColumn(field, iter.Value(), iter.IsOptional())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field, err)
+		}
+		if !ok {
+			continue
+		}
+		if other, has := names[c.name]; has {
+			return nil, fmt.Errorf("fields %s and %s both map to column %s", other, field, c.name)
+		}
+		if cfg.Dialect == MySQL && field == cfg.PrimaryKey && c.typ == "TEXT" {
+			// MySQL cannot index TEXT columns in full
+			c.typ = "VARCHAR(255)"
+		}
+		if c.name == cfg.DataColumn {
+			return nil, fmt.Errorf("field %s maps to column %s, which is the data column", field, c.name)
+		}
+		c.check = cfg.Table + "_" + c.name + "_check"
+		names[c.name] = field
+		t.byField[field] = len(t.cols)
+		t.cols = append(t.cols, c)
+	}
+	return t, nil
+}
+
+// mapColumn returns the column to which the field is mapped, or false if it
+// is stored in the data column.
+func (cfg *Config) mapColumn(field string, v cue.Value, optional bool) (column, bool, error) {
+	k := v.IncompleteKind()
+	nullable := k&cue.NullKind != 0
+	k &^= cue.NullKind
+
+	c := column{
+		field:   field,
+		name:    snake(field),
+		notNull: !optional && !nullable,
+		doc:     doc(v),
+	}
+	switch k {
+	case cue.StringKind:
+		c.typ = "TEXT"
+	case cue.BoolKind:
+		c.typ = "BOOLEAN"
+	case cue.IntKind:
+		c.typ = map[Dialect]string{Postgres: "BIGINT", MySQL: "BIGINT", SQLite: "INTEGER"}[cfg.Dialect]
+	case cue.FloatKind, cue.NumberKind:
+		c.typ = map[Dialect]string{Postgres: "DOUBLE PRECISION", MySQL: "DOUBLE", SQLite: "REAL"}[cfg.Dialect]
+	case cue.BytesKind:
+		c.typ = map[Dialect]string{Postgres: "BYTEA", MySQL: "BLOB", SQLite: "BLOB"}[cfg.Dialect]
+	default:
+		return c, false, nil
+	}
+
+	if d, has := v.Default(); has && d.IsConcrete() {
+		lit, err := literal(d)
+		if err != nil {
+			return c, false, err
+		}
+		c.def = lit
+	}
+	if k == cue.StringKind {
+		if op, args := v.Expr(); op == cue.OrOp {
+			for _, arg := range args {
+				if arg.IncompleteKind() == cue.NullKind {
+					continue
+				}
+				if !arg.IsConcrete() {
+					c.enum = nil
+					break
+				}
+				lit, err := literal(arg)
+				if err != nil {
+					return c, false, err
+				}
+				c.enum = append(c.enum, lit)
+			}
+			sort.Strings(c.enum)
+		}
+	}
+	return c, true, nil
+}
+
+// columnDef returns the definition of column c.
+func (cfg *Config) columnDef(c column) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", cfg.quote(c.name), c.typ)
+	if c.notNull {
+		b.WriteString(" NOT NULL")
+	}
+	if c.def != "" {
+		fmt.Fprintf(&b, " DEFAULT %s", cfg.defaultExpr(c))
+	}
+	if len(c.enum) > 0 {
+		fmt.Fprintf(&b, " CONSTRAINT %s CHECK (%s IN (%s))", cfg.quote(c.check), cfg.quote(c.name), strings.Join(c.enum, ", "))
+	}
+	return b.String()
+}
+
+// defaultExpr returns the expression for the default of column c.
+func (cfg *Config) defaultExpr(c column) string {
+	// MySQL only permits defaults for TEXT and BLOB columns as expressions
+	if cfg.Dialect == MySQL && (c.typ == "TEXT" || c.typ == "BLOB") {
+		return "(" + c.def + ")"
+	}
+	return c.def
+}
+
+// dataColumnDef returns the definition of the JSON data column.
+func (cfg *Config) dataColumnDef() string {
+	switch cfg.Dialect {
+	case MySQL:
+		return fmt.Sprintf("%s JSON NOT NULL DEFAULT (JSON_OBJECT())", cfg.quote(cfg.DataColumn))
+	case SQLite:
+		return fmt.Sprintf("%s TEXT NOT NULL DEFAULT '{}'", cfg.quote(cfg.DataColumn))
+	}
+	return fmt.Sprintf("%s JSONB NOT NULL DEFAULT '{}'", cfg.quote(cfg.DataColumn))
+}
+
+// quote quotes the identifier s.
+func (cfg *Config) quote(s string) string {
+	if cfg.Dialect == MySQL {
+		return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// literal returns the concrete value v as a SQL literal.
+func literal(v cue.Value) (string, error) {
+	switch v.Kind() {
+	case cue.StringKind:
+		s, err := v.String()
+		if err != nil {
+			return "", err
+		}
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+	case cue.BoolKind:
+		b, err := v.Bool()
+		if err != nil {
+			return "", err
+		}
+		return strings.ToUpper(strconv.FormatBool(b)), nil
+	case cue.IntKind, cue.FloatKind, cue.NumberKind:
+		return fmt.Sprint(v), nil
+	case cue.NullKind:
+		return "NULL", nil
+	}
+	return "", fmt.Errorf("default of kind %s cannot be expressed in SQL", v.Kind())
+}
+
+// doc returns the doc comments of v.
+func doc(v cue.Value) string {
+	var lines []string
+	for _, cg := range v.Doc() {
+		lines = append(lines, strings.TrimSpace(cg.Text()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// comment returns s as SQL line comments, each line prefixed with indent.
+func comment(s, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" {
+			fmt.Fprintf(&b, "%s--\n", indent)
+		} else {
+			fmt.Fprintf(&b, "%s-- %s\n", indent, line)
+		}
+	}
+	return b.String()
+}
+
+// snake converts s to snake_case.
+func snake(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			// Start a new word at lowerUpper, or at the last of UPPERLower
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") &&
+				(!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
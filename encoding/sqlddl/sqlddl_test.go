@@ -0,0 +1,149 @@
+package sqlddl
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLineage = `
+name: "dashboardPanel"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// Unique identifier.
+		uid: string
+		// The title of the panel, as shown.
+		title: string
+		count: int
+		ratio?: number
+		hidden: bool | *false
+		style: "light" | "dark" | *"dark"
+		gone: bool
+		options: {
+			legend: bool
+		}
+		tags: [...string]
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		// Unique identifier.
+		uid: string
+		// The title of the panel.
+		name: string
+		count: string
+		ratio?: number | null
+		hidden: bool | *true
+		style: "light" | "dark" | "auto" | *"auto"
+		owner: string
+		rank: int | *0
+		options: {
+			legend: bool
+		}
+		tags: [...string]
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: {uid: input.uid, title: input.name, count: 0, hidden: input.hidden, style: "dark", gone: false, options: input.options, tags: input.tags}
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {uid: input.uid, name: input.title, count: "0", hidden: input.hidden, style: input.style, owner: "", options: input.options, tags: input.tags}
+}]
+`
+
+func testLin(t *testing.T) thema.Lineage {
+	t.Helper()
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(testLineage), rt)
+	require.NoError(t, err)
+	return lin
+}
+
+func TestGenerateTable(t *testing.T) {
+	lin := testLin(t)
+
+	b, err := GenerateTable(lin.First(), &Config{PrimaryKey: "uid", Exclude: []cue.Path{cue.ParsePath("gone")}})
+	require.NoError(t, err)
+	assert.Equal(t, `-- Table for dashboardPanel schema version 0.0
+CREATE TABLE "dashboard_panel" (
+  -- Unique identifier.
+  "uid" TEXT NOT NULL,
+  -- The title of the panel, as shown.
+  "title" TEXT NOT NULL,
+  "count" BIGINT NOT NULL,
+  "ratio" DOUBLE PRECISION,
+  "hidden" BOOLEAN NOT NULL DEFAULT FALSE,
+  "style" TEXT NOT NULL DEFAULT 'dark' CONSTRAINT "dashboard_panel_style_check" CHECK ("style" IN ('dark', 'light')),
+  "data" JSONB NOT NULL DEFAULT '{}',
+  PRIMARY KEY ("uid")
+);
+`, string(b))
+
+	b, err = GenerateTable(lin.First(), &Config{Dialect: MySQL, Table: "panels", DataColumn: "rest", PrimaryKey: "uid"})
+	require.NoError(t, err)
+	assert.Equal(t, "-- Table for dashboardPanel schema version 0.0\n"+
+		"CREATE TABLE `panels` (\n"+
+		"  -- Unique identifier.\n"+
+		"  `uid` VARCHAR(255) NOT NULL,\n"+
+		"  -- The title of the panel, as shown.\n"+
+		"  `title` TEXT NOT NULL,\n"+
+		"  `count` BIGINT NOT NULL,\n"+
+		"  `ratio` DOUBLE,\n"+
+		"  `hidden` BOOLEAN NOT NULL DEFAULT FALSE,\n"+
+		"  `style` TEXT NOT NULL DEFAULT ('dark') CONSTRAINT `panels_style_check` CHECK (`style` IN ('dark', 'light')),\n"+
+		"  `gone` BOOLEAN NOT NULL,\n"+
+		"  `rest` JSON NOT NULL DEFAULT (JSON_OBJECT()),\n"+
+		"  PRIMARY KEY (`uid`)\n"+
+		");\n", string(b))
+
+	_, err = GenerateTable(lin.First(), &Config{PrimaryKey: "options"})
+	assert.Error(t, err, "fields not mapped to columns cannot be primary keys")
+	_, err = GenerateTable(lin.First(), &Config{Dialect: "oracle"})
+	assert.Error(t, err)
+}
+
+func TestGenerateMigration(t *testing.T) {
+	lin := testLin(t)
+
+	b, err := GenerateMigration(lin.First(), lin.Latest(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `-- Migrate dashboardPanel table from schema version 0.0 to 1.0
+-- Field title appears to have been renamed to name; confirm before applying.
+ALTER TABLE "dashboard_panel" RENAME COLUMN "title" TO "name";
+ALTER TABLE "dashboard_panel" DROP COLUMN "gone";
+ALTER TABLE "dashboard_panel" ALTER COLUMN "count" TYPE TEXT USING "count"::TEXT;
+ALTER TABLE "dashboard_panel" ALTER COLUMN "hidden" SET DEFAULT TRUE;
+ALTER TABLE "dashboard_panel" ALTER COLUMN "style" SET DEFAULT 'auto';
+ALTER TABLE "dashboard_panel" DROP CONSTRAINT "dashboard_panel_style_check";
+ALTER TABLE "dashboard_panel" ADD CONSTRAINT "dashboard_panel_style_check" CHECK ("style" IN ('auto', 'dark', 'light'));
+-- owner is required, but has no default with which to fill existing rows. It is added
+-- as nullable; after populating it, make it NOT NULL.
+ALTER TABLE "dashboard_panel" ADD COLUMN "owner" TEXT;
+ALTER TABLE "dashboard_panel" ADD COLUMN "rank" BIGINT NOT NULL DEFAULT 0;
+`, string(b))
+
+	b, err = GenerateMigration(lin.First(), lin.Latest(), &Config{Dialect: SQLite})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `-- SQLite cannot alter columns. Rebuild the table to change "count" to:
+--   "count" TEXT NOT NULL
+`)
+
+	b, err = GenerateMigration(lin.First(), lin.Latest(), &Config{Dialect: MySQL})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "ALTER TABLE `dashboard_panel` MODIFY COLUMN `style` TEXT NOT NULL DEFAULT ('auto');\n"+
+		"ALTER TABLE `dashboard_panel` DROP CONSTRAINT `dashboard_panel_style_check`;\n")
+
+	b, err = GenerateMigration(lin.First(), lin.First(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "-- No changes to the table are required.\n")
+}
@@ -0,0 +1,80 @@
+// Package toml provides a TOML (v1.0.0) decoder for Thema instances.
+//
+// TOML documents are decoded to the CUE data model: tables become structs and
+// arrays become lists. TOML has no null, so optional schema fields are simply
+// omitted. Offset and local date-times, dates and times have no CUE
+// equivalent, and are decoded as strings in their RFC 3339 form, e.g.
+// "1979-05-27T07:32:00Z". Infinite and NaN floats have no CUE equivalent, and
+// are rejected.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Unmarshal decodes TOML-encoded data and validates it against the provided
+// schema, exactly as if the equivalent JSON had been passed to
+// [thema.Schema.Validate].
+func Unmarshal(b []byte, sch thema.Schema) (*thema.Instance, error) {
+	v, err := Decode(sch.Underlying().Context(), b)
+	if err != nil {
+		return nil, err
+	}
+	return sch.Validate(v)
+}
+
+// Decode decodes TOML-encoded data into a [cue.Value] built by the provided
+// context, readying it for a call to [thema.Schema.Validate].
+func Decode(ctx *cue.Context, b []byte) (cue.Value, error) {
+	var m map[string]any
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return cue.Value{}, fmt.Errorf("toml: %w", err)
+	}
+	x, err := export(m)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	return ctx.Encode(x), nil
+}
+
+// export converts the date and time values in x to strings, and rejects
+// floats that CUE cannot represent.
+func export(x any) (any, error) {
+	switch v := x.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case toml.LocalDateTime:
+		return v.String(), nil
+	case toml.LocalDate:
+		return v.String(), nil
+	case toml.LocalTime:
+		return v.String(), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("toml: %v has no CUE representation", v)
+		}
+	case map[string]any:
+		for k, e := range v {
+			ex, err := export(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = ex
+		}
+	case []any:
+		for i, e := range v {
+			ex, err := export(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ex
+		}
+	}
+	return x, nil
+}
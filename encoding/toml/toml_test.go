@@ -0,0 +1,133 @@
+package toml
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, src string) (any, error) {
+	t.Helper()
+	v, err := Decode(cuecontext.New(), []byte(src))
+	if err != nil {
+		return nil, err
+	}
+	var x any
+	require.NoError(t, v.Decode(&x))
+	return x, nil
+}
+
+func TestDecode(t *testing.T) {
+	x, err := decode(t, `
+# A comment
+title = "TOML \"example\" \u00e9"
+literal = 'C:\Users\nodejs'
+multi = """
+Roses are red
+Violets are \
+    blue"""
+raw = '''
+first
+second'''
+int = +1_000
+hex = 0xDEAD_beef
+oct = 0o755
+bin = 0b1101
+neg = -17
+float = 6.626e-34
+frac = -0.01
+bools = [true, false]
+nested = [ [ 1, 2 ], ["a", 'b'], ] # trailing comma
+when = 1979-05-27 07:32:00Z
+date = 1979-05-27
+time = 07:32:00
+point = { x = 1, y.z = 2 }
+"quoted key" = 1
+site."google.com" = true
+
+[owner]
+name = "Tom"
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[servers]
+count = 2
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+
+[[products]]
+name = "Nail"
+  [products.dims]
+  len = 3
+`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"title":      `TOML "example" é`,
+		"literal":    `C:\Users\nodejs`,
+		"multi":      "Roses are red\nViolets are blue",
+		"raw":        "first\nsecond",
+		"int":        1000,
+		"hex":        0xdeadbeef,
+		"oct":        0755,
+		"bin":        13,
+		"neg":        -17,
+		"float":      6.626e-34,
+		"frac":       -0.01,
+		"bools":      []any{true, false},
+		"nested":     []any{[]any{1, 2}, []any{"a", "b"}},
+		"when":       "1979-05-27T07:32:00Z",
+		"date":       "1979-05-27",
+		"time":       "07:32:00",
+		"point":      map[string]any{"x": 1, "y": map[string]any{"z": 2}},
+		"quoted key": 1,
+		"site":       map[string]any{"google.com": true},
+		"owner":      map[string]any{"name": "Tom"},
+		"servers": map[string]any{
+			"count": 2,
+			"alpha": map[string]any{"ip": "10.0.0.1"},
+		},
+		"products": []any{
+			map[string]any{"name": "Hammer"},
+			map[string]any{},
+			map[string]any{"name": "Nail", "dims": map[string]any{"len": 3}},
+		},
+	}, x)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, src := range map[string]string{
+		"duplicate key":          "a = 1\na = 2",
+		"duplicate table":        "[a]\n[a]",
+		"table redefines key":    "a = 1\n[a]",
+		"header extends dotted":  "a.b = 1\n[a]",
+		"extend inline table":    "a = {b = 1}\n[a.c]",
+		"dotted into inline":     "a = {b = 1}\na.c = 2",
+		"array of tables clash":  "[a]\n[[a]]",
+		"missing value":          "a =",
+		"two values on a line":   "a = 1 b = 2",
+		"unterminated string":    "a = \"abc",
+		"newline in string":      "a = \"ab\nc\"",
+		"bad escape":             `a = "\q"`,
+		"leading zero":           "a = 012",
+		"bad underscore":         "a = 1__0",
+		"integer overflow":       "a = 99999999999999999999",
+		"inf":                    "a = inf",
+		"nan":                    "a = -nan",
+		"unclosed array":         "a = [1, 2",
+		"unclosed header":        "[a",
+		"bare point":             "a = .5",
+		"multi-line string key":  "\"\"\"a\"\"\" = 1",
+		"control char in string": "a = \"\x01\"",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decode(t, src)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package typescript
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/cuetsy/ts"
+	"github.com/grafana/cuetsy/ts/ast"
+)
+
+// tsAttr is the name of the attribute that customizes the TypeScript code
+// generated for a schema definition, e.g. @ts(name=PanelOptions).
+const tsAttr = "ts"
+
+var tsIdentRegexp = regexp.MustCompile("^[a-zA-Z_$][0-9a-zA-Z_$]*$")
+
+// tsRenames returns the renames requested by @ts attributes on the
+// definitions in schdef, keyed by the name of the identifier that cuetsy
+// generates for each.
+//
+// @ts(name=...) is rejected on regular fields, as the names of TypeScript
+// properties must match the JSON field names of the schema.
+func tsRenames(schdef cue.Value) (map[string]string, error) {
+	renames := make(map[string]string)
+	iter, err := schdef.Fields(cue.Definitions(true), cue.Optional(true))
+	if err != nil {
+		return renames, nil
+	}
+	for iter.Next() {
+		a := iter.Value().Attribute(tsAttr)
+		if a.Err() != nil {
+			continue
+		}
+		if !iter.Selector().IsDefinition() {
+			return nil, fmt.Errorf("%s: @ts attributes may only be declared on definitions, as TypeScript property names must match the schema's field names", iter.Value().Pos())
+		}
+		for i := 0; i < a.NumArgs(); i++ {
+			k, v := a.Arg(i)
+			if k != "name" || v == "" {
+				return nil, fmt.Errorf("%s: unknown @ts attribute argument %q; expected name=<ident>", iter.Value().Pos(), a.Contents())
+			}
+			if !tsIdentRegexp.MatchString(v) {
+				return nil, fmt.Errorf("%s: @ts(name=%s) is not a valid TypeScript identifier", iter.Value().Pos(), v)
+			}
+			// cuetsy retains the # of definitions in identifiers, and
+			// prefixes the names of their defaults
+			renames[iter.Selector().String()] = v
+			renames["default"+iter.Selector().String()] = "default" + v
+		}
+	}
+	return renames, nil
+}
+
+// renameIdents returns a copy of the provided TypeScript nodes, with every
+// identifier named in renames replaced.
+func renameIdents(nodes []ts.Decl, renames map[string]string) []ts.Decl {
+	if len(renames) == 0 {
+		return nodes
+	}
+	out := make([]ts.Decl, len(nodes))
+	for i, n := range nodes {
+		out[i] = renameValue(reflect.ValueOf(n), renames).Interface().(ts.Decl)
+	}
+	return out
+}
+
+var identType = reflect.TypeOf(ast.Ident{})
+
+// renameValue recursively copies v, renaming identifiers. The cuetsy AST is
+// built of value types, so nodes are copied rather than modified in place.
+func renameValue(v reflect.Value, renames map[string]string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(renameValue(v.Elem(), renames))
+		return nv
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.New(v.Type().Elem())
+		nv.Elem().Set(renameValue(v.Elem(), renames))
+		return nv
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			nv.Index(i).Set(renameValue(v.Index(i), renames))
+		}
+		return nv
+	case reflect.Struct:
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(v)
+		if v.Type() == identType {
+			if to, has := renames[v.Interface().(ast.Ident).Name]; has {
+				nv.FieldByName("Name").SetString(to)
+			}
+			return nv
+		}
+		for i := 0; i < nv.NumField(); i++ {
+			if f := nv.Field(i); f.CanSet() {
+				f.Set(renameValue(v.Field(i), renames))
+			}
+		}
+		return nv
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package typescript
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/cuetsy/ts"
+	"github.com/grafana/cuetsy/ts/ast"
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// fieldExcluder removes the fields at excluded paths from the TypeScript
+// generated for a schema.
+//
+// cuetsy inlines references to definitions that it does not generate a type
+// for, so the generated AST is walked alongside the schema. On entering a
+// reference to a top-level definition, paths are resolved relative to the
+// definition, such that a path like #Panel.legacy excludes the field wherever
+// the definition appears.
+type fieldExcluder struct {
+	schdef  cue.Value
+	exclude []cue.Path
+}
+
+// excludeFields returns the provided TypeScript nodes, with the fields at the
+// excluded paths removed from the declarations of the schema root, named
+// rootName, and of its top-level definitions.
+func excludeFields(nodes []ts.Decl, schdef cue.Value, rootName string, exclude []cue.Path) []ts.Decl {
+	if len(exclude) == 0 {
+		return nodes
+	}
+	fe := &fieldExcluder{schdef: schdef, exclude: exclude}
+
+	// The values and paths of the declarations, keyed by name
+	type decl struct {
+		v    cue.Value
+		path []cue.Selector
+	}
+	decls := map[string]decl{
+		rootName: {v: schdef},
+	}
+	iter, err := schdef.Fields(cue.Definitions(true))
+	if err != nil {
+		return nodes
+	}
+	for iter.Next() {
+		if sel := iter.Selector(); sel.IsDefinition() {
+			decls[sel.String()] = decl{v: iter.Value(), path: []cue.Selector{sel}}
+		}
+	}
+
+	out := make([]ts.Decl, len(nodes))
+	for i, n := range nodes {
+		out[i] = n
+		switch x := n.(type) {
+		case ast.TypeDecl:
+			d, has := decls[x.Name.Name]
+			if !has {
+				continue
+			}
+			if it, is := x.Type.(ast.InterfaceType); is {
+				it.Elems = fe.elems(it.Elems, d.v, d.path, true)
+				x.Type = it
+			} else if bt, is := x.Type.(ast.BasicType); is {
+				bt.Expr = fe.expr(bt.Expr, d.v, d.path, true)
+				x.Type = bt
+			}
+			out[i] = x
+		case ast.VarDecl:
+			if len(x.Names.Idents) != 1 {
+				continue
+			}
+			d, has := decls[strings.TrimPrefix(x.Names.Idents[0].Name, "default")]
+			if !has {
+				continue
+			}
+			x.Value = fe.expr(x.Value, d.v, d.path, true)
+			out[i] = x
+		}
+	}
+	return out
+}
+
+// expr returns e, the TypeScript generated for v, with excluded fields
+// removed. The path of v within the schema is path, if addressable is true;
+// values within lists and maps are not addressable.
+func (fe *fieldExcluder) expr(e ts.Expr, v cue.Value, path []cue.Selector, addressable bool) ts.Expr {
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		last := sels[len(sels)-1]
+		if last.IsDefinition() && fe.schdef.LookupPath(cue.MakePath(last)).Exists() {
+			path, addressable = []cue.Selector{last}, true
+		}
+	}
+
+	switch x := e.(type) {
+	case ast.ObjectLit:
+		if x.IsMap {
+			if len(x.Elems) == 1 {
+				x.Elems = []ast.KeyValueExpr{x.Elems[0]}
+				x.Elems[0].Value = fe.expr(x.Elems[0].Value, v.LookupPath(cue.MakePath(cue.AnyString)), nil, false)
+			}
+			return x
+		}
+		x.Elems = fe.elems(x.Elems, v, path, addressable)
+		return x
+	case ast.ListExpr:
+		x.Expr = fe.expr(x.Expr, listElem(v), nil, false)
+		return x
+	case ast.ListLit:
+		elems := make([]ts.Expr, len(x.Elems))
+		for i, el := range x.Elems {
+			elems[i] = fe.expr(el, v.LookupPath(cue.MakePath(cue.Index(i))), nil, false)
+		}
+		x.Elems = elems
+		return x
+	}
+	return e
+}
+
+// elems returns the properties of an object generated for the struct v, with
+// those at excluded paths removed.
+func (fe *fieldExcluder) elems(elems []ast.KeyValueExpr, v cue.Value, path []cue.Selector, addressable bool) []ast.KeyValueExpr {
+	fields := make(map[string]cue.Value)
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return elems
+	}
+	for iter.Next() {
+		fields[iter.Selector().Unquoted()] = iter.Value()
+	}
+
+	out := make([]ast.KeyValueExpr, 0, len(elems))
+	for _, kv := range elems {
+		key, is := kv.Key.(ast.Ident)
+		if !is {
+			out = append(out, kv)
+			continue
+		}
+		label := strings.TrimSuffix(key.Name, "?")
+		if ul, err := strconv.Unquote(label); err == nil {
+			label = ul
+		}
+		fv, has := fields[label]
+		if !has {
+			out = append(out, kv)
+			continue
+		}
+
+		fpath := append(append([]cue.Selector{}, path...), cue.Str(label))
+		if addressable && cuetil.ContainsPath(fe.exclude, cue.MakePath(fpath...)) {
+			continue
+		}
+		kv.Value = fe.expr(kv.Value, fv, fpath, addressable)
+		out = append(out, kv)
+	}
+	return out
+}
+
+// listElem returns the value of the elements of the list v.
+func listElem(v cue.Value) cue.Value {
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+		return elem
+	}
+	// Lists with a default, e.g. [...string] | *[], wrap the list type
+	if op, args := v.Expr(); op == cue.NoOp && len(args) == 1 {
+		return args[0].LookupPath(cue.MakePath(cue.AnyIndex))
+	}
+	return v
+}
@@ -54,10 +54,30 @@ type TypeConfig struct {
 	//
 	// No-op if Group is true.
 	RootAsType bool
+
+	// Validators causes zod (https://zod.dev) validator schemas to be generated
+	// alongside the types, allowing browser code to validate data with
+	// semantics matching those of the CUE schema. A validator is generated for
+	// each top-level definition and for the schema root, named by suffixing the
+	// name of the corresponding type with "Schema", e.g. FooSchema.
+	//
+	// Constraints without a zod equivalent are not checked by the generated
+	// validators, so data they accept may still fail validation by Thema.
+	Validators bool
+
+	// Exclude lists the paths of fields within the schema that are omitted from
+	// the generated types, defaults and validators, e.g. "meta.internal". Fields
+	// of top-level definitions are addressed through the definition, e.g.
+	// "#Panel.legacy", and are omitted wherever the definition is referenced.
+	Exclude []cue.Path
 }
 
 // GenerateTypes generates native TypeScript types and defaults corresponding to
 // the provided Schema.
+//
+// The TypeScript type generated for a definition in the schema may be renamed
+// with a @ts attribute, e.g. `#Options: {...} @ts(name=PanelOptions)`. All
+// references to the type, and its defaults, are renamed to match.
 func GenerateTypes(sch thema.Schema, cfg *TypeConfig) (*ast.File, error) {
 	if cfg == nil {
 		cfg = new(TypeConfig)
@@ -73,6 +93,10 @@ func GenerateTypes(sch thema.Schema, cfg *TypeConfig) (*ast.File, error) {
 	}
 
 	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	renames, err := tsRenames(schdef)
+	if err != nil {
+		return nil, err
+	}
 	tf, err := cuetsy.GenerateAST(schdef, *cfg.CuetsyConfig)
 	if err != nil {
 		return nil, fmt.Errorf("generating TS for child elements of schema failed: %w", err)
@@ -97,5 +121,16 @@ func GenerateTypes(sch thema.Schema, cfg *TypeConfig) (*ast.File, error) {
 		}
 	}
 
+	file.Nodes = excludeFields(file.Nodes, schdef, cfg.RootName, cfg.Exclude)
+	file.Nodes = renameIdents(file.Nodes, renames)
+
+	if cfg.Validators {
+		decls, err := generateZod(schdef, cfg.RootName, cfg.Group, renames, cfg.Exclude)
+		if err != nil {
+			return nil, err
+		}
+		file.Imports = append(file.Imports, zodImport)
+		file.Nodes = append(file.Nodes, decls...)
+	}
 	return file, nil
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"testing"
 
+	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/internal/txtartest/bindlin"
@@ -59,3 +60,126 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateAttributes(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "attrs"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		items: [...#Item]
+		kind: #Kind
+		#Item: {
+			key: string
+		} @cuetsy(kind="interface") @ts(name=Entry)
+		#Kind: "a" | *"b" @cuetsy(kind="enum") @ts(name=EntryKind)
+	}
+}]`), rt)
+	require.NoError(t, err)
+
+	f, err := GenerateTypes(lin.First(), nil)
+	require.NoError(t, err)
+	out := f.String()
+	require.Contains(t, out, "export interface Entry {")
+	require.Contains(t, out, "items: Array<Entry>;")
+	require.Contains(t, out, "export enum EntryKind {")
+	require.Contains(t, out, "export const defaultEntryKind: EntryKind = EntryKind.B;")
+	require.NotContains(t, out, "Item")
+
+	lin, err = thema.BindLineage(rt.Context().CompileString(`name: "attrs"
+schemas: [{
+	version: [0, 0]
+	schema: key: string @ts(name=Key)
+}]`), rt)
+	require.NoError(t, err)
+	_, err = GenerateTypes(lin.First(), nil)
+	require.Error(t, err)
+}
+
+func TestGenerateValidators(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "validated"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string & =~"^[a-z]+$"
+		count: uint8 | *1
+		ratio?: float & >0 & <1
+		labels?: [string]: int
+		items: [...#Item]
+		mode: "x" | "y" | *"z"
+		n: null | string
+		open: {a?: int, ...}
+		#Item: {
+			key: string & !=""
+		} @ts(name=Entry)
+	}
+}]`), rt)
+	require.NoError(t, err)
+
+	f, err := GenerateTypes(lin.First(), nil)
+	require.NoError(t, err)
+	require.NotContains(t, f.String(), "zod")
+
+	f, err = GenerateTypes(lin.First(), &TypeConfig{Validators: true})
+	require.NoError(t, err)
+	out := f.String()
+	require.Contains(t, out, "import { z } from 'zod';")
+	require.Contains(t, out, `export const EntrySchema = z.object({
+  key: z.string().refine((v) => v !== ""),
+}).strict();`)
+	require.Contains(t, out, `export const ValidatedSchema = z.object({
+  id: z.string().regex(new RegExp("^[a-z]+$")),
+  count: z.number().int().gte(0).lte(255).default(1),
+  ratio: z.number().gt(0).lt(1).optional(),
+  labels: z.record(z.string(), z.number().int()).optional(),
+  items: z.array(z.lazy(() => EntrySchema)).default([]),
+  mode: z.enum(["x", "y", "z"]).default("z"),
+  n: z.union([z.null(), z.string()]),
+  open: z.object({
+    a: z.number().int().optional(),
+  }).passthrough(),
+}).strict();`)
+}
+
+func TestGenerateExclude(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`name: "dash"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		id: string
+		meta: {
+			internal: string | *"x"
+			owner?: string
+		}
+		panels: [...#Panel]
+		#Panel: {
+			x: int
+			legacy?: bool
+		}
+	}
+}]`), rt)
+	require.NoError(t, err)
+
+	f, err := GenerateTypes(lin.First(), &TypeConfig{
+		Validators: true,
+		Exclude:    []cue.Path{cue.ParsePath("meta.internal"), cue.ParsePath("#Panel.legacy")},
+	})
+	require.NoError(t, err)
+	out := f.String()
+	require.NotContains(t, out, "internal")
+	require.NotContains(t, out, "legacy")
+	require.Contains(t, out, `export interface Dash {
+  id: string;
+  meta: {
+    owner?: string;
+  };
+  panels: Array<{
+    x: number;
+  }>;
+}`)
+	require.Contains(t, out, `export const PanelSchema = z.object({
+  x: z.number().int(),
+}).strict();`)
+}
@@ -0,0 +1,339 @@
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/cuetsy/ts"
+	"github.com/grafana/cuetsy/ts/ast"
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// maxZodDepth bounds the depth to which schemas are walked, guarding against
+// infinite expansion of recursive schemas.
+const maxZodDepth = 32
+
+// zodImport is the import statement required by generated zod validators.
+var zodImport = ast.ImportSpec{
+	Imports: ast.Idents{ts.Ident("z")},
+	From:    ast.Str{Value: "zod"},
+}
+
+// zodGen generates zod validator schemas from the values of a Thema schema.
+type zodGen struct {
+	// defs maps the selector of each top-level definition in the schema to the
+	// name of the const holding its validator.
+	defs map[string]string
+	// lvl is the nesting depth of the object being generated, for indentation.
+	lvl int
+
+	// schdef is the schema, relative to which excluded paths are resolved.
+	schdef  cue.Value
+	exclude []cue.Path
+}
+
+// zodValidatorName returns the name of the const holding the validator for
+// the TypeScript type with the provided name.
+func zodValidatorName(typeName string) string {
+	return typeName + "Schema"
+}
+
+// generateZod returns declarations of zod validators for the schema schdef:
+// one for each of its top-level definitions, followed by one for the schema
+// root, or for each of its top-level fields if group is true. Fields at the
+// excluded paths are omitted.
+func generateZod(schdef cue.Value, rootName string, group bool, renames map[string]string, exclude []cue.Path) ([]ts.Decl, error) {
+	g := &zodGen{
+		defs:    make(map[string]string),
+		schdef:  schdef,
+		exclude: exclude,
+	}
+
+	type named struct {
+		name string
+		v    cue.Value
+	}
+	var defs, roots []named
+
+	iter, err := schdef.Fields(cue.Definitions(true), cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		switch {
+		case sel.IsDefinition():
+			name := strings.TrimPrefix(sel.String(), "#")
+			if to, has := renames[sel.String()]; has {
+				name = to
+			}
+			g.defs[sel.String()] = zodValidatorName(name)
+			defs = append(defs, named{name: zodValidatorName(name), v: iter.Value()})
+		case group:
+			roots = append(roots, named{name: zodValidatorName(strings.Title(sel.Unquoted())), v: iter.Value()})
+		}
+	}
+	if !group {
+		roots = append(roots, named{name: zodValidatorName(rootName), v: schdef})
+	}
+
+	var decls []ts.Decl
+	for _, n := range append(defs, roots...) {
+		x, err := g.expr(n.v, 0)
+		if err != nil {
+			return nil, fmt.Errorf("generating zod validator %s failed: %w", n.name, err)
+		}
+		decls = append(decls, ast.Raw{Data: fmt.Sprintf("export const %s = %s;", n.name, x)})
+	}
+	return decls, nil
+}
+
+// expr returns a zod expression validating values of v.
+func (g *zodGen) expr(v cue.Value, depth int) (string, error) {
+	if depth > maxZodDepth {
+		return "z.any()", nil
+	}
+
+	// References to top-level definitions are validated lazily, so that
+	// declaration order does not matter, and recursion is possible.
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		if name, has := g.defs[sels[len(sels)-1].String()]; has {
+			return fmt.Sprintf("z.lazy(() => %s)", name), nil
+		}
+	}
+
+	x, err := g.exprNoDefault(v, depth)
+	if err != nil {
+		return "", err
+	}
+	if d, has := v.Default(); has && d.Validate(cue.Concrete(true)) == nil {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return "", err
+		}
+		x += fmt.Sprintf(".default(%s)", b)
+	}
+	return x, nil
+}
+
+func (g *zodGen) exprNoDefault(v cue.Value, depth int) (string, error) {
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		return g.union(args, depth)
+	}
+
+	switch k := v.IncompleteKind(); k {
+	case cue.StructKind:
+		return g.object(v, depth)
+	case cue.ListKind:
+		return g.list(v, depth)
+	case cue.BottomKind:
+		return "z.never()", nil
+	default:
+		if v.IsConcrete() && k&(cue.StructKind|cue.ListKind) == 0 {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			if k == cue.NullKind {
+				return "z.null()", nil
+			}
+			return fmt.Sprintf("z.literal(%s)", b), nil
+		}
+
+		var x string
+		switch k {
+		case cue.StringKind, cue.BytesKind:
+			x = "z.string()"
+		case cue.BoolKind:
+			x = "z.boolean()"
+		case cue.IntKind:
+			x = "z.number().int()"
+		case cue.FloatKind, cue.NumberKind:
+			x = "z.number()"
+		case cue.NullKind:
+			return "z.null()", nil
+		default:
+			return "z.any()", nil
+		}
+
+		return x + constraints(v, 0), nil
+	}
+}
+
+// union returns a zod expression validating values of any of the disjuncts.
+func (g *zodGen) union(disjuncts []cue.Value, depth int) (string, error) {
+	allstr := true
+	for _, d := range disjuncts {
+		if d.IncompleteKind() != cue.StringKind || !d.IsConcrete() {
+			allstr = false
+		}
+	}
+
+	var elems []string
+	for _, d := range disjuncts {
+		if allstr {
+			b, err := json.Marshal(d)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, string(b))
+			continue
+		}
+		x, err := g.expr(d, depth+1)
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, x)
+	}
+
+	switch {
+	case allstr:
+		return fmt.Sprintf("z.enum([%s])", strings.Join(elems, ", ")), nil
+	case len(elems) == 1:
+		return elems[0], nil
+	}
+	return fmt.Sprintf("z.union([%s])", strings.Join(elems, ", ")), nil
+}
+
+// constraints returns the zod refinements corresponding to the constraints
+// on the scalar v, as method calls to append to a zod expression.
+func constraints(v cue.Value, depth int) string {
+	if depth > maxZodDepth {
+		return ""
+	}
+
+	op, args := v.Expr()
+	switch op {
+	case cue.AndOp:
+		var x string
+		for _, arg := range args {
+			x += constraints(arg, depth+1)
+		}
+		return x
+	case cue.NoOp:
+		// References, such as to uint8, wrap the constraints they resolve to
+		if len(args) == 1 {
+			if aop, _ := args[0].Expr(); aop != cue.NoOp {
+				return constraints(args[0], depth+1)
+			}
+		}
+		return ""
+	}
+	return constraint(op, args)
+}
+
+// constraint returns the zod refinement corresponding to the application of
+// the CUE operator op to args, if any.
+func constraint(op cue.Op, args []cue.Value) string {
+	if len(args) != 1 || !args[0].IsConcrete() {
+		return ""
+	}
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return ""
+	}
+
+	switch op {
+	case cue.LessThanOp:
+		return fmt.Sprintf(".lt(%s)", b)
+	case cue.LessThanEqualOp:
+		return fmt.Sprintf(".lte(%s)", b)
+	case cue.GreaterThanOp:
+		return fmt.Sprintf(".gt(%s)", b)
+	case cue.GreaterThanEqualOp:
+		return fmt.Sprintf(".gte(%s)", b)
+	case cue.NotEqualOp:
+		return fmt.Sprintf(".refine((v) => v !== %s)", b)
+	case cue.RegexMatchOp:
+		return fmt.Sprintf(".regex(new RegExp(%s))", b)
+	case cue.NotRegexMatchOp:
+		return fmt.Sprintf(".refine((v) => !new RegExp(%s).test(v))", b)
+	}
+	return ""
+}
+
+// object returns a zod expression validating values of the struct v. Each
+// field is placed on its own line.
+func (g *zodGen) object(v cue.Value, depth int) (string, error) {
+	g.lvl++
+	defer func() { g.lvl-- }()
+	indent := strings.Repeat("  ", g.lvl)
+
+	var b strings.Builder
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return "", err
+	}
+	for iter.Next() {
+		if cuetil.ContainsPath(g.exclude, cuetil.RelPath(g.schdef, iter.Value())) {
+			continue
+		}
+		x, err := g.expr(iter.Value(), depth+1)
+		if err != nil {
+			return "", err
+		}
+		if iter.IsOptional() {
+			x += ".optional()"
+		}
+		key := iter.Selector().Unquoted()
+		if !tsIdentRegexp.MatchString(key) {
+			kb, err := json.Marshal(key)
+			if err != nil {
+				return "", err
+			}
+			key = string(kb)
+		}
+		fmt.Fprintf(&b, "\n%s%s: %s,", indent, key, x)
+	}
+	fields := "{}"
+	if b.Len() > 0 {
+		fields = "{" + b.String() + "\n" + indent[2:] + "}"
+	}
+
+	var pattern string
+	if elem := v.LookupPath(cue.MakePath(cue.AnyString)); elem.Exists() {
+		if pattern, err = g.expr(elem, depth+1); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case pattern == "z.any()" || (pattern == "" && v.Allows(cue.Str("_thema_undeclared_field"))):
+		// Open structs, e.g. {a: int, ...}
+		return fmt.Sprintf("z.object(%s).passthrough()", fields), nil
+	case pattern != "" && fields == "{}":
+		return fmt.Sprintf("z.record(z.string(), %s)", pattern), nil
+	case pattern != "":
+		return fmt.Sprintf("z.object(%s).catchall(%s)", fields, pattern), nil
+	}
+	return fmt.Sprintf("z.object(%s).strict()", fields), nil
+}
+
+// list returns a zod expression validating values of the list v.
+func (g *zodGen) list(v cue.Value, depth int) (string, error) {
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+		x, err := g.expr(elem, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("z.array(%s)", x), nil
+	}
+
+	var elems []string
+	iter, err := v.List()
+	if err != nil {
+		return "", err
+	}
+	for iter.Next() {
+		x, err := g.expr(iter.Value(), depth+1)
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, x)
+	}
+	return fmt.Sprintf("z.tuple([%s])", strings.Join(elems, ", ")), nil
+}
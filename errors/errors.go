@@ -28,6 +28,44 @@ const (
 	ExcessField
 )
 
+// String returns the name of the ValidationCode, e.g. "MissingField", or the
+// empty string if it is not one of the defined codes.
+func (c ValidationCode) String() string {
+	switch c {
+	case KindConflict:
+		return "KindConflict"
+	case OutOfBounds:
+		return "OutOfBounds"
+	case MissingField:
+		return "MissingField"
+	case ExcessField:
+		return "ExcessField"
+	}
+	return ""
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the ValidationCode
+// by its name.
+func (c ValidationCode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a ValidationCode
+// from its name. The empty string decodes to the zero ValidationCode.
+func (c *ValidationCode) UnmarshalText(text []byte) error {
+	for _, code := range []ValidationCode{KindConflict, OutOfBounds, MissingField, ExcessField} {
+		if code.String() == string(text) {
+			*c = code
+			return nil
+		}
+	}
+	if len(text) == 0 {
+		*c = 0
+		return nil
+	}
+	return errors.Newf("unknown validation code %q", text)
+}
+
 // ValidationError is a subtype of
 type ValidationError struct {
 	msg string
@@ -101,10 +139,23 @@ var (
 	// or more of the Thema invariants.
 	ErrInvalidLineage = errors.New("invalid lineage")
 
+	// ErrInvalidKind indicates that a provided CUE value is not a valid kind.
+	ErrInvalidKind = errors.New("invalid kind")
+
 	// ErrInvalidSchemasOrder indicates that schemas in a lineage are not ordered
 	// by version.
 	ErrInvalidSchemasOrder = errors.New("schemas in lineage are not ordered by version")
 
+	// ErrMajorNotBreaking indicates that a schema declared as a new major
+	// version is backwards compatible with its predecessor. It is a child of
+	// ErrInvalidLineage.
+	ErrMajorNotBreaking = errors.New("new major version is backwards compatible with its predecessor")
+
+	// ErrMinorNotCompatible indicates that a schema declared as a new minor
+	// version is not backwards compatible with its predecessor. It is a child
+	// of ErrInvalidLineage.
+	ErrMinorNotCompatible = errors.New("new minor version is not backwards compatible with its predecessor")
+
 	// ErrInvalidLensesOrder indicates that lenses are in the wrong order - they must be sorted by `to`, then `from`.
 	ErrInvalidLensesOrder = errors.New("lenses in lineage are not ordered by version")
 
@@ -129,4 +180,54 @@ var (
 	// ErrMalformedSyntacticVersion indicates a string input of a syntactic
 	// version was malformed.
 	ErrMalformedSyntacticVersion = errors.New("not a valid syntactic version")
+
+	// ErrMalformedVersionConstraint indicates a string input of a version
+	// constraint expression was malformed.
+	ErrMalformedVersionConstraint = errors.New("not a valid version constraint")
+
+	// ErrDuplicateLineage indicates that a lineage could not be added to a
+	// collection of lineages because another lineage with the same name was
+	// already present.
+	ErrDuplicateLineage = errors.New("lineage with the same name already present")
+
+	// ErrDefaultsRoundTrip indicates that applying and trimming schema defaults
+	// on an instance are not inverses of each other.
+	ErrDefaultsRoundTrip = errors.New("schema defaults do not round-trip")
+
+	// ErrEvalPanic indicates that CUE panicked while evaluating an operation,
+	// such as validation or translation.
+	ErrEvalPanic = errors.New("panic during CUE evaluation")
+
+	// ErrEvalTimeout indicates that an operation requiring CUE evaluation did
+	// not complete within the timeout configured for the lineage.
+	ErrEvalTimeout = errors.New("CUE evaluation timed out")
+
+	// ErrBudgetExceeded indicates that data was not validated because it
+	// exceeded the size limits configured for the lineage.
+	ErrBudgetExceeded = errors.New("data exceeds evaluation budget")
+
+	// ErrLensNotIdentity indicates that a lens could not be synthesized
+	// because translating between its schemas requires more than mapping each
+	// field to itself.
+	ErrLensNotIdentity = errors.New("schemas cannot be translated by an identity lens")
+
+	// ErrLacunaPolicyViolation indicates that a translation succeeded, but
+	// emitted lacunas that its caller's lacuna policy does not permit.
+	ErrLacunaPolicyViolation = errors.New("translation emitted lacunas forbidden by policy")
+
+	// ErrReleasedSchemaChanged indicates that a schema recorded as released in
+	// a release manifest has since been removed or changed.
+	ErrReleasedSchemaChanged = errors.New("released schema has changed")
+
+	// ErrDraftNotEnabled indicates that a draft schema was requested by a
+	// caller for which it has not been enabled.
+	ErrDraftNotEnabled = errors.New("draft schema is not enabled")
+
+	// ErrAmbiguousKind indicates that data could not be identified as an
+	// instance of a single kind, because it fits several equally well.
+	ErrAmbiguousKind = errors.New("data matches more than one kind equally well")
+
+	// ErrMergeConflict indicates that a three-way merge found fields changed
+	// differently on both sides, which it could not reconcile.
+	ErrMergeConflict = errors.New("merge has conflicting changes")
 )
@@ -0,0 +1,250 @@
+package thema
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"cuelang.org/go/cue/cuecontext"
+)
+
+// A TranslationJob is a unit of work for a [TranslationExecutor].
+type TranslationJob struct {
+	// Data is the JSON-encoded object to translate.
+	Data []byte
+
+	// From is the version of the schema of which Data is an instance.
+	From SyntacticVersion
+
+	// To is the version of the schema to which Data is translated.
+	To SyntacticVersion
+}
+
+// A TranslationJobResult is the outcome of a [TranslationJob].
+type TranslationJobResult struct {
+	Job TranslationJob
+
+	// Data is the JSON-encoded result of the translation, or nil if Err is
+	// non-nil.
+	Data []byte
+
+	Lacunas TranslationLacunas
+
+	// Warnings are the lacunas for which the executor's LacunaPolicy
+	// prescribes a warning.
+	Warnings Lacunas
+
+	Err error
+
+	// attempted lists the versions of the schemas the job was validated
+	// against or translated to, for reporting a failed job as a DeadLetter.
+	attempted []SyntacticVersion
+}
+
+// A TranslationExecutor translates objects concurrently across a pool of
+// copies of a lineage, each bound in its own cue.Context. As a cue.Context is
+// not safe for concurrent use, this is the means by which translation may be
+// parallelized.
+//
+// Because each job may be executed in any of the pool's contexts, jobs and
+// their results are exchanged as JSON, rather than as [Instance]s.
+//
+// A TranslationExecutor is safe for concurrent use. The pool is shared by all
+// concurrent calls to [TranslationExecutor.Run], bounding the total number of
+// translations in progress.
+type TranslationExecutor struct {
+	// DeadLetters, if non-nil, receives each job that fails to validate or
+	// translate, identified by its zero-based position among the jobs received
+	// by the call to Run. It must be set before the first call to Run.
+	DeadLetters DeadLetterSink
+
+	// LacunaPolicy, if non-nil, is checked against the lacunas emitted by each
+	// translation. Jobs whose lacunas the policy does not permit fail with an
+	// error wrapping [terrors.ErrLacunaPolicyViolation]. It must be set before
+	// the first call to Run.
+	LacunaPolicy *LacunaPolicy
+
+	// Progress, if non-nil, is notified as each job completes, identified as
+	// for DeadLetters. It must be set before the first call to Run, and should
+	// not be shared by concurrent calls to Run, as each reports its jobs as a
+	// separate operation.
+	Progress Progress
+
+	pool chan Lineage
+}
+
+// NewTranslationExecutor creates a [TranslationExecutor] with a pool of size
+// copies of the lineage produced by the provided factory, each bound in a new
+// [Runtime] and cue.Context with the provided options.
+func NewTranslationExecutor(factory func(*Runtime, ...BindOption) (Lineage, error), size int, opts ...BindOption) (*TranslationExecutor, error) {
+	if size < 1 {
+		return nil, errors.New("translation executor pool size must be at least one")
+	}
+
+	pool, err := newLineagePool(factory, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &TranslationExecutor{pool: pool}, nil
+}
+
+// newLineagePool returns a pool of size copies of the lineage produced by the
+// provided factory, each bound in a new [Runtime] and cue.Context.
+func newLineagePool(factory func(*Runtime, ...BindOption) (Lineage, error), size int, opts []BindOption) (chan Lineage, error) {
+	pool := make(chan Lineage, size)
+	for i := 0; i < size; i++ {
+		lin, err := factory(NewRuntime(cuecontext.New()), opts...)
+		if err != nil {
+			return nil, err
+		}
+		pool <- lin
+	}
+	return pool, nil
+}
+
+// Run translates each job received from the jobs channel, sending results on
+// the returned channel in the same order in which the jobs were received. The
+// returned channel is closed after the jobs channel is closed and all results
+// have been sent, or after ctx is done.
+//
+// Failures to translate individual jobs are reported in their results, and do
+// not stop processing of subsequent jobs.
+func (e *TranslationExecutor) Run(ctx context.Context, jobs <-chan TranslationJob) <-chan TranslationJobResult {
+	return e.run(ctx, jobs, -1)
+}
+
+// run is Run, reporting total as the number of jobs to the executor's Progress.
+func (e *TranslationExecutor) run(ctx context.Context, jobs <-chan TranslationJob, total int) <-chan TranslationJobResult {
+	prog := progressOrNop(e.Progress)
+	prog.OnStart("translate", total)
+
+	out := make(chan TranslationJobResult)
+	// Each in-progress job has a channel for its result, queued in submission
+	// order. The queue's capacity bounds how far ahead of the slowest job
+	// others may proceed.
+	pending := make(chan chan TranslationJobResult, cap(e.pool))
+
+	go func() {
+		defer close(pending)
+		for {
+			var job TranslationJob
+			select {
+			case <-ctx.Done():
+				return
+			case j, ok := <-jobs:
+				if !ok {
+					return
+				}
+				job = j
+			}
+
+			rc := make(chan TranslationJobResult, 1)
+			select {
+			case <-ctx.Done():
+				return
+			case pending <- rc:
+			}
+
+			select {
+			case <-ctx.Done():
+				rc <- TranslationJobResult{Job: job, Err: ctx.Err()}
+				return
+			case lin := <-e.pool:
+				go func() {
+					defer func() { e.pool <- lin }()
+					rc <- translateJob(lin, job, e.LacunaPolicy)
+				}()
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		defer func() { prog.OnFinish(ctx.Err()) }()
+		var seq int
+		for rc := range pending {
+			res := <-rc
+			if res.Err != nil && res.attempted != nil && e.DeadLetters != nil {
+				e.DeadLetters(DeadLetter{
+					ID:        strconv.Itoa(seq),
+					Data:      res.Job.Data,
+					Attempted: res.attempted,
+					Err:       res.Err,
+					Lacunas:   res.Lacunas,
+				})
+			}
+			prog.OnItem(strconv.Itoa(seq), res.Err)
+			seq++
+			select {
+			case <-ctx.Done():
+				return
+			case out <- res:
+			}
+		}
+	}()
+
+	return out
+}
+
+// TranslateAll runs all of the provided jobs, returning their results in the
+// same order.
+func (e *TranslationExecutor) TranslateAll(ctx context.Context, jobs []TranslationJob) ([]TranslationJobResult, error) {
+	in := make(chan TranslationJob)
+	go func() {
+		defer close(in)
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			case in <- job:
+			}
+		}
+	}()
+
+	results := make([]TranslationJobResult, 0, len(jobs))
+	for res := range e.run(ctx, in, len(jobs)) {
+		results = append(results, res)
+	}
+	if len(results) < len(jobs) {
+		return results, ctx.Err()
+	}
+	return results, nil
+}
+
+func translateJob(lin Lineage, job TranslationJob, policy *LacunaPolicy) TranslationJobResult {
+	res := TranslationJobResult{Job: job, attempted: []SyntacticVersion{}}
+	sch, err := lin.Schema(job.From)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if _, err = lin.Schema(job.To); err != nil {
+		res.Err = err
+		return res
+	}
+
+	data := lin.Runtime().Context().CompileBytes(job.Data)
+	if data.Err() != nil {
+		res.Err = data.Err()
+		return res
+	}
+	res.attempted = append(res.attempted, job.From)
+	inst, err := sch.Validate(data)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.attempted = append(res.attempted, job.To)
+	tinst, lac, err := inst.Translate(job.To)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Lacunas = lac
+	if res.Warnings, res.Err = policy.Check(lac); res.Err != nil {
+		return res
+	}
+	res.Data, res.Err = tinst.Underlying().MarshalJSON()
+	return res
+}
@@ -0,0 +1,90 @@
+package thema
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const executorLineage = `name: "exec"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	schema: name: string
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`
+
+func TestTranslationExecutor(t *testing.T) {
+	factory := func(rt *Runtime, opts ...BindOption) (Lineage, error) {
+		return BindLineage(rt.Context().CompileString(executorLineage), rt, opts...)
+	}
+	e, err := NewTranslationExecutor(factory, 4)
+	require.NoError(t, err)
+	var dead []DeadLetter
+	e.DeadLetters = func(dl DeadLetter) {
+		dead = append(dead, dl)
+	}
+
+	var jobs []TranslationJob
+	for i := 0; i < 50; i++ {
+		job := TranslationJob{
+			Data: []byte(fmt.Sprintf(`{"title": "t%d"}`, i)),
+			From: SV(0, 0),
+			To:   SV(1, 0),
+		}
+		if i%10 == 7 {
+			job.Data = []byte(`{"bad": true}`)
+		}
+		jobs = append(jobs, job)
+	}
+
+	results, err := e.TranslateAll(context.Background(), jobs)
+	require.NoError(t, err)
+	require.Len(t, results, len(jobs))
+	for i, res := range results {
+		assert.Equal(t, jobs[i], res.Job)
+		if i%10 == 7 {
+			assert.Error(t, res.Err, "job %d", i)
+			continue
+		}
+		require.NoError(t, res.Err, "job %d", i)
+		assert.JSONEq(t, fmt.Sprintf(`{"name": "t%d"}`, i), string(res.Data))
+	}
+	require.Len(t, dead, 5)
+	for i, dl := range dead {
+		assert.Equal(t, fmt.Sprint(i*10+7), dl.ID)
+		assert.Equal(t, []byte(`{"bad": true}`), dl.Data)
+		assert.Equal(t, []SyntacticVersion{SV(0, 0)}, dl.Attempted)
+		assert.Error(t, dl.Err)
+	}
+
+	res, err := e.TranslateAll(context.Background(), []TranslationJob{{Data: []byte(`{}`), From: SV(3, 0), To: SV(0, 0)}})
+	require.NoError(t, err)
+	assert.Error(t, res[0].Err)
+
+	_, err = NewTranslationExecutor(factory, 0)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = e.TranslateAll(ctx, jobs)
+	assert.Error(t, err)
+}
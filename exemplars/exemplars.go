@@ -5,9 +5,12 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"sort"
+	"sync"
 	"testing/fstest"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/load"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/internal/util"
@@ -36,6 +39,60 @@ func All(rt *thema.Runtime) map[string]thema.Lineage {
 	return all
 }
 
+// An Exemplar describes one of the exemplar lineages.
+type Exemplar struct {
+	// Name is the name of the exemplar, which is also the name of its lineage.
+	Name string
+
+	// Description summarizes the lineage behavior the exemplar exercises.
+	Description string
+}
+
+var (
+	listOnce sync.Once
+	list     []Exemplar
+)
+
+// ListExemplars returns all of the exemplars, ordered by name.
+func ListExemplars() []Exemplar {
+	listOnce.Do(func() {
+		iter, err := buildAll(cuecontext.New()).Fields(cue.Definitions(false))
+		if err != nil {
+			panic(err)
+		}
+		for iter.Next() {
+			desc, _ := iter.Value().LookupPath(cue.ParsePath("description")).String()
+			list = append(list, Exemplar{Name: iter.Selector().String(), Description: desc})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Name < list[j].Name
+		})
+	})
+	return append([]Exemplar(nil), list...)
+}
+
+// GetExemplar returns the exemplar with the provided name, or an error if no
+// such exemplar exists.
+func GetExemplar(name string) (Exemplar, error) {
+	for _, ex := range ListExemplars() {
+		if ex.Name == name {
+			return ex, nil
+		}
+	}
+	return Exemplar{}, fmt.Errorf("no exemplar exists with name %q", name)
+}
+
+// Lineage binds the exemplar's lineage against the provided [thema.Runtime],
+// with the same options as [All].
+func (ex Exemplar) Lineage(rt *thema.Runtime, o ...thema.BindOption) (thema.Lineage, error) {
+	v := buildAll(rt.Context()).LookupPath(cue.MakePath(cue.Str(ex.Name), cue.Str("l")))
+	if !v.Exists() {
+		return nil, fmt.Errorf("no exemplar exists with name %q", ex.Name)
+	}
+	opts := append(append([]thema.BindOption(nil), nameOpts[ex.Name]...), o...)
+	return thema.BindLineage(v, rt, opts...)
+}
+
 var nameOpts = map[string][]thema.BindOption{
 	"defaultchange": {thema.SkipBuggyChecks()},
 	"disjunct":      {thema.SkipBuggyChecks()},
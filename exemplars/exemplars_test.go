@@ -2,10 +2,14 @@ package exemplars
 
 import (
 	"testing"
+	"testing/fstest"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
 	"github.com/grafana/thema"
+	tload "github.com/grafana/thema/load"
 )
 
 var allctx = cuecontext.New()
@@ -36,3 +40,137 @@ func TestExemplarValidity(t *testing.T) {
 		})
 	}
 }
+
+func TestExemplarExport(t *testing.T) {
+	for name, lin := range All(allrt) {
+		lin := lin
+		t.Run(name, func(t *testing.T) {
+			f, err := lin.Export()
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Decls = append([]ast.Decl{&ast.Package{Name: ast.NewIdent("exported")}}, f.Decls...)
+			b, err := format.Node(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			modfs := fstest.MapFS{
+				"cue.mod/module.cue": &fstest.MapFile{Data: []byte(`module: "example.com/exported"`)},
+				"lineage.cue":        &fstest.MapFile{Data: b},
+			}
+			binst, err := tload.InstanceWithThema(modfs, ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			elin, err := thema.BindLineage(allctx.BuildInstance(binst), allrt, nameOpts[name]...)
+			if err != nil {
+				t.Fatalf("exported lineage failed to bind: %s\n%s", err, b)
+			}
+			if elin.Name() != lin.Name() || elin.Latest().Version() != lin.Latest().Version() {
+				t.Fatalf("exported lineage differs from original:\n%s", b)
+			}
+		})
+	}
+}
+
+func TestExemplarExportStandalone(t *testing.T) {
+	// The narrowing exemplar's lens references thema.#Lacuna, which must be
+	// vendored into the exported file.
+	lin := All(allrt)["narrowing"]
+	f, err := lin.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := format.Node(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range f.Decls {
+		if imp, is := decl.(*ast.ImportDecl); is {
+			for _, spec := range imp.Specs {
+				if spec.Path.Value == `"github.com/grafana/thema"` {
+					t.Fatalf("exported lineage still imports the Thema CUE package:\n%s", b)
+				}
+			}
+		}
+	}
+
+	ctx := cuecontext.New()
+	v := ctx.CompileBytes(b)
+	if v.Err() != nil {
+		t.Fatalf("exported lineage does not compile: %s\n%s", v.Err(), b)
+	}
+	elin, err := thema.BindLineage(v, thema.NewRuntime(ctx))
+	if err != nil {
+		t.Fatalf("exported lineage failed to bind: %s\n%s", err, b)
+	}
+
+	inst, err := elin.First().Validate(ctx.CompileString(`{ boolish: "maybe" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lac, err := inst.Translate(thema.SV(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lac.AsList()) != 1 {
+		t.Fatalf("expected one lacuna from translation, got %v", lac.AsList())
+	}
+}
+
+func TestExemplarSchemaExport(t *testing.T) {
+	for name, lin := range All(allrt) {
+		lin := lin
+		t.Run(name, func(t *testing.T) {
+			for sch := lin.First(); sch != nil; sch = sch.Successor() {
+				f, err := sch.Export()
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := format.Node(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v := cuecontext.New().CompileBytes(b); v.Err() != nil {
+					t.Fatalf("exported schema %s does not compile: %s\n%s", sch.Version(), v.Err(), b)
+				}
+			}
+		})
+	}
+}
+
+func TestListExemplars(t *testing.T) {
+	list := ListExemplars()
+	all := All(allrt)
+	if len(list) != len(all) {
+		t.Fatalf("ListExemplars returned %d exemplars, All returned %d", len(list), len(all))
+	}
+	for i, ex := range list {
+		if _, has := all[ex.Name]; !has {
+			t.Errorf("listed exemplar %q is not returned by All", ex.Name)
+		}
+		if ex.Description == "" {
+			t.Errorf("exemplar %q has no description", ex.Name)
+		}
+		if i > 0 && list[i-1].Name >= ex.Name {
+			t.Errorf("exemplars not ordered by name: %q before %q", list[i-1].Name, ex.Name)
+		}
+	}
+
+	ex, err := GetExemplar("single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin, err := ex.Lineage(thema.NewRuntime(cuecontext.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lin.Name() != "single" {
+		t.Fatalf("expected lineage named single, got %q", lin.Name())
+	}
+
+	if _, err := GetExemplar("nonexistent"); err == nil {
+		t.Fatal("expected error for nonexistent exemplar")
+	}
+}
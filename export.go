@@ -0,0 +1,355 @@
+package thema
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"github.com/cockroachdb/errors"
+
+	"github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/internal/cuetil"
+	"github.com/grafana/thema/internal/util"
+)
+
+// Export returns a single, self-contained CUE file containing the lineage's
+// declaration: its name, joinSchema, and all schemas and lenses.
+//
+// All imports of non-builtin CUE packages are inlined into the file, as are
+// references to values declared elsewhere in the package containing the
+// lineage. Conversely, the definitions from the Thema CUE package (e.g.
+// #Lineage) are stripped from the output. The Thema definitions are instead
+// supplied by the [Runtime] when the exported file is later loaded and passed
+// to [BindLineage], meaning the file can be embedded in another repository or
+// transferred elsewhere and loaded without any CUE module resolution.
+//
+// Where schemas or lenses explicitly reference Thema definitions, such as
+// thema.#Lacuna, the import of the Thema CUE package is replaced by a hidden
+// _thema field declaring the referenced definitions, vendored from the
+// Runtime. The file thus never imports the Thema CUE package, and compiles
+// with any [cue.Context].
+func (lin *baseLineage) Export() (*ast.File, error) {
+	isValidLineage(lin)
+
+	dir := loadRuntime().Dir
+
+	// The name is always concrete, but may be declared indirectly, such as
+	// through a pattern constraint, so use the evaluated value.
+	out := lin.rt.Context().CompileString("{}").FillPath(cue.MakePath(cue.Str("name")), lin.name)
+	iter, err := lin.raw.Fields(cue.All())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to iterate lineage fields")
+	}
+	for iter.Next() {
+		sel := iter.Selector()
+		if sel.PkgPath() != "" {
+			// Hidden fields are scoped to the package that declared them, and
+			// cannot be meaningfully carried into another file
+			continue
+		}
+		if sel.String() == "name" {
+			continue
+		}
+		for _, cv := range cuetil.AppendSplit(iter.Value(), cue.AndOp, nil) {
+			if isThemaFile(cv.Pos().Filename(), dir) {
+				continue
+			}
+			out = out.FillPath(cue.MakePath(sel), cv)
+		}
+	}
+
+	var f *ast.File
+	switch x := out.Syntax(
+		cue.Definitions(true),
+		cue.Docs(true),
+		cue.Optional(true),
+		cue.Attributes(true),
+		cue.InlineImports(true),
+	).(type) {
+	case *ast.File:
+		f = x
+	case *ast.StructLit:
+		f = &ast.File{Decls: x.Elts}
+	default:
+		return nil, errors.Newf("exporting lineage %s produced unexpected node type %T", lin.name, x)
+	}
+
+	astutil.SanitizeBottomLiteral(f)
+	if err := lin.vendorThemaDefs(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// vendorThemaDefs replaces any import of the Thema CUE package in f with a
+// hidden field, e.g. _thema, declaring each Thema definition that f references,
+// and each Thema definition those definitions in turn reference. References to
+// the package are rewritten to refer to the hidden field.
+func (lin *baseLineage) vendorThemaDefs(f *ast.File) error {
+	name, has := dropImport(f, "github.com/grafana/thema")
+	if !has {
+		return nil
+	}
+
+	defs := make(map[string]ast.Expr)
+	var imports []ast.Decl
+	var queue []string
+	// Hidden fields are exempt from the closedness of #Lineage.
+	field := "_" + name
+	ast.Walk(f, func(n ast.Node) bool {
+		if x, is := n.(*ast.SelectorExpr); is {
+			if id, is := x.X.(*ast.Ident); is && id.Name == name {
+				if sel, _, err := ast.LabelName(x.Sel); err == nil {
+					queue = append(queue, sel)
+				}
+				x.X = ast.NewIdent(field)
+			}
+		}
+		return true
+	}, nil)
+
+	for len(queue) > 0 {
+		def := queue[0]
+		queue = queue[1:]
+		if _, seen := defs[def]; seen {
+			continue
+		}
+		v := lin.rt.Underlying().LookupPath(cue.MakePath(cue.Def(def)))
+		if !v.Exists() {
+			return errors.Newf("lineage %s references %s.%s, which is not declared in the Thema CUE package", lin.name, name, def)
+		}
+		expr, imps := unwrapExport(v.Syntax(
+			cue.Definitions(true),
+			cue.Docs(true),
+			cue.Optional(true),
+			cue.Attributes(true),
+		))
+		defs[def] = expr
+		imports = append(imports, imps...)
+
+		// References between Thema definitions are unqualified. Nested
+		// definitions are not declared at the top level of the package, and
+		// are skipped when looked up.
+		ast.Walk(expr, func(n ast.Node) bool {
+			if id, is := n.(*ast.Ident); is && strings.HasPrefix(id.Name, "#") {
+				if _, seen := defs[id.Name]; !seen && lin.rt.Underlying().LookupPath(cue.MakePath(cue.Def(id.Name))).Exists() {
+					queue = append(queue, id.Name)
+				}
+			}
+			return true
+		}, nil)
+	}
+
+	names := make([]string, 0, len(defs))
+	for def := range defs {
+		names = append(names, def)
+	}
+	sort.Strings(names)
+	vendored := &ast.StructLit{}
+	for _, def := range names {
+		vendored.Elts = append(vendored.Elts, &ast.Field{
+			Label: ast.NewIdent(def),
+			Value: defs[def],
+		})
+	}
+	decl := &ast.Field{
+		Label: ast.NewIdent(field),
+		Value: vendored,
+	}
+	ast.AddComment(decl, &ast.CommentGroup{
+		Doc: true,
+		List: []*ast.Comment{{
+			Text: "// Definitions vendored from the Thema CUE package, github.com/grafana/thema.",
+		}},
+	})
+
+	// Imports must precede all other declarations.
+	var i int
+	for i < len(f.Decls) {
+		if _, is := f.Decls[i].(*ast.ImportDecl); !is {
+			break
+		}
+		i++
+	}
+	for _, imp := range imports {
+		for _, spec := range imp.(*ast.ImportDecl).Specs {
+			if !hasImport(f, spec) {
+				f.Decls = append(f.Decls[:i], append([]ast.Decl{&ast.ImportDecl{Specs: []*ast.ImportSpec{spec}}}, f.Decls[i:]...)...)
+				i++
+			}
+		}
+	}
+	f.Decls = append(f.Decls[:i], append([]ast.Decl{decl}, f.Decls[i:]...)...)
+	return nil
+}
+
+// dropImport removes the import of the package at path from f, returning the
+// name by which the package was referenced, and whether it was imported.
+func dropImport(f *ast.File, path string) (string, bool) {
+	for i := 0; i < len(f.Decls); i++ {
+		imp, is := f.Decls[i].(*ast.ImportDecl)
+		if !is {
+			continue
+		}
+		for j, spec := range imp.Specs {
+			if p, _ := strconv.Unquote(spec.Path.Value); p != path {
+				continue
+			}
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imp.Specs = append(imp.Specs[:j], imp.Specs[j+1:]...)
+			if len(imp.Specs) == 0 {
+				f.Decls = append(f.Decls[:i], f.Decls[i+1:]...)
+			}
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// hasImport reports whether f already imports the package imported by spec.
+func hasImport(f *ast.File, spec *ast.ImportSpec) bool {
+	for _, decl := range f.Decls {
+		if imp, is := decl.(*ast.ImportDecl); is {
+			for _, s := range imp.Specs {
+				if s.Path.Value == spec.Path.Value {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// vendoredThemaDir is the directory within a CUE module at which the Thema CUE
+// package is injected by the load package.
+var vendoredThemaDir = filepath.Join("cue.mod", "pkg", "github.com", "grafana", "thema")
+
+// isThemaFile reports whether the named file is part of the Thema CUE
+// package, either as loaded by the [Runtime] from dir, or as injected into a
+// CUE module by [github.com/grafana/thema/load.InstanceWithThema].
+func isThemaFile(filename, dir string) bool {
+	fdir := filepath.Dir(filename)
+	return fdir == dir || strings.HasSuffix(fdir, string(filepath.Separator)+vendoredThemaDir)
+}
+
+// Export flattens the schema into a standalone CUE file containing a single
+// closed definition, named for the lineage, e.g. #MyLineage for a lineage
+// named "my-lineage". All Thema lineage machinery is stripped away, and any
+// joinSchema is inlined.
+//
+// The resulting file is consumable by any tool that understands CUE, without
+// requiring any knowledge of Thema. Imports of non-builtin CUE packages are
+// inlined, such that the only imports that may remain in the file are from the
+// CUE standard library. The file has no package clause.
+func (sch *schemaDef) Export() (*ast.File, error) {
+	name := "#" + util.ToCamelCase(sch.lin.name)
+	n := sch.Underlying().LookupPath(pathSchDef).Syntax(
+		cue.Definitions(true),
+		cue.Docs(true),
+		cue.Optional(true),
+		cue.Attributes(true),
+		cue.InlineImports(true),
+	)
+
+	f := &ast.File{}
+	expr, imports := unwrapExport(n)
+	f.Decls = append(f.Decls, imports...)
+
+	field := &ast.Field{
+		Label: ast.NewIdent(name),
+		Value: expr,
+	}
+	ast.AddComment(field, &ast.CommentGroup{
+		Doc: true,
+		List: []*ast.Comment{{
+			Text: fmt.Sprintf("// %s is schema version %s of the %q Thema lineage.", name, sch.Version(), sch.lin.name),
+		}},
+	})
+	f.Decls = append(f.Decls, field)
+
+	if _, err := astutil.FmtNode(f); err != nil {
+		return nil, errors.Wrap(err, "exported schema could not be formatted")
+	}
+	return f, nil
+}
+
+// unwrapExport converts the output of a call to [cue.Value.Syntax] into a
+// single expression, and any import declarations the expression relies on.
+//
+// When exporting a definition, CUE emits a file or struct that embeds a
+// reference to a hidden definition alongside the declaration of that
+// definition. In that case, the declared value is returned directly.
+func unwrapExport(n ast.Node) (ast.Expr, []ast.Decl) {
+	var decls []ast.Decl
+	switch x := n.(type) {
+	case *ast.File:
+		decls = x.Decls
+	case *ast.StructLit:
+		decls = x.Elts
+	default:
+		return astutil.ToExpr(n), nil
+	}
+
+	var imports, rest []ast.Decl
+	var embedded string
+	for _, decl := range decls {
+		switch x := decl.(type) {
+		case *ast.ImportDecl:
+			imports = append(imports, x)
+		case *ast.EmbedDecl:
+			if id, is := x.Expr.(*ast.Ident); is {
+				embedded = id.Name
+			}
+			rest = append(rest, x)
+		default:
+			rest = append(rest, x)
+		}
+	}
+
+	if embedded != "" {
+		for i, decl := range rest {
+			field, is := decl.(*ast.Field)
+			if !is {
+				continue
+			}
+			if id, is := field.Label.(*ast.Ident); !is || id.Name != embedded {
+				continue
+			}
+			if len(rest) == 2 {
+				return field.Value, imports
+			}
+			// Let clauses declaring values the definition refers to remain
+			// alongside it, with the definition embedded in their place.
+			var elts []ast.Decl
+			for j, d := range rest {
+				switch {
+				case j == i:
+				case isEmbedOf(d, embedded):
+					elts = append(elts, &ast.EmbedDecl{Expr: field.Value})
+				default:
+					elts = append(elts, d)
+				}
+			}
+			return &ast.StructLit{Elts: elts}, imports
+		}
+	}
+
+	return astutil.ToExpr(&ast.File{Decls: rest}), imports
+}
+
+// isEmbedOf reports whether decl embeds a reference to the identifier name.
+func isEmbedOf(decl ast.Decl, name string) bool {
+	if x, is := decl.(*ast.EmbedDecl); is {
+		if id, is := x.Expr.(*ast.Ident); is {
+			return id.Name == name
+		}
+	}
+	return false
+}
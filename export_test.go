@@ -0,0 +1,117 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/internal/util"
+)
+
+func TestLineageExport(t *testing.T) {
+	lin := testLin(`import "strings"
+
+name: "exported"
+joinSchema: {
+	kind: string
+}
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: strings.MinRunes(1)
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		name: string
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: {
+		kind:  input.kind
+		title: input.name
+	}
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {
+		kind: input.kind
+		name: input.title
+	}
+	lacunas: []
+}]
+`)
+
+	f, err := lin.Export()
+	require.NoError(t, err)
+	b, err := astutil.FmtNode(f)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "#Lineage")
+
+	rt := lin.Runtime()
+	elin, err := BindLineage(rt.Context().CompileBytes(b), rt)
+	require.NoError(t, err, string(b))
+	assert.Equal(t, lin.Name(), elin.Name())
+	assert.Equal(t, lin.allVersions(), elin.allVersions())
+
+	inst, err := elin.First().Validate(rt.Context().CompileString(`{ kind: "a", title: "foo" }`))
+	require.NoError(t, err)
+	tinst, _, err := inst.Translate(SV(1, 0))
+	require.NoError(t, err)
+	name, err := tinst.Underlying().LookupPath(cue.ParsePath("name")).String()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", name)
+
+	_, err = elin.First().Validate(rt.Context().CompileString(`{ kind: "a", title: "" }`))
+	assert.Error(t, err)
+}
+
+func TestSchemaExport(t *testing.T) {
+	lin := testLin(`import "strings"
+
+name: "my-export"
+joinSchema: {
+	kind: string
+}
+schemas: [{
+	version: [0, 0]
+	schema: {
+		kind:   "x"
+		title:  strings.MinRunes(1)
+		count?: int & >0
+	}
+}]
+`)
+
+	f, err := lin.First().Export()
+	require.NoError(t, err)
+	b, err := astutil.FmtNode(f)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "_#schema")
+
+	// Compiled in a fresh context, with no knowledge of Thema.
+	v := cuecontext.New().CompileBytes(b)
+	require.NoError(t, v.Err(), string(b))
+	def := v.LookupPath(cue.MakePath(cue.Def("#" + util.ToCamelCase(lin.Name()))))
+	require.True(t, def.Exists(), "exported schema missing #MyExport definition:\n%s", b)
+
+	ctx := v.Context()
+	assert.NoError(t, def.Unify(ctx.CompileString(`{ kind: "x", title: "foo", count: 2 }`)).Validate(cue.Concrete(true)))
+	for _, bad := range []string{
+		`{ kind: "y", title: "foo" }`,
+		`{ kind: "x", title: "" }`,
+		`{ kind: "x", title: "foo", count: 0 }`,
+		`{ kind: "x", title: "foo", extra: true }`,
+	} {
+		assert.Error(t, def.Unify(ctx.CompileString(bad)).Validate(cue.Concrete(true)), bad)
+	}
+}
@@ -0,0 +1,41 @@
+package thema
+
+// A Rendering is an [Instance] as translated to a single schema in its
+// lineage, as returned from [TranslateToAll].
+type Rendering struct {
+	Version SyntacticVersion
+
+	// Instance is the translated instance, or nil if translation failed.
+	Instance *Instance
+
+	// Lacunas are the lacunas emitted by the translation.
+	Lacunas TranslationLacunas
+
+	// Err is the error returned by the translation, if any.
+	Err error
+}
+
+// TranslateToAll translates the instance to every schema in its lineage,
+// returning a [Rendering] per schema, in version order. The rendering for the
+// instance's own schema is equivalent to the instance.
+//
+// It is intended for previewing how data is seen by consumers of each
+// version of a lineage. Translations to each schema are independent, so a
+// schema that cannot be reached, such as due to a lens producing an incomplete
+// result, is reported by the Err of its rendering without affecting the
+// others.
+func TranslateToAll(inst *Instance) []Rendering {
+	inst.check()
+
+	all := inst.Schema().Lineage().All()
+	rs := make([]Rendering, 0, len(all))
+	for _, sch := range all {
+		r := Rendering{Version: sch.Version()}
+		r.Instance, r.Lacunas, r.Err = inst.Translate(sch.Version())
+		if r.Err != nil {
+			r.Instance, r.Lacunas = nil, nil
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
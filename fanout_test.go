@@ -0,0 +1,39 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateToAll(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(routelin), rt)
+	require.NoError(t, err)
+	sch, err := lin.Schema(SV(1, 0))
+	require.NoError(t, err)
+	inst, err := sch.Validate(rt.Context().CompileString(`{name: "foo", via: "bar"}`))
+	require.NoError(t, err)
+
+	rs := TranslateToAll(inst)
+	require.Len(t, rs, 4)
+	for i, want := range []struct {
+		v     SyntacticVersion
+		field string
+	}{
+		{SV(0, 0), "title"},
+		{SV(0, 1), "title"},
+		{SV(1, 0), "name"},
+		{SV(2, 0), "label"},
+	} {
+		require.NoError(t, rs[i].Err)
+		assert.Equal(t, want.v, rs[i].Version)
+		assert.Equal(t, want.v, rs[i].Instance.Schema().Version())
+		s, err := rs[i].Instance.Underlying().LookupPath(cue.ParsePath(want.field)).String()
+		require.NoError(t, err, want.v.String())
+		assert.Equal(t, "foo", s)
+	}
+}
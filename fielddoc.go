@@ -0,0 +1,162 @@
+package thema
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// deprecatedAttr is the name of the attribute marking a field as deprecated,
+// e.g. @deprecated("use title instead"). The attribute's contents, if any, are
+// the deprecation message.
+const deprecatedAttr = "deprecated"
+
+// A FieldDoc describes a single field in a schema, as needed by editors and
+// language servers to show contextual help for documents governed by a
+// lineage.
+type FieldDoc struct {
+	// Version is the version of the schema containing the field.
+	Version SyntacticVersion `json:"version"`
+
+	// Path is the path to the field, in the form accepted by
+	// [Schema.Attributes].
+	Path string `json:"path"`
+
+	// Kind is the kind of value the field accepts, e.g. "string", "struct" or
+	// "int|null".
+	Kind string `json:"kind"`
+
+	// Constraint is the CUE expression constraining the field, e.g.
+	// `*"a" | "b"`. Empty for struct fields, whose constraints are described
+	// by their own fields.
+	Constraint string `json:"constraint,omitempty"`
+
+	// Doc is the text of the comments documenting the field, if any.
+	Doc string `json:"doc,omitempty"`
+
+	// Default is the JSON form of the field's default value, or nil if it has
+	// none.
+	Default json.RawMessage `json:"default,omitempty"`
+
+	// Enum lists the JSON form of each value the field may take, if it is a
+	// disjunction of concrete values.
+	Enum []json.RawMessage `json:"enum,omitempty"`
+
+	// Optional reports whether the field may be omitted.
+	Optional bool `json:"optional"`
+
+	// Deprecated reports whether the field is deprecated, either by an
+	// @deprecated attribute or a doc comment paragraph beginning with
+	// "Deprecated:".
+	Deprecated bool `json:"deprecated"`
+
+	// DeprecationMessage explains the deprecation, if one was given.
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+
+	// Attributes are the attributes declared on the field.
+	Attributes []Attribute `json:"attributes,omitempty"`
+}
+
+// DescribeField returns documentation for the field at the provided path
+// within the schema, suitable for display by editors, for example on hover.
+//
+// An error wrapping [terrors.ErrValueNotExist] is returned if no field exists
+// at the provided path. Paths may address list elements by index, and fields
+// of maps by any key.
+func DescribeField(sch Schema, path cue.Path) (*FieldDoc, error) {
+	parts, err := fieldPathParts(path)
+	if err != nil {
+		return nil, err
+	}
+	return describeField(sch, parts)
+}
+
+// DescribeFieldPointer is as [DescribeField], but takes the path to the field
+// as a JSON Pointer (RFC 6901), e.g. "/spec/panels/0/title", as is convenient
+// when working with the JSON documents the schema governs.
+func DescribeFieldPointer(sch Schema, pointer string) (*FieldDoc, error) {
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Newf("invalid JSON pointer %q: must be empty or begin with \"/\"", pointer)
+	}
+	var parts []string
+	if pointer != "" {
+		for _, tok := range strings.Split(pointer[1:], "/") {
+			tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+			if _, err := strconv.Atoi(tok); err == nil {
+				tok = "*"
+			}
+			parts = append(parts, tok)
+		}
+	}
+	return describeField(sch, parts)
+}
+
+func describeField(sch Schema, parts []string) (*FieldDoc, error) {
+	v, optional, has := lookupField(sch.Underlying().LookupPath(pathSchDef), parts)
+	if !has {
+		return nil, errors.Mark(errors.Newf("no field %q in schema %s", strings.Join(parts, "."), sch.Version()), terrors.ErrValueNotExist)
+	}
+
+	fd := &FieldDoc{
+		Version:    sch.Version(),
+		Path:       strings.Join(parts, "."),
+		Kind:       v.IncompleteKind().String(),
+		Optional:   optional,
+		Attributes: valueAttrs(v),
+	}
+
+	if v.IncompleteKind() != cue.StructKind {
+		if b, err := format.Node(v.Syntax(cue.Raw())); err == nil {
+			fd.Constraint = string(b)
+		}
+	}
+
+	var docs []string
+	for _, cg := range v.Doc() {
+		docs = append(docs, strings.TrimSpace(cg.Text()))
+	}
+	fd.Doc = strings.Join(docs, "\n\n")
+
+	if dv, has := v.Default(); has && dv.IsConcrete() {
+		if b, err := dv.MarshalJSON(); err == nil {
+			fd.Default = b
+		}
+	}
+	if op, args := v.Expr(); op == cue.OrOp {
+		for _, arg := range args {
+			b, err := arg.MarshalJSON()
+			if !arg.IsConcrete() || err != nil {
+				fd.Enum = nil
+				break
+			}
+			fd.Enum = append(fd.Enum, b)
+		}
+	}
+
+	for _, a := range fd.Attributes {
+		if a.Name == deprecatedAttr {
+			fd.Deprecated = true
+			if msg, err := strconv.Unquote(a.Contents); err == nil {
+				fd.DeprecationMessage = msg
+			} else {
+				fd.DeprecationMessage = a.Contents
+			}
+		}
+	}
+	if !fd.Deprecated {
+		for _, para := range strings.Split(fd.Doc, "\n\n") {
+			if strings.HasPrefix(para, "Deprecated:") {
+				fd.Deprecated = true
+				fd.DeprecationMessage = strings.TrimSpace(strings.TrimPrefix(para, "Deprecated:"))
+				break
+			}
+		}
+	}
+	return fd, nil
+}
@@ -0,0 +1,81 @@
+package thema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestDescribeField(t *testing.T) {
+	lin := testLin(`name: "docs"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		// title is the title of the dashboard.
+		title: string
+		// kind of the dashboard.
+		//
+		// Deprecated: kinds are inferred.
+		kind?: *"a" | "b"
+		panels: [...{
+			id:      int
+			legend?: string @deprecated("use labels")
+		}]
+		labels: [string]: string
+		spec: {
+			n: int | *3
+		}
+	}
+}]
+`)
+	sch := lin.First()
+
+	fd, err := DescribeField(sch, cue.ParsePath("title"))
+	require.NoError(t, err)
+	assert.Equal(t, "title", fd.Path)
+	assert.Equal(t, "string", fd.Kind)
+	assert.Equal(t, "string", fd.Constraint)
+	assert.Equal(t, "title is the title of the dashboard.", fd.Doc)
+	assert.False(t, fd.Optional)
+	assert.False(t, fd.Deprecated)
+	assert.Nil(t, fd.Default)
+
+	fd, err = DescribeField(sch, cue.ParsePath("kind"))
+	require.NoError(t, err)
+	assert.True(t, fd.Optional)
+	assert.Equal(t, json.RawMessage(`"a"`), fd.Default)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`"b"`)}, fd.Enum)
+	assert.True(t, fd.Deprecated)
+	assert.Equal(t, "kinds are inferred.", fd.DeprecationMessage)
+
+	fd, err = DescribeField(sch, cue.MakePath(cue.Str("panels"), cue.Index(2), cue.Str("legend")))
+	require.NoError(t, err)
+	assert.Equal(t, "panels.*.legend", fd.Path)
+	assert.True(t, fd.Deprecated)
+	assert.Equal(t, "use labels", fd.DeprecationMessage)
+
+	fd, err = DescribeFieldPointer(sch, "/spec/n")
+	require.NoError(t, err)
+	assert.Equal(t, "int", fd.Kind)
+	assert.Equal(t, json.RawMessage(`3`), fd.Default)
+
+	fd, err = DescribeFieldPointer(sch, "/labels/some~1key")
+	require.NoError(t, err)
+	assert.Equal(t, "string", fd.Kind)
+
+	fd, err = DescribeFieldPointer(sch, "/spec")
+	require.NoError(t, err)
+	assert.Equal(t, "struct", fd.Kind)
+	assert.Empty(t, fd.Constraint)
+
+	_, err = DescribeField(sch, cue.ParsePath("nope"))
+	assert.True(t, errors.Is(err, terrors.ErrValueNotExist))
+	_, err = DescribeFieldPointer(sch, "spec")
+	assert.Error(t, err)
+}
@@ -0,0 +1,258 @@
+package thema
+
+import (
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// A FieldOrigin is the counterpart, in a single schema, of a field traced
+// through a lineage by [TraceField].
+type FieldOrigin struct {
+	FieldDoc
+
+	// Via describes how the field was found to correspond to its counterpart
+	// in the next newer schema: "lens" if it is mapped by the lens between
+	// them, or "name" if it has the same path and no lens mapping was found.
+	// Empty for the schema from which the trace began.
+	Via string `json:"via,omitempty"`
+}
+
+// TraceField answers the question of where a field came from. It returns the
+// field at the provided path within the schema, followed by its counterpart in
+// each prior schema in the lineage, newest first. The trace ends at the first
+// schema in which the field has no counterpart, i.e. just after the field was
+// introduced.
+//
+// Counterparts are found by following the mappings of the lenses between each
+// pair of schemas, such that renamed and moved fields are traced, including
+// through comprehensions over the lens input. Where a lens does not map the
+// field directly from its input, such as where a value is computed, or for
+// lenses written in Go, a field at the same path is assumed to be the
+// counterpart.
+//
+// An error wrapping [terrors.ErrValueNotExist] is returned if no field exists
+// at the provided path. Paths are as accepted by [DescribeField].
+func TraceField(sch Schema, path cue.Path) ([]FieldOrigin, error) {
+	parts, err := fieldPathParts(path)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := describeField(sch, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := []FieldOrigin{{FieldDoc: *fd}}
+	for newer, older := sch, sch.Predecessor(); older != nil; newer, older = older, older.Predecessor() {
+		var via string
+		parts, via = predecessorField(newer, older, parts)
+		if parts == nil {
+			break
+		}
+		fd, err := describeField(older, parts)
+		if err != nil {
+			break
+		}
+		trace = append(trace, FieldOrigin{FieldDoc: *fd, Via: via})
+	}
+	return trace, nil
+}
+
+// predecessorField returns the path in older of the counterpart of the field
+// at path in newer, and how it was found, or nil if it has no counterpart.
+func predecessorField(newer, older Schema, path []string) ([]string, string) {
+	olddef := older.Underlying().LookupPath(pathSchDef)
+	exists := func(p []string) bool {
+		_, _, has := lookupField(olddef, p)
+		return has
+	}
+
+	if bl, is := newer.Lineage().(*baseLineage); !is || len(bl.lensmap) == 0 {
+		lenses := newer.Lineage().Lenses()
+		// A lens to newer maps fields of older, and one from newer maps fields
+		// to older. Either may be absent.
+		for _, l := range []struct {
+			from, to SyntacticVersion
+			reverse  bool
+		}{
+			{older.Version(), newer.Version(), true},
+			{newer.Version(), older.Version(), false},
+		} {
+			mappings := lensMappings(findLens(lenses, l.from, l.to))
+			if l.reverse {
+				for i := range mappings {
+					mappings[i].src, mappings[i].dst = mappings[i].dst, mappings[i].src
+				}
+			}
+			if p := mapField(mappings, path); p != nil && exists(p) {
+				return p, "lens"
+			}
+		}
+	}
+
+	if exists(path) {
+		return path, "name"
+	}
+	return nil, ""
+}
+
+// findLens returns the CUE declaration of the lens in lenses translating from
+// the first version to the second, if any.
+func findLens(lenses []Lens, from, to SyntacticVersion) cue.Value {
+	for _, l := range lenses {
+		if l.From == from && l.To == to {
+			return l.Underlying()
+		}
+	}
+	return cue.Value{}
+}
+
+// A fieldMapping records that a lens populates the field at path dst in its
+// result directly from the field at path src in its input.
+type fieldMapping struct {
+	src, dst []string
+}
+
+// mapField returns the path to which the most specific of the mappings of a
+// field containing the one at path maps it, or nil if none do.
+func mapField(mappings []fieldMapping, path []string) []string {
+	var best *fieldMapping
+	for i, m := range mappings {
+		if len(m.src) > len(path) || strings.Join(m.src, ".") != strings.Join(path[:len(m.src)], ".") {
+			continue
+		}
+		if best == nil || len(m.src) > len(best.src) {
+			best = &mappings[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return append(best.dst[:len(best.dst):len(best.dst)], path[len(best.src):]...)
+}
+
+// lensMappings returns the fields the lens populates directly from its input.
+//
+// Mappings are found in the syntax of the lens result, rather than by
+// evaluating it, as fields declared within comprehensions cannot be evaluated
+// while the lens input is abstract.
+func lensMappings(lens cue.Value) []fieldMapping {
+	if !lens.Exists() {
+		return nil
+	}
+	var mappings []fieldMapping
+	var walk func(x ast.Expr, at []string, env map[string][]string)
+	// comprehension returns env extended with the variables bound by the
+	// clauses of c over the input.
+	comprehension := func(c *ast.Comprehension, env map[string][]string) map[string][]string {
+		for _, clause := range c.Clauses {
+			fc, is := clause.(*ast.ForClause)
+			if !is {
+				continue
+			}
+			if src := inputPath(fc.Source, env); src != nil {
+				inner := make(map[string][]string, len(env)+1)
+				for k, v := range env {
+					inner[k] = v
+				}
+				inner[fc.Value.Name] = append(src[:len(src):len(src)], "*")
+				env = inner
+			}
+		}
+		return env
+	}
+	walk = func(x ast.Expr, at []string, env map[string][]string) {
+		if src := inputPath(x, env); src != nil {
+			mappings = append(mappings, fieldMapping{src: src, dst: at})
+			return
+		}
+		switch x := x.(type) {
+		case *ast.ParenExpr:
+			walk(x.X, at, env)
+		case *ast.StructLit:
+			for _, d := range x.Elts {
+				switch d := d.(type) {
+				case *ast.Field:
+					if name, _, err := ast.LabelName(d.Label); err == nil {
+						walk(d.Value, append(at[:len(at):len(at)], name), env)
+					}
+				case *ast.Comprehension:
+					walk(d.Value, at, comprehension(d, env))
+				case *ast.EmbedDecl:
+					walk(d.Expr, at, env)
+				}
+			}
+		case *ast.ListLit:
+			for _, e := range x.Elts {
+				if c, is := e.(*ast.Comprehension); is {
+					walk(c.Value, append(at[:len(at):len(at)], "*"), comprehension(c, env))
+				} else {
+					walk(e, append(at[:len(at):len(at)], "*"), env)
+				}
+			}
+		}
+	}
+	// The result is unified with that of thema.#Lens
+	for _, part := range cuetil.AppendSplit(lens.LookupPath(cue.MakePath(cue.Str("result"))), cue.AndOp, nil) {
+		switch n := part.Source().(type) {
+		case *ast.Field:
+			walk(n.Value, []string{}, map[string][]string{"input": {}})
+		case ast.Expr:
+			walk(n, []string{}, map[string][]string{"input": {}})
+		}
+	}
+	return mappings
+}
+
+// inputPath returns the path within the lens input that x refers to, given
+// the paths to which the identifiers in env are bound, or nil if x is not such
+// a reference.
+func inputPath(x ast.Expr, env map[string][]string) []string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if p, has := env[x.Name]; has {
+			return p[:len(p):len(p)]
+		}
+	case *ast.SelectorExpr:
+		name, _, err := ast.LabelName(x.Sel)
+		if p := inputPath(x.X, env); p != nil && err == nil {
+			return append(p, name)
+		}
+	case *ast.IndexExpr:
+		lit, is := x.Index.(*ast.BasicLit)
+		p := inputPath(x.X, env)
+		if !is || p == nil {
+			return nil
+		}
+		if lit.Kind == token.INT {
+			return append(p, "*")
+		}
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			return append(p, s)
+		}
+	}
+	return nil
+}
+
+// fieldPathParts converts path to the path elements accepted by lookupField.
+func fieldPathParts(path cue.Path) ([]string, error) {
+	if err := path.Err(); err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, sel := range path.Selectors() {
+		switch sel.Type() {
+		case cue.IndexLabel:
+			parts = append(parts, "*")
+		default:
+			parts = append(parts, sel.Unquoted())
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,107 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestTraceField(t *testing.T) {
+	lin := testLin(`name: "trace"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		panels: [...{legend: bool}]
+	}
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		panels: [...{legend: bool}]
+		desc?: string
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		name: string
+		panels: [...{options: legend: bool}]
+		spec: desc?: string
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		title: input.title
+		panels: input.panels
+	}
+}, {
+	to: [0, 1]
+	from: [1, 0]
+	input: _
+	result: {
+		title: input.name
+		panels: [for p in input.panels {legend: p.options.legend}]
+		if input.spec.desc != _|_ {
+			desc: input.spec.desc
+		}
+	}
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		name: input.title
+		panels: [for p in input.panels {options: legend: p.legend}]
+		spec: {
+			if input.desc != _|_ {
+				desc: input.desc
+			}
+		}
+	}
+}]
+`)
+
+	type step struct {
+		version SyntacticVersion
+		path    string
+		via     string
+	}
+	trace := func(path string) []step {
+		t.Helper()
+		origins, err := TraceField(lin.Latest(), cue.ParsePath(path))
+		require.NoError(t, err)
+		var steps []step
+		for _, o := range origins {
+			steps = append(steps, step{o.Version, o.Path, o.Via})
+		}
+		return steps
+	}
+
+	assert.Equal(t, []step{
+		{SV(1, 0), "name", ""},
+		{SV(0, 1), "title", "lens"},
+		{SV(0, 0), "title", "lens"},
+	}, trace("name"))
+
+	assert.Equal(t, []step{
+		{SV(1, 0), "spec.desc", ""},
+		{SV(0, 1), "desc", "lens"},
+	}, trace("spec.desc"))
+
+	assert.Equal(t, []step{
+		{SV(1, 0), "panels.*.options.legend", ""},
+		{SV(0, 1), "panels.*.legend", "lens"},
+		{SV(0, 0), "panels.*.legend", "lens"},
+	}, trace("panels[0].options.legend"))
+
+	_, err := TraceField(lin.Latest(), cue.ParsePath("nope"))
+	assert.True(t, errors.Is(err, terrors.ErrValueNotExist))
+}
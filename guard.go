@@ -0,0 +1,71 @@
+package thema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// guard runs fn, converting any panic from within it to an error wrapping
+// [terrors.ErrEvalPanic], so that a pathological input cannot take down a
+// long-running process.
+//
+// guard imposes no time limit; operations bound by [EvalTimeout] check their
+// deadline between evaluations.
+func guard[T any](op string, fn func() (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero T
+			v, err = zero, errors.Mark(errors.Newf("%s: %s", op, fmt.Sprint(r)), terrors.ErrEvalPanic)
+		}
+	}()
+	return fn()
+}
+
+// EvalTimeout bounds the time that each [Schema.Validate] and
+// [Instance.Translate] performed on behalf of the bound [Lineage] may take.
+// Operations that do not complete within the timeout return an error wrapping
+// [terrors.ErrEvalTimeout].
+//
+// CUE evaluation cannot be interrupted, so the timeout is checked between the
+// evaluations that make up an operation, such as the steps of a translation
+// through several lenses, and an operation may overrun it by the duration of
+// a single evaluation. No evaluation is begun once the timeout has passed, and
+// none continues after the operation returns. The duration of each evaluation
+// of untrusted data is bounded by the [ValidationBudget] of the lineage.
+//
+// A duration less than or equal to zero disables the timeout, which is the
+// default.
+func EvalTimeout(d time.Duration) BindOption {
+	return func(c *bindConfig) {
+		c.evaltimeout = d
+	}
+}
+
+// An evalDeadline is the time by which an operation must complete, as set by
+// [EvalTimeout]. The zero evalDeadline never passes.
+type evalDeadline struct {
+	op      string
+	timeout time.Duration
+	at      time.Time
+}
+
+// deadline returns the deadline for the named operation, beginning now.
+func (lin *baseLineage) deadline(op string) evalDeadline {
+	if lin.evaltimeout <= 0 {
+		return evalDeadline{}
+	}
+	return evalDeadline{op: op, timeout: lin.evaltimeout, at: time.Now().Add(lin.evaltimeout)}
+}
+
+// check returns an error wrapping [terrors.ErrEvalTimeout] if the deadline has
+// passed.
+func (dl evalDeadline) check() error {
+	if dl.at.IsZero() || time.Now().Before(dl.at) {
+		return nil
+	}
+	return errors.Mark(errors.Newf("%s did not complete within %s", dl.op, dl.timeout), terrors.ErrEvalTimeout)
+}
@@ -0,0 +1,89 @@
+package thema
+
+import (
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestGuard(t *testing.T) {
+	_, err := guard("test", func() (int, error) {
+		var m map[string]int
+		m["boom"] = 1
+		return 0, nil
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, terrors.ErrEvalPanic), "expected ErrEvalPanic, got %s", err)
+
+	v, err := guard("test", func() (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestEvalTimeout(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	ctx := rt.Context()
+	linstr := `name: "timeout"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [1, 0]
+	schema: b: string
+}, {
+	version: [2, 0]
+	schema: c: string
+}]
+`
+	fields := []string{"a", "b", "c"}
+
+	var calls int
+	slow := func(from, to SyntacticVersion) ImperativeLens {
+		return ImperativeLens{
+			From: from,
+			To:   to,
+			Mapper: func(inst *Instance, to Schema) (*Instance, error) {
+				calls++
+				time.Sleep(20 * time.Millisecond)
+				var m map[string]string
+				if err := inst.Underlying().Decode(&m); err != nil {
+					return nil, err
+				}
+				return to.Validate(ctx.Encode(map[string]string{fields[to.Version()[0]]: m[fields[from[0]]]}))
+			},
+		}
+	}
+	lenses := ImperativeLenses(
+		slow(SV(0, 0), SV(1, 0)), slow(SV(1, 0), SV(0, 0)),
+		slow(SV(1, 0), SV(2, 0)), slow(SV(2, 0), SV(1, 0)),
+	)
+
+	lin, err := BindLineage(ctx.CompileString(linstr), rt, lenses, EvalTimeout(time.Millisecond))
+	require.NoError(t, err)
+	inst, err := lin.First().Validate(ctx.CompileString(`{a: "ok"}`))
+	require.NoError(t, err)
+
+	calls = 0
+	_, _, err = inst.Translate(SV(2, 0))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, terrors.ErrEvalTimeout), "expected ErrEvalTimeout, got %s", err)
+	assert.Equal(t, 1, calls, "expected no lens to run once the timeout passed")
+
+	lin, err = BindLineage(ctx.CompileString(linstr), rt, lenses, EvalTimeout(time.Minute))
+	require.NoError(t, err)
+	inst, err = lin.First().Validate(ctx.CompileString(`{a: "ok"}`))
+	require.NoError(t, err)
+	tinst, _, err := inst.Translate(SV(2, 0))
+	require.NoError(t, err)
+	assert.Equal(t, SV(2, 0), tinst.Schema().Version())
+	_, err = lin.First().Validate(ctx.CompileString(`{a: 1}`))
+	assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+}
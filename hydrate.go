@@ -169,10 +169,7 @@ func isCueValueEqual(inputdef cue.Value, input cue.Value) bool {
 func doDehydrate(sch, data cue.Value) (cue.Value, bool, error) {
 	// To include all optional fields, we need to use sch for iteration,
 	// since the lookuppath with optional field doesn't work very well
-	rv := sch.Context().CompileString("", cue.Filename("helper"))
-	if rv.Err() != nil {
-		return data, false, rv.Err()
-	}
+	rv := emptyStruct(sch.Context())
 
 	switch sch.IncompleteKind() {
 	case cue.StructKind:
@@ -245,6 +242,12 @@ func doDehydrate(sch, data cue.Value) (cue.Value, bool, error) {
 	}
 }
 
+// emptyStruct returns an empty struct value, without the cost of compiling
+// one from source.
+func emptyStruct(ctx *cue.Context) cue.Value {
+	return ctx.Encode(struct{}{})
+}
+
 func getBranch(sch cue.Value, data cue.Value) (cue.Value, error) {
 	op, defs := sch.Expr()
 	if op == cue.OrOp {
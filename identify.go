@@ -0,0 +1,111 @@
+package thema
+
+import (
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A KindMatch describes a lineage in a [LineageSet] against which some data
+// was identified by [LineageSet.Identify].
+type KindMatch struct {
+	// Kind is the name of the matching lineage, which is also the machine name
+	// of its [Kind], if it has one.
+	Kind string
+
+	// Instance is the data as an instance of the newest schema in the lineage
+	// that it is valid against, as returned from [SearchAndValidate].
+	Instance *Instance
+
+	// Score is the number of fields declared by the instance's schema that
+	// are absent from the data, counting nested fields only where their parent
+	// is present. Lower scores indicate a closer fit; data that sets every
+	// field its schema declares scores zero.
+	Score int
+}
+
+// Identify determines which lineage in the set some data is an instance of,
+// for programs such as ingest endpoints that accept objects of many kinds
+// and must dispatch each to the right handler. Every lineage in the set is
+// searched as by [SearchAndValidate], with the provided SearchOptions.
+//
+// Because schemas are closed, data that matches more than one lineage sets
+// only fields declared by all of them. The best match is the one with the
+// lowest [KindMatch.Score] - the schema that leaves the fewest of its fields
+// unset. All matches are also returned, ordered best first, then by kind.
+//
+// If the data matches no lineage, an error wrapping [terrors.ErrInvalidData]
+// is returned. If several lineages share the lowest score, the best match is
+// the first of them by kind, and an error wrapping [terrors.ErrAmbiguousKind]
+// is returned alongside it, so callers may choose whether to accept it.
+// Lineages in which no schema satisfies the SearchOptions are skipped; any
+// other error from searching a lineage is returned immediately.
+func (s *LineageSet) Identify(data cue.Value, opts ...SearchOption) (KindMatch, []KindMatch, error) {
+	var matches []KindMatch
+	var err error
+	s.Range(func(name string, lin Lineage) bool {
+		inst, _, serr := SearchAndValidate(lin, data, opts...)
+		switch {
+		case serr == nil:
+			matches = append(matches, KindMatch{
+				Kind:     name,
+				Instance: inst,
+				Score:    countUnsetFields(inst.Schema().Underlying().LookupPath(pathSchDef), data),
+			})
+		case errors.Is(serr, terrors.ErrInvalidData), errors.Is(serr, terrors.ErrVersionNotExist):
+		default:
+			err = errors.Wrapf(serr, "error searching lineage %s", name)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return KindMatch{}, nil, err
+	}
+	if len(matches) == 0 {
+		return KindMatch{}, nil, errors.Mark(errors.Newf("data is not valid against any of %d lineages", s.Len()), terrors.ErrInvalidData)
+	}
+
+	// Range visits lineages by name, so a stable sort keeps ties in that order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score < matches[j].Score
+	})
+
+	best := matches[0]
+	var tied []string
+	for _, m := range matches {
+		if m.Score == best.Score {
+			tied = append(tied, m.Kind)
+		}
+	}
+	if len(tied) > 1 {
+		return best, matches, errors.Mark(errors.Newf("data matches kinds %s equally well", strings.Join(tied, ", ")), terrors.ErrAmbiguousKind)
+	}
+	return best, matches, nil
+}
+
+// countUnsetFields counts the fields of the struct schema sch that are absent
+// from data, recursing into those that are present.
+func countUnsetFields(sch, data cue.Value) int {
+	if sch.IncompleteKind() != cue.StructKind {
+		return 0
+	}
+	iter, err := sch.Fields(cue.Optional(true))
+	if err != nil {
+		return 0
+	}
+	var n int
+	for iter.Next() {
+		dv := data.LookupPath(cue.MakePath(cue.Str(iter.Selector().Unquoted())))
+		if !dv.Exists() {
+			n++
+			continue
+		}
+		n += countUnsetFields(iter.Value(), dv)
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestLineageSetIdentify(t *testing.T) {
+	dashboard := testLin(`name: "dashboard"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		panels?: [...{type: string}]
+	}
+},
+{
+	version: [0, 1]
+	schema: {
+		title: string
+		panels?: [...{type: string}]
+		refresh?: string
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		title: input.title
+		if input.panels != _|_ { panels: input.panels }
+	}
+	lacunas: []
+}]
+`)
+	folder := testLin(`name: "folder"
+schemas: [{version: [0, 0], schema: {title: string, parent?: string}}]
+`)
+	playlist := testLin(`name: "playlist"
+schemas: [{version: [0, 0], schema: {title: string, interval: string}}]
+`)
+	set, err := NewLineageSet(dashboard, folder, playlist)
+	require.NoError(t, err)
+	ctx := dashboard.Runtime().Context()
+
+	t.Run("single match", func(t *testing.T) {
+		best, matches, err := set.Identify(ctx.CompileString(`{title: "t", interval: "5m"}`))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "playlist", best.Kind)
+		assert.Equal(t, 0, best.Score)
+	})
+
+	t.Run("closest fit", func(t *testing.T) {
+		best, matches, err := set.Identify(ctx.CompileString(`{title: "t", refresh: "1m"}`))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "dashboard", best.Kind)
+		assert.Equal(t, SV(0, 1), best.Instance.Schema().Version())
+		assert.Equal(t, 1, best.Score)
+
+		best, matches, err = set.Identify(ctx.CompileString(`{title: "t", parent: "root"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "folder", best.Kind)
+		assert.Len(t, matches, 1)
+	})
+
+	t.Run("scored", func(t *testing.T) {
+		best, matches, err := set.Identify(ctx.CompileString(`{title: "t"}`), Between(SV(0, 0), SV(0, 0)))
+		assert.True(t, errors.Is(err, terrors.ErrAmbiguousKind))
+		require.Len(t, matches, 2)
+		assert.Equal(t, "dashboard", best.Kind)
+		assert.Equal(t, []string{"dashboard", "folder"}, []string{matches[0].Kind, matches[1].Kind})
+		assert.Equal(t, []int{1, 1}, []int{matches[0].Score, matches[1].Score})
+
+		best, matches, err = set.Identify(ctx.CompileString(`{title: "t"}`))
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, "folder", best.Kind)
+		assert.Equal(t, 2, matches[1].Score)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, matches, err := set.Identify(ctx.CompileString(`{name: "t"}`))
+		assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+		assert.Empty(t, matches)
+	})
+}
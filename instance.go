@@ -43,6 +43,10 @@ type Instance struct {
 
 	// simple flag the prevents external creation
 	valid bool
+
+	// set in which an Instance that is not yet valid locates its lineage when
+	// unmarshaled into, as returned from LineageSet.UnmarshalTarget
+	set *LineageSet
 }
 
 func (i *Instance) check() {
@@ -55,12 +59,14 @@ func (i *Instance) check() {
 // the schema included.
 //
 // NOTE hydration implementation is a WIP. If errors are encountered, the
-// original input is returned unchanged.
+// original input is returned unchanged. For control over which defaults are
+// applied, see [Instance.ApplyDefaults].
 func (i *Instance) Hydrate() *Instance {
 	i.check()
 
-	i.sch.Lineage().Runtime()
-	ni, err := doHydrate(i.sch.Underlying(), i.raw)
+	ni, err := guard("hydration", func() (cue.Value, error) {
+		return doHydrate(i.sch.Underlying(), i.raw)
+	})
 	// FIXME For now, just no-op it if we error
 	if err != nil {
 		return i
@@ -82,7 +88,10 @@ func (i *Instance) Hydrate() *Instance {
 func (i *Instance) Dehydrate() *Instance {
 	i.check()
 
-	ni, _, err := doDehydrate(i.sch.Underlying(), i.raw)
+	ni, err := guard("dehydration", func() (cue.Value, error) {
+		ni, _, err := doDehydrate(i.sch.Underlying(), i.raw)
+		return ni, err
+	})
 	// FIXME For now, just no-op it if we error
 	if err != nil {
 		return i
@@ -201,15 +210,50 @@ func (inst *TypedInstance[T]) ValueP() T {
 // achieved in the program depending on Thema, so we avoid introducing
 // complexity into Thema that is not essential for all use cases.
 //
+// Lists whose elements are identified by a key, declared with the @key
+// attribute in the target schema, keep the order of their elements in the
+// translated instance, even where a lens rebuilds them in a different order.
+//
 // Errors only occur in cases where lenses were written in an unexpected way -
 // for example, not all fields were mapped over, and the resulting object is not
-// concrete. All errors returned from this func will children of [terrors.ErrInvalidLens].
+// concrete. All errors returned from this func will children of [terrors.ErrInvalidLens],
+// except for those wrapping [terrors.ErrEvalPanic] or [terrors.ErrEvalTimeout].
 func (i *Instance) Translate(to SyntacticVersion) (*Instance, TranslationLacunas, error) {
 	i.check()
 
-	if len(i.Schema().Lineage().(*baseLineage).lensmap) > 0 {
-		return i.translateGo(to)
+	type result struct {
+		inst *Instance
+		lac  TranslationLacunas
 	}
+	dl := i.Schema().Lineage().(*baseLineage).deadline("translation")
+	r, err := guard("translation", func() (result, error) {
+		inst, lac, err := i.translate(to, dl)
+		if err == nil {
+			err = dl.check()
+		}
+		if err == nil {
+			inst, err = alignTranslated(i, inst)
+		}
+		return result{inst: inst, lac: lac}, err
+	})
+	return r.inst, r.lac, err
+}
+
+func (i *Instance) translate(to SyntacticVersion, dl evalDeadline) (*Instance, TranslationLacunas, error) {
+	lin := i.Schema().Lineage().(*baseLineage)
+	if pc := lin.precomposed.get(lid(i.Schema().Version(), to)); pc != nil {
+		pc.hits.Add(1)
+	}
+	if len(lin.shortcuts) > 0 {
+		return i.translateRoute(to, dl)
+	}
+	if len(lin.lensmap) > 0 {
+		return i.translateGo(to, dl)
+	}
+	return i.translateCUE(to, dl)
+}
+
+func (i *Instance) translateCUE(to SyntacticVersion, dl evalDeadline) (*Instance, TranslationLacunas, error) {
 
 	// TODO define this in terms of AsSuccessor and AsPredecessor, rather than those in terms of this.
 	newsch, err := i.Schema().Lineage().Schema(to)
@@ -217,23 +261,44 @@ func (i *Instance) Translate(to SyntacticVersion) (*Instance, TranslationLacunas
 		panic(fmt.Sprintf("no schema in lineage with version %v, cannot translate", to))
 	}
 
-	out, err := cueArgs{
-		"inst": i.raw,
-		"to":   to,
-		"from": i.Schema().Version(),
-		"lin":  i.Schema().Lineage().Underlying(),
-	}.call("#Translate", i.rt())
-	if err != nil {
-		// This can't happen without a name change or an invariant violation
-		panic(err)
+	var out cue.Value
+	lin := i.Schema().Lineage().(*baseLineage)
+	if pc := lin.precomposed.get(lid(i.Schema().Version(), to)); pc != nil && pc.fn.Exists() {
+		out = pc.apply(i.raw, i.rt())
+	} else {
+		out, err = cueArgs{
+			"inst": i.raw,
+			"to":   to,
+			"from": i.Schema().Version(),
+			"lin":  lin.Underlying(),
+		}.call("#Translate", i.rt())
+		if err != nil {
+			// This can't happen without a name change or an invariant violation
+			panic(err)
+		}
 	}
 
 	if out.Err() != nil {
 		return nil, nil, errors.Mark(out.Err(), terrors.ErrInvalidLens)
 	}
 
-	lac := make(multiTranslationLacunas, 0)
-	out.LookupPath(cue.MakePath(cue.Str("lacunas"))).Decode(&lac)
+	var steps []struct {
+		To  SyntacticVersion `json:"to"`
+		Lac []Lacuna         `json:"lacunas"`
+	}
+	if err = out.LookupPath(cue.MakePath(cue.Str("steps"))).Decode(&steps); err != nil {
+		return nil, nil, errors.Mark(fmt.Errorf("lens produced invalid lacunas: %w", err), terrors.ErrInvalidLens)
+	}
+	lac := make(multiTranslationLacunas, 0, len(steps))
+	for _, step := range steps {
+		if len(step.Lac) > 0 {
+			lac = append(lac, stepLacunas{V: step.To, Lac: step.Lac})
+		}
+	}
+
+	if err := dl.check(); err != nil {
+		return nil, nil, err
+	}
 
 	// Attempt to evaluate #Translate result to remove intermediate structures created by #Translate.
 	// Otherwise, all the #Translate results are non-concrete, which leads to undesired effects.
@@ -244,6 +309,9 @@ func (i *Instance) Translate(to SyntacticVersion) (*Instance, TranslationLacunas
 	if err != nil {
 		return nil, nil, errors.Mark(fmt.Errorf("lens produced a non-concrete result: %s", cerrors.Details(err, nil)), terrors.ErrLensIncomplete)
 	}
+	if err := dl.check(); err != nil {
+		return nil, nil, err
+	}
 
 	// Ensure the result is a valid instance of the target schema
 	inst, err := newsch.Validate(raw)
@@ -253,7 +321,7 @@ func (i *Instance) Translate(to SyntacticVersion) (*Instance, TranslationLacunas
 	return inst, lac, err
 }
 
-func (i *Instance) translateGo(to SyntacticVersion) (*Instance, TranslationLacunas, error) {
+func (i *Instance) translateGo(to SyntacticVersion, dl evalDeadline) (*Instance, TranslationLacunas, error) {
 	from := i.Schema().Version()
 	if to == from {
 		// TODO make sure this mirrors the pure CUE behavior
@@ -265,6 +333,9 @@ func (i *Instance) translateGo(to SyntacticVersion) (*Instance, TranslationLacun
 	ti := new(Instance)
 	*ti = *i
 	for sch.Version() != to {
+		if err := dl.check(); err != nil {
+			return nil, nil, err
+		}
 		var nsch Schema
 		if to.Less(from) {
 			nsch = sch.Predecessor()
@@ -276,19 +347,9 @@ func (i *Instance) translateGo(to SyntacticVersion) (*Instance, TranslationLacun
 		var err error
 		if to.Less(from) || sch.Version()[0] != nsch.Version()[0] {
 			// Going backward, or crossing major version - need explicit lens
-			mlid := lid(sch.Version(), nsch.Version())
-			rti, err = lensmap[mlid].Mapper(ti, nsch)
+			rti, err = runImperativeLens(lensmap[lid(sch.Version(), nsch.Version())], ti, nsch)
 			if err != nil {
-				return nil, nil, fmt.Errorf("error executing %s migration: %w", mlid, err)
-			}
-			// Ensure that
-			//  - the returned instance exists
-			//  - the caller returned an instance of the expected schema version
-			if rti == nil {
-				return nil, nil, fmt.Errorf("lens returned a nil instance")
-			}
-			if rti.Schema().Version() != nsch.Version() {
-				return nil, nil, fmt.Errorf("lens returned an instance of the wrong schema version: expected %v, got %v", nsch.Version(), rti.Schema().Version())
+				return nil, nil, err
 			}
 		} else {
 			// going up a minor version - neither errors nor lacunas are possible
@@ -304,7 +365,24 @@ func (i *Instance) translateGo(to SyntacticVersion) (*Instance, TranslationLacun
 	return ti, nil, nil
 }
 
-type multiTranslationLacunas []struct {
+// translateTo translates the instance to sch, for operations that compare or
+// combine instances against a common schema. An error is returned if sch is
+// not in the instance's lineage. Lacunas emitted by the translation are
+// disregarded.
+func translateTo(inst *Instance, sch Schema) (*Instance, error) {
+	if sch.Lineage() != inst.Schema().Lineage() {
+		return nil, errors.Newf("schema in lineage %s cannot be used with instance of lineage %s", sch.Lineage().Name(), inst.Schema().Lineage().Name())
+	}
+	if sch.Version() == inst.Schema().Version() {
+		return inst, nil
+	}
+	tinst, _, err := inst.Translate(sch.Version())
+	return tinst, err
+}
+
+type multiTranslationLacunas []stepLacunas
+
+type stepLacunas struct {
 	V   SyntacticVersion `json:"v"`
 	Lac []Lacuna         `json:"lacunas"`
 }
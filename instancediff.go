@@ -0,0 +1,241 @@
+package thema
+
+import (
+	"reflect"
+	"sort"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+)
+
+// A ValueChangeKind classifies how a value differs between two instances.
+type ValueChangeKind string
+
+const (
+	// ValueAdded indicates a field or list element present only in the later
+	// instance.
+	ValueAdded ValueChangeKind = "added"
+	// ValueRemoved indicates a field or list element present only in the
+	// earlier instance.
+	ValueRemoved ValueChangeKind = "removed"
+	// ValueChanged indicates a field or list element whose value differs
+	// between the instances.
+	ValueChanged ValueChangeKind = "changed"
+	// ValueMoved indicates an element of a keyed list whose position relative
+	// to the other elements of the list differs between the instances.
+	ValueMoved ValueChangeKind = "moved"
+)
+
+// A ValueChange describes a difference in a single value between two
+// instances.
+type ValueChange struct {
+	Kind ValueChangeKind `json:"kind"`
+
+	// Path is the path to the value, in the syntax of a [cue.Path], e.g.
+	// "spec.panels[0].title". For removed values, this is the path in the
+	// earlier instance; otherwise, it is the path in the later instance.
+	Path string `json:"path"`
+
+	// MovedFrom is the path to the element in the earlier instance, for moved
+	// elements.
+	MovedFrom string `json:"movedFrom,omitempty"`
+
+	// From and To are the values in the earlier and later instances,
+	// respectively, decoded as by [cue.Value.Decode] into an any. Neither is
+	// set for moved elements, whose changes, if any, are reported separately.
+	From any `json:"from,omitempty"`
+	To   any `json:"to,omitempty"`
+}
+
+// DiffInstances reports the differences between the data in two instances,
+// relative to the provided schema, for audit trails and reviews of edits to a
+// single object.
+//
+// Both instances are first translated to sch, and every default declared in
+// sch is applied to them, as by [Instance.ApplyDefaults] with all options set.
+// A field omitted from one instance is therefore equal to the same field set
+// to its default value in the other, and a field changed from its default is
+// reported as changed from the default value, rather than as added. If sch is
+// nil, the schema of the later instance is used.
+//
+// Structs are compared field by field, with changes ordered by field name.
+// Lists are compared element by element, by index, unless the list field is
+// annotated with @key(<field>) in sch. Elements of such lists are matched by
+// the value of that field, so that reordering the list is reported as the
+// minimal set of moved elements, rather than a change to every element whose
+// index changed.
+//
+// An error is returned if either instance cannot be translated to sch.
+func DiffInstances(from, to *Instance, sch Schema) ([]ValueChange, error) {
+	from.check()
+	to.check()
+	if sch == nil {
+		sch = to.Schema()
+	}
+
+	var data [2]any
+	for i, inst := range []*Instance{from, to} {
+		inst, err := translateTo(inst, sch)
+		if err != nil {
+			return nil, err
+		}
+		if inst, err = inst.ApplyDefaults(ApplyDefaultsOpts{Optional: true, Lists: true}); err != nil {
+			return nil, err
+		}
+		if err := inst.Underlying().Decode(&data[i]); err != nil {
+			return nil, errors.Wrap(err, "unable to decode instance for diff")
+		}
+	}
+
+	var changes []ValueChange
+	diffValues(&changes, sch.Underlying().LookupPath(pathSchDef), nil, nil, data[0], data[1])
+	return changes, nil
+}
+
+func diffValues(changes *[]ValueChange, sch cue.Value, apath, bpath []cue.Selector, a, b any) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	add := func(kind ValueChangeKind, path []cue.Selector, from, to any) {
+		*changes = append(*changes, ValueChange{Kind: kind, Path: cue.MakePath(path...).String(), From: from, To: to})
+	}
+
+	switch am := a.(type) {
+	case map[string]any:
+		bm, is := b.(map[string]any)
+		if !is {
+			break
+		}
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, has := am[k]; !has {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, ahas := am[k]
+			bv, bhas := bm[k]
+			ap, bp := append(apath, cue.Str(k)), append(bpath, cue.Str(k))
+			switch {
+			case !bhas:
+				add(ValueRemoved, ap, av, nil)
+			case !ahas:
+				add(ValueAdded, bp, nil, bv)
+			default:
+				fsch, _, _ := lookupField(sch, []string{k})
+				diffValues(changes, fsch, ap, bp, av, bv)
+			}
+		}
+		return
+	case []any:
+		bl, is := b.([]any)
+		if !is {
+			break
+		}
+		esch, _, _ := lookupField(sch, []string{"*"})
+		if key := listKey(sch); key != "" && diffKeyed(changes, esch, apath, bpath, key, am, bl) {
+			return
+		}
+		for i := 0; i < len(am) || i < len(bl); i++ {
+			ap, bp := append(apath, cue.Index(i)), append(bpath, cue.Index(i))
+			switch {
+			case i >= len(bl):
+				add(ValueRemoved, ap, am[i], nil)
+			case i >= len(am):
+				add(ValueAdded, bp, nil, bl[i])
+			default:
+				diffValues(changes, esch, ap, bp, am[i], bl[i])
+			}
+		}
+		return
+	}
+	add(ValueChanged, bpath, a, b)
+}
+
+// diffKeyed compares lists of structs whose elements are identified by the
+// value of their key field. Of the elements in both lists, those outside a
+// longest common subsequence of the two orderings are reported as moved. It
+// returns false if the elements of either list cannot be identified, as by
+// keyedElems.
+func diffKeyed(changes *[]ValueChange, esch cue.Value, apath, bpath []cue.Selector, key string, a, b []any) bool {
+	aids, aindex, aok := keyedElems(a, key)
+	bids, bindex, bok := keyedElems(b, key)
+	if !aok || !bok {
+		return false
+	}
+
+	var acommon, bcommon []string
+	for _, id := range aids {
+		if _, has := bindex[id]; has {
+			acommon = append(acommon, id)
+		}
+	}
+	for _, id := range bids {
+		if _, has := aindex[id]; has {
+			bcommon = append(bcommon, id)
+		}
+	}
+	stay := lcsIDs(acommon, bcommon)
+
+	for _, id := range aids {
+		if _, has := bindex[id]; !has {
+			i := aindex[id]
+			*changes = append(*changes, ValueChange{Kind: ValueRemoved, Path: cue.MakePath(append(apath, cue.Index(i))...).String(), From: a[i]})
+		}
+	}
+	for _, id := range bids {
+		j := bindex[id]
+		bp := append(bpath, cue.Index(j))
+		i, has := aindex[id]
+		if !has {
+			*changes = append(*changes, ValueChange{Kind: ValueAdded, Path: cue.MakePath(bp...).String(), To: b[j]})
+			continue
+		}
+		ap := append(apath, cue.Index(i))
+		if !stay[id] {
+			*changes = append(*changes, ValueChange{Kind: ValueMoved, Path: cue.MakePath(bp...).String(), MovedFrom: cue.MakePath(ap...).String()})
+		}
+		diffValues(changes, esch, ap, bp, a[i], b[j])
+	}
+	return true
+}
+
+// lcsIDs returns the set of identities in a longest common subsequence of a
+// and b, each of which contains distinct identities.
+func lcsIDs(a, b []string) map[string]bool {
+	n, m := len(a), len(b)
+	lens := make([][]int, n+1)
+	for i := range lens {
+		lens[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lens[i][j] = lens[i+1][j+1] + 1
+			} else if lens[i+1][j] >= lens[i][j+1] {
+				lens[i][j] = lens[i+1][j]
+			} else {
+				lens[i][j] = lens[i][j+1]
+			}
+		}
+	}
+
+	stay := make(map[string]bool, lens[0][0])
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			stay[a[i]] = true
+			i++
+			j++
+		case lens[i+1][j] >= lens[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return stay
+}
@@ -0,0 +1,111 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffInstances(t *testing.T) {
+	lin := testLin(`name: "diff"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		tags?: [...string]
+		panels?: [...{id: int, title: string}] @key(id)
+	}
+}]
+`)
+	validate := func(data string) *Instance {
+		inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(data))
+		require.NoError(t, err)
+		return inst
+	}
+
+	from := validate(`{
+	title: "a"
+	tags: ["x", "y"]
+	panels: [{id: 1, title: "one"}, {id: 2, title: "two"}, {id: 3, title: "three"}, {id: 4, title: "four"}]
+}`)
+	changes, err := DiffInstances(from, from, nil)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	to := validate(`{
+	title: "b"
+	tags: ["y"]
+	panels: [{id: 2, title: "two"}, {id: 3, title: "drei"}, {id: 1, title: "one"}, {id: 5, title: "five"}]
+}`)
+	changes, err = DiffInstances(from, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []ValueChange{
+		{Kind: ValueRemoved, Path: "panels[3]", From: map[string]any{"id": 4, "title": "four"}},
+		{Kind: ValueChanged, Path: "panels[1].title", From: "three", To: "drei"},
+		{Kind: ValueMoved, Path: "panels[2]", MovedFrom: "panels[0]"},
+		{Kind: ValueAdded, Path: "panels[3]", To: map[string]any{"id": 5, "title": "five"}},
+		{Kind: ValueChanged, Path: "tags[0]", From: "x", To: "y"},
+		{Kind: ValueRemoved, Path: "tags[1]", From: "y"},
+		{Kind: ValueChanged, Path: "title", From: "a", To: "b"},
+	}, changes)
+}
+
+func TestDiffInstancesDefaults(t *testing.T) {
+	lin := testLin(`name: "defaults"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title:    string
+		hidden:   bool | *false
+		refresh?: string | *"1m"
+		note?:    string
+	}
+},
+{
+	version: [0, 1]
+	schema: {
+		title:    string
+		hidden:   bool | *false
+		refresh?: string | *"1m"
+		note?:    string
+		style?:   string | *"dark"
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		title: input.title
+		hidden: input.hidden
+		if input.refresh != _|_ { refresh: input.refresh }
+		if input.note != _|_ { note: input.note }
+	}
+	lacunas: []
+}]
+`)
+	ctx := lin.Runtime().Context()
+	validate := func(v SyntacticVersion, data string) *Instance {
+		inst, err := SchemaP(lin, v).Validate(ctx.CompileString(data))
+		require.NoError(t, err)
+		return inst
+	}
+
+	from := validate(SV(0, 0), `{title: "a"}`)
+	changes, err := DiffInstances(from, validate(SV(0, 0), `{title: "a", hidden: false, refresh: "1m"}`), nil)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "omitted fields must equal their defaults")
+
+	changes, err = DiffInstances(from, validate(SV(0, 1), `{title: "a", refresh: "5m", note: "n", style: "dark"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []ValueChange{
+		{Kind: ValueAdded, Path: "note", To: "n"},
+		{Kind: ValueChanged, Path: "refresh", From: "1m", To: "5m"},
+	}, changes)
+
+	// Compared against 0.0, the field added in 0.1 is not seen
+	changes, err = DiffInstances(from, validate(SV(0, 1), `{title: "a", hidden: false, style: "light"}`), lin.First())
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
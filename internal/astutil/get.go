@@ -153,17 +153,17 @@ func Format(v cue.Value) ast.Node {
 		cue.Docs(true),
 	)
 
-	sanitizeBottomLiteral(n)
+	SanitizeBottomLiteral(n)
 	return n
 }
 
-// Removes the comment that the CUE internal exporter adds on bottom literals,
-// which can cause format.Node to produce invalid CUE. This seems to only happen
-// because the CUE compiler injects these comments on a bottom when it's a
-// literal in the source
+// SanitizeBottomLiteral removes the comment that the CUE internal exporter adds
+// on bottom literals, which can cause format.Node to produce invalid CUE. This
+// seems to only happen because the CUE compiler injects these comments on a
+// bottom when it's a literal in the source
 //
 // TODO file a bug upstream, we shouldn't have to do this
-func sanitizeBottomLiteral(n ast.Node) {
+func SanitizeBottomLiteral(n ast.Node) {
 	ast.Walk(n, func(n ast.Node) bool {
 		if x, ok := n.(*ast.BottomLit); ok {
 			x.SetComments(nil)
@@ -0,0 +1,221 @@
+// Package columnar maps Thema schemas onto the nested, typed fields of
+// columnar and analytics formats, such as BigQuery and Parquet.
+package columnar
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/cuetil"
+)
+
+// Type is the type of a [Field].
+type Type int
+
+const (
+	// JSON is the type of values with no columnar equivalent, such as
+	// disjunctions of several kinds, tuples and unconstrained values. Such
+	// values are stored as JSON text.
+	JSON Type = iota
+	String
+	Int
+	Float
+	Bool
+	Bytes
+	// Record is the type of structs. Their fields are given by Field.Fields.
+	Record
+	// List is the type of lists. Their elements are described by Field.Elem.
+	List
+	// Map is the type of structs constrained only by a pattern, e.g.
+	// [string]: int, whose keys are strings. Their values are described by
+	// Field.Elem.
+	Map
+)
+
+// maxDepth bounds the depth to which schemas are walked, guarding against
+// infinite expansion of recursive schemas. Values deeper than this are
+// treated as JSON.
+const maxDepth = 32
+
+// Field is a field of a schema, or the element of a list or map.
+type Field struct {
+	// Name is the label of the field. It is empty for elements.
+	Name string
+	// Doc is the text of the field's doc comments.
+	Doc string
+	// Type is the type of the field's values.
+	Type Type
+	// Nullable is true for fields that are optional or may be null.
+	Nullable bool
+	// Fields are the fields of a Record.
+	Fields []*Field
+	// Elem describes the elements of a List, or the values of a Map.
+	Elem *Field
+}
+
+// Fields returns the fields of the root of the provided schema, omitting those
+// at excluded paths. References to definitions are expanded in place, and the
+// fields of a top-level definition are excluded through the definition, e.g.
+// as "#Panel.legacy", wherever it is referenced.
+func Fields(sch thema.Schema, exclude []cue.Path) ([]*Field, error) {
+	schdef := sch.Underlying().LookupPath(cue.MakePath(cue.Hid("_#schema", "github.com/grafana/thema")))
+	w := &walker{schdef: schdef, exclude: exclude}
+	return w.fields(schdef, nil, true, 0)
+}
+
+type walker struct {
+	// schdef is the schema, relative to which excluded paths are resolved.
+	schdef  cue.Value
+	exclude []cue.Path
+}
+
+// fields returns the fields of the struct v, whose path within the schema is
+// path, if addressable is true. Values within lists and maps are not
+// addressable.
+func (w *walker) fields(v cue.Value, path []cue.Selector, addressable bool, depth int) ([]*Field, error) {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+	var fields []*Field
+	for iter.Next() {
+		fv := iter.Value()
+		fpath := append(path[:len(path):len(path)], iter.Selector())
+		if addressable && cuetil.ContainsPath(w.exclude, cue.MakePath(fpath...)) {
+			continue
+		}
+		f := w.field(fv, fpath, addressable, depth+1)
+		f.Name = iter.Selector().Unquoted()
+		f.Doc = doc(fv)
+		f.Nullable = f.Nullable || iter.IsOptional()
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// field returns the field describing values of v, whose path is as for
+// fields.
+func (w *walker) field(v cue.Value, path []cue.Selector, addressable bool, depth int) *Field {
+	f := &Field{}
+	if depth > maxDepth {
+		return f
+	}
+
+	if _, p := v.ReferencePath(); len(p.Selectors()) > 0 {
+		sels := p.Selectors()
+		last := sels[len(sels)-1]
+		if last.IsDefinition() && w.schdef.LookupPath(cue.MakePath(last)).Exists() {
+			path, addressable = []cue.Selector{last}, true
+		}
+	}
+
+	if op, args := v.Expr(); op == cue.OrOp {
+		var rest []cue.Value
+		for _, d := range args {
+			if d.IncompleteKind() == cue.NullKind {
+				f.Nullable = true
+				continue
+			}
+			rest = append(rest, d)
+		}
+		if len(rest) == 0 {
+			return f
+		}
+		// Disjuncts of a single scalar kind, such as enums, or a value and
+		// its default, share a type
+		k := rest[0].IncompleteKind()
+		for _, d := range rest[1:] {
+			if d.IncompleteKind() != k {
+				return f
+			}
+		}
+		if len(rest) > 1 && (k == cue.StructKind || k == cue.ListKind) {
+			return f
+		}
+		inner := w.field(rest[0], path, addressable, depth)
+		inner.Nullable = inner.Nullable || f.Nullable
+		return inner
+	}
+
+	switch v.IncompleteKind() {
+	case cue.StringKind:
+		f.Type = String
+	case cue.IntKind:
+		f.Type = Int
+	case cue.FloatKind, cue.NumberKind:
+		f.Type = Float
+	case cue.BoolKind:
+		f.Type = Bool
+	case cue.BytesKind:
+		f.Type = Bytes
+	case cue.ListKind:
+		elem := listElem(v)
+		if !elem.Exists() {
+			// Closed lists are tuples
+			return f
+		}
+		f.Type = List
+		f.Elem = w.field(elem, nil, false, depth+1)
+	case cue.StructKind:
+		fields, err := w.fields(v, path, addressable, depth)
+		if err != nil {
+			return f
+		}
+		if len(fields) == 0 {
+			if elem := v.LookupPath(cue.MakePath(cue.AnyString)); elem.Exists() {
+				f.Type = Map
+				f.Elem = w.field(elem, nil, false, depth+1)
+			}
+			return f
+		}
+		f.Type = Record
+		f.Fields = fields
+	}
+	return f
+}
+
+// listElem returns the value of the elements of the list v, which does not
+// exist for closed lists.
+func listElem(v cue.Value) cue.Value {
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+		return elem
+	}
+	// Lists with a default, e.g. [...string] | *[], wrap the list type
+	if op, args := v.Expr(); op == cue.NoOp && len(args) == 1 {
+		return args[0].LookupPath(cue.MakePath(cue.AnyIndex))
+	}
+	return cue.Value{}
+}
+
+// doc returns the text of the doc comments of v.
+func doc(v cue.Value) string {
+	var lines []string
+	for _, cg := range v.Doc() {
+		lines = append(lines, strings.TrimSpace(cg.Text()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Ident returns s as an identifier of letters, digits and underscores that
+// does not begin with a digit, as required of column names by many formats.
+func Ident(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
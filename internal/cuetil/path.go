@@ -79,3 +79,25 @@ func LastSelectorEq(p cue.Path, sel cue.Selector) bool {
 func SelEq(s1, s2 cue.Selector) bool {
 	return s1 == s2 || s1.Optional() == s2.Optional()
 }
+
+// ContainsPath tests whether paths contains a [cue.Path] equivalent to p.
+// Paths that vary only by optionality are considered equivalent.
+func ContainsPath(paths []cue.Path, p cue.Path) bool {
+	for _, path := range paths {
+		if PathsAreEq(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RelPath returns the path of v relative to base, which must be an ancestor of
+// v. For example, for a field b in a struct a, the path relative to the struct's
+// parent is a.b.
+func RelPath(base, v cue.Value) cue.Path {
+	bsels, sels := base.Path().Selectors(), v.Path().Selectors()
+	if len(bsels) > len(sels) || !pathsAreEq(sels[:len(bsels)], bsels) {
+		return v.Path()
+	}
+	return cue.MakePath(sels[len(bsels):]...)
+}
@@ -25,12 +25,13 @@ import (
 	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
+
+	themautil "github.com/grafana/thema/internal/util"
 )
 
 var (
 	pathParamRE    *regexp.Regexp
 	predeclaredSet map[string]struct{}
-	separatorSet   map[rune]struct{}
 )
 
 func init() {
@@ -85,12 +86,6 @@ func init() {
 	for _, id := range predeclaredIdentifiers {
 		predeclaredSet[id] = struct{}{}
 	}
-
-	separators := "-#@!$&=.+:;_~ (){}[]"
-	separatorSet = map[rune]struct{}{}
-	for _, r := range separators {
-		separatorSet[r] = struct{}{}
-	}
 }
 
 // UppercaseFirstCharacter Uppercases the first character in a string. This assumes UTF-8, so we have
@@ -138,27 +133,7 @@ func LowercaseFirstCharacter(str string) string {
 // So, "word.word-word+word:word;word_word~word word(word)word{word}[word]"
 // would be converted to WordWordWordWordWordWordWordWordWordWordWordWordWord
 func ToCamelCase(str string) string {
-	s := strings.Trim(str, " ")
-
-	n := ""
-	capNext := true
-	for _, v := range s {
-		if unicode.IsUpper(v) {
-			n += string(v)
-		}
-		if unicode.IsDigit(v) {
-			n += string(v)
-		}
-		if unicode.IsLower(v) {
-			if capNext {
-				n += strings.ToUpper(string(v))
-			} else {
-				n += string(v)
-			}
-		}
-		_, capNext = separatorSet[v]
-	}
-	return n
+	return themautil.ToCamelCase(str)
 }
 
 // SortedSchemaKeys returns the keys of the given SchemaRef dictionary in sorted
@@ -1,35 +1,69 @@
 package txtartest
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/exemplars"
+	"golang.org/x/tools/txtar"
 )
 
-var exonce sync.Once
-var exmap map[string]thema.Lineage
+// exemplarPrefix prefixes the names of txtar files that test an exemplar.
+const exemplarPrefix = "exemplar_"
 
-func getExemplars(rt *thema.Runtime) map[string]thema.Lineage {
-	if rt == nil || rt == Runtime() {
-		exonce.Do(func() {
-			exmap = exemplars.All(Runtime())
-		})
+var exmu sync.Mutex
+var exmap = make(map[string]thema.Lineage)
+
+// exemplarLineage returns the lineage of the named exemplar, bound against rt.
+// Lineages bound against the shared [Runtime] are bound only once.
+func exemplarLineage(rt *thema.Runtime, name string) (thema.Lineage, error) {
+	ex, err := exemplars.GetExemplar(name)
+	if err != nil {
+		return nil, err
+	}
+	if rt != Runtime() {
+		return ex.Lineage(rt)
 	}
 
-	ret := make(map[string]thema.Lineage)
-	for k, v := range exmap {
-		ret[k] = v
+	exmu.Lock()
+	defer exmu.Unlock()
+	if lin, has := exmap[name]; has {
+		return lin, nil
+	}
+	lin, err := ex.Lineage(rt)
+	if err != nil {
+		return nil, err
 	}
-	return ret
+	exmap[name] = lin
+	return lin, nil
 }
 
-func exemplarNameFromPath(path string) string {
+// isExemplarPath reports whether the txtar file at path is reserved for
+// testing an exemplar.
+func isExemplarPath(path string) bool {
 	name := filepath.Base(path)
-	if name == "" || filepath.Ext(name) != ".txtar" || !strings.HasPrefix(name, "exemplar_") {
+	return filepath.Ext(name) == ".txtar" && strings.HasPrefix(name, exemplarPrefix)
+}
+
+// exemplarNameFromPath returns the name of the exemplar tested by the txtar
+// file at path, or "" if it does not test an exemplar.
+func exemplarNameFromPath(path string) string {
+	if !isExemplarPath(path) {
 		return ""
 	}
-	return name[9 : len(name)-6]
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), exemplarPrefix), ".txtar")
+}
+
+// createExemplarArchive creates a txtar file within root for testing the
+// exemplar, seeded with the exemplar's name and description as values in its
+// comment. Output files are added to it when golden files are updated.
+func createExemplarArchive(root string, ex exemplars.Exemplar) error {
+	a := &txtar.Archive{
+		Comment: []byte(fmt.Sprintf("#exemplar: %s\n#description: %s\n", ex.Name, strings.Join(strings.Fields(ex.Description), " "))),
+	}
+	return os.WriteFile(filepath.Join(root, exemplarPrefix+ex.Name+".txtar"), txtar.Format(a), 0644) // nolint: gosec
 }
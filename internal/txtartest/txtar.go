@@ -38,6 +38,7 @@ import (
 	"cuelang.org/go/pkg/encoding/yaml"
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/thema"
+	"github.com/grafana/thema/exemplars"
 	"github.com/grafana/thema/internal/envvars"
 	tload "github.com/grafana/thema/load"
 	"golang.org/x/tools/txtar"
@@ -71,7 +72,9 @@ type LineageSuite struct {
 	// should be included as inputs to the executed test.
 	//
 	// If true, the Thema exemplars will be loaded and test results will be
-	// placed within Root with the naming pattern exemplar_<name>.txtar.
+	// placed within Root with the naming pattern exemplar_<name>.txtar. Any
+	// such file that is missing is created, seeded with the #exemplar and
+	// #description of the exemplar listed by [exemplars.ListExemplars].
 	IncludeExemplars bool
 }
 
@@ -328,7 +331,11 @@ func (t *LineageTest) BindLineage(rt *thema.Runtime) thema.Lineage {
 	}
 
 	if t.exemplar != "" {
-		return getExemplars(rt)[t.exemplar]
+		lin, err := exemplarLineage(rt, t.exemplar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lin
 	}
 
 	inst := t.instance()
@@ -421,9 +428,12 @@ func (x *LineageSuite) Run(t *testing.T, f func(tc *LineageTest)) {
 
 	root := x.Root
 
-	all := make(map[string]thema.Lineage)
+	// Exemplars without a txtar file have one created
+	missing := make(map[string]exemplars.Exemplar)
 	if x.IncludeExemplars {
-		all = getExemplars(nil)
+		for _, ex := range exemplars.ListExemplars() {
+			missing[ex.Name] = ex
+		}
 	}
 	ents, err := os.ReadDir(x.Root)
 	if err != nil {
@@ -432,17 +442,17 @@ func (x *LineageSuite) Run(t *testing.T, f func(tc *LineageTest)) {
 
 	for _, ent := range ents {
 		name := ent.Name()
-		if path.Ext(name) == ".txtar" && strings.HasPrefix(name, "exemplar_") {
+		if !ent.IsDir() && isExemplarPath(name) {
 			if !x.IncludeExemplars {
 				t.Fail()
-				t.Logf("%s: test files with prefix exemplar_ are reserved for exemplar testing with LineageSuite.IncludeExemplars=true", name)
+				t.Logf("%s: test files with prefix %s are reserved for exemplar testing with LineageSuite.IncludeExemplars=true", name, exemplarPrefix)
 			} else {
-				name = name[9 : len(name)-6]
-				if _, has := all[name]; has {
-					delete(all, name)
+				exname := exemplarNameFromPath(name)
+				if _, has := missing[exname]; has {
+					delete(missing, exname)
 				} else {
 					t.Fail()
-					t.Logf("%s: no exemplar exists with name %q, file must be removed", ent.Name(), name)
+					t.Logf("%s: no exemplar exists with name %q, file must be removed", name, exname)
 				}
 			}
 		}
@@ -452,9 +462,9 @@ func (x *LineageSuite) Run(t *testing.T, f func(tc *LineageTest)) {
 		t.FailNow()
 	}
 
-	if x.IncludeExemplars {
-		for name := range all {
-			os.Create(filepath.Join(x.Root, fmt.Sprintf("exemplar_%s.txtar", name)))
+	for _, ex := range missing {
+		if err := createExemplarArchive(x.Root, ex); err != nil {
+			t.Fatalf("could not create test file for exemplar %q: %s", ex.Name, err)
 		}
 	}
 
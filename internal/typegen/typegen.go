@@ -0,0 +1,102 @@
+// Package typegen provides the schema-walking helpers shared by the
+// generators of types in other languages, in encoding/rust, encoding/python
+// and encoding/java.
+package typegen
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+)
+
+// MaxDepth bounds the depth to which schemas are walked, guarding against
+// infinite expansion of recursive schemas.
+const MaxDepth = 32
+
+// Names records the names of the types already generated.
+type Names map[string]bool
+
+// Reserve returns name, suffixed if necessary to make it unique, and marks it
+// as taken.
+func (n Names) Reserve(name string) string {
+	r := name
+	for i := 2; n[r]; i++ {
+		r = fmt.Sprintf("%s%d", name, i)
+	}
+	n[r] = true
+	return r
+}
+
+// StringEnum returns the values of the disjuncts if, ignoring null, they are
+// all concrete strings.
+func StringEnum(disjuncts []cue.Value) (strs []string, nullable bool) {
+	for _, d := range disjuncts {
+		switch {
+		case d.IncompleteKind() == cue.NullKind:
+			nullable = true
+		case d.IncompleteKind() == cue.StringKind && d.IsConcrete():
+			s, _ := d.String() // nolint: errcheck
+			strs = append(strs, s)
+		default:
+			return nil, false
+		}
+	}
+	return strs, nullable
+}
+
+// Subsumed reports whether the i'th disjunct is redundant, being subsumed by
+// another.
+func Subsumed(disjuncts []cue.Value, i int) bool {
+	for j, d := range disjuncts {
+		if j == i || d.Subsume(disjuncts[i]) != nil {
+			continue
+		}
+		// Of equivalent disjuncts, keep the first
+		if disjuncts[i].Subsume(d) != nil || j < i {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMap reports whether v is a struct constrained only by a pattern, e.g.
+// [string]: int.
+func IsMap(v cue.Value) bool {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil || iter.Next() {
+		return false
+	}
+	return v.LookupPath(cue.MakePath(cue.AnyString)).Exists()
+}
+
+// Constraints calls fn with the operator and operand of each unary
+// constraint on v, such as <10 or =~"^a", looking through conjunctions and
+// references.
+func Constraints(v cue.Value, fn func(op cue.Op, arg cue.Value)) {
+	constraints(v, fn, 0)
+}
+
+func constraints(v cue.Value, fn func(op cue.Op, arg cue.Value), depth int) {
+	if depth > MaxDepth {
+		return
+	}
+	op, args := v.Expr()
+	switch op {
+	case cue.AndOp:
+		for _, arg := range args {
+			constraints(arg, fn, depth+1)
+		}
+		return
+	case cue.NoOp:
+		// References, such as to uint8, wrap the constraints they resolve to
+		if len(args) == 1 {
+			if aop, _ := args[0].Expr(); aop != cue.NoOp {
+				constraints(args[0], fn, depth+1)
+			}
+		}
+		return
+	}
+	if len(args) == 1 {
+		fn(op, args[0])
+	}
+}
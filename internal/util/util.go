@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"cuelang.org/go/cue/load"
 )
@@ -96,3 +97,29 @@ func SanitizeLabelString(s string) string {
 		}
 	}, s)
 }
+
+// camelSeparators are the characters treated as word boundaries by
+// [ToCamelCase].
+const camelSeparators = "-#@!$&=.+:;_~ (){}[]"
+
+// ToCamelCase converts s to an exported-style identifier: letters following a
+// separator are upper-cased, and separators and other characters that are not
+// letters or digits are removed. For example, "my-lineage" becomes
+// "MyLineage".
+func ToCamelCase(s string) string {
+	var b strings.Builder
+	capNext := true
+	for _, r := range strings.Trim(s, " ") {
+		switch {
+		case unicode.IsUpper(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsLower(r):
+			if capNext {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+		}
+		capNext = strings.ContainsRune(camelSeparators, r)
+	}
+	return b.String()
+}
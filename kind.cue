@@ -0,0 +1,36 @@
+package thema
+
+// Kind layers metadata over a #Lineage, describing the kind of object that the
+// lineage schematizes in terms suitable for object registries and APIs.
+//
+// A kind's metadata is declared in CUE alongside its lineage, and the two are
+// bound together by Thema.
+#Kind: {
+	// machineName is the unique, machine-friendly name of the kind. It must be
+	// lowercase, and is used as the name of the kind's lineage.
+	machineName: =~"^[a-z][a-z0-9-]*$"
+
+	// name is the human-readable name of the kind, e.g. "Dashboard".
+	name: string
+
+	// pluralMachineName is the plural form of machineName, e.g. for use in
+	// API paths.
+	pluralMachineName: =~"^[a-z][a-z0-9-]*$" | *"\(machineName)s"
+
+	// pluralName is the plural form of name.
+	pluralName: string | *"\(name)s"
+
+	// group is the group to which the kind belongs, if any, e.g.
+	// "dashboards.grafana.com".
+	group?: string
+
+	// maturity indicates the stability of the kind, and the guarantees its
+	// maintainers make about it.
+	maturity: #Maturity
+
+	// lineage is the lineage that schematizes the kind. Its name must be the
+	// kind's machineName.
+	lineage: #Lineage & {
+		name: machineName
+	}
+}
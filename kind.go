@@ -0,0 +1,184 @@
+package thema
+
+import (
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// Maturity indicates the stability of a [Kind] or [Schema], and the
+// guarantees its maintainers make about it, corresponding to #Maturity in the
+// Thema CUE package. The defined maturities are ordered from least to most
+// mature; anything that does not declare a maturity is stable.
+type Maturity string
+
+const (
+	MaturityExperimental Maturity = "experimental"
+	MaturityBeta         Maturity = "beta"
+	MaturityStable       Maturity = "stable"
+	MaturityDeprecated   Maturity = "deprecated"
+)
+
+// maturities are the defined maturities, from least to most mature.
+var maturities = []Maturity{MaturityExperimental, MaturityBeta, MaturityStable, MaturityDeprecated}
+
+// rank orders maturities from least to most mature. Undefined maturities rank
+// below all defined ones.
+func (m Maturity) rank() int {
+	for i, dm := range maturities {
+		if m == dm {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m Maturity) less(o Maturity) bool {
+	return m.rank() < o.rank()
+}
+
+// KindMeta is the metadata declared for a [Kind], corresponding to the fields
+// of #Kind in the Thema CUE package.
+type KindMeta struct {
+	// MachineName is the unique, machine-friendly name of the kind. It is
+	// always the same as the name of the kind's lineage.
+	MachineName string `json:"machineName"`
+
+	// Name is the human-readable name of the kind.
+	Name string `json:"name"`
+
+	// PluralMachineName is the plural form of MachineName.
+	PluralMachineName string `json:"pluralMachineName"`
+
+	// PluralName is the plural form of Name.
+	PluralName string `json:"pluralName"`
+
+	// Group is the group to which the kind belongs. It is empty if the kind
+	// declares no group.
+	Group string `json:"group,omitempty"`
+
+	// Maturity is the maturity of the kind.
+	Maturity Maturity `json:"maturity"`
+}
+
+// kindMetaFields are the names of the metadata fields of #Kind.
+var kindMetaFields = []string{"machineName", "name", "pluralMachineName", "pluralName", "group", "maturity"}
+
+// A Kind layers metadata over a [Lineage], describing the kind of object the
+// lineage schematizes in terms suitable for object registries and APIs.
+//
+// Kinds are declared in CUE as instances of thema.#Kind, and may only be
+// produced by calling [BindKind].
+type Kind struct {
+	lin  Lineage
+	meta KindMeta
+}
+
+// BindKind takes a raw [cue.Value], checks that it is a valid instance of
+// thema.#Kind, and binds both its metadata and lineage. The provided
+// BindOptions are passed through to [BindLineage] for the kind's lineage.
+//
+// The lineage need not declare its own name, as it is always the same as the
+// kind's machineName.
+//
+// An error wrapping [terrors.ErrInvalidKind] is returned if the kind metadata
+// is invalid. Errors from binding the lineage are returned unmodified.
+func BindKind(v cue.Value, rt *Runtime, opts ...BindOption) (*Kind, error) {
+	if !v.Exists() {
+		return nil, errors.Mark(errors.New("cannot bind kind from nonexistent value"), terrors.ErrValueNotExist)
+	}
+
+	rt.rl()
+	kdef := rt.Underlying().LookupPath(cue.MakePath(cue.Def("#Kind")))
+	rt.ru()
+
+	// Only the metadata is checked against #Kind; the lineage is checked by
+	// BindLineage, which produces much better errors.
+	kv := kdef
+	for _, name := range kindMetaFields {
+		p := cue.MakePath(cue.Str(name))
+		if fv := v.LookupPath(p); fv.Exists() {
+			kv = kv.FillPath(p, fv)
+		}
+	}
+
+	var meta KindMeta
+	for _, name := range kindMetaFields {
+		fv := kv.LookupPath(cue.MakePath(cue.Str(name)))
+		if name == "group" && !fv.Exists() {
+			continue
+		}
+		if dv, has := fv.Default(); has {
+			fv = dv
+		}
+		str, err := fv.String()
+		if err == nil {
+			err = fv.Validate(cue.Concrete(true))
+		}
+		if err != nil {
+			return nil, errors.Mark(errors.Wrapf(err, "invalid kind field %q", name), terrors.ErrInvalidKind)
+		}
+
+		switch name {
+		case "machineName":
+			meta.MachineName = str
+		case "name":
+			meta.Name = str
+		case "pluralMachineName":
+			meta.PluralMachineName = str
+		case "pluralName":
+			meta.PluralName = str
+		case "group":
+			meta.Group = str
+		case "maturity":
+			meta.Maturity = Maturity(str)
+		}
+	}
+
+	linv := v.LookupPath(cue.MakePath(cue.Str("lineage")))
+	if !linv.Exists() {
+		return nil, errors.Mark(errors.New("kind has no lineage"), terrors.ErrInvalidKind)
+	}
+	if _, err := linv.LookupPath(cue.MakePath(cue.Str("name"))).String(); err != nil {
+		linv = linv.FillPath(cue.MakePath(cue.Str("name")), meta.MachineName)
+	}
+
+	lin, err := BindLineage(linv, rt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if lin.Name() != meta.MachineName {
+		return nil, errors.Mark(errors.Newf("kind machineName %q does not match lineage name %q", meta.MachineName, lin.Name()), terrors.ErrInvalidKind)
+	}
+
+	return &Kind{
+		lin:  lin,
+		meta: meta,
+	}, nil
+}
+
+// Lineage returns the lineage that schematizes the kind.
+func (k *Kind) Lineage() Lineage {
+	return k.lin
+}
+
+// Meta returns the kind's metadata.
+func (k *Kind) Meta() KindMeta {
+	return k.meta
+}
+
+// MachineName returns the unique, machine-friendly name of the kind.
+func (k *Kind) MachineName() string {
+	return k.meta.MachineName
+}
+
+// Name returns the human-readable name of the kind.
+func (k *Kind) Name() string {
+	return k.meta.Name
+}
+
+// Maturity returns the maturity of the kind.
+func (k *Kind) Maturity() Maturity {
+	return k.meta.Maturity
+}
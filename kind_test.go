@@ -0,0 +1,69 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestBindKind(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	bind := func(src string) (*Kind, error) {
+		return BindKind(rt.Context().CompileString(src), rt)
+	}
+
+	k, err := bind(`
+machineName: "dashboard"
+name:        "Dashboard"
+group:       "dashboards.grafana.com"
+lineage: schemas: [{
+	version: [0, 0]
+	schema: title: string
+}]
+`)
+	require.NoError(t, err)
+	assert.Equal(t, KindMeta{
+		MachineName:       "dashboard",
+		Name:              "Dashboard",
+		PluralMachineName: "dashboards",
+		PluralName:        "Dashboards",
+		Group:             "dashboards.grafana.com",
+		Maturity:          MaturityStable,
+	}, k.Meta())
+	assert.Equal(t, "dashboard", k.Lineage().Name())
+
+	k, err = bind(`
+machineName:       "query"
+name:              "Query"
+pluralMachineName: "queries"
+pluralName:        "Queries"
+maturity:          "beta"
+lineage: {
+	name: "query"
+	schemas: [{
+		version: [0, 0]
+		schema: expr: string
+	}]
+}
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "queries", k.Meta().PluralMachineName)
+	assert.Equal(t, MaturityBeta, k.Maturity())
+	assert.Empty(t, k.Meta().Group)
+
+	for name, src := range map[string]string{
+		"bad machine name": `machineName: "Bad Name", name: "Bad", lineage: schemas: [{version: [0, 0], schema: a: string}]`,
+		"bad maturity":     `machineName: "foo", name: "Foo", maturity: "merged", lineage: schemas: [{version: [0, 0], schema: a: string}]`,
+		"missing name":     `machineName: "foo", lineage: schemas: [{version: [0, 0], schema: a: string}]`,
+		"name mismatch":    `machineName: "foo", name: "Foo", lineage: {name: "bar", schemas: [{version: [0, 0], schema: a: string}]}`,
+		"missing lineage":  `machineName: "foo", name: "Foo"`,
+	} {
+		_, err := bind(src)
+		assert.True(t, errors.Is(err, terrors.ErrInvalidKind), "%s: expected ErrInvalidKind, got %v", name, err)
+	}
+}
@@ -45,6 +45,23 @@ package thema
 	message: string
 
 	type: or([ for t in #LacunaTypes {t}])
+
+	// How much attention the lacuna demands from the program that requested
+	// the translation. If unspecified, the lacuna has the default severity of
+	// its type: "critical" for Placeholder, "warning" for DroppedField and
+	// LossyFieldMapping, and "info" for ChangedDefault.
+	severity?: "info" | "warning" | "critical"
+
+	// The lacuna as emitted by #Translate, when its condition holds.
+	lacuna: {
+		"sourceFields": sourceFields
+		"targetFields": targetFields
+		"message":      message
+		"type":         type
+		if severity != _|_ {
+			"severity": severity
+		}
+	}
 }
 
 #LacunaTypes: [N=string]: #LacunaType & {name: N}
@@ -98,3 +115,25 @@ package thema
 	name: string
 	id:   int // FIXME this is a dumb way of trying to express identity
 }
+
+// A #LacunaPolicy declares which of the lacunas emitted by a translation are
+// acceptable. Each lacuna is checked against the rules in order, and the action
+// of the first matching rule is taken, or the default if no rule matches.
+#LacunaPolicy: {
+	rules: [...#LacunaRule]
+	default: #LacunaAction | *"allow"
+}
+
+// A #LacunaRule matches lacunas by all of its specified criteria, and
+// prescribes an action for them.
+#LacunaRule: {
+	// Matches lacunas of any of the listed types.
+	types?: [...or([ for n, _ in #LacunaTypes {n}])]
+	// Matches lacunas of at least the given severity.
+	severity?: "info" | "warning" | "critical"
+	// Matches lacunas with a source or target field at or beneath the path.
+	path?:  string
+	action: #LacunaAction
+}
+
+#LacunaAction: "allow" | "warn" | "fail"
@@ -1,17 +1,20 @@
 package thema
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+)
+
 // TranslationLacunas defines common patterns for unary and composite lineages
 // in the lacunas their translations emit.
 type TranslationLacunas interface {
 	AsList() []Lacuna
 }
 
-type flatLacunas []Lacuna
-
-func (fl flatLacunas) AsList() []Lacuna {
-	return fl
-}
-
 // A Lacuna represents a semantic gap in a Lens's mapping between schemas.
 //
 // For any given mapping between schema, there may exist some valid values and
@@ -40,15 +43,353 @@ type Lacuna struct {
 
 	// A human-readable message describing the gap in translation.
 	Message string `json:"message"`
+
+	// Severity indicates how much attention the lacuna demands, as declared by
+	// the lens author. If unset, the lacuna has the default severity of its
+	// type.
+	Severity LacunaSeverity `json:"severity,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. A lacuna without a Severity is
+// encoded with the default severity of its type.
+func (l Lacuna) MarshalJSON() ([]byte, error) {
+	type lacuna Lacuna
+	l.Severity = l.severity()
+	return json.Marshal(lacuna(l))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A lacuna without a severity is
+// decoded with the default severity of its type.
+func (l *Lacuna) UnmarshalJSON(b []byte) error {
+	type lacuna Lacuna
+	if err := json.Unmarshal(b, (*lacuna)(l)); err != nil {
+		return err
+	}
+	l.Severity = l.severity()
+	return nil
+}
+
+// severity returns the lacuna's Severity, or the default for its type if it
+// has none.
+func (l Lacuna) severity() LacunaSeverity {
+	if l.Severity != 0 {
+		return l.Severity
+	}
+	return l.Type.Severity()
+}
+
+// Lacunas is a list of lacunas, with helpers for selecting those relevant
+// to a caller.
+type Lacunas []Lacuna
+
+// AsList implements TranslationLacunas.
+func (ls Lacunas) AsList() []Lacuna {
+	return ls
+}
+
+// AtLeast returns the lacunas with a severity of at least s.
+func (ls Lacunas) AtLeast(s LacunaSeverity) Lacunas {
+	return ls.filter(func(l Lacuna) bool {
+		return l.severity() >= s
+	})
+}
+
+// OfType returns the lacunas of any of the given types.
+func (ls Lacunas) OfType(types ...LacunaType) Lacunas {
+	return ls.filter(func(l Lacuna) bool {
+		for _, t := range types {
+			if l.Type == t {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MaxSeverity returns the greatest severity of any of the lacunas, or zero if
+// there are none.
+func (ls Lacunas) MaxSeverity() LacunaSeverity {
+	var max LacunaSeverity
+	for _, l := range ls {
+		if s := l.severity(); s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+func (ls Lacunas) filter(fn func(Lacuna) bool) Lacunas {
+	var out Lacunas
+	for _, l := range ls {
+		if fn(l) {
+			out = append(out, l)
+		}
+	}
+	return out
 }
 
 // LacunaType assigns numeric identifiers to different classes of Lacunas.
 //
-// FIXME this is a terrible way of doing this and needs to change
+// The identifiers correspond to those of #LacunaTypes in lacuna.cue. When
+// encoded as JSON or text, a LacunaType is represented by its name, which is
+// stable across releases.
 type LacunaType uint16
 
+const (
+	// LacunaPlaceholder indicates that a field in the target instance has been
+	// filled with a placeholder value, which exists solely to be replaced by
+	// the calling program.
+	LacunaPlaceholder LacunaType = iota + 1
+
+	// LacunaDroppedField indicates that field(s) in the source instance were
+	// dropped in a manner that potentially lost some of their contained
+	// semantics.
+	LacunaDroppedField
+
+	// LacunaLossyFieldMapping indicates that no clear mapping existed from the
+	// source field value to the intended semantics of any valid target field
+	// value.
+	LacunaLossyFieldMapping
+
+	// LacunaChangedDefault indicates that the source field value was the
+	// schema-specified default, and the default changed in the target field,
+	// and the value in the instance was changed as well.
+	LacunaChangedDefault
+)
+
+var lacunaTypeNames = map[LacunaType]string{
+	LacunaPlaceholder:       "Placeholder",
+	LacunaDroppedField:      "DroppedField",
+	LacunaLossyFieldMapping: "LossyFieldMapping",
+	LacunaChangedDefault:    "ChangedDefault",
+}
+
+func (t LacunaType) String() string {
+	if name, has := lacunaTypeNames[t]; has {
+		return name
+	}
+	return fmt.Sprintf("LacunaType(%d)", uint16(t))
+}
+
+// Severity returns the default severity of lacunas of the type.
+func (t LacunaType) Severity() LacunaSeverity {
+	switch t {
+	case LacunaChangedDefault:
+		return LacunaInfo
+	case LacunaDroppedField, LacunaLossyFieldMapping:
+		return LacunaWarning
+	default:
+		// Placeholders must be replaced by the caller, and lacunas of an unknown
+		// type can't be assumed to be harmless
+		return LacunaCritical
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the LacunaType by
+// its name.
+func (t LacunaType) MarshalText() ([]byte, error) {
+	if _, has := lacunaTypeNames[t]; !has {
+		return nil, fmt.Errorf("unknown lacuna type %d", uint16(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a LacunaType
+// from its name.
+func (t *LacunaType) UnmarshalText(text []byte) error {
+	for lt, name := range lacunaTypeNames {
+		if name == string(text) {
+			*t = lt
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown lacuna type %q", text)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. In addition to the name of the
+// LacunaType, it accepts its numeric identifier, and the #LacunaType struct
+// emitted by lenses written in CUE.
+func (t *LacunaType) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case string:
+		return t.UnmarshalText([]byte(x))
+	case float64:
+		*t = LacunaType(x)
+	case map[string]interface{}:
+		if name, is := x["name"].(string); is {
+			return t.UnmarshalText([]byte(name))
+		}
+		id, is := x["id"].(float64)
+		if !is {
+			return fmt.Errorf("lacuna type has neither name nor id: %s", b)
+		}
+		*t = LacunaType(id)
+	default:
+		return fmt.Errorf("invalid lacuna type: %s", b)
+	}
+	if _, has := lacunaTypeNames[*t]; !has {
+		return fmt.Errorf("unknown lacuna type %d", uint16(*t))
+	}
+	return nil
+}
+
+// LacunaSeverity indicates how much attention a lacuna demands from the
+// program that requested a translation.
+type LacunaSeverity uint8
+
+const (
+	// LacunaInfo lacunas describe an expected change in the translated
+	// instance that may safely be ignored.
+	LacunaInfo LacunaSeverity = iota + 1
+
+	// LacunaWarning lacunas describe a potential loss of semantics in the
+	// translated instance.
+	LacunaWarning
+
+	// LacunaCritical lacunas describe a translated instance that must be
+	// amended before it is used.
+	LacunaCritical
+)
+
+var lacunaSeverityNames = map[LacunaSeverity]string{
+	LacunaInfo:     "info",
+	LacunaWarning:  "warning",
+	LacunaCritical: "critical",
+}
+
+func (s LacunaSeverity) String() string {
+	if name, has := lacunaSeverityNames[s]; has {
+		return name
+	}
+	return fmt.Sprintf("LacunaSeverity(%d)", uint8(s))
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the LacunaSeverity
+// by its name.
+func (s LacunaSeverity) MarshalText() ([]byte, error) {
+	if _, has := lacunaSeverityNames[s]; !has {
+		return nil, fmt.Errorf("unknown lacuna severity %d", uint8(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a
+// LacunaSeverity from its name.
+func (s *LacunaSeverity) UnmarshalText(text []byte) error {
+	for ls, name := range lacunaSeverityNames {
+		if name == string(text) {
+			*s = ls
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown lacuna severity %q", text)
+}
+
 // FieldRef identifies a path/field and the value in it within a Lacuna.
 type FieldRef struct {
+	// Path is the path to the field within the instance, in the syntax of a
+	// [cue.Path], e.g. "spec.panels[0].title".
 	Path  string      `json:"path"`
 	Value interface{} `json:"value"`
 }
+
+// NewFieldRef returns a FieldRef to the field at the given path within an
+// instance, holding the given value.
+func NewFieldRef(path cue.Path, value interface{}) FieldRef {
+	return FieldRef{Path: path.String(), Value: value}
+}
+
+// CUEPath returns the path to the field as a [cue.Path]. The returned path's
+// Err method reports whether the FieldRef's Path is invalid.
+func (fr FieldRef) CUEPath() cue.Path {
+	return cue.ParsePath(fr.Path)
+}
+
+// JSONPointer returns the path to the field as a JSON Pointer (RFC 6901), e.g.
+// "/spec/panels/0/title". An error is returned if the FieldRef's Path is
+// invalid, or contains selectors with no equivalent in JSON, such as
+// definitions.
+func (fr FieldRef) JSONPointer() (string, error) {
+	p := fr.CUEPath()
+	if err := p.Err(); err != nil {
+		return "", fmt.Errorf("invalid field path %q: %w", fr.Path, err)
+	}
+
+	var b strings.Builder
+	for _, sel := range p.Selectors() {
+		var tok string
+		switch sel.Type() {
+		case cue.StringLabel:
+			tok = sel.Unquoted()
+		case cue.IndexLabel:
+			tok = strconv.Itoa(sel.Index())
+		default:
+			return "", fmt.Errorf("field path %q has no JSON Pointer equivalent: unsupported selector %s", fr.Path, sel)
+		}
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String(), nil
+}
+
+// MarshalJSON implements json.Marshaler. In addition to its path and value,
+// the FieldRef is encoded with the JSON Pointer to the field, if it has one.
+func (fr FieldRef) MarshalJSON() ([]byte, error) {
+	type fieldRef FieldRef
+	ptr, err := fr.JSONPointer()
+	if err != nil {
+		return json.Marshal(fieldRef(fr))
+	}
+	return json.Marshal(struct {
+		fieldRef
+		Pointer string `json:"pointer"`
+	}{
+		fieldRef: fieldRef(fr),
+		Pointer:  ptr,
+	})
+}
+
+// A LacunaReport is an envelope for the lacunas emitted by a single
+// translation, suitable for returning from APIs and for storing alongside
+// translated objects.
+type LacunaReport struct {
+	// Lineage is the name of the lineage within which the translation occurred.
+	Lineage string `json:"lineage"`
+
+	// From and To are the versions of the schemas from and to which the
+	// translation occurred.
+	From SyntacticVersion `json:"from"`
+	To   SyntacticVersion `json:"to"`
+
+	// Severity is the greatest severity of any lacuna in the report. It is
+	// omitted if the report contains no lacunas.
+	Severity LacunaSeverity `json:"severity,omitempty"`
+
+	Lacunas Lacunas `json:"lacunas"`
+}
+
+// NewLacunaReport returns a LacunaReport for the lacunas emitted by
+// translating inst to the schema with the given version.
+func NewLacunaReport(inst *Instance, to SyntacticVersion, lac TranslationLacunas) LacunaReport {
+	r := LacunaReport{
+		Lineage: inst.Schema().Lineage().Name(),
+		From:    inst.Schema().Version(),
+		To:      to,
+		Lacunas: Lacunas{},
+	}
+	if lac != nil {
+		r.Lacunas = append(r.Lacunas, lac.AsList()...)
+	}
+	r.Severity = r.Lacunas.MaxSeverity()
+	return r
+}
+
+// AsList implements TranslationLacunas.
+func (r LacunaReport) AsList() []Lacuna {
+	return r.Lacunas
+}
@@ -0,0 +1,181 @@
+package thema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lacunalinstr = `name: "lacunae"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [1, 0]
+	schema: {a: string, b: string}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: a: input.a
+	lacunas: [{
+		sourceFields: [{path: "b", value: input.b}]
+		message: "b was dropped"
+		type: {name: "DroppedField", id: 2}
+		severity: "critical"
+	}]
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {a: input.a, b: "TODO"}
+	lacunas: [{
+		targetFields: [{path: "b", value: result.b}]
+		message: "b is a placeholder"
+		type: {name: "Placeholder", id: 1}
+	}]
+}]
+`
+
+func TestLacunaJSON(t *testing.T) {
+	lac := Lacuna{
+		SourceFields: []FieldRef{{Path: "a", Value: "foo"}},
+		Type:         LacunaChangedDefault,
+		Message:      "a changed",
+	}
+	b, err := json.Marshal(lac)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sourceFields":[{"path":"a","pointer":"/a","value":"foo"}],"type":"ChangedDefault","severity":"info","message":"a changed"}`, string(b))
+
+	var rt Lacuna
+	require.NoError(t, json.Unmarshal(b, &rt))
+	lac.Severity = LacunaInfo
+	assert.Equal(t, lac, rt)
+
+	for _, in := range []string{`"DroppedField"`, `2`, `{"name":"DroppedField","id":2}`} {
+		var lt LacunaType
+		require.NoError(t, json.Unmarshal([]byte(in), &lt), in)
+		assert.Equal(t, LacunaDroppedField, lt, in)
+	}
+	for _, in := range []string{`"Unknown"`, `9`, `{}`, `true`} {
+		var lt LacunaType
+		assert.Error(t, json.Unmarshal([]byte(in), &lt), in)
+	}
+
+	_, err = json.Marshal(Lacuna{Type: 9})
+	assert.Error(t, err)
+}
+
+func TestLacunaReport(t *testing.T) {
+	lin := testLin(lacunalinstr)
+	ctx := lin.Runtime().Context()
+	sch, err := lin.Schema(SV(1, 0))
+	require.NoError(t, err)
+
+	inst, err := sch.Validate(ctx.CompileString(`{a: "x", b: "y"}`))
+	require.NoError(t, err)
+	_, lac, err := inst.Translate(SV(0, 0))
+	require.NoError(t, err)
+
+	r := NewLacunaReport(inst, SV(0, 0), lac)
+	assert.Equal(t, LacunaCritical, r.Severity)
+	require.Len(t, r.Lacunas, 1)
+	assert.Equal(t, LacunaDroppedField, r.Lacunas[0].Type)
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"lineage": "lacunae",
+		"from": [1, 0],
+		"to": [0, 0],
+		"severity": "critical",
+		"lacunas": [{
+			"sourceFields": [{"path": "b", "pointer": "/b", "value": "y"}],
+			"type": "DroppedField",
+			"severity": "critical",
+			"message": "b was dropped"
+		}]
+	}`, string(b))
+
+	var rt LacunaReport
+	require.NoError(t, json.Unmarshal(b, &rt))
+	rtb, err := json.Marshal(rt)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(b), string(rtb))
+
+	sch, err = lin.Schema(SV(0, 0))
+	require.NoError(t, err)
+	inst, err = sch.Validate(ctx.CompileString(`{a: "x"}`))
+	require.NoError(t, err)
+	_, lac, err = inst.Translate(SV(1, 0))
+	require.NoError(t, err)
+	r = NewLacunaReport(inst, SV(1, 0), lac)
+	assert.Equal(t, LacunaCritical, r.Severity)
+	require.Len(t, r.Lacunas, 1)
+	assert.Equal(t, LacunaPlaceholder, r.Lacunas[0].Type)
+
+	_, lac, err = inst.Translate(SV(0, 0))
+	require.NoError(t, err)
+	b, err = json.Marshal(NewLacunaReport(inst, SV(0, 0), lac))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"lineage": "lacunae", "from": [0, 0], "to": [0, 0], "lacunas": []}`, string(b))
+}
+
+func TestLacunasFilter(t *testing.T) {
+	ls := Lacunas{
+		{Type: LacunaChangedDefault, Message: "a"},
+		{Type: LacunaDroppedField, Message: "b"},
+		{Type: LacunaChangedDefault, Severity: LacunaCritical, Message: "c"},
+		{Type: LacunaPlaceholder, Severity: LacunaInfo, Message: "d"},
+	}
+	messages := func(ls Lacunas) []string {
+		var m []string
+		for _, l := range ls {
+			m = append(m, l.Message)
+		}
+		return m
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, messages(ls.AtLeast(LacunaInfo)))
+	assert.Equal(t, []string{"b", "c"}, messages(ls.AtLeast(LacunaWarning)))
+	assert.Equal(t, []string{"c"}, messages(ls.AtLeast(LacunaCritical)))
+	assert.Equal(t, []string{"a", "c"}, messages(ls.OfType(LacunaChangedDefault)))
+	assert.Equal(t, LacunaCritical, ls.MaxSeverity())
+	assert.Equal(t, LacunaWarning, ls[:2].MaxSeverity())
+	assert.Equal(t, LacunaSeverity(0), Lacunas(nil).MaxSeverity())
+	assert.Empty(t, ls.AtLeast(LacunaCritical).OfType(LacunaPlaceholder))
+}
+
+func TestFieldRefPaths(t *testing.T) {
+	for path, ptr := range map[string]string{
+		"":                      "",
+		"a":                     "/a",
+		"spec.panels[0].title":  "/spec/panels/0/title",
+		`labels."some/key~1"`:   "/labels/some~1key~01",
+		`"with space"[2][10].x`: "/with space/2/10/x",
+	} {
+		fr := FieldRef{Path: path}
+		require.NoError(t, fr.CUEPath().Err(), path)
+		got, err := fr.JSONPointer()
+		require.NoError(t, err, path)
+		assert.Equal(t, ptr, got, path)
+	}
+
+	fr := NewFieldRef(cue.MakePath(cue.Str("spec"), cue.Str("a-b"), cue.Index(1)), 3)
+	assert.Equal(t, `spec."a-b"[1]`, fr.Path)
+	b, err := json.Marshal(fr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path": "spec.\"a-b\"[1]", "pointer": "/spec/a-b/1", "value": 3}`, string(b))
+
+	for _, path := range []string{"a.#Def", "a[", "a.b?"} {
+		_, err := FieldRef{Path: path}.JSONPointer()
+		assert.Error(t, err, path)
+	}
+	b, err = json.Marshal(FieldRef{Path: "a.#Def", Value: 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path": "a.#Def", "value": 1}`, string(b))
+}
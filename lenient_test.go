@@ -0,0 +1,60 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+var lenientlinstr = `name: "lenient"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		name:   string & =~"^[a-z]+$" @thema(advisory)
+		count:  int & <10
+		nested: {
+			inner: int & >0
+		} @thema(advisory)
+	}
+}]
+`
+
+func TestValidateLenient(t *testing.T) {
+	lin := testLin(lenientlinstr)
+	ctx := lin.Runtime().Context()
+	sch := lin.First()
+
+	t.Run("advisory failures are warnings", func(t *testing.T) {
+		data := ctx.CompileString(`{ name: "UPPER", count: 3, nested: { inner: -1 } }`)
+		_, err := sch.Validate(data)
+		require.Error(t, err)
+
+		inst, warnings, err := sch.ValidateLenient(data)
+		require.NoError(t, err)
+		require.NotNil(t, inst)
+		require.Len(t, warnings, 2)
+		for _, w := range warnings {
+			assert.Equal(t, terrors.OutOfBounds, w.Code)
+			assert.NotEmpty(t, w.Message)
+		}
+	})
+
+	t.Run("hard failures are errors", func(t *testing.T) {
+		data := ctx.CompileString(`{ name: "UPPER", count: 30, nested: { inner: 1 } }`)
+		inst, warnings, err := sch.ValidateLenient(data)
+		require.Error(t, err)
+		assert.Nil(t, inst)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("valid data has no warnings", func(t *testing.T) {
+		data := ctx.CompileString(`{ name: "lower", count: 3, nested: { inner: 1 } }`)
+		inst, warnings, err := sch.ValidateLenient(data)
+		require.NoError(t, err)
+		require.NotNil(t, inst)
+		assert.Empty(t, warnings)
+	})
+}
@@ -0,0 +1,75 @@
+package thema
+
+import (
+	"sort"
+
+	"cuelang.org/go/cue"
+)
+
+var pathLenses = cue.MakePath(cue.Str("lenses"))
+
+// A Lens describes a lens declared in a lineage, translating instances of one
+// schema to another. Lenses are only declared where translation is not
+// implicit: from each major version to the adjacent major versions, and from
+// each minor version back to its predecessor.
+type Lens struct {
+	From SyntacticVersion `json:"from"`
+	To   SyntacticVersion `json:"to"`
+
+	// Imperative reports whether the lens is written in Go and provided to
+	// [ImperativeLenses] or [ShortcutLenses], rather than declared in CUE.
+	Imperative bool `json:"imperative,omitempty"`
+
+	// Shortcut reports whether the lens is provided to [ShortcutLenses],
+	// connecting non-adjacent schemas.
+	Shortcut bool `json:"shortcut,omitempty"`
+
+	v cue.Value
+}
+
+// Underlying returns the CUE declaration of the lens, an instance of
+// #Lens in the Thema CUE package. Its "input", "result" and "lacunas" fields
+// may be inspected with [cue.Value.LookupPath].
+//
+// The returned value does not exist for imperative lenses.
+func (l Lens) Underlying() cue.Value {
+	return l.v
+}
+
+// Lenses returns the lenses declared in the lineage: those declared in CUE,
+// or if the lineage was bound with [ImperativeLenses], those written in Go;
+// followed by any provided to [ShortcutLenses]. Each group is ordered by To
+// version, then From version, as lenses must be declared in CUE.
+func (lin *baseLineage) Lenses() []Lens {
+	var lenses []Lens
+	if len(lin.lensmap) > 0 {
+		for _, il := range lin.lensmap {
+			lenses = append(lenses, Lens{From: il.From, To: il.To, Imperative: true})
+		}
+		sortLenses(lenses)
+	} else if iter, err := lin.uni.LookupPath(pathLenses).List(); err == nil {
+		for iter.Next() {
+			def, err := newLensVersionDef(iter.Value())
+			if err != nil {
+				continue
+			}
+			lenses = append(lenses, Lens{From: def.from, To: def.to, v: iter.Value()})
+		}
+	}
+
+	var shortcuts []Lens
+	for _, il := range lin.shortcuts {
+		shortcuts = append(shortcuts, Lens{From: il.From, To: il.To, Imperative: true, Shortcut: true})
+	}
+	sortLenses(shortcuts)
+	return append(lenses, shortcuts...)
+}
+
+func sortLenses(lenses []Lens) {
+	sort.Slice(lenses, func(i, j int) bool {
+		if lenses[i].To != lenses[j].To {
+			return lenses[i].To.Less(lenses[j].To)
+		}
+		return lenses[i].From.Less(lenses[j].From)
+	})
+}
@@ -0,0 +1,72 @@
+package thema
+
+import (
+	"errors"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLenses(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	ids := func(lenses []Lens) []lensID {
+		var l []lensID
+		for _, lens := range lenses {
+			l = append(l, lid(lens.From, lens.To))
+		}
+		return l
+	}
+	declared := []lensID{
+		lid(SV(0, 1), SV(0, 0)),
+		lid(SV(1, 0), SV(0, 1)),
+		lid(SV(0, 1), SV(1, 0)),
+		lid(SV(2, 0), SV(1, 0)),
+		lid(SV(1, 0), SV(2, 0)),
+	}
+	nope := func(inst *Instance, to Schema) (*Instance, error) {
+		return nil, errors.New("not implemented")
+	}
+
+	t.Run("cue", func(t *testing.T) {
+		lin, err := BindLineage(rt.Context().CompileString(routelin), rt)
+		require.NoError(t, err)
+		lenses := lin.Lenses()
+		assert.Equal(t, declared, ids(lenses))
+
+		lens := lenses[1]
+		assert.False(t, lens.Imperative)
+		require.True(t, lens.Underlying().Exists())
+		res, err := lens.Underlying().LookupPath(cue.MakePath(cue.Str("result"))).Fields()
+		require.NoError(t, err)
+		require.True(t, res.Next())
+		assert.Equal(t, "title", res.Selector().String())
+
+		assert.Empty(t, testLin(`name: "single"
+schemas: [{version: [0, 0], schema: title: string}]
+`).Lenses())
+	})
+
+	t.Run("imperative", func(t *testing.T) {
+		var imp []ImperativeLens
+		for _, id := range declared {
+			imp = append(imp, ImperativeLens{From: id.From, To: id.To, Mapper: nope})
+		}
+		// Order of provision is irrelevant
+		imp[0], imp[4] = imp[4], imp[0]
+		shortcut := ImperativeLens{From: SV(0, 0), To: SV(2, 0), Mapper: nope}
+
+		lin, err := BindLineage(rt.Context().CompileString(routelin), rt, ImperativeLenses(imp...), ShortcutLenses(shortcut))
+		require.NoError(t, err)
+		lenses := lin.Lenses()
+		assert.Equal(t, append(declared, lid(SV(0, 0), SV(2, 0))), ids(lenses))
+		for _, lens := range lenses {
+			assert.True(t, lens.Imperative)
+			assert.False(t, lens.Underlying().Exists())
+		}
+		assert.True(t, lenses[5].Shortcut)
+		assert.False(t, lenses[4].Shortcut)
+	})
+}
@@ -238,6 +238,48 @@ import (
 	// examples is an optional set of named examples of the schema, intended
 	// for use in documentation or other non-functional contexts.
 	examples?: [string]: _#schema
+
+	// maturity indicates the stability of the schema. Experimental and beta
+	// schemas are intended for early feedback, and consumers may choose to
+	// exclude them, e.g. in production configurations.
+	//
+	// Within a major version, maturity may only increase: a schema may not be
+	// less mature than its predecessor in the same major version.
+	maturity: #Maturity
+
+	// changes optionally records what changed in this schema relative to its
+	// predecessor, for use in changelogs and generated documentation. They
+	// have no effect on the behavior of the schema.
+	changes?: [...#ChangeNote]
+}
+
+// Maturity indicates the stability of a schema or kind, and the guarantees its
+// maintainers make about it. From least to most mature, it is one of:
+//
+//  - experimental: subject to change or removal without notice
+//  - beta: intended for early feedback, and changing only with notice
+//  - stable: changing only as permitted by Thema's compatibility rules
+//  - deprecated: stable, but no longer recommended, and to be removed
+//
+// Anything that does not declare a maturity is stable.
+#Maturity: *"stable" | "experimental" | "beta" | "deprecated"
+
+// ChangeNote describes a single change made in a schema, relative to its
+// predecessor in the lineage.
+#ChangeNote: {
+	// kind categorizes the change, following the conventions of
+	// https://keepachangelog.com.
+	kind: "added" | "changed" | "deprecated" | "removed" | "fixed" | "security"
+
+	// message describes the change, for human readers.
+	message: string
+
+	// fields optionally lists the paths of the fields affected by the change,
+	// e.g. "spec.title".
+	fields?: [...string]
+
+	// issue optionally references a related issue or pull request, e.g. a URL.
+	issue?: string
 }
 
 // Lens defines a transformation that maps the fields of one schema in a lineage to the
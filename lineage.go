@@ -3,6 +3,7 @@ package thema
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"cuelang.org/go/cue"
 	cerrors "cuelang.org/go/cue/errors"
@@ -41,6 +42,21 @@ type baseLineage struct {
 	allsch []*schemaDef
 
 	lensmap map[lensID]ImperativeLens
+
+	// lenses between non-adjacent schemas, from ShortcutLenses
+	shortcuts map[lensID]ImperativeLens
+
+	// cache of validation results, if enabled
+	vcache *validationCache
+
+	// lenses precomposed by Warm
+	precomposed *lensCache
+
+	// bounds on the size of data to validate
+	budget Budget
+
+	// maximum duration of validation and translation, if enabled
+	evaltimeout time.Duration
 }
 
 // BindLineage takes a raw [cue.Value], checks that it correctly follows Thema's
@@ -131,24 +147,44 @@ func BindLineage(v cue.Value, rt *Runtime, opts ...BindOption) (Lineage, error)
 	if err := ml.checkLensesOrder(); err != nil {
 		return nil, err
 	}
+	if err := ml.checkShortcutLenses(); err != nil {
+		return nil, err
+	}
+	if err := ml.checkValidators(); err != nil {
+		return nil, err
+	}
 
 	// previously verified that this value is concrete
 	nam, _ := orig.LookupPath(cue.MakePath(cue.Str("name"))).String()
 
 	lin := &baseLineage{
-		validated: true,
-		rt:        rt,
-		name:      nam,
-		raw:       ml.raw,
-		uni:       ml.uni,
-		allsch:    ml.schlist,
-		allv:      ml.allv,
-		lensmap:   ml.lensmap,
+		validated:   true,
+		rt:          rt,
+		name:        nam,
+		raw:         ml.raw,
+		uni:         ml.uni,
+		allsch:      ml.schlist,
+		allv:        ml.allv,
+		lensmap:     ml.lensmap,
+		shortcuts:   ml.shortcuts,
+		precomposed: new(lensCache),
+	}
+
+	if cfg.valcachesize > 0 {
+		lin.vcache = newValidationCache(cfg.valcachesize)
 	}
+	lin.budget = cfg.budget
+	lin.evaltimeout = cfg.evaltimeout
 
 	for _, sch := range lin.allsch {
 		sch.lin = lin
 	}
+
+	if cfg.releases != nil {
+		if err := cfg.releases.Verify(lin); err != nil {
+			return nil, err
+		}
+	}
 	return lin, nil
 }
 
@@ -193,6 +229,12 @@ func (lin *baseLineage) Latest() Schema {
 	return lin.allsch[len(lin.allsch)-1]
 }
 
+// LatestVersion returns the version of the schema returned by
+// [Lineage.Latest].
+func (lin *baseLineage) LatestVersion() SyntacticVersion {
+	return lin.allv[len(lin.allv)-1]
+}
+
 // First returns the first Schema in the lineage (v0.0). Thema requires that all
 // valid lineages contain at least one schema, so this is guaranteed to exist.
 func (lin *baseLineage) First() Schema {
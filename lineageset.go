@@ -0,0 +1,260 @@
+package thema
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A LineageSet is a collection of lineages, keyed by their name. It is the
+// natural container for programs that work with many kinds of objects, each
+// schematized by its own lineage.
+//
+// The zero value is an empty LineageSet, ready for use. LineageSets are safe
+// for concurrent use.
+type LineageSet struct {
+	mu   sync.RWMutex
+	lins map[string]Lineage
+
+	submu   sync.Mutex
+	subs    map[int]func(LineageChange)
+	nextsub int
+}
+
+// NewLineageSet creates a new LineageSet containing the provided lineages.
+//
+// An error wrapping [terrors.ErrDuplicateLineage] is returned if any two of
+// the provided lineages have the same name.
+func NewLineageSet(lins ...Lineage) (*LineageSet, error) {
+	set := &LineageSet{}
+	for _, lin := range lins {
+		if err := set.Register(lin); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// Register adds the provided lineage to the set.
+//
+// An error wrapping [terrors.ErrDuplicateLineage] is returned if a lineage
+// with the same name is already in the set. Because lineage names are the only
+// key, registering the same lineage twice is also an error.
+func (s *LineageSet) Register(lin Lineage) error {
+	isValidLineage(lin)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lins == nil {
+		s.lins = make(map[string]Lineage)
+	}
+	if _, has := s.lins[lin.Name()]; has {
+		return errors.Mark(errors.Newf("lineage %q is already registered", lin.Name()), terrors.ErrDuplicateLineage)
+	}
+	s.lins[lin.Name()] = lin
+	return nil
+}
+
+// Replace adds the provided lineage to the set, replacing any existing lineage
+// with the same name. It is intended for reloading lineages, such as with
+// [github.com/grafana/thema/load.Watch].
+//
+// If an existing lineage is replaced by one with a newer latest schema
+// version, all subscribers registered with [LineageSet.Subscribe] are notified
+// before Replace returns.
+func (s *LineageSet) Replace(lin Lineage) {
+	isValidLineage(lin)
+
+	s.mu.Lock()
+	if s.lins == nil {
+		s.lins = make(map[string]Lineage)
+	}
+	old, had := s.lins[lin.Name()]
+	s.lins[lin.Name()] = lin
+	s.mu.Unlock()
+
+	if !had || !old.Latest().Version().Less(lin.Latest().Version()) {
+		return
+	}
+
+	change := LineageChange{
+		Name:      lin.Name(),
+		Old:       old,
+		New:       lin,
+		OldLatest: old.Latest().Version(),
+		NewLatest: lin.Latest().Version(),
+		Plan: TranslationPlan{
+			Lineage: lin,
+			To:      lin.Latest().Version(),
+		},
+	}
+
+	s.submu.Lock()
+	ids := make([]int, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	fns := make([]func(LineageChange), 0, len(ids))
+	for _, id := range ids {
+		fns = append(fns, s.subs[id])
+	}
+	s.submu.Unlock()
+
+	for _, fn := range fns {
+		fn(change)
+	}
+}
+
+// Subscribe registers fn to be called each time a lineage in the set is
+// replaced by one with a newer latest schema version, via
+// [LineageSet.Replace]. Subscribers are called synchronously, in the order in
+// which they subscribed. The returned func removes the subscription.
+func (s *LineageSet) Subscribe(fn func(LineageChange)) (unsubscribe func()) {
+	s.submu.Lock()
+	defer s.submu.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[int]func(LineageChange))
+	}
+	id := s.nextsub
+	s.nextsub++
+	s.subs[id] = fn
+
+	return func() {
+		s.submu.Lock()
+		defer s.submu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+// A LineageChange describes the replacement of a lineage in a [LineageSet]
+// with a version having a newer latest schema.
+type LineageChange struct {
+	// Name is the name of the lineage.
+	Name string
+
+	// Old and New are the replaced and replacing lineages.
+	Old, New Lineage
+
+	// OldLatest and NewLatest are the versions of the latest schemas in the
+	// replaced and replacing lineages.
+	OldLatest, NewLatest SyntacticVersion
+
+	// Plan translates instances of either lineage to NewLatest, allowing
+	// consumers to catch up stored objects to the new latest schema.
+	Plan TranslationPlan
+}
+
+// A TranslationPlan translates instances to a target schema version in a
+// lineage.
+type TranslationPlan struct {
+	// Lineage is the lineage containing the target schema.
+	Lineage Lineage
+
+	// To is the version of the target schema.
+	To SyntacticVersion
+}
+
+// Apply translates the provided instance to the plan's target version.
+//
+// The instance may come from any lineage with the same name as the plan's
+// lineage, such as an earlier version of it that was replaced in a
+// [LineageSet]. Such instances are first revalidated against the schema of the
+// same version in the plan's lineage, after being copied into its
+// [Runtime], if necessary.
+func (p TranslationPlan) Apply(inst *Instance) (*Instance, TranslationLacunas, error) {
+	if inst.Schema().Lineage() != p.Lineage {
+		if name := inst.Schema().Lineage().Name(); name != p.Lineage.Name() {
+			return nil, nil, errors.Newf("cannot translate instance of lineage %q with plan for lineage %q", name, p.Lineage.Name())
+		}
+		sch, err := p.Lineage.Schema(inst.Schema().Version())
+		if err != nil {
+			return nil, nil, err
+		}
+		data := inst.Underlying()
+		if rt := p.Lineage.Runtime(); rt != inst.Schema().Lineage().Runtime() {
+			b, err := data.MarshalJSON()
+			if err != nil {
+				return nil, nil, err
+			}
+			data = rt.Context().CompileBytes(b)
+		}
+		if inst, err = sch.Validate(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return inst.Translate(p.To)
+}
+
+// A TranslationResult is the result of translating a single instance as part
+// of [TranslationPlan.ApplyAll].
+type TranslationResult struct {
+	Instance *Instance
+	Lacunas  TranslationLacunas
+	Err      error
+}
+
+// ApplyAll calls [TranslationPlan.Apply] on each of the provided instances,
+// returning the results in the same order. A failure to translate one instance
+// does not prevent translation of the others.
+func (p TranslationPlan) ApplyAll(insts []*Instance) []TranslationResult {
+	results := make([]TranslationResult, len(insts))
+	for i, inst := range insts {
+		results[i].Instance, results[i].Lacunas, results[i].Err = p.Apply(inst)
+	}
+	return results
+}
+
+// Get returns the lineage with the provided name, if it is in the set.
+func (s *LineageSet) Get(name string) (Lineage, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lin, has := s.lins[name]
+	return lin, has
+}
+
+// Range calls fn for each lineage in the set, in ascending order by name. If fn
+// returns false, Range stops iterating.
+//
+// The set may not be modified from within fn.
+func (s *LineageSet) Range(fn func(name string, lin Lineage) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, name := range s.names() {
+		if !fn(name, s.lins[name]) {
+			return
+		}
+	}
+}
+
+// Names returns the names of all lineages in the set, in ascending order.
+func (s *LineageSet) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.names()
+}
+
+func (s *LineageSet) names() []string {
+	names := make([]string, 0, len(s.lins))
+	for name := range s.lins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of lineages in the set.
+func (s *LineageSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.lins)
+}
@@ -0,0 +1,124 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestLineageSet(t *testing.T) {
+	foo := testLin(`name: "foo"
+schemas: [{version: [0, 0], schema: a: string}]
+`)
+	bar := testLin(`name: "bar"
+schemas: [{version: [0, 0], schema: b: string}]
+`)
+
+	set, err := NewLineageSet(foo, bar)
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+	assert.Equal(t, []string{"bar", "foo"}, set.Names())
+
+	lin, has := set.Get("foo")
+	require.True(t, has)
+	assert.Equal(t, foo, lin)
+	_, has = set.Get("baz")
+	assert.False(t, has)
+
+	var seen []string
+	set.Range(func(name string, _ Lineage) bool {
+		seen = append(seen, name)
+		return false
+	})
+	assert.Equal(t, []string{"bar"}, seen)
+
+	err = set.Register(testLin(`name: "foo"
+schemas: [{version: [0, 0], schema: c: string}]
+`))
+	assert.True(t, errors.Is(err, terrors.ErrDuplicateLineage))
+
+	_, err = NewLineageSet(foo, foo)
+	assert.True(t, errors.Is(err, terrors.ErrDuplicateLineage))
+
+	var zero LineageSet
+	require.NoError(t, zero.Register(foo))
+	assert.Equal(t, 1, zero.Len())
+}
+
+func TestLineageSetSubscribe(t *testing.T) {
+	v1 := testLin(`name: "foo"
+schemas: [{version: [0, 0], schema: title: string}]
+`)
+	v2 := testLin(`name: "foo"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	schema: name: string
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`)
+
+	set, err := NewLineageSet(v1)
+	require.NoError(t, err)
+
+	var changes []LineageChange
+	unsub := set.Subscribe(func(c LineageChange) {
+		changes = append(changes, c)
+	})
+
+	// Replacing with the same latest version does not notify
+	set.Replace(v1)
+	assert.Empty(t, changes)
+
+	set.Replace(v2)
+	require.Len(t, changes, 1)
+	c := changes[0]
+	assert.Equal(t, "foo", c.Name)
+	assert.Equal(t, SV(0, 0), c.OldLatest)
+	assert.Equal(t, SV(1, 0), c.NewLatest)
+	lin, _ := set.Get("foo")
+	assert.Equal(t, v2, lin)
+
+	ctx := v1.Runtime().Context()
+	good, err := v1.First().Validate(ctx.CompileString(`{title: "hi"}`))
+	require.NoError(t, err)
+	results := c.Plan.ApplyAll([]*Instance{good})
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, SV(1, 0), results[0].Instance.Schema().Version())
+	name, err := results[0].Instance.Underlying().LookupPath(cue.ParsePath("name")).String()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", name)
+
+	other := testLin(`name: "bar"
+schemas: [{version: [0, 0], schema: title: string}]
+`)
+	binst, err := other.First().Validate(ctx.CompileString(`{title: "hi"}`))
+	require.NoError(t, err)
+	_, _, err = c.Plan.Apply(binst)
+	assert.Error(t, err)
+
+	unsub()
+	set.Replace(v1)
+	set.Replace(v2)
+	assert.Len(t, changes, 1)
+}
@@ -0,0 +1,163 @@
+package thema
+
+import (
+	"encoding/json"
+	"sort"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// keyAttr is the name of the CUE attribute that declares the field
+// identifying each element of a list of structs, e.g.:
+//
+//	panels: [...{id: int, title: string}] @key(id)
+//
+// Elements with the same key are treated as the same element, wherever they
+// appear in the list. The key is honored by [DiffInstances], [Merge] and
+// [Instance.Translate].
+const keyAttr = "key"
+
+// listKey returns the identity key declared on the list field, if any.
+func listKey(field cue.Value) string {
+	for _, a := range valueAttrs(field) {
+		if a.Name == keyAttr && len(a.Args) > 0 {
+			return a.Args[0].Key
+		}
+	}
+	return ""
+}
+
+// hasListKeys reports whether any list in the schema declares an identity
+// key.
+func (sch *schemaDef) hasListKeys() bool {
+	sch.keyedOnce.Do(func() {
+		walkFields(sch.def, nil, func(_ []string, fv cue.Value) {
+			if !sch.keyed && listKey(fv) != "" {
+				sch.keyed = true
+			}
+		})
+	})
+	return sch.keyed
+}
+
+// keyedElems identifies the elements of a decoded list by the JSON encoding of
+// the value of their key field, returning the identities in list order and
+// the index of each. It returns false if any element is not a struct
+// containing the key field, or if two elements share an identity.
+func keyedElems(l []any, key string) ([]string, map[string]int, bool) {
+	ids := make([]string, 0, len(l))
+	index := make(map[string]int, len(l))
+	for i, elem := range l {
+		em, is := elem.(map[string]any)
+		if !is {
+			return nil, nil, false
+		}
+		kv, has := em[key]
+		if !has {
+			return nil, nil, false
+		}
+		b, err := json.Marshal(kv)
+		if err != nil {
+			return nil, nil, false
+		}
+		id := string(b)
+		if _, dup := index[id]; dup {
+			return nil, nil, false
+		}
+		ids = append(ids, id)
+		index[id] = i
+	}
+	return ids, index, true
+}
+
+// alignTranslated aligns the keyed lists in out, the result of translating
+// inst, with those in inst, as by alignKeyedLists.
+func alignTranslated(inst, out *Instance) (*Instance, error) {
+	sch, is := out.Schema().(*schemaDef)
+	if !is || out == inst || !sch.hasListKeys() {
+		return out, nil
+	}
+
+	var od, id any
+	if err := out.Underlying().Decode(&od); err != nil {
+		return out, nil
+	}
+	if err := inst.Underlying().Decode(&id); err != nil {
+		return out, nil
+	}
+	if !alignKeyedLists(sch.def, od, id) {
+		return out, nil
+	}
+	aligned, err := sch.Validate(out.Underlying().Context().Encode(od))
+	if err != nil {
+		return nil, errors.Mark(err, terrors.ErrLensResultIsInvalidData)
+	}
+	return aligned, nil
+}
+
+// alignKeyedLists reorders the elements of keyed lists in out, the result of
+// translating in, to follow the order of the same elements in in. Elements
+// only in out keep their positions. Lists are matched by path, so only those
+// at the same path in both schemas are aligned. It reports whether any list
+// was reordered.
+func alignKeyedLists(sch cue.Value, out, in any) bool {
+	var changed bool
+	switch x := out.(type) {
+	case map[string]any:
+		im, _ := in.(map[string]any)
+		for k, v := range x {
+			fsch, _, _ := lookupField(sch, []string{k})
+			if alignKeyedLists(fsch, v, im[k]) {
+				changed = true
+			}
+		}
+	case []any:
+		il, _ := in.([]any)
+		esch, _, _ := lookupField(sch, []string{"*"})
+		key := listKey(sch)
+		if key == "" {
+			for i, v := range x {
+				if i < len(il) && alignKeyedLists(esch, v, il[i]) {
+					changed = true
+				}
+			}
+			return changed
+		}
+
+		oids, _, ook := keyedElems(x, key)
+		_, iindex, iok := keyedElems(il, key)
+		if !ook || !iok {
+			return false
+		}
+		// The positions of elements common to both lists are refilled with
+		// those elements, in the order of in
+		type elem struct {
+			id string
+			v  any
+		}
+		var slots []int
+		var common []elem
+		for i, id := range oids {
+			if _, has := iindex[id]; has {
+				slots = append(slots, i)
+				common = append(common, elem{id, x[i]})
+			}
+		}
+		sort.SliceStable(common, func(a, b int) bool {
+			return iindex[common[a].id] < iindex[common[b].id]
+		})
+		for n, i := range slots {
+			if oids[i] != common[n].id {
+				changed = true
+			}
+			x[i] = common[n].v
+			if alignKeyedLists(esch, common[n].v, il[iindex[common[n].id]]) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,67 @@
+package thema
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reversinglinstr is a lineage whose lens to 1.0 reverses the order of panels.
+const reversinglinstr = `name: "reversing"
+schemas: [{
+	version: [0, 0]
+	schema: panels: [...{id: int, title: string}] @key(id)
+},
+{
+	version: [1, 0]
+	schema: {
+		rev: int
+		panels: [...{id: int, name: string}] %s
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: panels: [for p in input.panels {id: p.id, title: p.name}]
+	lacunas: []
+},
+{
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {
+		rev: 1
+		panels: [for i, _ in input.panels {
+			let p = input.panels[len(input.panels)-1-i]
+			{id: p.id, name: p.title}
+		}]
+	}
+	lacunas: []
+}]
+`
+
+func TestTranslateKeyedLists(t *testing.T) {
+	for name, tc := range map[string]struct {
+		attr string
+		want string
+	}{
+		"keyed":   {"@key(id)", `{"rev": 1, "panels": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}]}`},
+		"unkeyed": {"", `{"rev": 1, "panels": [{"id": 3, "name": "c"}, {"id": 2, "name": "b"}, {"id": 1, "name": "a"}]}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lin := testLin(fmt.Sprintf(reversinglinstr, tc.attr))
+			inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(`{panels: [{id: 1, title: "a"}, {id: 2, title: "b"}, {id: 3, title: "c"}]}`))
+			require.NoError(t, err)
+
+			tinst, _, err := inst.Translate(SV(1, 0))
+			require.NoError(t, err)
+			assert.Equal(t, SV(1, 0), tinst.Schema().Version())
+			b, err := tinst.Underlying().MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(b))
+		})
+	}
+}
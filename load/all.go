@@ -0,0 +1,111 @@
+package load
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+)
+
+// maxLineageDepth is the maximum depth within a CUE package at which LoadAll
+// searches for lineages.
+const maxLineageDepth = 8
+
+// LoadAll loads every CUE package in the provided fs.FS, binds every lineage
+// declared within them against the provided [thema.Runtime], and returns them
+// all in a single [thema.LineageSet].
+//
+// The fsys is treated as a CUE module root, as with [InstanceWithThema]; a
+// cue.mod/module.cue is synthesized if absent. Every directory containing .cue
+// files, other than those under cue.mod, is loaded. Within each package, any
+// struct with both a "name" and a "schemas" field is treated as a lineage and
+// bound. Lineages may be nested within other structs, but lineages within
+// definitions or hidden fields are ignored.
+//
+// An error is returned if any package fails to load, any lineage fails to
+// bind, or if two lineages share the same name, in which case the error wraps
+// [github.com/grafana/thema/errors.ErrDuplicateLineage].
+func LoadAll(fsys fs.FS, rt *thema.Runtime, opts ...Option) (*thema.LineageSet, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	modfs := AsModFS(fsys, bundleModule)
+
+	var dirs []string
+	seen := make(map[string]bool)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == "cue.mod" || d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if dir := path.Dir(p); path.Ext(p) == ".cue" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := &thema.LineageSet{}
+	for _, dir := range dirs {
+		dopts := opts
+		if lc.pkgname == "" {
+			pkgname, err := dirPackage(fsys, dir)
+			if err != nil {
+				return nil, err
+			}
+			dopts = append(dopts[:len(dopts):len(dopts)], Package(pkgname))
+		}
+
+		binst, err := InstanceWithThema(modfs, dir, dopts...)
+		if err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", dir, err)
+		}
+		v := rt.Context().BuildInstance(binst)
+		if v.Err() != nil {
+			return nil, fmt.Errorf("error building %s: %w", dir, v.Err())
+		}
+
+		for _, linv := range findLineages(v, nil, 0) {
+			lin, err := thema.BindLineage(linv, rt)
+			if err != nil {
+				return nil, fmt.Errorf("error binding lineage at %s in %s: %w", linv.Path(), dir, err)
+			}
+			if err := set.Register(lin); err != nil {
+				return nil, fmt.Errorf("error registering lineage at %s in %s: %w", linv.Path(), dir, err)
+			}
+		}
+	}
+	return set, nil
+}
+
+// findLineages appends all values within v that appear to be lineages.
+func findLineages(v cue.Value, a []cue.Value, depth int) []cue.Value {
+	if depth > maxLineageDepth || v.IncompleteKind() != cue.StructKind {
+		return a
+	}
+	if v.LookupPath(cue.MakePath(cue.Str("schemas"))).Exists() {
+		if _, err := v.LookupPath(cue.MakePath(cue.Str("name"))).String(); err == nil {
+			return append(a, v)
+		}
+	}
+
+	iter, err := v.Fields()
+	if err != nil {
+		return a
+	}
+	for iter.Next() {
+		a = findLineages(iter.Value(), a, depth+1)
+	}
+	return a
+}
@@ -0,0 +1,68 @@
+package load
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/grafana/thema"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestLoadAll(t *testing.T) {
+	modfs := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte(`module: "example.com/kinds"`)},
+		"foo/foo.cue": {Data: []byte(`package foo
+
+import "github.com/grafana/thema"
+
+lin: thema.#Lineage & {
+	name: "foo"
+	schemas: [{
+		version: [0, 0]
+		schema: a: string
+	}]
+}
+`)},
+		"nested/bar/bar.cue": {Data: []byte(`package bar
+
+kinds: bar: lineage: {
+	name: "bar"
+	schemas: [{
+		version: [0, 0]
+		schema: b: int
+	}]
+}
+
+#NotALineage: {
+	name: "ignored"
+	schemas: [...]
+}
+`)},
+	}
+
+	rt := thema.NewRuntime(cuecontext.New())
+	set, err := LoadAll(modfs, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := set.Names(); len(names) != 2 || names[0] != "bar" || names[1] != "foo" {
+		t.Fatalf("unexpected lineages in set: %v", names)
+	}
+
+	modfs["dupe/dupe.cue"] = &fstest.MapFile{Data: []byte(`package dupe
+
+lin: {
+	name: "foo"
+	schemas: [{
+		version: [0, 0]
+		schema: c: bool
+	}]
+}
+`)}
+	if _, err := LoadAll(modfs, rt); !errors.Is(err, terrors.ErrDuplicateLineage) {
+		t.Fatalf("expected ErrDuplicateLineage, got %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package load
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+
+	"cuelang.org/go/cue/format"
+	"github.com/grafana/thema"
+)
+
+// BundleManifestFile is the path of the manifest within a multi-kind lineage
+// bundle.
+const BundleManifestFile = "thema-bundle.json"
+
+// bundleFormat is the version of the bundle layout written by Pack. Unpack
+// rejects bundles with a newer format.
+const bundleFormat = 1
+
+// ErrBundleMismatch indicates that a lineage in a multi-kind bundle differs
+// from what the bundle's manifest records for it.
+var ErrBundleMismatch = errors.New("lineage does not match bundle manifest")
+
+// A BundleManifest describes the contents of a multi-kind lineage bundle, as
+// written by [Pack] and read by [Unpack].
+type BundleManifest struct {
+	// Format is the version of the bundle layout.
+	Format int `json:"format"`
+
+	// Name and Version identify the bundle as a whole, such as a product and
+	// its release. They are opaque to Thema.
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// Kinds lists the lineages in the bundle, ordered by name.
+	Kinds []BundleKind `json:"kinds"`
+}
+
+// A BundleKind describes a single lineage within a [BundleManifest].
+type BundleKind struct {
+	// Name is the name of the lineage.
+	Name string `json:"name"`
+
+	// File is the path of the lineage's .cue file within the bundle.
+	File string `json:"file"`
+
+	// Versions are the versions of all schemas in the lineage, in order.
+	Versions []thema.SyntacticVersion `json:"versions"`
+
+	// Checksum is the checksum of the lineage, as computed by
+	// [LineageChecksum].
+	Checksum string `json:"checksum"`
+
+	// Digest is the SHA-256 digest of the lineage's .cue file, in the form
+	// "sha256:<hex>".
+	Digest string `json:"digest"`
+}
+
+// ZipArchive makes [Pack] write a zip archive, rather than a gzipped tarball.
+func ZipArchive() Option {
+	return func(c *loadConfig) {
+		c.zip = true
+	}
+}
+
+// Kinds restricts [Unpack] to loading only the named lineages from a bundle,
+// so that programs needing a few kinds from a large bundle need not load
+// them all. Unpacking fails if any named lineage is absent from the bundle.
+func Kinds(names ...string) Option {
+	return func(c *loadConfig) {
+		c.kinds = append(c.kinds, names...)
+	}
+}
+
+// WithProgress makes [Pack] report its progress to p, as each lineage is
+// exported.
+func WithProgress(p thema.Progress) Option {
+	return func(c *loadConfig) {
+		c.progress = p
+	}
+}
+
+// Pack writes every lineage in the set to w as a single archive, so that the
+// schemas of a whole product may be versioned and shipped as one artifact,
+// such as a release asset. The name and version identify the bundle, and are
+// recorded in its manifest, which is returned.
+//
+// The bundle contains the manifest at [BundleManifestFile], and each lineage,
+// as exported by [thema.Lineage.Export], at kinds/<name>/lineage.cue. It is a
+// gzipped tarball, unless the [ZipArchive] Option is provided. Bundles are
+// reproducible: packing the same lineages yields identical bytes.
+func Pack(w io.Writer, set *thema.LineageSet, name, version string, opts ...Option) (*BundleManifest, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	prog := lc.progress
+	if prog == nil {
+		prog = nopProgress{}
+	}
+
+	prog.OnStart("pack", set.Len())
+	man, err := pack(w, set, name, version, lc, prog)
+	prog.OnFinish(err)
+	return man, err
+}
+
+func pack(w io.Writer, set *thema.LineageSet, name, version string, lc *loadConfig, prog thema.Progress) (*BundleManifest, error) {
+	man := &BundleManifest{
+		Format:  bundleFormat,
+		Name:    name,
+		Version: version,
+		Kinds:   []BundleKind{},
+	}
+	files := make(map[string][]byte)
+	for _, kname := range set.Names() {
+		lin, _ := set.Get(kname)
+		kind, b, err := packKind(kname, lin)
+		prog.OnItem(kname, err)
+		if err != nil {
+			return nil, err
+		}
+		man.Kinds = append(man.Kinds, kind)
+		files[kind.File] = b
+	}
+
+	mb, err := json.MarshalIndent(man, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var aw archiveWriter
+	if lc.zip {
+		aw = newZipWriter(w)
+	} else {
+		aw = newTarGzWriter(w)
+	}
+	if err := aw.add(BundleManifestFile, append(mb, '\n')); err != nil {
+		return nil, err
+	}
+	for _, kind := range man.Kinds {
+		if err := aw.add(kind.File, files[kind.File]); err != nil {
+			return nil, err
+		}
+	}
+	return man, aw.Close()
+}
+
+// packKind exports a single lineage for inclusion in a bundle, describing it
+// for the manifest.
+func packKind(kname string, lin thema.Lineage) (BundleKind, []byte, error) {
+	f, err := lin.Export()
+	if err != nil {
+		return BundleKind{}, nil, fmt.Errorf("error exporting lineage %q: %w", kname, err)
+	}
+	b, err := format.Node(f)
+	if err != nil {
+		return BundleKind{}, nil, fmt.Errorf("error formatting lineage %q: %w", kname, err)
+	}
+	sum, err := LineageChecksum(lin)
+	if err != nil {
+		return BundleKind{}, nil, err
+	}
+
+	kind := BundleKind{
+		Name:     kname,
+		File:     path.Join("kinds", kname, "lineage.cue"),
+		Checksum: sum,
+		Digest:   digestOf(b),
+	}
+	if !fs.ValidPath(kind.File) || path.Dir(path.Dir(kind.File)) != "kinds" {
+		return BundleKind{}, nil, fmt.Errorf("lineage name %q cannot be used as a path within a bundle", kname)
+	}
+	for _, sch := range lin.All() {
+		kind.Versions = append(kind.Versions, sch.Version())
+	}
+	return kind, b, nil
+}
+
+type nopProgress struct{}
+
+func (nopProgress) OnStart(string, int)  {}
+func (nopProgress) OnItem(string, error) {}
+func (nopProgress) OnFinish(error)       {}
+
+// An archiveWriter writes the files of a bundle to an archive.
+type archiveWriter interface {
+	add(name string, b []byte) error
+	io.Closer
+}
+
+type tarGzWriter struct {
+	gzw *gzip.Writer
+	tw  *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzWriter {
+	gzw := gzip.NewWriter(w)
+	return &tarGzWriter{gzw: gzw, tw: tar.NewWriter(gzw)}
+}
+
+func (aw *tarGzWriter) add(name string, b []byte) error {
+	if err := aw.tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(b)), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := aw.tw.Write(b)
+	return err
+}
+
+func (aw *tarGzWriter) Close() error {
+	if err := aw.tw.Close(); err != nil {
+		return err
+	}
+	return aw.gzw.Close()
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func newZipWriter(w io.Writer) *zipWriter {
+	return &zipWriter{zw: zip.NewWriter(w)}
+}
+
+func (aw *zipWriter) add(name string, b []byte) error {
+	fw, err := aw.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(b)
+	return err
+}
+
+func (aw *zipWriter) Close() error {
+	return aw.zw.Close()
+}
+
+// Unpack reads a multi-kind lineage bundle written by [Pack], and binds the
+// lineages it contains against the provided [thema.Runtime]. The lineages are
+// returned in a [thema.LineageSet], along with the bundle's manifest.
+//
+// Both gzipped tarball and zip bundles are accepted. If a [Checksum] Option is
+// provided, the bytes of the bundle are verified against it before anything
+// is loaded. If a [Kinds] Option is provided, only the named lineages are
+// loaded. Load Options, such as [Dependency], are honored when loading each
+// lineage.
+//
+// Each lineage loaded is verified against the manifest. An error wrapping
+// [ErrBundleMismatch] is returned if the digest of a lineage's file, or the
+// lineage's name, versions or checksum, differ from those recorded.
+func Unpack(r io.Reader, rt *thema.Runtime, opts ...Option) (*thema.LineageSet, *BundleManifest, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	bfs, man, err := openBundle(r, lc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kinds := man.Kinds
+	if len(lc.kinds) > 0 {
+		kinds = nil
+		for _, name := range lc.kinds {
+			kind, has := man.Kind(name)
+			if !has {
+				return nil, nil, fmt.Errorf("lineage bundle contains no lineage named %q", name)
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	set := &thema.LineageSet{}
+	for _, kind := range kinds {
+		if !fs.ValidPath(kind.File) {
+			return nil, nil, fmt.Errorf("lineage bundle manifest contains invalid path %q", kind.File)
+		}
+		b, err := fs.ReadFile(bfs, kind.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lineage bundle is missing file for lineage %q: %w", kind.Name, err)
+		}
+		if got := digestOf(b); kind.Digest != "" && got != kind.Digest {
+			return nil, nil, fmt.Errorf("%w: %s has digest %s, but recorded as %s", ErrBundleMismatch, kind.File, got, kind.Digest)
+		}
+
+		lin, err := bindFS(bfs, path.Dir(kind.File), rt, lc, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading lineage %q from bundle: %w", kind.Name, err)
+		}
+		if err := kind.verify(lin); err != nil {
+			return nil, nil, err
+		}
+		if err := set.Register(lin); err != nil {
+			return nil, nil, err
+		}
+	}
+	return set, man, nil
+}
+
+// ReadBundleManifest reads the manifest of a multi-kind lineage bundle written
+// by [Pack], without loading any of its lineages. If a [Checksum] Option is
+// provided, the bytes of the bundle are verified against it.
+func ReadBundleManifest(r io.Reader, opts ...Option) (*BundleManifest, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	_, man, err := openBundle(r, lc)
+	return man, err
+}
+
+// openBundle reads and verifies the bytes of a multi-kind bundle, returning
+// its files and manifest.
+func openBundle(r io.Reader, lc *loadConfig) (fs.FS, *BundleManifest, error) {
+	b, err := readBundle(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading lineage bundle: %w", err)
+	}
+	if lc.checksum != "" {
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != lc.checksum {
+			return nil, nil, fmt.Errorf("checksum mismatch for lineage bundle: expected %s, got %s", lc.checksum, got)
+		}
+	}
+	if !bytes.HasPrefix(b, []byte{0x1f, 0x8b}) && !bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		return nil, nil, errors.New("lineage bundle is neither a gzipped tarball nor a zip archive")
+	}
+	bfs, err := bundleFS(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mb, err := fs.ReadFile(bfs, BundleManifestFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lineage bundle has no manifest: %w", err)
+	}
+	man := &BundleManifest{}
+	if err := json.Unmarshal(mb, man); err != nil {
+		return nil, nil, fmt.Errorf("invalid lineage bundle manifest: %w", err)
+	}
+	if man.Format < 1 || man.Format > bundleFormat {
+		return nil, nil, fmt.Errorf("unsupported lineage bundle format %d", man.Format)
+	}
+	return bfs, man, nil
+}
+
+// Kind returns the entry for the lineage with the provided name, if any.
+func (m *BundleManifest) Kind(name string) (BundleKind, bool) {
+	for _, k := range m.Kinds {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return BundleKind{}, false
+}
+
+// verify checks that lin is the lineage described by the BundleKind.
+func (k BundleKind) verify(lin thema.Lineage) error {
+	if lin.Name() != k.Name {
+		return fmt.Errorf("%w: lineage in %s is named %q, but recorded as %q", ErrBundleMismatch, k.File, lin.Name(), k.Name)
+	}
+	var versions []thema.SyntacticVersion
+	for _, sch := range lin.All() {
+		versions = append(versions, sch.Version())
+	}
+	if !reflect.DeepEqual(versions, k.Versions) {
+		return fmt.Errorf("%w: lineage %q has versions %v, but recorded as %v", ErrBundleMismatch, k.Name, versions, k.Versions)
+	}
+
+	sum, err := LineageChecksum(lin)
+	if err != nil {
+		return err
+	}
+	if sum != k.Checksum {
+		return fmt.Errorf("%w: lineage %q has checksum %s, but recorded as %s", ErrBundleMismatch, k.Name, sum, k.Checksum)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package load
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+)
+
+func TestPackUnpack(t *testing.T) {
+	modfs := fstest.MapFS{
+		"foo/foo.cue": {Data: []byte(`package foo
+
+import "github.com/grafana/thema"
+
+lin: thema.#Lineage & {
+	name: "foo"
+	schemas: [{
+		version: [0, 0]
+		schema: a: string
+	}, {
+		version: [0, 1]
+		schema: {a: string, b?: int}
+	}]
+}
+`)},
+		"bar/bar.cue": {Data: []byte(`package bar
+
+lineage: {
+	name: "bar"
+	schemas: [{
+		version: [0, 0]
+		schema: b: int
+	}]
+}
+`)},
+	}
+	set, err := LoadAll(modfs, thema.NewRuntime(cuecontext.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	man, err := Pack(&buf, set, "product", "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Name != "product" || man.Version != "1.2.3" || len(man.Kinds) != 2 || man.Kinds[0].Name != "bar" || man.Kinds[1].Name != "foo" {
+		t.Fatalf("unexpected manifest: %+v", man)
+	}
+	if want := []thema.SyntacticVersion{{0, 0}, {0, 1}}; !reflect.DeepEqual(man.Kinds[1].Versions, want) {
+		t.Fatalf("expected foo versions %v, got %v", want, man.Kinds[1].Versions)
+	}
+
+	var again, progress bytes.Buffer
+	if _, err := Pack(&again, set, "product", "1.2.3", WithProgress(thema.ConsoleProgress(&progress, 0))); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Fatal("packing the same lineages twice produced different bundles")
+	}
+	if !bytes.Contains(progress.Bytes(), []byte("pack: 2/2 items (100%)")) || !bytes.Contains(progress.Bytes(), []byte("pack: finished 2 items")) {
+		t.Fatalf("unexpected progress output:\n%s", progress.String())
+	}
+
+	uset, uman, err := Unpack(bytes.NewReader(buf.Bytes()), thema.NewRuntime(cuecontext.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(man, uman) {
+		t.Fatalf("unpacked manifest differs: %+v", uman)
+	}
+	for _, kind := range man.Kinds {
+		lin, has := uset.Get(kind.Name)
+		if !has {
+			t.Fatalf("lineage %q missing from unpacked set", kind.Name)
+		}
+		orig, _ := set.Get(kind.Name)
+		osum, _ := LineageChecksum(orig)
+		if sum, _ := LineageChecksum(lin); sum != osum {
+			t.Fatalf("lineage %q changed in bundle: checksum %s, expected %s", kind.Name, sum, osum)
+		}
+	}
+
+	// Tamper with the manifest
+	files := make(map[string][]byte)
+	bfs, err := bundleFS(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range bfs.(fstest.MapFS) {
+		files[name] = f.Data
+	}
+	man.Kinds[0].Checksum = "bogus"
+	files[BundleManifestFile], _ = json.Marshal(man)
+	_, _, err = Unpack(bytes.NewReader(tgz(t, files)), thema.NewRuntime(cuecontext.New()))
+	if !errors.Is(err, ErrBundleMismatch) {
+		t.Fatalf("expected ErrBundleMismatch, got %v", err)
+	}
+
+	delete(files, BundleManifestFile)
+	if _, _, err = Unpack(bytes.NewReader(tgz(t, files)), thema.NewRuntime(cuecontext.New())); err == nil {
+		t.Fatal("expected error unpacking bundle without manifest")
+	}
+}
+
+func TestBundleArchives(t *testing.T) {
+	modfs := fstest.MapFS{}
+	for _, name := range []string{"one", "two", "three"} {
+		modfs[name+"/"+name+".cue"] = &fstest.MapFile{Data: []byte(`package ` + name + `
+
+lin: {
+	name: "` + name + `"
+	schemas: [{
+		version: [0, 0]
+		schema: a: string
+	}]
+}
+`)}
+	}
+	set, err := LoadAll(modfs, thema.NewRuntime(cuecontext.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var zbuf bytes.Buffer
+	man, err := Pack(&zbuf, set, "product", "2.0.0", ZipArchive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(zbuf.Bytes(), []byte("PK\x03\x04")) {
+		t.Fatal("expected a zip archive")
+	}
+	zman, err := ReadBundleManifest(bytes.NewReader(zbuf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(man, zman) {
+		t.Fatalf("read manifest differs: %+v", zman)
+	}
+
+	sum := sha256.Sum256(zbuf.Bytes())
+	uset, _, err := Unpack(bytes.NewReader(zbuf.Bytes()), thema.NewRuntime(cuecontext.New()), Checksum(hex.EncodeToString(sum[:])), Kinds("two", "three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := uset.Names(); !reflect.DeepEqual(names, []string{"three", "two"}) {
+		t.Fatalf("expected only the requested lineages, got %v", names)
+	}
+
+	if _, _, err = Unpack(bytes.NewReader(zbuf.Bytes()), thema.NewRuntime(cuecontext.New()), Kinds("four")); err == nil {
+		t.Fatal("expected error unpacking absent lineage")
+	}
+	if _, _, err = Unpack(bytes.NewReader(zbuf.Bytes()), thema.NewRuntime(cuecontext.New()), Checksum("00")); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+
+	// Tamper with a lineage file, but not the manifest
+	var tbuf bytes.Buffer
+	if _, err := Pack(&tbuf, set, "product", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	bfs, err := bundleFS(tbuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for name, f := range bfs.(fstest.MapFS) {
+		files[name] = f.Data
+	}
+	files["kinds/one/lineage.cue"] = append(files["kinds/one/lineage.cue"], "\n// tampered\n"...)
+	tampered := tgz(t, files)
+	if _, _, err = Unpack(bytes.NewReader(tampered), thema.NewRuntime(cuecontext.New())); !errors.Is(err, ErrBundleMismatch) {
+		t.Fatalf("expected ErrBundleMismatch, got %v", err)
+	}
+	if _, _, err = Unpack(bytes.NewReader(tampered), thema.NewRuntime(cuecontext.New()), Kinds("two")); err != nil {
+		t.Fatalf("unexpected error unpacking untampered lineage: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/grafana/thema"
+)
+
+// LoadGit loads a lineage from the provided git repository as it existed at
+// the provided ref, and binds it against the provided [thema.Runtime].
+//
+// The repo may be the path to a local repository, or any URL supported by
+// go-git, such as an https or ssh URL. Remote repositories are cloned into
+// memory. The ref may be any revision go-git can resolve, such as a full commit
+// hash, a tag, or a branch name. Pinning a commit hash or tag ensures that the
+// same lineage is bound each time, regardless of later changes to the
+// repository. The dir is the directory containing the CUE package with the
+// lineage, relative to the repository root.
+//
+// The repository root is treated as the CUE module root. If the repository
+// contains no cue.mod/module.cue, one is synthesized. As with [LoadURL], the
+// lineage must be the root value of the package unless a [LineagePath] option
+// is provided, and other Options are honored.
+func LoadGit(ctx context.Context, repo, ref, dir string, rt *thema.Runtime, opts ...Option) (thema.Lineage, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	if ref == "" {
+		return nil, fmt.Errorf("a git ref is required to load a lineage from %s", repo)
+	}
+	// Neither is ever a valid repository or revision, and either could be
+	// mistaken for an option by tooling that passes them on to git.
+	if strings.HasPrefix(repo, "-") {
+		return nil, fmt.Errorf("invalid git repository %q: may not begin with '-'", repo)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q: may not begin with '-'", ref)
+	}
+	dir = path.Clean(strings.TrimPrefix(dir, "/"))
+
+	r, err := openRepo(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repository %s: %w", repo, err)
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve ref %s in %s: %w", ref, repo, err)
+	}
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("ref %s in %s is not a commit: %w", ref, repo, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(fstest.MapFS)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !f.Mode.IsFile() {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		return addBundleFile(m, f.Name, []byte(contents))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bindFS(m, dir, rt, lc, opts)
+}
+
+// openRepo opens the local repository at repo if it is a directory, and
+// otherwise clones the remote repository at repo into memory.
+func openRepo(ctx context.Context, repo string) (*git.Repository, error) {
+	if fi, err := os.Stat(repo); err == nil && fi.IsDir() {
+		return git.PlainOpen(repo)
+	}
+	return git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+		URL:    repo,
+		Mirror: true,
+	})
+}
@@ -0,0 +1,100 @@
+package load
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/grafana/thema"
+)
+
+func TestLoadGit(t *testing.T) {
+	repo := t.TempDir()
+	r, err := git.PlainInit(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+
+	commit := func(msg, schema string) string {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(repo, "kinds"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		lin := `package kinds
+
+import "github.com/grafana/thema"
+
+lin: thema.#Lineage & {
+	name: "gitlin"
+	schemas: [` + schema + `]
+}
+`
+		if err := os.WriteFile(filepath.Join(repo, "kinds", "lin.cue"), []byte(lin), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("kinds/lin.cue"); err != nil {
+			t.Fatal(err)
+		}
+		h, err := wt.Commit(msg, &git.CommitOptions{Author: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.String()
+	}
+
+	first := commit("first", `{version: [0, 0], schema: a: string}`)
+	if _, err := r.CreateTag("v1", mustHash(t, r, first), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v1-annotated", mustHash(t, r, first), &git.CreateTagOptions{Tagger: sig, Message: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	commit("second", `{version: [0, 0], schema: a: string}, {version: [0, 1], schema: {a: string, b?: int}}`)
+
+	ctx := context.Background()
+	rt := thema.NewRuntime(cuecontext.New())
+	for ref, expect := range map[string]thema.SyntacticVersion{
+		first:          thema.SV(0, 0),
+		"v1":           thema.SV(0, 0),
+		"v1-annotated": thema.SV(0, 0),
+		"HEAD":         thema.SV(0, 1),
+	} {
+		lin, err := LoadGit(ctx, repo, ref, "kinds", rt, LineagePath("lin"))
+		if err != nil {
+			t.Fatalf("%s: %s", ref, err)
+		}
+		if lin.Latest().Version() != expect {
+			t.Fatalf("%s: expected latest version %s, got %s", ref, expect, lin.Latest().Version())
+		}
+	}
+
+	for _, args := range [][2]string{
+		{repo, "nope"},
+		{repo, "--output=/tmp/x"},
+		{"--upload-pack=touch /tmp/x", "HEAD"},
+	} {
+		if _, err := LoadGit(ctx, args[0], args[1], "kinds", rt, LineagePath("lin")); err == nil {
+			t.Fatalf("expected repo %q at ref %q to fail", args[0], args[1])
+		}
+	}
+}
+
+func mustHash(t *testing.T, r *git.Repository, rev string) plumbing.Hash {
+	t.Helper()
+	h, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *h
+}
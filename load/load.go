@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing/fstest"
+	"time"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/build"
@@ -77,9 +80,26 @@ var themamodpath = filepath.Join("cue.mod", "pkg", "github.com", "grafana", "the
 //   - "." and the empty string are a special value that will load the root
 //     directory of the modFS.
 //
+// The Thema CUE package is injected at cue.mod/pkg/github.com/grafana/thema.
+// If the modFS already contains that path, an error is returned, unless the
+// [ReplaceThema] option is passed.
+//
 // NOTE - this function is likely to be deprecated and removed in favor of a
 // more generic dependency overlay loader.
 func InstanceWithThema(modFS fs.FS, dir string, opts ...Option) (*build.Instance, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	for _, dep := range lc.deps {
+		if dep.modpath == "" || dep.fsys == nil {
+			return nil, fmt.Errorf("dependencies must have a non-empty module path and non-nil fs.FS")
+		}
+		if dep.modpath == "github.com/grafana/thema" {
+			return nil, fmt.Errorf("thema is always injected, and may not be passed as a dependency")
+		}
+	}
+
 	var modname string
 	err := fs.WalkDir(modFS, "cue.mod", func(path string, d fs.DirEntry, err error) error {
 		// fs.FS implementations tend to not use path separators as expected. Use a
@@ -94,6 +114,9 @@ func InstanceWithThema(modFS fs.FS, dir string, opts ...Option) (*build.Instance
 			case filepath.Join("cue.mod", "gen"), filepath.Join("cue.mod", "usr"):
 				return fs.SkipDir
 			case themamodpath:
+				if lc.replacethema {
+					return fs.SkipDir
+				}
 				return fmt.Errorf("path %q already exists in modFS passed to InstancesWithThema, must be absent for dynamic dependency injection", themamodpath)
 			}
 			return nil
@@ -133,6 +156,16 @@ func InstanceWithThema(modFS fs.FS, dir string, opts ...Option) (*build.Instance
 	if err := util.ToOverlay(modroot, modFS, overlay); err != nil {
 		return nil, err
 	}
+	if lc.replacethema {
+		// Drop any vendored copy of the Thema CUE package, so that the injected
+		// one is the only one CUE can see
+		vendored := filepath.Join(modroot, themamodpath) + string(filepath.Separator)
+		for path := range overlay {
+			if strings.HasPrefix(path, vendored) {
+				delete(overlay, path)
+			}
+		}
+	}
 
 	// Special case for when we're calling this loader with paths inside the thema module
 	if modname == "github.com/grafana/thema" {
@@ -145,6 +178,12 @@ func InstanceWithThema(modFS fs.FS, dir string, opts ...Option) (*build.Instance
 		}
 	}
 
+	for _, dep := range lc.deps {
+		if err := ToOverlay(filepath.Join(modroot, "cue.mod", "pkg", filepath.FromSlash(dep.modpath)), dep.fsys, overlay); err != nil {
+			return nil, fmt.Errorf("error injecting dependency %q: %w", dep.modpath, err)
+		}
+	}
+
 	cfg := &load.Config{
 		Overlay:    overlay,
 		ModuleRoot: modroot,
@@ -152,19 +191,16 @@ func InstanceWithThema(modFS fs.FS, dir string, opts ...Option) (*build.Instance
 		Dir:        filepath.Join(modroot, dir),
 		DataFiles:  true,
 	}
-	lc := &loadConfig{
-		pkgname: filepath.Base(dir),
-	}
-
+	pkgname := filepath.Base(dir)
 	if dir == "" || dir == "." {
-		lc.pkgname = filepath.Base(modroot)
+		pkgname = filepath.Base(modroot)
 		cfg.Dir = modroot
 	}
-	for _, opt := range opts {
-		opt(lc)
+	if lc.pkgname != "" {
+		pkgname = lc.pkgname
 	}
 
-	cfg.Package = lc.pkgname
+	cfg.Package = pkgname
 
 	inst := load.Instances(nil, cfg)[0]
 	if inst.Err != nil {
@@ -187,7 +223,21 @@ type Option loadOption
 type loadOption func(c *loadConfig)
 
 type loadConfig struct {
-	pkgname string
+	pkgname      string
+	replacethema bool
+	deps         []dependency
+	checksum     string
+	httpclient   *http.Client
+	linpath      string
+	poll         time.Duration
+	zip          bool
+	kinds        []string
+	progress     thema.Progress
+}
+
+type dependency struct {
+	modpath string
+	fsys    fs.FS
 }
 
 // Package specifies a custom CUE package name use when loading CUE files.
@@ -198,6 +248,43 @@ func Package(name string) Option {
 	}
 }
 
+// ReplaceThema indicates that any copy of the Thema CUE package already present
+// in the modFS under cue.mod/pkg/github.com/grafana/thema should be ignored,
+// and replaced by the CUE package embedded in this Go module.
+//
+// By default, InstanceWithThema refuses to load a modFS that contains its own
+// copy of the Thema CUE package. This option allows loading modules that
+// vendor Thema by hand, while guaranteeing that import "github.com/grafana/thema"
+// always resolves to the version matching the Thema Go module in use.
+func ReplaceThema() Option {
+	return func(c *loadConfig) {
+		c.replacethema = true
+	}
+}
+
+// Dependency makes the CUE files in the provided fs.FS importable under the
+// provided module path when loading, by injecting them under
+// cue.mod/pkg/<modpath>. This allows loading lineages that import shared CUE
+// libraries from other modules without copying those libraries into every
+// module being loaded.
+//
+// The root of fsys must correspond to the root of the imported module, such
+// that import "<modpath>/sub" resolves to the "sub" directory within fsys.
+// Files injected by a Dependency take precedence over any files at the same
+// path already in the modFS. Other contents of cue.mod/pkg in the modFS remain
+// resolvable as usual.
+//
+// Dependency may be passed multiple times to inject multiple modules. It is an
+// error to pass the module path of Thema itself, which is always injected.
+func Dependency(modpath string, fsys fs.FS) Option {
+	return func(c *loadConfig) {
+		c.deps = append(c.deps, dependency{
+			modpath: modpath,
+			fsys:    fsys,
+		})
+	}
+}
+
 // ToOverlay maps the provided fs.FS into an Overlay for use in load.Config.
 //
 // An absolute path prefix must be provided.
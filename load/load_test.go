@@ -0,0 +1,97 @@
+package load
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestReplaceThema(t *testing.T) {
+	modfs := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte(`module: "example.com/foo"`)},
+		"cue.mod/pkg/github.com/grafana/thema/lineage.cue": {Data: []byte(`package thema
+
+#Lineage: {
+	stale: true
+	...
+}
+`)},
+		"foo.cue": {Data: []byte(`package foo
+
+import "github.com/grafana/thema"
+
+lin: thema.#Lineage & {
+	name: "foo"
+	schemas: [{
+		version: [0, 0]
+		schema: a: string
+	}]
+}
+`)},
+	}
+
+	if _, err := InstanceWithThema(modfs, "."); err == nil {
+		t.Fatal("expected error when loading modFS with vendored thema")
+	}
+
+	binst, err := InstanceWithThema(modfs, ".", ReplaceThema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cuecontext.New().BuildInstance(binst)
+	if v.Err() != nil {
+		t.Fatal(v.Err())
+	}
+	if v.LookupPath(cue.ParsePath("lin.stale")).Exists() {
+		t.Fatal("vendored thema package was loaded instead of the injected one")
+	}
+}
+
+func TestDependency(t *testing.T) {
+	modfs := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte(`module: "example.com/foo"`)},
+		"foo.cue": {Data: []byte(`package foo
+
+import (
+	"github.com/grafana/thema"
+	"example.com/shared/common"
+)
+
+lin: thema.#Lineage & {
+	name: "foo"
+	schemas: [{
+		version: [0, 0]
+		schema: id: common.#ID
+	}]
+}
+`)},
+	}
+	shared := fstest.MapFS{
+		"common/common.cue": {Data: []byte(`package common
+
+#ID: string & =~"^[a-z]+$"
+`)},
+	}
+
+	if _, err := InstanceWithThema(modfs, "."); err == nil {
+		t.Fatal("expected error when loading without dependency")
+	}
+
+	binst, err := InstanceWithThema(modfs, ".", Dependency("example.com/shared", shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cuecontext.New().BuildInstance(binst)
+	if v.Err() != nil {
+		t.Fatal(v.Err())
+	}
+	if !v.LookupPath(cue.ParsePath("lin.schemas[0].schema.id")).Exists() {
+		t.Fatal("expected schema field from dependency to exist")
+	}
+
+	if _, err := InstanceWithThema(modfs, ".", Dependency("github.com/grafana/thema", shared)); err == nil {
+		t.Fatal("expected error when passing thema as a dependency")
+	}
+}
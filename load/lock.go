@@ -0,0 +1,144 @@
+package load
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"cuelang.org/go/cue/format"
+	"github.com/grafana/thema"
+)
+
+// ErrLockMismatch indicates that a loaded lineage differs from what is
+// recorded for it in a [Lockfile].
+var ErrLockMismatch = errors.New("lineage does not match lockfile")
+
+// A Lockfile records the exact lineages a program expects to load, so that
+// repeated loads across environments can be verified to bind identical
+// lineages.
+//
+// Lockfiles are serialized as JSON. The zero value is an empty Lockfile,
+// ready for use.
+type Lockfile struct {
+	Lineages []LockEntry `json:"lineages"`
+}
+
+// A LockEntry records a single lineage within a [Lockfile].
+type LockEntry struct {
+	// Name is the name of the lineage.
+	Name string `json:"name"`
+
+	// Source describes where the lineage is loaded from, e.g. a URL, OCI
+	// reference, or git repository and ref. It is opaque to Thema.
+	Source string `json:"source"`
+
+	// Version is the latest schema version in the lineage.
+	Version thema.SyntacticVersion `json:"version"`
+
+	// Checksum is the checksum of the lineage, as computed by
+	// [LineageChecksum].
+	Checksum string `json:"checksum"`
+}
+
+// ReadLockfile reads a JSON-encoded [Lockfile].
+func ReadLockfile(r io.Reader) (*Lockfile, error) {
+	lf := &Lockfile{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(lf); err != nil {
+		return nil, fmt.Errorf("invalid lockfile: %w", err)
+	}
+	return lf, nil
+}
+
+// Write writes the Lockfile as indented JSON.
+func (lf *Lockfile) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(lf)
+}
+
+// Entry returns the entry for the lineage with the provided name, if any.
+func (lf *Lockfile) Entry(name string) (LockEntry, bool) {
+	for _, e := range lf.Lineages {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return LockEntry{}, false
+}
+
+// Update records the provided lineage and its source in the Lockfile,
+// replacing any existing entry for a lineage of the same name. Entries are
+// kept sorted by name, so that lockfile contents are stable.
+func (lf *Lockfile) Update(source string, lin thema.Lineage) error {
+	sum, err := LineageChecksum(lin)
+	if err != nil {
+		return err
+	}
+	entry := LockEntry{
+		Name:     lin.Name(),
+		Source:   source,
+		Version:  lin.Latest().Version(),
+		Checksum: sum,
+	}
+
+	for i, e := range lf.Lineages {
+		if e.Name == entry.Name {
+			lf.Lineages[i] = entry
+			return nil
+		}
+	}
+	lf.Lineages = append(lf.Lineages, entry)
+	sort.Slice(lf.Lineages, func(i, j int) bool {
+		return lf.Lineages[i].Name < lf.Lineages[j].Name
+	})
+	return nil
+}
+
+// Verify checks that the provided lineage, loaded from the provided source,
+// is identical to the lineage recorded in the Lockfile. An error wrapping
+// [ErrLockMismatch] is returned if the lineage is absent from the Lockfile, or
+// if its source, latest version, or checksum differ from those recorded.
+func (lf *Lockfile) Verify(source string, lin thema.Lineage) error {
+	e, has := lf.Entry(lin.Name())
+	if !has {
+		return fmt.Errorf("%w: no entry for lineage %q", ErrLockMismatch, lin.Name())
+	}
+	if e.Source != source {
+		return fmt.Errorf("%w: lineage %q loaded from %q, but locked to %q", ErrLockMismatch, lin.Name(), source, e.Source)
+	}
+	if v := lin.Latest().Version(); v != e.Version {
+		return fmt.Errorf("%w: lineage %q has latest version %s, but locked to %s", ErrLockMismatch, lin.Name(), v, e.Version)
+	}
+
+	sum, err := LineageChecksum(lin)
+	if err != nil {
+		return err
+	}
+	if sum != e.Checksum {
+		return fmt.Errorf("%w: lineage %q has checksum %s, but locked to %s", ErrLockMismatch, lin.Name(), sum, e.Checksum)
+	}
+	return nil
+}
+
+// LineageChecksum computes a checksum of the provided lineage, suitable for
+// detecting any change to it. The checksum is taken over the formatted output
+// of [thema.Lineage.Export], and is therefore independent of how or where the
+// lineage was loaded.
+func LineageChecksum(lin thema.Lineage) (string, error) {
+	f, err := lin.Export()
+	if err != nil {
+		return "", err
+	}
+	b, err := format.Node(f)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
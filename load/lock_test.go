@@ -0,0 +1,79 @@
+package load
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+)
+
+func TestLockfile(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	bind := func(src string) thema.Lineage {
+		t.Helper()
+		lin, err := thema.BindLineage(rt.Context().CompileString(src), rt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lin
+	}
+	lin := bind(`name: "locked"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}]
+`)
+	changed := bind(`name: "locked"
+schemas: [{
+	version: [0, 0]
+	schema: a: string | int
+}]
+`)
+
+	lf := &Lockfile{}
+	if err := lf.Verify("src", lin); !errors.Is(err, ErrLockMismatch) {
+		t.Fatalf("expected ErrLockMismatch for unlocked lineage, got %v", err)
+	}
+	if err := lf.Update("src", lin); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := lf.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lf, err := ReadLockfile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lf.Verify("src", lin); err != nil {
+		t.Fatalf("expected identical lineage to verify: %s", err)
+	}
+	if err := lf.Verify("src", bind(`name: "locked"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}]
+`)); err != nil {
+		t.Fatalf("expected separately loaded identical lineage to verify: %s", err)
+	}
+	if err := lf.Verify("other", lin); !errors.Is(err, ErrLockMismatch) {
+		t.Fatalf("expected ErrLockMismatch for different source, got %v", err)
+	}
+	if err := lf.Verify("src", changed); !errors.Is(err, ErrLockMismatch) {
+		t.Fatalf("expected ErrLockMismatch for changed lineage, got %v", err)
+	}
+
+	if err := lf.Update("src", changed); err != nil {
+		t.Fatal(err)
+	}
+	if len(lf.Lineages) != 1 {
+		t.Fatalf("expected update to replace existing entry, got %d entries", len(lf.Lineages))
+	}
+	if err := lf.Verify("src", changed); err != nil {
+		t.Fatalf("expected updated lineage to verify: %s", err)
+	}
+}
@@ -0,0 +1,304 @@
+package load
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/thema"
+)
+
+const (
+	// LineageMediaType is the OCI media type of a layer containing a lineage
+	// bundle that is a single .cue file, such as one produced by
+	// [thema.Lineage.Export].
+	LineageMediaType = "application/vnd.grafana.thema.lineage.v1+cue"
+
+	// LineageArchiveMediaType is the OCI media type of a layer containing a
+	// lineage bundle that is a gzipped tarball of .cue files.
+	LineageArchiveMediaType = "application/vnd.grafana.thema.lineage.v1.tar+gzip"
+
+	// LineageConfigMediaType is the OCI media type of the config object of a
+	// lineage artifact. It is also used as the manifest's artifactType.
+	LineageConfigMediaType = "application/vnd.grafana.thema.config.v1+json"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// ociDescriptor is an OCI content descriptor.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	ArtifactType  string          `json:"artifactType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+// ociConfig is the config object of a lineage artifact.
+type ociConfig struct {
+	Name string `json:"name"`
+}
+
+// ociRef is a parsed OCI artifact reference, e.g.
+// registry.example.com/org/kind:v1 or registry.example.com/org/kind@sha256:...
+type ociRef struct {
+	registry, repository, reference string
+}
+
+func parseOCIRef(ref string) (ociRef, error) {
+	var r ociRef
+	slash := strings.IndexByte(ref, '/')
+	if slash <= 0 {
+		return r, fmt.Errorf("invalid OCI reference %q: must be of the form <registry>/<repository>[:<tag>|@<digest>]", ref)
+	}
+	r.registry, r.repository = ref[:slash], ref[slash+1:]
+
+	if at := strings.IndexByte(r.repository, '@'); at != -1 {
+		r.repository, r.reference = r.repository[:at], r.repository[at+1:]
+		if !strings.HasPrefix(r.reference, "sha256:") {
+			return r, fmt.Errorf("invalid OCI reference %q: only sha256 digests are supported", ref)
+		}
+	} else if colon := strings.LastIndexByte(r.repository, ':'); colon != -1 {
+		r.repository, r.reference = r.repository[:colon], r.repository[colon+1:]
+	} else {
+		r.reference = "latest"
+	}
+
+	if r.repository == "" || r.reference == "" {
+		return r, fmt.Errorf("invalid OCI reference %q", ref)
+	}
+	return r, nil
+}
+
+func (r ociRef) isDigest() bool {
+	return strings.HasPrefix(r.reference, "sha256:")
+}
+
+func (r ociRef) url(kind, ref string) string {
+	scheme := "https"
+	if host := strings.Split(r.registry, ":")[0]; host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, r.registry, r.repository, kind, url.PathEscape(ref))
+}
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// LoadOCI pulls a lineage artifact from an OCI registry, loads it, and binds
+// the lineage it contains against the provided [thema.Runtime].
+//
+// The reference takes the same form as for container images:
+// <registry>/<repository>:<tag>, e.g. registry.example.com/org/kind:v1. The tag
+// may be replaced by a digest, e.g. @sha256:..., in which case the pulled
+// manifest is verified against the digest. Digests of all pulled blobs are
+// always verified. Registries on localhost are accessed over plain HTTP;
+// all others require HTTPS.
+//
+// The artifact's manifest must contain exactly one layer with either the
+// [LineageMediaType] or [LineageArchiveMediaType] media type. The layer's
+// contents are treated as described in [LoadURL], and all the same Options
+// are honored. Registry authentication may be provided through the
+// [HTTPClient] option.
+func LoadOCI(ctx context.Context, ref string, rt *thema.Runtime, opts ...Option) (thema.Lineage, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	r, err := parseOCIRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	mb, err := ociGet(ctx, lc.client(), r.url("manifests", r.reference), ociManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching manifest for %s: %w", ref, err)
+	}
+	if r.isDigest() && digestOf(mb) != r.reference {
+		return nil, fmt.Errorf("manifest digest for %s does not match: got %s", ref, digestOf(mb))
+	}
+
+	var man ociManifest
+	if err := json.Unmarshal(mb, &man); err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s: %w", ref, err)
+	}
+	var layer *ociDescriptor
+	for i, l := range man.Layers {
+		if l.MediaType == LineageMediaType || l.MediaType == LineageArchiveMediaType {
+			if layer != nil {
+				return nil, fmt.Errorf("manifest for %s contains multiple lineage layers", ref)
+			}
+			layer = &man.Layers[i]
+		}
+	}
+	if layer == nil {
+		return nil, fmt.Errorf("manifest for %s contains no lineage layer", ref)
+	}
+
+	b, err := ociGet(ctx, lc.client(), r.url("blobs", layer.Digest), layer.MediaType)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lineage layer for %s: %w", ref, err)
+	}
+	if got := digestOf(b); got != layer.Digest {
+		return nil, fmt.Errorf("lineage layer digest for %s does not match: expected %s, got %s", ref, layer.Digest, got)
+	}
+
+	return bindBundle(b, rt, lc, opts)
+}
+
+// PushOCI pushes a lineage bundle to an OCI registry as a lineage artifact
+// that can later be retrieved with [LoadOCI]. The bundle may be either a
+// single .cue file or a gzipped tarball of .cue files, as described in
+// [LoadURL].
+//
+// The reference must include a tag, e.g. registry.example.com/org/kind:v1. The
+// digest of the pushed manifest is returned, and may be used to pin future
+// pulls of the artifact.
+//
+// Only the [HTTPClient] Option is honored.
+func PushOCI(ctx context.Context, ref string, name string, bundle []byte, opts ...Option) (string, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	r, err := parseOCIRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if r.isDigest() {
+		return "", fmt.Errorf("cannot push to digest reference %s, a tag is required", ref)
+	}
+
+	mt := LineageMediaType
+	if bytes.HasPrefix(bundle, []byte{0x1f, 0x8b}) {
+		mt = LineageArchiveMediaType
+	}
+
+	cb, err := json.Marshal(ociConfig{Name: name})
+	if err != nil {
+		return "", err
+	}
+	for _, blob := range [][]byte{cb, bundle} {
+		if err := ociPushBlob(ctx, lc.client(), r, blob); err != nil {
+			return "", err
+		}
+	}
+
+	mb, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  LineageConfigMediaType,
+		Config: ociDescriptor{
+			MediaType: LineageConfigMediaType,
+			Digest:    digestOf(cb),
+			Size:      int64(len(cb)),
+		},
+		Layers: []ociDescriptor{{
+			MediaType: mt,
+			Digest:    digestOf(bundle),
+			Size:      int64(len(bundle)),
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, r.url("manifests", r.reference), bytes.NewReader(mb))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", ociManifestMediaType)
+	if err := ociDo(lc.client(), req, http.StatusCreated); err != nil {
+		return "", fmt.Errorf("error pushing manifest for %s: %w", ref, err)
+	}
+	return digestOf(mb), nil
+}
+
+func ociGet(ctx context.Context, client *http.Client, u, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return readBundle(resp.Body)
+}
+
+// ociPushBlob uploads a blob using the monolithic POST-then-PUT upload flow.
+func ociPushBlob(ctx context.Context, client *http.Client, r ociRef, blob []byte) error {
+	dgst := digestOf(blob)
+
+	u := r.url("blobs", "uploads") + "/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body) // nolint: errcheck
+	resp.Body.Close()              // nolint: errcheck
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("error starting blob upload: unexpected status %s", resp.Status)
+	}
+
+	loc, err := resp.Location()
+	if err != nil {
+		return fmt.Errorf("error starting blob upload: %w", err)
+	}
+	q := loc.Query()
+	q.Set("digest", dgst)
+	loc.RawQuery = q.Encode()
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, loc.String(), bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := ociDo(client, req, http.StatusCreated); err != nil {
+		return fmt.Errorf("error uploading blob %s: %w", dgst, err)
+	}
+	return nil
+}
+
+func ociDo(client *http.Client, req *http.Request, expect int) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()        // nolint: errcheck
+	io.Copy(io.Discard, resp.Body) // nolint: errcheck
+
+	if resp.StatusCode != expect {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package load
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+)
+
+// memRegistry is a minimal in-memory implementation of the parts of the OCI
+// distribution API used by LoadOCI and PushOCI.
+type memRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (m *memRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(p, "/blobs/uploads/"):
+		w.Header().Set("Location", "/upload/"+p)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/upload/"):
+		b, _ := io.ReadAll(r.Body)
+		if digestOf(b) != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.blobs[digestOf(b)] = b
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(p, "/manifests/"):
+		if r.Method == http.MethodPut {
+			b, _ := io.ReadAll(r.Body)
+			m.manifests[p] = b
+			m.manifests[p[:strings.LastIndex(p, "/")+1]+digestOf(b)] = b
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		b, has := m.manifests[p]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b) // nolint: errcheck
+	case strings.Contains(p, "/blobs/"):
+		b, has := m.blobs[p[strings.LastIndex(p, "/")+1:]]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b) // nolint: errcheck
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestOCIRoundTrip(t *testing.T) {
+	reg := &memRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	ctx := context.Background()
+	rt := thema.NewRuntime(cuecontext.New())
+
+	dgst, err := PushOCI(ctx, host+"/org/remote:v1", "remote", urllin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{host + "/org/remote:v1", host + "/org/remote@" + dgst} {
+		lin, err := LoadOCI(ctx, ref, rt)
+		if err != nil {
+			t.Fatalf("%s: %s", ref, err)
+		}
+		if lin.Name() != "remote" {
+			t.Fatalf("%s: unexpected lineage name %q", ref, lin.Name())
+		}
+	}
+
+	// Tamper with the stored manifest, so it no longer matches the pinned digest
+	mp := "org/remote/manifests/" + dgst
+	reg.manifests[mp] = append(reg.manifests[mp], ' ')
+	if _, err := LoadOCI(ctx, host+"/org/remote@"+dgst, rt); err == nil {
+		t.Fatal("expected digest mismatch to fail")
+	}
+	if _, err := LoadOCI(ctx, host+"/org/missing:v1", rt); err == nil {
+		t.Fatal("expected missing artifact to fail")
+	}
+}
+
+func TestParseOCIRef(t *testing.T) {
+	for ref, expect := range map[string]ociRef{
+		"localhost:5000/org/kind:v1":       {"localhost:5000", "org/kind", "v1"},
+		"registry.example.com/kind":        {"registry.example.com", "kind", "latest"},
+		"r.example.com/org/kind@sha256:ab": {"r.example.com", "org/kind", "sha256:ab"},
+	} {
+		got, err := parseOCIRef(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expect {
+			t.Fatalf("%s: expected %+v, got %+v", ref, expect, got)
+		}
+	}
+	for _, ref := range []string{"kind", "/kind", "r.example.com/kind@md5:ab"} {
+		if _, err := parseOCIRef(ref); err == nil {
+			t.Fatalf("expected %q to fail", ref)
+		}
+	}
+}
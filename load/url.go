@@ -0,0 +1,287 @@
+package load
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"testing/fstest"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"github.com/grafana/thema"
+)
+
+// maxBundleSize is the largest lineage bundle LoadURL will read.
+const maxBundleSize = 32 << 20
+
+// maxBundleExpandedSize is the largest total size of the files LoadURL will
+// extract from an archived lineage bundle, guarding against archives that
+// decompress to far more than their own size.
+const maxBundleExpandedSize = 128 << 20
+
+// bundleModule is the module name given to fetched bundles that do not declare
+// their own cue.mod/module.cue.
+const bundleModule = "thema.bundle/remote"
+
+// Checksum specifies the expected hex-encoded SHA-256 digest of a lineage
+// bundle fetched by [LoadURL]. If the digest of the fetched bytes does not
+// match, loading fails.
+func Checksum(sha256hex string) Option {
+	return func(c *loadConfig) {
+		c.checksum = strings.ToLower(strings.TrimPrefix(sha256hex, "sha256:"))
+	}
+}
+
+// LoadURL fetches a lineage bundle from the provided HTTP(S) URL, loads it,
+// and binds the lineage it contains against the provided [thema.Runtime].
+//
+// The bundle may either be a single .cue file, such as one produced by
+// [thema.Lineage.Export], or a .tar.gz or .zip archive of .cue files. The
+// lineage must be the root value of the CUE package at the root of the bundle,
+// unless a [LineagePath] option is provided.
+// Archives may contain their own cue.mod/module.cue; one is synthesized if
+// absent. In all cases, the Thema CUE package is injected as with
+// [InstanceWithThema], and other load Options, such as [Dependency], are
+// honored.
+//
+// If a [Checksum] option is provided, the fetched bytes are verified against
+// it before anything is loaded.
+func LoadURL(ctx context.Context, url string, rt *thema.Runtime, opts ...Option) (thema.Lineage, error) {
+	lc := &loadConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := lc.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lineage bundle: %w", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching lineage bundle from %s: unexpected status %s", url, resp.Status)
+	}
+	b, err := readBundle(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading lineage bundle from %s: %w", url, err)
+	}
+
+	return bindBundle(b, rt, lc, opts)
+}
+
+// LineagePath specifies the CUE path at which the lineage to bind resides
+// within the loaded CUE package, e.g. "lin" or "kinds.foo.lineage". By
+// default, the root value of the package is bound.
+//
+// This option is honored by loaders that bind lineages, such as [LoadURL].
+func LineagePath(p string) Option {
+	return func(c *loadConfig) {
+		c.linpath = p
+	}
+}
+
+// HTTPClient specifies the http.Client used to fetch remote lineage bundles,
+// such as with [LoadURL]. This may be used to control timeouts, TLS
+// configuration, or to inject authentication. If unspecified,
+// http.DefaultClient is used.
+func HTTPClient(client *http.Client) Option {
+	return func(c *loadConfig) {
+		c.httpclient = client
+	}
+}
+
+func (c *loadConfig) client() *http.Client {
+	if c.httpclient != nil {
+		return c.httpclient
+	}
+	return http.DefaultClient
+}
+
+// readBundle reads the bytes of a lineage bundle, up to the maximum size.
+func readBundle(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxBundleSize {
+		return nil, fmt.Errorf("lineage bundle exceeds maximum size of %d bytes", maxBundleSize)
+	}
+	return b, nil
+}
+
+// bindBundle verifies, loads, and binds the lineage contained in the bytes of
+// a lineage bundle.
+func bindBundle(b []byte, rt *thema.Runtime, lc *loadConfig, opts []Option) (thema.Lineage, error) {
+	if lc.checksum != "" {
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != lc.checksum {
+			return nil, fmt.Errorf("checksum mismatch for lineage bundle: expected %s, got %s", lc.checksum, got)
+		}
+	}
+
+	bfs, err := bundleFS(b)
+	if err != nil {
+		return nil, err
+	}
+	return bindFS(bfs, ".", rt, lc, opts)
+}
+
+// bindFS loads the CUE package in the provided directory of the fs.FS, and
+// binds the lineage it contains.
+func bindFS(fsys fs.FS, dir string, rt *thema.Runtime, lc *loadConfig, opts []Option) (thema.Lineage, error) {
+	if lc.pkgname == "" {
+		pkgname, err := dirPackage(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, Package(pkgname))
+	}
+
+	binst, err := InstanceWithThema(AsModFS(fsys, bundleModule), dir, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	v := rt.Context().BuildInstance(binst)
+	if lc.linpath != "" {
+		v = v.LookupPath(cue.ParsePath(lc.linpath))
+	}
+	return thema.BindLineage(v, rt)
+}
+
+// bundleFS converts the bytes of a fetched lineage bundle into an fs.FS.
+func bundleFS(b []byte) (fs.FS, error) {
+	m := make(fstest.MapFS)
+	remaining := int64(maxBundleExpandedSize)
+	switch {
+	case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+		gzr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip lineage bundle: %w", err)
+		}
+		if err := addTar(m, gzr, &remaining); err != nil {
+			return nil, err
+		}
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip lineage bundle: %w", err)
+		}
+		for _, zf := range zr.File {
+			if zf.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			fb, err := readBundleFile(rc, &remaining)
+			rc.Close() // nolint: errcheck
+			if err != nil {
+				return nil, err
+			}
+			if err := addBundleFile(m, zf.Name, fb); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		m["lineage.cue"] = &fstest.MapFile{Data: b}
+	}
+
+	if len(m) == 0 {
+		return nil, fmt.Errorf("lineage bundle contains no files")
+	}
+	return m, nil
+}
+
+// addTar adds all regular files in the tar stream to the provided MapFS,
+// reading no more than remaining bytes of them in total.
+func addTar(m fstest.MapFS, r io.Reader, remaining *int64) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar lineage bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		fb, err := readBundleFile(tr, remaining)
+		if err != nil {
+			return err
+		}
+		if err := addBundleFile(m, hdr.Name, fb); err != nil {
+			return err
+		}
+	}
+}
+
+// readBundleFile reads a file extracted from a lineage bundle, failing if it
+// is larger than the remaining bytes permitted for all extracted files, and
+// deducting its size from them otherwise.
+func readBundleFile(r io.Reader, remaining *int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, *remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > *remaining {
+		return nil, fmt.Errorf("lineage bundle exceeds maximum extracted size of %d bytes", maxBundleExpandedSize)
+	}
+	*remaining -= int64(len(b))
+	return b, nil
+}
+
+func addBundleFile(m fstest.MapFS, name string, b []byte) error {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if !fs.ValidPath(clean) {
+		return fmt.Errorf("lineage bundle contains invalid path %q", name)
+	}
+	m[clean] = &fstest.MapFile{Data: b}
+	return nil
+}
+
+// dirPackage determines the name of the CUE package in the provided directory
+// of the fs.FS. Files without a package clause belong to the anonymous
+// package, "_".
+func dirPackage(fsys fs.FS, dir string) (string, error) {
+	ents, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return "", err
+	}
+	for _, ent := range ents {
+		if ent.IsDir() || path.Ext(ent.Name()) != ".cue" {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, ent.Name()))
+		if err != nil {
+			return "", err
+		}
+		f, err := parser.ParseFile(ent.Name(), b, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		if name := f.PackageName(); name != "" {
+			return name, nil
+		}
+		return "_", nil
+	}
+	return "", fmt.Errorf("no .cue files in directory %q", dir)
+}
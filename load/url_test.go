@@ -0,0 +1,145 @@
+package load
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+)
+
+var urllin = []byte(`import "github.com/grafana/thema"
+
+thema.#Lineage
+name: "remote"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}]
+`)
+
+func tgz(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, b := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(b)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadURL(t *testing.T) {
+	archive := tgz(t, map[string][]byte{
+		"lineage.cue": append([]byte("package remote\n\n"), urllin...),
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lineage.cue":
+			w.Write(urllin) // nolint: errcheck
+		case "/lineage.tar.gz":
+			w.Write(archive) // nolint: errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	rt := thema.NewRuntime(cuecontext.New())
+	ctx := context.Background()
+	sum := sha256.Sum256(urllin)
+
+	for _, p := range []string{"/lineage.cue", "/lineage.tar.gz"} {
+		lin, err := LoadURL(ctx, srv.URL+p, rt)
+		if err != nil {
+			t.Fatalf("%s: %s", p, err)
+		}
+		if lin.Name() != "remote" {
+			t.Fatalf("%s: unexpected lineage name %q", p, lin.Name())
+		}
+	}
+
+	if _, err := LoadURL(ctx, srv.URL+"/lineage.cue", rt, Checksum(hex.EncodeToString(sum[:]))); err != nil {
+		t.Fatalf("expected matching checksum to succeed: %s", err)
+	}
+	if _, err := LoadURL(ctx, srv.URL+"/lineage.tar.gz", rt, Checksum(hex.EncodeToString(sum[:]))); err == nil {
+		t.Fatal("expected mismatched checksum to fail")
+	}
+	if _, err := LoadURL(ctx, srv.URL+"/missing.cue", rt); err == nil {
+		t.Fatal("expected missing bundle to fail")
+	}
+}
+
+// zeros is an endless stream of zero bytes, which compresses extremely well.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestBundleFSExpandedSize(t *testing.T) {
+	const size = maxBundleExpandedSize/2 + 1
+
+	var tbuf bytes.Buffer
+	gzw := gzip.NewWriter(&tbuf)
+	tw := tar.NewWriter(gzw)
+	for _, name := range []string{"a.cue", "b.cue"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.CopyN(tw, zeros{}, size); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	fw, err := zw.Create("a.cue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.CopyN(fw, zeros{}, maxBundleExpandedSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, b := range map[string][]byte{"tar.gz": tbuf.Bytes(), "zip": zbuf.Bytes()} {
+		if len(b) > maxBundleSize {
+			t.Fatalf("%s: test bundle unexpectedly exceeds maximum fetched size", name)
+		}
+		if _, err := bundleFS(b); err == nil {
+			t.Fatalf("%s: expected bundle exceeding maximum extracted size to fail", name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package load
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/thema"
+)
+
+// defaultPollInterval is how often [Watch] checks for changes by default.
+const defaultPollInterval = time.Second
+
+// PollInterval specifies how often [Watch] checks the watched files for
+// changes. It defaults to one second.
+func PollInterval(d time.Duration) Option {
+	return func(c *loadConfig) {
+		c.poll = d
+	}
+}
+
+// A Handle holds the current version of a lineage that is being watched for
+// changes by [Watch]. It is safe for concurrent use.
+type Handle struct {
+	lin atomic.Pointer[thema.Lineage]
+}
+
+// Lineage returns the most recently bound version of the watched lineage.
+//
+// Callers should call Lineage each time they need the lineage, rather than
+// retaining the result, in order to observe reloads.
+func (h *Handle) Lineage() thema.Lineage {
+	return *h.lin.Load()
+}
+
+// Watch loads and binds a lineage from a directory on disk, then watches the
+// CUE files under that directory for changes, rebinding the lineage each
+// time they change. This is intended for development servers, allowing schema
+// edits to be picked up without a restart.
+//
+// The root is the CUE module root, which is loaded as with [InstanceWithThema];
+// a cue.mod/module.cue is synthesized if absent. The dir is the directory
+// containing the CUE package with the lineage, relative to root. As with
+// [LoadURL], the lineage must be the root value of the package unless a
+// [LineagePath] option is provided, and other Options are honored.
+//
+// If the initial bind fails, an error is returned. Otherwise, the returned
+// [Handle] always holds the most recent successfully bound lineage, which is
+// swapped atomically upon each successful reload. If onChange is non-nil, it
+// is called after each reload attempt with either the new lineage, or the error
+// that prevented reloading; on error, the Handle retains the prior lineage.
+//
+// Changes are detected by polling the modification time and size of files,
+// at the interval specified by [PollInterval]. Watching stops when ctx is
+// done.
+func Watch(ctx context.Context, root, dir string, rt *thema.Runtime, onChange func(thema.Lineage, error), opts ...Option) (*Handle, error) {
+	lc := &loadConfig{poll: defaultPollInterval}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	if lc.poll <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %s", lc.poll)
+	}
+
+	fsys := os.DirFS(root)
+	dir = path.Clean(dir)
+	bind := func() (thema.Lineage, error) {
+		return bindFS(fsys, dir, rt, lc, opts)
+	}
+
+	last, err := fingerprint(fsys)
+	if err != nil {
+		return nil, err
+	}
+	lin, err := bind()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handle{}
+	h.lin.Store(&lin)
+
+	go func() {
+		ticker := time.NewTicker(lc.poll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			fp, err := fingerprint(fsys)
+			if err != nil || fp == last {
+				continue
+			}
+			last = fp
+
+			lin, err := bind()
+			if err == nil {
+				h.lin.Store(&lin)
+			}
+			if onChange != nil {
+				onChange(lin, err)
+			}
+		}
+	}()
+
+	return h, nil
+}
+
+// fingerprint summarizes the name, size and modification time of all CUE
+// files in the fs.FS.
+func fingerprint(fsys fs.FS) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(p) != ".cue" {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", p, fi.Size(), fi.ModTime().UnixNano())
+		return nil
+	})
+
+	var sum [sha256.Size]byte
+	if err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
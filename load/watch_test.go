@@ -0,0 +1,70 @@
+package load
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+)
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	write := func(schema string) {
+		t.Helper()
+		lin := `package kinds
+
+name: "watched"
+schemas: [` + schema + `]
+`
+		if err := os.WriteFile(filepath.Join(root, "lin.cue"), []byte(lin), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{version: [0, 0], schema: a: string}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan error, 10)
+	rt := thema.NewRuntime(cuecontext.New())
+	h, err := Watch(ctx, root, ".", rt, func(_ thema.Lineage, err error) {
+		changes <- err
+	}, PollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Lineage().Latest().Version() != thema.SV(0, 0) {
+		t.Fatalf("unexpected initial version %s", h.Lineage().Latest().Version())
+	}
+
+	wait := func() error {
+		t.Helper()
+		select {
+		case err := <-changes:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for reload")
+			return nil
+		}
+	}
+
+	write(`{version: [0, 0], schema: a: string}, {version: [0, 1], schema: {a: string, b?: int}}`)
+	if err := wait(); err != nil {
+		t.Fatalf("unexpected reload error: %s", err)
+	}
+	if h.Lineage().Latest().Version() != thema.SV(0, 1) {
+		t.Fatalf("expected reloaded version 0.1, got %s", h.Lineage().Latest().Version())
+	}
+
+	write(`{version: [0, 0], schema: a: string}, {version: [0, 1], schema: b: int}`)
+	if err := wait(); err == nil {
+		t.Fatal("expected invalid lineage to produce a reload error")
+	}
+	if h.Lineage().Latest().Version() != thema.SV(0, 1) {
+		t.Fatalf("expected prior lineage to be retained after failed reload, got %s", h.Lineage().Latest().Version())
+	}
+}
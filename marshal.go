@@ -0,0 +1,137 @@
+package thema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+)
+
+// instanceJSON is the JSON representation of an [Instance].
+type instanceJSON struct {
+	Lineage string          `json:"lineage"`
+	Version string          `json:"version"`
+	Name    string          `json:"name,omitempty"`
+	Value   json.RawMessage `json:"value"`
+}
+
+var (
+	_ json.Marshaler   = &Instance{}
+	_ json.Unmarshaler = &Instance{}
+)
+
+// MarshalJSON implements [json.Marshaler]. The Instance is serialized as an
+// object containing the data, along with the name of its lineage and the
+// version of its schema:
+//
+//	{"lineage": "foo", "version": "1.0", "value": {...}}
+//
+// This allows Instances to be embedded directly in API response types, caches,
+// and the like.
+func (i *Instance) MarshalJSON() ([]byte, error) {
+	i.check()
+
+	val, err := i.raw.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(instanceJSON{
+		Lineage: i.sch.Lineage().Name(),
+		Version: i.sch.Version().String(),
+		Name:    i.name,
+		Value:   val,
+	})
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], accepting the output of
+// [Instance.MarshalJSON]. The data is validated against the schema of the
+// version it declares, and the receiver replaced with the resulting Instance.
+//
+// As Instances may only be created by validating against a schema, the zero
+// value of Instance cannot be unmarshaled into, having no lineage with which
+// to validate. The receiver must instead either be a valid Instance from the
+// same lineage as the input, which is used to locate the schema, or have been
+// returned from [LineageSet.UnmarshalTarget]. To unmarshal without an
+// Instance, use [LineageSet.UnmarshalInstance].
+func (i *Instance) UnmarshalJSON(b []byte) error {
+	if !i.valid {
+		if i.set == nil {
+			return fmt.Errorf("cannot unmarshal into an Instance with no lineage; use LineageSet.UnmarshalTarget or LineageSet.UnmarshalInstance")
+		}
+		inst, err := i.set.UnmarshalInstance(b)
+		if err != nil {
+			return err
+		}
+		*i = *inst
+		return nil
+	}
+
+	var ij instanceJSON
+	if err := json.Unmarshal(b, &ij); err != nil {
+		return err
+	}
+	lin := i.sch.Lineage()
+	if ij.Lineage != lin.Name() {
+		return fmt.Errorf("cannot unmarshal instance of lineage %q into instance of lineage %q", ij.Lineage, lin.Name())
+	}
+
+	inst, err := unmarshalInstance(lin, ij)
+	if err != nil {
+		return err
+	}
+	*i = *inst
+	return nil
+}
+
+// UnmarshalTarget returns an empty Instance into which the output of
+// [Instance.MarshalJSON] for any lineage in the set may be unmarshaled, as by
+// [json.Unmarshal]. The lineage named by the input is used to validate it.
+//
+// Use UnmarshalTarget to populate the Instance fields of a struct before
+// unmarshaling into the struct:
+//
+//	resp := Response{Item: set.UnmarshalTarget()}
+//	err := json.Unmarshal(b, &resp)
+//
+// The returned Instance is not valid for any other use until it has been
+// successfully unmarshaled into.
+func (s *LineageSet) UnmarshalTarget() *Instance {
+	return &Instance{set: s}
+}
+
+// UnmarshalInstance unmarshals the output of [Instance.MarshalJSON], using the
+// lineage in the set named by the input.
+func (s *LineageSet) UnmarshalInstance(b []byte) (*Instance, error) {
+	var ij instanceJSON
+	if err := json.Unmarshal(b, &ij); err != nil {
+		return nil, err
+	}
+	lin, has := s.Get(ij.Lineage)
+	if !has {
+		return nil, fmt.Errorf("no lineage named %q in set", ij.Lineage)
+	}
+	return unmarshalInstance(lin, ij)
+}
+
+func unmarshalInstance(lin Lineage, ij instanceJSON) (*Instance, error) {
+	sv, err := ParseSyntacticVersion(ij.Version)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := lin.Schema(sv)
+	if err != nil {
+		return nil, err
+	}
+
+	data := lin.Runtime().Context().CompileBytes(ij.Value, cue.Filename(ij.Name))
+	if data.Err() != nil {
+		return nil, errors.Wrap(data.Err(), "invalid instance value")
+	}
+	inst, err := sch.Validate(data)
+	if err != nil {
+		return nil, err
+	}
+	inst.name = ij.Name
+	return inst, nil
+}
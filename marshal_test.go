@@ -0,0 +1,108 @@
+package thema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceJSON(t *testing.T) {
+	lin := testLin(`name: "jsonlin"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+	}
+},
+{
+	version: [0, 1]
+	schema: {
+		title: string
+		count?: int
+	}
+}]
+`)
+	sch, err := lin.Schema(SV(0, 1))
+	require.NoError(t, err)
+	inst, err := sch.Validate(lin.Runtime().Context().CompileString(`{title: "hi", count: 3}`))
+	require.NoError(t, err)
+
+	type response struct {
+		Item *Instance `json:"item"`
+	}
+	b, err := json.Marshal(response{Item: inst})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"item": {"lineage": "jsonlin", "version": "0.1", "value": {"title": "hi", "count": 3}}}`, string(b))
+
+	t.Run("set", func(t *testing.T) {
+		set, err := NewLineageSet(lin)
+		require.NoError(t, err)
+		ib, err := json.Marshal(inst)
+		require.NoError(t, err)
+
+		uinst, err := set.UnmarshalInstance(ib)
+		require.NoError(t, err)
+		assert.Equal(t, SV(0, 1), uinst.Schema().Version())
+		ub, err := json.Marshal(uinst)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(ib), string(ub))
+
+		_, err = set.UnmarshalInstance([]byte(`{"lineage": "jsonlin", "version": "0.0", "value": {"title": "hi", "count": 3}}`))
+		assert.Error(t, err, "data invalid for declared version should fail")
+		_, err = set.UnmarshalInstance([]byte(`{"lineage": "other", "version": "0.0", "value": {}}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("primed", func(t *testing.T) {
+		var resp response
+		assert.Error(t, json.Unmarshal(b, &resp), "zero Instance should not be unmarshalable")
+
+		prime := *inst
+		resp.Item = &prime
+		require.NoError(t, json.Unmarshal([]byte(`{"item": {"lineage": "jsonlin", "version": "0.0", "value": {"title": "yo"}}}`), &resp))
+		assert.Equal(t, SV(0, 0), resp.Item.Schema().Version())
+		title, err := resp.Item.Underlying().LookupPath(cue.ParsePath("title")).String()
+		require.NoError(t, err)
+		assert.Equal(t, "yo", title)
+	})
+
+	t.Run("target", func(t *testing.T) {
+		set, err := NewLineageSet(lin)
+		require.NoError(t, err)
+
+		type envelope struct {
+			Kind string   `json:"kind"`
+			Item Instance `json:"item"`
+		}
+		var resp struct {
+			Items []envelope `json:"items"`
+			Ptr   *Instance  `json:"ptr"`
+			Value Instance   `json:"value"`
+		}
+		resp.Ptr = set.UnmarshalTarget()
+		resp.Value = *set.UnmarshalTarget()
+		resp.Items = []envelope{{Item: *set.UnmarshalTarget()}}
+
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"items": [{"kind": "a", "item": {"lineage": "jsonlin", "version": "0.0", "value": {"title": "one"}}}],
+			"ptr": {"lineage": "jsonlin", "version": "0.1", "value": {"title": "two", "count": 2}},
+			"value": {"lineage": "jsonlin", "version": "0.0", "value": {"title": "three"}}
+		}`), &resp))
+
+		for title, inst := range map[string]*Instance{"one": &resp.Items[0].Item, "two": resp.Ptr, "three": &resp.Value} {
+			got, err := inst.Underlying().LookupPath(cue.ParsePath("title")).String()
+			require.NoError(t, err)
+			assert.Equal(t, title, got)
+		}
+		assert.Equal(t, "a", resp.Items[0].Kind)
+		assert.Equal(t, SV(0, 1), resp.Ptr.Schema().Version())
+
+		// A target still validates against the declared version
+		target := set.UnmarshalTarget()
+		assert.Error(t, json.Unmarshal([]byte(`{"lineage": "jsonlin", "version": "0.0", "value": {"title": "hi", "count": 3}}`), target))
+		assert.Error(t, json.Unmarshal([]byte(`{"lineage": "other", "version": "0.0", "value": {}}`), target))
+	})
+}
@@ -0,0 +1,189 @@
+package thema
+
+import (
+	"reflect"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A MergeConflict describes a field changed differently by both sides of a
+// [Merge].
+type MergeConflict struct {
+	// Path is the path to the field, in the syntax of a [cue.Path], e.g.
+	// "spec.panels[0].title". List indices are those in ours, or in theirs
+	// for elements ours does not contain.
+	Path string `json:"path"`
+
+	// Base, Ours and Theirs are the values of the field in each of the merged
+	// instances, decoded as by [cue.Value.Decode] into an any. A value is nil
+	// if the field is absent from that instance.
+	Base   any `json:"base"`
+	Ours   any `json:"ours"`
+	Theirs any `json:"theirs"`
+}
+
+// Merge performs a three-way merge of two instances, ours and theirs, that were
+// both derived from a common base instance, as for collaborative editing of a
+// single document. A change made on only one side, relative to base, is kept
+// in the result, as are identical changes made on both sides.
+//
+// Structs are merged field by field. Lists are treated as single values,
+// unless the list field is annotated with @key(<field>) in the schema, in
+// which case its elements are matched across instances by the value of that
+// field and merged individually. The merged list follows the order of ours,
+// with elements added only in theirs following.
+//
+// Where both sides change a field differently, the value in ours is kept, and
+// a [MergeConflict] is reported. If there are any conflicts, the merged
+// instance is returned along with all conflicts, and an error wrapping
+// [terrors.ErrMergeConflict].
+//
+// If sch is nil, the schema of ours is used. Otherwise, all three instances
+// must be of sch's lineage, and are first translated to it. The merged data is
+// validated against sch; a validation error is returned if merging otherwise
+// compatible changes produced data that is not an instance of it.
+func Merge(base, ours, theirs *Instance, sch Schema) (*Instance, []MergeConflict, error) {
+	insts := [3]*Instance{base, ours, theirs}
+	var data [3]any
+	// ours is taken first, such that its schema is the default for the others
+	for _, i := range []int{1, 0, 2} {
+		inst := insts[i]
+		inst.check()
+		if sch == nil {
+			sch = inst.Schema()
+		}
+		inst, err := translateTo(inst, sch)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to translate %s instance for merge", mergeInputs[i])
+		}
+		if err := inst.Underlying().Decode(&data[i]); err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to decode %s instance for merge", mergeInputs[i])
+		}
+	}
+
+	m := &merger{}
+	out := m.merge(sch.Underlying().LookupPath(pathSchDef), nil, mergeSlot{data[0], true}, mergeSlot{data[1], true}, mergeSlot{data[2], true})
+	inst, err := sch.Validate(sch.Underlying().Context().Encode(out.v))
+	if err != nil {
+		return nil, m.conflicts, err
+	}
+	if len(m.conflicts) > 0 {
+		return inst, m.conflicts, errors.Mark(errors.Newf("%d conflicting changes", len(m.conflicts)), terrors.ErrMergeConflict)
+	}
+	return inst, nil, nil
+}
+
+// mergeInputs names the instances passed to [Merge], in order.
+var mergeInputs = [3]string{"base", "ours", "theirs"}
+
+// A mergeSlot holds a field's decoded value in one of the merged instances,
+// and whether the field is present at all.
+type mergeSlot struct {
+	v   any
+	has bool
+}
+
+func (s mergeSlot) equal(o mergeSlot) bool {
+	return s.has == o.has && (!s.has || reflect.DeepEqual(s.v, o.v))
+}
+
+func slotOf(m map[string]any, k string) mergeSlot {
+	v, has := m[k]
+	return mergeSlot{v, has}
+}
+
+type merger struct {
+	conflicts []MergeConflict
+}
+
+func (m *merger) merge(sch cue.Value, path []cue.Selector, base, ours, theirs mergeSlot) mergeSlot {
+	switch {
+	case ours.equal(theirs), theirs.equal(base):
+		return ours
+	case ours.equal(base):
+		return theirs
+	}
+
+	if ours.has && theirs.has {
+		om, ook := ours.v.(map[string]any)
+		tm, tok := theirs.v.(map[string]any)
+		if ook && tok {
+			// A base that is absent, or not a struct, has no fields in common
+			bm, _ := base.v.(map[string]any)
+			out := make(map[string]any, len(om))
+			for _, fields := range []map[string]any{om, tm} {
+				for k := range fields {
+					if _, done := out[k]; done {
+						continue
+					}
+					fsch, _, _ := lookupField(sch, []string{k})
+					if r := m.merge(fsch, append(path, cue.Str(k)), slotOf(bm, k), slotOf(om, k), slotOf(tm, k)); r.has {
+						out[k] = r.v
+					}
+				}
+			}
+			return mergeSlot{out, true}
+		}
+
+		ol, ook := ours.v.([]any)
+		tl, tok := theirs.v.([]any)
+		if ook && tok {
+			if key := listKey(sch); key != "" {
+				bl, _ := base.v.([]any)
+				if out, ok := m.mergeKeyed(sch, path, key, bl, ol, tl); ok {
+					return mergeSlot{out, true}
+				}
+			}
+		}
+	}
+
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:   cue.MakePath(path...).String(),
+		Base:   base.v,
+		Ours:   ours.v,
+		Theirs: theirs.v,
+	})
+	return ours
+}
+
+// mergeKeyed merges lists of structs whose elements are identified by the
+// value of their key field. It returns false if the elements of any list
+// cannot be identified, as by keyedElems.
+func (m *merger) mergeKeyed(sch cue.Value, path []cue.Selector, key string, base, ours, theirs []any) ([]any, bool) {
+	_, bindex, bok := keyedElems(base, key)
+	oids, oindex, ook := keyedElems(ours, key)
+	tids, tindex, tok := keyedElems(theirs, key)
+	if !bok || !ook || !tok {
+		return nil, false
+	}
+	slot := func(l []any, index map[string]int, id string) mergeSlot {
+		if i, has := index[id]; has {
+			return mergeSlot{l[i], true}
+		}
+		return mergeSlot{}
+	}
+
+	ids := append([]string(nil), oids...)
+	for _, id := range tids {
+		if _, has := oindex[id]; !has {
+			ids = append(ids, id)
+		}
+	}
+
+	esch, _, _ := lookupField(sch, []string{"*"})
+	out := make([]any, 0, len(ids))
+	for _, id := range ids {
+		idx, has := oindex[id]
+		if !has {
+			idx = tindex[id]
+		}
+		bs, os, ts := slot(base, bindex, id), slot(ours, oindex, id), slot(theirs, tindex, id)
+		if r := m.merge(esch, append(path, cue.Index(idx)), bs, os, ts); r.has {
+			out = append(out, r.v)
+		}
+	}
+	return out, true
+}
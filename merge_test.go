@@ -0,0 +1,113 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestMerge(t *testing.T) {
+	lin := testLin(`name: "merge"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		tags?: [...string]
+		options?: {
+			refresh?: string
+			timezone?: string
+		}
+		panels?: [...{id: int, title: string, span?: int}] @key(id)
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+	validate := func(data string) *Instance {
+		inst, err := lin.First().Validate(ctx.CompileString(data))
+		require.NoError(t, err)
+		return inst
+	}
+	base := validate(`{
+	title: "base"
+	tags: ["a"]
+	options: refresh: "1m"
+	panels: [{id: 1, title: "one"}, {id: 2, title: "two"}]
+}`)
+
+	t.Run("independent changes", func(t *testing.T) {
+		ours := validate(`{
+	title: "ours"
+	tags: ["a"]
+	options: refresh: "5m"
+	panels: [{id: 2, title: "two"}, {id: 1, title: "one", span: 6}]
+}`)
+		theirs := validate(`{
+	title: "base"
+	tags: ["a", "b"]
+	options: {refresh: "1m", timezone: "utc"}
+	panels: [{id: 1, title: "uno"}, {id: 3, title: "three"}]
+}`)
+		merged, conflicts, err := Merge(base, ours, theirs, nil)
+		require.NoError(t, err)
+		assert.Empty(t, conflicts)
+		b, err := merged.Underlying().MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+	"title": "ours",
+	"tags": ["a", "b"],
+	"options": {"refresh": "5m", "timezone": "utc"},
+	"panels": [{"id": 1, "title": "uno", "span": 6}, {"id": 3, "title": "three"}]
+}`, string(b))
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		ours := validate(`{
+	title: "ours"
+	tags: ["a", "x"]
+	panels: [{id: 1, title: "one"}]
+}`)
+		theirs := validate(`{
+	title: "theirs"
+	tags: ["a", "y"]
+	options: refresh: "1m"
+	panels: [{id: 1, title: "one"}, {id: 2, title: "deux"}]
+}`)
+		merged, conflicts, err := Merge(base, ours, theirs, nil)
+		assert.True(t, errors.Is(err, terrors.ErrMergeConflict))
+		require.NotNil(t, merged)
+
+		paths := make(map[string]MergeConflict)
+		for _, c := range conflicts {
+			paths[c.Path] = c
+		}
+		require.Len(t, paths, 3)
+		assert.Equal(t, "ours", paths["title"].Ours)
+		assert.Equal(t, "theirs", paths["title"].Theirs)
+		assert.Contains(t, paths, "tags")
+		require.Contains(t, paths, "panels[1]")
+		assert.Nil(t, paths["panels[1]"].Ours)
+
+		b, err := merged.Underlying().MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "ours", "tags": ["a", "x"], "panels": [{"id": 1, "title": "one"}]}`, string(b))
+	})
+
+	t.Run("other lineage", func(t *testing.T) {
+		other := testLin(`name: "other"
+schemas: [{version: [0, 0], schema: title: string}]
+`)
+		_, _, err := Merge(base, base, base, other.First())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ours instance")
+
+		theirs, err := other.First().Validate(other.Runtime().Context().CompileString(`{title: "theirs"}`))
+		require.NoError(t, err)
+		_, _, err = Merge(base, base, theirs, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "theirs instance")
+	})
+}
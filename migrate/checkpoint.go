@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A Checkpointer persists the cursor of the last item processed by a
+// [Runner].
+type Checkpointer interface {
+	// Load returns the most recently saved cursor, or an empty string if none
+	// has been saved.
+	Load(ctx context.Context) (string, error)
+
+	// Save records the provided cursor.
+	Save(ctx context.Context, cursor string) error
+}
+
+// FileCheckpointer returns a [Checkpointer] that stores the cursor in the file
+// at the provided path. Each save atomically replaces the file.
+func FileCheckpointer(path string) Checkpointer {
+	return fileCheckpointer(path)
+}
+
+type fileCheckpointer string
+
+func (fc fileCheckpointer) Load(_ context.Context) (string, error) {
+	b, err := os.ReadFile(string(fc))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+func (fc fileCheckpointer) Save(_ context.Context, cursor string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(string(fc)), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := tmp.WriteString(cursor); err != nil {
+		tmp.Close() // nolint: errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(fc))
+}
@@ -0,0 +1,10 @@
+// Package migrate provides a runner for bulk migrations of stored objects from
+// any schema version in a Thema lineage to a single target version.
+//
+// A [Runner] reads objects from a [Source], translates each to the target
+// schema, and writes the result to a [Sink]. Progress is recorded with a
+// [Checkpointer], so that a migration interrupted by a crash or a failure of
+// the Source or Sink can be resumed where it left off. Failures to translate
+// individual objects do not stop the migration; they are reported, along with
+// any lacunas, through [Runner.OnResult] and the returned [Report].
+package migrate
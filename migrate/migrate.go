@@ -0,0 +1,226 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/vmux"
+)
+
+// An Item is a single stored object to be migrated.
+type Item struct {
+	// ID identifies the object, for reporting and for use by the Sink.
+	ID string
+
+	// Cursor is the position of the item within its Source. Passing it to
+	// [Source.Next] must return the item following this one.
+	Cursor string
+
+	// Data is the encoded object, in the format of the Runner's Codec.
+	Data []byte
+}
+
+// A Source provides the objects to migrate, in a stable order.
+type Source interface {
+	// Next returns the item following the item with the provided cursor, or the
+	// first item if the cursor is empty. It returns io.EOF when there are no
+	// more items.
+	Next(ctx context.Context, cursor string) (Item, error)
+}
+
+// A Sink receives migrated objects.
+type Sink interface {
+	// Write stores the migrated form of the provided item. Writes must be
+	// idempotent, as items processed after the last checkpoint are written
+	// again when a migration is resumed.
+	Write(ctx context.Context, item Item, inst *thema.Instance) error
+}
+
+// A Result reports the outcome of migrating a single item.
+type Result struct {
+	Item Item
+
+	// Instance is the migrated object, or nil if migration failed.
+	Instance *thema.Instance
+
+	// Lacunas are the lacunas emitted by translating the object.
+	Lacunas thema.TranslationLacunas
+
+	// Warnings are the lacunas for which the Runner's LacunaPolicy prescribes
+	// a warning.
+	Warnings thema.Lacunas
+
+	// Err is the error that prevented the object from being migrated, if any.
+	Err error
+}
+
+// A Report summarizes a migration run.
+type Report struct {
+	// Migrated is the number of items successfully translated and written.
+	Migrated int
+
+	// Failed is the number of items that could not be translated.
+	Failed int
+
+	// WithLacunas is the number of migrated items whose translation emitted
+	// lacunas.
+	WithLacunas int
+
+	// Cursor is the cursor of the last item processed.
+	Cursor string
+}
+
+// A Runner migrates all objects in a Source to a single schema version.
+type Runner struct {
+	// Schema is the schema to which all objects are translated.
+	Schema thema.Schema
+
+	// Codec decodes items from the Source. If nil, a JSON codec is used.
+	Codec vmux.Codec
+
+	Source Source
+	Sink   Sink
+
+	// Checkpoint records progress. If nil, progress is not recorded and every
+	// Run starts from the beginning of the Source.
+	Checkpoint Checkpointer
+
+	// CheckpointEvery is the number of items processed between checkpoints. If
+	// less than one, a checkpoint is saved after every item.
+	CheckpointEvery int
+
+	// OnResult, if non-nil, is called with the result of each item.
+	OnResult func(Result)
+
+	// DeadLetters, if non-nil, receives each item that fails to migrate,
+	// identified by its ID, so that it may be quarantined.
+	DeadLetters thema.DeadLetterSink
+
+	// LacunaPolicy, if non-nil, is checked against the lacunas emitted by
+	// translating each object. Objects whose lacunas the policy does not
+	// permit are not written, and are counted as failed.
+	LacunaPolicy *thema.LacunaPolicy
+}
+
+// Run performs the migration, resuming from the last checkpoint if there is
+// one. It returns when the Source is exhausted, the context is canceled, or the
+// Source, Sink or Checkpointer fails. In the latter cases, progress up to the
+// last successfully written item is checkpointed before returning, so a
+// subsequent Run resumes from there.
+//
+// Items that cannot be decoded, validated against any schema in the lineage,
+// or translated, or whose translation the LacunaPolicy rejects, are counted as
+// failed and skipped, and do not cause Run to return an error.
+func (r *Runner) Run(ctx context.Context) (Report, error) {
+	if r.Schema == nil || r.Source == nil || r.Sink == nil {
+		return Report{}, errors.New("migrate: Runner requires a Schema, Source and Sink")
+	}
+	codec := r.Codec
+	if codec == nil {
+		codec = vmux.NewJSONCodec("migrate")
+	}
+	mux := vmux.NewSearchMux(r.Schema, codec)
+
+	var rep Report
+	if r.Checkpoint != nil {
+		cursor, err := r.Checkpoint.Load(ctx)
+		if err != nil {
+			return rep, fmt.Errorf("migrate: failed to load checkpoint: %w", err)
+		}
+		rep.Cursor = cursor
+	}
+
+	saved := rep.Cursor
+	save := func() error {
+		if r.Checkpoint == nil || rep.Cursor == saved {
+			return nil
+		}
+		if err := r.Checkpoint.Save(ctx, rep.Cursor); err != nil {
+			return fmt.Errorf("migrate: failed to save checkpoint: %w", err)
+		}
+		saved = rep.Cursor
+		return nil
+	}
+	fail := func(err error) (Report, error) {
+		if serr := save(); serr != nil {
+			err = fmt.Errorf("%w; additionally, %s", err, serr)
+		}
+		return rep, err
+	}
+
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+
+		item, err := r.Source.Next(ctx, rep.Cursor)
+		if errors.Is(err, io.EOF) {
+			return rep, save()
+		}
+		if err != nil {
+			return fail(fmt.Errorf("migrate: failed to read from source: %w", err))
+		}
+
+		res := Result{Item: item}
+		var cands []thema.Candidate
+		res.Instance, res.Lacunas, cands, res.Err = mux(item.Data)
+		if res.Err == nil {
+			if res.Warnings, res.Err = r.LacunaPolicy.Check(res.Lacunas); res.Err != nil {
+				res.Instance = nil
+			}
+		}
+		if res.Err == nil {
+			if err := r.Sink.Write(ctx, item, res.Instance); err != nil {
+				return fail(fmt.Errorf("migrate: failed to write item %q: %w", item.ID, err))
+			}
+			rep.Migrated++
+			if res.Lacunas != nil && len(res.Lacunas.AsList()) > 0 {
+				rep.WithLacunas++
+			}
+		} else {
+			rep.Failed++
+			if r.DeadLetters != nil {
+				r.DeadLetters(thema.DeadLetter{
+					ID:        item.ID,
+					Data:      item.Data,
+					Attempted: attempted(r.Schema, cands),
+					Err:       res.Err,
+					Lacunas:   res.Lacunas,
+				})
+			}
+		}
+		rep.Cursor = item.Cursor
+
+		if r.OnResult != nil {
+			r.OnResult(res)
+		}
+		if r.CheckpointEvery <= 1 || n%r.CheckpointEvery == 0 {
+			if err := save(); err != nil {
+				return rep, err
+			}
+		}
+	}
+}
+
+// attempted returns the versions of the schemas against which the mux for sch
+// validated data, as described by its candidates, followed by the version of
+// sch if the data was translated to it, in the order attempted.
+func attempted(sch thema.Schema, cands []thema.Candidate) []thema.SyntacticVersion {
+	if len(cands) == 0 {
+		return nil
+	}
+
+	var vs []thema.SyntacticVersion
+	var translated bool
+	for _, c := range cands {
+		vs = append(vs, c.Version)
+		translated = translated || (c.Matched() && c.Version != sch.Version())
+	}
+	if translated {
+		vs = append(vs, sch.Version())
+	}
+	return vs
+}
@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const linstr = `
+name: "migrated"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	schema: name: string
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`
+
+// sliceSource serves items from a slice, using the index as the cursor.
+type sliceSource []string
+
+func (s sliceSource) Next(_ context.Context, cursor string) (Item, error) {
+	i := 0
+	if cursor != "" {
+		c, err := strconv.Atoi(cursor)
+		if err != nil {
+			return Item{}, err
+		}
+		i = c + 1
+	}
+	if i >= len(s) {
+		return Item{}, io.EOF
+	}
+	return Item{ID: "item" + strconv.Itoa(i), Cursor: strconv.Itoa(i), Data: []byte(s[i])}, nil
+}
+
+type mapSink struct {
+	out    map[string]string
+	failOn string
+}
+
+func (s *mapSink) Write(_ context.Context, item Item, inst *thema.Instance) error {
+	if item.ID == s.failOn {
+		s.failOn = ""
+		return errors.New("sink unavailable")
+	}
+	b, err := inst.Underlying().MarshalJSON()
+	if err != nil {
+		return err
+	}
+	s.out[item.ID] = string(b)
+	return nil
+}
+
+func TestRunner(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(linstr), rt)
+	require.NoError(t, err)
+
+	src := sliceSource{
+		`{"title": "a"}`,
+		`{"name": "b"}`,
+		`{"nope": true}`,
+		`{"title": "d"}`,
+		`not json`,
+		`{"title": "f"}`,
+	}
+	sink := &mapSink{out: make(map[string]string), failOn: "item3"}
+	var results []Result
+	var dead []thema.DeadLetter
+	r := &Runner{
+		Schema:     lin.Latest(),
+		Source:     src,
+		Sink:       sink,
+		Checkpoint: FileCheckpointer(filepath.Join(t.TempDir(), "checkpoint")),
+		OnResult: func(res Result) {
+			results = append(results, res)
+		},
+		DeadLetters: func(dl thema.DeadLetter) {
+			dead = append(dead, dl)
+		},
+	}
+
+	rep, err := r.Run(context.Background())
+	require.Error(t, err, "sink failure should stop the run")
+	assert.Equal(t, Report{Migrated: 2, Failed: 1, Cursor: "2"}, rep)
+
+	cursor, err := r.Checkpoint.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "2", cursor)
+
+	rep, err = r.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Migrated: 2, Failed: 1, Cursor: "5"}, rep)
+
+	assert.Equal(t, map[string]string{
+		"item0": `{"name":"a"}`,
+		"item1": `{"name":"b"}`,
+		"item3": `{"name":"d"}`,
+		"item5": `{"name":"f"}`,
+	}, sink.out)
+
+	var failed []string
+	for _, res := range results {
+		if res.Err != nil {
+			failed = append(failed, res.Item.ID)
+		}
+	}
+	assert.Equal(t, []string{"item2", "item4"}, failed)
+
+	require.Len(t, dead, 2)
+	assert.Equal(t, "item2", dead[0].ID)
+	assert.Equal(t, []byte(`{"nope": true}`), dead[0].Data)
+	assert.Equal(t, []thema.SyntacticVersion{thema.SV(1, 0), thema.SV(0, 0)}, dead[0].Attempted)
+	assert.Error(t, dead[0].Err)
+	assert.Equal(t, "item4", dead[1].ID)
+	assert.Empty(t, dead[1].Attempted, "undecodable data is attempted against no schema")
+
+	// A completed migration has nothing left to do
+	rep, err = r.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Report{Cursor: "5"}, rep)
+}
@@ -0,0 +1,79 @@
+package thema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// NegotiateVersion returns the newest schema in the lineage that a client
+// understanding the provided versions is able to read, for APIs in which the
+// client states the versions it supports and the server translates its
+// response to one of them.
+//
+// Each schema is backwards compatible with its predecessors in the same major
+// version, so a client that understands X.Y can also read instances of X.0
+// through X.Y. A client that understands 2.3, talking to a server whose
+// lineage ends at 2.1, is therefore served 2.1; talking to a server with 2.5,
+// it is served 2.3.
+//
+// An error wrapping [terrors.ErrVersionNotExist] is returned if the lineage
+// contains no schema that the client can read.
+func NegotiateVersion(lin Lineage, accepted ...SyntacticVersion) (Schema, error) {
+	isValidLineage(lin)
+
+	sch, err := Find(lin, ReadableBy(accepted...))
+	if err != nil {
+		return nil, errors.Mark(errors.Newf("no schema in lineage %s is readable by a client accepting versions [%s]", lin.Name(), versionList(accepted)), terrors.ErrVersionNotExist)
+	}
+	return sch, nil
+}
+
+// ReadableBy restricts a search to schemas that a client understanding the
+// provided versions is able to read, as described by [NegotiateVersion]. It
+// may be combined with other options, such as [WithDraftGate], to negotiate
+// among a subset of the lineage's schemas.
+func ReadableBy(accepted ...SyntacticVersion) SearchOption {
+	return func(c *searchConfig) {
+		c.filters = append(c.filters, func(sch Schema) bool {
+			v := sch.Version()
+			for _, a := range accepted {
+				if v[0] == a[0] && v[1] <= a[1] {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// ParseVersionList parses a comma-separated list of syntactic versions, such
+// as "2.3, 1.4", as stated by clients to [NegotiateVersion]. Whitespace around
+// each version is ignored, as are empty elements.
+func ParseVersionList(s string) ([]SyntacticVersion, error) {
+	var vl []SyntacticVersion
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := ParseSyntacticVersion(part)
+		if err != nil {
+			return nil, err
+		}
+		vl = append(vl, v)
+	}
+	if len(vl) == 0 {
+		return nil, fmt.Errorf("%w: empty version list %q", terrors.ErrMalformedSyntacticVersion, s)
+	}
+	return vl, nil
+}
+
+// FormatVersionList formats versions as a comma-separated list, as parsed by
+// [ParseVersionList].
+func FormatVersionList(vl ...SyntacticVersion) string {
+	return versionList(vl).String()
+}
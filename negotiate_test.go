@@ -0,0 +1,50 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	lin := testLin(searchlinstr)
+
+	for name, tc := range map[string]struct {
+		accept []SyntacticVersion
+		want   SyntacticVersion
+	}{
+		"exact":                 {[]SyntacticVersion{SV(0, 1)}, SV(0, 1)},
+		"older minor":           {[]SyntacticVersion{SV(0, 0)}, SV(0, 0)},
+		"newer minor":           {[]SyntacticVersion{SV(0, 7)}, SV(0, 1)},
+		"newest major":          {[]SyntacticVersion{SV(0, 1), SV(1, 0)}, SV(1, 0)},
+		"order is irrelevant":   {[]SyntacticVersion{SV(1, 3), SV(0, 0)}, SV(1, 0)},
+		"unknown major skipped": {[]SyntacticVersion{SV(0, 0), SV(4, 0)}, SV(0, 0)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sch, err := NegotiateVersion(lin, tc.accept...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, sch.Version())
+		})
+	}
+
+	_, err := NegotiateVersion(lin, SV(2, 0))
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+	_, err = NegotiateVersion(lin)
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+}
+
+func TestParseVersionList(t *testing.T) {
+	vl, err := ParseVersionList(" 2.3,1.4 , ,0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []SyntacticVersion{SV(2, 3), SV(1, 4), SV(0, 0)}, vl)
+	assert.Equal(t, "2.3, 1.4, 0.0", FormatVersionList(vl...))
+
+	for _, s := range []string{"", " , ", "2", "1.x"} {
+		_, err := ParseVersionList(s)
+		assert.True(t, errors.Is(err, terrors.ErrMalformedSyntacticVersion), s)
+	}
+}
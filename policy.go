@@ -0,0 +1,184 @@
+package thema
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A LacunaAction is the outcome prescribed by a [LacunaPolicy] for a lacuna.
+type LacunaAction uint8
+
+const (
+	// LacunaAllow accepts a translation despite the lacuna.
+	LacunaAllow LacunaAction = iota
+
+	// LacunaWarn accepts a translation despite the lacuna, but reports the
+	// lacuna as a warning.
+	LacunaWarn
+
+	// LacunaFail rejects a translation because of the lacuna.
+	LacunaFail
+)
+
+var lacunaActionNames = map[LacunaAction]string{
+	LacunaAllow: "allow",
+	LacunaWarn:  "warn",
+	LacunaFail:  "fail",
+}
+
+func (a LacunaAction) String() string {
+	if name, has := lacunaActionNames[a]; has {
+		return name
+	}
+	return fmt.Sprintf("LacunaAction(%d)", uint8(a))
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the LacunaAction by
+// its name.
+func (a LacunaAction) MarshalText() ([]byte, error) {
+	if _, has := lacunaActionNames[a]; !has {
+		return nil, fmt.Errorf("unknown lacuna action %d", uint8(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding a LacunaAction
+// from its name.
+func (a *LacunaAction) UnmarshalText(text []byte) error {
+	for la, name := range lacunaActionNames {
+		if name == string(text) {
+			*a = la
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown lacuna action %q", text)
+}
+
+// A LacunaRule prescribes an action for the lacunas it matches. A rule matches
+// a lacuna if all of its criteria do; a rule without criteria matches every
+// lacuna.
+type LacunaRule struct {
+	// Types, if non-empty, matches lacunas of any of the listed types.
+	Types []LacunaType `json:"types,omitempty"`
+
+	// Severity, if set, matches lacunas of at least the given severity.
+	Severity LacunaSeverity `json:"severity,omitempty"`
+
+	// Path, if set, matches lacunas with a source or target field at or
+	// beneath the given path, written in the syntax of a [cue.Path].
+	Path string `json:"path,omitempty"`
+
+	// Action is the action taken for matching lacunas.
+	Action LacunaAction `json:"action"`
+}
+
+func (r LacunaRule) matches(l Lacuna) bool {
+	if len(r.Types) > 0 && len(Lacunas{l}.OfType(r.Types...)) == 0 {
+		return false
+	}
+	if r.Severity != 0 && l.severity() < r.Severity {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+
+	prefix := cue.ParsePath(r.Path).Selectors()
+	for _, fr := range append(append([]FieldRef{}, l.SourceFields...), l.TargetFields...) {
+		sels := fr.CUEPath().Selectors()
+		if len(sels) < len(prefix) {
+			continue
+		}
+		under := true
+		for i, sel := range prefix {
+			if sel.String() != sels[i].String() {
+				under = false
+				break
+			}
+		}
+		if under {
+			return true
+		}
+	}
+	return false
+}
+
+// A LacunaPolicy declares which of the lacunas emitted by a translation are
+// acceptable, so that programs translating data apply the same judgment
+// without inspecting lacuna messages. It is evaluated by [LacunaPolicy.Check]
+// after translation, including by the [TranslationExecutor] and the migrate
+// and server packages when configured with one.
+//
+// Policies may be written in Go, or decoded from JSON or CUE, e.g. an instance
+// of thema.#LacunaPolicy, by [DecodeLacunaPolicy]:
+//
+//	rules: [
+//		{severity: "critical", action: "fail"},
+//		{types: ["DroppedField"], path: "spec.legacy", action: "warn"},
+//	]
+//	default: "allow"
+type LacunaPolicy struct {
+	// Rules are checked against each lacuna in order. The action of the first
+	// matching rule is taken.
+	Rules []LacunaRule `json:"rules"`
+
+	// Default is the action taken for lacunas matched by no rule.
+	Default LacunaAction `json:"default,omitempty"`
+}
+
+// DecodeLacunaPolicy decodes a LacunaPolicy from a CUE value.
+func DecodeLacunaPolicy(v cue.Value) (*LacunaPolicy, error) {
+	var p LacunaPolicy
+	if err := v.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid lacuna policy: %w", err)
+	}
+	for i, r := range p.Rules {
+		if r.Path != "" {
+			if err := cue.ParsePath(r.Path).Err(); err != nil {
+				return nil, fmt.Errorf("invalid lacuna policy: rule %d has invalid path %q: %w", i, r.Path, err)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// Action returns the action the policy prescribes for l.
+func (p *LacunaPolicy) Action(l Lacuna) LacunaAction {
+	for _, r := range p.Rules {
+		if r.matches(l) {
+			return r.Action
+		}
+	}
+	return p.Default
+}
+
+// Check applies the policy to the lacunas emitted by a translation, returning
+// those for which it prescribes a warning. If it prescribes failure for any,
+// an error wrapping [terrors.ErrLacunaPolicyViolation] is returned describing
+// them.
+//
+// A nil policy allows all lacunas.
+func (p *LacunaPolicy) Check(lac TranslationLacunas) (Lacunas, error) {
+	if p == nil || lac == nil {
+		return nil, nil
+	}
+
+	var warn Lacunas
+	var failed []string
+	for _, l := range lac.AsList() {
+		switch p.Action(l) {
+		case LacunaWarn:
+			warn = append(warn, l)
+		case LacunaFail:
+			failed = append(failed, fmt.Sprintf("%s lacuna: %s", l.Type, l.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return warn, errors.Mark(errors.Newf("%d lacunas not permitted by policy: %s", len(failed), strings.Join(failed, "; ")), terrors.ErrLacunaPolicyViolation)
+	}
+	return warn, nil
+}
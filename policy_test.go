@@ -0,0 +1,92 @@
+package thema
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLacunaPolicy(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	v := rt.Underlying().LookupPath(cue.MakePath(cue.Def("#LacunaPolicy"))).Unify(rt.Context().CompileString(`
+rules: [
+	{types: ["DroppedField"], path: "spec.legacy", action: "warn"},
+	{severity: "warning", action: "fail"},
+]
+`))
+	require.NoError(t, v.Validate(cue.Concrete(true)))
+	p, err := DecodeLacunaPolicy(v)
+	require.NoError(t, err)
+	assert.Equal(t, &LacunaPolicy{
+		Rules: []LacunaRule{
+			{Types: []LacunaType{LacunaDroppedField}, Path: "spec.legacy", Action: LacunaWarn},
+			{Severity: LacunaWarning, Action: LacunaFail},
+		},
+		Default: LacunaAllow,
+	}, p)
+
+	legacy := Lacuna{Type: LacunaDroppedField, SourceFields: []FieldRef{{Path: "spec.legacy.x"}}, Message: "legacy"}
+	other := Lacuna{Type: LacunaDroppedField, SourceFields: []FieldRef{{Path: "spec.legacyx"}}, Message: "other"}
+	changed := Lacuna{Type: LacunaChangedDefault, Message: "changed"}
+	assert.Equal(t, LacunaWarn, p.Action(legacy))
+	assert.Equal(t, LacunaFail, p.Action(other))
+	assert.Equal(t, LacunaAllow, p.Action(changed))
+
+	warn, err := p.Check(Lacunas{legacy, changed})
+	require.NoError(t, err)
+	assert.Equal(t, Lacunas{legacy}, warn)
+
+	warn, err = p.Check(Lacunas{legacy, other})
+	assert.True(t, errors.Is(err, terrors.ErrLacunaPolicyViolation), err)
+	assert.Contains(t, err.Error(), "DroppedField lacuna: other")
+	assert.Equal(t, Lacunas{legacy}, warn)
+
+	var nilp *LacunaPolicy
+	warn, err = nilp.Check(Lacunas{other})
+	assert.NoError(t, err)
+	assert.Empty(t, warn)
+
+	for _, bad := range []string{`rules: [{action: "nope"}]`, `rules: [{types: ["Nope"], action: "fail"}]`, `rules: [{path: "a[", action: "fail"}]`} {
+		_, err := DecodeLacunaPolicy(rt.Context().CompileString(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTranslationExecutorLacunaPolicy(t *testing.T) {
+	factory := func(rt *Runtime, opts ...BindOption) (Lineage, error) {
+		return BindLineage(rt.Context().CompileString(lacunalinstr), rt, opts...)
+	}
+	e, err := NewTranslationExecutor(factory, 2)
+	require.NoError(t, err)
+	e.LacunaPolicy = &LacunaPolicy{Rules: []LacunaRule{
+		{Types: []LacunaType{LacunaPlaceholder}, Action: LacunaFail},
+		{Action: LacunaWarn},
+	}}
+	var dead []DeadLetter
+	e.DeadLetters = func(dl DeadLetter) {
+		dead = append(dead, dl)
+	}
+
+	results, err := e.TranslateAll(context.Background(), []TranslationJob{
+		{Data: []byte(`{"a": "x", "b": "y"}`), From: SV(1, 0), To: SV(0, 0)},
+		{Data: []byte(`{"a": "x"}`), From: SV(0, 0), To: SV(1, 0)},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, results[0].Err)
+	assert.JSONEq(t, `{"a": "x"}`, string(results[0].Data))
+	require.Len(t, results[0].Warnings, 1)
+	assert.Equal(t, LacunaDroppedField, results[0].Warnings[0].Type)
+
+	assert.True(t, errors.Is(results[1].Err, terrors.ErrLacunaPolicyViolation), results[1].Err)
+	assert.Nil(t, results[1].Data)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "1", dead[0].ID)
+	assert.Len(t, dead[0].Lacunas.AsList(), 1)
+}
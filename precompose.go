@@ -0,0 +1,235 @@
+package thema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A PrecomposedLens describes a translation between two schemas that was
+// precomposed and cached by [Lineage.Warm].
+type PrecomposedLens struct {
+	From SyntacticVersion `json:"from"`
+	To   SyntacticVersion `json:"to"`
+
+	// Route is the route taken by translations between the schemas, as
+	// reported by [PlanTranslation].
+	Route []TranslationStep `json:"route"`
+
+	// Hits is the number of translations that have used the precomposed lens.
+	Hits uint64 `json:"hits"`
+
+	// Duration is the time taken to precompose the lens, including translating
+	// the examples of the From schema through it.
+	Duration time.Duration `json:"duration"`
+}
+
+// PrecomposedLenses returns the lenses precomposed on the lineage by calls to
+// [Lineage.Warm], ordered by their From version, then their To version.
+func PrecomposedLenses(lin Lineage) []PrecomposedLens {
+	isValidLineage(lin)
+
+	lc := lin.(*baseLineage).precomposed
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	all := make([]PrecomposedLens, 0, len(lc.m))
+	for id, pc := range lc.m {
+		all = append(all, PrecomposedLens{
+			From:     id.From,
+			To:       id.To,
+			Route:    pc.route,
+			Hits:     pc.hits.Load(),
+			Duration: pc.dur,
+		})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].From != all[j].From {
+			return all[i].From.Less(all[j].From)
+		}
+		return all[i].To.Less(all[j].To)
+	})
+	return all
+}
+
+// lensCache holds the lenses precomposed by [Lineage.Warm].
+type lensCache struct {
+	mu sync.RWMutex
+	m  map[lensID]*precomposed
+}
+
+type precomposed struct {
+	route []TranslationStep
+	// fn is #Translate applied to all its arguments but the instance, for
+	// lineages whose lenses are all written in CUE.
+	fn   cue.Value
+	hits atomic.Uint64
+	dur  time.Duration
+}
+
+func (lc *lensCache) get(id lensID) *precomposed {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.m[id]
+}
+
+func (lc *lensCache) put(id lensID, pc *precomposed) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.m == nil {
+		lc.m = make(map[lensID]*precomposed)
+	}
+	lc.m[id] = pc
+}
+
+func (lc *lensCache) remove(id lensID) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	delete(lc.m, id)
+}
+
+func (lin *baseLineage) Warm(from, to SyntacticVersion) error {
+	isValidLineage(lin)
+
+	start := time.Now()
+	trace, err := PlanTranslation(lin, from, to)
+	if err != nil {
+		return err
+	}
+	pc := &precomposed{route: trace.Route}
+	if len(lin.shortcuts) == 0 && len(lin.lensmap) == 0 {
+		pc.fn, err = guard("translation", func() (cue.Value, error) {
+			return cueArgs{
+				"to":   to,
+				"from": from,
+				"lin":  lin.Underlying(),
+			}.make("#Translate", lin.rt)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	id := lid(from, to)
+	lin.precomposed.put(id, pc)
+
+	// Exercise the precomposed lens, so that any evaluation CUE defers until
+	// first use happens now
+	sch := lin.schema(from)
+	examples := sch.Examples()
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, _, err := examples[name].translate(to, evalDeadline{}); err != nil {
+			lin.precomposed.remove(id)
+			return errors.Wrapf(err, "example %s of schema %s failed to translate to %s", name, from, to)
+		}
+	}
+	pc.hits.Store(0)
+	pc.dur = time.Since(start)
+	return nil
+}
+
+// A PrecompileError reports the latent errors found in a lineage by
+// [Lineage.Precompile].
+type PrecompileError struct {
+	// Lineage is the name of the lineage.
+	Lineage string
+
+	// Errs are the errors found, in the order in which they were found.
+	Errs []error
+}
+
+func (e *PrecompileError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "lineage %s failed to precompile with %d errors:", e.Lineage, len(e.Errs))
+	for _, err := range e.Errs {
+		fmt.Fprintf(&b, "\n\t%s", strings.ReplaceAll(err.Error(), "\n", "\n\t"))
+	}
+	return b.String()
+}
+
+// Unwrap implements standard Go error unwrapping, relied on by errors.Is.
+// All PrecompileErrors wrap [terrors.ErrInvalidLineage].
+func (e *PrecompileError) Unwrap() error {
+	return terrors.ErrInvalidLineage
+}
+
+func (lin *baseLineage) Precompile(ctx context.Context) error {
+	isValidLineage(lin)
+
+	var errs []error
+	for _, sch := range lin.allsch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := guard("precompiling schema", func() (struct{}, error) {
+			lin.rt.rl()
+			defer lin.rt.ru()
+			return struct{}{}, sch.def.Validate()
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "schema %s", sch.v))
+			continue
+		}
+		sch.hasListKeys()
+
+		examples := sch.Examples()
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := sch.Validate(examples[name].raw); err != nil {
+				errs = append(errs, errors.Wrapf(err, "example %s of schema %s is invalid", name, sch.v))
+			}
+		}
+	}
+
+	var pairs []lensID
+	for i := 1; i < len(lin.allv); i++ {
+		pairs = append(pairs, lid(lin.allv[i-1], lin.allv[i]), lid(lin.allv[i], lin.allv[i-1]))
+	}
+	for _, l := range lin.Lenses() {
+		if l.Shortcut {
+			pairs = append(pairs, lid(l.From, l.To))
+		}
+	}
+	for _, id := range pairs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := lin.Warm(id.From, id.To); err != nil {
+			errs = append(errs, errors.Wrapf(err, "lens %s", id))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &PrecompileError{Lineage: lin.name, Errs: errs}
+	}
+	return nil
+}
+
+// apply returns the output of the precomposed #Translate for the instance raw.
+func (pc *precomposed) apply(raw cue.Value, rt *Runtime) cue.Value {
+	rt.l()
+	v := pc.fn.FillPath(cue.MakePath(cue.Str("inst")), raw)
+	rt.u()
+
+	rt.rl()
+	defer rt.ru()
+	return v.LookupPath(outpath)
+}
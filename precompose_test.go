@@ -0,0 +1,100 @@
+package thema
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestWarm(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(routelin), rt)
+	require.NoError(t, err)
+	cold, err := BindLineage(rt.Context().CompileString(routelin), rt)
+	require.NoError(t, err)
+
+	assert.Empty(t, PrecomposedLenses(lin))
+	require.NoError(t, lin.Warm(SV(0, 0), SV(2, 0)))
+	require.NoError(t, lin.Warm(SV(2, 0), SV(0, 0)))
+	err = lin.Warm(SV(0, 0), SV(3, 0))
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist), "expected ErrVersionNotExist, got %s", err)
+
+	pcs := PrecomposedLenses(lin)
+	require.Len(t, pcs, 2)
+	assert.Equal(t, SV(0, 0), pcs[0].From)
+	assert.Equal(t, SV(2, 0), pcs[0].To)
+	assert.Len(t, pcs[0].Route, 3)
+	assert.Equal(t, uint64(0), pcs[0].Hits)
+	assert.Equal(t, SV(2, 0), pcs[1].From)
+
+	translate := func(lin Lineage) string {
+		t.Helper()
+		inst, err := lin.First().Validate(rt.Context().CompileString(`{title: "foo"}`))
+		require.NoError(t, err)
+		out, _, err := inst.Translate(SV(2, 0))
+		require.NoError(t, err)
+		label, err := out.Underlying().LookupPath(cue.ParsePath("label")).String()
+		require.NoError(t, err)
+		return label
+	}
+	assert.Equal(t, translate(cold), translate(lin))
+	assert.Equal(t, "foo", translate(lin))
+	assert.Equal(t, uint64(2), PrecomposedLenses(lin)[0].Hits)
+	assert.Equal(t, uint64(0), PrecomposedLenses(lin)[1].Hits)
+}
+
+func TestPrecompile(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin, err := BindLineage(rt.Context().CompileString(routelin), rt)
+	require.NoError(t, err)
+	require.NoError(t, lin.Precompile(context.Background()))
+	// Each pair of adjacent schemas, in both directions
+	assert.Len(t, PrecomposedLenses(lin), 6)
+
+	broken := testLin(`name: "broken"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+	examples: {
+		lower: title: "fine"
+		upper: title: "NOT FINE"
+	}
+},
+{
+	version: [1, 0]
+	schema: name: string & =~"^[a-z]+$"
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+},
+{
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`)
+	err = broken.Precompile(context.Background())
+	assert.True(t, errors.Is(err, terrors.ErrInvalidLineage))
+	var perr *PrecompileError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, "broken", perr.Lineage)
+	require.Len(t, perr.Errs, 1)
+	assert.Contains(t, perr.Errs[0].Error(), "example upper of schema 0.0 failed to translate to 1.0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, broken.Precompile(ctx))
+}
@@ -0,0 +1,129 @@
+package thema
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Progress receives reports on the progress of a long-running operation
+// over many items, such as translating a large number of objects with a
+// [TranslationExecutor], so that it need not run silently.
+//
+// The methods of a Progress are not called concurrently for a single
+// operation.
+type Progress interface {
+	// OnStart is called once, before any item is processed, with the name of
+	// the operation and the number of items it will process, or -1 if that
+	// is not known in advance.
+	OnStart(op string, total int)
+
+	// OnItem is called as each item is completed, with a name identifying it
+	// and the error processing it, if any.
+	OnItem(item string, err error)
+
+	// OnFinish is called once, after the last item is completed, with the
+	// error that ended the operation early, if any.
+	OnFinish(err error)
+}
+
+// nopProgress discards all reports, where no Progress is provided.
+type nopProgress struct{}
+
+func (nopProgress) OnStart(string, int)  {}
+func (nopProgress) OnItem(string, error) {}
+func (nopProgress) OnFinish(error)       {}
+
+func progressOrNop(p Progress) Progress {
+	if p == nil {
+		return nopProgress{}
+	}
+	return p
+}
+
+// ConsoleProgress returns a [Progress] writing human-readable reports to w,
+// typically standard error. A line reporting the number of items completed
+// and failed, the rate of completion and, where the total is known, the
+// estimated time remaining, is written at most once per interval, and once
+// when the operation finishes. An interval of zero reports every item.
+//
+// The returned Progress is safe for concurrent use, and may be reused for
+// successive operations.
+func ConsoleProgress(w io.Writer, interval time.Duration) Progress {
+	return &consoleProgress{w: w, interval: interval, now: time.Now}
+}
+
+type consoleProgress struct {
+	w        io.Writer
+	interval time.Duration
+	now      func() time.Time
+
+	mu                  sync.Mutex
+	op                  string
+	total, done, failed int
+	start, last         time.Time
+}
+
+func (p *consoleProgress) OnStart(op string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.op, p.total, p.done, p.failed = op, total, 0, 0
+	p.start = p.now()
+	p.last = p.start
+}
+
+func (p *consoleProgress) OnItem(item string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if err != nil {
+		p.failed++
+	}
+	if now := p.now(); now.Sub(p.last) >= p.interval {
+		p.last = now
+		fmt.Fprintln(p.w, p.status(now)) // nolint: errcheck
+	}
+}
+
+func (p *consoleProgress) OnFinish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if err != nil {
+		fmt.Fprintf(p.w, "%s: stopped after %d items in %s: %s\n", p.op, p.done, roundDuration(now.Sub(p.start)), err) // nolint: errcheck
+		return
+	}
+	fmt.Fprintf(p.w, "%s: finished %d items in %s, %d failed\n", p.op, p.done, roundDuration(now.Sub(p.start)), p.failed) // nolint: errcheck
+}
+
+// status describes the progress of the operation as of now.
+func (p *consoleProgress) status(now time.Time) string {
+	elapsed := now.Sub(p.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.done) / elapsed.Seconds()
+	}
+
+	if p.total < 0 {
+		return fmt.Sprintf("%s: %d items, %d failed, %.1f/s", p.op, p.done, p.failed, rate)
+	}
+	s := fmt.Sprintf("%s: %d/%d items", p.op, p.done, p.total)
+	if p.total > 0 {
+		s += fmt.Sprintf(" (%d%%)", p.done*100/p.total)
+	}
+	s += fmt.Sprintf(", %d failed, %.1f/s", p.failed, rate)
+	if rate > 0 && p.done < p.total {
+		eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		s += fmt.Sprintf(", %s remaining", roundDuration(eta))
+	}
+	return s
+}
+
+// roundDuration rounds d to a precision suitable for display.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
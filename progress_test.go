@@ -0,0 +1,79 @@
+package thema
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordProgress records the reports it receives as lines of text.
+type recordProgress struct {
+	lines []string
+}
+
+func (p *recordProgress) OnStart(op string, total int) {
+	p.lines = append(p.lines, fmt.Sprintf("start %s %d", op, total))
+}
+
+func (p *recordProgress) OnItem(item string, err error) {
+	p.lines = append(p.lines, fmt.Sprintf("item %s %t", item, err != nil))
+}
+
+func (p *recordProgress) OnFinish(err error) {
+	p.lines = append(p.lines, fmt.Sprintf("finish %t", err != nil))
+}
+
+func TestConsoleProgress(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+	p := &consoleProgress{w: &buf, interval: 2 * time.Second, now: func() time.Time { return now }}
+
+	p.OnStart("translate", 10)
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Second)
+		var err error
+		if i == 2 {
+			err = errors.New("bad")
+		}
+		p.OnItem(fmt.Sprint(i), err)
+	}
+	p.OnFinish(nil)
+	assert.Equal(t, `translate: 2/10 items (20%), 0 failed, 1.0/s, 8s remaining
+translate: 4/10 items (40%), 1 failed, 1.0/s, 6s remaining
+translate: finished 4 items in 4s, 1 failed
+`, buf.String())
+
+	buf.Reset()
+	p.OnStart("stream", -1)
+	now = now.Add(4 * time.Second)
+	p.OnItem("a", nil)
+	p.OnFinish(context.Canceled)
+	assert.Equal(t, `stream: 1 items, 0 failed, 0.2/s
+stream: stopped after 1 items in 4s: context canceled
+`, buf.String())
+}
+
+func TestTranslationExecutorProgress(t *testing.T) {
+	factory := func(rt *Runtime, opts ...BindOption) (Lineage, error) {
+		return BindLineage(rt.Context().CompileString(executorLineage), rt, opts...)
+	}
+	e, err := NewTranslationExecutor(factory, 2)
+	require.NoError(t, err)
+	p := &recordProgress{}
+	e.Progress = p
+
+	_, err = e.TranslateAll(context.Background(), []TranslationJob{
+		{Data: []byte(`{"title": "a"}`), From: SV(0, 0), To: SV(1, 0)},
+		{Data: []byte(`{"bad": true}`), From: SV(0, 0), To: SV(1, 0)},
+		{Data: []byte(`{"title": "c"}`), From: SV(0, 0), To: SV(1, 0)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "start translate 3\nitem 0 false\nitem 1 true\nitem 2 false\nfinish false", strings.Join(p.lines, "\n"))
+}
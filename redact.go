@@ -0,0 +1,83 @@
+package thema
+
+import (
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+)
+
+// RedactedPlaceholder is the value with which [Redact] replaces the values of
+// sensitive fields.
+const RedactedPlaceholder = "[REDACTED]"
+
+// sensitiveAttr is the name of the CUE attribute that marks a schema field as
+// sensitive, e.g.:
+//
+//	password: string @sensitive()
+const sensitiveAttr = "sensitive"
+
+// Redact returns a copy of the data in the provided [Instance] in which the
+// values of all fields marked sensitive in the provided schema are replaced
+// with [RedactedPlaceholder]. Fields are marked sensitive with the @sensitive()
+// attribute. All values beneath a sensitive field are replaced, including
+// structs and lists.
+//
+// If sch is nil, the instance's own schema is used. Passing a different schema
+// allows, for example, redacting data with respect to the annotations of the
+// latest schema in a lineage.
+//
+// The returned value is intended for inclusion in logs, support bundles and
+// the like. It is generally not a valid instance of the schema, as the
+// placeholder may not be a valid value for the redacted fields.
+func Redact(inst *Instance, sch Schema) (cue.Value, error) {
+	inst.check()
+	if sch == nil {
+		sch = inst.Schema()
+	}
+
+	var paths [][]string
+	walkAttrs(sch.Underlying().LookupPath(pathSchDef), nil, func(path []string, attrs []Attribute) {
+		for _, a := range attrs {
+			if a.Name == sensitiveAttr {
+				paths = append(paths, path)
+				return
+			}
+		}
+	})
+
+	var data any
+	if err := inst.Underlying().Decode(&data); err != nil {
+		return cue.Value{}, errors.Wrap(err, "unable to decode instance for redaction")
+	}
+	for _, path := range paths {
+		data, _ = mapPath(data, path, func(any) (any, error) {
+			return RedactedPlaceholder, nil
+		})
+	}
+	return inst.Underlying().Context().Encode(data), nil
+}
+
+// mapPath replaces the value at the provided path in the decoded data with the
+// result of calling fn on it. A path element of "*" matches all elements of a
+// list. Paths that do not exist in the data are ignored.
+func mapPath(data any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(data)
+	}
+
+	var err error
+	switch x := data.(type) {
+	case map[string]any:
+		if v, has := x[path[0]]; has {
+			x[path[0]], err = mapPath(v, path[1:], fn)
+		}
+	case []any:
+		if path[0] == "*" {
+			for i, v := range x {
+				if x[i], err = mapPath(v, path[1:], fn); err != nil {
+					break
+				}
+			}
+		}
+	}
+	return data, err
+}
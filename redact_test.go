@@ -0,0 +1,53 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	lin := testLin(`name: "redact"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		user:      string
+		password:  string @sensitive()
+		tokens?: [...{
+			name:   string
+			secret: string @sensitive()
+		}]
+		creds?: {
+			key: string
+		} @sensitive()
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+
+	inst, err := lin.First().Validate(ctx.CompileString(`{
+	user: "admin"
+	password: "hunter2"
+	tokens: [{name: "a", secret: "s1"}, {name: "b", secret: "s2"}]
+	creds: key: "k"
+}`))
+	require.NoError(t, err)
+
+	v, err := Redact(inst, nil)
+	require.NoError(t, err)
+	b, err := v.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+	"user": "admin",
+	"password": "[REDACTED]",
+	"tokens": [{"name": "a", "secret": "[REDACTED]"}, {"name": "b", "secret": "[REDACTED]"}],
+	"creds": "[REDACTED]"
+}`, string(b))
+
+	// Original instance is unmodified
+	pw, err := inst.Underlying().LookupPath(cue.ParsePath("password")).String()
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", pw)
+}
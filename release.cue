@@ -0,0 +1,22 @@
+package thema
+
+// #ReleaseMap maps the releases of a product to the versions of the schemas
+// in each of its lineages that the release shipped.
+//
+// Releases need only be listed when a schema in some lineage changes. A release
+// that is not listed shipped the same schemas as the most recent listed release
+// preceding it.
+#ReleaseMap: {
+	releases: [...#Release]
+}
+
+// #Release records the schemas shipped in a single release of a product.
+#Release: {
+	// version is the semantic version of the release, e.g. "v10.2.0".
+	version: =~"^v?[0-9]+\\.[0-9]+\\.[0-9]+"
+
+	// schemas maps the name of each lineage to the version of its schema
+	// shipped in the release. Lineages absent from the release either did not
+	// exist, or are unchanged since a preceding release.
+	schemas: [string]: #SyntacticVersion
+}
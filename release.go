@@ -0,0 +1,119 @@
+package thema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+	terrors "github.com/grafana/thema/errors"
+	"golang.org/x/mod/semver"
+)
+
+// A ReleaseMap maps the releases of a product to the versions of the schemas
+// in each of its lineages that the release shipped, so that tooling can
+// answer which schema a given release used.
+//
+// A ReleaseMap may be declared in CUE as a thema.#ReleaseMap, or in a JSON
+// manifest of the same shape, and decoded by [DecodeReleaseMap].
+type ReleaseMap struct {
+	// Releases are the releases of the product, in ascending order of version.
+	Releases []Release `json:"releases"`
+}
+
+// A Release records the schemas shipped in a single release of a product.
+type Release struct {
+	// Version is the semantic version of the release, e.g. "v10.2.0".
+	Version string `json:"version"`
+
+	// Schemas maps the name of each lineage to the version of its schema
+	// shipped in the release.
+	Schemas map[string]SyntacticVersion `json:"schemas"`
+}
+
+// DecodeReleaseMap decodes a ReleaseMap from a CUE value. Releases are sorted
+// by version, which must be a valid semantic version, with or without a
+// leading "v". An error is returned if any version is declared twice.
+func DecodeReleaseMap(v cue.Value) (*ReleaseMap, error) {
+	var m ReleaseMap
+	if err := v.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid release map: %w", err)
+	}
+	for _, r := range m.Releases {
+		if !semver.IsValid(canonicalRelease(r.Version)) {
+			return nil, fmt.Errorf("invalid release map: %q is not a semantic version", r.Version)
+		}
+	}
+	sort.SliceStable(m.Releases, func(i, j int) bool {
+		return compareReleases(m.Releases[i].Version, m.Releases[j].Version) < 0
+	})
+	for i := 1; i < len(m.Releases); i++ {
+		if compareReleases(m.Releases[i-1].Version, m.Releases[i].Version) == 0 {
+			return nil, fmt.Errorf("invalid release map: release %s declared more than once", m.Releases[i].Version)
+		}
+	}
+	return &m, nil
+}
+
+// VersionAt returns the version of the named lineage's schema shipped in the
+// given release, which is that declared by the most recent release at or
+// preceding it that mentions the lineage. False is returned if no such release
+// exists.
+func (m *ReleaseMap) VersionAt(lineage, release string) (SyntacticVersion, bool) {
+	for i := len(m.Releases) - 1; i >= 0; i-- {
+		r := m.Releases[i]
+		if compareReleases(r.Version, release) > 0 {
+			continue
+		}
+		if v, has := r.Schemas[lineage]; has {
+			return v, true
+		}
+	}
+	return SyntacticVersion{}, false
+}
+
+// SchemaAt returns the schema in lin shipped in the given release, as
+// determined by [ReleaseMap.VersionAt]. An error wrapping
+// [terrors.ErrVersionNotExist] is returned if the release map declares no
+// schema for the lineage at the release, or if the declared version does not
+// exist in lin.
+func (m *ReleaseMap) SchemaAt(lin Lineage, release string) (Schema, error) {
+	if !semver.IsValid(canonicalRelease(release)) {
+		return nil, errors.Newf("%q is not a semantic version", release)
+	}
+	v, has := m.VersionAt(lin.Name(), release)
+	if !has {
+		return nil, errors.Mark(errors.Newf("no schema for lineage %q declared at or before release %s", lin.Name(), release), terrors.ErrVersionNotExist)
+	}
+	sch, err := lin.Schema(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "release %s declares lineage %q at version %s", release, lin.Name(), v)
+	}
+	return sch, nil
+}
+
+// ReleasesOf returns the versions of the releases that shipped the schema of
+// the named lineage with the given version, in ascending order. Only releases
+// declared in the map are returned.
+func (m *ReleaseMap) ReleasesOf(lineage string, v SyntacticVersion) []string {
+	var rels []string
+	for _, r := range m.Releases {
+		if rv, has := m.VersionAt(lineage, r.Version); has && rv == v {
+			rels = append(rels, r.Version)
+		}
+	}
+	return rels
+}
+
+// canonicalRelease adds the leading "v" required by the semver package.
+func canonicalRelease(s string) string {
+	if strings.HasPrefix(s, "v") {
+		return s
+	}
+	return "v" + s
+}
+
+func compareReleases(a, b string) int {
+	return semver.Compare(canonicalRelease(a), canonicalRelease(b))
+}
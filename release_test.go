@@ -0,0 +1,65 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseMap(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	lin := testLin(lacunalinstr)
+
+	v := rt.Underlying().LookupPath(cue.MakePath(cue.Def("#ReleaseMap"))).Unify(rt.Context().CompileString(`
+releases: [{
+	version: "v10.2.0"
+	schemas: lacunae: [1, 0]
+}, {
+	version: "9.0.0"
+	schemas: {lacunae: [0, 0], other: [0, 0]}
+}, {
+	version: "v10.4.1"
+	schemas: other: [0, 1]
+}, {
+	version: "v11.0.0"
+	schemas: lacunae: [2, 0]
+}]
+`))
+	require.NoError(t, v.Validate(cue.Concrete(true)))
+	m, err := DecodeReleaseMap(v)
+	require.NoError(t, err)
+	assert.Equal(t, "9.0.0", m.Releases[0].Version)
+
+	for rel, want := range map[string]SyntacticVersion{
+		"v9.0.0":  SV(0, 0),
+		"9.5.3":   SV(0, 0),
+		"v10.2.0": SV(1, 0),
+		"v10.4.1": SV(1, 0),
+		"v10.9.0": SV(1, 0),
+	} {
+		sch, err := m.SchemaAt(lin, rel)
+		require.NoError(t, err, rel)
+		assert.Equal(t, want, sch.Version(), rel)
+	}
+
+	_, err = m.SchemaAt(lin, "v8.0.0")
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist), err)
+	_, err = m.SchemaAt(lin, "v11.0.0")
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist), err)
+	_, err = m.SchemaAt(lin, "latest")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"v10.2.0", "v10.4.1"}, m.ReleasesOf("lacunae", SV(1, 0)))
+	assert.Equal(t, []string{"v10.4.1", "v11.0.0"}, m.ReleasesOf("other", SV(0, 1)))
+	assert.Empty(t, m.ReleasesOf("lacunae", SV(0, 1)))
+
+	_, err = DecodeReleaseMap(rt.Context().CompileString(`releases: [{version: "v1.0.0", schemas: {}}, {version: "1.0.0", schemas: {}}]`))
+	assert.Error(t, err)
+	_, err = DecodeReleaseMap(rt.Context().CompileString(`releases: [{version: "ten", schemas: {}}]`))
+	assert.Error(t, err)
+}
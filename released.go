@@ -0,0 +1,208 @@
+package thema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A ReleaseManifest records the schemas of one or more lineages that have been
+// released, along with a checksum of each, so that a released schema cannot
+// later be changed without notice. Where a [ReleaseMap] records which schema
+// versions each release of a product shipped, a ReleaseManifest records what
+// those schemas were.
+//
+// A ReleaseManifest is meant to be committed alongside the lineages it
+// records. Lineages bound with [EnforceReleases] are then rejected if any of
+// their released schemas differ from the manifest, turning the rule that
+// released schemas are immutable from a convention into one that is enforced.
+//
+// ReleaseManifests are serialized as JSON. The zero value is an empty
+// ReleaseManifest, ready for use.
+type ReleaseManifest struct {
+	// Lineages maps the name of each lineage to its released schemas, in
+	// ascending order of version.
+	Lineages map[string][]ReleasedSchema `json:"lineages"`
+}
+
+// A ReleasedSchema records a single released schema within a
+// [ReleaseManifest].
+type ReleasedSchema struct {
+	// Version is the version of the schema.
+	Version SyntacticVersion `json:"version"`
+
+	// Checksum is the checksum of the schema, as computed by [SchemaChecksum].
+	Checksum string `json:"checksum"`
+
+	// Release optionally identifies the release in which the schema was first
+	// released, such as a product version. It is opaque to Thema.
+	Release string `json:"release,omitempty"`
+}
+
+// EnforceReleases makes [BindLineage] verify the lineage against the provided
+// ReleaseManifest with [ReleaseManifest.Verify], failing if any schema the
+// manifest records as released has been removed or changed.
+func EnforceReleases(m *ReleaseManifest) BindOption {
+	return func(c *bindConfig) {
+		c.releases = m
+	}
+}
+
+// ReadReleaseManifest reads a JSON-encoded [ReleaseManifest].
+func ReadReleaseManifest(r io.Reader) (*ReleaseManifest, error) {
+	m := &ReleaseManifest{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("invalid release manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Write writes the ReleaseManifest as indented JSON.
+func (m *ReleaseManifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Released returns the record of the named lineage's schema with the provided
+// version, if it has been released.
+func (m *ReleaseManifest) Released(lineage string, v SyntacticVersion) (ReleasedSchema, bool) {
+	for _, rs := range m.Lineages[lineage] {
+		if rs.Version == v {
+			return rs, true
+		}
+	}
+	return ReleasedSchema{}, false
+}
+
+// Release marks every schema in the lineage up to and including the provided
+// version as released, recording the given release against those not already
+// marked. The lineage is first verified with [ReleaseManifest.Verify], so that
+// schemas which have already changed cannot be marked afresh.
+func (m *ReleaseManifest) Release(lin Lineage, through SyntacticVersion, release string) error {
+	isValidLineage(lin)
+	if _, err := lin.Schema(through); err != nil {
+		return err
+	}
+	if err := m.Verify(lin); err != nil {
+		return err
+	}
+
+	rel := m.Lineages[lin.Name()]
+	for _, sch := range lin.All() {
+		if sch.Version().Less(through) || sch.Version() == through {
+			if _, has := m.Released(lin.Name(), sch.Version()); has {
+				continue
+			}
+			sum, err := SchemaChecksum(sch)
+			if err != nil {
+				return err
+			}
+			rel = append(rel, ReleasedSchema{
+				Version:  sch.Version(),
+				Checksum: sum,
+				Release:  release,
+			})
+		}
+	}
+	sort.Slice(rel, func(i, j int) bool {
+		return rel[i].Version.Less(rel[j].Version)
+	})
+
+	if m.Lineages == nil {
+		m.Lineages = make(map[string][]ReleasedSchema)
+	}
+	m.Lineages[lin.Name()] = rel
+	return nil
+}
+
+// Verify checks that every schema of the lineage recorded in the
+// ReleaseManifest as released still exists, and is unchanged. Schemas not yet
+// released may be changed freely, and lineages absent from the manifest are
+// always accepted.
+//
+// The returned error describes every offending schema, and is marked with
+// [terrors.ErrReleasedSchemaChanged].
+func (m *ReleaseManifest) Verify(lin Lineage) error {
+	isValidLineage(lin)
+
+	var problems []string
+	for _, rs := range m.Lineages[lin.Name()] {
+		sch, err := lin.Schema(rs.Version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("released schema %s has been removed", rs.Version))
+			continue
+		}
+		sum, err := SchemaChecksum(sch)
+		if err != nil {
+			return err
+		}
+		if sum != rs.Checksum {
+			problems = append(problems, fmt.Sprintf("released schema %s has checksum %s, but was released with %s", rs.Version, sum, rs.Checksum))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Mark(errors.Newf("lineage %q: %s", lin.Name(), strings.Join(problems, "; ")), terrors.ErrReleasedSchemaChanged)
+	}
+	return nil
+}
+
+// SchemaChecksum computes a checksum of the provided schema, suitable for
+// detecting any change to it. The checksum is taken over the formatted
+// declaration of the schema within the output of [Lineage.Export], and is
+// therefore independent of how or where the lineage was loaded, and unaffected
+// by changes to the lineage's other schemas, lenses, or examples.
+func SchemaChecksum(sch Schema) (string, error) {
+	f, err := sch.Lineage().Export()
+	if err != nil {
+		return "", err
+	}
+
+	var list *ast.ListLit
+	for _, decl := range f.Decls {
+		if field, is := decl.(*ast.Field); is && fieldName(field) == "schemas" {
+			list, _ = field.Value.(*ast.ListLit)
+		}
+	}
+	idx := -1
+	for i, s := range sch.Lineage().All() {
+		if s.Version() == sch.Version() {
+			idx = i
+		}
+	}
+	if list == nil || idx < 0 || idx >= len(list.Elts) {
+		return "", errors.Newf("unable to locate schema %s in exported lineage %s", sch.Version(), sch.Lineage().Name())
+	}
+
+	var node ast.Node = list.Elts[idx]
+	if st, is := node.(*ast.StructLit); is {
+		for _, elt := range st.Elts {
+			if field, is := elt.(*ast.Field); is && fieldName(field) == "schema" {
+				node = field.Value
+			}
+		}
+	}
+	b, err := format.Node(node)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func fieldName(f *ast.Field) string {
+	name, _, _ := ast.LabelName(f.Label)
+	return name
+}
@@ -0,0 +1,67 @@
+package thema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestReleaseManifest(t *testing.T) {
+	lin := testLin(lacunalinstr)
+	bind := func(linstr string, opts ...BindOption) (Lineage, error) {
+		rt := NewRuntime(cuecontext.New())
+		return BindLineage(rt.Context().CompileString(linstr), rt, opts...)
+	}
+
+	m := &ReleaseManifest{}
+	require.NoError(t, m.Release(lin, SV(0, 0), "v1.0.0"))
+	rs, has := m.Released("lacunae", SV(0, 0))
+	require.True(t, has)
+	assert.Equal(t, "v1.0.0", rs.Release)
+	assert.True(t, strings.HasPrefix(rs.Checksum, "sha256:"))
+	_, has = m.Released("lacunae", SV(1, 0))
+	assert.False(t, has)
+
+	require.NoError(t, m.Release(lin, SV(1, 0), "v2.0.0"))
+	require.Len(t, m.Lineages["lacunae"], 2)
+	assert.Equal(t, "v1.0.0", m.Lineages["lacunae"][0].Release, "existing releases are not overwritten")
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+	rm, err := ReadReleaseManifest(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, m, rm)
+
+	t.Run("unreleased schemas may change", func(t *testing.T) {
+		m := &ReleaseManifest{}
+		require.NoError(t, m.Release(lin, SV(0, 0), ""))
+		_, err := bind(strings.Replace(lacunalinstr, "{a: string, b: string}", "{a: string, b: string, c?: int}", 1), EnforceReleases(m))
+		assert.NoError(t, err)
+	})
+
+	t.Run("released schemas may not change", func(t *testing.T) {
+		_, err := bind(lacunalinstr, EnforceReleases(m))
+		require.NoError(t, err)
+
+		_, err = bind(strings.Replace(lacunalinstr, "{a: string, b: string}", "{a: string, b: string, c?: int}", 1), EnforceReleases(m))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, terrors.ErrReleasedSchemaChanged))
+		assert.Contains(t, err.Error(), "released schema 1.0 has checksum")
+
+		_, err = bind(strings.Replace(lacunalinstr, "schema: a: string", "schema: a: string | *\"x\"", 1), EnforceReleases(m))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "released schema 0.0 has checksum")
+	})
+
+	t.Run("other lineages unaffected", func(t *testing.T) {
+		_, err := bind(lenientlinstr, EnforceReleases(m))
+		assert.NoError(t, err)
+	})
+}
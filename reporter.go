@@ -0,0 +1,258 @@
+package thema
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxReporterPaths is the number of distinct failing paths a Reporter
+	// tracks, bounding its memory use and the cardinality of its metrics.
+	// Failures at further paths are counted under the empty path.
+	maxReporterPaths = 1000
+
+	// reporterTopPaths is the number of failing paths included in a
+	// [ReportSnapshot].
+	reporterTopPaths = 10
+)
+
+// A Reporter accumulates the outcomes of validations and translations against
+// the schemas of a single lineage, for dashboards and alerts about the health
+// of the lineage's schemas. Outcomes are recorded by calling
+// [Reporter.ObserveValidation] and [Reporter.ObserveTranslation] with the
+// results of the corresponding operations, and summarized by
+// [Reporter.Snapshot].
+//
+// A Reporter is safe for concurrent use.
+type Reporter struct {
+	lin   Lineage
+	since time.Time
+
+	mu           sync.Mutex
+	valid        map[SyntacticVersion]uint64
+	invalid      map[SyntacticVersion]uint64
+	unmatched    uint64
+	paths        map[pathKey]uint64
+	translations uint64
+	translateErr uint64
+	lacunas      map[LacunaType]uint64
+}
+
+type pathKey struct {
+	v    SyntacticVersion
+	path string
+}
+
+// NewReporter returns a Reporter for outcomes against the schemas of lin.
+func NewReporter(lin Lineage) *Reporter {
+	isValidLineage(lin)
+	return &Reporter{
+		lin:     lin,
+		since:   time.Now(),
+		valid:   make(map[SyntacticVersion]uint64),
+		invalid: make(map[SyntacticVersion]uint64),
+		paths:   make(map[pathKey]uint64),
+		lacunas: make(map[LacunaType]uint64),
+	}
+}
+
+// ObserveValidation records the outcome of validating data against sch, as
+// returned from [Schema.Validate] or [Schema.ValidateLenient]. A nil sch
+// records that no schema in the lineage matched the data, as reported by
+// [SearchAndValidate] or [Lineage.ValidateAny].
+func (r *Reporter) ObserveValidation(sch Schema, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sch == nil {
+		r.unmatched++
+		return
+	}
+	v := sch.Version()
+	if err == nil {
+		r.valid[v]++
+		return
+	}
+	r.invalid[v]++
+
+	seen := make(map[string]bool)
+	for _, issue := range ValidationIssues(err) {
+		p := strings.Join(issue.Path, ".")
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		k := pathKey{v: v, path: p}
+		if _, has := r.paths[k]; !has && len(r.paths) >= maxReporterPaths {
+			k.path = ""
+		}
+		r.paths[k]++
+	}
+}
+
+// ObserveTranslation records the outcome of a translation, as returned from
+// [Instance.Translate].
+func (r *Reporter) ObserveTranslation(lac TranslationLacunas, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.translations++
+	if err != nil {
+		r.translateErr++
+		return
+	}
+	if lac == nil {
+		return
+	}
+	for _, l := range lac.AsList() {
+		r.lacunas[l.Type]++
+	}
+}
+
+// A ReportSnapshot summarizes the outcomes accumulated by a [Reporter], as
+// returned from [Reporter.Snapshot]. It is suitable for encoding as JSON, or
+// in the Prometheus text exposition format by [ReportSnapshot.WritePrometheus].
+type ReportSnapshot struct {
+	Lineage string `json:"lineage"`
+
+	// Since is when the Reporter was created, and At when the snapshot was
+	// taken.
+	Since time.Time `json:"since"`
+	At    time.Time `json:"at"`
+
+	// Versions holds the validation outcomes against each schema in the
+	// lineage, in version order.
+	Versions []VersionOutcomes `json:"versions"`
+
+	// Unmatched is the number of validations for which no schema in the
+	// lineage matched the data.
+	Unmatched uint64 `json:"unmatched"`
+
+	// FailingPaths lists the paths at which data most commonly failed
+	// validation, most common first.
+	FailingPaths []FailingPathCount `json:"failingPaths,omitempty"`
+
+	// Translations is the number of translations observed, of which
+	// TranslationErrors failed.
+	Translations      uint64 `json:"translations"`
+	TranslationErrors uint64 `json:"translationErrors"`
+
+	// Lacunas counts the lacunas emitted by translations by type, in type
+	// order.
+	Lacunas []LacunaCount `json:"lacunas,omitempty"`
+}
+
+// VersionOutcomes counts the validation outcomes against a single schema.
+type VersionOutcomes struct {
+	Version SyntacticVersion `json:"version"`
+	Valid   uint64           `json:"valid"`
+	Invalid uint64           `json:"invalid"`
+}
+
+// A FailingPathCount counts the validation failures against a single schema at
+// a single path.
+type FailingPathCount struct {
+	Version SyntacticVersion `json:"version"`
+
+	// Path is the dot-separated path to the failing field. It is empty for
+	// failures not attributed to a field, and for failures at paths beyond
+	// those the Reporter tracks.
+	Path  string `json:"path"`
+	Count uint64 `json:"count"`
+}
+
+// A LacunaCount counts the lacunas of a single type.
+type LacunaCount struct {
+	Type  LacunaType `json:"type"`
+	Count uint64     `json:"count"`
+}
+
+// Snapshot returns a summary of the outcomes observed so far.
+func (r *Reporter) Snapshot() ReportSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := ReportSnapshot{
+		Lineage:           r.lin.Name(),
+		Since:             r.since,
+		At:                time.Now(),
+		Unmatched:         r.unmatched,
+		Translations:      r.translations,
+		TranslationErrors: r.translateErr,
+	}
+	for _, v := range r.lin.allVersions() {
+		s.Versions = append(s.Versions, VersionOutcomes{Version: v, Valid: r.valid[v], Invalid: r.invalid[v]})
+	}
+
+	for k, n := range r.paths {
+		s.FailingPaths = append(s.FailingPaths, FailingPathCount{Version: k.v, Path: k.path, Count: n})
+	}
+	sort.Slice(s.FailingPaths, func(i, j int) bool {
+		pi, pj := s.FailingPaths[i], s.FailingPaths[j]
+		if pi.Count != pj.Count {
+			return pi.Count > pj.Count
+		}
+		if pi.Version != pj.Version {
+			return pi.Version.Less(pj.Version)
+		}
+		return pi.Path < pj.Path
+	})
+	if len(s.FailingPaths) > reporterTopPaths {
+		s.FailingPaths = s.FailingPaths[:reporterTopPaths]
+	}
+
+	for t, n := range r.lacunas {
+		s.Lacunas = append(s.Lacunas, LacunaCount{Type: t, Count: n})
+	}
+	sort.Slice(s.Lacunas, func(i, j int) bool {
+		return s.Lacunas[i].Type < s.Lacunas[j].Type
+	})
+	return s
+}
+
+// WritePrometheus writes the snapshot to w as counters in the Prometheus text
+// exposition format, labeled by lineage name. Only the top failing paths are
+// written, so the failing path counter is intended for display, rather than
+// for computing rates.
+func (s ReportSnapshot) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	lin := "lineage=" + strconv.Quote(s.Lineage)
+	header := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+
+	header("thema_validations_total", "Validations observed, by schema version and outcome.")
+	for _, vo := range s.Versions {
+		fmt.Fprintf(&b, "thema_validations_total{%s,version=%q,outcome=\"valid\"} %d\n", lin, vo.Version.String(), vo.Valid)
+		fmt.Fprintf(&b, "thema_validations_total{%s,version=%q,outcome=\"invalid\"} %d\n", lin, vo.Version.String(), vo.Invalid)
+	}
+	header("thema_validations_unmatched_total", "Validations for which no schema matched.")
+	fmt.Fprintf(&b, "thema_validations_unmatched_total{%s} %d\n", lin, s.Unmatched)
+
+	if len(s.FailingPaths) > 0 {
+		header("thema_validation_failures_by_path_total", "Validation failures at the most commonly failing paths.")
+		for _, fp := range s.FailingPaths {
+			fmt.Fprintf(&b, "thema_validation_failures_by_path_total{%s,version=%q,path=%q} %d\n", lin, fp.Version.String(), fp.Path, fp.Count)
+		}
+	}
+
+	header("thema_translations_total", "Translations observed, by outcome.")
+	fmt.Fprintf(&b, "thema_translations_total{%s,outcome=\"ok\"} %d\n", lin, s.Translations-s.TranslationErrors)
+	fmt.Fprintf(&b, "thema_translations_total{%s,outcome=\"error\"} %d\n", lin, s.TranslationErrors)
+
+	if len(s.Lacunas) > 0 {
+		header("thema_lacunas_total", "Lacunas emitted by translations, by type.")
+		for _, lc := range s.Lacunas {
+			fmt.Fprintf(&b, "thema_lacunas_total{%s,type=\"%d\"} %d\n", lin, lc.Type, lc.Count)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,55 @@
+package thema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter(t *testing.T) {
+	lin := testLin(lenientlinstr)
+	ctx := lin.Runtime().Context()
+	sch := lin.First()
+	r := NewReporter(lin)
+
+	for _, s := range []string{
+		`{name: "ok", count: 3, nested: {inner: 1}}`,
+		`{name: "ok", count: 12, nested: {inner: 1}}`,
+		`{name: "ok", count: 11, nested: {inner: 1}}`,
+		`{name: "UPPER", count: 3, nested: {inner: 1}}`,
+	} {
+		_, err := sch.Validate(ctx.CompileString(s))
+		r.ObserveValidation(sch, err)
+	}
+	r.ObserveValidation(nil, errors.New("no match"))
+	r.ObserveTranslation(Lacunas{{Type: 2}, {Type: 1}, {Type: 2}}, nil)
+	r.ObserveTranslation(nil, errors.New("boom"))
+
+	s := r.Snapshot()
+	assert.Equal(t, "lenient", s.Lineage)
+	assert.Equal(t, []VersionOutcomes{{Version: SV(0, 0), Valid: 1, Invalid: 3}}, s.Versions)
+	assert.Equal(t, uint64(1), s.Unmatched)
+	assert.Equal(t, []FailingPathCount{
+		{Version: SV(0, 0), Path: "count", Count: 2},
+		{Version: SV(0, 0), Path: "name", Count: 1},
+	}, s.FailingPaths)
+	assert.Equal(t, uint64(2), s.Translations)
+	assert.Equal(t, uint64(1), s.TranslationErrors)
+	assert.Equal(t, []LacunaCount{{Type: 1, Count: 1}, {Type: 2, Count: 2}}, s.Lacunas)
+
+	_, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WritePrometheus(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE thema_validations_total counter\n")
+	assert.Contains(t, out, `thema_validations_total{lineage="lenient",version="0.0",outcome="invalid"} 3`)
+	assert.Contains(t, out, `thema_validation_failures_by_path_total{lineage="lenient",version="0.0",path="count"} 2`)
+	assert.Contains(t, out, `thema_translations_total{lineage="lenient",outcome="error"} 1`)
+	assert.Contains(t, out, `thema_lacunas_total{lineage="lenient",type="2"} 2`)
+}
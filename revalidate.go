@@ -0,0 +1,91 @@
+package thema
+
+import (
+	"cuelang.org/go/cue"
+)
+
+// Revalidate validates data that differs from the data in a previously
+// validated [Instance] only at the provided changed paths. Rather than
+// re-evaluating the entire document against the schema, only the struct
+// enclosing each changed path is validated against the corresponding part of
+// the schema. This is intended for interactive editors, where documents are
+// large but each edit is small.
+//
+// Validating the enclosing struct, rather than just the changed value, ensures
+// that additions and removals of fields are checked against the closedness and
+// required fields of that struct. Constraints in the schema that relate
+// values in different structs, however, are not checked. Where the schema
+// contains such constraints, or on any doubt as to the completeness of the
+// changed paths, prefer [Schema.Validate].
+//
+// If a changed path is the root of the document, or cannot be mapped onto the
+// schema, Revalidate falls back to validating the entire document. If no
+// changed paths are provided, the data is assumed to be unchanged.
+func Revalidate(prev *Instance, data cue.Value, changed ...cue.Path) (*Instance, error) {
+	prev.check()
+	sch, is := prev.sch.(*schemaDef)
+	if !is {
+		return prev.sch.Validate(data)
+	}
+
+	return guard("revalidation", func() (*Instance, error) {
+		if err := sch.lin.budget.check(data); err != nil {
+			return nil, err
+		}
+
+		sch.rt().rl()
+		defer sch.rt().ru()
+
+		seen := make(map[string]bool)
+		for _, p := range changed {
+			sels := p.Selectors()
+			if len(sels) == 0 {
+				return sch.validate(data, evalDeadline{})
+			}
+			parent := cue.MakePath(sels[:len(sels)-1]...)
+			if seen[parent.String()] {
+				continue
+			}
+			seen[parent.String()] = true
+
+			schv, ok := schemaAtPath(sch.def, parent)
+			dv := data.LookupPath(parent)
+			if !ok || !dv.Exists() {
+				return sch.validate(data, evalDeadline{})
+			}
+			if err := schv.Unify(dv).Validate(cue.Concrete(true)); err != nil {
+				return nil, mungeValidateErr(err, sch)
+			}
+		}
+
+		return &Instance{
+			valid: true,
+			raw:   data,
+			name:  prev.name,
+			sch:   sch,
+		}, nil
+	})
+}
+
+// schemaAtPath returns the part of the schema that constrains the data at the
+// provided path.
+func schemaAtPath(v cue.Value, p cue.Path) (cue.Value, bool) {
+	for _, sel := range p.Selectors() {
+		switch sel.Type() {
+		case cue.IndexLabel:
+			v = v.LookupPath(cue.MakePath(cue.AnyIndex))
+		case cue.StringLabel:
+			if fv := v.LookupPath(cue.MakePath(sel)); fv.Exists() {
+				v = fv
+			} else {
+				v = v.LookupPath(cue.MakePath(cue.Str(sel.Unquoted()).Optional()))
+			}
+		default:
+			return v, false
+		}
+		if !v.Exists() {
+			return v, false
+		}
+	}
+	return v, true
+}
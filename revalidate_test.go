@@ -0,0 +1,78 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevalidate(t *testing.T) {
+	lin := testLin(`name: "reval"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		panels: [...{
+			title: string
+			span:  int & >0 & <=24
+			desc?: string
+		}]
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+	prev, err := lin.First().Validate(ctx.CompileString(`{
+	title: "dash"
+	panels: [{title: "a", span: 12}, {title: "b", span: 12}]
+}`))
+	require.NoError(t, err)
+
+	table := map[string]struct {
+		data    string
+		changed string
+		ok      bool
+	}{
+		"valid change": {
+			data:    `{title: "dash", panels: [{title: "a", span: 12}, {title: "b", span: 6}]}`,
+			changed: "panels[1].span",
+			ok:      true,
+		},
+		"add optional": {
+			data:    `{title: "dash", panels: [{title: "a", span: 12, desc: "x"}, {title: "b", span: 12}]}`,
+			changed: "panels[0].desc",
+			ok:      true,
+		},
+		"out of bounds": {
+			data:    `{title: "dash", panels: [{title: "a", span: 12}, {title: "b", span: 30}]}`,
+			changed: "panels[1].span",
+		},
+		"removed required": {
+			data:    `{title: "dash", panels: [{title: "a", span: 12}, {span: 12}]}`,
+			changed: "panels[1].title",
+		},
+		"excess field": {
+			data:    `{title: "dash", panels: [{title: "a", span: 12}, {title: "b", span: 12, nope: 1}]}`,
+			changed: "panels[1].nope",
+		},
+		"root": {
+			data:    `{title: 1, panels: []}`,
+			changed: "title",
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			inst, err := Revalidate(prev, ctx.CompileString(tt.data), cue.ParsePath(tt.changed))
+			if !tt.ok {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_, err = lin.First().Validate(inst.Underlying())
+			assert.NoError(t, err, "full validation should agree with revalidation")
+		})
+	}
+}
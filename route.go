@@ -0,0 +1,266 @@
+package thema
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// ShortcutLenses takes a slice of [ImperativeLens] that translate directly
+// between non-adjacent schemas in the lineage, such as from 0.0 to 3.0. Each
+// offers an alternative to the route through every intermediate schema, which
+// [Instance.Translate] takes where it is cheaper, as reported by
+// [PlanTranslation].
+//
+// Shortcuts may be provided for lineages whose lenses are written in either
+// CUE or Go. [BindLineage] fails with an error wrapping
+// [terrors.ErrErroneousLenses] if a shortcut connects adjacent schemas, which
+// must be done with the lineage's own lenses, or if any shortcut is duplicated
+// or refers to a schema that does not exist.
+//
+// As with [ImperativeLenses], the correctness of shortcut lenses cannot be
+// verified in advance, and it is the responsibility of the author that a
+// shortcut's result is equivalent to that of the route it bypasses.
+func ShortcutLenses(lenses ...ImperativeLens) BindOption {
+	return func(c *bindConfig) {
+		c.shortcuts = append(c.shortcuts, lenses...)
+	}
+}
+
+// A TranslationStep is a single hop in the route taken to translate an
+// [Instance] from one schema to another.
+type TranslationStep struct {
+	From SyntacticVersion `json:"from"`
+	To   SyntacticVersion `json:"to"`
+
+	// Shortcut reports whether the step is made by a lens provided to
+	// [ShortcutLenses], rather than by the lens between adjacent schemas.
+	Shortcut bool `json:"shortcut,omitempty"`
+
+	// Cost is the cost of the step. Steps between adjacent schemas cost 1.
+	Cost int `json:"cost"`
+}
+
+// A TranslationTrace describes the route taken to translate an [Instance]
+// from one schema to another.
+type TranslationTrace struct {
+	From SyntacticVersion `json:"from"`
+	To   SyntacticVersion `json:"to"`
+
+	// Route is the sequence of steps taken, empty if From and To are equal.
+	Route []TranslationStep `json:"route"`
+
+	// Cost is the total cost of the steps in Route.
+	Cost int `json:"cost"`
+}
+
+// PlanTranslation returns the route by which [Instance.Translate] translates
+// instances of the schema with version from to the schema with version to.
+//
+// The route is that with the least total cost, where each step between
+// adjacent schemas costs 1, and each shortcut provided to [ShortcutLenses]
+// costs its [ImperativeLens.Cost]. Ties are broken deterministically, first by
+// preferring fewer steps, then by preferring the route whose sequence of
+// versions is least when compared version by version.
+func PlanTranslation(lin Lineage, from, to SyntacticVersion) (*TranslationTrace, error) {
+	isValidLineage(lin)
+	for _, v := range []SyntacticVersion{from, to} {
+		if !synvExists(lin.allVersions(), v) {
+			return nil, errors.Mark(errors.Newf("lineage %q has no schema with version %s", lin.Name(), v), terrors.ErrVersionNotExist)
+		}
+	}
+	var shortcuts map[lensID]ImperativeLens
+	if blin, is := lin.(*baseLineage); is {
+		shortcuts = blin.shortcuts
+	}
+	return planRoute(lin.allVersions(), shortcuts, from, to), nil
+}
+
+// A route is a candidate path through the versions of a lineage.
+type route struct {
+	cost  int
+	steps []TranslationStep
+}
+
+// less reports whether r is preferred to o.
+func (r route) less(o route) bool {
+	if r.cost != o.cost {
+		return r.cost < o.cost
+	}
+	if len(r.steps) != len(o.steps) {
+		return len(r.steps) < len(o.steps)
+	}
+	for i := range r.steps {
+		if r.steps[i].To != o.steps[i].To {
+			return r.steps[i].To.Less(o.steps[i].To)
+		}
+	}
+	return false
+}
+
+// planRoute finds the preferred route between two versions by Dijkstra's
+// algorithm. Lineages are small, so the unvisited version with the preferred
+// route is found by linear search.
+func planRoute(allv []SyntacticVersion, shortcuts map[lensID]ImperativeLens, from, to SyntacticVersion) *TranslationTrace {
+	edges := make(map[SyntacticVersion][]TranslationStep, len(allv))
+	for i := 1; i < len(allv); i++ {
+		edges[allv[i-1]] = append(edges[allv[i-1]], TranslationStep{From: allv[i-1], To: allv[i], Cost: 1})
+		edges[allv[i]] = append(edges[allv[i]], TranslationStep{From: allv[i], To: allv[i-1], Cost: 1})
+	}
+	for id, lens := range shortcuts {
+		edges[id.From] = append(edges[id.From], TranslationStep{From: id.From, To: id.To, Shortcut: true, Cost: lens.cost()})
+	}
+
+	best := map[SyntacticVersion]route{from: {}}
+	visited := make(map[SyntacticVersion]bool, len(allv))
+	for {
+		var cur SyntacticVersion
+		var r route
+		found := false
+		for _, v := range allv {
+			if cand, has := best[v]; has && !visited[v] && (!found || cand.less(r)) {
+				cur, r, found = v, cand, true
+			}
+		}
+		if !found || cur == to {
+			break
+		}
+		visited[cur] = true
+
+		for _, step := range edges[cur] {
+			next := route{cost: r.cost + step.Cost, steps: append(r.steps[:len(r.steps):len(r.steps)], step)}
+			if prev, has := best[step.To]; !has || next.less(prev) {
+				best[step.To] = next
+			}
+		}
+	}
+
+	r := best[to]
+	return &TranslationTrace{From: from, To: to, Route: r.steps, Cost: r.cost}
+}
+
+// cost returns the cost of the lens when used as a shortcut.
+func (lens ImperativeLens) cost() int {
+	if lens.Cost <= 0 {
+		return 1
+	}
+	return lens.Cost
+}
+
+// TranslateTraced is as [Instance.Translate], but additionally returns a trace
+// of the route taken by the translation.
+func (i *Instance) TranslateTraced(to SyntacticVersion) (*Instance, TranslationLacunas, *TranslationTrace, error) {
+	i.check()
+
+	trace, err := PlanTranslation(i.Schema().Lineage(), i.Schema().Version(), to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	inst, lac, err := i.Translate(to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return inst, lac, trace, nil
+}
+
+// translateRoute translates the instance by the route planned for the
+// lineage's shortcut lenses, one step at a time.
+func (i *Instance) translateRoute(to SyntacticVersion, dl evalDeadline) (*Instance, TranslationLacunas, error) {
+	lin := i.Schema().Lineage().(*baseLineage)
+	var steps []TranslationStep
+	if pc := lin.precomposed.get(lid(i.Schema().Version(), to)); pc != nil {
+		steps = pc.route
+	} else {
+		steps = planRoute(lin.allv, lin.shortcuts, i.Schema().Version(), to).Route
+	}
+
+	ti := i
+	lac := make(multiTranslationLacunas, 0)
+	for _, step := range steps {
+		if err := dl.check(); err != nil {
+			return nil, nil, err
+		}
+		nsch, err := lin.Schema(step.To)
+		if err != nil {
+			panic(fmt.Sprintf("unreachable - planned route through nonexistent schema %s", step.To))
+		}
+
+		var next *Instance
+		var slac TranslationLacunas
+		switch {
+		case step.Shortcut:
+			next, err = runImperativeLens(lin.shortcuts[lid(step.From, step.To)], ti, nsch)
+		case len(lin.lensmap) > 0:
+			next, slac, err = ti.translateGo(step.To, dl)
+		default:
+			next, slac, err = ti.translateCUE(step.To, dl)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if mlac, is := slac.(multiTranslationLacunas); is {
+			lac = append(lac, mlac...)
+		}
+		ti = next
+	}
+	if ti == i {
+		ti = new(Instance)
+		*ti = *i
+	}
+	return ti, lac, nil
+}
+
+// runImperativeLens executes the Go lens, checking that it returns an instance
+// of the schema it claims to.
+func runImperativeLens(lens ImperativeLens, inst *Instance, to Schema) (*Instance, error) {
+	mlid := lid(lens.From, lens.To)
+	rti, err := lens.Mapper(inst, to)
+	if err != nil {
+		return nil, fmt.Errorf("error executing %s migration: %w", mlid, err)
+	}
+	// Ensure that
+	//  - the returned instance exists
+	//  - the caller returned an instance of the expected schema version
+	if rti == nil {
+		return nil, fmt.Errorf("lens returned a nil instance")
+	}
+	if rti.Schema().Version() != to.Version() {
+		return nil, fmt.Errorf("lens returned an instance of the wrong schema version: expected %v, got %v", to.Version(), rti.Schema().Version())
+	}
+	return rti, nil
+}
+
+func (ml *maybeLineage) checkShortcutLenses() error {
+	if len(ml.cfg.shortcuts) == 0 {
+		return nil
+	}
+
+	b := new(bytes.Buffer)
+	ml.shortcuts = make(map[lensID]ImperativeLens, len(ml.cfg.shortcuts))
+	for _, lens := range ml.cfg.shortcuts {
+		id := lid(lens.From, lens.To)
+		fi, ti := searchSynv(ml.allv, lens.From), searchSynv(ml.allv, lens.To)
+		switch {
+		case lens.Mapper == nil:
+			fmt.Fprintf(b, "\t%s (nil Go migration func)\n", id)
+		case !synvExists(ml.allv, lens.From):
+			fmt.Fprintf(b, "\t%s (schema version %s does not exist)\n", id, lens.From)
+		case !synvExists(ml.allv, lens.To):
+			fmt.Fprintf(b, "\t%s (schema version %s does not exist)\n", id, lens.To)
+		case fi-ti <= 1 && ti-fi <= 1:
+			fmt.Fprintf(b, "\t%s (shortcuts must connect non-adjacent schemas)\n", id)
+		default:
+			if _, has := ml.shortcuts[id]; has {
+				fmt.Fprintf(b, "\t%s (duplicate shortcut)\n", id)
+			}
+			ml.shortcuts[id] = lens
+		}
+	}
+	if b.Len() > 0 {
+		return errors.Mark(errors.New("invalid shortcut lenses provided:\n"+b.String()), terrors.ErrErroneousLenses)
+	}
+	return nil
+}
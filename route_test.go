@@ -0,0 +1,151 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+const routelin = `name: "route"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		via?:  string
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		name: string
+		via?: string
+	}
+}, {
+	version: [2, 0]
+	schema: {
+		label: string
+		via?:  string
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: title: input.title
+}, {
+	to: [0, 1]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: name: input.title
+}, {
+	to: [1, 0]
+	from: [2, 0]
+	input: _
+	result: name: input.label
+}, {
+	to: [2, 0]
+	from: [1, 0]
+	input: _
+	result: label: input.name
+}]
+`
+
+func TestTranslationRoutes(t *testing.T) {
+	shortcut := func(cost int) ImperativeLens {
+		return ImperativeLens{
+			From: SV(0, 0),
+			To:   SV(2, 0),
+			Cost: cost,
+			Mapper: func(inst *Instance, to Schema) (*Instance, error) {
+				title, _ := inst.Underlying().LookupPath(cue.ParsePath("title")).String()
+				return to.Validate(to.Underlying().Context().Encode(map[string]string{"label": title, "via": "shortcut"}))
+			},
+		}
+	}
+	bind := func(opts ...BindOption) Lineage {
+		t.Helper()
+		rt := NewRuntime(cuecontext.New())
+		lin, err := BindLineage(rt.Context().CompileString(routelin), rt, opts...)
+		require.NoError(t, err)
+		return lin
+	}
+	route := func(tr *TranslationTrace) []SyntacticVersion {
+		var vs []SyntacticVersion
+		for _, step := range tr.Route {
+			vs = append(vs, step.To)
+		}
+		return vs
+	}
+
+	t.Run("adjacent", func(t *testing.T) {
+		lin := bind()
+		tr, err := PlanTranslation(lin, SV(0, 0), SV(2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, []SyntacticVersion{SV(0, 1), SV(1, 0), SV(2, 0)}, route(tr))
+		assert.Equal(t, 3, tr.Cost)
+
+		tr, err = PlanTranslation(lin, SV(1, 0), SV(1, 0))
+		require.NoError(t, err)
+		assert.Empty(t, tr.Route)
+
+		_, err = PlanTranslation(lin, SV(0, 0), SV(3, 0))
+		assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+	})
+
+	t.Run("shortcut", func(t *testing.T) {
+		// A shortcut is preferred at equal cost, as it takes fewer steps
+		lin := bind(ShortcutLenses(shortcut(3)))
+		inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(`{title: "foo"}`))
+		require.NoError(t, err)
+
+		out, _, tr, err := inst.TranslateTraced(SV(2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, []TranslationStep{{From: SV(0, 0), To: SV(2, 0), Shortcut: true, Cost: 3}}, tr.Route)
+		via, _ := out.Underlying().LookupPath(cue.ParsePath("via")).String()
+		assert.Equal(t, "shortcut", via)
+
+		// Routes not improved by the shortcut are unchanged
+		out, _, tr, err = inst.TranslateTraced(SV(1, 0))
+		require.NoError(t, err)
+		assert.Equal(t, []SyntacticVersion{SV(0, 1), SV(1, 0)}, route(tr))
+		name, _ := out.Underlying().LookupPath(cue.ParsePath("name")).String()
+		assert.Equal(t, "foo", name)
+	})
+
+	t.Run("costly shortcut", func(t *testing.T) {
+		lin := bind(ShortcutLenses(shortcut(4)))
+		inst, err := lin.First().Validate(lin.Runtime().Context().CompileString(`{title: "foo"}`))
+		require.NoError(t, err)
+
+		out, _, tr, err := inst.TranslateTraced(SV(2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, []SyntacticVersion{SV(0, 1), SV(1, 0), SV(2, 0)}, route(tr))
+		assert.False(t, out.Underlying().LookupPath(cue.ParsePath("via")).Exists())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rt := NewRuntime(cuecontext.New())
+		adjacent := shortcut(1)
+		adjacent.To = SV(0, 1)
+		_, err := BindLineage(rt.Context().CompileString(routelin), rt, ShortcutLenses(adjacent))
+		assert.True(t, errors.Is(err, terrors.ErrErroneousLenses), err)
+
+		missing := shortcut(1)
+		missing.To = SV(3, 0)
+		_, err = BindLineage(rt.Context().CompileString(routelin), rt, ShortcutLenses(missing))
+		assert.True(t, errors.Is(err, terrors.ErrErroneousLenses), err)
+	})
+}
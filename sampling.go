@@ -0,0 +1,133 @@
+package thema
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync/atomic"
+
+	"cuelang.org/go/cue"
+)
+
+// A SamplingValidator validates a configurable fraction of the data passed to
+// it against a schema, for ingestion paths too busy to pay the cost of CUE
+// evaluation on every message that still want a signal of how well their data
+// conforms to the schema.
+//
+// Every failure of a sampled validation is recorded: it is counted in
+// [SamplingValidator.Stats], and passed to the [Reporter] and callback
+// provided by [ReportSamples] and [OnSampledFailure].
+//
+// A SamplingValidator is safe for concurrent use.
+type SamplingValidator struct {
+	sch       Schema
+	rate      float64
+	hash      bool
+	reporter  *Reporter
+	onFailure func(data cue.Value, err error)
+
+	seen, sampled, failed atomic.Uint64
+}
+
+// A SamplingOption configures a [SamplingValidator].
+type SamplingOption func(*SamplingValidator)
+
+// HashSampling makes sampling deterministic, deciding whether to validate data
+// by a hash of its content rather than at random. Identical data is then
+// always either validated or not, which keeps samples reproducible, though
+// the fraction validated only approximates the rate if data is often repeated.
+func HashSampling() SamplingOption {
+	return func(sv *SamplingValidator) {
+		sv.hash = true
+	}
+}
+
+// ReportSamples records the outcome of every sampled validation with r.
+func ReportSamples(r *Reporter) SamplingOption {
+	return func(sv *SamplingValidator) {
+		sv.reporter = r
+	}
+}
+
+// OnSampledFailure calls fn with the data and error of every sampled
+// validation that fails. fn may be called concurrently.
+func OnSampledFailure(fn func(data cue.Value, err error)) SamplingOption {
+	return func(sv *SamplingValidator) {
+		sv.onFailure = fn
+	}
+}
+
+// NewSamplingValidator returns a SamplingValidator that validates the given
+// fraction of data against sch. Rates are clamped to between 0, validating
+// nothing, and 1, validating everything.
+func NewSamplingValidator(sch Schema, rate float64, opts ...SamplingOption) *SamplingValidator {
+	sv := &SamplingValidator{
+		sch:  sch,
+		rate: math.Max(0, math.Min(1, rate)),
+	}
+	for _, opt := range opts {
+		opt(sv)
+	}
+	return sv
+}
+
+// Validate validates data against the schema if it is sampled, reporting
+// whether it was, and the error from [Schema.Validate] if so.
+func (sv *SamplingValidator) Validate(data cue.Value) (bool, error) {
+	sv.seen.Add(1)
+	if !sv.sample(data) {
+		return false, nil
+	}
+	sv.sampled.Add(1)
+
+	_, err := sv.sch.Validate(data)
+	if sv.reporter != nil {
+		sv.reporter.ObserveValidation(sv.sch, err)
+	}
+	if err != nil {
+		sv.failed.Add(1)
+		if sv.onFailure != nil {
+			sv.onFailure(data, err)
+		}
+	}
+	return true, err
+}
+
+func (sv *SamplingValidator) sample(data cue.Value) bool {
+	switch {
+	case sv.rate >= 1:
+		return true
+	case sv.rate <= 0:
+		return false
+	case !sv.hash:
+		return rand.Float64() < sv.rate // nolint: gosec
+	}
+
+	b, err := data.MarshalJSON()
+	if err != nil {
+		// Data that cannot be hashed is not concrete, and certainly invalid
+		return true
+	}
+	h := fnv.New64a()
+	h.Write(b) // nolint: errcheck
+	return float64(h.Sum64()) < sv.rate*math.MaxUint64
+}
+
+// SamplingStats reports the activity of a [SamplingValidator].
+type SamplingStats struct {
+	// Seen is the number of calls to Validate.
+	Seen uint64 `json:"seen"`
+	// Sampled is the number of those calls that performed validation.
+	Sampled uint64 `json:"sampled"`
+	// Failed is the number of sampled validations that failed.
+	Failed uint64 `json:"failed"`
+}
+
+// Stats returns the activity of the SamplingValidator so far.
+func (sv *SamplingValidator) Stats() SamplingStats {
+	return SamplingStats{
+		Seen:    sv.seen.Load(),
+		Sampled: sv.sampled.Load(),
+		Failed:  sv.failed.Load(),
+	}
+}
@@ -0,0 +1,71 @@
+package thema
+
+import (
+	"fmt"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingValidator(t *testing.T) {
+	lin := testLin(lenientlinstr)
+	ctx := lin.Runtime().Context()
+	sch := lin.First()
+
+	data := make([]cue.Value, 200)
+	for i := range data {
+		data[i] = ctx.CompileString(fmt.Sprintf(`{name: "ok", count: %d, nested: {inner: 1}}`, i%20))
+	}
+
+	t.Run("rates", func(t *testing.T) {
+		sv := NewSamplingValidator(sch, 0)
+		for _, d := range data[:10] {
+			sampled, err := sv.Validate(d)
+			assert.False(t, sampled)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, SamplingStats{Seen: 10}, sv.Stats())
+
+		sv = NewSamplingValidator(sch, 2)
+		for _, d := range data[5:15] {
+			sampled, _ := sv.Validate(d)
+			assert.True(t, sampled)
+		}
+		assert.Equal(t, SamplingStats{Seen: 10, Sampled: 10, Failed: 5}, sv.Stats())
+	})
+
+	t.Run("failures recorded", func(t *testing.T) {
+		r := NewReporter(lin)
+		var failures int
+		sv := NewSamplingValidator(sch, 0.5, ReportSamples(r), OnSampledFailure(func(data cue.Value, err error) {
+			require.Error(t, err)
+			failures++
+		}))
+		for _, d := range data {
+			sv.Validate(d) // nolint: errcheck
+		}
+		stats := sv.Stats()
+		assert.Equal(t, uint64(200), stats.Seen)
+		assert.Greater(t, stats.Sampled, uint64(0))
+		assert.Less(t, stats.Sampled, uint64(200))
+		assert.Equal(t, stats.Failed, uint64(failures))
+
+		snap := r.Snapshot()
+		assert.Equal(t, stats.Sampled, snap.Versions[0].Valid+snap.Versions[0].Invalid)
+		assert.Equal(t, stats.Failed, snap.Versions[0].Invalid)
+	})
+
+	t.Run("hash sampling is deterministic", func(t *testing.T) {
+		sv := NewSamplingValidator(sch, 0.5, HashSampling())
+		for _, d := range data[:20] {
+			first, _ := sv.Validate(d)
+			second, _ := sv.Validate(d)
+			assert.Equal(t, first, second)
+		}
+		stats := sv.Stats()
+		assert.Greater(t, stats.Sampled, uint64(0))
+		assert.Less(t, stats.Sampled, uint64(40))
+	})
+}
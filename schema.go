@@ -2,10 +2,14 @@ package thema
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/errors"
+
+	terrors "github.com/grafana/thema/errors"
 )
 
 var (
@@ -18,6 +22,8 @@ var (
 	pathExamples = cue.MakePath(cue.Str("examples"))
 	pathSch      = cue.MakePath(cue.Str("schema"))
 	pathJoin     = cue.MakePath(cue.Hid("_join", "github.com/grafana/thema"))
+	pathMaturity = cue.MakePath(cue.Str("maturity"))
+	pathChanges  = cue.MakePath(cue.Str("changes"))
 )
 
 // schemaDef represents a single #SchemaDef, with a backlink to its containing
@@ -32,9 +38,36 @@ type schemaDef struct {
 	// v is the version of this schema.
 	v SyntacticVersion
 
+	// maturity is the declared maturity of this schema.
+	maturity Maturity
+
+	// notes are the change notes declared for this schema.
+	notes []ChangeNote
+
+	// keyed reports whether any list in the schema declares an identity key.
+	// It is computed on first use, guarded by keyedOnce.
+	keyed     bool
+	keyedOnce sync.Once
+
+	// validators are the Validators named by @validate attributes in the
+	// schema.
+	validators []fieldValidator
+
 	lin *baseLineage
 }
 
+// Maturity returns the declared maturity of the schema, which is
+// [MaturityStable] unless otherwise specified.
+func (sch *schemaDef) Maturity() Maturity {
+	return sch.maturity
+}
+
+// ReleaseNotes returns the change notes declared for the schema, in the order
+// declared. It is empty if none were declared.
+func (sch *schemaDef) ReleaseNotes() []ChangeNote {
+	return sch.notes
+}
+
 // Examples returns the set of examples of this schema defined in the original
 // lineage. The string key is the name given to the example.
 func (sch *schemaDef) Examples() map[string]*Instance {
@@ -72,8 +105,40 @@ func (sch *schemaDef) rt() *Runtime {
 // incomplete CUE values with Thema schemas, prefer working directly in CUE,
 // or if you must, rely on Underlying().
 func (sch *schemaDef) Validate(data cue.Value) (*Instance, error) {
+	dl := sch.lin.deadline("validation")
+	return guard("validation", func() (*Instance, error) {
+		if err := sch.lin.budget.check(data); err != nil {
+			return nil, err
+		}
+		return sch.validateCached(data, dl)
+	})
+}
+
+func (sch *schemaDef) validateCached(data cue.Value, dl evalDeadline) (*Instance, error) {
 	sch.rt().rl()
 	defer sch.rt().ru()
+
+	if vc := sch.lin.vcache; vc != nil {
+		if k, ok := vc.key(sch.v, data); ok {
+			if ent, has := vc.get(k); has {
+				if ent.err != nil {
+					return nil, ent.err
+				}
+				return &Instance{valid: true, raw: data, sch: sch}, nil
+			}
+			inst, err := sch.validate(data, dl)
+			if !errors.Is(err, terrors.ErrEvalTimeout) {
+				vc.put(k, err)
+			}
+			return inst, err
+		}
+	}
+	return sch.validate(data, dl)
+}
+
+// validate checks data against the schema, abandoning the check before
+// running any validators if the deadline has passed.
+func (sch *schemaDef) validate(data cue.Value, dl evalDeadline) (*Instance, error) {
 	// TODO which approach is actually the right one, unify or subsume? ugh
 	// err := sch.raw.Subsume(data, cue.All(), cue.Raw())
 	// if err != nil {
@@ -90,6 +155,14 @@ func (sch *schemaDef) Validate(data cue.Value) (*Instance, error) {
 	if err := x.Validate(cue.Concrete(true)); err != nil {
 		return nil, mungeValidateErr(err, sch)
 	}
+	if len(sch.validators) > 0 {
+		if err := dl.check(); err != nil {
+			return nil, err
+		}
+	}
+	if errs := sch.runValidators(data); len(errs) > 0 {
+		return nil, errs
+	}
 
 	return &Instance{
 		valid: true,
@@ -99,6 +172,92 @@ func (sch *schemaDef) Validate(data cue.Value) (*Instance, error) {
 	}, nil
 }
 
+// ValidateLenient performs validation identically to Validate, except that
+// failures of constraints marked with @thema(advisory) are returned as warnings.
+func (sch *schemaDef) ValidateLenient(data cue.Value) (*Instance, []ValidationIssue, error) {
+	type result struct {
+		inst     *Instance
+		warnings []ValidationIssue
+	}
+	r, err := guard("validation", func() (result, error) {
+		if err := sch.lin.budget.check(data); err != nil {
+			return result{}, err
+		}
+		inst, warnings, err := sch.validateLenient(data)
+		return result{inst: inst, warnings: warnings}, err
+	})
+	return r.inst, r.warnings, err
+}
+
+func (sch *schemaDef) validateLenient(data cue.Value) (*Instance, []ValidationIssue, error) {
+	sch.rt().rl()
+	defer sch.rt().ru()
+
+	x := sch.def.Unify(data)
+	var warnings []ValidationIssue
+	if err := x.Validate(cue.Concrete(true)); err != nil {
+		var hard, soft errors.Error
+		for _, ee := range errors.Errors(err) {
+			if sch.isAdvisory(trimThemaPath(ee.Path())) {
+				soft = errors.Append(soft, ee)
+			} else {
+				hard = errors.Append(hard, ee)
+			}
+		}
+		if hard != nil {
+			return nil, nil, mungeValidateErr(hard, sch)
+		}
+
+		if vf, is := mungeValidateErr(soft, sch).(validationFailure); is {
+			for _, e := range vf {
+				warnings = append(warnings, toValidationIssue(e))
+			}
+		}
+	}
+
+	var hard validationFailure
+	for _, e := range sch.runValidators(data) {
+		if ve, is := e.(*validatorerr); is && sch.isAdvisory(ve.coords.fieldpath) {
+			warnings = append(warnings, toValidationIssue(e))
+		} else {
+			hard = append(hard, e)
+		}
+	}
+	if len(hard) > 0 {
+		return nil, nil, hard
+	}
+	sortIssues(warnings)
+
+	return &Instance{
+		valid: true,
+		raw:   data,
+		sch:   sch,
+	}, warnings, nil
+}
+
+// isAdvisory reports whether the field at the provided path, or any of its
+// parents, is marked with the @thema(advisory) attribute in the schema.
+func (sch *schemaDef) isAdvisory(fieldpath []string) bool {
+	v := sch.ref.LookupPath(pathSch)
+	for _, part := range fieldpath {
+		if _, err := strconv.Atoi(part); err == nil {
+			v = v.LookupPath(cue.MakePath(cue.AnyIndex))
+		} else if fv := v.LookupPath(cue.MakePath(cue.Str(part))); fv.Exists() {
+			v = fv
+		} else {
+			v = v.LookupPath(cue.MakePath(cue.Str(part).Optional()))
+		}
+		if !v.Exists() {
+			return false
+		}
+		attr := v.Attribute("thema")
+		if has, _ := attr.Flag(0, "advisory"); has {
+			return true
+		}
+	}
+	return false
+}
+
 // Successor returns the next schema in the lineage, or nil if it is the last schema.
 func (sch *schemaDef) Successor() Schema {
 	if s := sch.successor(); s != nil {
@@ -137,7 +296,14 @@ func (sch *schemaDef) predecessor() *schemaDef {
 // within this Schema's major version. If the receiver Schema is the latest, it
 // will return itself.
 func (sch *schemaDef) LatestInMajor() Schema {
-	return sch.lin.allsch[searchSynv(sch.lin.allv, SyntacticVersion{sch.v[0] + 1, 0})]
+	// The schema preceding the first of the next major version
+	return sch.lin.allsch[searchSynv(sch.lin.allv, SyntacticVersion{sch.v[0] + 1, 0})-1]
+}
+
+// LatestVersionInMajor returns the version of the schema returned by
+// [Schema.LatestInMajor].
+func (sch *schemaDef) LatestVersionInMajor() SyntacticVersion {
+	return sch.LatestInMajor().Version()
 }
 
 // Underlying returns the cue.Value that represents the underlying CUE #SchemaDef.
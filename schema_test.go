@@ -192,3 +192,23 @@ func mkNew[T any](t T) func() T {
 
 	}
 }
+
+func TestLatestVersions(t *testing.T) {
+	lin := testLin(searchlinstr)
+	assert.Equal(t, SV(1, 0), lin.LatestVersion())
+	assert.Equal(t, lin.Latest().Version(), lin.LatestVersion())
+
+	for v, want := range map[SyntacticVersion]SyntacticVersion{
+		SV(0, 0): SV(0, 1),
+		SV(0, 1): SV(0, 1),
+		SV(1, 0): SV(1, 0),
+	} {
+		sch := SchemaP(lin, v)
+		assert.Equal(t, want, sch.LatestInMajor().Version(), v.String())
+		assert.Equal(t, want, sch.LatestVersionInMajor(), v.String())
+	}
+
+	v, err := LatestVersionInSequence(lin, 0)
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), v)
+}
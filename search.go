@@ -0,0 +1,425 @@
+package thema
+
+import (
+	"sync"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A Candidate describes the outcome of validating some data against a single
+// schema during a call to [SearchAndValidate].
+type Candidate struct {
+	// Version is the version of the schema the data was checked against.
+	Version SyntacticVersion
+
+	// Err is the validation error produced by the schema, or nil if the data
+	// is a valid instance of it.
+	Err error
+
+	// Score is the number of distinct validation failures reported by the
+	// schema. Zero indicates the data is valid; among failing candidates, a
+	// lower score indicates data that is closer to being an instance.
+	Score int
+}
+
+// Matched reports whether the data validated against the candidate schema.
+func (c Candidate) Matched() bool {
+	return c.Err == nil
+}
+
+// A SearchOption defines options that constrain the set of schemas considered
+// by [SearchAndValidate].
+//
+// When multiple SearchOptions are provided, a schema must satisfy all of them
+// in order to be considered.
+type SearchOption searchOption
+
+// Internal representation of SearchOption.
+type searchOption func(c *searchConfig)
+
+// Internal search configuration options.
+type searchConfig struct {
+	filters []func(sch Schema) bool
+	pool    *SearchPool
+
+	// order in which schemas are considered, and so preferred
+	direction SearchDirection
+
+	// versions that must exist in the lineage for the search to be meaningful
+	requires []SyntacticVersion
+}
+
+func newSearchConfig(lin Lineage, opts []SearchOption) (*searchConfig, error) {
+	cfg := &searchConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	for _, v := range cfg.requires {
+		if !synvExists(lin.allVersions(), v) {
+			return nil, errors.Mark(errors.Newf("no schema with version %s in lineage %s", v, lin.Name()), terrors.ErrVersionNotExist)
+		}
+	}
+	return cfg, nil
+}
+
+// candidates returns the schemas in the lineage that satisfy the search
+// config, in the order they should be considered.
+func (c *searchConfig) candidates(lin Lineage) []Schema {
+	var schs []Schema
+	if c.direction == OldestFirst {
+		for sch := lin.First(); sch != nil; sch = sch.Successor() {
+			if c.allows(sch) {
+				schs = append(schs, sch)
+			}
+		}
+	} else {
+		for sch := lin.Latest(); sch != nil; sch = sch.Predecessor() {
+			if c.allows(sch) {
+				schs = append(schs, sch)
+			}
+		}
+	}
+	return schs
+}
+
+func (c *searchConfig) allows(sch Schema) bool {
+	for _, f := range c.filters {
+		if !f(sch) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *searchConfig) noCandidatesErr(lin Lineage) error {
+	return errors.Mark(errors.Newf("no schema in lineage %s satisfies %d search constraint(s)", lin.Name(), len(c.filters)), terrors.ErrVersionNotExist)
+}
+
+// InMajor restricts a search to schemas within the provided major version.
+//
+// This is useful for APIs that only accept payloads for a particular major
+// version, and must not accidentally match permissive schemas in other majors.
+func InMajor(maj uint) SearchOption {
+	return func(c *searchConfig) {
+		c.filters = append(c.filters, func(sch Schema) bool {
+			return sch.Version()[0] == maj
+		})
+	}
+}
+
+// Between restricts a search to schemas with versions that fall within the
+// provided range, inclusive of both ends.
+func Between(lo, hi SyntacticVersion) SearchOption {
+	return func(c *searchConfig) {
+		c.filters = append(c.filters, func(sch Schema) bool {
+			v := sch.Version()
+			return !v.Less(lo) && !hi.Less(v)
+		})
+	}
+}
+
+// EarliestInMajor restricts a search to schemas within the provided major
+// version, and prefers the oldest satisfying schema - the first schema in the
+// major version - rather than the newest.
+func EarliestInMajor(maj uint) SearchOption {
+	return func(c *searchConfig) {
+		InMajor(maj)(c)
+		c.direction = OldestFirst
+	}
+}
+
+// SearchDirection is the order in which a search considers schemas, and so
+// which of several satisfying schemas it prefers.
+type SearchDirection int
+
+const (
+	// NewestFirst walks the lineage from its latest schema backwards,
+	// preferring the newest satisfying schema. It is the default.
+	NewestFirst SearchDirection = iota
+
+	// OldestFirst walks the lineage from its first schema forwards,
+	// preferring the oldest satisfying schema. With [SearchAndValidate], this
+	// finds the earliest schema some data conforms to, e.g. to measure how
+	// long clients have been sending data in the shape of old versions.
+	OldestFirst
+)
+
+// Direction sets the order in which a search considers schemas. Of the
+// options provided to a search, the last to set a direction wins.
+func Direction(d SearchDirection) SearchOption {
+	return func(c *searchConfig) {
+		c.direction = d
+	}
+}
+
+// PredecessorOf restricts a search to schemas older than the provided version.
+// Because searches prefer the newest satisfying schema by default, this
+// selects the immediate predecessor of v when used with [Find].
+//
+// A schema with version v must exist in the lineage being searched, or the
+// search fails with an error wrapping [terrors.ErrVersionNotExist].
+func PredecessorOf(v SyntacticVersion) SearchOption {
+	return func(c *searchConfig) {
+		c.requires = append(c.requires, v)
+		c.filters = append(c.filters, func(sch Schema) bool {
+			return sch.Version().Less(v)
+		})
+	}
+}
+
+// MinMaturity restricts a search to schemas that are at least as mature as the
+// provided [Maturity]. For example, passing [MaturityStable] excludes
+// experimental and beta schemas, as may be desirable in production
+// configurations, but not deprecated ones.
+func MinMaturity(m Maturity) SearchOption {
+	return func(c *searchConfig) {
+		c.filters = append(c.filters, func(sch Schema) bool {
+			return !sch.Maturity().less(m)
+		})
+	}
+}
+
+// ExcludeExperimental restricts a search to schemas that are not experimental.
+// It is equivalent to MinMaturity(MaturityBeta).
+func ExcludeExperimental() SearchOption {
+	return MinMaturity(MaturityBeta)
+}
+
+// AsArray returns every schema in the lineage containing the provided schema,
+// oldest first, as [Lineage.All]. As each schema refers back to its lineage
+// through [Schema.Lineage], the result is the same whichever schema is
+// provided, including a mid-lineage schema whose predecessors cannot be
+// reached by following [Schema.Successor].
+//
+// Helpers holding only a schema may likewise search its whole lineage by
+// passing sch.Lineage() to [Find] or [SearchAndValidate].
+func AsArray(sch Schema) []Schema {
+	return sch.Lineage().All()
+}
+
+// Find returns the schema in the provided lineage selected by the provided
+// [SearchOption]s. Without any options that alter preference, such as
+// [Direction] or [EarliestInMajor], the newest schema that satisfies all
+// options is returned.
+//
+// An error wrapping [terrors.ErrVersionNotExist] is returned if no schema in
+// the lineage satisfies the options.
+func Find(lin Lineage, opts ...SearchOption) (Schema, error) {
+	isValidLineage(lin)
+
+	cfg, err := newSearchConfig(lin, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	schs := cfg.candidates(lin)
+	if len(schs) == 0 {
+		return nil, cfg.noCandidatesErr(lin)
+	}
+	return schs[0], nil
+}
+
+// A SearchPool holds copies of a lineage, each bound in its own [Runtime] and
+// cue.Context, across which a search with the [Parallel] option validates
+// candidate schemas concurrently. A cue.Context is not safe for concurrent
+// evaluation, so concurrent validation within a single Runtime would gain
+// nothing over sequential validation.
+//
+// A SearchPool is safe for concurrent use, and may be shared by many searches.
+type SearchPool struct {
+	pool chan Lineage
+}
+
+// NewSearchPool creates a [SearchPool] of size copies of the lineage produced
+// by the provided factory, each bound in a new [Runtime] and cue.Context with
+// the provided options. The factory should produce the same lineage that will
+// be searched.
+func NewSearchPool(factory func(*Runtime, ...BindOption) (Lineage, error), size int, opts ...BindOption) (*SearchPool, error) {
+	if size < 1 {
+		return nil, errors.New("search pool size must be at least one")
+	}
+
+	pool, err := newLineagePool(factory, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchPool{pool: pool}, nil
+}
+
+// validate checks data, encoded as JSON, against the schema with the provided
+// version in a lineage taken from the pool.
+func (p *SearchPool) validate(name string, v SyntacticVersion, data []byte) error {
+	lin := <-p.pool
+	defer func() { p.pool <- lin }()
+
+	if lin.Name() != name {
+		return errors.Newf("search pool holds lineage %s, not %s", lin.Name(), name)
+	}
+	sch, err := lin.Schema(v)
+	if err != nil {
+		return err
+	}
+	pdata := lin.Runtime().Context().CompileBytes(data)
+	if pdata.Err() != nil {
+		return pdata.Err()
+	}
+	_, err = sch.Validate(pdata)
+	return err
+}
+
+// Parallel causes the candidate schemas in a search to be validated
+// concurrently, one per lineage in the provided [SearchPool].
+//
+// The outcome of a parallel search is identical to that of a sequential search:
+// the newest matching schema is chosen, and candidates are returned in the same
+// order. Returned instances belong to the searched lineage, not to the pool.
+// Only latency differs. Parallelism is most beneficial for lineages with many
+// schemas, where sequential search latency grows linearly with the number of
+// versions.
+//
+// Data that cannot be encoded as JSON, such as non-concrete data, is validated
+// sequentially.
+func Parallel(pool *SearchPool) SearchOption {
+	return func(c *searchConfig) {
+		c.pool = pool
+	}
+}
+
+// SearchAndValidate searches the provided lineage for a schema against which
+// the provided data is valid, starting from the newest schema and walking
+// backwards. The newest schema against which the data validates is chosen, and
+// an [Instance] of it is returned. If [Direction] is passed [OldestFirst], or
+// [EarliestInMajor] is passed, the walk instead proceeds forwards, and the
+// oldest validating schema is chosen. To obtain an instance of every
+// validating schema, use [SearchAndValidateAll].
+//
+// The set of schemas considered may be constrained by passing [SearchOption]s,
+// such as [InMajor] or [Between].
+//
+// Every considered schema is checked, regardless of whether a match has
+// already been found. One [Candidate] is returned per schema, in the order they
+// were checked, describing the outcome against that schema. This is useful for
+// observing which older schemas some data also conforms to - for example, to
+// measure how much traffic still arrives in the shape of old versions.
+//
+// If the data does not validate against any schema, a nil Instance is returned
+// along with an error wrapping [terrors.ErrInvalidData] that contains the
+// errors from all candidates. If no schema in the lineage satisfies the
+// provided SearchOptions, the returned error wraps [terrors.ErrVersionNotExist].
+//
+// As with [Schema.Validate], input values must be concrete.
+func SearchAndValidate(lin Lineage, data cue.Value, opts ...SearchOption) (*Instance, []Candidate, error) {
+	insts, cands, err := SearchAndValidateAll(lin, data, opts...)
+	if err != nil {
+		return nil, cands, err
+	}
+	return insts[0], cands, nil
+}
+
+// SearchAndValidateAll is as [SearchAndValidate], but returns an [Instance] of
+// every considered schema against which the data is valid, rather than only
+// the first, in the order they were checked. That is, newest first, unless the
+// search [Direction] is [OldestFirst].
+//
+// Errors are as for SearchAndValidate. If an error is returned, no instances
+// are returned.
+func SearchAndValidateAll(lin Lineage, data cue.Value, opts ...SearchOption) ([]*Instance, []Candidate, error) {
+	isValidLineage(lin)
+
+	cfg, err := newSearchConfig(lin, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	schs := cfg.candidates(lin)
+
+	insts := make([]*Instance, len(schs))
+	cands := make([]Candidate, len(schs))
+	check := func(i int) {
+		var err error
+		insts[i], err = schs[i].Validate(data)
+		cands[i] = Candidate{
+			Version: schs[i].Version(),
+			Err:     err,
+			Score:   scoreValidateErr(err),
+		}
+	}
+
+	var b []byte
+	if cfg.pool != nil && len(schs) > 1 {
+		b, _ = data.MarshalJSON()
+	}
+	if b != nil {
+		var wg sync.WaitGroup
+		for i := range schs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := cfg.pool.validate(lin.Name(), schs[i].Version(), b)
+				if err == nil {
+					// Validity is established, so the instance is built around
+					// the caller's data, within the searched lineage.
+					insts[i] = &Instance{valid: true, raw: data, sch: schs[i]}
+				}
+				cands[i] = Candidate{
+					Version: schs[i].Version(),
+					Err:     err,
+					Score:   scoreValidateErr(err),
+				}
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range schs {
+			check(i)
+		}
+	}
+
+	// Schemas were gathered in order of preference, so are kept in that order
+	var matched []*Instance
+	for _, sinst := range insts {
+		if sinst != nil {
+			matched = append(matched, sinst)
+		}
+	}
+
+	if len(cands) == 0 {
+		return nil, nil, cfg.noCandidatesErr(lin)
+	}
+	if len(matched) == 0 {
+		return nil, cands, &noMatchError{cands: cands}
+	}
+	return matched, cands, nil
+}
+
+// scoreValidateErr counts the discrete failures contained in an error returned
+// from [Schema.Validate].
+func scoreValidateErr(err error) int {
+	if err == nil {
+		return 0
+	}
+	if vf, is := err.(validationFailure); is && len(vf) > 0 {
+		return len(vf)
+	}
+	return 1
+}
+
+// noMatchError aggregates the errors from all candidates in a search that
+// produced no match.
+type noMatchError struct {
+	cands []Candidate
+}
+
+func (e *noMatchError) Error() string {
+	var errs validationFailure
+	for _, c := range e.cands {
+		errs = append(errs, c.Err)
+	}
+	return "data is not valid against any schema in lineage:\n" + errs.Error()
+}
+
+func (e *noMatchError) Unwrap() error {
+	return terrors.ErrInvalidData
+}
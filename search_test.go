@@ -0,0 +1,355 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+var searchlinstr = `name: "search"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+	}
+}, {
+	version: [0, 1]
+	schema: {
+		title:  string
+		count?: int
+	}
+}, {
+	version: [1, 0]
+	schema: {
+		name:   string
+		count?: int
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		title: input.title
+	}
+	lacunas: []
+}, {
+	to: [0, 1]
+	from: [1, 0]
+	input: _
+	result: {
+		title: input.name
+		if input.count != _|_ {
+			count: input.count
+		}
+	}
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: {
+		name: input.title
+		if input.count != _|_ {
+			count: input.count
+		}
+	}
+	lacunas: []
+}]
+`
+
+func TestSearchAndValidate(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+
+	t.Run("newest match with older candidates", func(t *testing.T) {
+		inst, cands, err := SearchAndValidate(lin, ctx.CompileString(`{ title: "foo" }`))
+		require.NoError(t, err)
+		assert.Equal(t, SV(0, 1), inst.Schema().Version())
+
+		require.Len(t, cands, 3)
+		assert.Equal(t, SV(1, 0), cands[0].Version)
+		assert.False(t, cands[0].Matched())
+		assert.Greater(t, cands[0].Score, 0)
+		assert.True(t, cands[1].Matched())
+		assert.True(t, cands[2].Matched())
+		assert.Equal(t, SV(0, 0), cands[2].Version)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		inst, cands, err := SearchAndValidate(lin, ctx.CompileString(`{ nope: true }`))
+		require.Error(t, err)
+		assert.Nil(t, inst)
+		assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+		require.Len(t, cands, 3)
+		for _, c := range cands {
+			assert.False(t, c.Matched())
+		}
+	})
+}
+
+func TestSearchAndValidateConstrained(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+	data := ctx.CompileString(`{ title: "foo" }`)
+
+	t.Run("in major", func(t *testing.T) {
+		_, _, err := SearchAndValidate(lin, data, InMajor(1))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+
+		inst, cands, err := SearchAndValidate(lin, data, InMajor(0))
+		require.NoError(t, err)
+		assert.Equal(t, SV(0, 1), inst.Schema().Version())
+		assert.Len(t, cands, 2)
+	})
+
+	t.Run("between", func(t *testing.T) {
+		inst, cands, err := SearchAndValidate(lin, data, Between(SV(0, 0), SV(0, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, SV(0, 0), inst.Schema().Version())
+		assert.Len(t, cands, 1)
+	})
+
+	t.Run("unsatisfiable", func(t *testing.T) {
+		_, _, err := SearchAndValidate(lin, data, InMajor(0), Between(SV(1, 0), SV(2, 0)))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+	})
+}
+
+func TestSearchDirection(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+	data := ctx.CompileString(`{ title: "foo" }`)
+
+	inst, cands, err := SearchAndValidate(lin, data, Direction(OldestFirst))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 0), inst.Schema().Version())
+	require.Len(t, cands, 3)
+	assert.Equal(t, SV(0, 0), cands[0].Version)
+	assert.Equal(t, SV(1, 0), cands[2].Version)
+
+	// The last direction set wins
+	inst, _, err = SearchAndValidate(lin, data, Direction(OldestFirst), Direction(NewestFirst))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), inst.Schema().Version())
+
+	sch, err := Find(lin, Direction(OldestFirst))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 0), sch.Version())
+}
+
+func TestSearchAndValidateAll(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+	data := ctx.CompileString(`{ title: "foo" }`)
+
+	versions := func(insts []*Instance) []SyntacticVersion {
+		var vl []SyntacticVersion
+		for _, inst := range insts {
+			vl = append(vl, inst.Schema().Version())
+		}
+		return vl
+	}
+
+	insts, cands, err := SearchAndValidateAll(lin, data)
+	require.NoError(t, err)
+	assert.Equal(t, []SyntacticVersion{SV(0, 1), SV(0, 0)}, versions(insts))
+	assert.Len(t, cands, 3)
+
+	insts, _, err = SearchAndValidateAll(lin, data, Direction(OldestFirst), Parallel(testSearchPool(t, 2)))
+	require.NoError(t, err)
+	assert.Equal(t, []SyntacticVersion{SV(0, 0), SV(0, 1)}, versions(insts))
+
+	insts, cands, err = SearchAndValidateAll(lin, ctx.CompileString(`{ nope: true }`))
+	assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+	assert.Nil(t, insts)
+	assert.Len(t, cands, 3)
+}
+
+func TestSearchFromAnySchema(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+	data := ctx.CompileString(`{ title: "foo" }`)
+
+	for _, start := range lin.All() {
+		t.Run(start.Version().String(), func(t *testing.T) {
+			all := AsArray(start)
+			require.Len(t, all, 3)
+			assert.Equal(t, SV(0, 0), all[0].Version())
+			assert.Equal(t, SV(1, 0), all[2].Version())
+
+			sch, err := Find(start.Lineage(), Direction(OldestFirst))
+			require.NoError(t, err)
+			assert.Equal(t, SV(0, 0), sch.Version())
+
+			inst, _, err := SearchAndValidate(start.Lineage(), data)
+			require.NoError(t, err)
+			assert.Equal(t, SV(0, 1), inst.Schema().Version())
+		})
+	}
+}
+
+func TestSearchAndValidateParallel(t *testing.T) {
+	lin := testLin(searchlinstr)
+	ctx := lin.Runtime().Context()
+	data := ctx.CompileString(`{ title: "foo" }`)
+
+	pool := testSearchPool(t, 3)
+	for _, datastr := range []string{`{ title: "foo" }`, `{ title: "foo", count: 1 }`, `{ nope: true }`} {
+		data := ctx.CompileString(datastr)
+		sinsts, scands, serr := SearchAndValidateAll(lin, data)
+		pinsts, pcands, perr := SearchAndValidateAll(lin, data, Parallel(pool))
+		assert.Equal(t, serr == nil, perr == nil, datastr)
+
+		require.Len(t, pinsts, len(sinsts), datastr)
+		for i := range sinsts {
+			assert.Equal(t, sinsts[i].Schema().Version(), pinsts[i].Schema().Version())
+			// Instances belong to the searched lineage, not the pool
+			assert.Equal(t, lin, pinsts[i].Schema().Lineage())
+			assert.Equal(t, data, pinsts[i].Underlying())
+		}
+		require.Len(t, pcands, len(scands), datastr)
+		for i := range scands {
+			assert.Equal(t, scands[i].Version, pcands[i].Version)
+			assert.Equal(t, scands[i].Matched(), pcands[i].Matched())
+			assert.Equal(t, scands[i].Score, pcands[i].Score)
+		}
+	}
+
+	// A pool holding a different lineage matches nothing
+	other, err := NewSearchPool(func(rt *Runtime, opts ...BindOption) (Lineage, error) {
+		return BindLineage(rt.Context().CompileString(`name: "other", schemas: [{version: [0, 0], schema: {}}]`), rt, opts...)
+	}, 1)
+	require.NoError(t, err)
+	_, _, err = SearchAndValidate(lin, data, Parallel(other))
+	assert.Error(t, err)
+
+	_, err = NewSearchPool(nil, 0)
+	assert.Error(t, err)
+}
+
+func testSearchPool(t *testing.T, size int) *SearchPool {
+	t.Helper()
+	pool, err := NewSearchPool(func(rt *Runtime, opts ...BindOption) (Lineage, error) {
+		return BindLineage(rt.Context().CompileString(searchlinstr), rt, opts...)
+	}, size)
+	require.NoError(t, err)
+	return pool
+}
+
+func TestFind(t *testing.T) {
+	lin := testLin(searchlinstr)
+
+	sch, err := Find(lin)
+	require.NoError(t, err)
+	assert.Equal(t, SV(1, 0), sch.Version())
+
+	sch, err = Find(lin, EarliestInMajor(0))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 0), sch.Version())
+
+	sch, err = Find(lin, InMajor(0))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), sch.Version())
+
+	sch, err = Find(lin, Between(SV(0, 0), SV(0, 5)))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), sch.Version())
+
+	sch, err = Find(lin, PredecessorOf(SV(1, 0)))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 1), sch.Version())
+
+	_, err = Find(lin, PredecessorOf(SV(0, 0)))
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+
+	_, err = Find(lin, PredecessorOf(SV(0, 7)))
+	assert.True(t, errors.Is(err, terrors.ErrVersionNotExist))
+}
+
+var maturitylinstr = `name: "maturity"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	maturity: "experimental"
+	schema: name: string
+}, {
+	version: [1, 1]
+	maturity: "beta"
+	schema: {
+		name:   string
+		count?: int
+	}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`
+
+func TestMaturity(t *testing.T) {
+	lin := testLin(maturitylinstr)
+	assert.Equal(t, MaturityStable, lin.First().Maturity())
+	assert.Equal(t, MaturityExperimental, SchemaP(lin, SV(1, 0)).Maturity())
+	assert.Equal(t, MaturityBeta, lin.Latest().Maturity())
+
+	sch, err := Find(lin, ExcludeExperimental())
+	require.NoError(t, err)
+	assert.Equal(t, SV(1, 1), sch.Version())
+
+	sch, err = Find(lin, MinMaturity(MaturityStable))
+	require.NoError(t, err)
+	assert.Equal(t, SV(0, 0), sch.Version())
+
+	_, _, err = SearchAndValidate(lin, lin.Runtime().Context().CompileString(`{ name: "foo" }`), MinMaturity(MaturityStable))
+	assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+
+	_, err = BindLineage(lin.Runtime().Context().CompileString(`name: "maturity"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	maturity: "experimental"
+	schema: {
+		title:  string
+		count?: int
+	}
+}]
+`), lin.Runtime())
+	assert.True(t, errors.Is(err, terrors.ErrInvalidLineage))
+
+	deplin := testLin(`name: "deprecated"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	maturity: "deprecated"
+	schema: {
+		title:  string
+		count?: int
+	}
+}]
+`)
+	sch, err = Find(deplin, MinMaturity(MaturityStable))
+	require.NoError(t, err)
+	assert.Equal(t, MaturityDeprecated, sch.Maturity())
+}
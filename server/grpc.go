@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPCServiceName is the fully-qualified name of the gRPC service registered by
+// [Handler.RegisterGRPC], as declared in thema.proto.
+const GRPCServiceName = "thema.server.v1.Thema"
+
+// RegisterGRPC registers the Handler's gRPC service, declared in thema.proto,
+// with the provided gRPC server. Each method of the service corresponds to an
+// HTTP endpoint, and behaves identically:
+//
+//   - Validate corresponds to /validate
+//   - Translate corresponds to /translate
+//   - Lineages corresponds to /lineages
+//
+// Requests to Validate and Translate are a google.protobuf.Struct with the
+// string fields lineage, and optionally version and to, corresponding to the
+// query parameters of the HTTP endpoints, and the field data, containing the
+// data to validate. Responses are a google.protobuf.Struct with the same
+// fields as the [ValidateResponse] or [TranslateResponse] JSON body. Lineages
+// responds with a Struct containing the field lineages, an array of
+// [LineageInfo].
+//
+// Request metadata is passed on as HTTP headers, such that the
+// [AcceptVersionHeader] and any headers consulted by [DraftAccess] may be
+// given as metadata. The [VersionHeader] is sent as response header metadata.
+// Failures are reported with the gRPC status code nearest to the HTTP status
+// of the equivalent [Error], which is attached to the status as a
+// google.protobuf.Struct detail.
+func (h *Handler) RegisterGRPC(s grpc.ServiceRegistrar) {
+	s.RegisterService(&grpcServiceDesc, h)
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: GRPCServiceName,
+	HandlerType: (*http.Handler)(nil),
+	Methods: []grpc.MethodDesc{
+		grpcMethod("Validate", "/validate", func() proto.Message { return new(structpb.Struct) }),
+		grpcMethod("Translate", "/translate", func() proto.Message { return new(structpb.Struct) }),
+		grpcMethod("Lineages", "/lineages", func() proto.Message { return new(emptypb.Empty) }),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "thema.proto",
+}
+
+// grpcMethod describes the named gRPC method, which serves requests of the type
+// returned by newReq with the HTTP endpoint at path.
+func grpcMethod(name, path string, newReq func() proto.Message) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := newReq()
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			serve := func(ctx context.Context, req any) (any, error) {
+				return srv.(*Handler).serveGRPC(ctx, path, req)
+			}
+			if interceptor == nil {
+				return serve(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + GRPCServiceName + "/" + name,
+			}
+			return interceptor(ctx, in, info, serve)
+		},
+	}
+}
+
+// serveGRPC serves a gRPC request by making the equivalent request to the HTTP
+// endpoint at path, and converting its response.
+func (h *Handler) serveGRPC(ctx context.Context, path string, in any) (*structpb.Struct, error) {
+	method, q, body := http.MethodGet, url.Values{}, []byte(nil)
+	if req, is := in.(*structpb.Struct); is {
+		method = http.MethodPost
+		for _, param := range []string{"lineage", "version", "to"} {
+			if s := req.GetFields()[param].GetStringValue(); s != "" {
+				q.Set(param, s)
+			}
+		}
+		if data, has := req.GetFields()["data"]; has {
+			var err error
+			if body, err = data.MarshalJSON(); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid data: %s", err)
+			}
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, path+"?"+q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		if strings.HasPrefix(k, ":") || strings.HasPrefix(k, "grpc-") {
+			continue
+		}
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	// Data arrives as a Struct, whatever the content type of the gRPC request
+	r.Header.Set("Content-Type", "application/json")
+	if p, has := peer.FromContext(ctx); has {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	w := &grpcResponseWriter{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(w, r)
+
+	if w.status != http.StatusOK {
+		return nil, grpcError(w.status, w.body.Bytes())
+	}
+	if v := w.header.Get(VersionHeader); v != "" {
+		grpc.SetHeader(ctx, metadata.Pairs(VersionHeader, v)) // nolint: errcheck
+	}
+
+	var out map[string]any
+	if path == "/lineages" {
+		var infos []any
+		if err := json.Unmarshal(w.body.Bytes(), &infos); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		out = map[string]any{"lineages": infos}
+	} else if err := json.Unmarshal(w.body.Bytes(), &out); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp, err := structpb.NewStruct(out)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+// grpcError converts an HTTP error response to a gRPC status error. Where the
+// body is an [Error], it is attached to the status as a Struct detail.
+func grpcError(code int, body []byte) error {
+	var resp struct {
+		Error map[string]any `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == nil {
+		return status.Error(grpcCode(code), strings.TrimSpace(string(body)))
+	}
+
+	msg, _ := resp.Error["message"].(string)
+	st := status.New(grpcCode(code), msg)
+	if detail, err := structpb.NewStruct(resp.Error); err == nil {
+		if dst, err := st.WithDetails(detail); err == nil {
+			st = dst
+		}
+	}
+	return st.Err()
+}
+
+// grpcCode returns the gRPC status code nearest to the HTTP status code.
+func grpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusNotAcceptable:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcResponseWriter captures the response written by the HTTP handler in
+// serving a gRPC request.
+type grpcResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (w *grpcResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *grpcResponseWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+}
+
+func (w *grpcResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
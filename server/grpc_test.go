@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestHandlerGRPC(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		desc?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+
+	h := NewHandler(set)
+	require.NoError(t, h.Warm(context.Background()))
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	h.RegisterGRPC(s)
+	go s.Serve(lis) // nolint: errcheck
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() }) // nolint: errcheck
+
+	ctx := context.Background()
+	method := func(name string) string { return "/" + GRPCServiceName + "/" + name }
+	req := func(t *testing.T, fields map[string]any) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(fields)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		out := new(structpb.Struct)
+		err := conn.Invoke(ctx, method("Validate"), req(t, map[string]any{
+			"lineage": "served",
+			"data":    map[string]any{"title": "hi", "desc": "there"},
+		}), out)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"lineage": "served", "version": []any{0.0, 1.0}}, out.AsMap())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := conn.Invoke(ctx, method("Validate"), req(t, map[string]any{
+			"lineage": "served",
+			"data":    map[string]any{"title": 42.0},
+		}), new(structpb.Struct))
+		st, is := status.FromError(err)
+		require.True(t, is)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+		detail, is := st.Details()[0].(*structpb.Struct)
+		require.True(t, is)
+		assert.Equal(t, 422.0, detail.GetFields()["status"].GetNumberValue())
+		assert.NotEmpty(t, detail.GetFields()["issues"].GetListValue().GetValues())
+	})
+
+	t.Run("unknown lineage", func(t *testing.T) {
+		err := conn.Invoke(ctx, method("Validate"), req(t, map[string]any{
+			"lineage": "nope",
+			"data":    map[string]any{"title": "hi"},
+		}), new(structpb.Struct))
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("translate", func(t *testing.T) {
+		var header metadata.MD
+		out := new(structpb.Struct)
+		err := conn.Invoke(ctx, method("Translate"), req(t, map[string]any{
+			"lineage": "served",
+			"version": "0.0",
+			"data":    map[string]any{"title": "hi"},
+		}), out, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0.1"}, header.Get(VersionHeader))
+		got := out.AsMap()
+		assert.Equal(t, []any{0.0, 0.0}, got["from"])
+		assert.Equal(t, []any{0.0, 1.0}, got["to"])
+		assert.Equal(t, map[string]any{"title": "hi"}, got["data"])
+	})
+
+	t.Run("lineages", func(t *testing.T) {
+		out := new(structpb.Struct)
+		require.NoError(t, conn.Invoke(ctx, method("Lineages"), new(emptypb.Empty), out))
+		infos := out.GetFields()["lineages"].GetListValue().GetValues()
+		require.Len(t, infos, 1)
+		assert.Equal(t, "served", infos[0].GetStructValue().GetFields()["name"].GetStringValue())
+	})
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxClients bounds the number of per-client rate limiters that are retained
+// before idle ones are evicted.
+const maxClients = 10000
+
+// An Error is the body of all non-2xx responses from the validate and
+// translate endpoints.
+type Error struct {
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Message describes the error.
+	Message string `json:"message"`
+
+	// RetryAfter is the number of seconds after which a rate limited request
+	// may be retried. It is only set on 429 responses.
+	RetryAfter int `json:"retryAfter,omitempty"`
+
+	// Issues lists the individual failures of the request data to validate,
+	// as reported by [thema.ValidationIssues]. It is only set on 422
+	// responses to validation failures.
+	Issues []Issue `json:"issues,omitempty"`
+}
+
+// An Issue describes a single failure of request data to conform to a schema.
+type Issue struct {
+	// Path is the dot-separated path to the field at which the failure
+	// occurred, e.g. "spec.title".
+	Path string `json:"path,omitempty"`
+
+	// Code classifies the failure, e.g. "OutOfBounds".
+	Code string `json:"code,omitempty"`
+
+	// Message describes the failure. Where the schema declares a message for
+	// the failure with an @error attribute, it is that message.
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, e Error) {
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(e.RetryAfter))
+	}
+	writeJSON(w, e.Status, struct {
+		Error Error `json:"error"`
+	}{e})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
+
+// limiter tracks a token bucket rate limiter for each client.
+type limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*rate.Limiter
+}
+
+func newLimiter(c *config) *limiter {
+	if c.rps <= 0 {
+		return nil
+	}
+	burst := c.burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{
+		limit:   rate.Limit(c.rps),
+		burst:   burst,
+		clients: make(map[string]*rate.Limiter),
+	}
+}
+
+// reserve consumes a token for the client, returning zero if the request may
+// proceed, or otherwise the duration the client must wait before retrying.
+func (l *limiter) reserve(client string) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	lim, has := l.clients[client]
+	if !has {
+		if len(l.clients) >= maxClients {
+			l.evict(now)
+		}
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.clients[client] = lim
+	}
+	l.mu.Unlock()
+
+	if lim.AllowN(now, 1) {
+		return 0
+	}
+	r := lim.ReserveN(now, 1)
+	d := r.DelayFrom(now)
+	r.CancelAt(now)
+	return d
+}
+
+// evict removes limiters whose buckets have refilled completely, as their
+// clients have been idle long enough that forgetting them changes nothing.
+// Must be called with l.mu held.
+func (l *limiter) evict(now time.Time) {
+	for client, lim := range l.clients {
+		if lim.TokensAt(now) >= float64(l.burst) {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// limit wraps an endpoint handler with the configured rate and body size
+// limits.
+func (h *Handler) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.limiter != nil {
+			if d := h.limiter.reserve(h.cfg.clientKey(r)); d > 0 {
+				writeError(w, Error{
+					Status:     http.StatusTooManyRequests,
+					Message:    "rate limit exceeded",
+					RetryAfter: int(math.Ceil(d.Seconds())),
+				})
+				return
+			}
+		}
+
+		if h.cfg.maxBody > 0 {
+			if r.ContentLength > h.cfg.maxBody {
+				writeTooLarge(w, h.cfg.maxBody)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, h.cfg.maxBody)
+		}
+		next(w, r)
+	}
+}
+
+func writeTooLarge(w http.ResponseWriter, max int64) {
+	writeError(w, Error{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: "request body exceeds limit of " + strconv.FormatInt(max, 10) + " bytes",
+	})
+}
+
+// isTooLarge reports whether err arose from reading a request body in excess
+// of the limit set by [MaxBodyBytes].
+func isTooLarge(err error) bool {
+	var mbe *http.MaxBytesError
+	return errors.As(err, &mbe)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/grafana/thema"
+)
+
+// DefaultMaxBodyBytes is the largest request body accepted by the validate and
+// translate endpoints, unless otherwise specified with [MaxBodyBytes].
+const DefaultMaxBodyBytes = 1 << 20
+
+// DefaultsMode determines how schema defaults are treated in the data returned
+// from the translate endpoint.
+type DefaultsMode int
+
+const (
+	// DefaultsKeep returns translated data as produced by translation, with
+	// neither defaults added nor removed.
+	DefaultsKeep DefaultsMode = iota
+
+	// DefaultsApply fills all defaults of the target schema into translated
+	// data, as [thema.Instance.ApplyDefaults] with all options enabled.
+	DefaultsApply
+
+	// DefaultsTrim removes all fields equal to their defaults in the target
+	// schema from translated data, as [thema.Instance.TrimDefaults].
+	DefaultsTrim
+)
+
+// ParseDefaultsMode parses the name of a DefaultsMode: "keep", "apply" or
+// "trim".
+func ParseDefaultsMode(s string) (DefaultsMode, error) {
+	switch s {
+	case "keep":
+		return DefaultsKeep, nil
+	case "apply":
+		return DefaultsApply, nil
+	case "trim":
+		return DefaultsTrim, nil
+	}
+	return DefaultsKeep, fmt.Errorf("unknown defaults mode %q, expected one of keep, apply, trim", s)
+}
+
+// An Option configures a [Handler].
+type Option func(c *config)
+
+type config struct {
+	maxBody   int64
+	rps       float64
+	burst     int
+	clientKey func(r *http.Request) string
+	targets   map[string]thema.SyntacticVersion
+	defaults  DefaultsMode
+	policy    *thema.LacunaPolicy
+	drafts    func(r *http.Request) thema.DraftGate
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		maxBody:   DefaultMaxBodyBytes,
+		clientKey: remoteHost,
+		targets:   make(map[string]thema.SyntacticVersion),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// MaxBodyBytes limits the size of request bodies accepted by the validate and
+// translate endpoints. Requests with larger bodies are rejected with a 413
+// response before any CUE evaluation occurs. Values less than one disable the
+// limit.
+func MaxBodyBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBody = n
+	}
+}
+
+// RateLimit limits each client to an average of perSecond requests per second
+// to the validate and translate endpoints, permitting bursts of up to burst
+// requests. Requests in excess of the limit are rejected with a 429 response.
+//
+// Health, readiness and metadata endpoints are never rate limited. By default,
+// clients are identified by the host of the request's remote address; see
+// [ClientKey].
+func RateLimit(perSecond float64, burst int) Option {
+	return func(c *config) {
+		c.rps = perSecond
+		c.burst = burst
+	}
+}
+
+// ClientKey sets the function used to identify the client making a request
+// for the purposes of [RateLimit]. This is necessary when serving behind a
+// proxy, where the remote address is that of the proxy rather than the client.
+func ClientKey(fn func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.clientKey = fn
+	}
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// TargetVersion sets the schema version to which the translate endpoint
+// translates data in the named lineage when the request does not specify one.
+// Otherwise, data is translated to the lineage's latest schema.
+//
+// It is not an error for the lineage or version not to exist; requests that
+// rely on the target then fail with a 404 response.
+func TargetVersion(lineage string, v thema.SyntacticVersion) Option {
+	return func(c *config) {
+		c.targets[lineage] = v
+	}
+}
+
+// Defaults sets how schema defaults are treated in the data returned from the
+// translate endpoint. The default is [DefaultsKeep].
+func Defaults(mode DefaultsMode) Option {
+	return func(c *config) {
+		c.defaults = mode
+	}
+}
+
+// LacunaPolicy sets a policy checked against the lacunas emitted by each
+// translation performed by the translate endpoint. Translations whose lacunas
+// the policy does not permit are rejected with a 422 response, and lacunas for
+// which it prescribes a warning are reported in the response's Warnings.
+func LacunaPolicy(p *thema.LacunaPolicy) Option {
+	return func(c *config) {
+		c.policy = p
+	}
+}
+
+// DraftHeader is the request header read by [DraftsFromHeader], listing the
+// draft schema versions a client opts into, e.g. "2.0, 1.4", or "*" for all.
+const DraftHeader = "Thema-Drafts"
+
+// DraftAccess gates the draft schemas of each lineage, those whose maturity is
+// experimental or beta, per request, so that new versions can be dark-launched
+// to a subset of traffic. The provided function returns the [thema.DraftGate]
+// for each request, e.g. based on the client's identity, or a header set by a
+// proxy; see [DraftsFromHeader].
+//
+// Drafts a request's gate does not enable are neither searched when validating
+// its data, nor chosen as the default translation target or by version
+// negotiation. Requests naming them explicitly fail with a 404 response. By
+// default, all drafts are enabled for all requests.
+func DraftAccess(fn func(r *http.Request) thema.DraftGate) Option {
+	return func(c *config) {
+		c.drafts = fn
+	}
+}
+
+// DraftsFromHeader returns a function for [DraftAccess] that enables the
+// drafts listed in the [DraftHeader] of each request, as parsed by
+// [thema.ParseDraftGate]. Requests with no header, or an invalid one, are
+// given no drafts.
+//
+// As any client may set the header, it should only be trusted when set or
+// filtered by a proxy in front of the server.
+func DraftsFromHeader() func(r *http.Request) thema.DraftGate {
+	return func(r *http.Request) thema.DraftGate {
+		g, err := thema.ParseDraftGate(r.Header.Get(DraftHeader))
+		if err != nil {
+			return thema.DraftGate{}
+		}
+		return g
+	}
+}
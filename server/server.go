@@ -0,0 +1,427 @@
+// Package server provides HTTP endpoints for programs that serve Thema
+// lineages, covering validation and translation of data, along with the
+// health, readiness and metadata checks that deployment tooling expects.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/load"
+	"github.com/grafana/thema/vmux"
+)
+
+const (
+	// AcceptVersionHeader is the request header in which clients of the
+	// /translate endpoint may list the schema versions they understand, e.g.
+	// "2.3, 1.4", as parsed by [thema.ParseVersionList]. The data is translated
+	// to the version chosen by [thema.NegotiateVersion], or the request is
+	// rejected with 406 if the lineage has no schema the client can read.
+	AcceptVersionHeader = "Thema-Accept-Version"
+
+	// VersionHeader is the response header in which the /translate endpoint
+	// reports the version of the schema to which the data was translated.
+	VersionHeader = "Thema-Version"
+)
+
+// LineageInfo describes a single lineage, as served by the /lineages endpoint.
+type LineageInfo struct {
+	// Name is the name of the lineage.
+	Name string `json:"name"`
+
+	// Versions are the versions of all schemas in the lineage, in order.
+	Versions []thema.SyntacticVersion `json:"versions"`
+
+	// Latest is the version of the latest schema in the lineage.
+	Latest thema.SyntacticVersion `json:"latest"`
+
+	// Checksum is the checksum of the lineage, as computed by
+	// [load.LineageChecksum].
+	Checksum string `json:"checksum"`
+}
+
+// A Handler serves the following endpoints for a [thema.LineageSet]:
+//
+//   - /healthz: responds 200 whenever the process is able to serve requests.
+//   - /readyz: responds 200 once [Handler.Warm] has completed successfully,
+//     and 503 before then.
+//   - /lineages: responds with a JSON array of [LineageInfo], one per lineage,
+//     ordered by name.
+//   - /validate: validates the request body against the lineage named by
+//     the lineage query parameter, responding with a [ValidateResponse]. If the
+//     version parameter is given, only that schema is checked; otherwise the
+//     newest schema against which the data is valid is chosen.
+//   - /translate: validates the request body as /validate, then
+//     translates it to the schema version given by the to parameter, or if
+//     absent the version negotiated from the [AcceptVersionHeader], the
+//     version set by [TargetVersion] or the latest schema, responding with a
+//     [TranslateResponse] and the [VersionHeader]. Defaults in the result are
+//     treated according to [Defaults], and lacunas emitted by the translation
+//     are checked against any [LacunaPolicy].
+//
+// The validate and translate endpoints accept only POST requests, and are
+// subject to the limits configured by [MaxBodyBytes] and [RateLimit]. Their
+// failures are reported with an [Error] body. Request bodies may be in any
+// format for which a decoder is registered with [vmux.RegisterDecoder], as
+// given by the Content-Type header, and are treated as JSON if it is absent.
+// Other content types are rejected with 415.
+//
+// If [DraftAccess] is set, draft schemas are only used for requests for which
+// they are enabled, and are otherwise treated as if they did not exist.
+//
+// Other paths respond 404. Handler is intended to be mounted alongside a
+// program's own endpoints. The validate, translate and lineages endpoints may
+// also be served over gRPC with [Handler.RegisterGRPC].
+type Handler struct {
+	set     *thema.LineageSet
+	ready   atomic.Bool
+	mux     *http.ServeMux
+	cfg     *config
+	limiter *limiter
+
+	mu    sync.RWMutex
+	infos []LineageInfo
+}
+
+// NewHandler creates a [Handler] serving the lineages in the provided set. The
+// Handler is not ready until [Handler.Warm] is called.
+func NewHandler(set *thema.LineageSet, opts ...Option) *Handler {
+	cfg := newConfig(opts)
+	h := &Handler{
+		set:     set,
+		mux:     http.NewServeMux(),
+		cfg:     cfg,
+		limiter: newLimiter(cfg),
+	}
+	h.mux.HandleFunc("/healthz", h.healthz)
+	h.mux.HandleFunc("/readyz", h.readyz)
+	h.mux.HandleFunc("/lineages", h.lineages)
+	h.mux.HandleFunc("/validate", h.limit(h.validate))
+	h.mux.HandleFunc("/translate", h.limit(h.translate))
+	return h
+}
+
+// Warm computes the metadata for each lineage in the set and precompiles each
+// of them with [thema.Lineage.Precompile], so that the CUE evaluation they
+// require does not occur while serving requests, and broken lineages are
+// reported before any request is served. Once Warm completes without error,
+// the Handler reports itself as ready.
+//
+// Warm may be called again, e.g. after lineages in the set are replaced, to
+// refresh the served metadata.
+func (h *Handler) Warm(ctx context.Context) error {
+	var infos []LineageInfo
+	var err error
+	h.set.Range(func(name string, lin thema.Lineage) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		info := LineageInfo{
+			Name:   name,
+			Latest: lin.Latest().Version(),
+		}
+		for sch := lin.First(); sch != nil; sch = sch.Successor() {
+			info.Versions = append(info.Versions, sch.Version())
+		}
+		if err = lin.Precompile(ctx); err != nil {
+			return false
+		}
+		if info.Checksum, err = load.LineageChecksum(lin); err != nil {
+			return false
+		}
+		infos = append(infos, info)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.infos = infos
+	h.mu.Unlock()
+	h.ready.Store(true)
+	return nil
+}
+
+// ServeHTTP implements [http.Handler].
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n")) // nolint: errcheck
+}
+
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !h.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("lineages not yet warm\n")) // nolint: errcheck
+		return
+	}
+	w.Write([]byte("ok\n")) // nolint: errcheck
+}
+
+func (h *Handler) lineages(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		http.Error(w, "lineages not yet warm", http.StatusServiceUnavailable)
+		return
+	}
+
+	h.mu.RLock()
+	infos := h.infos
+	h.mu.RUnlock()
+	if infos == nil {
+		infos = []LineageInfo{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos) // nolint: errcheck
+}
+
+// ValidateResponse is the body of a successful response from the /validate
+// endpoint.
+type ValidateResponse struct {
+	// Lineage is the name of the lineage against which the data was validated.
+	Lineage string `json:"lineage"`
+
+	// Version is the version of the schema of which the data is an instance.
+	Version thema.SyntacticVersion `json:"version"`
+}
+
+// TranslateResponse is the body of a successful response from the /translate
+// endpoint.
+type TranslateResponse struct {
+	// Lineage is the name of the lineage within which the data was translated.
+	Lineage string `json:"lineage"`
+
+	// From is the version of the schema of which the input data is an instance.
+	From thema.SyntacticVersion `json:"from"`
+
+	// To is the version of the schema to which the data was translated.
+	To thema.SyntacticVersion `json:"to"`
+
+	// Data is the translated data.
+	Data json.RawMessage `json:"data"`
+
+	// Lacunas are the lacunas emitted by the translation, if any.
+	Lacunas []thema.Lacuna `json:"lacunas,omitempty"`
+
+	// Warnings are the lacunas for which the policy set by [LacunaPolicy]
+	// prescribes a warning, if any.
+	Warnings []thema.Lacuna `json:"warnings,omitempty"`
+}
+
+func (h *Handler) validate(w http.ResponseWriter, r *http.Request) {
+	lin, inst, ok := h.instance(w, r, h.gate(r))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, ValidateResponse{
+		Lineage: lin.Name(),
+		Version: inst.Schema().Version(),
+	})
+}
+
+func (h *Handler) translate(w http.ResponseWriter, r *http.Request) {
+	gate := h.gate(r)
+	lin, inst, ok := h.instance(w, r, gate)
+	if !ok {
+		return
+	}
+
+	var to thema.SyntacticVersion
+	if s := r.URL.Query().Get("to"); s != "" {
+		sch, ok := schemaParam(w, lin, gate, "to", s)
+		if !ok {
+			return
+		}
+		to = sch.Version()
+	} else if s := r.Header.Get(AcceptVersionHeader); s != "" {
+		accepted, err := thema.ParseVersionList(s)
+		if err != nil {
+			writeError(w, Error{Status: http.StatusBadRequest, Message: "invalid " + AcceptVersionHeader + " header: " + err.Error()})
+			return
+		}
+		sch, err := thema.Find(lin, thema.ReadableBy(accepted...), thema.WithDraftGate(gate))
+		if err != nil {
+			writeError(w, Error{Status: http.StatusNotAcceptable, Message: "no schema in lineage " + lin.Name() + " is readable by a client accepting versions [" + thema.FormatVersionList(accepted...) + "]"})
+			return
+		}
+		to = sch.Version()
+	} else if v, has := h.cfg.targets[lin.Name()]; has {
+		sch, err := lin.Schema(v)
+		if err == nil {
+			err = gate.Check(sch)
+		}
+		if err != nil {
+			writeError(w, Error{Status: http.StatusNotFound, Message: err.Error()})
+			return
+		}
+		to = v
+	} else {
+		sch, err := thema.Find(lin, thema.WithDraftGate(gate))
+		if err != nil {
+			writeError(w, Error{Status: http.StatusNotFound, Message: err.Error()})
+			return
+		}
+		to = sch.Version()
+	}
+
+	tinst, lac, err := inst.Translate(to)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusUnprocessableEntity, Message: err.Error()})
+		return
+	}
+	warnings, err := h.cfg.policy.Check(lac)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusUnprocessableEntity, Message: err.Error()})
+		return
+	}
+	switch h.cfg.defaults {
+	case DefaultsApply:
+		tinst, err = tinst.ApplyDefaults(thema.ApplyDefaultsOpts{Optional: true, Lists: true, PreserveOrder: true})
+	case DefaultsTrim:
+		tinst, err = tinst.TrimDefaults(thema.TrimDefaultsOpts{PreserveOrder: true})
+	}
+	if err != nil {
+		writeError(w, Error{Status: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	data, err := tinst.Underlying().MarshalJSON()
+	if err != nil {
+		writeError(w, Error{Status: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+
+	resp := TranslateResponse{
+		Lineage:  lin.Name(),
+		From:     inst.Schema().Version(),
+		To:       to,
+		Data:     data,
+		Warnings: warnings,
+	}
+	if lac != nil {
+		resp.Lacunas = lac.AsList()
+	}
+	w.Header().Set(VersionHeader, to.String())
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// instance reads the lineage, optional version and request body common to the
+// validate and translate endpoints, and validates the body. If false is
+// returned, an error response has already been written.
+func (h *Handler) instance(w http.ResponseWriter, r *http.Request, gate thema.DraftGate) (thema.Lineage, *thema.Instance, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, Error{Status: http.StatusMethodNotAllowed, Message: "method must be POST"})
+		return nil, nil, false
+	}
+
+	q := r.URL.Query()
+	name := q.Get("lineage")
+	if name == "" {
+		writeError(w, Error{Status: http.StatusBadRequest, Message: "lineage parameter is required"})
+		return nil, nil, false
+	}
+	lin, has := h.set.Get(name)
+	if !has {
+		writeError(w, Error{Status: http.StatusNotFound, Message: "no lineage named " + name})
+		return nil, nil, false
+	}
+
+	var sch thema.Schema
+	if s := q.Get("version"); s != "" {
+		var ok bool
+		if sch, ok = schemaParam(w, lin, gate, "version", s); !ok {
+			return nil, nil, false
+		}
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		ct = "application/json"
+	}
+	dec, err := vmux.DecoderFor(ct, "request")
+	if err != nil {
+		writeError(w, Error{Status: http.StatusUnsupportedMediaType, Message: err.Error()})
+		return nil, nil, false
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		if isTooLarge(err) {
+			writeTooLarge(w, h.cfg.maxBody)
+		} else {
+			writeError(w, Error{Status: http.StatusBadRequest, Message: err.Error()})
+		}
+		return nil, nil, false
+	}
+	data, err := dec.Decode(lin.Runtime().Context(), b)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusBadRequest, Message: err.Error()})
+		return nil, nil, false
+	}
+
+	var inst *thema.Instance
+	if sch != nil {
+		inst, err = sch.Validate(data)
+	} else {
+		inst, _, err = thema.SearchAndValidate(lin, data, thema.WithDraftGate(gate))
+	}
+	if err != nil {
+		writeError(w, invalidDataError(err))
+		return nil, nil, false
+	}
+	return lin, inst, true
+}
+
+// schemaParam returns the schema in the lineage with the version given in the
+// named query parameter, which must be enabled by the gate. If false is
+// returned, an error response has already been written.
+func schemaParam(w http.ResponseWriter, lin thema.Lineage, gate thema.DraftGate, param, s string) (thema.Schema, bool) {
+	v, err := thema.ParseSyntacticVersion(s)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusBadRequest, Message: "invalid " + param + " parameter: " + err.Error()})
+		return nil, false
+	}
+	sch, err := lin.Schema(v)
+	if err == nil {
+		err = gate.Check(sch)
+	}
+	if err != nil {
+		writeError(w, Error{Status: http.StatusNotFound, Message: err.Error()})
+		return nil, false
+	}
+	return sch, true
+}
+
+// gate returns the draft gate for the request, as set by [DraftAccess]. All
+// drafts are enabled if no DraftAccess function is set.
+func (h *Handler) gate(r *http.Request) thema.DraftGate {
+	if h.cfg.drafts == nil {
+		return thema.EnableAllDrafts()
+	}
+	return h.cfg.drafts(r)
+}
+
+// invalidDataError describes the failure of request data to validate, listing
+// each of the issues in err.
+func invalidDataError(err error) Error {
+	e := Error{Status: http.StatusUnprocessableEntity, Message: err.Error()}
+	for _, vi := range thema.ValidationIssues(err) {
+		e.Issues = append(e.Issues, Issue{
+			Path:    strings.Join(vi.Path, "."),
+			Code:    vi.Code.String(),
+			Message: vi.Message,
+		})
+	}
+	return e
+}
@@ -0,0 +1,467 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+	examples: simple: title: "hi"
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		desc?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+
+	h := NewHandler(set)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/lineages").Code)
+	assert.Equal(t, http.StatusNotFound, get("/other").Code)
+
+	require.NoError(t, h.Warm(context.Background()))
+	assert.Equal(t, http.StatusOK, get("/readyz").Code)
+
+	w := get("/lineages")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var infos []LineageInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &infos))
+
+	sum, err := load.LineageChecksum(lin)
+	require.NoError(t, err)
+	assert.Equal(t, []LineageInfo{{
+		Name:     "served",
+		Versions: []thema.SyntacticVersion{{0, 0}, {0, 1}},
+		Latest:   thema.SV(0, 1),
+		Checksum: sum,
+	}}, infos)
+}
+
+func TestValidateTranslate(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		desc?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+	h := NewHandler(set)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := post("/validate?lineage=served", `{"title": "hi"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var vr ValidateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &vr))
+	assert.Equal(t, ValidateResponse{Lineage: "served", Version: thema.SV(0, 1)}, vr)
+
+	w = post("/validate?lineage=served&version=0.0", `{"title": "hi"}`)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &vr))
+	assert.Equal(t, thema.SV(0, 0), vr.Version)
+
+	w = post("/translate?lineage=served&version=0.0", `{"title": "hi"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tr TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tr))
+	assert.Equal(t, thema.SV(0, 0), tr.From)
+	assert.Equal(t, thema.SV(0, 1), tr.To)
+	assert.JSONEq(t, `{"title": "hi"}`, string(tr.Data))
+
+	for path, code := range map[string]int{
+		"/validate":                            http.StatusBadRequest,
+		"/validate?lineage=other":              http.StatusNotFound,
+		"/validate?lineage=served&version=x":   http.StatusBadRequest,
+		"/validate?lineage=served&version=2.0": http.StatusNotFound,
+		"/translate?lineage=served&to=0.9":     http.StatusNotFound,
+	} {
+		assert.Equal(t, code, post(path, `{"title": "hi"}`).Code, path)
+	}
+
+	w = post("/validate?lineage=served", `{"title": 42}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var er struct{ Error Error }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &er))
+	assert.Equal(t, http.StatusUnprocessableEntity, er.Error.Status)
+	assert.NotEmpty(t, er.Error.Message)
+	require.NotEmpty(t, er.Error.Issues)
+	assert.Equal(t, "title", er.Error.Issues[0].Path)
+	assert.Equal(t, "KindConflict", er.Error.Issues[0].Code)
+
+	assert.Equal(t, http.StatusBadRequest, post("/validate?lineage=served", `{`).Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validate?lineage=served", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	postType := func(ct, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/validate?lineage=served", strings.NewReader(body))
+		r.Header.Set("Content-Type", ct)
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for ct, body := range map[string]string{
+		"application/json; charset=utf-8": `{"title": "hi", "desc": "d"}`,
+		"application/yaml":                "title: hi\ndesc: d\n",
+		"application/toml":                "title = \"hi\"\ndesc = \"d\"\n",
+		"application/cbor":                "\xa2\x65title\x62hi\x64desc\x61d",
+	} {
+		w := postType(ct, body)
+		require.Equal(t, http.StatusOK, w.Code, "%s: %s", ct, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &vr))
+		assert.Equal(t, thema.SV(0, 1), vr.Version, ct)
+	}
+	assert.Equal(t, http.StatusUnsupportedMediaType, postType("text/csv", "title\nhi").Code)
+}
+
+func TestLimits(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+	h := NewHandler(set, MaxBodyBytes(32), RateLimit(0.001, 2))
+
+	post := func(addr, body string, chunked bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/validate?lineage=served", strings.NewReader(body))
+		r.RemoteAddr = addr
+		if chunked {
+			r.ContentLength = -1
+		}
+		h.ServeHTTP(w, r)
+		return w
+	}
+	errOf := func(w *httptest.ResponseRecorder) Error {
+		var er struct{ Error Error }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &er))
+		return er.Error
+	}
+
+	big := `{"title": "` + strings.Repeat("x", 32) + `"}`
+	w := post("10.0.0.1:1234", big, false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, errOf(w).Status)
+	w = post("10.0.0.1:1234", big, true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Two requests were allowed by the burst, so the third is limited
+	w = post("10.0.0.1:1234", `{"title": "hi"}`, false)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	e := errOf(w)
+	assert.Equal(t, http.StatusTooManyRequests, e.Status)
+	assert.Positive(t, e.RetryAfter)
+	assert.Equal(t, strconv.Itoa(e.RetryAfter), w.Header().Get("Retry-After"))
+
+	// Other clients, and other endpoints, are unaffected
+	assert.Equal(t, http.StatusOK, post("10.0.0.2:1234", `{"title": "hi"}`, false).Code)
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTranslateNegotiation(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		desc?: string
+	}
+}, {
+	version: [1, 0]
+	schema: name: string
+}]
+lenses: [{
+	to: [0, 0]
+	from: [0, 1]
+	input: _
+	result: title: input.title
+	lacunas: []
+}, {
+	to: [0, 1]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 1]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+	h := NewHandler(set)
+
+	translate := func(accept, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/translate?lineage=served&version=1.0"+query, strings.NewReader(`{"name": "hi"}`))
+		if accept != "" {
+			r.Header.Set(AcceptVersionHeader, accept)
+		}
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for accept, want := range map[string]thema.SyntacticVersion{
+		"":         thema.SV(1, 0),
+		"0.0":      thema.SV(0, 0),
+		"0.5":      thema.SV(0, 1),
+		"0.1, 1.2": thema.SV(1, 0),
+	} {
+		w := translate(accept, "")
+		require.Equal(t, http.StatusOK, w.Code, "%s: %s", accept, w.Body.String())
+		var tr TranslateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tr))
+		assert.Equal(t, want, tr.To, accept)
+		assert.Equal(t, want.String(), w.Header().Get(VersionHeader), accept)
+	}
+
+	// An explicit target takes precedence
+	w := translate("0.0", "&to=0.1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "0.1", w.Header().Get(VersionHeader))
+
+	assert.Equal(t, http.StatusNotAcceptable, translate("2.0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, translate("latest", "").Code)
+}
+
+func TestDraftAccess(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: title: string
+}, {
+	version: [1, 0]
+	maturity: "experimental"
+	schema: name: string
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: title: input.name
+	lacunas: []
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: name: input.title
+	lacunas: []
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+	h := NewHandler(set, DraftAccess(DraftsFromHeader()))
+
+	post := func(path, drafts, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if drafts != "" {
+			r.Header.Set(DraftHeader, drafts)
+		}
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// Without opting in, the draft is invisible
+	w := post("/translate?lineage=served", "", `{"title": "hi"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "0.0", w.Header().Get(VersionHeader))
+	assert.Equal(t, http.StatusUnprocessableEntity, post("/validate?lineage=served", "", `{"name": "hi"}`).Code)
+	assert.Equal(t, http.StatusNotFound, post("/validate?lineage=served&version=1.0", "", `{"name": "hi"}`).Code)
+	assert.Equal(t, http.StatusNotFound, post("/translate?lineage=served&to=1.0", "", `{"title": "hi"}`).Code)
+	assert.Equal(t, http.StatusNotFound, post("/translate?lineage=served&to=1.0", "bogus", `{"title": "hi"}`).Code)
+
+	// Opted in, it is the default target, and data in its shape validates
+	for _, drafts := range []string{"1.0", "*"} {
+		w = post("/translate?lineage=served", drafts, `{"title": "hi"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "1.0", w.Header().Get(VersionHeader))
+		assert.Equal(t, http.StatusOK, post("/validate?lineage=served", drafts, `{"name": "hi"}`).Code)
+	}
+
+	// Negotiation does not choose drafts that are not enabled
+	r := httptest.NewRequest(http.MethodPost, "/translate?lineage=served", strings.NewReader(`{"title": "hi"}`))
+	r.Header.Set(AcceptVersionHeader, "1.0")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestTranslateOptions(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		kind:  *"a" | "b"
+	}
+}, {
+	version: [0, 1]
+	schema: {
+		title: string
+		kind:  *"a" | "b"
+		desc?: string
+	}
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+
+	translate := func(h *Handler, body string) TranslateResponse {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/translate?lineage=served&version=0.0", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var tr TranslateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tr))
+		return tr
+	}
+
+	tr := translate(NewHandler(set, TargetVersion("served", thema.SV(0, 0)), Defaults(DefaultsApply)), `{"title": "hi"}`)
+	assert.Equal(t, thema.SV(0, 0), tr.To)
+	assert.JSONEq(t, `{"title": "hi", "kind": "a"}`, string(tr.Data))
+
+	tr = translate(NewHandler(set, Defaults(DefaultsTrim)), `{"title": "hi", "kind": "a"}`)
+	assert.Equal(t, thema.SV(0, 1), tr.To)
+	assert.JSONEq(t, `{"title": "hi"}`, string(tr.Data))
+
+	w := httptest.NewRecorder()
+	NewHandler(set, TargetVersion("served", thema.SV(3, 0))).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/translate?lineage=served", strings.NewReader(`{"title": "hi"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for s, mode := range map[string]DefaultsMode{"keep": DefaultsKeep, "apply": DefaultsApply, "trim": DefaultsTrim} {
+		m, err := ParseDefaultsMode(s)
+		require.NoError(t, err)
+		assert.Equal(t, mode, m)
+	}
+	_, err = ParseDefaultsMode("other")
+	assert.Error(t, err)
+}
+
+func TestTranslateLacunaPolicy(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := thema.BindLineage(rt.Context().CompileString(`
+name: "served"
+schemas: [{
+	version: [0, 0]
+	schema: a: string
+}, {
+	version: [1, 0]
+	schema: {a: string, b: string}
+}]
+lenses: [{
+	to: [0, 0]
+	from: [1, 0]
+	input: _
+	result: a: input.a
+	lacunas: [{
+		sourceFields: [{path: "b", value: input.b}]
+		message: "b was dropped"
+		type: {name: "DroppedField", id: 2}
+	}]
+}, {
+	to: [1, 0]
+	from: [0, 0]
+	input: _
+	result: {a: input.a, b: "TODO"}
+	lacunas: [{
+		targetFields: [{path: "b", value: result.b}]
+		message: "b is a placeholder"
+		type: {name: "Placeholder", id: 1}
+	}]
+}]
+`), rt)
+	require.NoError(t, err)
+	set, err := thema.NewLineageSet(lin)
+	require.NoError(t, err)
+
+	h := NewHandler(set, LacunaPolicy(&thema.LacunaPolicy{
+		Rules:   []thema.LacunaRule{{Severity: thema.LacunaCritical, Action: thema.LacunaFail}},
+		Default: thema.LacunaWarn,
+	}))
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := post("/translate?lineage=served&to=0.0", `{"a": "x", "b": "y"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tr TranslateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tr))
+	assert.Len(t, tr.Lacunas, 1)
+	require.Len(t, tr.Warnings, 1)
+	assert.Equal(t, thema.LacunaDroppedField, tr.Warnings[0].Type)
+
+	w = post("/translate?lineage=served&to=1.0", `{"a": "x"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "b is a placeholder")
+}
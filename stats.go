@@ -0,0 +1,139 @@
+package thema
+
+import (
+	"sort"
+
+	"cuelang.org/go/cue"
+)
+
+// SchemaStats reports metrics describing the size and complexity of a single
+// [Schema], as returned from [Schema.Stats].
+type SchemaStats struct {
+	// Fields is the total number of fields in the schema, including optional
+	// fields and fields within nested structs and list elements.
+	Fields int `json:"fields"`
+
+	// MaxDepth is the deepest level of struct or list nesting in the schema. A
+	// schema containing only scalar fields has a depth of one.
+	MaxDepth int `json:"maxDepth"`
+
+	// Disjunctions is the number of disjunctions (including those introduced
+	// by defaults) appearing in the schema.
+	Disjunctions int `json:"disjunctions"`
+
+	// References is the set of distinct references made from within the
+	// schema, such as to definitions. The length of this slice is the schema's
+	// reference fan-out.
+	References []string `json:"references,omitempty"`
+}
+
+// LineageStats aggregates [SchemaStats] across all schemas in a [Lineage].
+type LineageStats struct {
+	// Schemas holds the stats for each schema, keyed by version string.
+	Schemas map[string]SchemaStats `json:"schemas"`
+
+	// MaxFields is the largest field count of any schema in the lineage.
+	MaxFields int `json:"maxFields"`
+
+	// MaxDepth is the deepest nesting of any schema in the lineage.
+	MaxDepth int `json:"maxDepth"`
+
+	// TotalDisjunctions is the sum of disjunction counts across all schemas.
+	TotalDisjunctions int `json:"totalDisjunctions"`
+
+	// MaxReferences is the largest reference fan-out of any schema in the lineage.
+	MaxReferences int `json:"maxReferences"`
+}
+
+// AggregateStats computes [LineageStats] for the provided lineage.
+func AggregateStats(lin Lineage) LineageStats {
+	isValidLineage(lin)
+
+	ls := LineageStats{
+		Schemas: make(map[string]SchemaStats),
+	}
+	for _, sch := range lin.All() {
+		ss := sch.Stats()
+		ls.Schemas[sch.Version().String()] = ss
+		if ss.Fields > ls.MaxFields {
+			ls.MaxFields = ss.Fields
+		}
+		if ss.MaxDepth > ls.MaxDepth {
+			ls.MaxDepth = ss.MaxDepth
+		}
+		if len(ss.References) > ls.MaxReferences {
+			ls.MaxReferences = len(ss.References)
+		}
+		ls.TotalDisjunctions += ss.Disjunctions
+	}
+	return ls
+}
+
+// Stats computes metrics describing the size and complexity of the schema.
+func (sch *schemaDef) Stats() SchemaStats {
+	sch.rt().rl()
+	defer sch.rt().ru()
+
+	w := &statsWalker{
+		refs:  make(map[string]bool),
+		stack: make(map[string]bool),
+	}
+	w.walk(sch.ref.LookupPath(pathSch), 0)
+
+	ss := w.stats
+	for ref := range w.refs {
+		ss.References = append(ss.References, ref)
+	}
+	sort.Strings(ss.References)
+	return ss
+}
+
+type statsWalker struct {
+	stats SchemaStats
+	refs  map[string]bool
+	// references currently being walked, to guard against cycles
+	stack map[string]bool
+}
+
+func (w *statsWalker) walk(v cue.Value, depth int) {
+	if depth > w.stats.MaxDepth {
+		w.stats.MaxDepth = depth
+	}
+
+	if _, path := v.ReferencePath(); len(path.Selectors()) > 0 {
+		ref := path.String()
+		w.refs[ref] = true
+		if w.stack[ref] {
+			return
+		}
+		w.stack[ref] = true
+		defer delete(w.stack, ref)
+	}
+
+	if op, branches := v.Expr(); op == cue.OrOp {
+		w.stats.Disjunctions++
+		for _, b := range branches {
+			w.walkChildren(b, depth)
+		}
+		return
+	}
+	w.walkChildren(v, depth)
+}
+
+func (w *statsWalker) walkChildren(v cue.Value, depth int) {
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		iter, err := v.Fields(cue.Optional(true))
+		if err != nil {
+			return
+		}
+		for iter.Next() {
+			w.stats.Fields++
+			w.walk(iter.Value(), depth+1)
+		}
+	case cue.ListKind:
+		if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); elem.Exists() {
+			w.walk(elem, depth+1)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package thema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var statslinstr = `name: "stats"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		#Inner: {
+			a: string
+			b?: int
+		}
+		title: string
+		kind: "a" | "b" | *"c"
+		inner: #Inner
+		list: [...{
+			x: int
+			y: #Inner
+		}]
+	}
+}]
+`
+
+func TestSchemaStats(t *testing.T) {
+	lin := testLin(statslinstr)
+
+	ss := lin.First().Stats()
+	// title, kind, inner, inner.{a, b}, list, list.x, list.y, list.y.{a, b}
+	assert.Equal(t, 10, ss.Fields)
+	// list -> elem struct -> y -> y.a
+	assert.Equal(t, 4, ss.MaxDepth)
+	assert.Equal(t, 1, ss.Disjunctions)
+	assert.Len(t, ss.References, 1)
+
+	ls := AggregateStats(lin)
+	assert.Equal(t, ss, ls.Schemas["0.0"])
+	assert.Equal(t, 10, ls.MaxFields)
+	assert.Equal(t, 1, ls.TotalDisjunctions)
+}
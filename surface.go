@@ -1,11 +1,14 @@
 package thema
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 
 	terrors "github.com/grafana/thema/errors"
 	"github.com/grafana/thema/internal/envvars"
@@ -73,13 +76,48 @@ type Lineage interface {
 	// Otherwise, it is probably preferable to pick an explicit version number.
 	Latest() Schema
 
+	// LatestVersion returns the version of the newest schema in the lineage,
+	// as returned by Latest. The same caution applies.
+	LatestVersion() SyntacticVersion
+
 	// All returns all Schemas in the lineage. Thema requires that all valid lineages
 	// contain at least one schema, so this is guaranteed to contain at least one element.
 	All() []Schema
 
+	// Lenses returns the lenses declared in the lineage, in CUE or Go, so that
+	// tooling may inspect them without knowledge of the lineage's structure.
+	Lenses() []Lens
+
 	// Runtime returns the thema.Runtime instance with which this lineage was built.
 	Runtime() *Runtime
 
+	// Export returns a single, self-contained CUE file containing the complete
+	// lineage declaration, with all schemas and lenses inlined. The file may be
+	// loaded and passed to [BindLineage] without any CUE module resolution.
+	Export() (*ast.File, error)
+
+	// Warm precomposes the lenses translating instances of the schema with
+	// version from to the schema with version to, and caches the result for use
+	// by subsequent calls to [Instance.Translate]. It is intended to be called at
+	// startup for the version pairs translated most often, so that the cost of
+	// composing them is not paid by the first translation.
+	//
+	// The examples of the from schema are translated to warm the cache, and an
+	// error is returned if any fail. Cached lenses may be inspected with
+	// [PrecomposedLenses].
+	Warm(from, to SyntacticVersion) error
+
+	// Precompile eagerly evaluates every schema in the lineage, validates their
+	// examples, and warms the lenses between each pair of adjacent schemas and
+	// any shortcut lenses, as by [Lineage.Warm]. It is intended to be called at
+	// startup, so that a lineage with latent errors - those which CUE does not
+	// surface until first use - is discovered then, rather than by the first
+	// unlucky request.
+	//
+	// All errors found are returned together in a [*PrecompileError]. If ctx
+	// is done before precompiling completes, ctx.Err() is returned.
+	Precompile(ctx context.Context) error
+
 	// Lineage must be a private interface in order to ensure creation is only possible
 	// through BindLineage().
 	allVersions() versionList
@@ -92,6 +130,12 @@ type Lineage interface {
 type ImperativeLens struct {
 	To, From SyntacticVersion
 	Mapper   func(inst *Instance, to Schema) (*Instance, error)
+
+	// Cost is the relative cost of executing the lens, used to choose between
+	// alternative routes when it is provided to [ShortcutLenses]. Values less
+	// than one are treated as one, the cost of each step between adjacent
+	// schemas.
+	Cost int
 }
 
 // SchemaP returns the schema identified by the provided version. If no schema
@@ -109,7 +153,7 @@ func SchemaP(lin Lineage, v SyntacticVersion) Schema {
 // LatestVersion returns the version number of the newest (largest) schema
 // version in the provided lineage.
 //
-// Deprecated: call Lineage.Latest().Version().
+// Deprecated: call Lineage.LatestVersion().
 func LatestVersion(lin Lineage) SyntacticVersion {
 	return lin.Latest().Version()
 }
@@ -119,13 +163,13 @@ func LatestVersion(lin Lineage) SyntacticVersion {
 //
 // An error indicates the number of the provided sequence does not exist.
 //
-// Deprecated: call Schema.LatestInMajor().Version() after loading a schema in the desired major version.
+// Deprecated: call Schema.LatestVersionInMajor() after loading a schema in the desired major version.
 func LatestVersionInSequence(lin Lineage, seqv uint) (SyntacticVersion, error) {
 	sch, err := lin.Schema(SV(seqv, 0))
 	if err != nil {
 		return SyntacticVersion{}, err
 	}
-	return sch.LatestInMajor().Version(), nil
+	return sch.LatestVersionInMajor(), nil
 }
 
 // A LineageFactory returns a [Lineage], which is immutably bound to a single
@@ -170,6 +214,12 @@ type bindOption func(c *bindConfig)
 type bindConfig struct {
 	skipbuggychecks bool
 	implens         []ImperativeLens
+	shortcuts       []ImperativeLens
+	valcachesize    int
+	budget          Budget
+	evaltimeout     time.Duration
+	releases        *ReleaseManifest
+	validators      map[string]Validator
 }
 
 // SkipBuggyChecks indicates that [BindLineage] should skip validation checks
@@ -239,6 +289,21 @@ type Schema interface {
 	// TODO should this instead be interface{} (ugh ugh wish Go had tagged unions) like FillPath?
 	Validate(data cue.Value) (*Instance, error)
 
+	// ValidateLenient performs validation identically to [Schema.Validate],
+	// except that failures of constraints the schema author has marked as
+	// advisory are returned as warnings, rather than causing validation to
+	// fail.
+	//
+	// Constraints are marked advisory by placing a @thema(advisory) attribute on
+	// the field to which they apply. The attribute covers the entire value of the
+	// field, including any nested fields.
+	//
+	// Lenient validation is intended for gradually rolling out tightened
+	// constraints against real-world data: the new constraints may first be
+	// introduced as advisory, their warnings observed, and the attribute
+	// removed once data conforms.
+	ValidateLenient(data cue.Value) (*Instance, []ValidationIssue, error)
+
 	// Successor returns the next schema in the lineage, or nil if it is the last schema.
 	Successor() Schema
 
@@ -250,6 +315,10 @@ type Schema interface {
 	// will return itself.
 	LatestInMajor() Schema
 
+	// LatestVersionInMajor returns the version of the schema returned by
+	// LatestInMajor.
+	LatestVersionInMajor() SyntacticVersion
+
 	// Version returns the schema's version number.
 	Version() SyntacticVersion
 
@@ -260,6 +329,30 @@ type Schema interface {
 	// lineage. The string key is the name given to the example.
 	Examples() map[string]*Instance
 
+	// Maturity returns the declared maturity of the schema: experimental, beta,
+	// stable, or deprecated. Schemas that do not declare a maturity are stable.
+	Maturity() Maturity
+
+	// ReleaseNotes returns the notes declared by the lineage author on what
+	// changed in the schema relative to its predecessor, in the order
+	// declared. See [WriteChangelog] to render them for a whole lineage.
+	ReleaseNotes() []ChangeNote
+
+	// Attributes returns the CUE attributes declared on the field at the provided
+	// path in the schema, e.g. "spec.title". See [IndexAttributes] to find
+	// attributes across all schemas in a lineage.
+	Attributes(path string) ([]Attribute, error)
+
+	// Stats returns metrics describing the size and complexity of the schema,
+	// such as its field count and nesting depth. Use [AggregateStats] to
+	// compute stats across a whole lineage.
+	Stats() SchemaStats
+
+	// Export flattens the schema into a standalone CUE file containing a
+	// single closed definition, consumable by tools that understand CUE but
+	// not Thema. See [Lineage.Export] to export the whole lineage.
+	Export() (*ast.File, error)
+
 	// Schema must be a private interface in order to ensure all instances fully
 	// conform to Thema invariants.
 	_schema()
@@ -2,7 +2,10 @@ package thema
 
 import (
 	"bytes"
+	goerrors "errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"cuelang.org/go/cue"
@@ -79,6 +82,130 @@ func (e *twosidederr) Unwrap() error {
 	return terrors.ErrInvalidData
 }
 
+// unclassifiederr is a validation failure for which no more specific
+// description is available.
+type unclassifiederr struct {
+	schpos, datapos []token.Pos
+	code            terrors.ValidationCode
+	coords          coords
+	msg             string
+}
+
+func (e *unclassifiederr) Error() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:\n\t%s", e.coords, e.msg)
+	for _, pos := range append(e.schpos, e.datapos...) {
+		fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
+	}
+	return buf.String()
+}
+
+func (e *unclassifiederr) Unwrap() error {
+	return terrors.ErrInvalidData
+}
+
+// errorAttr is the name of the CUE attribute that replaces the validation
+// errors produced by a schema field with a message written by the schema's
+// author, attached to a designated field. It is intended for constraints
+// between fields, whose errors CUE otherwise reports in terms of the
+// constraint's own expression, e.g.:
+//
+//	min: int
+//	max: int & >=min @error(msg="max must not be less than min")
+//	_ordered: true & (max > min) @error(path=max, msg="max must exceed min")
+//
+// The path argument is a dot-separated path relative to the struct containing
+// the annotated field, and defaults to the annotated field itself. Hidden
+// fields, such as _ordered above, are not part of the data, and so are well
+// suited to declaring invariants that belong to no single field.
+const errorAttr = "error"
+
+// annotatederr is a validation failure described by an @error attribute on
+// the schema field at which it occurred.
+type annotatederr struct {
+	schpos, datapos []token.Pos
+	code            terrors.ValidationCode
+	coords          coords
+	msg             string
+}
+
+func (e *annotatederr) Error() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:\n\t%s", e.coords, e.msg)
+	for _, pos := range e.schpos {
+		fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
+	}
+	return buf.String()
+}
+
+func (e *annotatederr) Unwrap() error {
+	return terrors.ErrInvalidData
+}
+
+// annotateErrs replaces each error in errs that occurred at a schema field
+// with an @error attribute by an annotatederr, dropping duplicates that
+// result from CUE reporting a single constraint more than once.
+func annotateErrs(errs validationFailure, sch Schema) validationFailure {
+	def := sch.Underlying().LookupPath(pathSchDef)
+	seen := make(map[string]bool)
+	out := errs[:0]
+	for _, err := range errs {
+		var x coords
+		var schpos, datapos []token.Pos
+		var code terrors.ValidationCode
+		switch e := err.(type) {
+		case *onesidederr:
+			x, schpos, datapos, code = e.coords, e.schpos, e.datapos, e.code
+		case *twosidederr:
+			x, schpos, datapos, code = e.coords, e.schpos, e.datapos, e.code
+		case *unclassifiederr:
+			x, schpos, datapos, code = e.coords, e.schpos, e.datapos, e.code
+		default:
+			out = append(out, err)
+			continue
+		}
+
+		// An invariant with a missing operand is incomplete rather than
+		// violated, and the missing field is reported in its own right
+		field, _, has := lookupField(def, x.fieldpath)
+		var attr *Attribute
+		if has && code != terrors.MissingField {
+			for _, a := range valueAttrs(field) {
+				if _, hasmsg := a.Lookup("msg"); a.Name == errorAttr && hasmsg {
+					a := a
+					attr = &a
+					break
+				}
+			}
+		}
+		if attr == nil {
+			out = append(out, err)
+			continue
+		}
+
+		msg, _ := attr.Lookup("msg")
+		if path, has := attr.Lookup("path"); has && len(x.fieldpath) > 0 {
+			parent := x.fieldpath[: len(x.fieldpath)-1 : len(x.fieldpath)-1]
+			x.fieldpath = append(parent, strings.Split(path, ".")...)
+		}
+		key := strings.Join(x.fieldpath, ".") + "\x00" + msg
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, &annotatederr{
+			schpos:  schpos,
+			datapos: datapos,
+			code:    code,
+			coords:  x,
+			msg:     msg,
+		})
+	}
+	return out
+}
+
 // TODO differentiate this once we have generic composition to support trimming out irrelevant disj branches
 type emptydisjunction struct {
 	schpos, datapos []token.Pos
@@ -106,6 +233,103 @@ func (vf validationFailure) Error() string {
 	return buf.String()
 }
 
+// sort orders the errors by the path at which they occurred, then by message.
+// CUE reports errors in an order that varies between runs, which must not leak
+// into output that is compared or logged.
+func (vf validationFailure) sort() {
+	sort.SliceStable(vf, func(i, j int) bool {
+		return toValidationIssue(vf[i]).less(toValidationIssue(vf[j]))
+	})
+}
+
+func sortIssues(issues []ValidationIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].less(issues[j])
+	})
+}
+
+// less orders issues by path, then by message. List indices within paths are
+// compared numerically, so that [2] precedes [10].
+func (vi ValidationIssue) less(o ValidationIssue) bool {
+	for k := 0; k < len(vi.Path) && k < len(o.Path); k++ {
+		a, b := vi.Path[k], o.Path[k]
+		if a == b {
+			continue
+		}
+		ai, aerr := strconv.Atoi(a)
+		bi, berr := strconv.Atoi(b)
+		if aerr == nil && berr == nil {
+			return ai < bi
+		}
+		return a < b
+	}
+	if len(vi.Path) != len(o.Path) {
+		return len(vi.Path) < len(o.Path)
+	}
+	return vi.Message < o.Message
+}
+
+// A ValidationIssue describes a single failure of some data to conform to a
+// constraint in a schema.
+type ValidationIssue struct {
+	// Path is the path to the field in the data at which the failure occurred.
+	Path []string
+
+	// Code classifies the failure.
+	Code terrors.ValidationCode
+
+	// Message is a human-readable description of the failure.
+	Message string
+}
+
+// ValidationIssues returns the individual issues described by an error returned
+// from [Schema.Validate] or [SearchAndValidate]. For the latter, the issues from
+// all candidate schemas are returned, in the order the candidates were checked.
+//
+// Errors that do not describe validation failures are returned as a single
+// issue with no path or code. A nil error returns no issues.
+func ValidationIssues(err error) []ValidationIssue {
+	if err == nil {
+		return nil
+	}
+
+	var vf validationFailure
+	var nm *noMatchError
+	switch {
+	case goerrors.As(err, &vf):
+		issues := make([]ValidationIssue, 0, len(vf))
+		for _, e := range vf {
+			issues = append(issues, toValidationIssue(e))
+		}
+		return issues
+	case goerrors.As(err, &nm):
+		var issues []ValidationIssue
+		for _, c := range nm.cands {
+			issues = append(issues, ValidationIssues(c.Err)...)
+		}
+		return issues
+	}
+	return []ValidationIssue{toValidationIssue(err)}
+}
+
+func toValidationIssue(err error) ValidationIssue {
+	switch x := err.(type) {
+	case *onesidederr:
+		return ValidationIssue{Path: x.coords.fieldpath, Code: x.code, Message: x.Error()}
+	case *twosidederr:
+		return ValidationIssue{Path: x.coords.fieldpath, Code: x.code, Message: x.Error()}
+	case *unclassifiederr:
+		return ValidationIssue{Path: x.coords.fieldpath, Code: x.code, Message: x.Error()}
+	case *validatorerr:
+		return ValidationIssue{Path: x.coords.fieldpath, Code: terrors.OutOfBounds, Message: x.Error()}
+	case *annotatederr:
+		// The author's message is written for end users, so stands alone
+		return ValidationIssue{Path: x.coords.fieldpath, Code: x.code, Message: x.msg}
+	default:
+		return ValidationIssue{Message: err.Error()}
+	}
+}
+
 // HERE BE DRAGONS, BRING A SWORD.
 func mungeValidateErr(err error, sch Schema) error {
 	_, is := err.(errors.Error)
@@ -210,7 +434,21 @@ func mungeValidateErr(err error, sch Schema) error {
 			errs = append(errs, err)
 			continue
 		}
+
+		// Closedness errors may carry no values to describe, but must not be
+		// dropped, as they may be the only reason validation failed
+		if strings.Contains(msg, "not allowed") {
+			errs = append(errs, &unclassifiederr{
+				schpos:  schpos,
+				datapos: datapos,
+				code:    terrors.ExcessField,
+				coords:  x,
+				msg:     fmt.Sprintf(msg, vals...),
+			})
+		}
 	}
+	errs = annotateErrs(errs, sch)
+	errs.sort()
 	return errs
 }
 
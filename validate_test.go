@@ -10,6 +10,7 @@ import (
 	"cuelang.org/go/cue/cuecontext"
 	cjson "cuelang.org/go/encoding/json"
 	"cuelang.org/go/pkg/strings"
+	terrors "github.com/grafana/thema/errors"
 	"github.com/grafana/thema/internal/txtartest/vanilla"
 	"github.com/stretchr/testify/require"
 )
@@ -69,3 +70,112 @@ func decodeData(rt *Runtime, inputJSON string) (cue.Value, error) {
 
 	return ctx.BuildExpr(expr), nil
 }
+
+func TestValidationIssues(t *testing.T) {
+	lin := testLin(`
+name: "issues"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		title: string
+		count: int & <10
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+
+	require.Nil(t, ValidationIssues(nil))
+
+	_, err := lin.Latest().Validate(ctx.CompileString(`{ title: 42, count: 3 }`))
+	issues := ValidationIssues(err)
+	require.Len(t, issues, 1)
+	require.Equal(t, []string{"title"}, issues[0].Path)
+	require.Equal(t, terrors.KindConflict, issues[0].Code)
+	require.Equal(t, "KindConflict", issues[0].Code.String())
+
+	_, _, err = SearchAndValidate(lin, ctx.CompileString(`{ title: "hi", count: 30 }`))
+	issues = ValidationIssues(err)
+	require.Len(t, issues, 1)
+	require.Equal(t, []string{"count"}, issues[0].Path)
+	require.Equal(t, terrors.OutOfBounds, issues[0].Code)
+
+	issues = ValidationIssues(errors.New("other"))
+	require.Equal(t, []ValidationIssue{{Message: "other"}}, issues)
+}
+
+func TestValidationIssuesSorted(t *testing.T) {
+	lin := testLin(`
+name: "sorted"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		zeta:  int & <10
+		alpha: string
+		items: [...{n: int & <10}]
+		mid: {b: int & <10, a: int & <10}
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+
+	data := `{
+	zeta: 30,
+	alpha: 1,
+	items: [{n: 1}, {n: 1}, {n: 20}, {n: 1}, {n: 1}, {n: 1}, {n: 1}, {n: 1}, {n: 1}, {n: 1}, {n: 10}],
+	mid: {b: 11, a: 12},
+}`
+	var want [][]string
+	for i := 0; i < 5; i++ {
+		_, err := lin.Latest().Validate(ctx.CompileString(data))
+		var paths [][]string
+		for _, issue := range ValidationIssues(err) {
+			paths = append(paths, issue.Path)
+		}
+		if want == nil {
+			want = paths
+			require.Equal(t, [][]string{{"alpha"}, {"items", "2", "n"}, {"items", "10", "n"}, {"mid", "a"}, {"mid", "b"}, {"zeta"}}, paths)
+			continue
+		}
+		require.Equal(t, want, paths)
+	}
+}
+
+func TestValidationErrorAttribute(t *testing.T) {
+	lin := testLin(`
+name: "invariants"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		min: int
+		max: int & >=min @error(msg="max must not be less than min")
+		range: {
+			lo: int
+			hi: int
+			_ordered: true & (hi > lo) @error(path=hi, msg="hi must exceed lo")
+		}
+		count: int & <10
+	}
+}]
+`)
+	ctx := lin.Runtime().Context()
+
+	_, err := lin.Latest().Validate(ctx.CompileString(`{ min: 5, max: 3, range: { lo: 1, hi: 2 }, count: 1 }`))
+	require.True(t, errors.Is(err, terrors.ErrInvalidData))
+	require.Equal(t, []ValidationIssue{{Path: []string{"max"}, Code: terrors.OutOfBounds, Message: "max must not be less than min"}}, ValidationIssues(err))
+	require.Contains(t, err.Error(), "<invariants@v0.0>.max: validation failed, data is not an instance:\n\tmax must not be less than min")
+
+	_, err = lin.Latest().Validate(ctx.CompileString(`{ min: 1, max: 3, range: { lo: 4, hi: 2 }, count: 1 }`))
+	require.Equal(t, []ValidationIssue{{Path: []string{"range", "hi"}, Code: terrors.OutOfBounds, Message: "hi must exceed lo"}}, ValidationIssues(err))
+
+	// Fields without the attribute are unaffected
+	_, err = lin.Latest().Validate(ctx.CompileString(`{ min: 1, max: 3, range: { lo: 1, hi: 2 }, count: 30 }`))
+	issues := ValidationIssues(err)
+	require.Len(t, issues, 1)
+	require.Equal(t, []string{"count"}, issues[0].Path)
+	require.NotEqual(t, "max must not be less than min", issues[0].Message)
+
+	attrs, err := lin.Latest().Attributes("range._ordered")
+	require.NoError(t, err)
+	require.Len(t, attrs, 1)
+	require.Equal(t, "error", attrs[0].Name)
+}
@@ -0,0 +1,212 @@
+package thema
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/cockroachdb/errors"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+// validateAttr is the name of the CUE attribute that subjects a schema field to
+// checks written in Go, for constraints that CUE cannot express. Its arguments
+// name one or more [Validator]s, e.g.:
+//
+//	host: string @validate(hostname)
+const validateAttr = "validate"
+
+// A Validator checks a single value in data that has already been validated
+// against a schema, for fields annotated with a @validate(<name>) attribute.
+// It is passed the field's value, decoded as by [cue.Value.Decode] into an
+// any, and returns an error describing why the value is invalid, if it is.
+type Validator func(value any) error
+
+// CustomValidator registers a [Validator] under the provided name, making it
+// available to @validate(<name>) attributes in the schemas of the bound
+// [Lineage]. It replaces any built-in Validator of the same name.
+//
+// The following Validators are built in:
+//
+//   - hostname: a string that is a valid DNS hostname, per RFC 1123.
+//   - duration: a string accepted by [time.ParseDuration].
+//   - url: a string that is an absolute URL, with a scheme and host.
+//
+// Validators run after data is found to be valid against the CUE schema, so
+// are never passed values of the wrong kind. Failures are reported as for
+// CUE constraints, with the [terrors.OutOfBounds] code. BindLineage fails with
+// an error wrapping [terrors.ErrInvalidLineage] if any schema names a
+// Validator that is neither built in nor registered.
+func CustomValidator(name string, fn Validator) BindOption {
+	return func(c *bindConfig) {
+		if c.validators == nil {
+			c.validators = make(map[string]Validator)
+		}
+		c.validators[name] = fn
+	}
+}
+
+var builtinValidators = map[string]Validator{
+	"hostname": validateHostname,
+	"duration": validateDuration,
+	"url":      validateURL,
+}
+
+func validateHostname(value any) error {
+	s, is := value.(string)
+	if !is {
+		return errors.Newf("hostname must be a string, not %T", value)
+	}
+	host := strings.TrimSuffix(s, ".")
+	if host == "" || len(host) > 253 {
+		return errors.Newf("%q is not a valid hostname: must be between 1 and 253 characters", s)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 {
+			return errors.Newf("%q is not a valid hostname: labels must be between 1 and 63 characters", s)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.Newf("%q is not a valid hostname: labels may not begin or end with a hyphen", s)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return errors.Newf("%q is not a valid hostname: invalid character %q", s, r)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDuration(value any) error {
+	s, is := value.(string)
+	if !is {
+		return errors.Newf("duration must be a string, not %T", value)
+	}
+	_, err := time.ParseDuration(s)
+	return err
+}
+
+func validateURL(value any) error {
+	s, is := value.(string)
+	if !is {
+		return errors.Newf("url must be a string, not %T", value)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return errors.Newf("%q is not an absolute URL", s)
+	}
+	return nil
+}
+
+// A fieldValidator is a [Validator] applied to the field at path, in the form
+// accepted by [Schema.Attributes].
+type fieldValidator struct {
+	path []string
+	name string
+	fn   Validator
+}
+
+// checkValidators resolves the Validators named by @validate attributes in
+// each schema.
+func (ml *maybeLineage) checkValidators() error {
+	for _, sch := range ml.schlist {
+		var err error
+		walkAttrs(sch.def, nil, func(path []string, attrs []Attribute) {
+			for _, a := range attrs {
+				if a.Name != validateAttr || err != nil {
+					continue
+				}
+				for _, arg := range a.Args {
+					fn, has := ml.cfg.validators[arg.Key]
+					if !has {
+						fn, has = builtinValidators[arg.Key]
+					}
+					if !has {
+						err = errors.Mark(errors.Newf("schema %s: field %s: no validator named %q", sch.v, strings.Join(path, "."), arg.Key), terrors.ErrInvalidLineage)
+						return
+					}
+					sch.validators = append(sch.validators, fieldValidator{path: path, name: arg.Key, fn: fn})
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runValidators applies the schema's Validators to data, which must already
+// be valid against the schema.
+func (sch *schemaDef) runValidators(data cue.Value) validationFailure {
+	if len(sch.validators) == 0 {
+		return nil
+	}
+	var x any
+	if err := data.Decode(&x); err != nil {
+		return validationFailure{err}
+	}
+
+	var errs validationFailure
+	for _, fv := range sch.validators {
+		fv := fv
+		eachAtPath(x, fv.path, nil, func(fieldpath []string, value any) {
+			if err := fv.fn(value); err != nil {
+				errs = append(errs, &validatorerr{
+					coords: coords{sch: sch, fieldpath: fieldpath},
+					name:   fv.name,
+					err:    err,
+				})
+			}
+		})
+	}
+	errs.sort()
+	return errs
+}
+
+// eachAtPath calls fn with each value in the decoded data at the provided path,
+// in which a path element of "*" matches all elements of a list, along with
+// the concrete path to the value.
+func eachAtPath(data any, path, fieldpath []string, fn func([]string, any)) {
+	if len(path) == 0 {
+		fn(fieldpath, data)
+		return
+	}
+	switch x := data.(type) {
+	case map[string]any:
+		if v, has := x[path[0]]; has {
+			eachAtPath(v, path[1:], append(fieldpath[:len(fieldpath):len(fieldpath)], path[0]), fn)
+		}
+	case []any:
+		if path[0] == "*" {
+			for i, v := range x {
+				eachAtPath(v, path[1:], append(fieldpath[:len(fieldpath):len(fieldpath)], strconv.Itoa(i)), fn)
+			}
+		}
+	}
+}
+
+// validatorerr is a validation failure reported by a [Validator].
+type validatorerr struct {
+	coords coords
+	name   string
+	err    error
+}
+
+func (e *validatorerr) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:\n\tvalidator %s: %s", e.coords, e.name, e.err)
+	return buf.String()
+}
+
+func (e *validatorerr) Unwrap() error {
+	return terrors.ErrInvalidData
+}
@@ -0,0 +1,77 @@
+package thema
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestCustomValidator(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	linstr := `name: "validators"
+schemas: [{
+	version: [0, 0]
+	schema: {
+		host:      string @validate(hostname)
+		timeout?:  string @validate(duration)
+		homepage?: string @validate(url)
+		code?:     string @validate(even) @thema(advisory)
+		targets?: [...{addr: string @validate(hostname)}]
+	}
+}]
+`
+	even := func(value any) error {
+		if len(value.(string))%2 != 0 {
+			return errors.New("must have an even number of characters")
+		}
+		return nil
+	}
+
+	_, err := BindLineage(rt.Context().CompileString(linstr), rt)
+	assert.True(t, errors.Is(err, terrors.ErrInvalidLineage), "unknown validators must fail binding")
+
+	lin, err := BindLineage(rt.Context().CompileString(linstr), rt, CustomValidator("even", even))
+	require.NoError(t, err)
+	validate := func(data string) error {
+		_, err := lin.First().Validate(rt.Context().CompileString(data))
+		return err
+	}
+
+	assert.NoError(t, validate(`{host: "grafana.com.", timeout: "1m30s", homepage: "https://grafana.com/docs", code: "ab", targets: [{addr: "a-1"}]}`))
+
+	for data, path := range map[string][]string{
+		`{host: "-bad"}`:                                   {"host"},
+		`{host: "a..b"}`:                                   {"host"},
+		`{host: "a_b"}`:                                    {"host"},
+		`{host: "ok", timeout: "soon"}`:                    {"timeout"},
+		`{host: "ok", homepage: "/docs"}`:                  {"homepage"},
+		`{host: "ok", code: "abc"}`:                        {"code"},
+		`{host: "ok", targets: [{addr: "a"}, {addr: ""}]}`: {"targets", "1", "addr"},
+	} {
+		err := validate(data)
+		require.True(t, errors.Is(err, terrors.ErrInvalidData), data)
+		issues := ValidationIssues(err)
+		require.Len(t, issues, 1, data)
+		assert.Equal(t, path, issues[0].Path, data)
+		assert.Equal(t, terrors.OutOfBounds, issues[0].Code, data)
+	}
+
+	// Validators do not run on data that fails CUE validation
+	issues := ValidationIssues(validate(`{host: 42}`))
+	require.Len(t, issues, 1)
+	assert.Equal(t, terrors.KindConflict, issues[0].Code)
+
+	inst, warnings, err := lin.First().ValidateLenient(rt.Context().CompileString(`{host: "ok", code: "abc"}`))
+	require.NoError(t, err)
+	require.NotNil(t, inst)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, []string{"code"}, warnings[0].Path)
+
+	_, _, err = lin.First().ValidateLenient(rt.Context().CompileString(`{host: "-bad", code: "abc"}`))
+	assert.True(t, errors.Is(err, terrors.ErrInvalidData))
+}
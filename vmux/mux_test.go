@@ -248,6 +248,20 @@ func checkSpectrumAcrossMuxers[T thema.Assignee](t *testing.T, clin thema.Conver
 					Fatalf(t, "encoding of translated instance failed")
 				require.Equal(t, img.str, string(final))
 			})
+			t.Run("SearchMux", func(t *testing.T) {
+				mux := NewSearchMux(thema.SchemaP(clin, v), spec.codec)
+				inst, lac, cands, err := mux([]byte(spec.in.str))
+				handleLE(t, img, lac, err)
+
+				// The target schema is always checked first
+				require.NotEmpty(t, cands)
+				require.Equal(t, v, cands[0].Version)
+				if err == nil {
+					final := e(spec.codec.Encode(inst.Underlying())).
+						Fatalf(t, "encoding of translated instance failed")
+					require.Equal(t, img.str, string(final))
+				}
+			})
 			t.Run("ByteMux", func(t *testing.T) {
 				mux := NewByteMux(thema.SchemaP(clin, v), spec.codec)
 				final, lac, err := mux([]byte(spec.in.str))
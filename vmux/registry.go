@@ -0,0 +1,174 @@
+package vmux
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema/encoding/cbor"
+	"github.com/grafana/thema/encoding/msgpack"
+	"github.com/grafana/thema/encoding/pbstruct"
+	"github.com/grafana/thema/encoding/toml"
+)
+
+// ErrUnsupportedMediaType indicates that no [Decoder] is registered for a
+// media type or file extension.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// DecoderFunc adapts a function to the [Decoder] interface.
+type DecoderFunc func(ctx *cue.Context, b []byte) (cue.Value, error)
+
+// Decode calls f(ctx, b).
+func (f DecoderFunc) Decode(ctx *cue.Context, b []byte) (cue.Value, error) {
+	return f(ctx, b)
+}
+
+// A DecoderFactory returns a [Decoder]. The provided path identifies the input
+// in error output, as for [NewJSONCodec]; decoders for binary formats may
+// ignore it.
+type DecoderFactory func(path string) Decoder
+
+type registry struct {
+	mu     sync.RWMutex
+	byType map[string]DecoderFactory
+	byExt  map[string]string
+}
+
+var decoders = &registry{
+	byType: make(map[string]DecoderFactory),
+	byExt:  make(map[string]string),
+}
+
+func init() {
+	jsonf := func(path string) Decoder { return NewJSONCodec(path) }
+	yamlf := func(path string) Decoder { return NewYAMLCodec(path) }
+	fixed := func(f DecoderFunc) DecoderFactory {
+		return func(string) Decoder { return f }
+	}
+
+	RegisterDecoder("application/json", jsonf, ".json", ".ldjson")
+	RegisterDecoder("text/json", jsonf)
+	RegisterDecoder("application/yaml", yamlf, ".yaml", ".yml")
+	RegisterDecoder("application/x-yaml", yamlf)
+	RegisterDecoder("text/yaml", yamlf)
+	RegisterDecoder("application/toml", fixed(toml.Decode), ".toml")
+	RegisterDecoder("application/cbor", fixed(cbor.Decode), ".cbor")
+	RegisterDecoder("application/msgpack", fixed(msgpack.Decode), ".msgpack")
+	RegisterDecoder("application/x-msgpack", fixed(msgpack.Decode))
+	// google.protobuf.Struct in the binary wire format
+	RegisterDecoder("application/x-protobuf", fixed(pbstruct.Decode), ".pb", ".binpb")
+	RegisterDecoder("application/protobuf", fixed(pbstruct.Decode))
+}
+
+// RegisterDecoder registers a [Decoder] for the provided media type, such as
+// "application/json", replacing any already registered. The decoder is also
+// used for files with any of the provided extensions, e.g. ".json".
+//
+// Decoders are registered for JSON, YAML, TOML, CBOR, MessagePack, and
+// google.protobuf.Struct in the protobuf binary wire format. Register decoders
+// for further formats from an init function, before any lookups are made.
+//
+// RegisterDecoder panics if the media type cannot be parsed.
+func RegisterDecoder(mediaType string, fn DecoderFactory, exts ...string) {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		panic(fmt.Sprintf("vmux: invalid media type %q: %s", mediaType, err))
+	}
+	decoders.mu.Lock()
+	defer decoders.mu.Unlock()
+	decoders.byType[mt] = fn
+	for _, ext := range exts {
+		decoders.byExt[normalizeExt(ext)] = mt
+	}
+}
+
+// DecoderFor returns a [Decoder] for the provided content type, as in a
+// Content-Type header, e.g. "application/json; charset=utf-8". Parameters are
+// ignored. Types with a structured syntax suffix, such as
+// "application/vnd.grafana.dashboard+json", fall back to the decoder for the
+// suffix. The provided path is passed to the [DecoderFactory].
+//
+// An error wrapping [ErrUnsupportedMediaType] is returned if no decoder is
+// registered for the content type.
+func DecoderFor(contentType, path string) (Decoder, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %s", ErrUnsupportedMediaType, contentType, err)
+	}
+
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+	if fn, has := decoders.byType[mt]; has {
+		return fn(path), nil
+	}
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 {
+		if fn, has := decoders.byType["application/"+mt[i+1:]]; has {
+			return fn(path), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mt)
+}
+
+// DecoderForExt returns a [Decoder] for files with the provided extension,
+// with or without its leading dot, e.g. ".yaml" or "yaml". The provided path
+// is passed to the [DecoderFactory].
+//
+// An error wrapping [ErrUnsupportedMediaType] is returned if no decoder is
+// registered for the extension.
+func DecoderForExt(ext, path string) (Decoder, error) {
+	mt, has := MediaTypeByExt(ext)
+	if !has {
+		return nil, fmt.Errorf("%w: no decoder for extension %q", ErrUnsupportedMediaType, ext)
+	}
+	return DecoderFor(mt, path)
+}
+
+// MediaTypeByExt returns the media type of the decoder registered for files
+// with the provided extension, with or without its leading dot.
+func MediaTypeByExt(ext string) (string, bool) {
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+	mt, has := decoders.byExt[normalizeExt(ext)]
+	return mt, has
+}
+
+// DecoderForFile returns a [Decoder] for the file at the provided path, as
+// determined by its extension. Errors are as for [DecoderForExt].
+func DecoderForFile(path string) (Decoder, error) {
+	return DecoderForExt(filepath.Ext(path), path)
+}
+
+// MediaTypes returns the media types for which decoders are registered, in
+// lexical order.
+func MediaTypes() []string {
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+	mts := make([]string, 0, len(decoders.byType))
+	for mt := range decoders.byType {
+		mts = append(mts, mt)
+	}
+	sort.Strings(mts)
+	return mts
+}
+
+// Extensions returns the file extensions, without their leading dot, for which
+// decoders are registered, in lexical order.
+func Extensions() []string {
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+	exts := make([]string, 0, len(decoders.byExt))
+	for ext := range decoders.byExt {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
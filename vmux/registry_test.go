@@ -0,0 +1,75 @@
+package vmux
+
+import (
+	"errors"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoderRegistry(t *testing.T) {
+	ctx := cuecontext.New()
+	want := map[string]any{"name": "x", "n": 1}
+
+	for ct, data := range map[string]string{
+		"application/json":                       `{"name": "x", "n": 1}`,
+		"application/json; charset=utf-8":        `{"name": "x", "n": 1}`,
+		"application/vnd.grafana.dashboard+json": `{"name": "x", "n": 1}`,
+		"application/yaml":                       "name: x\nn: 1\n",
+		"text/yaml":                              "name: x\nn: 1\n",
+		"application/toml":                       "name = \"x\"\nn = 1\n",
+		"application/cbor":                       "\xa2\x64name\x61x\x61n\x01",
+		"application/msgpack":                    "\x82\xa4name\xa1x\xa1n\x01",
+		// Struct{fields: {"name": {string_value: "x"}, "n": {number_value: 1}}}
+		"application/x-protobuf": "\x0a\x0b\x0a\x04name\x12\x03\x1a\x01x" +
+			"\x0a\x0e\x0a\x01n\x12\x09\x11\x00\x00\x00\x00\x00\x00\xf0\x3f",
+	} {
+		t.Run(ct, func(t *testing.T) {
+			dec, err := DecoderFor(ct, "test")
+			require.NoError(t, err)
+			v, err := dec.Decode(ctx, []byte(data))
+			require.NoError(t, err)
+			var x map[string]any
+			require.NoError(t, v.Decode(&x))
+			assert.Equal(t, want, x)
+		})
+	}
+
+	_, err := DecoderFor("text/csv", "test")
+	assert.True(t, errors.Is(err, ErrUnsupportedMediaType))
+	_, err = DecoderFor("not a media type", "test")
+	assert.True(t, errors.Is(err, ErrUnsupportedMediaType))
+
+	dec, err := DecoderForFile("dir/data.YML")
+	require.NoError(t, err)
+	_, err = dec.Decode(ctx, []byte("a: 1"))
+	assert.NoError(t, err)
+	_, err = DecoderForExt("toml", "")
+	assert.NoError(t, err)
+	_, err = DecoderForFile("data.csv")
+	assert.True(t, errors.Is(err, ErrUnsupportedMediaType))
+
+	RegisterDecoder("text/x-test", func(path string) Decoder {
+		return DecoderFunc(func(ctx *cue.Context, b []byte) (cue.Value, error) {
+			return ctx.Encode(map[string]string{path: string(b)}), nil
+		})
+	}, ".xtest")
+	defer func() {
+		decoders.mu.Lock()
+		delete(decoders.byType, "text/x-test")
+		delete(decoders.byExt, "xtest")
+		decoders.mu.Unlock()
+	}()
+	assert.Contains(t, MediaTypes(), "text/x-test")
+	assert.Contains(t, Extensions(), "xtest")
+	dec, err = DecoderForFile("in.xtest")
+	require.NoError(t, err)
+	v, err := dec.Decode(ctx, []byte("hi"))
+	require.NoError(t, err)
+	s, err := v.LookupPath(cue.ParsePath(`"in.xtest"`)).String()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", s)
+}
@@ -0,0 +1,59 @@
+package vmux
+
+import (
+	"fmt"
+
+	"github.com/grafana/thema"
+)
+
+// SearchMux is a version multiplexer that, like [UntypedMux], maps a []byte
+// containing data at any schematized version to a [thema.Instance] at a
+// particular schematized version. It additionally returns a [thema.Candidate]
+// describing the outcome of validating the data against each schema it was
+// checked against, in the order checked.
+type SearchMux func(b []byte) (*thema.Instance, thema.TranslationLacunas, []thema.Candidate, error)
+
+// NewSearchMux creates a [SearchMux] from the provided [thema.Schema].
+//
+// When the returned mux func is called, it will:
+//
+//   - Decode the input []byte using the provided [Decoder], then
+//   - Pass the result to [thema.SearchAndValidate], considering only the provided [thema.Schema], then
+//   - If that fails, pass the result to [thema.SearchAndValidate], considering all other schemas in the lineage, then
+//   - Call [thema.Instance.Translate] on the result, to the version of the provided [thema.Schema], then
+//   - Return the resulting [thema.Instance], [thema.TranslationLacunas], candidates from all searches, and error
+//
+// The returned error may be from any of the above steps. No candidates are
+// returned if decoding fails.
+func NewSearchMux(sch thema.Schema, dec Decoder) SearchMux {
+	ctx := sch.Lineage().Underlying().Context()
+	// Prepare no-match error string and search options once for reuse
+	vstring := allvstr(sch)
+	only := thema.Between(sch.Version(), sch.Version())
+	others := thema.Satisfies(thema.MustParseVersionConstraint("!=" + sch.Version().String()))
+
+	return func(b []byte) (*thema.Instance, thema.TranslationLacunas, []thema.Candidate, error) {
+		v, err := dec.Decode(ctx, b)
+		if err != nil {
+			// TODO wrap error for use with errors.Is
+			return nil, nil, nil, err
+		}
+
+		// Try the given schema first, on the premise that in general it's the
+		// most likely one for an application to encounter
+		tinst, cands, err := thema.SearchAndValidate(sch.Lineage(), v, only)
+		if err == nil {
+			return tinst, nil, cands, nil
+		}
+		err = cands[0].Err
+
+		inst, ocands, oerr := thema.SearchAndValidate(sch.Lineage(), v, others)
+		cands = append(cands, ocands...)
+		if oerr != nil {
+			return nil, nil, cands, fmt.Errorf("data invalid against all versions (%s), error against %s: %w", vstring, sch.Version(), err)
+		}
+
+		tinst, lac, err := inst.Translate(sch.Version())
+		return tinst, lac, cands, err
+	}
+}